@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// anomalyAlert is the JSON body POSTed to anomalies.alert_url.
+type anomalyAlert struct {
+	Simulation string    `json:"simulation,omitempty"` // empty for the main simulation
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"`
+	Value      float64   `json:"value"`
+	Mean       float64   `json:"mean"`
+	StdDev     float64   `json:"stddev"`
+}
+
+// alertAnomalies returns an anomaly subscriber that logs each anomaly as
+// a warning and, if url is set, POSTs it there. sim names the named
+// simulation the anomalies come from, and is empty for the main one.
+func alertAnomalies(url, sim string) func(stats.Anomaly) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	prefix := ""
+	if sim != "" {
+		prefix = "simulation " + sim + ": "
+	}
+	return func(a stats.Anomaly) {
+		log.Printf("Warning: %s%s at %s: %.3f against a trailing mean of %.3f (stddev %.3f)",
+			prefix, a.Kind, a.Time.Format(time.TimeOnly), a.Value, a.Mean, a.StdDev)
+		if url == "" {
+			return
+		}
+		body, err := json.Marshal(anomalyAlert{Simulation: sim, Time: a.Time, Kind: a.Kind, Value: a.Value, Mean: a.Mean, StdDev: a.StdDev})
+		if err != nil {
+			return
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Anomaly alert failed: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Anomaly alert failed: %s", resp.Status)
+		}
+	}
+}
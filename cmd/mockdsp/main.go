@@ -0,0 +1,79 @@
+// Command mockdsp serves one or more configurable OpenRTB bidders for
+// exercising the simulator without external DSPs.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/mockdsp"
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+func main() {
+	configPath := flag.String("config", "mockdsp.yaml", "path to mock DSP configuration file")
+	seed := flag.Uint64("seed", 0, "random seed for reproducible responses (0 = random)")
+	flag.Parse()
+
+	cfg, err := mockdsp.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *seed != 0 {
+		randutil.Seed(*seed)
+		log.Printf("Random seed: %d", *seed)
+	}
+
+	bidders := make([]*mockdsp.Bidder, len(cfg.Bidders))
+	servers := make([]*http.Server, len(cfg.Bidders))
+	var wg sync.WaitGroup
+
+	for i, bc := range cfg.Bidders {
+		bidders[i] = mockdsp.NewBidder(bc, nil)
+		servers[i] = &http.Server{
+			Addr:              fmt.Sprintf(":%d", bc.Port),
+			Handler:           bidders[i],
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+
+		wg.Add(1)
+		go func(name string, srv *http.Server) {
+			defer wg.Done()
+			log.Printf("Mock DSP %s listening on %s", name, srv.Addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Mock DSP %s error: %v", name, err)
+			}
+		}(bc.Name, servers[i])
+	}
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-shutdown
+	log.Printf("Received signal %v, shutting down...", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Shutdown error on %s: %v", srv.Addr, err)
+		}
+	}
+	wg.Wait()
+
+	for _, b := range bidders {
+		s := b.Stats()
+		log.Printf("  %s: requests=%d bids=%d no-bids=%d errors=%d notices=%d",
+			b.Name(), s.Requests, s.Bids, s.NoBids, s.Errors, s.Notices)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/mockdsp"
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// demoBidders are the in-process DSPs the demo runs against, chosen to
+// give every stat something to show: a steady bidder, a cheap fast one,
+// a picky high bidder, one that errors, and one that often misses tmax.
+var demoBidders = []mockdsp.BidderConfig{
+	{
+		Name:      "steady",
+		NoBidRate: 0.15,
+		Price:     randutil.Dist{Type: randutil.DistNormal, Mean: 2.5, StdDev: 0.6, Min: 0.2, Max: 8},
+		LatencyMS: randutil.Dist{Type: randutil.DistLogNormal, Mu: 3.0, Sigma: 0.3, Max: 90},
+		Notices:   true,
+	},
+	{
+		Name:      "bargain",
+		NoBidRate: 0.3,
+		Price:     randutil.Dist{Type: randutil.DistUniform, Min: 0.3, Max: 2},
+		LatencyMS: randutil.Dist{Type: randutil.DistUniform, Min: 2, Max: 12},
+		Notices:   true,
+	},
+	{
+		Name:      "premium",
+		NoBidRate: 0.6,
+		Price:     randutil.Dist{Type: randutil.DistNormal, Mean: 4.5, StdDev: 1.2, Min: 1, Max: 15},
+		LatencyMS: randutil.Dist{Type: randutil.DistLogNormal, Mu: 3.6, Sigma: 0.35, Max: 150},
+		Notices:   true,
+	},
+	{
+		Name:      "flaky",
+		NoBidRate: 0.2,
+		ErrorRate: 0.08,
+		Price:     randutil.Dist{Type: randutil.DistUniform, Min: 1, Max: 4},
+		LatencyMS: randutil.Dist{Type: randutil.DistUniform, Min: 5, Max: 60},
+	},
+	{
+		Name:      "laggard",
+		NoBidRate: 0.1,
+		Price:     randutil.Dist{Type: randutil.DistNormal, Mean: 3, StdDev: 0.8, Min: 0.5, Max: 9},
+		LatencyMS: randutil.Dist{Type: randutil.DistNormal, Mean: 85, StdDev: 20, Min: 30, Max: 200},
+	},
+}
+
+// runDemo serves the demo bidders on loopback ports and runs a simulation
+// against them with no configuration file.
+func runDemo(args []string) {
+	fs := flag.NewFlagSet("demo", flag.ExitOnError)
+	port := fs.Int("port", 8080, "API server port")
+	rps := fs.Int("rps", 200, "requests per second")
+	scenario := fs.String("scenario", "mobile_app", "request scenario: mobile_app or video")
+	duration := fs.Duration("duration", 0, "stop after this long (0 = until interrupted)")
+	seed := fs.Uint64("seed", 0, "random seed for reproducible runs (0 = random)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s demo [flags]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Runs a simulation against built-in mock DSPs; no configuration needed.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: *port},
+		Simulation: config.SimulationConfig{
+			RequestsPerSecond: *rps,
+			Scenario:          *scenario,
+			Duration:          *duration,
+			Seed:              *seed,
+		},
+		Auction:       config.AuctionConfig{Type: "second_price", TimeoutMS: 100},
+		Notifications: config.NotificationConfig{Enabled: true},
+	}
+
+	servers := make([]*http.Server, 0, len(demoBidders))
+	for _, bc := range demoBidders {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting mock DSP %s: %v\n", bc.Name, err)
+			os.Exit(1)
+		}
+		srv := &http.Server{
+			Handler:           mockdsp.NewBidder(bc, nil),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Mock DSP %s error: %v", bc.Name, err)
+			}
+		}()
+		servers = append(servers, srv)
+		cfg.DSPs = append(cfg.DSPs, config.DSPConfig{
+			Name:     bc.Name,
+			Endpoint: "http://" + ln.Addr().String() + "/bid",
+			Enabled:  true,
+		})
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, srv := range servers {
+			_ = srv.Shutdown(ctx)
+		}
+	}()
+
+	if err := cfg.Prepare(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error in demo configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	log.Printf("Demo mode: %d mock DSPs on loopback", len(demoBidders))
+	log.Printf("  Live stats:   curl -N http://localhost:%d/stats/stream", cfg.Server.Port)
+	log.Printf("  Full stats:   http://localhost:%d/stats", cfg.Server.Port)
+	run(cfg, runOptions{autoStart: true})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/generator/scenarios"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/internal/schema"
+)
+
+// genCommand prints generated bid requests as NDJSON, from the scenario
+// settings of a configuration file or from a scenario's defaults.
+func genCommand(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	configPath := fs.String("config", "", "configuration file to take scenario settings from (default: scenario defaults)")
+	scenarioName := fs.String("scenario", "", "scenario to generate, overriding the configuration (mobile_app, video, replay)")
+	n := fs.Int("n", 1, "number of requests to print")
+	seed := fs.Uint64("seed", 0, "random seed for reproducible output (0 keeps the configured seed, or a random one)")
+	indent := fs.Bool("indent", false, "indent each request instead of printing one per line")
+	validate := fs.Bool("validate", false, "exit with an error on the first request violating the OpenRTB schema")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s gen [flags]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	cfg := &config.Config{}
+	if *configPath != "" {
+		loaded, err := config.Load(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		cfg = loaded
+	}
+	sim := cfg.Simulation
+	if *scenarioName != "" {
+		if err := scenarios.ValidateName(*scenarioName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -scenario: %v\n", err)
+			os.Exit(1)
+		}
+		sim.Scenario, sim.Scenarios = *scenarioName, nil
+	}
+	if sim.Scenario == "" && len(sim.Scenarios) == 0 {
+		sim.Scenario = "mobile_app"
+	}
+	if *seed != 0 {
+		sim.Seed = *seed
+	}
+	if sim.Seed != 0 {
+		randutil.Seed(sim.Seed)
+	}
+
+	scenario, err := createScenario(sim, cfg.Currency.BaseCurrency())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in simulation: %v\n", err)
+		os.Exit(1)
+	}
+
+	var genOpts []generator.Option
+	if cfg.Auction.TimeoutMS > 0 {
+		genOpts = append(genOpts, generator.WithTimeout(cfg.Auction.TimeoutMS))
+	}
+	if cfg.Auction.Type != "" {
+		genOpts = append(genOpts, generator.WithAuctionType(auction.RequestType(cfg.Auction.Type)))
+	}
+	if d := cfg.Simulation.RenderDelay; d > 0 {
+		genOpts = append(genOpts, generator.WithImpExpiry(d))
+	}
+	if *validate {
+		genOpts = append(genOpts, generator.WithValidation(schema.BidRequest().Validate))
+	}
+	gen := generator.New(scenario, genOpts...)
+
+	enc := json.NewEncoder(os.Stdout)
+	if *indent {
+		enc.SetIndent("", "  ")
+	}
+	for range *n {
+		if err := enc.Encode(gen.Generate()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing request: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// HeaderAPIKey carries the API key for control endpoints.
+const HeaderAPIKey = "X-API-Key"
+
+// credentials are accepted on control endpoints. Empty fields disable
+// that method; with none set, control endpoints are open.
+type credentials struct {
+	apiKey   string
+	username string
+	password string
+}
+
+func (c credentials) enabled() bool {
+	return c.apiKey != "" || c.username != ""
+}
+
+// WithAuth requires credentials on control endpoints (start, stop, and
+// other mutations): apiKey in the X-API-Key header, or HTTP basic auth
+// with username and password. Either is accepted when both are set.
+// Empty values disable each method. Read-only endpoints stay open.
+func WithAuth(apiKey, username, password string) Option {
+	return func(s *Server) {
+		s.auth = credentials{apiKey: apiKey, username: username, password: password}
+	}
+}
+
+// requireAuth rejects requests to h without valid credentials, if any are
+// configured.
+func (s *Server) requireAuth(h http.HandlerFunc) http.HandlerFunc {
+	if !s.auth.enabled() {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.authorized(r) {
+			h(w, r)
+			return
+		}
+		if s.auth.username != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="rtb-simulator"`)
+		}
+		s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+}
+
+// authorized reports whether r carries a valid API key or basic auth
+// credentials.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.auth.apiKey != "" {
+		if key := r.Header.Get(HeaderAPIKey); key != "" && equalSecret(key, s.auth.apiKey) {
+			return true
+		}
+	}
+	if s.auth.username != "" {
+		if user, pass, ok := r.BasicAuth(); ok {
+			// Compare both so timing does not reveal which was wrong
+			userOK := equalSecret(user, s.auth.username)
+			passOK := equalSecret(pass, s.auth.password)
+			return userOK && passOK
+		}
+	}
+	return false
+}
+
+func equalSecret(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
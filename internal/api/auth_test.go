@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+func TestServer_Auth(t *testing.T) {
+	tests := []struct {
+		name   string
+		opt    Option
+		method string
+		path   string
+		setup  func(r *http.Request)
+		want   int
+	}{
+		{"no auth configured", WithAuth("", "", ""), http.MethodPost, "/start", nil, http.StatusOK},
+		{"missing key", WithAuth("secret", "", ""), http.MethodPost, "/start", nil, http.StatusUnauthorized},
+		{"wrong key", WithAuth("secret", "", ""), http.MethodPost, "/stop",
+			func(r *http.Request) { r.Header.Set(HeaderAPIKey, "guess") }, http.StatusUnauthorized},
+		{"valid key", WithAuth("secret", "", ""), http.MethodPost, "/start",
+			func(r *http.Request) { r.Header.Set(HeaderAPIKey, "secret") }, http.StatusOK},
+		{"key protects rps", WithAuth("secret", "", ""), http.MethodPut, "/rps", nil, http.StatusUnauthorized},
+		{"valid basic auth", WithAuth("", "admin", "pw"), http.MethodPost, "/start",
+			func(r *http.Request) { r.SetBasicAuth("admin", "pw") }, http.StatusOK},
+		{"wrong password", WithAuth("", "admin", "pw"), http.MethodPost, "/start",
+			func(r *http.Request) { r.SetBasicAuth("admin", "nope") }, http.StatusUnauthorized},
+		{"basic auth when key also set", WithAuth("secret", "admin", "pw"), http.MethodPost, "/start",
+			func(r *http.Request) { r.SetBasicAuth("admin", "pw") }, http.StatusOK},
+		{"read-only stays open", WithAuth("secret", "", ""), http.MethodGet, "/status", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := &mockEngine{}
+			srv := New(eng, stats.New(), &config.Config{}, tt.opt)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"rps":5}`))
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			rec := httptest.NewRecorder()
+			srv.AdminHandler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d (body %s)", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+			}
+			if rec.Code == http.StatusUnauthorized && eng.startCalled {
+				t.Error("engine started without credentials")
+			}
+		})
+	}
+}
+
+func TestServer_Auth_BasicChallenge(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithAuth("", "admin", "pw"))
+
+	rec := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/start", nil))
+	if got := rec.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, "Basic ") {
+		t.Errorf("WWW-Authenticate = %q, want a basic auth challenge", got)
+	}
+}
+
+func TestServer_ConfigEndpoint_RedactsCredentials(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{Port: 8080, Auth: config.AuthConfig{APIKey: "secret", Username: "admin", Password: "pw"}}}
+	srv := New(&mockEngine{}, stats.New(), cfg)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if body := rec.Body.String(); strings.Contains(body, "secret") || strings.Contains(body, `"pw"`) {
+		t.Errorf("GET /config leaked credentials: %s", body)
+	}
+	if cfg.Server.Auth.APIKey != "secret" {
+		t.Error("redaction modified the server's config")
+	}
+}
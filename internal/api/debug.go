@@ -0,0 +1,178 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RuntimeStats is a snapshot of the simulator process's Go runtime, for
+// profiling it as a load generator at high RPS.
+type RuntimeStats struct {
+	Goroutines int `json:"goroutines"`
+	GOMAXPROCS int `json:"gomaxprocs"`
+	NumCPU     int `json:"num_cpu"`
+
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64 `json:"heap_inuse_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	Mallocs         uint64 `json:"mallocs"`
+	// Rates over the interval since the previous /debug/runtime request,
+	// or since the process started for the first.
+	IntervalSeconds  float64 `json:"interval_seconds"`
+	AllocBytesPerSec float64 `json:"alloc_bytes_per_sec"`
+	AllocsPerSec     float64 `json:"allocs_per_sec"`
+
+	GC GCStats `json:"gc"`
+}
+
+// GCStats summarizes garbage collection since the process started.
+type GCStats struct {
+	Count         uint32    `json:"count"`
+	LastGC        time.Time `json:"last_gc,omitzero"`
+	LastPauseMS   float64   `json:"last_pause_ms"`
+	PauseTotalMS  float64   `json:"pause_total_ms"`
+	CPUFraction   float64   `json:"cpu_fraction"`
+	NextGCBytes   uint64    `json:"next_gc_bytes"`
+	CountInterval uint32    `json:"count_interval"` // collections since the previous request
+}
+
+// runtimeSampler turns cumulative allocation counters into rates between
+// successive requests.
+type runtimeSampler struct {
+	mu      sync.Mutex
+	at      time.Time
+	alloc   uint64
+	mallocs uint64
+	numGC   uint32
+}
+
+// processStart approximates the process start for the first sample.
+var processStart = time.Now()
+
+// sample reads the runtime's statistics and the rates since the previous
+// sample. It stops the world briefly.
+func (rs *runtimeSampler) sample() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	now := time.Now()
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.at.IsZero() {
+		rs.at = processStart
+	}
+	st := RuntimeStats{
+		Goroutines:      runtime.NumGoroutine(),
+		GOMAXPROCS:      runtime.GOMAXPROCS(0),
+		NumCPU:          runtime.NumCPU(),
+		HeapAllocBytes:  m.HeapAlloc,
+		HeapInuseBytes:  m.HeapInuse,
+		HeapObjects:     m.HeapObjects,
+		SysBytes:        m.Sys,
+		TotalAllocBytes: m.TotalAlloc,
+		Mallocs:         m.Mallocs,
+		IntervalSeconds: now.Sub(rs.at).Seconds(),
+		GC: GCStats{
+			Count:         m.NumGC,
+			PauseTotalMS:  float64(m.PauseTotalNs) / float64(time.Millisecond),
+			CPUFraction:   m.GCCPUFraction,
+			NextGCBytes:   m.NextGC,
+			CountInterval: m.NumGC - rs.numGC,
+		},
+	}
+	if m.NumGC > 0 {
+		st.GC.LastGC = time.Unix(0, int64(m.LastGC))
+		st.GC.LastPauseMS = float64(m.PauseNs[(m.NumGC+255)%256]) / float64(time.Millisecond)
+	}
+	if st.IntervalSeconds > 0 {
+		st.AllocBytesPerSec = float64(m.TotalAlloc-rs.alloc) / st.IntervalSeconds
+		st.AllocsPerSec = float64(m.Mallocs-rs.mallocs) / st.IntervalSeconds
+	}
+
+	rs.at, rs.alloc, rs.mallocs, rs.numGC = now, m.TotalAlloc, m.Mallocs, m.NumGC
+	return st
+}
+
+// WithDebug serves Go profiles under /debug/pprof/ and runtime statistics
+// at /debug/runtime on the control listener, behind authentication when
+// configured. Profiling costs CPU, so leave it off unless needed.
+func WithDebug() Option {
+	return func(s *Server) {
+		s.debug = &runtimeSampler{}
+	}
+}
+
+// setupDebugRoutes registers the profiling and runtime endpoints.
+func (s *Server) setupDebugRoutes() {
+	s.adminMux.HandleFunc("/debug/pprof/", s.requireAuth(pprof.Index))
+	s.adminMux.HandleFunc("/debug/pprof/cmdline", s.requireAuth(pprof.Cmdline))
+	s.adminMux.HandleFunc("/debug/pprof/profile", s.requireAuth(timedProfile("profile", 30*time.Second,
+		runtimepprof.StartCPUProfile, runtimepprof.StopCPUProfile)))
+	s.adminMux.HandleFunc("/debug/pprof/symbol", s.requireAuth(pprof.Symbol))
+	s.adminMux.HandleFunc("/debug/pprof/trace", s.requireAuth(timedProfile("trace", time.Second, trace.Start, trace.Stop)))
+	s.adminMux.HandleFunc("/debug/runtime", s.requireAuth(s.handleRuntime))
+}
+
+// profileSlack is how long past the profiling period a profile may take
+// to write.
+const profileSlack = 10 * time.Second
+
+// timedProfile serves a CPU profile or execution trace over the ?seconds=
+// period (def if unset) as net/http/pprof does, except that it extends
+// the connection's write deadline to cover the period rather than
+// refusing periods beyond the server's write timeout, which the default
+// 30-second CPU profile exceeds.
+func timedProfile(name string, def time.Duration, start func(io.Writer) error, stop func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		period := def
+		if v := r.FormValue("seconds"); v != "" {
+			sec, err := strconv.ParseFloat(v, 64)
+			if err != nil || sec <= 0 {
+				http.Error(w, "seconds must be a positive number", http.StatusBadRequest)
+				return
+			}
+			period = time.Duration(sec * float64(time.Second))
+		}
+
+		deadline := time.Now().Add(period + profileSlack)
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("pprof: extending write deadline: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		if err := start(w); err != nil {
+			// Another profile or trace is running
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "could not start "+name+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		select {
+		case <-time.After(period):
+		case <-r.Context().Done():
+		}
+		stop()
+	}
+}
+
+// handleRuntime returns goroutine, memory, and GC statistics, with
+// allocation rates since the previous request.
+func (s *Server) handleRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.debug.sample())
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+func TestServer_Debug(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithDebug(), WithAuth("secret", "", ""))
+
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+		rec := httptest.NewRecorder()
+		srv.AdminHandler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/debug/runtime", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("/debug/runtime without credentials: status = %d, want 401", rec.Code)
+	}
+
+	rec := get("/debug/runtime", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/debug/runtime status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	var st RuntimeStats
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode runtime stats: %v", err)
+	}
+	if st.Goroutines < 1 || st.GOMAXPROCS < 1 || st.HeapAllocBytes == 0 || st.Mallocs == 0 {
+		t.Errorf("runtime stats = %+v, want goroutines, GOMAXPROCS, heap, and mallocs", st)
+	}
+	if st.IntervalSeconds <= 0 || st.AllocsPerSec <= 0 {
+		t.Errorf("IntervalSeconds, AllocsPerSec = %v, %v; want rates since the process started", st.IntervalSeconds, st.AllocsPerSec)
+	}
+
+	// The next request reports rates since this one
+	rec = get("/debug/runtime", "secret")
+	var next RuntimeStats
+	if err := json.NewDecoder(rec.Body).Decode(&next); err != nil {
+		t.Fatalf("decode runtime stats: %v", err)
+	}
+	if next.IntervalSeconds >= st.IntervalSeconds+1 || next.TotalAllocBytes <= st.TotalAllocBytes {
+		t.Errorf("second sample = %+v, want a short interval after %+v", next, st)
+	}
+
+	rec = get("/debug/pprof/", "secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine") {
+		t.Errorf("/debug/pprof/ status = %d, want 200 listing profiles", rec.Code)
+	}
+	if rec = get("/debug/pprof/goroutine?debug=1", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("/debug/pprof/goroutine status = %d, want 200", rec.Code)
+	}
+}
+
+func TestServer_Debug_TraceOutlastsWriteTimeout(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithDebug())
+	ts := httptest.NewUnstartedServer(srv.AdminHandler())
+	ts.Config.WriteTimeout = 100 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/debug/pprof/trace?seconds=0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading trace: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(body), "go 1.") {
+		t.Errorf("status = %d, body %.20q; want a trace past the write timeout", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(ts.URL + "/debug/pprof/trace?seconds=soon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid seconds: status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestServer_DebugDisabled(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{})
+
+	for _, path := range []string{"/debug/runtime", "/debug/pprof/"} {
+		rec := httptest.NewRecorder()
+		srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s status = %d without WithDebug, want 404", path, rec.Code)
+		}
+	}
+}
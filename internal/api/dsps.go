@@ -0,0 +1,209 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+)
+
+// DSPManager changes the set of DSPs receiving bid requests at runtime.
+type DSPManager interface {
+	DSPs() []config.DSPConfig
+	AddDSP(cfg config.DSPConfig) error
+	RemoveDSP(name string) error
+	SetEnabled(name string, enabled bool) error
+}
+
+// ReachabilityReporter reports the preflight checks of DSP endpoints.
+type ReachabilityReporter interface {
+	Reachability() []dispatcher.Reachability
+}
+
+// HealthReporter reports the health checks of DSP endpoints.
+type HealthReporter interface {
+	Health() []dispatcher.Health
+}
+
+// DSPHealthResponse is the latest health check of one DSP endpoint.
+// Status is "healthy" or "unhealthy".
+type DSPHealthResponse struct {
+	DSP       string    `json:"dsp"`
+	URL       string    `json:"url"`
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	LatencyMS float64   `json:"latency_ms"`
+	Failures  int       `json:"consecutive_failures,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ReachabilityResponse is the preflight check of one DSP endpoint. Status
+// is "pending", "reachable", or "unreachable".
+type ReachabilityResponse struct {
+	DSP       string     `json:"dsp"`
+	Endpoint  string     `json:"endpoint"`
+	Status    string     `json:"status"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	LatencyMS float64    `json:"latency_ms,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// DSPResponse describes a DSP known to the dispatcher.
+type DSPResponse struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// handleDSPs lists DSPs (GET) or adds one (POST).
+func (s *Server) handleDSPs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		dsps := s.dsps.DSPs()
+		resp := make([]DSPResponse, len(dsps))
+		for i, d := range dsps {
+			resp[i] = dspResponse(d)
+		}
+		s.writeJSON(w, http.StatusOK, resp)
+
+	case http.MethodPost:
+		cfg, err := decodeDSP(r.Body)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body: " + err.Error()})
+			return
+		}
+		if err := s.dsps.AddDSP(cfg); err != nil {
+			s.writeDSPError(w, err)
+			return
+		}
+		log.Printf("DSP %s added (%s)", cfg.Name, cfg.Endpoint)
+		s.writeJSON(w, http.StatusCreated, dspResponse(cfg))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeDSP reads a DSP to add, given as JSON with the fields of a dsps
+// entry in the configuration file. Enabled defaults to true.
+func decodeDSP(body io.Reader) (config.DSPConfig, error) {
+	cfg := config.DSPConfig{Enabled: true}
+	// JSON is YAML, so the configuration's field names and types apply
+	dec := yaml.NewDecoder(body)
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return config.DSPConfig{}, err
+	}
+	return cfg, nil
+}
+
+// handleDSP removes a DSP.
+func (s *Server) handleDSP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := s.dsps.RemoveDSP(name); err != nil {
+		s.writeDSPError(w, err)
+		return
+	}
+	log.Printf("DSP %s removed", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDSPEnabled returns a handler that enables or disables a DSP.
+func (s *Server) handleDSPEnabled(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.PathValue("name")
+		if err := s.dsps.SetEnabled(name, enabled); err != nil {
+			s.writeDSPError(w, err)
+			return
+		}
+		log.Printf("DSP %s enabled=%t", name, enabled)
+
+		for _, d := range s.dsps.DSPs() {
+			if d.Name == name {
+				s.writeJSON(w, http.StatusOK, dspResponse(d))
+				return
+			}
+		}
+		// Removed concurrently
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "unknown dsp: " + name})
+	}
+}
+
+// writeDSPError maps DSP management errors to HTTP statuses.
+func (s *Server) writeDSPError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, dispatcher.ErrDSPNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, dispatcher.ErrDSPExists):
+		status = http.StatusConflict
+	}
+	s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func dspResponse(d config.DSPConfig) DSPResponse {
+	return DSPResponse{Name: d.Name, Endpoint: d.Endpoint, Enabled: d.Enabled}
+}
+
+// handleReachability lists the preflight checks of DSP endpoints.
+func (s *Server) handleReachability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	checks := s.reachability.Reachability()
+	resp := make([]ReachabilityResponse, len(checks))
+	for i, c := range checks {
+		rr := ReachabilityResponse{DSP: c.DSP, Endpoint: c.Endpoint, Status: "pending"}
+		if !c.Pending() {
+			rr.CheckedAt = &c.Checked
+			rr.Status = "reachable"
+			rr.LatencyMS = float64(c.Latency) / float64(time.Millisecond)
+			if c.Err != nil {
+				rr.Status = "unreachable"
+				rr.Error = c.Err.Error()
+			}
+		}
+		resp[i] = rr
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+func healthResponses(checks []dispatcher.Health) []DSPHealthResponse {
+	resp := make([]DSPHealthResponse, len(checks))
+	for i, h := range checks {
+		hr := DSPHealthResponse{
+			DSP:       h.DSP,
+			URL:       h.URL,
+			Status:    "healthy",
+			CheckedAt: h.Checked,
+			LatencyMS: float64(h.Latency) / float64(time.Millisecond),
+			Failures:  h.Failures,
+		}
+		if !h.Healthy {
+			hr.Status = "unhealthy"
+		}
+		if h.Err != nil {
+			hr.Error = h.Err.Error()
+		}
+		resp[i] = hr
+	}
+	return resp
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+func TestServer_ManageDSPs(t *testing.T) {
+	disp := dispatcher.New([]config.DSPConfig{
+		{Name: "dsp1", Endpoint: "http://localhost:9001/bid", Enabled: true},
+	})
+	defer disp.Close()
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithDSPManager(disp))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"add", http.MethodPost, "/dsps", `{"name":"dsp2","endpoint":"http://localhost:9002/bid"}`, http.StatusCreated},
+		{"add duplicate", http.MethodPost, "/dsps", `{"name":"dsp2","endpoint":"http://localhost:9002/bid"}`, http.StatusConflict},
+		{"add without endpoint", http.MethodPost, "/dsps", `{"name":"dsp3"}`, http.StatusBadRequest},
+		{"add with chaos faults", http.MethodPost, "/dsps", `{"name":"dsp3","endpoint":"http://localhost:9003/bid","faults":{"delay_rate":0.1,"delay":"50ms","drop_rate":0.05}}`, http.StatusCreated},
+		{"add with delay_rate but no delay", http.MethodPost, "/dsps", `{"name":"dsp4","endpoint":"http://localhost:9004/bid","faults":{"delay_rate":0.1}}`, http.StatusBadRequest},
+		{"remove chaotic", http.MethodDelete, "/dsps/dsp3", "", http.StatusNoContent},
+		{"add invalid body", http.MethodPost, "/dsps", `{`, http.StatusBadRequest},
+		{"add unknown field", http.MethodPost, "/dsps", `{"name":"dsp5","endpoint":"http://localhost:9005/bid","qps":10}`, http.StatusBadRequest},
+		{"add with traffic settings", http.MethodPost, "/dsps", `{"name":"dsp5","endpoint":"http://localhost:9005/bid","qps_limit":100,"traffic_pct":50,"formats":["banner"]}`, http.StatusCreated},
+		{"add with invalid traffic_pct", http.MethodPost, "/dsps", `{"name":"dsp6","endpoint":"http://localhost:9006/bid","traffic_pct":150}`, http.StatusBadRequest},
+		{"remove dsp5", http.MethodDelete, "/dsps/dsp5", "", http.StatusNoContent},
+		{"disable", http.MethodPost, "/dsps/dsp1/disable", "", http.StatusOK},
+		{"enable unknown", http.MethodPost, "/dsps/missing/enable", "", http.StatusNotFound},
+		{"remove", http.MethodDelete, "/dsps/dsp2", "", http.StatusNoContent},
+		{"remove unknown", http.MethodDelete, "/dsps/dsp2", "", http.StatusNotFound},
+		{"enable wrong method", http.MethodGet, "/dsps/dsp1/enable", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d (body %s)", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dsps", nil))
+
+	var dsps []DSPResponse
+	if err := json.NewDecoder(rec.Body).Decode(&dsps); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(dsps) != 1 || dsps[0].Name != "dsp1" || dsps[0].Enabled {
+		t.Errorf("GET /dsps = %+v, want only dsp1, disabled", dsps)
+	}
+}
+
+func TestServer_ManageDSPs_AdminOnly(t *testing.T) {
+	disp := dispatcher.New(nil)
+	defer disp.Close()
+	srv := New(&mockEngine{}, stats.New(), &config.Config{},
+		WithAdminAddr("127.0.0.1:0"), WithDSPManager(disp))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dsps",
+		strings.NewReader(`{"name":"dsp1","endpoint":"http://localhost/bid"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("public POST /dsps status = %d, want 404", rec.Code)
+	}
+}
+
+func TestServer_RecentErrors_RuntimeDSP(t *testing.T) {
+	disp := dispatcher.New(nil)
+	defer disp.Close()
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithDSPManager(disp))
+
+	if err := disp.AddDSP(config.DSPConfig{Name: "added", Endpoint: "http://localhost/bid"}); err != nil {
+		t.Fatalf("AddDSP() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dsps/added/recent-errors", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 for a DSP added at runtime", rec.Code)
+	}
+}
+
+type stubReachability []dispatcher.Reachability
+
+func (s stubReachability) Reachability() []dispatcher.Reachability { return s }
+
+func TestServer_Reachability(t *testing.T) {
+	checked := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithReachability(stubReachability{
+		{DSP: "up", Endpoint: "http://up/bid", Checked: checked, Latency: 2 * time.Millisecond},
+		{DSP: "down", Endpoint: "http://down/bid", Checked: checked, Err: errors.New("connection refused")},
+		{DSP: "new", Endpoint: "http://new/bid"},
+	}))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dsps/reachability", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var got []ReachabilityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []string{"reachable", "unreachable", "pending"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i, status := range want {
+		if got[i].Status != status {
+			t.Errorf("%s: status = %q, want %q", got[i].DSP, got[i].Status, status)
+		}
+	}
+	if got[0].LatencyMS != 2 {
+		t.Errorf("up: latency_ms = %v, want 2", got[0].LatencyMS)
+	}
+	if got[1].Error != "connection refused" {
+		t.Errorf("down: error = %q, want connection refused", got[1].Error)
+	}
+	if got[2].CheckedAt != nil {
+		t.Errorf("new: checked_at = %v, want omitted while pending", got[2].CheckedAt)
+	}
+}
+
+type stubHealth []dispatcher.Health
+
+func (s stubHealth) Health() []dispatcher.Health { return s }
+
+func TestServer_StatusHealth(t *testing.T) {
+	checked := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv := New(&mockEngine{running: true}, stats.New(), &config.Config{}, WithHealth(stubHealth{
+		{DSP: "up", URL: "http://up/health", Healthy: true, Checked: checked, Latency: 3 * time.Millisecond, Status: 200},
+		{DSP: "down", URL: "http://down/health", Checked: checked, Failures: 4, Err: errors.New("status 503")},
+	}))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var got StatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []DSPHealthResponse{
+		{DSP: "up", URL: "http://up/health", Status: "healthy", CheckedAt: checked, LatencyMS: 3},
+		{DSP: "down", URL: "http://down/health", Status: "unhealthy", CheckedAt: checked, Failures: 4, Error: "status 503"},
+	}
+	if !got.Running || !reflect.DeepEqual(got.DSPs, want) {
+		t.Errorf("GET /status = %+v, want running with DSPs %+v", got, want)
+	}
+}
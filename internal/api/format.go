@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// Response formats /stats can render.
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatText = "text"
+)
+
+// mediaFormats maps Accept media types to response formats.
+var mediaFormats = map[string]string{
+	"application/json":   formatJSON,
+	"application/yaml":   formatYAML,
+	"application/x-yaml": formatYAML,
+	"text/yaml":          formatYAML,
+	"text/x-yaml":        formatYAML,
+	"text/plain":         formatText,
+	"*/*":                formatJSON,
+}
+
+// negotiateFormat returns the response format r asks for: the format
+// query parameter (json, yaml, or text) if given, otherwise the first
+// media type in the Accept header that has a format, otherwise JSON. ok
+// is false for an unknown format parameter.
+func negotiateFormat(r *http.Request) (format string, ok bool) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		switch f {
+		case formatJSON, formatYAML, formatText:
+			return f, true
+		}
+		return "", false
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		if f, ok := mediaFormats[mediaType]; ok {
+			return f, true
+		}
+	}
+	return formatJSON, true
+}
+
+func (s *Server) writeYAML(w http.ResponseWriter, status int, v any) {
+	node, err := yamlNode(v)
+	if err != nil {
+		log.Printf("failed to encode YAML response: %v", err)
+		s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(status)
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(node); err != nil {
+		log.Printf("failed to encode YAML response: %v", err)
+	}
+	enc.Close()
+}
+
+// yamlNode converts v to YAML by way of its JSON encoding, so YAML
+// responses have the same field names, in the same order, as JSON ones.
+func yamlNode(v any) (*yaml.Node, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	return &node, nil
+}
+
+// blockStyle clears the flow and quoting styles n was parsed from JSON
+// with, so it encodes as block YAML.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// writeStatsTable renders a snapshot as aligned plain text: the totals,
+// then one row per DSP in name order.
+func writeStatsTable(w io.Writer, snap stats.Snapshot) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Requests\t%d\n", snap.TotalRequests)
+	fmt.Fprintf(tw, "Bids\t%d\n", snap.TotalBids)
+	fmt.Fprintf(tw, "Wins\t%d\n", snap.TotalWins)
+	fmt.Fprintf(tw, "No-bids\t%d\n", snap.TotalNoBids)
+	fmt.Fprintf(tw, "Errors\t%d\n", snap.TotalErrors)
+	fmt.Fprintf(tw, "Revenue\t$%.4f\n", snap.TotalRevenue)
+	fmt.Fprintf(tw, "Win rate\t%.1f%%\n", snap.Rates.WinRate*100)
+	fmt.Fprintf(tw, "Latency p50/p95/p99\t%v / %v / %v\n", snap.Latency.P50, snap.Latency.P95, snap.Latency.P99)
+	tw.Flush()
+
+	if len(snap.DSPStats) == 0 {
+		return
+	}
+	names := make([]string, 0, len(snap.DSPStats))
+	for name := range snap.DSPStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DSP\tREQUESTS\tBIDS\tWINS\tWIN RATE\tNO-BIDS\tERRORS\tTIMEOUTS\tSPEND\tP50\tP95\tP99")
+	for _, name := range names {
+		d := snap.DSPStats[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%d\t%d\t%d\t$%.4f\t%v\t%v\t%v\n",
+			name, d.Requests, d.Bids, d.Wins, d.Rates.WinRate*100, d.NoBids, d.Errors, d.Timeouts,
+			d.Spend, d.Latency.P50, d.Latency.P95, d.Latency.P99)
+	}
+	tw.Flush()
+}
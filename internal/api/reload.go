@@ -0,0 +1,54 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/cass/rtb-simulator/internal/config"
+)
+
+// ConfigReloader re-reads the configuration file and applies what it can
+// to the running simulator, returning the configuration now in effect.
+type ConfigReloader interface {
+	Reload() (*config.Config, error)
+}
+
+// WithConfigReloader serves POST /config/reload on the control listener.
+func WithConfigReloader(r ConfigReloader) Option {
+	return func(s *Server) {
+		s.reloader = r
+	}
+}
+
+// SetConfig replaces the configuration served at /config, such as after
+// a reload.
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config = cfg
+}
+
+// currentConfig returns the configuration in effect.
+func (s *Server) currentConfig() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// handleConfigReload reloads the configuration file, leaving the
+// simulation running.
+func (s *Server) handleConfigReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg, err := s.reloader.Reload()
+	if err != nil {
+		log.Printf("Config reload failed: %v", err)
+		s.writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.SetConfig(cfg)
+	s.writeJSON(w, http.StatusOK, cfg.Redacted())
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// mockReloader returns cfg, or err if set.
+type mockReloader struct {
+	cfg *config.Config
+	err error
+}
+
+func (m *mockReloader) Reload() (*config.Config, error) {
+	return m.cfg, m.err
+}
+
+func TestServer_ConfigReload(t *testing.T) {
+	reloaded := &config.Config{Simulation: config.SimulationConfig{RequestsPerSecond: 250}}
+	reloader := &mockReloader{cfg: reloaded}
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithConfigReloader(reloader))
+
+	rec := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config/reload", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /config/reload status = %d, want 405", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/config/reload", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /config/reload status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+
+	// GET /config serves the reloaded configuration
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	var got config.Config
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode config: %v", err)
+	}
+	if got.Simulation.RequestsPerSecond != 250 {
+		t.Errorf("config RPS = %d after reload, want 250", got.Simulation.RequestsPerSecond)
+	}
+
+	reloader.err = errors.New("parsing config: bad yaml")
+	rec = httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/config/reload", nil))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("failed reload status = %d, want 422", rec.Code)
+	}
+}
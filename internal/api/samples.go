@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cass/rtb-simulator/internal/export"
+)
+
+// defaultSampleSize is the number of auctions /auctions/sample returns
+// when n is not given.
+const defaultSampleSize = 10
+
+// AuctionSampler hands out random auctions of the current run for review,
+// each at most once per run.
+type AuctionSampler interface {
+	Sample(n int) []export.SampledAuction
+}
+
+// SampleResponse is a set of complete auctions for spot-checking.
+type SampleResponse struct {
+	Auctions []export.SampledAuction `json:"auctions"`
+}
+
+// WithAuctionSampler serves random samples of complete auctions at
+// /auctions/sample.
+func WithAuctionSampler(a AuctionSampler) Option {
+	return func(s *Server) {
+		s.sampler = a
+	}
+}
+
+// handleAuctionSample returns n random auctions (default 10) from the
+// current run, none returned by an earlier call during the run. Fewer are
+// returned once the sampled pool runs low.
+func (s *Server) handleAuctionSample(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	n := defaultSampleSize
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "n must be a positive integer"})
+			return
+		}
+	}
+	s.writeJSON(w, http.StatusOK, SampleResponse{Auctions: s.sampler.Sample(n)})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/export"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestServer_AuctionSample(t *testing.T) {
+	sampler := export.NewSampler()
+	for _, id := range []string{"req-1", "req-2", "req-3"} {
+		sampler.ObserveAuction(&openrtb.BidRequest{ID: id}, nil, auction.Outcome{RequestID: id})
+	}
+	handler := New(&mockEngine{}, stats.New(), &config.Config{}, WithAuctionSampler(sampler)).Handler()
+
+	get := func(path string) (int, SampleResponse) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp SampleResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return rec.Code, resp
+	}
+
+	code, first := get("/auctions/sample?n=2")
+	if code != http.StatusOK || len(first.Auctions) != 2 {
+		t.Fatalf("GET /auctions/sample?n=2 = %d with %d auctions, want 200 with 2", code, len(first.Auctions))
+	}
+	if first.Auctions[0].Request == nil || first.Auctions[0].Request.ID != first.Auctions[0].RequestID {
+		t.Errorf("auction = %+v, want its request included", first.Auctions[0])
+	}
+
+	// The remaining auction, then none
+	_, second := get("/auctions/sample")
+	if len(second.Auctions) != 1 {
+		t.Fatalf("second sample has %d auctions, want 1", len(second.Auctions))
+	}
+	for _, sa := range first.Auctions {
+		if sa.RequestID == second.Auctions[0].RequestID {
+			t.Errorf("auction %s returned twice", sa.RequestID)
+		}
+	}
+	if _, third := get("/auctions/sample"); len(third.Auctions) != 0 {
+		t.Errorf("third sample has %d auctions, want 0", len(third.Auctions))
+	}
+
+	if code, _ := get("/auctions/sample?n=0"); code != http.StatusBadRequest {
+		t.Errorf("GET /auctions/sample?n=0 status = %d, want %d", code, http.StatusBadRequest)
+	}
+}
@@ -5,11 +5,19 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/market"
+	"github.com/cass/rtb-simulator/internal/runs"
 	"github.com/cass/rtb-simulator/internal/stats"
 )
 
@@ -17,13 +25,49 @@ import (
 type EngineController interface {
 	Start() error
 	Stop()
+	Drain(ctx context.Context) error
 	IsRunning() bool
+	RPS() int
+	SetRPS(rps int) error
 }
 
 // StatusResponse represents the engine status response.
 type StatusResponse struct {
-	Running bool   `json:"running"`
-	Message string `json:"message,omitempty"`
+	Running bool                `json:"running"`
+	Message string              `json:"message,omitempty"`
+	DSPs    []DSPHealthResponse `json:"dsps,omitempty"` // health checks, see WithHealth
+
+	RequestBuffer *RequestBufferResponse `json:"request_buffer,omitempty"` // see WithRequestBuffer
+}
+
+// RequestBufferResponse reports how full the engine's request buffer is.
+type RequestBufferResponse struct {
+	Buffered  int    `json:"buffered"`
+	Capacity  int    `json:"capacity"`
+	Underruns uint64 `json:"underruns"`
+}
+
+// BufferReporter reports the engine's buffer of requests generated ahead
+// of dispatch.
+type BufferReporter interface {
+	RequestBuffer() engine.BufferStats
+}
+
+// RPSRequest changes the engine's requests-per-second rate.
+type RPSRequest struct {
+	RPS int `json:"rps"`
+}
+
+// RPSResponse reports the engine's requests-per-second rate.
+type RPSResponse struct {
+	RPS int `json:"rps"`
+}
+
+// EffectiveConfigResponse lists every setting in effect and where its
+// value came from: the file, the environment, the control API, or
+// defaults.
+type EffectiveConfigResponse struct {
+	Settings []config.Setting `json:"settings"`
 }
 
 // ErrorResponse represents an error response.
@@ -31,13 +75,58 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// RecentErrorsResponse lists the most recent errors recorded for a DSP.
+type RecentErrorsResponse struct {
+	DSP    string        `json:"dsp"`
+	Errors []RecentError `json:"errors"`
+}
+
+// RecentError is a single DSP error sample.
+type RecentError struct {
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Message    string    `json:"message"`
+}
+
 // Server handles HTTP API requests for the RTB simulator.
+// Read-only endpoints are served on the public listener. Control endpoints
+// are served on a separate admin listener when one is configured, and on
+// the public listener otherwise.
 type Server struct {
-	engine    EngineController
-	stats     *stats.Collector
-	config    *config.Config
-	server    *http.Server
-	mux       *http.ServeMux
+	engine   EngineController
+	stats    *stats.Collector
+	config   *config.Config // guarded by configMu; see SetConfig
+	configMu sync.RWMutex
+	server   *http.Server
+	mux      *http.ServeMux
+	adminMux *http.ServeMux
+
+	adminAddr   string
+	adminServer *http.Server
+
+	market *market.Feed
+	runs   *runs.Registry
+	dsps   DSPManager
+
+	reloader    ConfigReloader
+	sampler     AuctionSampler
+	simulations *engine.Manager
+	debug       *runtimeSampler // set by WithDebug
+
+	// apiRPS is the rate last set through /rps, so the effective
+	// configuration can attribute it; 0 if never set.
+	apiRPS atomic.Int64
+
+	reachability ReachabilityReporter
+	health       HealthReporter
+	buffer       BufferReporter
+	auth         credentials
+
+	// streamInterval paces /stats/stream events. done is closed on
+	// Shutdown to end open streams, which Shutdown does not interrupt.
+	streamInterval time.Duration
+	done           chan struct{}
+	closeOnce      sync.Once
 }
 
 // Option configures the server.
@@ -50,6 +139,66 @@ func WithAddr(addr string) Option {
 	}
 }
 
+// WithAdminAddr serves control endpoints (start, stop, and other mutations)
+// on a separate listener at addr instead of the public listener.
+func WithAdminAddr(addr string) Option {
+	return func(s *Server) {
+		s.adminAddr = addr
+	}
+}
+
+// WithMarketFeed serves the clearing price feed at /market/prices.
+func WithMarketFeed(f *market.Feed) Option {
+	return func(s *Server) {
+		s.market = f
+	}
+}
+
+// WithRuns serves run listings and artifact downloads under /runs.
+func WithRuns(r *runs.Registry) Option {
+	return func(s *Server) {
+		s.runs = r
+	}
+}
+
+// WithDSPManager serves runtime DSP management under /dsps on the
+// control listener.
+func WithDSPManager(m DSPManager) Option {
+	return func(s *Server) {
+		s.dsps = m
+	}
+}
+
+// WithReachability serves DSP endpoint preflight checks at
+// /dsps/reachability.
+func WithReachability(r ReachabilityReporter) Option {
+	return func(s *Server) {
+		s.reachability = r
+	}
+}
+
+// WithHealth adds the health of DSP endpoints to /status.
+func WithHealth(h HealthReporter) Option {
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
+// WithRequestBuffer adds the occupancy of the engine's request buffer to
+// /status, to tune its size.
+func WithRequestBuffer(b BufferReporter) Option {
+	return func(s *Server) {
+		s.buffer = b
+	}
+}
+
+// WithStreamInterval sets how often /stats/stream pushes an event.
+func WithStreamInterval(d time.Duration) Option {
+	return func(s *Server) {
+		s.streamInterval = d
+	}
+}
+
 // WithReadTimeout sets the read timeout.
 func WithReadTimeout(d time.Duration) Option {
 	return func(s *Server) {
@@ -77,12 +226,26 @@ func New(engine EngineController, stats *stats.Collector, cfg *config.Config, op
 			WriteTimeout: 10 * time.Second,
 			IdleTimeout:  60 * time.Second,
 		},
+		streamInterval: time.Second,
+		done:           make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	s.adminMux = s.mux
+	if s.adminAddr != "" {
+		s.adminMux = http.NewServeMux()
+		s.adminServer = &http.Server{
+			Addr:         s.adminAddr,
+			Handler:      s.adminMux,
+			ReadTimeout:  s.server.ReadTimeout,
+			WriteTimeout: s.server.WriteTimeout,
+			IdleTimeout:  s.server.IdleTimeout,
+		}
+	}
+
 	s.setupRoutes()
 	s.server.Handler = s.mux
 
@@ -91,27 +254,100 @@ func New(engine EngineController, stats *stats.Collector, cfg *config.Config, op
 
 // setupRoutes registers all API routes.
 func (s *Server) setupRoutes() {
+	// Read-only routes
 	s.mux.HandleFunc("/health", s.handleHealth)
 	s.mux.HandleFunc("/status", s.handleStatus)
-	s.mux.HandleFunc("/start", s.handleStart)
-	s.mux.HandleFunc("/stop", s.handleStop)
 	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/stats/stream", s.handleStatsStream)
+	s.mux.HandleFunc("/stats/timeseries", s.handleTimeSeries)
 	s.mux.HandleFunc("/config", s.handleConfig)
+	s.mux.HandleFunc("/config/effective", s.handleEffectiveConfig)
+	s.mux.HandleFunc("/dsps/{name}/recent-errors", s.handleRecentErrors)
+	if s.market != nil {
+		s.mux.HandleFunc("/market/prices", s.handleMarketPrices)
+	}
+	if s.reachability != nil {
+		s.mux.HandleFunc("/dsps/reachability", s.handleReachability)
+	}
+	if s.sampler != nil {
+		s.mux.HandleFunc("/auctions/sample", s.handleAuctionSample)
+	}
+	if s.runs != nil {
+		s.mux.HandleFunc("/runs", s.handleRuns)
+		s.mux.HandleFunc("/runs/{id}/artifacts.zip", s.handleRunArtifacts)
+	}
+	if s.simulations != nil {
+		s.mux.HandleFunc("/simulations", s.handleSimulations)
+		s.mux.HandleFunc("/simulations/{id}", s.handleSimulation)
+		s.mux.HandleFunc("/simulations/{id}/stats", s.handleSimulationStats)
+	}
+
+	// Control routes, behind authentication when configured
+	s.adminMux.HandleFunc("/start", s.requireAuth(s.handleStart))
+	s.adminMux.HandleFunc("/stop", s.requireAuth(s.handleStop))
+	s.adminMux.HandleFunc("/drain", s.requireAuth(s.handleDrain))
+	s.adminMux.HandleFunc("/rps", s.requireAuth(s.handleRPS))
+	if s.reloader != nil {
+		s.adminMux.HandleFunc("/config/reload", s.requireAuth(s.handleConfigReload))
+	}
+	if s.dsps != nil {
+		s.adminMux.HandleFunc("/dsps", s.requireAuth(s.handleDSPs))
+		s.adminMux.HandleFunc("/dsps/{name}", s.requireAuth(s.handleDSP))
+		s.adminMux.HandleFunc("/dsps/{name}/enable", s.requireAuth(s.handleDSPEnabled(true)))
+		s.adminMux.HandleFunc("/dsps/{name}/disable", s.requireAuth(s.handleDSPEnabled(false)))
+	}
+	if s.simulations != nil {
+		s.adminMux.HandleFunc("/simulations/start", s.requireAuth(s.handleSimulationsStart))
+		s.adminMux.HandleFunc("/simulations/stop", s.requireAuth(s.handleSimulationsStop))
+		s.adminMux.HandleFunc("/simulations/{id}/start", s.requireAuth(s.handleSimulationStart))
+		s.adminMux.HandleFunc("/simulations/{id}/stop", s.requireAuth(s.handleSimulationStop))
+	}
+	if s.debug != nil {
+		s.setupDebugRoutes()
+	}
+
+	if s.adminMux != s.mux {
+		s.adminMux.HandleFunc("/health", s.handleHealth)
+		s.adminMux.HandleFunc("/status", s.handleStatus)
+	}
 }
 
-// Handler returns the HTTP handler for testing.
+// Handler returns the public HTTP handler for testing.
 func (s *Server) Handler() http.Handler {
 	return s.mux
 }
 
-// ListenAndServe starts the HTTP server.
+// AdminHandler returns the handler serving control endpoints. It is the
+// same as Handler when no admin listener is configured.
+func (s *Server) AdminHandler() http.Handler {
+	return s.adminMux
+}
+
+// ListenAndServe starts the HTTP server and, if configured, the admin
+// listener. It returns when either listener stops.
 func (s *Server) ListenAndServe() error {
-	return s.server.ListenAndServe()
+	if s.adminServer == nil {
+		return s.server.ListenAndServe()
+	}
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- s.adminServer.ListenAndServe() }()
+	go func() { errCh <- s.server.ListenAndServe() }()
+	return <-errCh
 }
 
-// Shutdown gracefully shuts down the server.
+// Shutdown gracefully shuts down the server and admin listener.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	s.closeOnce.Do(func() { close(s.done) })
+
+	var adminErr error
+	if s.adminServer != nil {
+		adminErr = s.adminServer.Shutdown(ctx)
+	}
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return adminErr
 }
 
 // handleHealth returns a simple health check response.
@@ -132,6 +368,13 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := StatusResponse{Running: s.engine.IsRunning()}
+	if s.health != nil {
+		resp.DSPs = healthResponses(s.health.Health())
+	}
+	if s.buffer != nil {
+		bs := s.buffer.RequestBuffer()
+		resp.RequestBuffer = &RequestBufferResponse{Buffered: bs.Buffered, Capacity: bs.Capacity, Underruns: bs.Underruns}
+	}
 	s.writeJSON(w, http.StatusOK, resp)
 }
 
@@ -169,15 +412,98 @@ func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, resp)
 }
 
-// handleStats returns the current statistics snapshot.
+// defaultDrainTimeout bounds /drain when no timeout is given.
+const defaultDrainTimeout = 30 * time.Second
+
+// handleDrain stops the simulation engine once the auctions and
+// notifications underway finish, or after timeout (default 30s), when
+// the rest are cancelled.
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	timeout := defaultDrainTimeout
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		var err error
+		if timeout, err = time.ParseDuration(v); err != nil || timeout <= 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "timeout must be a positive duration such as 10s"})
+			return
+		}
+	}
+
+	// The drain may outlast the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("drain: clearing write deadline: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	if err := s.engine.Drain(ctx); err != nil {
+		s.writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{
+			Error: fmt.Sprintf("drain did not finish within %v; the simulation was stopped", timeout),
+		})
+		return
+	}
+
+	resp := StatusResponse{Running: false, Message: "simulation drained"}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRPS reports (GET) or changes (PUT) the engine's request rate.
+// Changes take effect immediately, including while the simulation runs.
+func (s *Server) handleRPS(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req RPSRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+			return
+		}
+		if err := s.engine.SetRPS(req.RPS); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		s.apiRPS.Store(int64(req.RPS))
+		log.Printf("RPS changed to %d", req.RPS)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, RPSResponse{RPS: s.engine.RPS()})
+}
+
+// handleStats returns the current statistics snapshot as JSON, YAML, or
+// a plain-text table, per ?format= or the Accept header.
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	s.writeStats(w, r, s.stats)
+}
 
-	snap := s.stats.Snapshot()
-	s.writeJSON(w, http.StatusOK, snap)
+// writeStats writes c's snapshot in the format r asks for.
+func (s *Server) writeStats(w http.ResponseWriter, r *http.Request, c *stats.Collector) {
+	format, ok := negotiateFormat(r)
+	if !ok {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "format must be json, yaml, or text"})
+		return
+	}
+
+	snap := c.Snapshot()
+	switch format {
+	case formatYAML:
+		s.writeYAML(w, http.StatusOK, snap)
+	case formatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeStatsTable(w, snap)
+	default:
+		s.writeJSON(w, http.StatusOK, snap)
+	}
 }
 
 // handleConfig returns the current configuration.
@@ -187,7 +513,123 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, s.config)
+	s.writeJSON(w, http.StatusOK, s.currentConfig().Redacted())
+}
+
+// handleEffectiveConfig returns the configuration in effect, including
+// changes made at runtime, setting by setting with each value's source.
+func (s *Server) handleEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// A ramp also moves the rate, so only a rate still at the value last
+	// set through /rps is attributed to the API
+	var rps int
+	if set := int(s.apiRPS.Load()); set > 0 && s.engine.RPS() == set {
+		rps = set
+	}
+	var dsps []config.DSPConfig
+	if s.dsps != nil {
+		dsps = s.dsps.DSPs()
+	}
+	cfg := s.currentConfig().WithRuntime(rps, dsps)
+	s.writeJSON(w, http.StatusOK, EffectiveConfigResponse{Settings: cfg.Redacted().Settings()})
+}
+
+// handleRecentErrors returns the most recent errors for a single DSP.
+// An optional limit query parameter caps the number of samples returned.
+func (s *Server) handleRecentErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	name := r.PathValue("name")
+	samples, ok := s.stats.RecentErrors(name, limit)
+	if !ok && !s.isConfiguredDSP(name) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "unknown dsp: " + name})
+		return
+	}
+
+	resp := RecentErrorsResponse{DSP: name, Errors: make([]RecentError, len(samples))}
+	for i, e := range samples {
+		resp.Errors[i] = RecentError{Time: e.Time, StatusCode: e.StatusCode, Message: e.Message}
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleMarketPrices returns recent clearing prices, optionally filtered
+// by size (e.g. 320x50) and geo (geo.country, e.g. USA).
+func (s *Server) handleMarketPrices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	s.writeJSON(w, http.StatusOK, s.market.Prices(market.Query{
+		Size: q.Get("size"),
+		Geo:  q.Get("geo"),
+	}))
+}
+
+// handleRuns lists retained simulation runs, newest first.
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, s.runs.List())
+}
+
+// handleRunArtifacts streams a zip archive of a run's artifacts.
+func (s *Server) handleRunArtifacts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	if _, err := s.runs.Get(id); err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id+`-artifacts.zip"`)
+	if err := s.runs.WriteArtifacts(w, id); err != nil {
+		log.Printf("failed to write artifacts for %s: %v", id, err)
+	}
+}
+
+// isConfiguredDSP reports whether name matches a configured DSP,
+// including DSPs added at runtime.
+func (s *Server) isConfiguredDSP(name string) bool {
+	var dsps []config.DSPConfig
+	if s.dsps != nil {
+		dsps = s.dsps.DSPs()
+	} else if cfg := s.currentConfig(); cfg != nil {
+		dsps = cfg.DSPs
+	}
+	for _, dsp := range dsps {
+		if dsp.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // writeJSON writes a JSON response.
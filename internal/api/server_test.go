@@ -1,16 +1,29 @@
 package api
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
 	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/internal/market"
+	"github.com/cass/rtb-simulator/internal/runs"
 	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
 // mockEngine implements EngineController for testing.
@@ -19,6 +32,10 @@ type mockEngine struct {
 	startCalled bool
 	stopCalled  bool
 	startErr    error
+	rps         int
+
+	drainTimeout time.Duration // deadline of the last Drain context
+	drainErr     error
 }
 
 func (m *mockEngine) Start() error {
@@ -35,10 +52,37 @@ func (m *mockEngine) Stop() {
 	m.running = false
 }
 
+func (m *mockEngine) Drain(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		m.drainTimeout = time.Until(deadline).Round(time.Second)
+	}
+	m.running = false
+	return m.drainErr
+}
+
+func (m *mockEngine) StopGenerating() {}
+
+func (m *mockEngine) Wait(context.Context) error {
+	m.running = false
+	return nil
+}
+
 func (m *mockEngine) IsRunning() bool {
 	return m.running
 }
 
+func (m *mockEngine) RPS() int {
+	return m.rps
+}
+
+func (m *mockEngine) SetRPS(rps int) error {
+	if rps <= 0 {
+		return errors.New("rps must be positive")
+	}
+	m.rps = rps
+	return nil
+}
+
 func TestServer_StartEndpoint(t *testing.T) {
 	eng := &mockEngine{}
 	collector := stats.New()
@@ -88,6 +132,44 @@ func TestServer_StartEndpoint_AlreadyRunning(t *testing.T) {
 	}
 }
 
+func TestServer_DrainEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		drainErr    error
+		wantStatus  int
+		wantTimeout time.Duration
+	}{
+		{"default timeout", "/drain", nil, http.StatusOK, 30 * time.Second},
+		{"custom timeout", "/drain?timeout=5s", nil, http.StatusOK, 5 * time.Second},
+		{"invalid timeout", "/drain?timeout=soon", nil, http.StatusBadRequest, 0},
+		{"negative timeout", "/drain?timeout=-1s", nil, http.StatusBadRequest, 0},
+		{"timed out", "/drain", context.DeadlineExceeded, http.StatusGatewayTimeout, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := &mockEngine{running: true, drainErr: tt.drainErr}
+			srv := New(eng, stats.New(), &config.Config{})
+
+			rec := httptest.NewRecorder()
+			srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("POST %s status = %d, want %d (body %s)", tt.path, rec.Code, tt.wantStatus, rec.Body)
+			}
+			if eng.drainTimeout != tt.wantTimeout {
+				t.Errorf("drain timeout = %v, want %v", eng.drainTimeout, tt.wantTimeout)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	New(&mockEngine{}, stats.New(), &config.Config{}).AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/drain", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /drain status = %d, want 405", rec.Code)
+	}
+}
+
 func TestServer_StopEndpoint(t *testing.T) {
 	eng := &mockEngine{running: true}
 	collector := stats.New()
@@ -119,6 +201,135 @@ func TestServer_StopEndpoint(t *testing.T) {
 	}
 }
 
+func TestServer_RPSEndpoint(t *testing.T) {
+	eng := &mockEngine{running: true, rps: 10}
+	handler := New(eng, stats.New(), &config.Config{}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/rps", strings.NewReader(`{"rps": 250}`)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT /rps status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp RPSResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.RPS != 250 || eng.rps != 250 {
+		t.Errorf("RPS = %d (engine %d), want 250", resp.RPS, eng.rps)
+	}
+	if !eng.running {
+		t.Error("engine should keep running across an RPS change")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rps", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /rps status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestServer_RPSEndpoint_Invalid(t *testing.T) {
+	eng := &mockEngine{rps: 10}
+	handler := New(eng, stats.New(), &config.Config{}).Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"zero", http.MethodPut, `{"rps": 0}`, http.StatusBadRequest},
+		{"malformed", http.MethodPut, `{`, http.StatusBadRequest},
+		{"wrong method", http.MethodPost, `{"rps": 5}`, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/rps", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+	if eng.rps != 10 {
+		t.Errorf("engine rps = %d, want unchanged 10", eng.rps)
+	}
+}
+
+func TestServer_MarketPricesEndpoint(t *testing.T) {
+	feed := market.New()
+	req := &openrtb.BidRequest{
+		ID:     "req-1",
+		Imp:    []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{W: 320, H: 50}}},
+		Device: &openrtb.Device{Geo: &openrtb.Geo{Country: "USA"}},
+	}
+	feed.ObserveAuction(req, nil, auction.Outcome{Winner: &openrtb.Bid{Price: 2}, ClearingPrice: 2})
+
+	handler := New(&mockEngine{}, stats.New(), &config.Config{}, WithMarketFeed(feed)).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/market/prices?size=320x50&geo=USA", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /market/prices status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var prices market.Prices
+	if err := json.NewDecoder(rec.Body).Decode(&prices); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if prices.Cleared != 1 || prices.P50 != 2 {
+		t.Errorf("Cleared, P50 = %d, %v, want 1, 2", prices.Cleared, prices.P50)
+	}
+}
+
+func TestServer_MarketPricesEndpoint_Disabled(t *testing.T) {
+	handler := New(&mockEngine{}, stats.New(), &config.Config{}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/market/prices", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestServer_RunArtifactsEndpoint(t *testing.T) {
+	registry := runs.NewRegistry(stats.New(), &config.Config{})
+	registry.RunStarted()
+	registry.RunStopped()
+
+	handler := New(&mockEngine{}, stats.New(), &config.Config{}, WithRuns(registry)).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/runs/run-0001/artifacts.zip", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type = %q, want application/zip", ct)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len())); err != nil {
+		t.Errorf("response is not a zip archive: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/runs/run-0042/artifacts.zip", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown run status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/runs", nil))
+	var list []runs.Info
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != "run-0001" {
+		t.Errorf("GET /runs = %+v, want [run-0001]", list)
+	}
+}
+
 func TestServer_StatsEndpoint(t *testing.T) {
 	eng := &mockEngine{}
 	collector := stats.New()
@@ -142,6 +353,81 @@ func TestServer_StatsEndpoint(t *testing.T) {
 	}
 }
 
+func TestServer_StatsEndpoint_Percentiles(t *testing.T) {
+	eng := &mockEngine{}
+	collector := stats.New()
+	cfg := &config.Config{}
+
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 40 * time.Millisecond},
+	})
+
+	srv := New(eng, collector, cfg)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	var snap stats.Snapshot
+	if err := json.NewDecoder(rec.Body).Decode(&snap); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if snap.Latency.Max != 40*time.Millisecond {
+		t.Errorf("Latency.Max = %v, want 40ms", snap.Latency.Max)
+	}
+	if snap.DSPStats["dsp1"].Latency.P99 != 40*time.Millisecond {
+		t.Errorf("dsp1 Latency.P99 = %v, want 40ms", snap.DSPStats["dsp1"].Latency.P99)
+	}
+}
+
+func TestServer_RecentErrorsEndpoint(t *testing.T) {
+	collector := stats.New()
+	cfg := &config.Config{DSPs: []config.DSPConfig{{Name: "dsp1"}, {Name: "idle"}}}
+
+	for i := 0; i < 3; i++ {
+		collector.RecordAuction(auction.Outcome{RequestID: "req"}, []dispatcher.Result{
+			{DSPName: "dsp1", Error: &httpclient.StatusError{StatusCode: 500 + i}},
+		})
+	}
+
+	handler := New(&mockEngine{}, collector, cfg).Handler()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantErrors int
+	}{
+		{"/dsps/dsp1/recent-errors", http.StatusOK, 3},
+		{"/dsps/dsp1/recent-errors?limit=2", http.StatusOK, 2},
+		{"/dsps/idle/recent-errors", http.StatusOK, 0},
+		{"/dsps/missing/recent-errors", http.StatusNotFound, 0},
+		{"/dsps/dsp1/recent-errors?limit=abc", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp RecentErrorsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Errors) != tt.wantErrors {
+				t.Errorf("len(Errors) = %d, want %d", len(resp.Errors), tt.wantErrors)
+			}
+			if tt.wantErrors > 0 && resp.Errors[0].StatusCode != 502 {
+				t.Errorf("Errors[0].StatusCode = %d, want 502 (newest first)", resp.Errors[0].StatusCode)
+			}
+		})
+	}
+}
+
 func TestServer_ConfigEndpoint(t *testing.T) {
 	eng := &mockEngine{}
 	collector := stats.New()
@@ -175,6 +461,57 @@ func TestServer_ConfigEndpoint(t *testing.T) {
 	}
 }
 
+func TestServer_EffectiveConfigEndpoint(t *testing.T) {
+	eng := &mockEngine{rps: 100}
+	cfg := &config.Config{
+		Server:     config.ServerConfig{Port: 8080, Auth: config.AuthConfig{APIKey: "secret"}},
+		Simulation: config.SimulationConfig{RequestsPerSecond: 100},
+		Sources: map[string]config.Source{
+			"server.port":                    config.SourceFile,
+			"server.auth.api_key":            config.SourceEnv,
+			"simulation.requests_per_second": config.SourceFile,
+		},
+	}
+	srv := New(eng, stats.New(), cfg)
+
+	rec := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/rps", strings.NewReader(`{"rps":250}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT /rps status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config/effective", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /config/effective status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp EffectiveConfigResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	settings := make(map[string]config.Setting)
+	for _, s := range resp.Settings {
+		settings[s.Path] = s
+	}
+	tests := []struct {
+		path   string
+		value  any
+		source config.Source
+	}{
+		{"server.port", float64(8080), config.SourceFile},
+		{"server.auth.api_key", "REDACTED", config.SourceEnv},
+		{"simulation.requests_per_second", float64(250), config.SourceAPI},
+		{"simulation.scenario", "", config.SourceDefault},
+	}
+	for _, tt := range tests {
+		got := settings[tt.path]
+		if got.Value != tt.value || got.Source != tt.source {
+			t.Errorf("%s = %v from %q, want %v from %q", tt.path, got.Value, got.Source, tt.value, tt.source)
+		}
+	}
+}
+
 func TestServer_StatusEndpoint(t *testing.T) {
 	eng := &mockEngine{running: true}
 	collector := stats.New()
@@ -202,6 +539,26 @@ func TestServer_StatusEndpoint(t *testing.T) {
 	}
 }
 
+type stubBuffer engine.BufferStats
+
+func (b stubBuffer) RequestBuffer() engine.BufferStats { return engine.BufferStats(b) }
+
+func TestServer_StatusEndpoint_RequestBuffer(t *testing.T) {
+	srv := New(&mockEngine{running: true}, stats.New(), &config.Config{},
+		WithRequestBuffer(stubBuffer{Buffered: 12, Capacity: 64, Underruns: 3}))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var resp StatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := RequestBufferResponse{Buffered: 12, Capacity: 64, Underruns: 3}
+	if resp.RequestBuffer == nil || *resp.RequestBuffer != want {
+		t.Errorf("request_buffer = %+v, want %+v", resp.RequestBuffer, want)
+	}
+}
+
 func TestServer_HealthEndpoint(t *testing.T) {
 	eng := &mockEngine{}
 	collector := stats.New()
@@ -286,6 +643,66 @@ func TestServer_ListenAndServe(t *testing.T) {
 	}
 }
 
+func TestServer_AdminListener_SeparatesControlRoutes(t *testing.T) {
+	eng := &mockEngine{}
+	collector := stats.New()
+	cfg := &config.Config{}
+
+	srv := New(eng, collector, cfg, WithAddr(":0"), WithAdminAddr("127.0.0.1:0"))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		path    string
+		want    int
+	}{
+		{"public stats", srv.Handler(), http.MethodGet, "/stats", http.StatusOK},
+		{"public start hidden", srv.Handler(), http.MethodPost, "/start", http.StatusNotFound},
+		{"public stop hidden", srv.Handler(), http.MethodPost, "/stop", http.StatusNotFound},
+		{"admin start", srv.AdminHandler(), http.MethodPost, "/start", http.StatusOK},
+		{"admin status", srv.AdminHandler(), http.MethodGet, "/status", http.StatusOK},
+		{"admin stats hidden", srv.AdminHandler(), http.MethodGet, "/stats", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_AdminListener_Combined(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{})
+
+	if srv.AdminHandler() != srv.Handler() {
+		t.Error("AdminHandler() should equal Handler() without an admin listener")
+	}
+}
+
+func TestServer_AdminListener_Shutdown(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{},
+		WithAddr("127.0.0.1:0"),
+		WithAdminAddr("127.0.0.1:0"),
+	)
+
+	go func() {
+		_ = srv.ListenAndServe()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
+
 func TestServer_Options(t *testing.T) {
 	eng := &mockEngine{}
 	collector := stats.New()
@@ -307,3 +724,134 @@ func TestServer_Options(t *testing.T) {
 		t.Errorf("server.WriteTimeout = %v, want 10s", srv.server.WriteTimeout)
 	}
 }
+
+func TestServer_TimeSeriesEndpoint(t *testing.T) {
+	collector := stats.New(stats.WithTimeSeriesWindow(time.Minute))
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 40 * time.Millisecond},
+	})
+	handler := New(&mockEngine{}, collector, &config.Config{}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/timeseries?window=30s", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /stats/timeseries status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp TimeSeriesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Points) != 30 {
+		t.Fatalf("len(Points) = %d, want 30", len(resp.Points))
+	}
+	// The auction may have landed in the previous second
+	var requests uint64
+	for _, p := range resp.Points {
+		requests += p.Requests
+		if p.Requests > 0 && p.AvgLatency != 40 {
+			t.Errorf("AvgLatency = %v, want 40", p.AvgLatency)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+
+	for _, window := range []string{"soon", "0s", "500ms"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/timeseries?window="+window, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("window=%s: status = %d, want %d", window, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestServer_TimeSeriesEndpoint_Anomalies(t *testing.T) {
+	clk := clock.NewManual(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	collector := stats.New(stats.WithClock(clk), stats.WithAnomalyDetection(time.Minute, 3, nil))
+	results := []dispatcher.Result{{DSPName: "dsp1", Latency: 20 * time.Millisecond}}
+	for range 15 {
+		collector.RecordAuction(auction.Outcome{}, results)
+		clk.Advance(time.Second)
+	}
+	collector.RecordAuction(auction.Outcome{}, []dispatcher.Result{{DSPName: "dsp1", Latency: 500 * time.Millisecond}})
+	clk.Advance(time.Second)
+	collector.RecordAuction(auction.Outcome{}, results)
+	handler := New(&mockEngine{}, collector, &config.Config{}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/timeseries?window=3s", nil))
+	var resp TimeSeriesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := []AnomalyResponse{{Kind: stats.AnomalyLatencySpike, Value: 500, Mean: 20, StdDev: 1}}
+	if got := resp.Points[1].Anomalies; !reflect.DeepEqual(got, want) {
+		t.Errorf("Points[1].Anomalies = %+v, want %+v", got, want)
+	}
+	if got := resp.Points[2].Anomalies; got != nil {
+		t.Errorf("Points[2].Anomalies = %+v, want none", got)
+	}
+}
+
+func TestServer_StatsEndpoint_Formats(t *testing.T) {
+	collector := stats.New()
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 40 * time.Millisecond},
+	})
+	handler := New(&mockEngine{}, collector, &config.Config{}).Handler()
+
+	tests := []struct {
+		target, accept string
+		wantType       string
+		wantBody       string
+	}{
+		{"/stats", "", "application/json", `"TotalRequests":1`},
+		{"/stats", "text/html, application/yaml;q=0.9", "application/yaml", "TotalRequests: 1"},
+		{"/stats", "text/plain", "text/plain; charset=utf-8", "Requests"},
+		{"/stats?format=yaml", "application/json", "application/yaml", "TotalRequests: 1"},
+		{"/stats?format=text", "", "text/plain; charset=utf-8", "dsp1"},
+		{"/stats?format=json", "text/plain", "application/json", `"TotalRequests":1`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s (Accept %q): status = %d, want %d", tt.target, tt.accept, rec.Code, http.StatusOK)
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+			t.Errorf("%s (Accept %q): Content-Type = %q, want %q", tt.target, tt.accept, got, tt.wantType)
+		}
+		if !strings.Contains(rec.Body.String(), tt.wantBody) {
+			t.Errorf("%s (Accept %q): body does not contain %q:\n%s", tt.target, tt.accept, tt.wantBody, rec.Body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("format=xml: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestWriteStatsTable_Rates(t *testing.T) {
+	snap := stats.Snapshot{
+		TotalRequests: 10, TotalBids: 5, TotalWins: 2,
+		Rates: stats.Rates{WinRate: 0.2},
+		DSPStats: map[string]stats.DSPStats{
+			"dsp1": {Requests: 8, Bids: 4, Wins: 2, Rates: stats.Rates{WinRate: 0.25}},
+		},
+	}
+	var buf strings.Builder
+	writeStatsTable(&buf, snap)
+
+	// Win rates are per auction and per DSP request, not per bid
+	if out := buf.String(); !strings.Contains(out, "20.0%") || !strings.Contains(out, "25.0%") || strings.Contains(out, "40.0%") {
+		t.Errorf("table does not show the snapshot's win rates:\n%s", out)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cass/rtb-simulator/internal/engine"
+)
+
+// SimulationResponse describes a named simulation.
+type SimulationResponse struct {
+	Name     string   `json:"name"`
+	Running  bool     `json:"running"`
+	RPS      int      `json:"rps"`
+	Scenario string   `json:"scenario,omitempty"`
+	DSPs     []string `json:"dsps"`
+}
+
+// WithSimulations serves the named simulations m hosts under
+// /simulations: listings and stats on the public listener, starting and
+// stopping them on the control listener.
+func WithSimulations(m *engine.Manager) Option {
+	return func(s *Server) {
+		s.simulations = m
+	}
+}
+
+func simulationResponse(sim *engine.Simulation) SimulationResponse {
+	return SimulationResponse{
+		Name:     sim.Name,
+		Running:  sim.Engine.IsRunning(),
+		RPS:      sim.Engine.RPS(),
+		Scenario: sim.Scenario,
+		DSPs:     sim.DSPs,
+	}
+}
+
+// handleSimulations lists the named simulations.
+func (s *Server) handleSimulations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.writeSimulations(w)
+}
+
+// writeSimulations lists the named simulations.
+func (s *Server) writeSimulations(w http.ResponseWriter) {
+	sims := s.simulations.List()
+	resp := make([]SimulationResponse, len(sims))
+	for i, sim := range sims {
+		resp[i] = simulationResponse(sim)
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// simulation returns the simulation named in r's path, writing a 404 and
+// returning nil if there is none.
+func (s *Server) simulation(w http.ResponseWriter, r *http.Request) *engine.Simulation {
+	sim, err := s.simulations.Get(r.PathValue("id"))
+	if err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return nil
+	}
+	return sim
+}
+
+// handleSimulation describes a single named simulation.
+func (s *Server) handleSimulation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if sim := s.simulation(w, r); sim != nil {
+		s.writeJSON(w, http.StatusOK, simulationResponse(sim))
+	}
+}
+
+// handleSimulationStats returns a named simulation's statistics, in the
+// formats /stats offers.
+func (s *Server) handleSimulationStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if sim := s.simulation(w, r); sim != nil {
+		s.writeStats(w, r, sim.Stats)
+	}
+}
+
+// handleSimulationStart starts a named simulation.
+func (s *Server) handleSimulationStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sim := s.simulation(w, r)
+	if sim == nil {
+		return
+	}
+	if err := sim.Engine.Start(); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, engine.ErrAlreadyRunning) {
+			status = http.StatusConflict
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, simulationResponse(sim))
+}
+
+// handleSimulationStop stops a named simulation.
+func (s *Server) handleSimulationStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if sim := s.simulation(w, r); sim != nil {
+		sim.Engine.Stop()
+		s.writeJSON(w, http.StatusOK, simulationResponse(sim))
+	}
+}
+
+// handleSimulationsStart starts every named simulation at once, for a
+// side-by-side comparison over the same period.
+func (s *Server) handleSimulationsStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.simulations.StartAll(); err != nil {
+		s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.writeSimulations(w)
+}
+
+// handleSimulationsStop stops every named simulation.
+func (s *Server) handleSimulationsStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.simulations.StopAll()
+	s.writeSimulations(w)
+}
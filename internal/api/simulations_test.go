@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+func TestServer_Simulations(t *testing.T) {
+	m := engine.NewManager()
+	a := &engine.Simulation{Name: "a", Engine: &mockEngine{rps: 10}, Stats: stats.New(), DSPs: []string{"dsp-1"}}
+	b := &engine.Simulation{Name: "b", Engine: &mockEngine{rps: 20}, Stats: stats.New(), DSPs: []string{"dsp-2"}}
+	_ = m.Add(a)
+	_ = m.Add(b)
+	b.Stats.RecordAuction(auction.Outcome{RequestID: "req-1"}, nil)
+
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithSimulations(m))
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/simulations/a/start")
+	if rec.Code != http.StatusOK || !a.Engine.IsRunning() || b.Engine.IsRunning() {
+		t.Fatalf("start a: status = %d, running a=%v b=%v; want only a running", rec.Code, a.Engine.IsRunning(), b.Engine.IsRunning())
+	}
+
+	rec = do(http.MethodGet, "/simulations")
+	var list []SimulationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].Running || list[1].Running || list[1].RPS != 20 || list[1].DSPs[0] != "dsp-2" {
+		t.Errorf("GET /simulations = %+v", list)
+	}
+
+	rec = do(http.MethodGet, "/simulations/b/stats")
+	var snap stats.Snapshot
+	if err := json.NewDecoder(rec.Body).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.TotalRequests != 1 {
+		t.Errorf("b TotalRequests = %d, want 1", snap.TotalRequests)
+	}
+	if rec = do(http.MethodGet, "/simulations/a/stats?format=text"); !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("stats?format=text Content-Type = %q", rec.Header().Get("Content-Type"))
+	}
+
+	if rec = do(http.MethodPost, "/simulations/start"); rec.Code != http.StatusOK || !b.Engine.IsRunning() {
+		t.Errorf("start all: status = %d, b running = %v", rec.Code, b.Engine.IsRunning())
+	}
+	if rec = do(http.MethodPost, "/simulations/stop"); rec.Code != http.StatusOK || a.Engine.IsRunning() || b.Engine.IsRunning() {
+		t.Errorf("stop all: status = %d, left a simulation running", rec.Code)
+	}
+
+	if rec = do(http.MethodGet, "/simulations/c"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /simulations/c status = %d, want 404", rec.Code)
+	}
+	if rec = do(http.MethodGet, "/simulations/a/start"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /simulations/a/start status = %d, want 405", rec.Code)
+	}
+}
+
+func TestServer_SimulationsAdminListener(t *testing.T) {
+	m := engine.NewManager()
+	_ = m.Add(&engine.Simulation{Name: "a", Engine: &mockEngine{}, Stats: stats.New()})
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithSimulations(m), WithAdminAddr(":0"))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/simulations/a/start", nil))
+	if rec.Code == http.StatusOK {
+		t.Error("public listener started a simulation, want control routes on the admin listener only")
+	}
+	rec = httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/simulations/a/start", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("admin start status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// StatsDelta is one /stats/stream event: the traffic recorded since the
+// previous event. Counters are deltas over Interval; latency percentiles
+// are cumulative for the run, since histograms cannot be subtracted.
+type StatsDelta struct {
+	Time     time.Time `json:"time"`
+	Interval float64   `json:"interval_seconds"`
+
+	// Reset is set when the collector was reset (a new run started) since
+	// the previous event; the deltas then count from the reset.
+	Reset bool `json:"reset,omitempty"`
+
+	Requests uint64  `json:"requests"`
+	Bids     uint64  `json:"bids"`
+	Wins     uint64  `json:"wins"`
+	NoBids   uint64  `json:"no_bids"`
+	Errors   uint64  `json:"errors"`
+	Revenue  float64 `json:"revenue"`
+	RPS      float64 `json:"rps"`
+	WinRate  float64 `json:"win_rate"` // wins per bid
+
+	Latency LatencySummary      `json:"latency"`
+	DSPs    map[string]DSPDelta `json:"dsps"`
+}
+
+// DSPDelta is a DSP's share of a StatsDelta.
+type DSPDelta struct {
+	Requests uint64         `json:"requests"`
+	Bids     uint64         `json:"bids"`
+	Wins     uint64         `json:"wins"`
+	Errors   uint64         `json:"errors"`
+	BidRate  float64        `json:"bid_rate"` // bids per request
+	WinRate  float64        `json:"win_rate"` // wins per bid
+	Latency  LatencySummary `json:"latency"`
+}
+
+// LatencySummary reports latency percentiles in milliseconds.
+type LatencySummary struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// handleStatsStream pushes a StatsDelta every stream interval as a
+// server-sent "stats" event until the client disconnects or the server
+// shuts down.
+func (s *Server) handleStatsStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "streaming not supported"})
+		return
+	}
+
+	// The server's write timeout would cut the stream off
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("stats stream: clearing write deadline: %v", err)
+	}
+
+	// Baseline before the client sees the response, so nothing recorded
+	// after it connects is missed
+	prev := s.stats.Snapshot()
+	last := time.Now()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(s.streamInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			cur := s.stats.Snapshot()
+			data, err := json.Marshal(statsDelta(prev, cur, now, now.Sub(last)))
+			if err != nil {
+				log.Printf("stats stream: encoding event: %v", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: stats\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			prev, last = cur, now
+		}
+	}
+}
+
+// statsDelta computes the traffic between two snapshots taken interval
+// apart. A drop in the request count means the collector was reset, so
+// cur is reported in full.
+func statsDelta(prev, cur stats.Snapshot, now time.Time, interval time.Duration) StatsDelta {
+	d := StatsDelta{
+		Time:     now,
+		Interval: interval.Seconds(),
+		Latency:  latencySummary(cur.Latency),
+		DSPs:     make(map[string]DSPDelta, len(cur.DSPStats)),
+	}
+	if cur.TotalRequests < prev.TotalRequests {
+		d.Reset = true
+		prev = stats.Snapshot{}
+	}
+
+	d.Requests = cur.TotalRequests - prev.TotalRequests
+	d.Bids = cur.TotalBids - prev.TotalBids
+	d.Wins = cur.TotalWins - prev.TotalWins
+	d.NoBids = cur.TotalNoBids - prev.TotalNoBids
+	d.Errors = cur.TotalErrors - prev.TotalErrors
+	d.Revenue = cur.TotalRevenue - prev.TotalRevenue
+	if interval > 0 {
+		d.RPS = float64(d.Requests) / interval.Seconds()
+	}
+	d.WinRate = ratio(d.Wins, d.Bids)
+
+	for name, dsp := range cur.DSPStats {
+		// DSPs added since the previous event, or removed and re-added,
+		// count from zero
+		p := prev.DSPStats[name]
+		if dsp.Requests < p.Requests {
+			p = stats.DSPStats{}
+		}
+		dd := DSPDelta{
+			Requests: dsp.Requests - p.Requests,
+			Bids:     dsp.Bids - p.Bids,
+			Wins:     dsp.Wins - p.Wins,
+			Errors:   dsp.Errors - p.Errors,
+			Latency:  latencySummary(dsp.Latency),
+		}
+		dd.BidRate = ratio(dd.Bids, dd.Requests)
+		dd.WinRate = ratio(dd.Wins, dd.Bids)
+		d.DSPs[name] = dd
+	}
+	return d
+}
+
+func latencySummary(p stats.LatencyPercentiles) LatencySummary {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return LatencySummary{P50: ms(p.P50), P95: ms(p.P95), P99: ms(p.P99)}
+}
+
+func ratio(n, d uint64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+func TestStatsDelta(t *testing.T) {
+	prev := stats.Snapshot{
+		TotalRequests: 100, TotalBids: 80, TotalWins: 40, TotalRevenue: 10,
+		DSPStats: map[string]stats.DSPStats{"dsp1": {Requests: 100, Bids: 80, Wins: 40}},
+	}
+	cur := stats.Snapshot{
+		TotalRequests: 150, TotalBids: 120, TotalWins: 50, TotalRevenue: 12.5,
+		Latency: stats.LatencyPercentiles{P99: 20 * time.Millisecond},
+		DSPStats: map[string]stats.DSPStats{
+			"dsp1": {Requests: 150, Bids: 110, Wins: 45},
+			"dsp2": {Requests: 10, Bids: 10, Wins: 5},
+		},
+	}
+
+	d := statsDelta(prev, cur, time.Now(), 2*time.Second)
+	if d.Requests != 50 || d.Bids != 40 || d.Wins != 10 || d.Revenue != 2.5 {
+		t.Errorf("delta = %+v, want 50 requests, 40 bids, 10 wins, 2.5 revenue", d)
+	}
+	if d.RPS != 25 || d.WinRate != 0.25 {
+		t.Errorf("RPS = %v, WinRate = %v, want 25, 0.25", d.RPS, d.WinRate)
+	}
+	if d.Latency.P99 != 20 {
+		t.Errorf("Latency.P99 = %v, want 20", d.Latency.P99)
+	}
+	if got := d.DSPs["dsp1"]; got.Requests != 50 || got.Bids != 30 || got.Wins != 5 {
+		t.Errorf("dsp1 = %+v, want 50 requests, 30 bids, 5 wins", got)
+	}
+	if got := d.DSPs["dsp2"]; got.Requests != 10 || got.WinRate != 0.5 {
+		t.Errorf("new dsp2 = %+v, want 10 requests and 0.5 win rate", got)
+	}
+
+	reset := statsDelta(cur, prev, time.Now(), time.Second)
+	if !reset.Reset || reset.Requests != prev.TotalRequests {
+		t.Errorf("after reset: Reset = %v, Requests = %d, want true, %d", reset.Reset, reset.Requests, prev.TotalRequests)
+	}
+}
+
+func TestServer_StatsStream(t *testing.T) {
+	collector := stats.New()
+	srv := New(&mockEngine{}, collector, &config.Config{}, WithStreamInterval(10*time.Millisecond))
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/stats/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /stats/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 5 * time.Millisecond},
+	})
+
+	// Skip events sent before the auction was recorded
+	scanner := bufio.NewScanner(resp.Body)
+	var event string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if event != "stats" {
+			t.Fatalf("event = %q, want stats", event)
+		}
+		var d StatsDelta
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			t.Fatalf("decoding event: %v", err)
+		}
+		if d.Requests == 1 && d.DSPs["dsp1"].Requests == 1 {
+			return
+		}
+	}
+	t.Fatalf("stream ended without the recorded auction: %v", scanner.Err())
+}
+
+func TestServer_StatsStream_Shutdown(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{}, WithStreamInterval(time.Hour))
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/stats/stream")
+	if err != nil {
+		t.Fatalf("GET /stats/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = bufio.NewReader(resp.Body).ReadString('\x00')
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream still open after Shutdown")
+	}
+}
+
+func TestServer_StatsStream_MethodNotAllowed(t *testing.T) {
+	srv := New(&mockEngine{}, stats.New(), &config.Config{})
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stats/stream", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /stats/stream status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultTimeSeriesWindow is how far back /stats/timeseries reaches when
+// window is not given.
+const defaultTimeSeriesWindow = 10 * time.Minute
+
+// TimeSeriesPoint is the traffic of one second.
+type TimeSeriesPoint struct {
+	Time       time.Time `json:"time"`
+	Requests   uint64    `json:"requests"`
+	Wins       uint64    `json:"wins"`
+	Revenue    float64   `json:"revenue"`
+	AvgLatency float64   `json:"avg_latency_ms"`
+
+	// Anomalies flags the second when anomaly detection is enabled.
+	Anomalies []AnomalyResponse `json:"anomalies,omitempty"`
+}
+
+// AnomalyResponse is a win rate drop or latency spike against the
+// trailing window. Latencies are in milliseconds.
+type AnomalyResponse struct {
+	Kind   string  `json:"kind"`
+	Value  float64 `json:"value"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+}
+
+// TimeSeriesResponse is per-second traffic over a window, oldest first.
+type TimeSeriesResponse struct {
+	Points []TimeSeriesPoint `json:"points"`
+}
+
+// handleTimeSeries returns per-second requests, wins, revenue, and
+// average latency over the last window (default 10m), up to the history
+// the collector keeps. Seconds flagged by anomaly detection list their
+// anomalies.
+func (s *Server) handleTimeSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	window := defaultTimeSeriesWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		var err error
+		if window, err = time.ParseDuration(v); err != nil || window < time.Second {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "window must be a duration of at least 1s"})
+			return
+		}
+	}
+
+	series := s.stats.TimeSeries(window)
+	resp := TimeSeriesResponse{Points: make([]TimeSeriesPoint, len(series))}
+	for i, p := range series {
+		resp.Points[i] = TimeSeriesPoint{
+			Time:       p.Time,
+			Requests:   p.Requests,
+			Wins:       p.Wins,
+			Revenue:    p.Revenue,
+			AvgLatency: float64(p.AvgLatency) / float64(time.Millisecond),
+		}
+		for _, a := range p.Anomalies {
+			resp.Points[i].Anomalies = append(resp.Points[i].Anomalies, AnomalyResponse{
+				Kind:   a.Kind,
+				Value:  a.Value,
+				Mean:   a.Mean,
+				StdDev: a.StdDev,
+			})
+		}
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,158 @@
+package auction
+
+import (
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Reasons a bid is rejected by a DomainPolicy.
+const (
+	ViolationBlocked    = "blocked_adomain"     // an adomain is on the block list
+	ViolationNotAllowed = "adomain_not_allowed" // an adomain is missing from the allow list
+	ViolationMissing    = "missing_adomain"     // no adomain declared while an allow list is set
+)
+
+// DomainPolicy restricts the advertiser domains (bid.adomain) a DSP may
+// bid with. A domain matches an entry if it equals it or is a subdomain
+// of it, ignoring case. An empty Allow list permits any domain not
+// blocked.
+type DomainPolicy struct {
+	Allow []string
+	Block []string
+}
+
+// Check returns the reason a bid declaring adomains violates the policy,
+// or "" if it complies.
+func (p DomainPolicy) Check(adomains []string) string {
+	if len(p.Allow) > 0 && len(adomains) == 0 {
+		return ViolationMissing
+	}
+	for _, d := range adomains {
+		if matchDomain(d, p.Block) {
+			return ViolationBlocked
+		}
+	}
+	if len(p.Allow) > 0 {
+		for _, d := range adomains {
+			if !matchDomain(d, p.Allow) {
+				return ViolationNotAllowed
+			}
+		}
+	}
+	return ""
+}
+
+func matchDomain(domain string, list []string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, entry := range list {
+		entry = strings.ToLower(entry)
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rejection is a bid removed from an auction by a DomainPolicy, by
+// SanityLimits, or for breaking the terms of a deal.
+type Rejection struct {
+	BidWithDSP
+	Reason string
+}
+
+// DomainFilter enforces per-DSP advertiser domain policies. Violating bids
+// are removed before the wrapped auction runs and reported in
+// Outcome.Rejected.
+type DomainFilter struct {
+	next     Auction
+	policies map[string]DomainPolicy
+}
+
+// NewDomainFilter wraps next with policies keyed by DSP name. DSPs without
+// a policy are not filtered.
+func NewDomainFilter(next Auction, policies map[string]DomainPolicy) *DomainFilter {
+	return &DomainFilter{next: next, policies: policies}
+}
+
+// Run filters the results and executes the wrapped auction on the rest.
+func (f *DomainFilter) Run(requestID string, bidFloor float64, results []dispatcher.Result) Outcome {
+	var rejected []Rejection
+	filtered := results
+	copied := false
+	for i, r := range results {
+		policy, ok := f.policies[r.DSPName]
+		if !ok || r.Error != nil || r.Response == nil {
+			continue
+		}
+
+		resp, dropped := filterResponse(r.Response, func(_ string, bid openrtb.Bid) string {
+			return policy.Check(bid.ADomain)
+		})
+		if len(dropped) == 0 {
+			continue
+		}
+		for _, d := range dropped {
+			d.DSPName = r.DSPName
+			rejected = append(rejected, d)
+		}
+		// Copy on first change so the caller's results stay intact
+		if !copied {
+			filtered = append([]dispatcher.Result(nil), results...)
+			copied = true
+		}
+		filtered[i].Response = resp
+	}
+
+	outcome := f.next.Run(requestID, bidFloor, filtered)
+	if len(rejected) > 0 {
+		outcome.Rejected = append(rejected, outcome.Rejected...)
+	}
+	return outcome
+}
+
+// filterResponse returns a copy of resp without the bids check gives a
+// rejection reason for, along with the dropped bids. check is passed each
+// bid's seat. resp is returned unchanged if nothing was dropped.
+func filterResponse(resp *openrtb.BidResponse, check func(seat string, bid openrtb.Bid) string) (*openrtb.BidResponse, []Rejection) {
+	var dropped []Rejection
+	var seatBids []openrtb.SeatBid
+	for i, sb := range resp.SeatBid {
+		var kept []openrtb.Bid
+		for j, bid := range sb.Bid {
+			reason := check(sb.Seat, bid)
+			if reason == "" {
+				if kept != nil {
+					kept = append(kept, bid)
+				}
+				continue
+			}
+			if kept == nil {
+				kept = append(make([]openrtb.Bid, 0, len(sb.Bid)), sb.Bid[:j]...)
+			}
+			dropped = append(dropped, Rejection{
+				BidWithDSP: BidWithDSP{Bid: bid, Seat: sb.Seat, ResponseID: resp.BidID},
+				Reason:     reason,
+			})
+		}
+		if kept == nil {
+			if seatBids != nil {
+				seatBids = append(seatBids, sb)
+			}
+			continue
+		}
+		if seatBids == nil {
+			seatBids = append(make([]openrtb.SeatBid, 0, len(resp.SeatBid)), resp.SeatBid[:i]...)
+		}
+		sb.Bid = kept
+		seatBids = append(seatBids, sb)
+	}
+
+	if len(dropped) == 0 {
+		return resp, nil
+	}
+	filtered := *resp
+	filtered.SeatBid = seatBids
+	return &filtered, dropped
+}
@@ -0,0 +1,125 @@
+package auction
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestDomainPolicy_Check(t *testing.T) {
+	policy := DomainPolicy{
+		Allow: []string{"brand.example", "Shop.Example"},
+		Block: []string{"outlet.brand.example"},
+	}
+
+	tests := []struct {
+		adomains []string
+		want     string
+	}{
+		{[]string{"brand.example"}, ""},
+		{[]string{"www.brand.example"}, ""},
+		{[]string{"shop.example", "brand.example"}, ""},
+		{[]string{"outlet.brand.example"}, ViolationBlocked},
+		{[]string{"brand.example", "other.example"}, ViolationNotAllowed},
+		{[]string{"notbrand.example"}, ViolationNotAllowed},
+		{nil, ViolationMissing},
+	}
+	for _, tt := range tests {
+		if got := policy.Check(tt.adomains); got != tt.want {
+			t.Errorf("Check(%v) = %q, want %q", tt.adomains, got, tt.want)
+		}
+	}
+
+	blockOnly := DomainPolicy{Block: []string{"casino.example"}}
+	if got := blockOnly.Check(nil); got != "" {
+		t.Errorf("block-only Check(nil) = %q, want compliant", got)
+	}
+	if got := blockOnly.Check([]string{"slots.casino.example"}); got != ViolationBlocked {
+		t.Errorf("block-only Check(subdomain) = %q, want %q", got, ViolationBlocked)
+	}
+}
+
+func TestDomainFilter_Run(t *testing.T) {
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{BidID: "r1", SeatBid: []openrtb.SeatBid{{
+			Seat: "s1",
+			Bid: []openrtb.Bid{
+				{ID: "a", Price: 5.0, ADomain: []string{"casino.example"}},
+				{ID: "b", Price: 2.0, ADomain: []string{"brand.example"}},
+			},
+		}}}},
+		{DSPName: "dsp2", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "c", Price: 3.0, ADomain: []string{"casino.example"}}},
+		}}}},
+	}
+
+	auc := NewDomainFilter(NewFirstPrice(), map[string]DomainPolicy{
+		"dsp1": {Block: []string{"casino.example"}},
+	})
+	outcome := auc.Run("req-1", 0.5, results)
+
+	if outcome.Winner == nil || outcome.Winner.ID != "c" {
+		t.Fatalf("winner = %+v, want bid c from unrestricted dsp2", outcome.Winner)
+	}
+	if len(outcome.AllBids) != 2 {
+		t.Errorf("len(AllBids) = %d, want 2", len(outcome.AllBids))
+	}
+	if len(outcome.Rejected) != 1 {
+		t.Fatalf("len(Rejected) = %d, want 1", len(outcome.Rejected))
+	}
+	rej := outcome.Rejected[0]
+	if rej.DSPName != "dsp1" || rej.Bid.ID != "a" || rej.Reason != ViolationBlocked || rej.Seat != "s1" || rej.ResponseID != "r1" {
+		t.Errorf("Rejected[0] = %+v, want bid a from dsp1/s1/r1 for %s", rej, ViolationBlocked)
+	}
+
+	// The caller's results must not be modified
+	if n := len(results[0].Response.SeatBid[0].Bid); n != 2 {
+		t.Errorf("original dsp1 bids = %d, want 2", n)
+	}
+}
+
+func TestDomainFilter_KeepsInnerRejections(t *testing.T) {
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{
+				{ID: "a", Price: 5.0, ADomain: []string{"casino.example"}},
+				{ID: "b", Price: 10000, ADomain: []string{"brand.example"}},
+			},
+		}}}},
+	}
+
+	inner := NewSanityFilter(NewFirstPrice(), SanityLimits{MaxCPM: 500})
+	auc := NewDomainFilter(inner, map[string]DomainPolicy{
+		"dsp1": {Block: []string{"casino.example"}},
+	})
+	outcome := auc.Run("req-1", 0.5, results)
+
+	if len(outcome.Rejected) != 2 {
+		t.Fatalf("Rejected = %+v, want the blocked and the insane bid", outcome.Rejected)
+	}
+	if outcome.Rejected[0].Reason != ViolationBlocked || outcome.Rejected[1].Reason != ViolationInsaneCPM {
+		t.Errorf("reasons = %s, %s; want %s, %s", outcome.Rejected[0].Reason, outcome.Rejected[1].Reason,
+			ViolationBlocked, ViolationInsaneCPM)
+	}
+}
+
+func TestDomainFilter_Compliant(t *testing.T) {
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "a", Price: 2.0, ADomain: []string{"brand.example"}}},
+		}}}},
+	}
+
+	auc := NewDomainFilter(NewFirstPrice(), map[string]DomainPolicy{
+		"dsp1": {Allow: []string{"brand.example"}},
+	})
+	outcome := auc.Run("req-1", 0.5, results)
+
+	if outcome.Winner == nil || outcome.Winner.ID != "a" {
+		t.Errorf("winner = %+v, want bid a", outcome.Winner)
+	}
+	if outcome.Rejected != nil {
+		t.Errorf("Rejected = %+v, want none", outcome.Rejected)
+	}
+}
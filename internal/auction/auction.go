@@ -1,5 +1,5 @@
 // Package auction provides auction implementations for selecting winning bids.
-// It currently supports first-price auctions where the highest bidder pays their bid price.
+// The highest eligible bid wins; the clearing rule decides what it pays.
 package auction
 
 import (
@@ -14,12 +14,71 @@ type Outcome struct {
 	WinningDSP    string
 	ClearingPrice float64
 	AllBids       []BidWithDSP
+
+	// WinnerIndex is the position of the winning bid in AllBids, or -1.
+	WinnerIndex int
+
+	// BidFloor is the floor the auction was run with.
+	BidFloor float64
+
+	// Rejected lists bids removed before the auction for being malformed,
+	// or for violating a DSP's advertiser domain policy, the terms of a
+	// deal, or sanity limits.
+	Rejected []Rejection
+
+	// Preempted lists open-market bids that were eligible but lost to a
+	// deal bid, which takes priority regardless of price. See RunDeals.
+	Preempted []BidWithDSP
+
+	// Partial is set when the run was stopped mid-dispatch: the auction
+	// ran on the Completed DSP calls only, and Cancelled calls never got
+	// a response.
+	Partial   bool
+	Completed int
+	Cancelled int
+
+	// Scenario names the scenario that generated the request when the
+	// run mixes several; empty otherwise.
+	Scenario string
+}
+
+// RunnerUpPrice returns the highest eligible bid price other than the
+// winner's. The boolean is false if the winner was the only bid.
+func (o Outcome) RunnerUpPrice() (float64, bool) {
+	var price float64
+	found := false
+	for i, b := range o.AllBids {
+		if i == o.WinnerIndex {
+			continue
+		}
+		if !found || b.Bid.Price > price {
+			price, found = b.Bid.Price, true
+		}
+	}
+	return price, found
+}
+
+// PriceGap returns the winning price minus the runner-up bid, the yield a
+// second-price auction would have given up. The boolean is false unless
+// at least two eligible bids competed.
+func (o Outcome) PriceGap() (float64, bool) {
+	if o.Winner == nil {
+		return 0, false
+	}
+	second, ok := o.RunnerUpPrice()
+	if !ok {
+		return 0, false
+	}
+	return o.Winner.Price - second, true
 }
 
 // BidWithDSP associates a bid with its originating DSP.
 type BidWithDSP struct {
 	Bid     openrtb.Bid
 	DSPName string
+	Seat    string
+	// ResponseID is the BidResponse.bidid the bid arrived in.
+	ResponseID string
 }
 
 // Auction defines the interface for auction implementations.
@@ -38,11 +97,28 @@ func NewFirstPrice() *FirstPrice {
 
 // Run executes the first-price auction on the given results.
 func (a *FirstPrice) Run(requestID string, bidFloor float64, results []dispatcher.Result) Outcome {
-	outcome := Outcome{RequestID: requestID}
+	outcome := Outcome{RequestID: requestID, WinnerIndex: -1, BidFloor: bidFloor}
+	outcome.AllBids = eligibleBids(results, bidFloor)
+
+	if len(outcome.AllBids) == 0 {
+		return outcome
+	}
 
-	// Collect all eligible bids (above floor, no errors)
+	highestIdx := highestBid(outcome.AllBids)
+	winner := outcome.AllBids[highestIdx]
+	outcome.WinnerIndex = highestIdx
+	outcome.Winner = &winner.Bid
+	outcome.WinningDSP = winner.DSPName
+	outcome.ClearingPrice = winner.Bid.Price // First-price: pay what you bid
+
+	return outcome
+}
+
+// eligibleBids collects all bids at or above the floor from successful
+// responses.
+func eligibleBids(results []dispatcher.Result, bidFloor float64) []BidWithDSP {
 	// Pre-allocate with estimated capacity to reduce allocations
-	eligibleBids := make([]BidWithDSP, 0, len(results)*2)
+	bids := make([]BidWithDSP, 0, len(results)*2)
 
 	for _, r := range results {
 		if r.Error != nil || r.Response == nil {
@@ -52,33 +128,27 @@ func (a *FirstPrice) Run(requestID string, bidFloor float64, results []dispatche
 		for _, sb := range r.Response.SeatBid {
 			for _, bid := range sb.Bid {
 				if bid.Price >= bidFloor {
-					eligibleBids = append(eligibleBids, BidWithDSP{
-						Bid:     bid,
-						DSPName: r.DSPName,
+					bids = append(bids, BidWithDSP{
+						Bid:        bid,
+						DSPName:    r.DSPName,
+						Seat:       sb.Seat,
+						ResponseID: r.Response.BidID,
 					})
 				}
 			}
 		}
 	}
+	return bids
+}
 
-	outcome.AllBids = eligibleBids
-
-	if len(eligibleBids) == 0 {
-		return outcome
-	}
-
-	// Find the highest bid
+// highestBid returns the index of the highest bid; the first one wins ties.
+// bids must not be empty.
+func highestBid(bids []BidWithDSP) int {
 	var highestIdx int
-	for i, b := range eligibleBids {
-		if b.Bid.Price > eligibleBids[highestIdx].Bid.Price {
+	for i, b := range bids {
+		if b.Bid.Price > bids[highestIdx].Bid.Price {
 			highestIdx = i
 		}
 	}
-
-	winner := eligibleBids[highestIdx]
-	outcome.Winner = &winner.Bid
-	outcome.WinningDSP = winner.DSPName
-	outcome.ClearingPrice = winner.Bid.Price // First-price: pay what you bid
-
-	return outcome
+	return highestIdx
 }
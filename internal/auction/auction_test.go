@@ -252,3 +252,80 @@ func init() {
 type testError struct{}
 
 func (testError) Error() string { return "deadline exceeded" }
+
+func TestFirstPriceAuction_Run_BidMetadata(t *testing.T) {
+	auction := NewFirstPrice()
+
+	results := []dispatcher.Result{
+		{
+			DSPName: "dsp1",
+			Response: &openrtb.BidResponse{
+				ID:    "req-1",
+				BidID: "resp-1",
+				SeatBid: []openrtb.SeatBid{{
+					Seat: "seat-a",
+					Bid:  []openrtb.Bid{{ID: "bid-1", ImpID: "imp-1", Price: 1.0}, {ID: "bid-2", ImpID: "imp-1", Price: 3.0}},
+				}},
+			},
+		},
+	}
+
+	outcome := auction.Run("req-1", 0.5, results)
+
+	if outcome.WinnerIndex != 1 {
+		t.Fatalf("WinnerIndex = %d, want 1", outcome.WinnerIndex)
+	}
+	winner := outcome.AllBids[outcome.WinnerIndex]
+	if winner.Bid.ID != "bid-2" || winner.Seat != "seat-a" || winner.ResponseID != "resp-1" {
+		t.Errorf("winner = %+v, want bid-2 from seat-a in resp-1", winner)
+	}
+
+	empty := auction.Run("req-2", 0.5, nil)
+	if empty.WinnerIndex != -1 {
+		t.Errorf("WinnerIndex without bids = %d, want -1", empty.WinnerIndex)
+	}
+}
+
+func TestOutcome_RunnerUpPrice(t *testing.T) {
+	auction := NewFirstPrice()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "a", Price: 3.0}}}}}},
+		{DSPName: "dsp2", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", Price: 2.0}, {ID: "c", Price: 2.5}}}}}},
+	}
+
+	outcome := auction.Run("req-1", 0.5, results)
+	if outcome.BidFloor != 0.5 {
+		t.Errorf("BidFloor = %v, want 0.5", outcome.BidFloor)
+	}
+	second, ok := outcome.RunnerUpPrice()
+	if !ok || second != 2.5 {
+		t.Errorf("RunnerUpPrice() = %v, %v, want 2.5, true", second, ok)
+	}
+
+	single := auction.Run("req-2", 0.5, results[:1])
+	if _, ok := single.RunnerUpPrice(); ok {
+		t.Error("RunnerUpPrice() ok = true for uncontested win, want false")
+	}
+}
+
+func TestOutcome_PriceGap(t *testing.T) {
+	auction := NewFirstPrice()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "a", Price: 3.0}}}}}},
+		{DSPName: "dsp2", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", Price: 2.25}}}}}},
+	}
+
+	gap, ok := auction.Run("req-1", 0.5, results).PriceGap()
+	if !ok || gap != 0.75 {
+		t.Errorf("PriceGap() = %v, %v, want 0.75, true", gap, ok)
+	}
+
+	if _, ok := auction.Run("req-2", 0.5, results[:1]).PriceGap(); ok {
+		t.Error("PriceGap() ok = true for uncontested win, want false")
+	}
+	if _, ok := auction.Run("req-3", 0.5, nil).PriceGap(); ok {
+		t.Error("PriceGap() ok = true without a winner, want false")
+	}
+}
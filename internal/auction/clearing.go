@@ -0,0 +1,186 @@
+package auction
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Clearing rule names accepted by New.
+const (
+	RuleFirstPrice         = "first_price"          // winner pays its bid
+	RuleSecondPrice        = "second_price"         // winner pays the runner-up bid, or its own bid if uncontested
+	RuleReserveSecondPrice = "reserve_second_price" // winner pays the runner-up bid or the floor, whichever is higher
+	RuleSoftSecondPrice    = "soft_second_price"    // reserve second price plus an increment, capped at the winning bid
+	RuleBidReduction       = "bid_reduction"        // winner pays its bid reduced by a percentage, not below the floor
+	RuleSoftFloor          = "soft_floor"           // first price below the soft floor, soft second price from it up
+)
+
+// Rules lists the supported clearing rules.
+var Rules = []string{RuleFirstPrice, RuleSecondPrice, RuleReserveSecondPrice, RuleSoftSecondPrice, RuleBidReduction, RuleSoftFloor}
+
+// ErrUnknownRule is returned by New for an unsupported clearing rule.
+var ErrUnknownRule = errors.New("unknown clearing rule")
+
+// ClearingRule computes the price a winning bid pays from the bid, the best
+// competing eligible bid (contested is false when there was none), and the
+// floor.
+type ClearingRule func(bid, runnerUp float64, contested bool, floor float64) float64
+
+// SealedBid is a sealed-bid auction: the highest eligible bid wins and pays
+// the price set by its clearing rule.
+type SealedBid struct {
+	name string
+	rule ClearingRule
+}
+
+// Option configures the clearing rule built by New.
+type Option func(*ruleParams)
+
+type ruleParams struct {
+	increment    float64
+	bidReduction float64
+	softFloor    float64
+}
+
+// WithIncrement sets the amount soft_second_price and soft_floor add to
+// the second price. Default $0.01.
+func WithIncrement(v float64) Option {
+	return func(p *ruleParams) {
+		p.increment = v
+	}
+}
+
+// WithBidReduction sets the percentage (0-100) bid_reduction takes off the
+// winning bid. Default 10.
+func WithBidReduction(pct float64) Option {
+	return func(p *ruleParams) {
+		p.bidReduction = pct
+	}
+}
+
+// WithSoftFloor sets the price at which soft_floor switches from first
+// to second price. Requests whose floor is higher use their floor
+// instead. Default 0, which makes soft_floor a soft_second_price.
+func WithSoftFloor(v float64) Option {
+	return func(p *ruleParams) {
+		p.softFloor = v
+	}
+}
+
+// New creates an auction using the named clearing rule (see Rules).
+func New(rule string, opts ...Option) (Auction, error) {
+	p := ruleParams{increment: 0.01, bidReduction: 10}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.increment < 0 {
+		return nil, errors.New("increment must not be negative")
+	}
+	if p.bidReduction < 0 || p.bidReduction > 100 {
+		return nil, errors.New("bid reduction must be between 0 and 100")
+	}
+	if p.softFloor < 0 {
+		return nil, errors.New("soft floor must not be negative")
+	}
+
+	switch rule {
+	case RuleFirstPrice:
+		return NewFirstPrice(), nil
+	case RuleSecondPrice:
+		return NewSealedBid(rule, secondPrice), nil
+	case RuleReserveSecondPrice:
+		return NewSealedBid(rule, reserveSecondPrice), nil
+	case RuleSoftSecondPrice:
+		return NewSealedBid(rule, softSecondPrice(p.increment)), nil
+	case RuleBidReduction:
+		return NewSealedBid(rule, bidReduction(p.bidReduction)), nil
+	case RuleSoftFloor:
+		return NewSealedBid(rule, softFloor(p.softFloor, p.increment)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRule, rule)
+	}
+}
+
+// RequestType returns the OpenRTB auction type (BidRequest.at) that tells
+// bidders how a clearing rule prices wins.
+func RequestType(rule string) int {
+	switch rule {
+	case RuleSecondPrice, RuleReserveSecondPrice, RuleSoftSecondPrice, RuleSoftFloor:
+		return openrtb.AuctionSecondPrice
+	default:
+		return openrtb.AuctionFirstPrice
+	}
+}
+
+// NewSealedBid creates a sealed-bid auction with a custom clearing rule.
+func NewSealedBid(name string, rule ClearingRule) *SealedBid {
+	return &SealedBid{name: name, rule: rule}
+}
+
+// Name returns the clearing rule name.
+func (a *SealedBid) Name() string {
+	return a.name
+}
+
+// Run executes the auction on the given results.
+func (a *SealedBid) Run(requestID string, bidFloor float64, results []dispatcher.Result) Outcome {
+	outcome := Outcome{RequestID: requestID, WinnerIndex: -1, BidFloor: bidFloor}
+	outcome.AllBids = eligibleBids(results, bidFloor)
+
+	if len(outcome.AllBids) == 0 {
+		return outcome
+	}
+
+	highestIdx := highestBid(outcome.AllBids)
+	winner := outcome.AllBids[highestIdx]
+	outcome.WinnerIndex = highestIdx
+	outcome.Winner = &winner.Bid
+	outcome.WinningDSP = winner.DSPName
+
+	runnerUp, contested := outcome.RunnerUpPrice()
+	outcome.ClearingPrice = a.rule(winner.Bid.Price, runnerUp, contested, bidFloor)
+
+	return outcome
+}
+
+func secondPrice(bid, runnerUp float64, contested bool, floor float64) float64 {
+	if !contested {
+		return bid
+	}
+	return runnerUp
+}
+
+func reserveSecondPrice(bid, runnerUp float64, contested bool, floor float64) float64 {
+	if !contested {
+		return floor
+	}
+	return max(runnerUp, floor)
+}
+
+func softSecondPrice(increment float64) ClearingRule {
+	return func(bid, runnerUp float64, contested bool, floor float64) float64 {
+		return min(reserveSecondPrice(bid, runnerUp, contested, floor)+increment, bid)
+	}
+}
+
+func bidReduction(pct float64) ClearingRule {
+	return func(bid, runnerUp float64, contested bool, floor float64) float64 {
+		return max(bid*(1-pct/100), floor)
+	}
+}
+
+// softFloor prices winning bids below the soft floor, or the request's
+// floor when higher, at the bid, and others as soft_second_price does
+// with the soft floor as the reserve.
+func softFloor(soft, increment float64) ClearingRule {
+	return func(bid, runnerUp float64, contested bool, floor float64) float64 {
+		reserve := max(soft, floor)
+		if bid < reserve {
+			return bid
+		}
+		return min(reserveSecondPrice(bid, runnerUp, contested, reserve)+increment, bid)
+	}
+}
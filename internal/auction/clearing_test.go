@@ -0,0 +1,101 @@
+package auction
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// bidResults returns one DSP result per price.
+func bidResults(prices ...float64) []dispatcher.Result {
+	results := make([]dispatcher.Result, len(prices))
+	for i, p := range prices {
+		results[i] = dispatcher.Result{
+			DSPName:  string(rune('a' + i)),
+			Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "bid", Price: p}}}}},
+		}
+	}
+	return results
+}
+
+func TestNew_ClearingRules(t *testing.T) {
+	tests := []struct {
+		rule   string
+		opts   []Option
+		floor  float64
+		prices []float64
+		want   float64
+	}{
+		{RuleFirstPrice, nil, 0.5, []float64{3, 2}, 3},
+		{RuleSecondPrice, nil, 0.5, []float64{3, 2}, 2},
+		{RuleSecondPrice, nil, 0.5, []float64{3}, 3},
+		{RuleReserveSecondPrice, nil, 0.5, []float64{3, 2}, 2},
+		{RuleReserveSecondPrice, nil, 0.5, []float64{3}, 0.5},
+		{RuleSoftSecondPrice, nil, 0.5, []float64{3, 2}, 2.01},
+		{RuleSoftSecondPrice, nil, 0.5, []float64{3}, 0.51},
+		{RuleSoftSecondPrice, nil, 0.5, []float64{2, 2}, 2},
+		{RuleSoftSecondPrice, []Option{WithIncrement(0.25)}, 0.5, []float64{3, 2}, 2.25},
+		{RuleBidReduction, nil, 0.5, []float64{3, 2}, 2.7},
+		{RuleBidReduction, []Option{WithBidReduction(50)}, 0.5, []float64{3}, 1.5},
+		{RuleBidReduction, []Option{WithBidReduction(90)}, 0.5, []float64{3}, 0.5},
+		{RuleSoftFloor, []Option{WithSoftFloor(2)}, 0.5, []float64{1.5, 1}, 1.5},
+		{RuleSoftFloor, []Option{WithSoftFloor(2)}, 0.5, []float64{3, 1}, 2.01},
+		{RuleSoftFloor, []Option{WithSoftFloor(2)}, 0.5, []float64{3, 2.5}, 2.51},
+		{RuleSoftFloor, []Option{WithSoftFloor(2)}, 0.5, []float64{2}, 2},
+		{RuleSoftFloor, []Option{WithSoftFloor(2), WithIncrement(0.5)}, 0.5, []float64{3}, 2.5},
+		{RuleSoftFloor, []Option{WithSoftFloor(2)}, 4, []float64{5, 4.5}, 4.51},
+		{RuleSoftFloor, nil, 0.5, []float64{3, 2}, 2.01},
+	}
+
+	for _, tt := range tests {
+		auc, err := New(tt.rule, tt.opts...)
+		if err != nil {
+			t.Fatalf("New(%q) error = %v", tt.rule, err)
+		}
+		outcome := auc.Run("req-1", tt.floor, bidResults(tt.prices...))
+		if outcome.Winner == nil || outcome.Winner.Price != tt.prices[0] {
+			t.Errorf("%s %v: winner = %+v, want the %v bid", tt.rule, tt.prices, outcome.Winner, tt.prices[0])
+		}
+		if math.Abs(outcome.ClearingPrice-tt.want) > 1e-9 {
+			t.Errorf("%s %v floor %v: ClearingPrice = %v, want %v", tt.rule, tt.prices, tt.floor, outcome.ClearingPrice, tt.want)
+		}
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New("vickrey_plus"); !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("New(unknown) error = %v, want ErrUnknownRule", err)
+	}
+	if _, err := New(RuleSoftSecondPrice, WithIncrement(-1)); err == nil {
+		t.Error("New() with negative increment error = nil")
+	}
+	if _, err := New(RuleBidReduction, WithBidReduction(150)); err == nil {
+		t.Error("New() with bid reduction over 100 error = nil")
+	}
+	if _, err := New(RuleSoftFloor, WithSoftFloor(-1)); err == nil {
+		t.Error("New() with negative soft floor error = nil")
+	}
+}
+
+func TestSealedBid_NoBids(t *testing.T) {
+	auc, _ := New(RuleSecondPrice)
+	outcome := auc.Run("req-1", 0.5, bidResults(0.25))
+	if outcome.Winner != nil || outcome.ClearingPrice != 0 || outcome.WinnerIndex != -1 {
+		t.Errorf("outcome = %+v, want no winner", outcome)
+	}
+}
+
+func TestRequestType(t *testing.T) {
+	for _, rule := range Rules {
+		want := openrtb.AuctionSecondPrice
+		if rule == RuleFirstPrice || rule == RuleBidReduction {
+			want = openrtb.AuctionFirstPrice
+		}
+		if got := RequestType(rule); got != want {
+			t.Errorf("RequestType(%q) = %d, want %d", rule, got, want)
+		}
+	}
+}
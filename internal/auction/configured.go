@@ -0,0 +1,43 @@
+package auction
+
+import "github.com/cass/rtb-simulator/internal/config"
+
+// FromConfig builds the configured auction, wrapped in the DSPs'
+// advertiser domain policies and the bid sanity limits when set.
+func FromConfig(cfg *config.Config) (Auction, error) {
+	opts := []Option{WithSoftFloor(cfg.Auction.SoftFloor)}
+	if v := cfg.Auction.Increment; v != nil {
+		opts = append(opts, WithIncrement(*v))
+	}
+	if v := cfg.Auction.BidReduction; v != nil {
+		opts = append(opts, WithBidReduction(*v))
+	}
+	auc, err := New(cfg.Auction.Type, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if policies := DomainPolicies(cfg.DSPs); len(policies) > 0 {
+		auc = NewDomainFilter(auc, policies)
+	}
+	if limits := ConfiguredLimits(cfg.Auction); limits.Enabled() {
+		auc = NewSanityFilter(auc, limits)
+	}
+	return auc, nil
+}
+
+// ConfiguredLimits returns the configured bid sanity limits.
+func ConfiguredLimits(ac config.AuctionConfig) SanityLimits {
+	return SanityLimits{MaxCPM: ac.MaxCPM, MaxFloorRatio: ac.MaxFloorRatio}
+}
+
+// DomainPolicies returns the advertiser domain policies of DSPs that
+// configure one, keyed by DSP name.
+func DomainPolicies(dsps []config.DSPConfig) map[string]DomainPolicy {
+	policies := make(map[string]DomainPolicy)
+	for _, dsp := range dsps {
+		if dsp.ADomains.Enabled() {
+			policies[dsp.Name] = DomainPolicy{Allow: dsp.ADomains.Allow, Block: dsp.ADomains.Block}
+		}
+	}
+	return policies
+}
@@ -0,0 +1,159 @@
+package auction_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/export"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// corpusAuction is one recorded auction ready to re-run.
+type corpusAuction struct {
+	requestID string
+	floor     float64
+	results   []dispatcher.Result
+}
+
+// corpus is a recorded response stream, as written by run -stream
+// -stream-responses, loaded for benchmarking.
+type corpus struct {
+	auctions []corpusAuction
+	pmp      *openrtb.PMP // offers every deal bid on in the corpus
+}
+
+// loadCorpus reads the NDJSON auction records at path.
+func loadCorpus(b *testing.B, path string) corpus {
+	b.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	var c corpus
+	deals := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		var rec export.AuctionRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			b.Fatalf("%s: %v", path, err)
+		}
+		if !rec.Replayable() {
+			b.Fatalf("%s: record %s has no responses", path, rec.RequestID)
+		}
+		results := rec.Results()
+		for _, r := range results {
+			if r.Response == nil {
+				continue
+			}
+			for _, sb := range r.Response.SeatBid {
+				for _, bid := range sb.Bid {
+					if bid.DealID != "" && !deals[bid.DealID] {
+						deals[bid.DealID] = true
+						c.pmp = addDeal(c.pmp, bid.DealID)
+					}
+				}
+			}
+		}
+		c.auctions = append(c.auctions, corpusAuction{requestID: rec.RequestID, floor: rec.BidFloor, results: results})
+	}
+	if err := sc.Err(); err != nil {
+		b.Fatal(err)
+	}
+	if len(c.auctions) == 0 {
+		b.Fatalf("%s: no records", path)
+	}
+	return c
+}
+
+func addDeal(pmp *openrtb.PMP, id string) *openrtb.PMP {
+	if pmp == nil {
+		pmp = &openrtb.PMP{}
+	}
+	pmp.Deals = append(pmp.Deals, openrtb.Deal{ID: id})
+	return pmp
+}
+
+// BenchmarkCorpus runs every clearing rule, deal adjudication, and the
+// bid filters over each recorded corpus in testdata, cycling through its
+// auctions. Compare runs with benchstat to catch hot path regressions.
+func BenchmarkCorpus(b *testing.B) {
+	paths, err := filepath.Glob("testdata/*.ndjson")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(paths) == 0 {
+		b.Fatal("no corpora in testdata")
+	}
+
+	for _, path := range paths {
+		c := loadCorpus(b, path)
+		name := strings.TrimSuffix(filepath.Base(path), ".ndjson")
+		b.Run(name, func(b *testing.B) {
+			for _, rule := range auction.Rules {
+				a, err := auction.New(rule)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.Run(rule, func(b *testing.B) {
+					benchmarkCorpus(b, c, func(ca corpusAuction) auction.Outcome {
+						return a.Run(ca.requestID, ca.floor, ca.results)
+					})
+				})
+			}
+
+			first := auction.NewFirstPrice()
+			if c.pmp != nil {
+				b.Run("deals", func(b *testing.B) {
+					benchmarkCorpus(b, c, func(ca corpusAuction) auction.Outcome {
+						return auction.RunDeals(first, ca.requestID, ca.floor, c.pmp, ca.results)
+					})
+				})
+			}
+
+			filtered := auction.NewDomainFilter(
+				auction.NewSanityFilter(first, auction.SanityLimits{MaxCPM: 50, MaxFloorRatio: 20}),
+				corpusPolicies(c),
+			)
+			b.Run("filters", func(b *testing.B) {
+				benchmarkCorpus(b, c, func(ca corpusAuction) auction.Outcome {
+					return filtered.Run(ca.requestID, ca.floor, ca.results)
+				})
+			})
+		})
+	}
+}
+
+// corpusPolicies gives every DSP in c a block list, so each bid is
+// checked.
+func corpusPolicies(c corpus) map[string]auction.DomainPolicy {
+	policies := make(map[string]auction.DomainPolicy)
+	for _, ca := range c.auctions {
+		for _, r := range ca.results {
+			policies[r.DSPName] = auction.DomainPolicy{Block: []string{"gambling.example", "crypto.example"}}
+		}
+	}
+	return policies
+}
+
+func benchmarkCorpus(b *testing.B, c corpus, run func(corpusAuction) auction.Outcome) {
+	b.ReportAllocs()
+	sold := 0
+	for i := 0; b.Loop(); i++ {
+		if run(c.auctions[i%len(c.auctions)]).Winner != nil {
+			sold++
+		}
+	}
+	if sold == 0 {
+		b.Fatal("no auction in the corpus sold")
+	}
+}
@@ -0,0 +1,66 @@
+package auction
+
+import (
+	"github.com/cass/rtb-simulator/internal/currency"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// ViolationCurrency rejects bids in a currency without an exchange rate.
+const ViolationCurrency = "unsupported_currency"
+
+// ConvertBids returns results with every bid priced in rates' base
+// currency, so bids in different currencies can be compared. Responses
+// without a currency are in currency.Default. Bids in currencies without
+// a rate are removed and returned as rejections. The caller's results
+// are not modified.
+func ConvertBids(results []dispatcher.Result, rates *currency.Table) ([]dispatcher.Result, []Rejection) {
+	var rejected []Rejection
+	converted := results
+	copied := false
+	for i, r := range results {
+		if r.Error != nil || r.Response == nil || r.Response.Cur == rates.Base() ||
+			(r.Response.Cur == "" && rates.Base() == currency.Default) {
+			continue
+		}
+
+		resp, dropped := convertResponse(r.Response, rates)
+		for _, d := range dropped {
+			d.DSPName = r.DSPName
+			rejected = append(rejected, d)
+		}
+		if !copied {
+			converted = append([]dispatcher.Result(nil), results...)
+			copied = true
+		}
+		converted[i].Response = resp
+	}
+	return converted, rejected
+}
+
+// convertResponse returns a copy of resp priced in rates' base currency,
+// or without its bids if resp's currency has no rate.
+func convertResponse(resp *openrtb.BidResponse, rates *currency.Table) (*openrtb.BidResponse, []Rejection) {
+	out := *resp
+	out.Cur = rates.Base()
+	out.SeatBid = make([]openrtb.SeatBid, len(resp.SeatBid))
+
+	var dropped []Rejection
+	for i, sb := range resp.SeatBid {
+		out.SeatBid[i] = sb
+		out.SeatBid[i].Bid = make([]openrtb.Bid, 0, len(sb.Bid))
+		for _, bid := range sb.Bid {
+			price, err := rates.Convert(bid.Price, resp.Cur, rates.Base())
+			if err != nil {
+				dropped = append(dropped, Rejection{
+					BidWithDSP: BidWithDSP{Bid: bid, Seat: sb.Seat, ResponseID: resp.BidID},
+					Reason:     ViolationCurrency,
+				})
+				continue
+			}
+			bid.Price = price
+			out.SeatBid[i].Bid = append(out.SeatBid[i].Bid, bid)
+		}
+	}
+	return &out, dropped
+}
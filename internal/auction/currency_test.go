@@ -0,0 +1,59 @@
+package auction
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/currency"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestConvertBids(t *testing.T) {
+	rates, err := currency.NewTable("USD", map[string]float64{"EUR": 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []dispatcher.Result{
+		{DSPName: "usd", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "a", Price: 2.2}},
+		}}}},
+		{DSPName: "eur", Response: &openrtb.BidResponse{Cur: "EUR", SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "b", Price: 2}},
+		}}}},
+		{DSPName: "jpy", Response: &openrtb.BidResponse{Cur: "JPY", SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "c", Price: 300}},
+		}}}},
+	}
+
+	converted, rejected := ConvertBids(results, rates)
+
+	// 2 EUR is 2.50 USD, beating the 2.20 USD bid
+	outcome := NewFirstPrice().Run("req-1", 0.5, converted)
+	if outcome.Winner == nil || outcome.Winner.ID != "b" || outcome.ClearingPrice != 2.5 {
+		t.Errorf("winner = %+v at %v, want b at 2.5", outcome.Winner, outcome.ClearingPrice)
+	}
+	if converted[1].Response.Cur != "USD" {
+		t.Errorf("converted Cur = %q, want USD", converted[1].Response.Cur)
+	}
+	if len(rejected) != 1 || rejected[0].DSPName != "jpy" || rejected[0].Reason != ViolationCurrency {
+		t.Errorf("rejected = %+v, want the JPY bid", rejected)
+	}
+
+	// The caller's results keep the original prices
+	if results[1].Response.Cur != "EUR" || results[1].Response.SeatBid[0].Bid[0].Price != 2 {
+		t.Errorf("original response = %+v, want it unchanged", results[1].Response)
+	}
+}
+
+func TestConvertBids_Unchanged(t *testing.T) {
+	rates, _ := currency.NewTable("USD", nil)
+	results := []dispatcher.Result{
+		{DSPName: "usd", Response: &openrtb.BidResponse{Cur: "USD", SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "a", Price: 2}},
+		}}}},
+	}
+	converted, rejected := ConvertBids(results, rates)
+	if &converted[0] != &results[0] || rejected != nil {
+		t.Error("ConvertBids copied results already in the base currency")
+	}
+}
@@ -0,0 +1,132 @@
+package auction
+
+import (
+	"slices"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Reasons a bid is rejected by RunDeals.
+const (
+	ViolationUnknownDeal    = "unknown_deal"          // the dealid is not offered in the request
+	ViolationDealSeat       = "deal_seat_not_allowed" // the seat is missing from the deal's wseat
+	ViolationPrivateAuction = "private_auction"       // an open-market bid on a private auction
+	ViolationDealFloor      = "below_deal_floor"      // a deal bid below the deal's floor
+)
+
+// RunDeals runs a on results with the deals of a private marketplace
+// taking priority over the open market. Bids naming a dealid compete
+// first, each against its deal's floor (bidFloor for deals without one);
+// if any clears, the highest wins, pays at least its deal's floor, and
+// the open-market bids are reported in Outcome.Preempted. Otherwise the
+// open market competes against bidFloor, unless the auction is private.
+// Bids on deals the request does not offer, from seats a deal does not
+// allow, or below their deal's floor are rejected. A nil pmp runs a
+// unchanged.
+func RunDeals(a Auction, requestID string, bidFloor float64, pmp *openrtb.PMP, results []dispatcher.Result) Outcome {
+	if pmp == nil || len(pmp.Deals) == 0 {
+		return a.Run(requestID, bidFloor, results)
+	}
+
+	s := splitDeals(results, pmp, bidFloor)
+	if s.dealBids > 0 {
+		outcome := a.Run(requestID, s.dealFloor, s.deal)
+		if outcome.Winner != nil {
+			floor := findDeal(pmp, outcome.Winner.DealID).Floor(bidFloor)
+			outcome.BidFloor = floor
+			outcome.ClearingPrice = max(outcome.ClearingPrice, floor)
+			outcome.Preempted = eligibleBids(s.open, bidFloor)
+			outcome.Rejected = append(outcome.Rejected, s.rejected...)
+			return outcome
+		}
+		// Every deal bid was filtered by a wrapped auction
+		s.rejected = append(s.rejected, outcome.Rejected...)
+	}
+
+	outcome := a.Run(requestID, bidFloor, s.open)
+	outcome.Rejected = append(s.rejected, outcome.Rejected...)
+	return outcome
+}
+
+// dealSplit is an auction's results divided into deal and open-market
+// bids.
+type dealSplit struct {
+	deal, open []dispatcher.Result
+	dealBids   int
+	dealFloor  float64 // lowest floor among the deals bid on at or above it
+	rejected   []Rejection
+}
+
+// splitDeals divides the bids in results into deal and open-market
+// results. Deal bids below their deal's floor are rejected, as are
+// open-market bids on a private auction.
+func splitDeals(results []dispatcher.Result, pmp *openrtb.PMP, bidFloor float64) dealSplit {
+	s := dealSplit{
+		deal: make([]dispatcher.Result, len(results)),
+		open: make([]dispatcher.Result, len(results)),
+	}
+	for i, r := range results {
+		s.deal[i], s.open[i] = r, r
+		if r.Error != nil || r.Response == nil {
+			continue
+		}
+
+		dealResp, openResp := *r.Response, *r.Response
+		dealResp.SeatBid, openResp.SeatBid = nil, nil
+		for _, sb := range r.Response.SeatBid {
+			dealSeat, openSeat := sb, sb
+			dealSeat.Bid, openSeat.Bid = nil, nil
+			for _, bid := range sb.Bid {
+				reject := func(reason string) {
+					s.rejected = append(s.rejected, Rejection{
+						BidWithDSP: BidWithDSP{Bid: bid, DSPName: r.DSPName, Seat: sb.Seat, ResponseID: r.Response.BidID},
+						Reason:     reason,
+					})
+				}
+				if bid.DealID == "" {
+					if pmp.PrivateAuction == 1 {
+						reject(ViolationPrivateAuction)
+						continue
+					}
+					openSeat.Bid = append(openSeat.Bid, bid)
+					continue
+				}
+
+				deal := findDeal(pmp, bid.DealID)
+				switch {
+				case deal == nil:
+					reject(ViolationUnknownDeal)
+				case len(deal.WSeat) > 0 && !slices.Contains(deal.WSeat, sb.Seat):
+					reject(ViolationDealSeat)
+				case bid.Price < deal.Floor(bidFloor):
+					reject(ViolationDealFloor)
+				default:
+					if s.dealBids == 0 || deal.Floor(bidFloor) < s.dealFloor {
+						s.dealFloor = deal.Floor(bidFloor)
+					}
+					s.dealBids++
+					dealSeat.Bid = append(dealSeat.Bid, bid)
+				}
+			}
+			if len(dealSeat.Bid) > 0 {
+				dealResp.SeatBid = append(dealResp.SeatBid, dealSeat)
+			}
+			if len(openSeat.Bid) > 0 {
+				openResp.SeatBid = append(openResp.SeatBid, openSeat)
+			}
+		}
+		s.deal[i].Response, s.open[i].Response = &dealResp, &openResp
+	}
+	return s
+}
+
+// findDeal returns the deal with id in pmp, or nil.
+func findDeal(pmp *openrtb.PMP, id string) *openrtb.Deal {
+	for i := range pmp.Deals {
+		if pmp.Deals[i].ID == id {
+			return &pmp.Deals[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package auction
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// seatResult returns a result with bids from a single seat.
+func seatResult(dsp, seat string, bids ...openrtb.Bid) dispatcher.Result {
+	return dispatcher.Result{DSPName: dsp, Response: &openrtb.BidResponse{
+		SeatBid: []openrtb.SeatBid{{Seat: seat, Bid: bids}},
+	}}
+}
+
+func TestRunDeals(t *testing.T) {
+	pmp := &openrtb.PMP{Deals: []openrtb.Deal{
+		{ID: "gold", BidFloor: 4, WSeat: []string{"s1", "s2"}},
+		{ID: "silver", BidFloor: 2},
+	}}
+
+	tests := []struct {
+		name          string
+		auc           Auction
+		pmp           *openrtb.PMP
+		results       []dispatcher.Result
+		wantWinner    string // bid ID, "" for no winner
+		wantPrice     float64
+		wantFloor     float64
+		wantPreempted int
+		wantRejected  []string
+	}{
+		{
+			name: "deal bid beats higher open bid",
+			auc:  NewFirstPrice(),
+			pmp:  pmp,
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "deal", Price: 4.5, DealID: "gold"}),
+				seatResult("dsp2", "s2", openrtb.Bid{ID: "open", Price: 9}),
+			},
+			wantWinner: "deal", wantPrice: 4.5, wantFloor: 4, wantPreempted: 1,
+		},
+		{
+			name: "deal winner pays at least its floor",
+			auc:  mustNew(t, RuleSecondPrice),
+			pmp:  pmp,
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "gold", Price: 5, DealID: "gold"}),
+				seatResult("dsp2", "s2", openrtb.Bid{ID: "silver", Price: 3, DealID: "silver"}),
+			},
+			wantWinner: "gold", wantPrice: 4, wantFloor: 4,
+		},
+		{
+			name: "deal bid below its floor is rejected and falls back to open market",
+			auc:  NewFirstPrice(),
+			pmp:  pmp,
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "deal", Price: 3, DealID: "gold"}),
+				seatResult("dsp2", "s2", openrtb.Bid{ID: "open", Price: 1}),
+			},
+			wantWinner: "open", wantPrice: 1, wantFloor: 0.5,
+			wantRejected: []string{ViolationDealFloor},
+		},
+		{
+			name: "unknown deal and disallowed seat are rejected",
+			auc:  NewFirstPrice(),
+			pmp:  pmp,
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "bogus", Price: 8, DealID: "platinum"}),
+				seatResult("dsp2", "s3", openrtb.Bid{ID: "seat", Price: 8, DealID: "gold"}),
+				seatResult("dsp3", "s3", openrtb.Bid{ID: "open", Price: 1}),
+			},
+			wantWinner: "open", wantPrice: 1, wantFloor: 0.5,
+			wantRejected: []string{ViolationUnknownDeal, ViolationDealSeat},
+		},
+		{
+			name: "private auction rejects open bids",
+			auc:  NewFirstPrice(),
+			pmp:  &openrtb.PMP{PrivateAuction: 1, Deals: pmp.Deals},
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "open", Price: 9}),
+			},
+			wantFloor:    0.5,
+			wantRejected: []string{ViolationPrivateAuction},
+		},
+		{
+			name: "no pmp runs the open auction",
+			auc:  NewFirstPrice(),
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "open", Price: 9}),
+			},
+			wantWinner: "open", wantPrice: 9, wantFloor: 0.5,
+		},
+		{
+			name: "deal bids filtered by a wrapped auction fall back to open market",
+			auc: NewDomainFilter(NewFirstPrice(), map[string]DomainPolicy{
+				"dsp1": {Block: []string{"casino.example"}},
+			}),
+			pmp: pmp,
+			results: []dispatcher.Result{
+				seatResult("dsp1", "s1", openrtb.Bid{ID: "deal", Price: 5, DealID: "gold", ADomain: []string{"casino.example"}}),
+				seatResult("dsp2", "s2", openrtb.Bid{ID: "open", Price: 1}),
+			},
+			wantWinner: "open", wantPrice: 1, wantFloor: 0.5,
+			wantRejected: []string{ViolationBlocked},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := RunDeals(tt.auc, "req-1", 0.5, tt.pmp, tt.results)
+
+			var winner string
+			if outcome.Winner != nil {
+				winner = outcome.Winner.ID
+			}
+			if winner != tt.wantWinner {
+				t.Fatalf("winner = %q, want %q", winner, tt.wantWinner)
+			}
+			if winner != "" && outcome.ClearingPrice != tt.wantPrice {
+				t.Errorf("ClearingPrice = %v, want %v", outcome.ClearingPrice, tt.wantPrice)
+			}
+			if outcome.BidFloor != tt.wantFloor {
+				t.Errorf("BidFloor = %v, want %v", outcome.BidFloor, tt.wantFloor)
+			}
+			if len(outcome.Preempted) != tt.wantPreempted {
+				t.Errorf("len(Preempted) = %d, want %d", len(outcome.Preempted), tt.wantPreempted)
+			}
+			if len(outcome.Rejected) != len(tt.wantRejected) {
+				t.Fatalf("Rejected = %+v, want reasons %v", outcome.Rejected, tt.wantRejected)
+			}
+			for i, r := range outcome.Rejected {
+				if r.Reason != tt.wantRejected[i] {
+					t.Errorf("Rejected[%d].Reason = %q, want %q", i, r.Reason, tt.wantRejected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunDeals_LeavesResultsIntact(t *testing.T) {
+	results := []dispatcher.Result{seatResult("dsp1", "s1",
+		openrtb.Bid{ID: "deal", Price: 5, DealID: "gold"},
+		openrtb.Bid{ID: "open", Price: 6},
+	)}
+	RunDeals(NewFirstPrice(), "req-1", 0.5, &openrtb.PMP{Deals: []openrtb.Deal{{ID: "gold"}}}, results)
+
+	if bids := results[0].Response.SeatBid[0].Bid; len(bids) != 2 {
+		t.Errorf("caller's bids = %+v, want both bids kept", bids)
+	}
+}
+
+func mustNew(t *testing.T, rule string) Auction {
+	t.Helper()
+	auc, err := New(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auc
+}
@@ -0,0 +1,92 @@
+package auction
+
+import (
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Reasons a bid is rejected by SanityLimits. Both count as insane bids;
+// see IsInsane.
+const (
+	ViolationInsaneCPM        = "insane_cpm"         // price above MaxCPM
+	ViolationInsaneFloorRatio = "insane_floor_ratio" // price more than MaxFloorRatio times the floor
+)
+
+// SanityLimits disqualify absurd bids, such as a $10,000 CPM from a buggy
+// bidder, before they win and pollute revenue stats. Zero disables a
+// limit.
+type SanityLimits struct {
+	MaxCPM        float64 // highest believable price
+	MaxFloorRatio float64 // highest believable multiple of the bid floor
+}
+
+// Enabled reports whether any limit is set.
+func (l SanityLimits) Enabled() bool {
+	return l.MaxCPM > 0 || l.MaxFloorRatio > 0
+}
+
+// Check returns the reason a bid at price against floor is insane, or ""
+// if it is within the limits. The floor ratio is not checked without a
+// floor.
+func (l SanityLimits) Check(price, floor float64) string {
+	if l.MaxCPM > 0 && price > l.MaxCPM {
+		return ViolationInsaneCPM
+	}
+	if l.MaxFloorRatio > 0 && floor > 0 && price > floor*l.MaxFloorRatio {
+		return ViolationInsaneFloorRatio
+	}
+	return ""
+}
+
+// IsInsane reports whether a rejection reason is one of SanityLimits'.
+func IsInsane(reason string) bool {
+	return reason == ViolationInsaneCPM || reason == ViolationInsaneFloorRatio
+}
+
+// SanityFilter removes bids outside SanityLimits before the wrapped
+// auction runs and adds them to Outcome.Rejected. Wrapped by RunDeals,
+// deal bids are checked against their deal floor.
+type SanityFilter struct {
+	next   Auction
+	limits SanityLimits
+}
+
+// NewSanityFilter wraps next with limits.
+func NewSanityFilter(next Auction, limits SanityLimits) *SanityFilter {
+	return &SanityFilter{next: next, limits: limits}
+}
+
+// Run filters the results and executes the wrapped auction on the rest.
+func (f *SanityFilter) Run(requestID string, bidFloor float64, results []dispatcher.Result) Outcome {
+	var rejected []Rejection
+	filtered := results
+	copied := false
+	for i, r := range results {
+		if r.Error != nil || r.Response == nil {
+			continue
+		}
+
+		resp, dropped := filterResponse(r.Response, func(_ string, bid openrtb.Bid) string {
+			return f.limits.Check(bid.Price, bidFloor)
+		})
+		if len(dropped) == 0 {
+			continue
+		}
+		for _, d := range dropped {
+			d.DSPName = r.DSPName
+			rejected = append(rejected, d)
+		}
+		// Copy on first change so the caller's results stay intact
+		if !copied {
+			filtered = append([]dispatcher.Result(nil), results...)
+			copied = true
+		}
+		filtered[i].Response = resp
+	}
+
+	outcome := f.next.Run(requestID, bidFloor, filtered)
+	if len(rejected) > 0 {
+		outcome.Rejected = append(rejected, outcome.Rejected...)
+	}
+	return outcome
+}
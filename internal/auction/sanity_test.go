@@ -0,0 +1,77 @@
+package auction
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestSanityLimits_Check(t *testing.T) {
+	limits := SanityLimits{MaxCPM: 100, MaxFloorRatio: 20}
+
+	tests := []struct {
+		price, floor float64
+		want         string
+	}{
+		{5, 1, ""},
+		{100, 10, ""},
+		{10000, 1, ViolationInsaneCPM},
+		{21, 1, ViolationInsaneFloorRatio},
+		{50, 0, ""}, // no floor to compare against
+	}
+	for _, tt := range tests {
+		if got := limits.Check(tt.price, tt.floor); got != tt.want {
+			t.Errorf("Check(%v, %v) = %q, want %q", tt.price, tt.floor, got, tt.want)
+		}
+	}
+
+	if (SanityLimits{}).Enabled() {
+		t.Error("zero limits Enabled() = true, want false")
+	}
+	if got := (SanityLimits{}).Check(1e9, 0.01); got != "" {
+		t.Errorf("zero limits Check() = %q, want no limit", got)
+	}
+}
+
+func TestSanityFilter_Run(t *testing.T) {
+	results := []dispatcher.Result{
+		{DSPName: "buggy", Response: &openrtb.BidResponse{BidID: "r1", SeatBid: []openrtb.SeatBid{{
+			Seat: "s1",
+			Bid:  []openrtb.Bid{{ID: "a", Price: 10000}, {ID: "b", Price: 2}},
+		}}}},
+		{DSPName: "sane", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "c", Price: 3}},
+		}}}},
+	}
+
+	auc := NewSanityFilter(NewFirstPrice(), SanityLimits{MaxCPM: 500})
+	outcome := auc.Run("req-1", 0.5, results)
+
+	if outcome.Winner == nil || outcome.Winner.ID != "c" {
+		t.Fatalf("winner = %+v, want bid c once the $10,000 bid is disqualified", outcome.Winner)
+	}
+	if len(outcome.Rejected) != 1 {
+		t.Fatalf("len(Rejected) = %d, want 1", len(outcome.Rejected))
+	}
+	rej := outcome.Rejected[0]
+	if rej.DSPName != "buggy" || rej.Bid.ID != "a" || rej.Reason != ViolationInsaneCPM || !IsInsane(rej.Reason) {
+		t.Errorf("Rejected[0] = %+v, want insane bid a from buggy", rej)
+	}
+	if n := len(results[0].Response.SeatBid[0].Bid); n != 2 {
+		t.Errorf("original buggy bids = %d, want 2", n)
+	}
+}
+
+func TestSanityFilter_DealFloor(t *testing.T) {
+	// Against a $10 deal floor a $150 bid is within 20x; against the $1
+	// open floor it would not be.
+	pmp := &openrtb.PMP{Deals: []openrtb.Deal{{ID: "deal-1", BidFloor: 10}}}
+	results := []dispatcher.Result{seatResult("dsp1", "", openrtb.Bid{ID: "a", Price: 150, DealID: "deal-1"})}
+
+	auc := NewSanityFilter(NewFirstPrice(), SanityLimits{MaxFloorRatio: 20})
+	outcome := RunDeals(auc, "req-1", 1, pmp, results)
+	if outcome.Winner == nil || len(outcome.Rejected) != 0 {
+		t.Errorf("winner = %+v, rejected = %+v; want the deal bid to win", outcome.Winner, outcome.Rejected)
+	}
+}
@@ -0,0 +1,133 @@
+package auction
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Reasons a bid is rejected by ValidateBids. All count as invalid bids;
+// see IsInvalid.
+const (
+	ViolationUnknownImp       = "unknown_impid"      // impid names no impression in the request
+	ViolationNonPositivePrice = "non_positive_price" // price is zero or negative
+	ViolationMissingCreative  = "missing_creative"   // no crid, or neither adm nor nurl to fetch markup from
+	ViolationSizeMismatch     = "size_mismatch"      // banner creative size does not fit the impression
+	ViolationMissingSeat      = "missing_seat"       // the seatbid names no seat
+	ViolationInsecureCreative = "insecure_creative"  // secure impression with markup loaded over http://
+)
+
+// IsInvalid reports whether a rejection reason is one of ValidateBids'.
+func IsInvalid(reason string) bool {
+	switch reason {
+	case ViolationUnknownImp, ViolationNonPositivePrice, ViolationMissingCreative,
+		ViolationSizeMismatch, ViolationMissingSeat, ViolationInsecureCreative:
+		return true
+	}
+	return false
+}
+
+// ValidateBids checks every bid in results against req and returns
+// results without the malformed ones, which are returned as rejections.
+// The caller's results are not modified.
+func ValidateBids(req *openrtb.BidRequest, results []dispatcher.Result) ([]dispatcher.Result, []Rejection) {
+	var rejected []Rejection
+	valid := results
+	copied := false
+	for i, r := range results {
+		if r.Error != nil || r.Response == nil {
+			continue
+		}
+
+		resp, dropped := filterResponse(r.Response, func(seat string, bid openrtb.Bid) string {
+			return validateBid(req, seat, bid)
+		})
+		if len(dropped) == 0 {
+			continue
+		}
+		for _, d := range dropped {
+			d.DSPName = r.DSPName
+			rejected = append(rejected, d)
+		}
+		// Copy on first change so the caller's results stay intact
+		if !copied {
+			valid = append([]dispatcher.Result(nil), results...)
+			copied = true
+		}
+		valid[i].Response = resp
+	}
+	return valid, rejected
+}
+
+// validateBid returns the reason bid, made by seat, is malformed, or "".
+func validateBid(req *openrtb.BidRequest, seat string, bid openrtb.Bid) string {
+	imp := findImp(req, bid.ImpID)
+	switch {
+	case imp == nil:
+		return ViolationUnknownImp
+	case bid.Price <= 0:
+		return ViolationNonPositivePrice
+	case bid.CrID == "" || (bid.AdM == "" && bid.NURL == ""):
+		return ViolationMissingCreative
+	case seat == "":
+		return ViolationMissingSeat
+	case isBannerBid(imp, bid) && !bannerFits(imp.Banner, bid.W, bid.H):
+		return ViolationSizeMismatch
+	case imp.Secure == 1 && !secureCreative(bid):
+		return ViolationInsecureCreative
+	}
+	return ""
+}
+
+// insecureResource matches a resource the markup loads over http://: a
+// src or href attribute, or a VAST MediaFile. Other URLs, such as XML
+// namespaces, are never fetched by the browser or player.
+var insecureResource = regexp.MustCompile(`(?i)(\b(src|href)\s*=\s*["']?|<MediaFile\b[^>]*>\s*(<!\[CDATA\[)?)\s*http://`)
+
+// secureCreative reports whether bid's creative loads only over HTTPS:
+// its markup loads no resource from an http:// URL or, without markup,
+// the nurl the markup is fetched from is not http://. Notice URLs are
+// called by the exchange, not the browser, so they are not checked
+// otherwise.
+func secureCreative(bid openrtb.Bid) bool {
+	if bid.AdM == "" {
+		return !strings.HasPrefix(strings.ToLower(bid.NURL), "http://")
+	}
+	return !insecureResource.MatchString(bid.AdM)
+}
+
+func findImp(req *openrtb.BidRequest, id string) *openrtb.Imp {
+	for i := range req.Imp {
+		if req.Imp[i].ID == id {
+			return &req.Imp[i]
+		}
+	}
+	return nil
+}
+
+// isBannerBid reports whether bid is for imp's banner, by its markup type
+// or, without one, because the impression offers nothing else.
+func isBannerBid(imp *openrtb.Imp, bid openrtb.Bid) bool {
+	if imp.Banner == nil {
+		return false
+	}
+	return bid.MType == openrtb.MTypeBanner || (bid.MType == 0 && imp.Video == nil)
+}
+
+// bannerFits reports whether a w x h creative fits b: its exact size if
+// set, otherwise its min/max bounds. A creative without a size takes the
+// slot's.
+func bannerFits(b *openrtb.Banner, w, h int) bool {
+	if w == 0 && h == 0 {
+		return true
+	}
+	if b.W > 0 && b.H > 0 {
+		return w == b.W && h == b.H
+	}
+	within := func(v, lo, hi int) bool {
+		return v >= lo && (hi == 0 || v <= hi)
+	}
+	return within(w, b.Wmin, b.Wmax) && within(h, b.Hmin, b.Hmax)
+}
@@ -0,0 +1,98 @@
+package auction
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestValidateBid(t *testing.T) {
+	req := &openrtb.BidRequest{Imp: []openrtb.Imp{
+		{ID: "banner", Banner: &openrtb.Banner{W: 300, H: 250}},
+		{ID: "flex", Banner: &openrtb.Banner{Wmin: 300, Wmax: 728, Hmin: 50, Hmax: 250}},
+		{ID: "video", Video: &openrtb.Video{W: 640, H: 480}},
+		{ID: "secure", Banner: &openrtb.Banner{W: 300, H: 250}, Secure: 1},
+	}}
+	valid := openrtb.Bid{ID: "b", ImpID: "banner", Price: 1, CrID: "cr", AdM: "<div/>", W: 300, H: 250}
+	with := func(f func(*openrtb.Bid)) openrtb.Bid {
+		b := valid
+		f(&b)
+		return b
+	}
+
+	tests := []struct {
+		name string
+		seat string
+		bid  openrtb.Bid
+		want string
+	}{
+		{"valid", "s1", valid, ""},
+		{"unknown impid", "s1", with(func(b *openrtb.Bid) { b.ImpID = "nope" }), ViolationUnknownImp},
+		{"zero price", "s1", with(func(b *openrtb.Bid) { b.Price = 0 }), ViolationNonPositivePrice},
+		{"negative price", "s1", with(func(b *openrtb.Bid) { b.Price = -1 }), ViolationNonPositivePrice},
+		{"no crid", "s1", with(func(b *openrtb.Bid) { b.CrID = "" }), ViolationMissingCreative},
+		{"no markup", "s1", with(func(b *openrtb.Bid) { b.AdM = "" }), ViolationMissingCreative},
+		{"markup by nurl", "s1", with(func(b *openrtb.Bid) { b.AdM, b.NURL = "", "http://dsp/win" }), ""},
+		{"no seat", "", valid, ViolationMissingSeat},
+		{"wrong size", "s1", with(func(b *openrtb.Bid) { b.W, b.H = 728, 90 }), ViolationSizeMismatch},
+		{"no size", "s1", with(func(b *openrtb.Bid) { b.W, b.H = 0, 0 }), ""},
+		{"within bounds", "s1", with(func(b *openrtb.Bid) { b.ImpID, b.W, b.H = "flex", 728, 90 }), ""},
+		{"out of bounds", "s1", with(func(b *openrtb.Bid) { b.ImpID, b.W, b.H = "flex", 970, 250 }), ViolationSizeMismatch},
+		{"video not size checked", "s1", with(func(b *openrtb.Bid) { b.ImpID, b.W, b.H = "video", 1, 1 }), ""},
+		{"secure https markup", "s1", with(func(b *openrtb.Bid) {
+			b.ImpID, b.AdM, b.NURL = "secure", `<img src="https://cdn/ad.png">`, "http://dsp/win"
+		}), ""},
+		{"secure http markup", "s1", with(func(b *openrtb.Bid) { b.ImpID, b.AdM = "secure", `<img src="HTTP://cdn/ad.png">` }), ViolationInsecureCreative},
+		{"secure markup by http nurl", "s1", with(func(b *openrtb.Bid) { b.ImpID, b.AdM, b.NURL = "secure", "", "http://dsp/adm" }), ViolationInsecureCreative},
+		{"secure markup with http namespace", "s1", with(func(b *openrtb.Bid) {
+			b.ImpID, b.AdM = "secure", `<VAST xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><MediaFile type="video/mp4"><![CDATA[https://cdn/ad.mp4]]></MediaFile></VAST>`
+		}), ""},
+		{"secure http media file", "s1", with(func(b *openrtb.Bid) {
+			b.ImpID, b.AdM = "secure", `<VAST><MediaFile type="video/mp4"> <![CDATA[http://cdn/ad.mp4]]></MediaFile></VAST>`
+		}), ViolationInsecureCreative},
+		{"secure http stylesheet", "s1", with(func(b *openrtb.Bid) { b.ImpID, b.AdM = "secure", `<link rel=stylesheet href=http://cdn/ad.css>` }), ViolationInsecureCreative},
+		{"non-secure http markup", "s1", with(func(b *openrtb.Bid) { b.AdM = `<img src="http://cdn/ad.png">` }), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateBid(req, tt.seat, tt.bid)
+			if got != tt.want {
+				t.Errorf("validateBid() = %q, want %q", got, tt.want)
+			}
+			if got != "" && !IsInvalid(got) {
+				t.Errorf("IsInvalid(%q) = false", got)
+			}
+		})
+	}
+}
+
+func TestValidateBids(t *testing.T) {
+	req := &openrtb.BidRequest{Imp: []openrtb.Imp{{ID: "imp-1", Banner: &openrtb.Banner{W: 300, H: 250}}}}
+	good := openrtb.Bid{ID: "a", ImpID: "imp-1", Price: 2, CrID: "cr", AdM: "<div/>"}
+	bad := openrtb.Bid{ID: "b", ImpID: "imp-9", Price: 5, CrID: "cr", AdM: "<div/>"}
+	results := []dispatcher.Result{
+		seatResult("dsp1", "s1", good, bad),
+		seatResult("dsp2", "s2", good),
+		{DSPName: "down", Error: errors.New("timeout")},
+	}
+
+	valid, rejected := ValidateBids(req, results)
+
+	if len(rejected) != 1 || rejected[0].DSPName != "dsp1" || rejected[0].Bid.ID != "b" || rejected[0].Reason != ViolationUnknownImp {
+		t.Fatalf("rejected = %+v, want bid b from dsp1 for its impid", rejected)
+	}
+	if n := len(valid[0].Response.SeatBid[0].Bid); n != 1 {
+		t.Errorf("dsp1 bids after validation = %d, want 1", n)
+	}
+	if valid[1].Response != results[1].Response {
+		t.Error("valid response was copied, want it passed through")
+	}
+	if n := len(results[0].Response.SeatBid[0].Bid); n != 2 {
+		t.Errorf("original dsp1 bids = %d, want 2", n)
+	}
+	if IsInvalid(ViolationBlocked) {
+		t.Error("IsInvalid(blocked_adomain) = true, want false")
+	}
+}
@@ -0,0 +1,90 @@
+// Package clock provides the simulation's time source. Modules that
+// measure simulated time take a Clock instead of calling time.Now, so
+// tests can drive them with a Manual clock and a Scaled clock can run a
+// simulated day in an hour.
+package clock
+
+import "time"
+
+// Clock tells simulated time.
+type Clock interface {
+	// Now returns the current simulated time.
+	Now() time.Time
+	// Since returns the simulated time elapsed since t.
+	Since(t time.Time) time.Duration
+	// NewTimer returns a timer that fires once d of simulated time has
+	// passed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer, like time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the wall clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) NewTimer(d time.Duration) Timer  { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// Scaled runs simulated time factor times as fast as the wall clock,
+// starting from the wall time it was created at. Safe for concurrent use.
+type Scaled struct {
+	factor float64
+	origin time.Time
+	now    func() time.Time // wall clock
+}
+
+// NewScaled returns a clock that runs factor times as fast as the wall
+// clock: 24 passes a simulated day every hour, 0.5 runs at half speed.
+// factor must be positive.
+func NewScaled(factor float64) *Scaled {
+	return newScaled(factor, time.Now)
+}
+
+func newScaled(factor float64, now func() time.Time) *Scaled {
+	return &Scaled{factor: factor, origin: now(), now: now}
+}
+
+// Factor returns how many simulated seconds pass per wall-clock second.
+func (c *Scaled) Factor() float64 {
+	return c.factor
+}
+
+func (c *Scaled) Now() time.Time {
+	elapsed := c.now().Sub(c.origin)
+	return c.origin.Add(time.Duration(float64(elapsed) * c.factor))
+}
+
+func (c *Scaled) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *Scaled) NewTimer(d time.Duration) Timer {
+	return scaledTimer{realTimer{time.NewTimer(c.Wall(d))}, c}
+}
+
+// Wall returns how long simulated duration d takes to pass on the wall
+// clock.
+func (c *Scaled) Wall(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.factor)
+}
+
+type scaledTimer struct {
+	realTimer
+	c *Scaled
+}
+
+func (t scaledTimer) Reset(d time.Duration) bool { return t.t.Reset(t.c.Wall(d)) }
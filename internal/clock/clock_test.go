@@ -0,0 +1,43 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScaled(t *testing.T) {
+	wall := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newScaled(24, func() time.Time { return wall })
+
+	if got := c.Now(); !got.Equal(wall) {
+		t.Errorf("Now() at start = %v, want %v", got, wall)
+	}
+
+	wall = wall.Add(time.Hour)
+	if got, want := c.Now(), wall.Add(23*time.Hour); !got.Equal(want) {
+		t.Errorf("Now() after an hour = %v, want %v", got, want)
+	}
+	if got := c.Wall(24 * time.Hour); got != time.Hour {
+		t.Errorf("Wall(24h) = %v, want 1h", got)
+	}
+}
+
+func TestScaled_SlowMotion(t *testing.T) {
+	wall := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := wall
+	c := newScaled(0.5, func() time.Time { return wall })
+
+	wall = wall.Add(time.Minute)
+	if got := c.Now().Sub(start); got != 30*time.Second {
+		t.Errorf("simulated elapsed = %v, want 30s", got)
+	}
+	if got := c.Wall(time.Second); got != 2*time.Second {
+		t.Errorf("Wall(1s) = %v, want 2s", got)
+	}
+}
+
+func TestReal(t *testing.T) {
+	if d := time.Since(Real.Now()); d < 0 || d > time.Second {
+		t.Errorf("Real.Now() is %v off the wall clock", d)
+	}
+}
@@ -0,0 +1,107 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Manual is a clock that only moves when told to, for deterministic
+// tests. Timers fire during Advance or Set once their time is reached.
+// Safe for concurrent use.
+type Manual struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// NewManual returns a clock stopped at start.
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Manual) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+func (m *Manual) NewTimer(d time.Duration) Timer {
+	t := &manualTimer{m: m, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d.
+func (m *Manual) Advance(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set moves the clock to now, firing the timers due by then.
+func (m *Manual) Set(now time.Time) {
+	m.mu.Lock()
+	m.now = now
+	pending := m.timers[:0]
+	var due []*manualTimer
+	for _, t := range m.timers {
+		if !t.when.After(now) {
+			due = append(due, t)
+		} else {
+			pending = append(pending, t)
+		}
+	}
+	m.timers = pending
+	m.mu.Unlock()
+
+	for _, t := range due {
+		select {
+		case t.c <- now:
+		default:
+		}
+	}
+}
+
+// Timers returns the number of timers waiting to fire, so tests can wait
+// for the code under test to set one before advancing.
+func (m *Manual) Timers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.timers)
+}
+
+type manualTimer struct {
+	m    *Manual
+	c    chan time.Time
+	when time.Time
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() bool {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	for i, other := range t.m.timers {
+		if other == t {
+			t.m.timers = append(t.m.timers[:i], t.m.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *manualTimer) Reset(d time.Duration) bool {
+	active := t.Stop()
+	t.m.mu.Lock()
+	t.when = t.m.now.Add(d)
+	t.m.timers = append(t.m.timers, t)
+	t.m.mu.Unlock()
+	if d <= 0 {
+		t.m.Set(t.m.Now())
+	}
+	return active
+}
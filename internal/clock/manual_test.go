@@ -0,0 +1,66 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManual_Timer(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewManual(start)
+	timer := m.NewTimer(time.Minute)
+
+	m.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	m.Advance(time.Second)
+	select {
+	case got := <-timer.C():
+		if want := start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("fired at %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("timer did not fire when due")
+	}
+	if m.Timers() != 0 {
+		t.Errorf("Timers() = %d after firing, want 0", m.Timers())
+	}
+}
+
+func TestManual_StopAndReset(t *testing.T) {
+	m := NewManual(time.Now())
+	timer := m.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Error("Stop() = false for a pending timer")
+	}
+	m.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset() = true for a stopped timer")
+	}
+	m.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("reset timer did not fire")
+	}
+}
+
+func TestManual_Since(t *testing.T) {
+	start := time.Now()
+	m := NewManual(start)
+	m.Advance(90 * time.Second)
+	if got := m.Since(start); got != 90*time.Second {
+		t.Errorf("Since(start) = %v, want 1m30s", got)
+	}
+}
@@ -3,38 +3,1161 @@
 package config
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/cass/rtb-simulator/internal/currency"
+	"github.com/cass/rtb-simulator/internal/fuzz"
+	"github.com/cass/rtb-simulator/internal/generator/scenarios"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
 type Config struct {
-	Server     ServerConfig     `yaml:"server"`
-	Simulation SimulationConfig `yaml:"simulation"`
-	Auction    AuctionConfig    `yaml:"auction"`
-	DSPs       []DSPConfig      `yaml:"dsps"`
+	Server         ServerConfig         `yaml:"server"`
+	Simulation     SimulationConfig     `yaml:"simulation"`
+	Simulations    []NamedSimulation    `yaml:"simulations"`
+	Auction        AuctionConfig        `yaml:"auction"`
+	DSPs           []DSPConfig          `yaml:"dsps"`
+	Market         MarketConfig         `yaml:"market"`
+	State          StateConfig          `yaml:"state"`
+	Notifications  NotificationConfig   `yaml:"notifications"`
+	BidLog         BidLogConfig         `yaml:"bid_log"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Retries        RetryConfig          `yaml:"retries"`
+	HealthChecks   HealthCheckConfig    `yaml:"health_checks"`
+	CreativeQA     CreativeQAConfig     `yaml:"creative_qa"`
+	ResultSink     ResultSinkConfig     `yaml:"result_sink"`
+	Rollups        RollupConfig         `yaml:"rollups"`
+	Kafka          KafkaConfig          `yaml:"kafka"`
+	Currency       CurrencyConfig       `yaml:"currency"`
+	Report         ReportConfig         `yaml:"report"`
+	Anomalies      AnomalyConfig        `yaml:"anomalies"`
+	Shutdown       ShutdownConfig       `yaml:"shutdown"`
+	Debug          DebugConfig          `yaml:"debug"`
+
+	// Sources records where each setting came from, keyed by its dotted
+	// YAML path; settings missing from it are defaults. See Settings.
+	Sources map[string]Source `yaml:"-" json:"-"`
+}
+
+// CurrencyConfig converts bids made in other currencies to Base before
+// each auction. Rates gives the units of each currency one unit of Base
+// buys; URL, if set, serves rates as {"base": ..., "rates": {...}} and
+// replaces them every Refresh. Floors, clearing prices, and revenue are
+// in Base.
+type CurrencyConfig struct {
+	Base    string             `yaml:"base"`
+	Rates   map[string]float64 `yaml:"rates"`
+	URL     string             `yaml:"url"`
+	Refresh time.Duration      `yaml:"refresh"`
+}
+
+// Enabled reports whether bids are converted.
+func (c CurrencyConfig) Enabled() bool {
+	return len(c.Rates) > 0 || c.URL != ""
+}
+
+// BaseCurrency returns the currency of bid floors and clearing prices:
+// Base, or USD when unset.
+func (c CurrencyConfig) BaseCurrency() string {
+	return cmp.Or(c.Base, currency.Default)
+}
+
+func (c CurrencyConfig) validate() error {
+	if c.Base != "" && !currency.ValidCode(c.Base) {
+		return fmt.Errorf("base %q is not a three-letter currency code", c.Base)
+	}
+	for code, rate := range c.Rates {
+		if !currency.ValidCode(code) {
+			return fmt.Errorf("rates: %q is not a three-letter currency code", code)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("rates[%s] must be positive", code)
+		}
+	}
+	if c.Refresh < 0 {
+		return errors.New("refresh must not be negative")
+	}
+	return nil
+}
+
+// CircuitBreakerConfig makes the dispatcher skip a DSP for Cooldown after
+// ErrorThreshold consecutive errors or timeouts, so a dead DSP doesn't drag
+// every auction to the timeout. A zero ErrorThreshold disables it.
+type CircuitBreakerConfig struct {
+	ErrorThreshold int           `yaml:"error_threshold"`
+	Cooldown       time.Duration `yaml:"cooldown"`
+}
+
+// RetryConfig retries a DSP call up to Max times when it fails in one of
+// the ways listed in On: RetryConnRefused, RetryConnReset, or an HTTP
+// status such as "503" (default: all three). Retries wait Backoff
+// (default 10ms) before the first and double it for each one after, and
+// share the call's timeout, so one that would not fit in it is not made.
+// Timeouts are never retried. A zero Max disables retries.
+type RetryConfig struct {
+	Max     int           `yaml:"max"`
+	Backoff time.Duration `yaml:"backoff"`
+	On      []string      `yaml:"retry_on"`
+}
+
+// Failures accepted in RetryConfig.On besides HTTP statuses.
+const (
+	RetryConnRefused = "connection_refused"
+	RetryConnReset   = "connection_reset" // reset or closed before a response
+)
+
+// Enabled reports whether DSP calls are retried.
+func (r RetryConfig) Enabled() bool {
+	return r.Max > 0
+}
+
+// Statuses returns the HTTP statuses listed in On.
+func (r RetryConfig) Statuses() []int {
+	var codes []int
+	for _, on := range r.On {
+		if code, err := strconv.Atoi(on); err == nil {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+func (r RetryConfig) validate() error {
+	if r.Max < 0 || r.Backoff < 0 {
+		return errors.New("max and backoff must not be negative")
+	}
+	for _, on := range r.On {
+		if on == RetryConnRefused || on == RetryConnReset {
+			continue
+		}
+		if code, err := strconv.Atoi(on); err != nil || code < 400 || code > 599 {
+			return fmt.Errorf("retry_on: unknown failure %q (want %s, %s, or an HTTP status from 400 to 599)", on, RetryConnRefused, RetryConnReset)
+		}
+	}
+	return nil
+}
+
+// HealthCheckConfig probes every enabled DSP with a GET of Path (default
+// /health) on its endpoint's host every Interval, each probe giving up
+// after Timeout (default 2s). A DSP turns unhealthy after UnhealthyAfter
+// (default 3) consecutive probes fail or answer other than 2xx, and
+// healthy again on its next success. With AutoDisable, unhealthy DSPs are
+// skipped until then. A zero Interval disables health checks.
+type HealthCheckConfig struct {
+	Path           string        `yaml:"path"`
+	Interval       time.Duration `yaml:"interval"`
+	Timeout        time.Duration `yaml:"timeout"`
+	UnhealthyAfter int           `yaml:"unhealthy_after"`
+	AutoDisable    bool          `yaml:"auto_disable"`
+}
+
+// Enabled reports whether DSPs are health checked.
+func (h HealthCheckConfig) Enabled() bool {
+	return h.Interval > 0
+}
+
+// ShutdownConfig bounds each stage of the shutdown sequence on SIGINT,
+// SIGTERM, or a finished bounded run: waiting for the auctions underway
+// (Drain), delivering queued notifications (Notifications), flushing
+// exporters and result sinks (Exporters), and closing DSP and other
+// clients (Clients). A stage that overruns is abandoned, losing whatever
+// it had left, so the process still exits.
+type ShutdownConfig struct {
+	Drain         time.Duration `yaml:"drain_timeout"`
+	Notifications time.Duration `yaml:"notify_timeout"`
+	Exporters     time.Duration `yaml:"export_timeout"`
+	Clients       time.Duration `yaml:"close_timeout"`
+}
+
+// BidLogConfig writes every request, all DSP responses, and the auction
+// outcome as NDJSON to Path ("-" for stdout), for SampleRate (default 1)
+// of auctions. An existing file is overwritten. An empty Path disables
+// it.
+type BidLogConfig struct {
+	Path       string  `yaml:"path"`
+	SampleRate float64 `yaml:"sample_rate"`
+}
+
+// Enabled reports whether auctions are logged.
+func (b BidLogConfig) Enabled() bool {
+	return b.Path != ""
+}
+
+// NotificationConfig controls firing of win (nurl), billing (burl), and
+// loss (lurl) notifications after each auction.
+type NotificationConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	TimeoutMS int  `yaml:"timeout_ms"`
+	Workers   int  `yaml:"workers"`
+	QueueSize int  `yaml:"queue_size"`
+}
+
+// CreativeQAConfig flags bid creatives whose markup (AdM) exceeds
+// MaxAdMBytes in per-DSP creative stats. Zero disables the check.
+type CreativeQAConfig struct {
+	MaxAdMBytes int `yaml:"max_adm_bytes"`
+}
+
+// ResultSinkConfig writes every auction outcome to a database in batches.
+// Type is "clickhouse", with URL the HTTP interface address, or
+// "postgres", with URL a connection string. An empty Type disables it.
+type ResultSinkConfig struct {
+	Type          string        `yaml:"type"`
+	URL           string        `yaml:"url"`
+	Table         string        `yaml:"table"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	QueueSize     int           `yaml:"queue_size"`
+}
+
+// Wire protocols accepted in DSPConfig.Protocol.
+const (
+	ProtocolJSON     = "json"
+	ProtocolProtobuf = "protobuf" // OpenRTB protocol buffers
+)
+
+// Impression formats accepted in DSPConfig.Formats.
+const (
+	FormatBanner = "banner"
+	FormatVideo  = "video"
+	FormatNative = "native"
+)
+
+// Bidding strategies accepted in SeatConfig.Strategy.
+const (
+	StrategyTruthful = "truthful" // bid the value
+	StrategyShaded   = "shaded"   // bid the value less a Shade fraction
+)
+
+// Budget pacing modes accepted in SeatConfig.Pacing.
+const (
+	PacingASAP = "asap" // spend as fast as the seat wins
+	PacingEven = "even" // spread the budget evenly over the pacing period
+)
+
+// Response parsing modes accepted in DSPConfig.Parsing.
+const (
+	ParsingLenient = "lenient"
+	ParsingStrict  = "strict" // reject unknown fields, a missing cur, and unknown impids
+)
+
+// Result sink types accepted in ResultSinkConfig.Type.
+const (
+	SinkClickHouse = "clickhouse"
+	SinkPostgres   = "postgres"
+)
+
+// Enabled reports whether a result sink is configured.
+func (r ResultSinkConfig) Enabled() bool {
+	return r.Type != ""
+}
+
+// RollupConfig writes a CSV of per-DSP requests, bids, wins, revenue,
+// and p99 latency to Path, a row per DSP for every Interval (default 1m)
+// of simulated time. The file is overwritten at startup. An empty Path
+// disables it.
+type RollupConfig struct {
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Enabled reports whether rollups are written.
+func (r RollupConfig) Enabled() bool {
+	return r.Path != ""
+}
+
+// KafkaConfig publishes bid requests, DSP responses, and auction
+// outcomes to Kafka topics. An empty topic skips that event type; no
+// brokers disables export.
+type KafkaConfig struct {
+	Brokers       []string      `yaml:"brokers"`
+	Format        string        `yaml:"format"` // json (default) or avro
+	Topics        KafkaTopics   `yaml:"topics"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	QueueSize     int           `yaml:"queue_size"`
+	TLS           KafkaTLS      `yaml:"tls"`
+	SASL          KafkaSASL     `yaml:"sasl"`
+}
+
+// KafkaTLS connects to the brokers over TLS, verifying their certificates
+// against CAFile if set, or the system roots.
+type KafkaTLS struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// KafkaSASL authenticates to the brokers with Mechanism, one of the
+// SASL mechanisms below. An empty Mechanism disables authentication.
+type KafkaSASL struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+// SASL mechanisms accepted in KafkaSASL.Mechanism.
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// KafkaTopics names the topic for each exported event type.
+type KafkaTopics struct {
+	Requests  string `yaml:"requests"`
+	Responses string `yaml:"responses"`
+	Outcomes  string `yaml:"outcomes"`
+}
+
+// Kafka formats accepted in KafkaConfig.Format.
+const (
+	KafkaJSON = "json"
+	KafkaAvro = "avro"
+)
+
+// Enabled reports whether Kafka export is configured.
+func (k KafkaConfig) Enabled() bool {
+	return len(k.Brokers) > 0
+}
+
+// ReportConfig writes an end-of-run report to Dir each time the
+// simulation stops: <run-id>.json with win rate, eCPM, timeout rate, and
+// latency percentiles per DSP, and the same as text in <run-id>.txt.
+// Summary, if set, is the path of a JSON file written at shutdown with the
+// final statistics, run metadata, and per-DSP scorecards of the session.
+type ReportConfig struct {
+	Dir     string `yaml:"dir"`
+	Summary string `yaml:"summary"`
+
+	Baseline BaselineConfig `yaml:"baseline"`
+}
+
+// AnomalyConfig flags seconds whose win rate drops, or whose mean DSP
+// latency spikes, more than Sigma standard deviations from the trailing
+// Window (default 1m, at most the 10m of time series history), marking
+// them in /stats/timeseries and logging a warning. AlertURL, if set, is
+// POSTed each anomaly as JSON. A zero Sigma disables detection.
+type AnomalyConfig struct {
+	Sigma    float64       `yaml:"sigma"`
+	Window   time.Duration `yaml:"window"`
+	AlertURL string        `yaml:"alert_url"`
+}
+
+// Enabled reports whether anomalies are detected.
+func (a AnomalyConfig) Enabled() bool {
+	return a.Sigma > 0
+}
+
+func (a AnomalyConfig) validate() error {
+	if a.Sigma < 0 {
+		return errors.New("sigma must not be negative")
+	}
+	if a.Window != 0 && (a.Window < 10*time.Second || a.Window > 10*time.Minute) {
+		return errors.New("window must be between 10s and 10m")
+	}
+	if a.AlertURL != "" && !strings.HasPrefix(a.AlertURL, "http://") && !strings.HasPrefix(a.AlertURL, "https://") {
+		return errors.New("alert_url must be an http:// or https:// URL")
+	}
+	return nil
+}
+
+// BaselineConfig compares every run against a baseline report stored as
+// <Name>.json in Dir, one per named configuration (default "default").
+// The first run saves the baseline; with Update, every run replaces it.
+// Metrics that regress beyond the thresholds are listed in the run
+// report, and the simulator exits with a non-zero status at shutdown.
+//
+// Win rate, eCPM, and p95 latency thresholds are relative changes (0.1
+// is 10%); MaxErrorRateIncrease is an absolute increase in errors per
+// request. If none are set, they default to 0.1, 0.1, 0.2, and 0.01.
+type BaselineConfig struct {
+	Dir    string `yaml:"dir"`
+	Name   string `yaml:"name"`
+	Update bool   `yaml:"update"`
+
+	MaxWinRateDrop       float64 `yaml:"max_win_rate_drop"`
+	MaxECPMDrop          float64 `yaml:"max_ecpm_drop"`
+	MaxLatencyIncrease   float64 `yaml:"max_latency_increase"`
+	MaxErrorRateIncrease float64 `yaml:"max_error_rate_increase"`
+}
+
+// Enabled reports whether runs are compared against a baseline.
+func (b BaselineConfig) Enabled() bool {
+	return b.Dir != ""
+}
+
+// Path returns the baseline report's file path.
+func (b BaselineConfig) Path() string {
+	return filepath.Join(b.Dir, b.Name+".json")
+}
+
+// DebugConfig enables diagnostics that are too costly for normal runs.
+type DebugConfig struct {
+	ConsistencyChecks bool `yaml:"consistency_checks"`
+	ValidateRequests  bool `yaml:"validate_requests"` // exit on the first request violating the OpenRTB schema
+
+	// Endpoints serves Go profiles (/debug/pprof/) and runtime statistics
+	// (/debug/runtime) alongside the control endpoints.
+	Endpoints bool `yaml:"endpoints"`
 }
 
 type ServerConfig struct {
 	Port int `yaml:"port"`
+
+	// AdminPort, when set, moves control endpoints (start, stop, and other
+	// mutations) to a separate listener bound to AdminHost.
+	AdminPort int    `yaml:"admin_port"`
+	AdminHost string `yaml:"admin_host"`
+
+	Auth AuthConfig `yaml:"auth"`
+}
+
+// AuthConfig protects control endpoints. Requests must carry APIKey in
+// the X-API-Key header or, if Username is set, HTTP basic auth
+// credentials. Empty leaves control endpoints open.
+type AuthConfig struct {
+	APIKey   string `yaml:"api_key"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Enabled reports whether control endpoints require credentials.
+func (a AuthConfig) Enabled() bool {
+	return a.APIKey != "" || a.Username != ""
+}
+
+// AdminAddr returns the admin listener address, or "" if control endpoints
+// share the public listener.
+func (s ServerConfig) AdminAddr() string {
+	if s.AdminPort == 0 {
+		return ""
+	}
+	return net.JoinHostPort(s.AdminHost, strconv.Itoa(s.AdminPort))
 }
 
 type SimulationConfig struct {
 	RequestsPerSecond int    `yaml:"requests_per_second"`
 	Scenario          string `yaml:"scenario"`
+
+	// Scenarios mixes several scenarios in one run instead of Scenario:
+	// each request is built by one of them, picked in proportion to its
+	// weight, and stats break auctions down by scenario.
+	Scenarios []ScenarioWeight `yaml:"scenarios"`
+
+	// Concurrency is how many auctions may be in flight at once, so slow
+	// DSPs do not lower the achieved request rate.
+	Concurrency int `yaml:"concurrency"`
+	// BatchSize is how many requests each tick issues, each with its own
+	// auction. Ticks come BatchSize times less often, which keeps very
+	// high rates within the timer resolution the OS can honor.
+	BatchSize int `yaml:"batch_size"`
+	// RequestBuffer is how many requests are generated ahead of dispatch,
+	// so generation stalls such as GC pauses do not leave gaps in the
+	// request rate. Zero generates each request when it is sent.
+	RequestBuffer int `yaml:"request_buffer"`
+
+	// Seed makes random generation reproducible. 0 uses a random seed.
+	Seed uint64 `yaml:"seed"`
+
+	// Duration and MaxRequests bound each run; the engine stops on its own
+	// when either is reached. Zero means unbounded.
+	Duration    time.Duration `yaml:"duration"`
+	MaxRequests uint64        `yaml:"max_requests"`
+	// SpendCap ends the run once total clearing prices reach it.
+	// Zero means unbounded.
+	SpendCap float64 `yaml:"spend_cap"`
+
+	// TimeScale is how many simulated seconds pass per wall-clock second,
+	// so 24 runs a simulated day in an hour. Duration, ramps, and state
+	// TTLs are simulated time; request rates stay per wall-clock second.
+	TimeScale float64 `yaml:"time_scale"`
+
+	// Locales weights generated device countries by ISO-3166-1 alpha-3
+	// code. Empty uses the scenario's default global mix.
+	Locales map[string]float64 `yaml:"locales"`
+
+	// DeviceMix weights generated devices by class (phone, tablet, desktop,
+	// ctv). Empty uses the scenario's default.
+	DeviceMix map[string]float64 `yaml:"device_mix"`
+
+	SharedIPs   SharedIPConfig    `yaml:"shared_ips"`
+	Users       UserPoolConfig    `yaml:"users"`
+	Consent     ConsentConfig     `yaml:"consent"`
+	SupplyChain SupplyChainConfig `yaml:"supply_chain"`
+	Deals       DealsConfig       `yaml:"deals"`
+	Contextual  ContextualConfig  `yaml:"contextual"`
+
+	// Currencies lists the bid currencies requests allow (cur), the
+	// preferred first. Empty allows USD only.
+	Currencies []string `yaml:"currencies"`
+
+	// NonSecureShare is the share of impressions that do not require
+	// HTTPS creatives (imp.secure omitted). Zero marks every impression
+	// secure.
+	NonSecureShare float64 `yaml:"non_secure_share"`
+
+	// RenderDelay simulates the time between an auction and the rendering
+	// of its impression. Requests advertise it, rounded up to whole
+	// seconds, as imp.exp; bids whose exp is shorter are counted, and
+	// winning ones as expired before render. Zero disables both.
+	RenderDelay time.Duration `yaml:"render_delay"`
+
+	// Floors sets the distribution of impression bid floors. Unset uses
+	// each scenario's default range.
+	Floors FloorConfig `yaml:"floors"`
+
+	Ramp RampConfig `yaml:"ramp"`
+
+	// Fuzz malforms a share of requests to test DSP robustness.
+	Fuzz FuzzConfig `yaml:"fuzz"`
+
+	// Replay configures the replay scenario.
+	Replay ReplayConfig `yaml:"replay"`
+}
+
+// ScenarioWeight is one scenario of a mix and its relative weight.
+type ScenarioWeight struct {
+	Name   string  `yaml:"name"`
+	Weight float64 `yaml:"weight"`
+
+	// NonSecureShare overrides simulation.non_secure_share for this
+	// scenario when positive.
+	NonSecureShare float64 `yaml:"non_secure_share"`
+	// Floors overrides simulation.floors for this scenario when set.
+	Floors FloorConfig `yaml:"floors"`
+}
+
+// NamedSimulation is a further simulation hosted alongside the main one,
+// started and stopped under /simulations/{name}, for comparing DSP
+// configurations side by side. It takes every setting it leaves unset
+// from simulation, and bids to every enabled DSP unless DSPs names some.
+// Named simulations keep their own stats but do not send notifications,
+// export auctions, or record runs.
+type NamedSimulation struct {
+	Name              string   `yaml:"name"`
+	Scenario          string   `yaml:"scenario"`
+	RequestsPerSecond int      `yaml:"requests_per_second"`
+	DSPs              []string `yaml:"dsps"`
+}
+
+// reservedSimulationNames would collide with the routes that start and
+// stop every named simulation.
+var reservedSimulationNames = map[string]bool{"start": true, "stop": true}
+
+// ForSimulation returns a copy of c configured for the named simulation
+// ns. A rate set by ns replaces the ramp along with requests_per_second,
+// and the DSPs it names are enabled whether or not they are in c.
+func (c Config) ForSimulation(ns NamedSimulation) Config {
+	if ns.Scenario != "" {
+		c.Simulation.Scenario, c.Simulation.Scenarios = ns.Scenario, nil
+	}
+	if ns.RequestsPerSecond > 0 {
+		c.Simulation.RequestsPerSecond = ns.RequestsPerSecond
+		c.Simulation.Ramp = RampConfig{}
+	}
+	if len(ns.DSPs) > 0 {
+		dsps := make([]DSPConfig, 0, len(ns.DSPs))
+		for _, name := range ns.DSPs {
+			if i := findDSP(c.DSPs, name); i >= 0 {
+				dsp := c.DSPs[i]
+				dsp.Enabled = true
+				dsps = append(dsps, dsp)
+			}
+		}
+		c.DSPs = dsps
+	}
+	c.Simulations = nil
+	return c
+}
+
+// UsesScenario reports whether requests are built by the named scenario,
+// alone or as part of a mix.
+func (s SimulationConfig) UsesScenario(name string) bool {
+	if len(s.Scenarios) == 0 {
+		return s.Scenario == name
+	}
+	for _, sw := range s.Scenarios {
+		if sw.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateScenarioOptions checks the settings the scenarios check when
+// built, since the scenario options ignore invalid ones.
+func (s SimulationConfig) validateScenarioOptions() error {
+	if len(s.Locales) > 0 {
+		if err := scenarios.ValidateLocaleWeights(s.Locales); err != nil {
+			return fmt.Errorf("locales: %w", err)
+		}
+	}
+	if len(s.DeviceMix) > 0 {
+		if err := scenarios.ValidateDeviceMix(s.DeviceMix); err != nil {
+			return fmt.Errorf("device_mix: %w", err)
+		}
+	}
+	if sc := s.SupplyChain; sc.Hops != 0 || len(sc.SellerIDs) > 0 {
+		if err := scenarios.ValidateSupplyChain(sc.Hops, sc.SellerIDs); err != nil {
+			return fmt.Errorf("supply_chain: %w", err)
+		}
+	}
+	if d := s.Deals; d.Share != 0 || len(d.Deals) > 0 {
+		if err := scenarios.ValidateDeals(d.Share, d.PMPDeals()); err != nil {
+			return fmt.Errorf("deals: %w", err)
+		}
+	}
+	if cx := s.Contextual; cx.Share != 0 || len(cx.Taxonomy) > 0 {
+		if err := scenarios.ValidateContextual(cx.Share, cx.Topics()); err != nil {
+			return fmt.Errorf("contextual: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReplayConfig replays recorded bid requests from File, NDJSON with one
+// OpenRTB bid request per line, instead of generating them. RewriteIDs
+// replaces recorded request IDs with generated ones.
+type ReplayConfig struct {
+	File       string `yaml:"file"`
+	RewriteIDs bool   `yaml:"rewrite_ids"`
+}
+
+// FloorConfig draws impression bid floors from Default, a fixed,
+// uniform, normal, or lognormal distribution, or from BySize for banners
+// and video players of a listed size, keyed "WxH" such as "300x250".
+// Sizes without an entry use Default, or the scenario's default range
+// when Default is unset.
+type FloorConfig struct {
+	Default randutil.Dist            `yaml:"default"`
+	BySize  map[string]randutil.Dist `yaml:"by_size"`
+}
+
+// Enabled reports whether any floor distribution is configured.
+func (f FloorConfig) Enabled() bool {
+	return !f.Default.IsZero() || len(f.BySize) > 0
+}
+
+func (f FloorConfig) validate() error {
+	return scenarios.ValidateFloors(f.Default, f.BySize)
+}
+
+// ConsentConfig attaches privacy signals to generated requests:
+// GDPRShare of requests get regs.ext.gdpr=1 with a TCF v2 consent string,
+// and USPrivacyShare get a CCPA US Privacy string. Zero disables each.
+type ConsentConfig struct {
+	GDPRShare      float64 `yaml:"gdpr_share"`
+	USPrivacyShare float64 `yaml:"us_privacy_share"`
+}
+
+// Enabled reports whether any consent signals are configured.
+func (c ConsentConfig) Enabled() bool {
+	return c.GDPRShare > 0 || c.USPrivacyShare > 0
+}
+
+// SupplyChainConfig attaches an IAB SupplyChain (source.ext.schain) with
+// Hops nodes to every generated request. The first node's seller ID is
+// drawn from SellerIDs, or from random IDs if empty. Zero Hops disables it.
+type SupplyChainConfig struct {
+	Hops      int      `yaml:"hops"`
+	SellerIDs []string `yaml:"seller_ids"`
+}
+
+// Enabled reports whether a supply chain is configured.
+func (s SupplyChainConfig) Enabled() bool {
+	return s.Hops > 0
+}
+
+// DealsConfig attaches a private marketplace (imp.pmp) listing Deals to
+// Share of generated requests. With PrivateAuction set, only bids on the
+// deals are accepted for those requests. Zero Share disables deals.
+type DealsConfig struct {
+	Share          float64      `yaml:"share"`
+	PrivateAuction bool         `yaml:"private_auction"`
+	Deals          []DealConfig `yaml:"list"`
+}
+
+// DealConfig is a single direct deal. Seats lists the buyer seats allowed
+// to bid on it; empty allows any seat.
+type DealConfig struct {
+	ID       string   `yaml:"id"`
+	BidFloor float64  `yaml:"bidfloor"`
+	Seats    []string `yaml:"seats"`
+}
+
+// Enabled reports whether deals are attached to requests.
+func (d DealsConfig) Enabled() bool {
+	return d.Share > 0
+}
+
+// PMPDeals returns the configured deals as OpenRTB deals.
+func (d DealsConfig) PMPDeals() []openrtb.Deal {
+	deals := make([]openrtb.Deal, len(d.Deals))
+	for i, dc := range d.Deals {
+		deals[i] = openrtb.Deal{ID: dc.ID, BidFloor: dc.BidFloor, WSeat: dc.Seats}
+	}
+	return deals
+}
+
+// ContextualConfig attaches contextual signals to Share of generated
+// requests: a content object (app.content or site.content) with a genre
+// and keywords from the Taxonomy topic of the publisher's IAB category,
+// and user interest keywords (user.keywords) drawn from UserKeywords.
+// Taxonomy is keyed by IAB category; a subcategory without an entry uses
+// its tier-1 category's. Empty Taxonomy or UserKeywords use built-in
+// ones. Zero Share disables contextual signals.
+type ContextualConfig struct {
+	Share        float64                `yaml:"share"`
+	Taxonomy     map[string]TopicConfig `yaml:"taxonomy"`
+	UserKeywords []string               `yaml:"user_keywords"`
+}
+
+// TopicConfig lists the content genres and keywords of a category.
+type TopicConfig struct {
+	Genres   []string `yaml:"genres"`
+	Keywords []string `yaml:"keywords"`
+}
+
+// Enabled reports whether contextual signals are attached to requests.
+func (c ContextualConfig) Enabled() bool {
+	return c.Share > 0
+}
+
+// Topics returns the configured taxonomy for the scenarios, nil when it
+// is empty so the built-in one is used.
+func (c ContextualConfig) Topics() map[string]scenarios.Topic {
+	if len(c.Taxonomy) == 0 {
+		return nil
+	}
+	topics := make(map[string]scenarios.Topic, len(c.Taxonomy))
+	for cat, t := range c.Taxonomy {
+		topics[cat] = scenarios.Topic{Genres: t.Genres, Keywords: t.Keywords}
+	}
+	return topics
+}
+
+// RampConfig moves the request rate linearly from StartRPS to EndRPS over
+// Duration at the start of each run, then holds EndRPS. A zero Duration
+// disables the ramp and the run uses RequestsPerSecond throughout.
+type RampConfig struct {
+	StartRPS int           `yaml:"start_rps"`
+	EndRPS   int           `yaml:"end_rps"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Enabled reports whether a ramp profile is configured.
+func (r RampConfig) Enabled() bool {
+	return r.Duration > 0
+}
+
+// FuzzConfig sends a Rate share of bid requests malformed, each with one
+// mutation of a class picked from Classes (missing_field, wrong_type,
+// huge_string, or invalid_enum; all when empty). Only DSPs speaking JSON
+// are sent malformed requests. A zero Rate disables fuzzing.
+type FuzzConfig struct {
+	Rate    float64  `yaml:"rate"`
+	Classes []string `yaml:"classes"`
+}
+
+// Enabled reports whether requests are fuzzed.
+func (f FuzzConfig) Enabled() bool {
+	return f.Rate > 0
+}
+
+// MarketConfig runs the simulation against Seats, virtual bidders that
+// bid in-process instead of DSP endpoints, so auction mechanism
+// experiments can run at rates no HTTP bidder could sustain. dsps must be
+// empty when seats are set.
+type MarketConfig struct {
+	Seats []SeatConfig `yaml:"seats"`
+}
+
+// Enabled reports whether the simulation runs against virtual seats.
+func (m MarketConfig) Enabled() bool {
+	return len(m.Seats) > 0
+}
+
+// SeatConfig is one virtual bidder. For each impression it draws a value
+// CPM from Value and bids it by Strategy, unless it no-bids at NoBidRate
+// or the impression is floored above the value. Budget caps the clearing
+// prices the seat pays over a run (0 = unlimited); with even Pacing its
+// spend is also held to the share of Budget for the part of PacingPeriod
+// elapsed, which defaults to simulation.duration. FrequencyCap stops it
+// bidding on users it has already won often enough.
+type SeatConfig struct {
+	Name         string             `yaml:"name"`
+	Value        randutil.Dist      `yaml:"value"`
+	Strategy     string             `yaml:"strategy"`
+	Shade        float64            `yaml:"shade"`
+	NoBidRate    float64            `yaml:"no_bid_rate"`
+	Budget       float64            `yaml:"budget"`
+	Pacing       string             `yaml:"pacing"`
+	PacingPeriod time.Duration      `yaml:"pacing_period"`
+	FrequencyCap FrequencyCapConfig `yaml:"frequency_cap"`
+}
+
+// FrequencyCapConfig limits a seat to Impressions wins per user within
+// Window of simulated time from the user's first win; a zero Window caps
+// wins over the user's lifetime. Users are told apart by user.id, so caps
+// only bind when simulation.users draws from a pool. Zero Impressions
+// disables the cap.
+type FrequencyCapConfig struct {
+	Impressions int           `yaml:"impressions"`
+	Window      time.Duration `yaml:"window"`
+}
+
+// Enabled reports whether the seat is frequency capped.
+func (f FrequencyCapConfig) Enabled() bool {
+	return f.Impressions > 0
+}
+
+// validate checks a seat's settings. Error messages name the offending
+// field relative to the seat.
+func (s SeatConfig) validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := s.Value.Validate(); err != nil {
+		return fmt.Errorf("value: %w", err)
+	}
+	switch s.Strategy {
+	case StrategyTruthful:
+	case StrategyShaded:
+		if s.Shade <= 0 || s.Shade >= 1 {
+			return errors.New("shade must be between 0 and 1 for the shaded strategy")
+		}
+	default:
+		return fmt.Errorf("strategy: unknown strategy %q (want %s or %s)", s.Strategy, StrategyTruthful, StrategyShaded)
+	}
+	if s.NoBidRate < 0 || s.NoBidRate > 1 {
+		return errors.New("no_bid_rate must be between 0 and 1")
+	}
+	if s.Budget < 0 {
+		return errors.New("budget must not be negative")
+	}
+	switch s.Pacing {
+	case PacingASAP:
+	case PacingEven:
+		if s.Budget == 0 || s.PacingPeriod <= 0 {
+			return errors.New("pacing: even requires a budget and a pacing_period or simulation.duration")
+		}
+	default:
+		return fmt.Errorf("pacing: unknown mode %q (want %s or %s)", s.Pacing, PacingASAP, PacingEven)
+	}
+	if f := s.FrequencyCap; f.Impressions < 0 || f.Window < 0 {
+		return errors.New("frequency_cap: impressions and window must not be negative")
+	}
+	return nil
+}
+
+// SharedIPConfig models many users behind the same IP (carrier-grade NAT,
+// corporate proxies). Share is the fraction of requests drawn from a pool of
+// PoolSize addresses whose popularity is Zipf-distributed with exponent
+// Concentration. A zero PoolSize or Share disables shared IPs.
+type SharedIPConfig struct {
+	PoolSize      int     `yaml:"pool_size"`
+	Concentration float64 `yaml:"concentration"`
+	Share         float64 `yaml:"share"`
+}
+
+// Enabled reports whether shared IP modeling is configured.
+func (s SharedIPConfig) Enabled() bool {
+	return s.PoolSize > 0 && s.Share > 0
+}
+
+// UserPoolConfig draws request users from Size returning users instead
+// of a new random user per request, so DSPs and frequency caps see the
+// same users again. Each user leaves the pool TTL of simulated time
+// after first seen and a new one takes its place; zero keeps users for
+// good. A zero Size disables the pool.
+type UserPoolConfig struct {
+	Size int           `yaml:"size"`
+	TTL  time.Duration `yaml:"ttl"`
+}
+
+// Enabled reports whether requests draw users from a pool.
+func (u UserPoolConfig) Enabled() bool {
+	return u.Size > 0
+}
+
+// StateConfig holds the simulation state that outlives an auction: the
+// user pool, seat frequency caps, and seat spend. It is kept in memory
+// and, when Path is set, loaded from and saved to a snapshot file there,
+// so returning users and their caps survive a restart. Entries past their
+// TTL are evicted every SweepInterval (default 1m).
+type StateConfig struct {
+	Path          string        `yaml:"path"`
+	SweepInterval time.Duration `yaml:"sweep_interval"`
 }
 
 type AuctionConfig struct {
 	Type      string `yaml:"type"`
 	TimeoutMS int    `yaml:"timeout_ms"`
+	// TmaxDeadline gives DSPs each request's tmax, less TmaxOverheadMS
+	// for the exchange's own processing, instead of TimeoutMS, which then
+	// only applies to requests without a tmax.
+	TmaxDeadline   bool `yaml:"tmax_deadline"`
+	TmaxOverheadMS int  `yaml:"tmax_overhead_ms"`
+	// Increment is added to the second price by soft_second_price and
+	// soft_floor (default $0.01). Pointers so an explicit 0 is kept.
+	Increment *float64 `yaml:"increment"`
+	// BidReduction is the percentage bid_reduction takes off the winning
+	// bid (default 10).
+	BidReduction *float64 `yaml:"bid_reduction"`
+	// SoftFloor is the price below which soft_floor charges winners their
+	// bid instead of the second price. Requests with a higher floor use
+	// their floor.
+	SoftFloor float64 `yaml:"soft_floor"`
+	// LatencyTiersMS are the upper bounds of the per-DSP response time
+	// tiers reported in stats, below the timeout tier (default 20, 50, 80).
+	LatencyTiersMS []int `yaml:"latency_tiers_ms"`
+	// MaxCPM and MaxFloorRatio disqualify absurd bids: prices above
+	// MaxCPM, or more than MaxFloorRatio times the bid floor. Zero
+	// disables each.
+	MaxCPM        float64 `yaml:"max_cpm"`
+	MaxFloorRatio float64 `yaml:"max_floor_ratio"`
 }
 
 type DSPConfig struct {
-	Name     string `yaml:"name"`
-	Endpoint string `yaml:"endpoint"`
-	Enabled  bool   `yaml:"enabled"`
+	Name     string      `yaml:"name"`
+	Endpoint string      `yaml:"endpoint"`
+	Enabled  bool        `yaml:"enabled"`
+	Faults   FaultConfig `yaml:"faults"`
+
+	// Protocol is the wire format of the DSP's bid requests and
+	// responses: ProtocolJSON, the default, or ProtocolProtobuf.
+	Protocol string `yaml:"protocol"`
+
+	// Parsing is how strictly the DSP's responses are parsed:
+	// ParsingLenient, the default, takes whatever decodes, while
+	// ParsingStrict fails responses with fields OpenRTB does not define,
+	// bids without cur, or bids on impressions the request lacks, so a
+	// mature bidder can be held to the spec while a new one is not.
+	Parsing string `yaml:"parsing"`
+
+	// StatusHandling maps HTTP status codes to how the response should be
+	// classified (nobid, throttle, overload, error), overriding the default
+	// of treating every 4xx/5xx as an error.
+	StatusHandling map[int]string `yaml:"status_handling"`
+
+	// SpendCap pauses the DSP for the rest of the run once its winning
+	// clearing prices reach it. Zero means unbounded.
+	SpendCap float64 `yaml:"spend_cap"`
+
+	// ADomains restricts the advertiser domains the DSP may bid with.
+	// Violating bids are dropped at auction time and reported.
+	ADomains ADomainConfig `yaml:"adomains"`
+
+	// HTTPS sends a share of the DSP's traffic to an HTTPS endpoint, for
+	// comparing secure and plaintext bidder behavior.
+	HTTPS HTTPSConfig `yaml:"https"`
+
+	// AB splits the DSP's traffic between two builds for canary analysis.
+	AB ABConfig `yaml:"ab"`
+
+	// TrafficPct sends the DSP only this percentage of requests, and
+	// QPSLimit caps the rate of those sent, as exchange-side throttling
+	// and supply-path optimization would. Zero means no restriction.
+	TrafficPct float64 `yaml:"traffic_pct"`
+	QPSLimit   float64 `yaml:"qps_limit"`
+
+	// MaxInFlight caps the requests the DSP has outstanding at once, as
+	// the bidder's connection limit would. A request beyond it waits up to
+	// QueueTimeout for one to complete, then is skipped as saturated.
+	// Zero means no cap.
+	MaxInFlight  int           `yaml:"max_in_flight"`
+	QueueTimeout time.Duration `yaml:"queue_timeout"`
+
+	// HealthPath overrides health_checks.path for this DSP.
+	HealthPath string `yaml:"health_path"`
+
+	// Formats lists the impression formats the DSP buys: FormatBanner,
+	// FormatVideo, or FormatNative. Requests none of whose impressions
+	// offer one are not sent to it. Empty means every format.
+	Formats []string `yaml:"formats"`
+}
+
+// ABConfig splits a DSP's traffic between its main endpoint (arm A) and
+// Endpoint (arm B), sending Split of requests to B. Stats report each arm
+// separately along with a B-versus-A comparison.
+type ABConfig struct {
+	Endpoint string  `yaml:"endpoint"`
+	Split    float64 `yaml:"split"`
+}
+
+// Enabled reports whether any traffic is routed to arm B.
+func (a ABConfig) Enabled() bool {
+	return a.Split > 0
+}
+
+// HTTPSConfig routes Share of a DSP's requests to Endpoint, an https URL,
+// instead of the DSP's main endpoint. InsecureSkipVerify accepts any
+// server certificate, for test bidders with self-signed certificates; it
+// also applies when the main endpoint is itself https.
+type HTTPSConfig struct {
+	Endpoint           string  `yaml:"endpoint"`
+	Share              float64 `yaml:"share"`
+	InsecureSkipVerify bool    `yaml:"insecure_skip_verify"`
+}
+
+// Enabled reports whether any traffic is routed to the HTTPS endpoint.
+func (h HTTPSConfig) Enabled() bool {
+	return h.Share > 0
+}
+
+// ADomainConfig lists advertiser domains a DSP is allowed or blocked from
+// bidding with. Entries also match their subdomains. An empty Allow list
+// permits any domain not blocked.
+type ADomainConfig struct {
+	Allow []string `yaml:"allow"`
+	Block []string `yaml:"block"`
+}
+
+// Enabled reports whether any advertiser domain restriction is configured.
+func (a ADomainConfig) Enabled() bool {
+	return len(a.Allow) > 0 || len(a.Block) > 0
+}
+
+// Status classifications accepted in DSPConfig.StatusHandling.
+const (
+	StatusNoBid    = "nobid"
+	StatusThrottle = "throttle"
+	StatusOverload = "overload"
+	StatusError    = "error"
+)
+
+// FaultConfig controls dispatcher-level fault injection for a single DSP.
+// Rates are probabilities in [0, 1] applied independently per request; at
+// most one fault is injected per call, so they must not sum past 1.
+type FaultConfig struct {
+	ResetRate    float64 `yaml:"reset_rate"`
+	TruncateRate float64 `yaml:"truncate_rate"`
+
+	// DelayRate holds requests back for a random time up to Delay before
+	// sending them, as a congested network path would.
+	DelayRate float64       `yaml:"delay_rate"`
+	Delay     time.Duration `yaml:"delay"`
+
+	// DropRate loses requests before they reach the bidder, so the call
+	// times out.
+	DropRate float64 `yaml:"drop_rate"`
+
+	// MalformedRate replaces the response body with one that is not JSON,
+	// as a misbehaving proxy would; CorruptRate flips random bytes in it,
+	// which may still decode into a garbled bid.
+	MalformedRate float64 `yaml:"malformed_rate"`
+	CorruptRate   float64 `yaml:"corrupt_rate"`
+}
+
+// Redacted returns a copy of c with credentials masked, for display.
+func (c Config) Redacted() Config {
+	const mask = "REDACTED"
+	if c.Server.Auth.APIKey != "" {
+		c.Server.Auth.APIKey = mask
+	}
+	if c.Server.Auth.Password != "" {
+		c.Server.Auth.Password = mask
+	}
+	c.ResultSink.URL = redactURL(c.ResultSink.URL, mask)
+	c.Currency.URL = redactURL(c.Currency.URL, mask)
+	c.Anomalies.AlertURL = redactURL(c.Anomalies.AlertURL, mask)
+	if c.Kafka.SASL.Password != "" {
+		c.Kafka.SASL.Password = mask
+	}
+	return c
+}
+
+// redactURL masks the password in raw's userinfo and password query
+// parameter, or a username given alone, which may be a token. raw may
+// also be a keyword/value connection string such as lib/pq's
+// "host=db password=secret", whose password is masked. A URL that does
+// not parse is masked whole.
+func redactURL(raw, mask string) string {
+	if isKeyValueDSN(raw) {
+		return redactKeyValues(raw, mask)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return mask
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), mask)
+		} else {
+			u.User = url.User(mask)
+		}
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", mask)
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// isKeyValueDSN reports whether raw is a keyword/value connection string:
+// it opens with a keyword and '=' rather than a URL scheme.
+func isKeyValueDSN(raw string) bool {
+	s := strings.TrimLeft(raw, " \t\n")
+	key := strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	return key > 0 && strings.HasPrefix(strings.TrimLeft(s[key:], " "), "=")
+}
+
+// redactKeyValues masks the password value of a keyword/value
+// connection string, keeping the rest as written. Values are separated
+// by whitespace or single-quoted, with backslash escapes, as lib/pq
+// parses them.
+func redactKeyValues(raw, mask string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		eq := strings.IndexByte(raw[i:], '=')
+		if eq < 0 {
+			b.WriteString(raw[i:])
+			break
+		}
+		key := strings.TrimSpace(raw[i : i+eq])
+		b.WriteString(raw[i : i+eq+1])
+		i += eq + 1
+		for i < len(raw) && raw[i] == ' ' {
+			b.WriteByte(' ')
+			i++
+		}
+
+		end := i
+		if end < len(raw) && raw[end] == '\'' {
+			for end++; end < len(raw) && raw[end] != '\''; end++ {
+				if raw[end] == '\\' {
+					end++
+				}
+			}
+			end = min(end+1, len(raw))
+		} else {
+			for end < len(raw) && !strings.ContainsRune(" \t\n", rune(raw[end])) {
+				end++
+			}
+		}
+		if key == "password" {
+			b.WriteString(mask)
+		} else {
+			b.WriteString(raw[i:end])
+		}
+		i = end
+	}
+	return b.String()
 }
 
 func Load(path string) (*Config, error) {
@@ -47,47 +1170,466 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
+	inFile, err := filePaths(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	fromEnv, err := cfg.applyEnv()
+	if err != nil {
+		return nil, fmt.Errorf("environment override %w", err)
+	}
 
-	cfg.applyDefaults()
-
-	if err := cfg.Validate(); err != nil {
+	before := cfg.settingValues()
+	if err := cfg.Prepare(); err != nil {
 		return nil, fmt.Errorf("validating config: %w", err)
 	}
+	cfg.recordSources(inFile, fromEnv, before)
 
 	return cfg, nil
 }
 
+// Prepare fills in defaults and validates a configuration built in code
+// rather than loaded from a file.
+func (c *Config) Prepare() error {
+	c.applyDefaults()
+	return c.Validate()
+}
+
+// ptr returns a pointer to v, for defaults of settings where 0 is valid.
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func (c *Config) applyDefaults() {
 	if c.Server.Port == 0 {
 		c.Server.Port = 8080
 	}
+	if c.Server.AdminPort != 0 && c.Server.AdminHost == "" {
+		c.Server.AdminHost = "127.0.0.1"
+	}
 	if c.Simulation.RequestsPerSecond == 0 {
 		c.Simulation.RequestsPerSecond = 10
 	}
 	if c.Simulation.Scenario == "" {
 		c.Simulation.Scenario = "mobile_app"
 	}
+	if c.Simulation.Concurrency == 0 {
+		c.Simulation.Concurrency = 64
+	}
+	if c.Simulation.BatchSize == 0 {
+		c.Simulation.BatchSize = 1
+	}
+	if c.Simulation.TimeScale == 0 {
+		c.Simulation.TimeScale = 1
+	}
 	if c.Auction.Type == "" {
 		c.Auction.Type = "first_price"
 	}
 	if c.Auction.TimeoutMS == 0 {
 		c.Auction.TimeoutMS = 100
 	}
+	if c.Auction.Increment == nil {
+		c.Auction.Increment = ptr(0.01)
+	}
+	if c.Auction.BidReduction == nil {
+		c.Auction.BidReduction = ptr(10.0)
+	}
+	if c.Notifications.TimeoutMS == 0 {
+		c.Notifications.TimeoutMS = 1000
+	}
+	if c.Notifications.Workers == 0 {
+		c.Notifications.Workers = 8
+	}
+	if c.Notifications.QueueSize == 0 {
+		c.Notifications.QueueSize = 4096
+	}
+	if c.BidLog.Enabled() && c.BidLog.SampleRate == 0 {
+		c.BidLog.SampleRate = 1
+	}
+	if c.ResultSink.Enabled() && c.ResultSink.Table == "" {
+		c.ResultSink.Table = "auctions"
+	}
+	if c.Rollups.Enabled() && c.Rollups.Interval == 0 {
+		c.Rollups.Interval = time.Minute
+	}
+	if c.Currency.Enabled() && c.Currency.Base == "" {
+		c.Currency.Base = "USD"
+	}
+	if c.Currency.URL != "" && c.Currency.Refresh == 0 {
+		c.Currency.Refresh = time.Hour
+	}
+	if c.Kafka.Enabled() && c.Kafka.Format == "" {
+		c.Kafka.Format = KafkaJSON
+	}
+	if c.CircuitBreaker.ErrorThreshold > 0 && c.CircuitBreaker.Cooldown == 0 {
+		c.CircuitBreaker.Cooldown = 30 * time.Second
+	}
+	if r := &c.Retries; r.Enabled() {
+		if r.Backoff == 0 {
+			r.Backoff = 10 * time.Millisecond
+		}
+		if len(r.On) == 0 {
+			r.On = []string{RetryConnRefused, RetryConnReset, "503"}
+		}
+	}
+	if h := &c.HealthChecks; h.Enabled() {
+		if h.Path == "" {
+			h.Path = "/health"
+		}
+		if h.Timeout == 0 {
+			h.Timeout = 2 * time.Second
+		}
+		if h.UnhealthyAfter == 0 {
+			h.UnhealthyAfter = 3
+		}
+	}
+	if c.Anomalies.Enabled() && c.Anomalies.Window == 0 {
+		c.Anomalies.Window = time.Minute
+	}
+	if c.Shutdown.Drain == 0 {
+		c.Shutdown.Drain = 10 * time.Second
+	}
+	if c.Shutdown.Notifications == 0 {
+		c.Shutdown.Notifications = 5 * time.Second
+	}
+	if c.Shutdown.Exporters == 0 {
+		c.Shutdown.Exporters = 15 * time.Second
+	}
+	if c.Shutdown.Clients == 0 {
+		c.Shutdown.Clients = 5 * time.Second
+	}
+	for i := range c.Market.Seats {
+		s := &c.Market.Seats[i]
+		if s.Strategy == "" {
+			s.Strategy = StrategyTruthful
+		}
+		if s.Pacing == "" {
+			s.Pacing = PacingASAP
+		}
+		if s.Pacing == PacingEven && s.PacingPeriod == 0 {
+			s.PacingPeriod = c.Simulation.Duration
+		}
+	}
+	if b := &c.Report.Baseline; b.Enabled() {
+		if b.Name == "" {
+			b.Name = "default"
+		}
+		if b.MaxWinRateDrop == 0 && b.MaxECPMDrop == 0 && b.MaxLatencyIncrease == 0 && b.MaxErrorRateIncrease == 0 {
+			b.MaxWinRateDrop = 0.1
+			b.MaxECPMDrop = 0.1
+			b.MaxLatencyIncrease = 0.2
+			b.MaxErrorRateIncrease = 0.01
+		}
+	}
 }
 
 func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return errors.New("server.port must be between 1 and 65535")
 	}
+	if c.Server.AdminPort < 0 || c.Server.AdminPort > 65535 {
+		return errors.New("server.admin_port must be between 1 and 65535")
+	}
+	if c.Server.AdminPort == c.Server.Port {
+		return errors.New("server.admin_port must differ from server.port")
+	}
+	if a := c.Server.Auth; (a.Username == "") != (a.Password == "") {
+		return errors.New("server.auth: username and password must be set together")
+	}
 	if c.Simulation.RequestsPerSecond <= 0 {
 		return errors.New("simulation.requests_per_second must be positive")
 	}
-	if len(c.DSPs) == 0 {
-		return errors.New("at least one DSP must be configured")
+	if len(c.Simulation.Scenarios) == 0 && c.Simulation.Scenario != "" {
+		if err := scenarios.ValidateName(c.Simulation.Scenario); err != nil {
+			return fmt.Errorf("simulation.scenario: %w", err)
+		}
+	}
+	seen := make(map[string]bool, len(c.Simulation.Scenarios))
+	for i, sw := range c.Simulation.Scenarios {
+		if sw.Name == "" || sw.Weight <= 0 {
+			return fmt.Errorf("simulation.scenarios[%d]: name is required and weight must be positive", i)
+		}
+		if err := scenarios.ValidateName(sw.Name); err != nil {
+			return fmt.Errorf("simulation.scenarios[%d].name: %w", i, err)
+		}
+		if sw.NonSecureShare < 0 || sw.NonSecureShare > 1 {
+			return fmt.Errorf("simulation.scenarios[%d].non_secure_share must be between 0 and 1", i)
+		}
+		if err := sw.Floors.validate(); err != nil {
+			return fmt.Errorf("simulation.scenarios[%d].floors: %w", i, err)
+		}
+		if seen[sw.Name] {
+			return fmt.Errorf("simulation.scenarios: %s is listed more than once", sw.Name)
+		}
+		seen[sw.Name] = true
+	}
+	if s := c.Simulation.NonSecureShare; s < 0 || s > 1 {
+		return errors.New("simulation.non_secure_share must be between 0 and 1")
+	}
+	if c.Simulation.RenderDelay < 0 {
+		return errors.New("simulation.render_delay must not be negative")
+	}
+	if err := c.Simulation.Floors.validate(); err != nil {
+		return fmt.Errorf("simulation.floors: %w", err)
+	}
+	if c.Simulation.UsesScenario("replay") && c.Simulation.Replay.File == "" {
+		return errors.New("simulation.replay.file is required for the replay scenario")
+	}
+	if c.Simulation.Concurrency < 0 {
+		return errors.New("simulation.concurrency must not be negative")
+	}
+	if c.Simulation.BatchSize < 0 {
+		return errors.New("simulation.batch_size must not be negative")
+	}
+	if c.Simulation.RequestBuffer < 0 {
+		return errors.New("simulation.request_buffer must not be negative")
+	}
+	if c.Simulation.Duration < 0 {
+		return errors.New("simulation.duration must not be negative")
+	}
+	if c.Simulation.SpendCap < 0 {
+		return errors.New("simulation.spend_cap must not be negative")
+	}
+	if c.Simulation.TimeScale < 0 {
+		return errors.New("simulation.time_scale must be positive")
+	}
+	for country, w := range c.Simulation.Locales {
+		if w < 0 {
+			return fmt.Errorf("simulation.locales[%s] must not be negative", country)
+		}
+	}
+	for class, w := range c.Simulation.DeviceMix {
+		if w < 0 {
+			return fmt.Errorf("simulation.device_mix[%s] must not be negative", class)
+		}
+	}
+	if sip := c.Simulation.SharedIPs; sip.PoolSize < 0 || sip.Concentration < 0 || sip.Share < 0 || sip.Share > 1 {
+		return errors.New("simulation.shared_ips: pool_size and concentration must not be negative, share must be between 0 and 1")
+	}
+	if u := c.Simulation.Users; u.Size < 0 || u.TTL < 0 {
+		return errors.New("simulation.users: size and ttl must not be negative")
+	}
+	if err := c.Simulation.validateScenarioOptions(); err != nil {
+		return fmt.Errorf("simulation.%w", err)
+	}
+	if cs := c.Simulation.Consent; cs.GDPRShare < 0 || cs.GDPRShare > 1 || cs.USPrivacyShare < 0 || cs.USPrivacyShare > 1 {
+		return errors.New("simulation.consent: gdpr_share and us_privacy_share must be between 0 and 1")
+	}
+	if r := c.Simulation.Ramp; r.Duration < 0 || (r.Enabled() && (r.StartRPS <= 0 || r.EndRPS <= 0)) {
+		return errors.New("simulation.ramp: duration must not be negative, start_rps and end_rps must be positive")
+	}
+	if f := c.Simulation.Fuzz; f.Rate < 0 || f.Rate > 1 {
+		return errors.New("simulation.fuzz.rate must be between 0 and 1")
+	}
+	for i, class := range c.Simulation.Fuzz.Classes {
+		if !fuzz.Valid(fuzz.Class(class)) {
+			return fmt.Errorf("simulation.fuzz.classes[%d]: unknown class %q", i, class)
+		}
+	}
+	if inc, red := c.Auction.Increment, c.Auction.BidReduction; (inc != nil && *inc < 0) || (red != nil && (*red < 0 || *red > 100)) {
+		return errors.New("auction: increment must not be negative, bid_reduction must be between 0 and 100")
+	}
+	if c.Auction.SoftFloor < 0 {
+		return errors.New("auction.soft_floor must not be negative")
+	}
+	if c.Auction.TmaxOverheadMS < 0 {
+		return errors.New("auction.tmax_overhead_ms must not be negative")
+	}
+	if c.Auction.MaxCPM < 0 || c.Auction.MaxFloorRatio < 0 {
+		return errors.New("auction: max_cpm and max_floor_ratio must not be negative")
+	}
+	for _, ms := range c.Auction.LatencyTiersMS {
+		if ms <= 0 {
+			return errors.New("auction: latency_tiers_ms must be positive")
+		}
+	}
+	if c.Notifications.Workers < 0 || c.Notifications.QueueSize < 0 || c.Notifications.TimeoutMS < 0 {
+		return errors.New("notifications: timeout_ms, workers, and queue_size must not be negative")
+	}
+	if r := c.BidLog.SampleRate; r < 0 || r > 1 {
+		return errors.New("bid_log.sample_rate must be between 0 and 1")
+	}
+	if err := c.ResultSink.validate(); err != nil {
+		return fmt.Errorf("result_sink: %w", err)
+	}
+	if c.Rollups.Interval < 0 {
+		return errors.New("rollups.interval must not be negative")
+	}
+	if err := c.Kafka.validate(); err != nil {
+		return fmt.Errorf("kafka: %w", err)
+	}
+	if err := c.Currency.validate(); err != nil {
+		return fmt.Errorf("currency: %w", err)
+	}
+	for _, code := range c.Simulation.Currencies {
+		if !currency.ValidCode(code) {
+			return fmt.Errorf("simulation.currencies: %q is not a three-letter currency code", code)
+		}
+	}
+	if c.CircuitBreaker.ErrorThreshold < 0 || c.CircuitBreaker.Cooldown < 0 {
+		return errors.New("circuit_breaker: error_threshold and cooldown must not be negative")
+	}
+	if err := c.Retries.validate(); err != nil {
+		return fmt.Errorf("retries: %w", err)
+	}
+	if h := c.HealthChecks; h.Interval < 0 || h.Timeout < 0 || h.UnhealthyAfter < 0 {
+		return errors.New("health_checks: interval, timeout, and unhealthy_after must not be negative")
+	}
+	if p := c.HealthChecks.Path; p != "" && !strings.HasPrefix(p, "/") {
+		return errors.New("health_checks.path must start with /")
+	}
+	if c.CreativeQA.MaxAdMBytes < 0 {
+		return errors.New("creative_qa.max_adm_bytes must not be negative")
+	}
+	if err := c.Report.Baseline.validate(); err != nil {
+		return fmt.Errorf("report.baseline: %w", err)
+	}
+	if err := c.Anomalies.validate(); err != nil {
+		return fmt.Errorf("anomalies: %w", err)
+	}
+	if s := c.Shutdown; s.Drain < 0 || s.Notifications < 0 || s.Exporters < 0 || s.Clients < 0 {
+		return errors.New("shutdown: timeouts must not be negative")
+	}
+	if c.State.SweepInterval < 0 {
+		return errors.New("state.sweep_interval must not be negative")
+	}
+	if c.Market.Enabled() {
+		if len(c.DSPs) > 0 {
+			return errors.New("market: dsps must be empty when seats are set")
+		}
+		seats := make(map[string]bool, len(c.Market.Seats))
+		for i, s := range c.Market.Seats {
+			if err := s.validate(); err != nil {
+				return fmt.Errorf("market.seats[%d].%w", i, err)
+			}
+			if seats[s.Name] {
+				return fmt.Errorf("market.seats: %s is listed more than once", s.Name)
+			}
+			seats[s.Name] = true
+		}
+	} else if len(c.DSPs) == 0 {
+		return errors.New("at least one DSP or market seat must be configured")
 	}
 	for i, dsp := range c.DSPs {
-		if dsp.Endpoint == "" {
-			return fmt.Errorf("dsps[%d].endpoint is required", i)
+		if err := dsp.Validate(); err != nil {
+			return fmt.Errorf("dsps[%d].%w", i, err)
+		}
+	}
+	names := make(map[string]bool, len(c.Simulations))
+	for i, ns := range c.Simulations {
+		if err := c.validateSimulation(ns); err != nil {
+			return fmt.Errorf("simulations[%d].%w", i, err)
+		}
+		if names[ns.Name] {
+			return fmt.Errorf("simulations: %s is listed more than once", ns.Name)
+		}
+		names[ns.Name] = true
+	}
+	return nil
+}
+
+// validateSimulation checks a named simulation against c's DSPs and
+// replay settings.
+func (c *Config) validateSimulation(ns NamedSimulation) error {
+	if ns.Name == "" {
+		return errors.New("name is required")
+	}
+	if reservedSimulationNames[ns.Name] {
+		return fmt.Errorf("name: %q is reserved", ns.Name)
+	}
+	if ns.RequestsPerSecond < 0 {
+		return errors.New("requests_per_second must not be negative")
+	}
+	if ns.Scenario != "" {
+		if err := scenarios.ValidateName(ns.Scenario); err != nil {
+			return fmt.Errorf("scenario: %w", err)
+		}
+	}
+	if ns.Scenario == "replay" && c.Simulation.Replay.File == "" {
+		return errors.New("scenario: replay requires simulation.replay.file")
+	}
+	for _, name := range ns.DSPs {
+		if findDSP(c.DSPs, name) < 0 {
+			return fmt.Errorf("dsps: unknown DSP %q", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks a single DSP's settings. Error messages name the
+// offending field relative to the DSP.
+func (d DSPConfig) Validate() error {
+	if d.Endpoint == "" {
+		return errors.New("endpoint is required")
+	}
+	switch d.Protocol {
+	case "", ProtocolJSON, ProtocolProtobuf:
+	default:
+		return fmt.Errorf("protocol: unknown protocol %q (want %s or %s)", d.Protocol, ProtocolJSON, ProtocolProtobuf)
+	}
+	switch d.Parsing {
+	case "", ParsingLenient, ParsingStrict:
+	default:
+		return fmt.Errorf("parsing: unknown mode %q (want %s or %s)", d.Parsing, ParsingLenient, ParsingStrict)
+	}
+	if d.SpendCap < 0 {
+		return errors.New("spend_cap must not be negative")
+	}
+	if err := d.Faults.validate(); err != nil {
+		return fmt.Errorf("faults: %w", err)
+	}
+	for code, class := range d.StatusHandling {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("status_handling: invalid status code %d", code)
+		}
+		switch class {
+		case StatusNoBid, StatusThrottle, StatusOverload, StatusError:
+		default:
+			return fmt.Errorf("status_handling[%d]: unknown classification %q", code, class)
+		}
+	}
+	for i, domain := range d.ADomains.Allow {
+		if domain == "" {
+			return fmt.Errorf("adomains.allow[%d]: domain must not be empty", i)
+		}
+	}
+	for i, domain := range d.ADomains.Block {
+		if domain == "" {
+			return fmt.Errorf("adomains.block[%d]: domain must not be empty", i)
+		}
+	}
+	if d.HTTPS.Share < 0 || d.HTTPS.Share > 1 {
+		return errors.New("https.share must be between 0 and 1")
+	}
+	if d.HTTPS.Enabled() && !strings.HasPrefix(d.HTTPS.Endpoint, "https://") {
+		return errors.New("https.endpoint must be an https:// URL when https.share is set")
+	}
+	if d.AB.Split < 0 || d.AB.Split > 1 {
+		return errors.New("ab.split must be between 0 and 1")
+	}
+	if d.AB.Enabled() && d.AB.Endpoint == "" {
+		return errors.New("ab.endpoint is required when ab.split is set")
+	}
+	if d.TrafficPct < 0 || d.TrafficPct > 100 {
+		return errors.New("traffic_pct must be between 0 and 100")
+	}
+	if d.QPSLimit < 0 {
+		return errors.New("qps_limit must not be negative")
+	}
+	if d.MaxInFlight < 0 {
+		return errors.New("max_in_flight must not be negative")
+	}
+	if d.QueueTimeout < 0 {
+		return errors.New("queue_timeout must not be negative")
+	}
+	if d.HealthPath != "" && !strings.HasPrefix(d.HealthPath, "/") {
+		return errors.New("health_path must start with /")
+	}
+	for _, f := range d.Formats {
+		switch f {
+		case FormatBanner, FormatVideo, FormatNative:
+		default:
+			return fmt.Errorf("formats: unknown format %q (want %s, %s, or %s)", f, FormatBanner, FormatVideo, FormatNative)
 		}
 	}
 	return nil
@@ -102,3 +1644,91 @@ func (c *Config) EnabledDSPs() []DSPConfig {
 	}
 	return enabled
 }
+
+func (r ResultSinkConfig) validate() error {
+	if !r.Enabled() {
+		return nil
+	}
+	if r.Type != SinkClickHouse && r.Type != SinkPostgres {
+		return fmt.Errorf("unknown type %q (want %s or %s)", r.Type, SinkClickHouse, SinkPostgres)
+	}
+	if r.URL == "" {
+		return errors.New("url is required")
+	}
+	if r.BatchSize < 0 || r.QueueSize < 0 || r.FlushInterval < 0 {
+		return errors.New("batch_size, queue_size, and flush_interval must not be negative")
+	}
+	return nil
+}
+
+func (k KafkaConfig) validate() error {
+	if !k.Enabled() {
+		return nil
+	}
+	if k.Format != KafkaJSON && k.Format != KafkaAvro {
+		return fmt.Errorf("unknown format %q (want %s or %s)", k.Format, KafkaJSON, KafkaAvro)
+	}
+	if t := k.Topics; t.Requests == "" && t.Responses == "" && t.Outcomes == "" {
+		return errors.New("at least one of topics.requests, topics.responses, and topics.outcomes is required")
+	}
+	if k.BatchSize < 0 || k.QueueSize < 0 || k.FlushInterval < 0 {
+		return errors.New("batch_size, queue_size, and flush_interval must not be negative")
+	}
+	if k.TLS.CAFile != "" && !k.TLS.Enabled {
+		return errors.New("tls.ca_file requires tls.enabled")
+	}
+	switch k.SASL.Mechanism {
+	case "":
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+		if k.SASL.Username == "" {
+			return fmt.Errorf("sasl.username is required for %s", k.SASL.Mechanism)
+		}
+	default:
+		return fmt.Errorf("unknown sasl.mechanism %q (want %s, %s, or %s)", k.SASL.Mechanism, SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+	return nil
+}
+
+func (b BaselineConfig) validate() error {
+	if !b.Enabled() {
+		return nil
+	}
+	if strings.ContainsAny(b.Name, `/\`) {
+		return fmt.Errorf("name %q must not contain a path separator", b.Name)
+	}
+	if b.MaxWinRateDrop < 0 || b.MaxECPMDrop < 0 || b.MaxLatencyIncrease < 0 || b.MaxErrorRateIncrease < 0 {
+		return errors.New("thresholds must not be negative")
+	}
+	return nil
+}
+
+func (f FaultConfig) validate() error {
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{"reset_rate", f.ResetRate},
+		{"truncate_rate", f.TruncateRate},
+		{"delay_rate", f.DelayRate},
+		{"drop_rate", f.DropRate},
+		{"malformed_rate", f.MalformedRate},
+		{"corrupt_rate", f.CorruptRate},
+	}
+	total := 0.0
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", r.name)
+		}
+		total += r.rate
+	}
+	if total > 1 {
+		return errors.New("combined fault rates must not exceed 1")
+	}
+	if f.Delay < 0 {
+		return errors.New("delay must not be negative")
+	}
+	if f.DelayRate > 0 && f.Delay == 0 {
+		return errors.New("delay is required when delay_rate is set")
+	}
+	return nil
+}
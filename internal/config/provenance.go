@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source identifies where a setting's effective value came from.
+type Source string
+
+const (
+	SourceDefault Source = "default" // left unset, or filled in by defaults
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceAPI     Source = "api" // changed at runtime through the control API
+)
+
+// EnvPrefix starts the names of environment variables that override
+// settings from the file: the prefix followed by the setting's path in
+// upper case with dots as underscores, so RTB_SIM_SIMULATION_REQUESTS_PER_SECOND
+// overrides simulation.requests_per_second. Values are parsed as YAML,
+// except for strings, which are taken as is. Settings inside lists such
+// as dsps cannot be overridden.
+const EnvPrefix = "RTB_SIM_"
+
+// Setting is one resolved configuration value and where it came from.
+type Setting struct {
+	Path   string `json:"path"`
+	Value  any    `json:"value"`
+	Source Source `json:"source"`
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Settings flattens c into its individual settings in field order,
+// attributed to the sources recorded when it was loaded. Durations are
+// rendered as strings such as "1m30s".
+func (c Config) Settings() []Setting {
+	var settings []Setting
+	walkSettings(reflect.ValueOf(c), "", func(path string, v reflect.Value) {
+		value := v.Interface()
+		if v.Type() == durationType {
+			value = time.Duration(v.Int()).String()
+		}
+		settings = append(settings, Setting{Path: path, Value: value, Source: c.source(path)})
+	})
+	return settings
+}
+
+// source returns where the setting at path came from.
+func (c Config) source(path string) Source {
+	if s, ok := c.Sources[path]; ok {
+		return s
+	}
+	return SourceDefault
+}
+
+// WithRuntime returns a copy of c updated with the request rate and DSP
+// list in effect at runtime, attributing settings that differ from c to
+// SourceAPI. An rps of 0 or nil dsps leave that part of c as is.
+func (c Config) WithRuntime(rps int, dsps []DSPConfig) Config {
+	sources := make(map[string]Source, len(c.Sources))
+	for path, s := range c.Sources {
+		if !strings.HasPrefix(path, "dsps[") {
+			sources[path] = s
+		}
+	}
+
+	if rps > 0 && rps != c.Simulation.RequestsPerSecond {
+		c.Simulation.RequestsPerSecond = rps
+		sources["simulation.requests_per_second"] = SourceAPI
+	}
+
+	if dsps == nil {
+		dsps = c.DSPs
+	}
+	for i, dsp := range dsps {
+		prefix := fmt.Sprintf("dsps[%d]", i)
+		j := findDSP(c.DSPs, dsp.Name)
+		walkSettings(reflect.ValueOf(dsp), prefix, func(path string, _ reflect.Value) {
+			if j < 0 || !reflect.DeepEqual(c.DSPs[j], dsp) {
+				sources[path] = SourceAPI
+				return
+			}
+			old := fmt.Sprintf("dsps[%d]", j) + strings.TrimPrefix(path, prefix)
+			if s, ok := c.Sources[old]; ok {
+				sources[path] = s
+			}
+		})
+	}
+	c.DSPs = dsps
+	c.Sources = sources
+	return c
+}
+
+func findDSP(dsps []DSPConfig, name string) int {
+	for i, d := range dsps {
+		if d.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// walkSettings calls fn for each setting under v, named by its dotted
+// YAML path below prefix. Lists of structs are walked element by element
+// as path[i]; other lists and maps are single settings.
+func walkSettings(v reflect.Value, prefix string, fn func(path string, v reflect.Value)) {
+	switch {
+	case v.Kind() == reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			walkSettings(v.Field(i), name, fn)
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		for i := range v.Len() {
+			walkSettings(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), fn)
+		}
+	default:
+		fn(prefix, v)
+	}
+}
+
+// applyEnv overrides settings with any EnvPrefix environment variables
+// set, returning the paths it overrode.
+func (c *Config) applyEnv() (map[string]bool, error) {
+	overridden := make(map[string]bool)
+	var err error
+	walkSettings(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) {
+		if err != nil || strings.Contains(path, "[") {
+			return
+		}
+		name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if v.Kind() == reflect.String {
+			v.SetString(value)
+		} else if uerr := yaml.Unmarshal([]byte(value), v.Addr().Interface()); uerr != nil {
+			err = fmt.Errorf("%s: %w", name, uerr)
+			return
+		}
+		overridden[path] = true
+	})
+	return overridden, err
+}
+
+// filePaths returns the path of every value set in a YAML document,
+// including the mappings and lists that contain them.
+func filePaths(data []byte) (map[string]bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool)
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		if path != "" {
+			paths[path] = true
+		}
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, child := range n.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i].Value
+				if path != "" {
+					key = path + "." + key
+				}
+				walk(n.Content[i+1], key)
+			}
+		case yaml.SequenceNode:
+			for i, child := range n.Content {
+				walk(child, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	walk(&doc, "")
+	return paths, nil
+}
+
+// settingValues returns the value of every setting in c by path.
+func (c *Config) settingValues() map[string]any {
+	values := make(map[string]any)
+	walkSettings(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) {
+		values[path] = v.Interface()
+	})
+	return values
+}
+
+// recordSources attributes each setting of c, now that defaults are
+// applied: to the environment if overridden there, to the file if set
+// there and kept by defaults, and to defaults otherwise.
+func (c *Config) recordSources(inFile, fromEnv map[string]bool, before map[string]any) {
+	c.Sources = make(map[string]Source)
+	walkSettings(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) {
+		if !reflect.DeepEqual(before[path], v.Interface()) {
+			return // filled in by defaults
+		}
+		switch {
+		case fromEnv[path]:
+			c.Sources[path] = SourceEnv
+		case inFile[path]:
+			c.Sources[path] = SourceFile
+		}
+	})
+}
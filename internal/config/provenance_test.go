@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoad_Sources(t *testing.T) {
+	content := `
+server:
+  port: 9090
+simulation:
+  requests_per_second: 50
+  duration: 90s
+  locales:
+    USA: 1
+dsps:
+  - name: "dsp1"
+    endpoint: "http://localhost:9000/bid"
+`
+	path := createTempConfig(t, content)
+	defer os.Remove(path)
+	t.Setenv("RTB_SIM_SIMULATION_REQUESTS_PER_SECOND", "75")
+	t.Setenv("RTB_SIM_AUCTION_TYPE", "second_price")
+	t.Setenv("RTB_SIM_SIMULATION_CURRENCIES", "[EUR, USD]")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Simulation.RequestsPerSecond != 75 || cfg.Auction.Type != "second_price" || len(cfg.Simulation.Currencies) != 2 {
+		t.Fatalf("RequestsPerSecond, Auction.Type, Currencies = %d, %q, %v; want the environment's",
+			cfg.Simulation.RequestsPerSecond, cfg.Auction.Type, cfg.Simulation.Currencies)
+	}
+
+	settings := make(map[string]Setting)
+	for _, s := range cfg.Settings() {
+		settings[s.Path] = s
+	}
+	for path, want := range map[string]Source{
+		"server.port":                    SourceFile,
+		"simulation.requests_per_second": SourceEnv,
+		"simulation.currencies":          SourceEnv,
+		"simulation.locales":             SourceFile,
+		"simulation.duration":            SourceFile,
+		"auction.type":                   SourceEnv,
+		"auction.timeout_ms":             SourceDefault,
+		"simulation.concurrency":         SourceDefault,
+		"dsps[0].endpoint":               SourceFile,
+		"dsps[0].qps_limit":              SourceDefault,
+	} {
+		s, ok := settings[path]
+		if !ok {
+			t.Errorf("%s: missing from Settings()", path)
+			continue
+		}
+		if s.Source != want {
+			t.Errorf("%s: Source = %q, want %q", path, s.Source, want)
+		}
+	}
+	if got := settings["simulation.duration"].Value; got != "1m30s" {
+		t.Errorf("simulation.duration = %v, want 1m30s", got)
+	}
+}
+
+func TestLoad_EnvOverrideInvalid(t *testing.T) {
+	path := createTempConfig(t, "server:\n  port: 8080\n")
+	defer os.Remove(path)
+	t.Setenv("RTB_SIM_SIMULATION_DURATION", "soon")
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load() error = nil for an unparseable override")
+	}
+}
+
+func TestConfig_WithRuntime(t *testing.T) {
+	cfg := Config{
+		Simulation: SimulationConfig{RequestsPerSecond: 10, Duration: time.Minute},
+		DSPs: []DSPConfig{
+			{Name: "dsp1", Endpoint: "http://one/bid", Enabled: true},
+			{Name: "dsp2", Endpoint: "http://two/bid", Enabled: true},
+		},
+		Sources: map[string]Source{
+			"simulation.requests_per_second": SourceFile,
+			"dsps[0].name":                   SourceFile,
+			"dsps[1].name":                   SourceFile,
+			"dsps[1].endpoint":               SourceFile,
+		},
+	}
+
+	// dsp1 was removed, dsp2 kept as is, and dsp3 added
+	got := cfg.WithRuntime(20, []DSPConfig{
+		{Name: "dsp2", Endpoint: "http://two/bid", Enabled: true},
+		{Name: "dsp3", Endpoint: "http://three/bid", Enabled: true},
+	})
+	if got.Simulation.RequestsPerSecond != 20 || len(got.DSPs) != 2 {
+		t.Fatalf("RequestsPerSecond, DSPs = %d, %d; want 20, 2", got.Simulation.RequestsPerSecond, len(got.DSPs))
+	}
+	for path, want := range map[string]Source{
+		"simulation.requests_per_second": SourceAPI,
+		"simulation.duration":            SourceDefault,
+		"dsps[0].name":                   SourceFile,
+		"dsps[0].endpoint":               SourceFile,
+		"dsps[0].qps_limit":              SourceDefault,
+		"dsps[1].name":                   SourceAPI,
+		"dsps[1].qps_limit":              SourceAPI,
+	} {
+		if s := got.source(path); s != want {
+			t.Errorf("%s: source = %q, want %q", path, s, want)
+		}
+	}
+	if cfg.Simulation.RequestsPerSecond != 10 || cfg.Sources["dsps[1].name"] != SourceFile {
+		t.Error("WithRuntime() modified its receiver")
+	}
+
+	// Nothing changed at runtime
+	same := cfg.WithRuntime(0, nil)
+	if s := same.source("dsps[1].endpoint"); s != SourceFile {
+		t.Errorf("dsps[1].endpoint: source = %q, want file", s)
+	}
+}
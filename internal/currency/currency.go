@@ -0,0 +1,126 @@
+// Package currency converts prices between currencies with a table of
+// exchange rates, either configured statically or fetched from a URL.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Default is the currency of OpenRTB prices that do not name one.
+const Default = "USD"
+
+// ErrUnknownCurrency is returned when a conversion involves a currency
+// without an exchange rate.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCode reports whether code is an ISO-4217 alphabetic code in the
+// form OpenRTB uses: three upper-case letters.
+func ValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+// Table holds exchange rates relative to a base currency: a rate of 0.9
+// for EUR means one unit of the base currency buys 0.9 EUR. Safe for
+// concurrent use; rates may be replaced while conversions run.
+type Table struct {
+	base string
+
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+// NewTable creates a table with rates relative to base. An empty base is
+// Default.
+func NewTable(base string, rates map[string]float64) (*Table, error) {
+	if base == "" {
+		base = Default
+	}
+	if !ValidCode(base) {
+		return nil, fmt.Errorf("base currency %q is not a three-letter code", base)
+	}
+	t := &Table{base: base}
+	if err := t.Update(base, rates); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Base returns the currency rates are relative to.
+func (t *Table) Base() string {
+	return t.base
+}
+
+// Update replaces the rates with rates relative to base, which need not
+// be the table's base as long as rates include the table's base.
+func (t *Table) Update(base string, rates map[string]float64) error {
+	next := make(map[string]float64, len(rates)+1)
+	for code, rate := range rates {
+		if !ValidCode(code) {
+			return fmt.Errorf("currency %q is not a three-letter code", code)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("rate for %s must be positive", code)
+		}
+		next[code] = rate
+	}
+	next[base] = 1
+
+	if base != t.base {
+		own, ok := next[t.base]
+		if !ok {
+			return fmt.Errorf("rates relative to %s have no rate for %s", base, t.base)
+		}
+		for code, rate := range next {
+			next[code] = rate / own
+		}
+	}
+
+	t.mu.Lock()
+	t.rates = next
+	t.mu.Unlock()
+	return nil
+}
+
+// Rates returns a copy of the current rates, including the base.
+func (t *Table) Rates() map[string]float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rates := make(map[string]float64, len(t.rates))
+	for code, rate := range t.rates {
+		rates[code] = rate
+	}
+	return rates
+}
+
+// Convert converts amount from one currency to another. An empty
+// currency is Default.
+func (t *Table) Convert(amount float64, from, to string) (float64, error) {
+	if from == "" {
+		from = Default
+	}
+	if to == "" {
+		to = Default
+	}
+	if from == to {
+		return amount, nil
+	}
+
+	t.mu.RLock()
+	fromRate, fromOK := t.rates[from]
+	toRate, toOK := t.rates[to]
+	t.mu.RUnlock()
+
+	if !fromOK {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	if !toOK {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+	return amount / fromRate * toRate, nil
+}
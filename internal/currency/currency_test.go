@@ -0,0 +1,113 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTable_Convert(t *testing.T) {
+	table, err := NewTable("", map[string]float64{"EUR": 0.8, "GBP": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Base() != "USD" {
+		t.Errorf("Base() = %q, want USD", table.Base())
+	}
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+	}{
+		{2, "EUR", "USD", 2.5},
+		{2, "USD", "EUR", 1.6},
+		{1, "GBP", "EUR", 1.6},
+		{3, "", "USD", 3},
+		{3, "EUR", "EUR", 3},
+	}
+	for _, tt := range tests {
+		got, err := table.Convert(tt.amount, tt.from, tt.to)
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Convert(%v, %q, %q) = %v, %v, want %v", tt.amount, tt.from, tt.to, got, err, tt.want)
+		}
+	}
+
+	if _, err := table.Convert(1, "JPY", "USD"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Convert(JPY) error = %v, want ErrUnknownCurrency", err)
+	}
+}
+
+func TestTable_Update(t *testing.T) {
+	table, err := NewTable("USD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rates quoted against EUR are rebased to USD
+	if err := table.Update("EUR", map[string]float64{"USD": 1.25, "GBP": 0.625}); err != nil {
+		t.Fatal(err)
+	}
+	rates := table.Rates()
+	if rates["USD"] != 1 || rates["EUR"] != 0.8 || rates["GBP"] != 0.5 {
+		t.Errorf("Rates() = %v, want USD 1, EUR 0.8, GBP 0.5", rates)
+	}
+
+	for _, bad := range []map[string]float64{
+		{"GBP": 0.5},  // no rate for the table's base
+		{"usd": 1},    // not a currency code
+		{"USD": -1.0}, // not positive
+	} {
+		if err := table.Update("EUR", bad); err == nil {
+			t.Errorf("Update(%v) error = nil", bad)
+		}
+	}
+	if _, err := NewTable("dollars", nil); err == nil {
+		t.Error("NewTable(invalid base) error = nil")
+	}
+}
+
+func TestRefresher(t *testing.T) {
+	var eur atomic.Value
+	eur.Store("0.8")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": ` + eur.Load().(string) + `}}`))
+	}))
+	defer srv.Close()
+
+	table, _ := NewTable("USD", nil)
+	r, err := NewRefresher(table, srv.URL, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if got := table.Rates()["EUR"]; got != 0.8 {
+		t.Errorf("EUR after first load = %v, want 0.8", got)
+	}
+
+	eur.Store("0.9")
+	deadline := time.Now().Add(time.Second)
+	for table.Rates()["EUR"] != 0.9 {
+		if time.Now().After(deadline) {
+			t.Fatal("rates were not refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewRefresher_FailedLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	table, _ := NewTable("USD", nil)
+	if _, err := NewRefresher(table, srv.URL, time.Minute); err == nil {
+		t.Error("NewRefresher error = nil, want the failed load")
+	}
+}
@@ -0,0 +1,103 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Feed is the JSON document a rate URL serves:
+//
+//	{"base": "USD", "rates": {"EUR": 0.92, "GBP": 0.79}}
+type Feed struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Fetch downloads a rate feed from url.
+func Fetch(ctx context.Context, client *http.Client, url string) (Feed, error) {
+	var feed Feed
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return feed, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return feed, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return feed, fmt.Errorf("fetching rates: status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return feed, fmt.Errorf("decoding rates: %w", err)
+	}
+	if feed.Base == "" {
+		feed.Base = Default
+	}
+	return feed, nil
+}
+
+// Refresher keeps a table up to date from a rate URL. A failed refresh
+// is logged and the previous rates stay in effect.
+type Refresher struct {
+	table  *Table
+	url    string
+	client *http.Client
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRefresher loads the rates at url into table and refreshes them
+// every interval until Close. It fails if the first load fails.
+func NewRefresher(table *Table, url string, interval time.Duration) (*Refresher, error) {
+	r := &Refresher{
+		table:  table,
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		done:   make(chan struct{}),
+	}
+	if err := r.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.run(ctx, interval)
+	return r, nil
+}
+
+// Close stops refreshing.
+func (r *Refresher) Close() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *Refresher) run(ctx context.Context, interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("currency: refreshing rates from %s: %v", r.url, err)
+			}
+		}
+	}
+}
+
+func (r *Refresher) refresh(ctx context.Context) error {
+	feed, err := Fetch(ctx, r.client, r.url)
+	if err != nil {
+		return err
+	}
+	return r.table.Update(feed.Base, feed.Rates)
+}
@@ -0,0 +1,43 @@
+package dispatcher
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter caps the request rate sent to a DSP, as an exchange enforcing
+// a bidder's QPS allotment would. It is a token bucket holding a tenth of
+// a second of requests, so the cap holds over short windows too.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64 // requests per second; 0 means unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(qps float64) limiter {
+	burst := max(qps/10, 1)
+	return limiter{rate: qps, burst: burst, tokens: burst}
+}
+
+// allow reports whether a request may be sent at now, consuming a token
+// if so.
+func (l *limiter) allow(now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
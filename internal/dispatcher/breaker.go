@@ -0,0 +1,98 @@
+package dispatcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/events"
+)
+
+// breaker is a per-DSP circuit breaker. After threshold consecutive
+// failures it opens and the DSP is skipped for cooldown; the first request
+// after the cooldown is a probe that closes the breaker on success or
+// reopens it on failure. A zero threshold disables the breaker.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether a request should be sent to the DSP at now.
+func (b *breaker) allow(now time.Time) bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of an allowed request,
+// reporting whether it opened the breaker.
+func (b *breaker) record(now time.Time, failed bool) (opened bool) {
+	if b.threshold <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		b.probing = false
+		return false
+	}
+
+	b.failures++
+	if b.probing || b.failures >= b.threshold {
+		// Failures of requests sent before the breaker opened extend it
+		// without opening it again
+		opened = b.openUntil.IsZero() || b.probing
+		b.openUntil = now.Add(b.cooldown)
+		b.probing = false
+	}
+	return opened
+}
+
+// abandon releases an allowed request whose outcome says nothing about the
+// DSP's health, such as one cut short by context cancellation.
+func (b *breaker) abandon() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// BreakerOpened is published when a DSP's circuit breaker opens, or
+// reopens after a failed probe.
+type BreakerOpened struct {
+	Time  time.Time
+	DSP   string
+	Until time.Time // when the next probe may be sent
+}
+
+// recordBreaker updates the DSP's breaker with the outcome of a request,
+// publishing a BreakerOpened event if that opened it.
+func (d *Dispatcher) recordBreaker(dsp *endpoint, failed bool) {
+	now := d.clock.Now()
+	if dsp.breaker.record(now, failed) {
+		events.Publish(d.events, BreakerOpened{Time: now, DSP: dsp.Name, Until: now.Add(dsp.breaker.cooldown)})
+	}
+}
@@ -0,0 +1,257 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/events"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestBreaker_Disabled(t *testing.T) {
+	var b breaker
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		b.record(now, true)
+	}
+	if !b.allow(now) {
+		t.Error("allow() = false with breaker disabled")
+	}
+}
+
+func TestBreaker_TripsAndRecovers(t *testing.T) {
+	b := breaker{threshold: 3, cooldown: time.Second}
+	now := time.Now()
+
+	b.record(now, true)
+	b.record(now, true)
+	if !b.allow(now) {
+		t.Fatal("allow() = false below threshold")
+	}
+	b.record(now, true)
+	if b.allow(now.Add(500 * time.Millisecond)) {
+		t.Fatal("allow() = true while open")
+	}
+
+	// After the cooldown exactly one probe is let through
+	probeAt := now.Add(time.Second)
+	if !b.allow(probeAt) {
+		t.Fatal("allow() = false for probe after cooldown")
+	}
+	if b.allow(probeAt) {
+		t.Error("allow() = true for second request while probing")
+	}
+
+	b.record(probeAt, false)
+	if !b.allow(probeAt) {
+		t.Error("allow() = false after successful probe")
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b := breaker{threshold: 2, cooldown: time.Second}
+	now := time.Now()
+
+	b.record(now, true)
+	b.record(now, true)
+
+	probeAt := now.Add(time.Second)
+	if !b.allow(probeAt) {
+		t.Fatal("allow() = false for probe after cooldown")
+	}
+	b.record(probeAt, true)
+	if b.allow(probeAt.Add(500 * time.Millisecond)) {
+		t.Error("allow() = true after failed probe, want reopened")
+	}
+	if !b.allow(probeAt.Add(time.Second)) {
+		t.Error("allow() = false after second cooldown")
+	}
+}
+
+func TestBreaker_AbandonedProbe(t *testing.T) {
+	b := breaker{threshold: 1, cooldown: time.Second}
+	now := time.Now()
+
+	b.record(now, true)
+	probeAt := now.Add(time.Second)
+	if !b.allow(probeAt) {
+		t.Fatal("allow() = false for probe after cooldown")
+	}
+	b.abandon()
+	if !b.allow(probeAt) {
+		t.Error("allow() = false after abandoned probe, want another probe")
+	}
+}
+
+func TestDispatcher_Dispatch_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{{Name: "dead", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(5*time.Second), WithCircuitBreaker(3, time.Minute))
+
+	skipped := 0
+	for i := 0; i < 10; i++ {
+		results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+		if results[0].Skipped == SkipCircuitOpen {
+			skipped++
+		}
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("server calls = %d, want 3", calls.Load())
+	}
+	if skipped != 7 {
+		t.Errorf("skipped = %d, want 7", skipped)
+	}
+}
+
+func TestDispatcher_CircuitBreaker_OverloadBetweenFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 500, 503, 500, 429, 500, ...
+		switch calls.Add(1) % 4 {
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 0:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{{Name: "flaky", Endpoint: server.URL, Enabled: true, StatusHandling: map[int]string{
+		http.StatusServiceUnavailable: config.StatusOverload,
+		http.StatusTooManyRequests:    config.StatusThrottle,
+	}}}
+	d := New(dsps, WithTimeout(5*time.Second), WithCircuitBreaker(3, time.Minute))
+
+	for range 10 {
+		d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	}
+
+	// The third 500 opens the breaker; the 503 and 429 between them
+	// neither count nor reset the failures
+	if got := calls.Load(); got != 5 {
+		t.Errorf("server calls = %d, want 5", got)
+	}
+}
+
+func TestDispatcher_CircuitBreaker_ManualClock(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	clk := clock.NewManual(time.Now())
+	dsps := []config.DSPConfig{{Name: "dead", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(5*time.Second), WithCircuitBreaker(1, time.Minute), WithClock(clk))
+	dispatch := func() Result {
+		return d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})[0]
+	}
+
+	dispatch()
+	clk.Advance(59 * time.Second)
+	if r := dispatch(); r.Skipped != SkipCircuitOpen {
+		t.Errorf("Skipped = %q during cooldown, want %q", r.Skipped, SkipCircuitOpen)
+	}
+	clk.Advance(time.Second)
+	if r := dispatch(); r.Skipped != SkipNone {
+		t.Errorf("Skipped = %q after cooldown, want a probe", r.Skipped)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
+
+func TestDispatcher_CircuitBreaker_KeepsQuota(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	clk := clock.NewManual(time.Now())
+	dsps := []config.DSPConfig{{Name: "capped", Endpoint: server.URL, Enabled: true, QPSLimit: 1}}
+	d := New(dsps, WithTimeout(5*time.Second), WithCircuitBreaker(1, 1500*time.Millisecond), WithClock(clk))
+	dispatch := func() Result {
+		return d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})[0]
+	}
+
+	dispatch()
+	clk.Advance(time.Second)
+	if r := dispatch(); r.Skipped != SkipCircuitOpen {
+		t.Fatalf("Skipped = %q during cooldown, want %q", r.Skipped, SkipCircuitOpen)
+	}
+	// The token refilled during the cooldown is still there for the probe
+	clk.Advance(500 * time.Millisecond)
+	if r := dispatch(); r.Skipped != SkipNone {
+		t.Errorf("Skipped = %q after cooldown, want a probe", r.Skipped)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
+
+func TestBreaker_RecordReportsOpening(t *testing.T) {
+	b := breaker{threshold: 2, cooldown: time.Second}
+	now := time.Now()
+
+	if b.record(now, true) {
+		t.Error("record() = true below threshold")
+	}
+	if !b.record(now, true) {
+		t.Error("record() = false when the breaker opened")
+	}
+	if b.record(now, true) {
+		t.Error("record() = true for a late failure while already open")
+	}
+
+	probeAt := now.Add(time.Second)
+	b.allow(probeAt)
+	if !b.record(probeAt, true) {
+		t.Error("record() = false when a failed probe reopened the breaker")
+	}
+}
+
+func TestDispatcher_BreakerOpenedEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	bus := events.New()
+	var opened []BreakerOpened
+	events.Subscribe(bus, "test", func(ev BreakerOpened) { opened = append(opened, ev) })
+
+	dsps := []config.DSPConfig{{Name: "dead", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(5*time.Second), WithCircuitBreaker(2, time.Minute), WithEvents(bus))
+	for range 5 {
+		d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	}
+	bus.Close()
+
+	if len(opened) != 1 {
+		t.Fatalf("BreakerOpened events = %d, want 1", len(opened))
+	}
+	if ev := opened[0]; ev.DSP != "dead" || ev.Until.Sub(ev.Time) != time.Minute {
+		t.Errorf("BreakerOpened = %+v, want dead open for a minute", ev)
+	}
+}
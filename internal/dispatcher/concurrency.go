@@ -0,0 +1,61 @@
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+)
+
+// slots caps the requests a DSP has in flight, as a bidder's connection
+// limit would. A request that finds every slot taken waits for one up to
+// the queue timeout; a QPS cap alone cannot model this, since slow
+// responses hold slots longer. The zero value is unlimited.
+type slots struct {
+	free    chan struct{} // nil when unlimited
+	timeout time.Duration // longest wait for a slot; 0 does not wait
+}
+
+func newSlots(n int, timeout time.Duration) slots {
+	if n <= 0 {
+		return slots{}
+	}
+	return slots{free: make(chan struct{}, n), timeout: timeout}
+}
+
+// acquire takes a slot, waiting until one is released, the queue timeout
+// or budget runs out on clk, or ctx ends, whichever comes first. It
+// returns how long it waited and whether it got a slot, which must then be
+// released.
+func (s *slots) acquire(ctx context.Context, clk clock.Clock, budget time.Duration) (time.Duration, bool) {
+	if s.free == nil {
+		return 0, true
+	}
+	select {
+	case s.free <- struct{}{}:
+		return 0, true
+	default:
+	}
+	wait := min(s.timeout, budget)
+	if wait <= 0 {
+		return 0, false
+	}
+
+	start := clk.Now()
+	timer := clk.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case s.free <- struct{}{}:
+		return clk.Since(start), true
+	case <-timer.C():
+	case <-ctx.Done():
+	}
+	return clk.Since(start), false
+}
+
+// release frees a slot taken by acquire.
+func (s *slots) release() {
+	if s.free != nil {
+		<-s.free
+	}
+}
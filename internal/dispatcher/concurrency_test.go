@@ -0,0 +1,135 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestSlots_Unlimited(t *testing.T) {
+	var s slots
+	for range 100 {
+		if _, ok := s.acquire(context.Background(), clock.Real, time.Second); !ok {
+			t.Fatal("acquire() = false without a cap")
+		}
+	}
+}
+
+func TestSlots_NoWait(t *testing.T) {
+	s := newSlots(1, 0)
+	if _, ok := s.acquire(context.Background(), clock.Real, time.Second); !ok {
+		t.Fatal("first acquire() = false")
+	}
+	if waited, ok := s.acquire(context.Background(), clock.Real, time.Second); ok || waited != 0 {
+		t.Fatalf("acquire() with every slot taken = %v, %v, want 0, false", waited, ok)
+	}
+	s.release()
+	if _, ok := s.acquire(context.Background(), clock.Real, time.Second); !ok {
+		t.Error("acquire() after release = false")
+	}
+}
+
+func TestSlots_Wait(t *testing.T) {
+	s := newSlots(1, time.Second)
+	s.acquire(context.Background(), clock.Real, time.Second)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.release()
+	}()
+	waited, ok := s.acquire(context.Background(), clock.Real, time.Second)
+	if !ok || waited < 10*time.Millisecond {
+		t.Errorf("acquire() = %v, %v, want a slot after ~20ms", waited, ok)
+	}
+
+	// The budget bounds the wait as well as the queue timeout
+	waited, ok = s.acquire(context.Background(), clock.Real, 20*time.Millisecond)
+	if ok || waited < 10*time.Millisecond || waited > 500*time.Millisecond {
+		t.Errorf("acquire() = %v, %v, want false after ~20ms", waited, ok)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := s.acquire(ctx, clock.Real, time.Second); ok {
+		t.Error("acquire() with a cancelled context = true")
+	}
+}
+
+func TestSlots_Wait_ManualClock(t *testing.T) {
+	clk := clock.NewManual(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newSlots(1, time.Minute)
+	s.acquire(context.Background(), clk, time.Minute)
+
+	type acquired struct {
+		waited time.Duration
+		ok     bool
+	}
+	done := make(chan acquired)
+	go func() {
+		waited, ok := s.acquire(context.Background(), clk, 30*time.Second)
+		done <- acquired{waited, ok}
+	}()
+	for clk.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clk.Advance(30 * time.Second)
+	if got := <-done; got.ok || got.waited != 30*time.Second {
+		t.Errorf("acquire() = %v, %v, want false after the 30s budget", got.waited, got.ok)
+	}
+}
+
+func TestDispatcher_Dispatch_MaxInFlight(t *testing.T) {
+	var inFlight atomic.Int32
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Add(1)
+		<-unblock
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{
+		{Name: "capped", Endpoint: server.URL, Enabled: true, MaxInFlight: 2},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	var wg sync.WaitGroup
+	held := make([]Result, 2)
+	for i := range held {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			held[i] = d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})[0]
+		}()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for inFlight.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	if results[0].Skipped != SkipSaturated {
+		t.Errorf("Skipped = %q with both slots taken, want %q", results[0].Skipped, SkipSaturated)
+	}
+
+	close(unblock)
+	wg.Wait()
+	for _, r := range held {
+		if r.Skipped != SkipNone || r.Error != nil {
+			t.Errorf("held call = skipped %q, error %v, want sent", r.Skipped, r.Error)
+		}
+	}
+	results = d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	if results[0].Skipped != SkipNone {
+		t.Errorf("Skipped = %q after the slots freed, want none", results[0].Skipped)
+	}
+}
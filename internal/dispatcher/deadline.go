@@ -0,0 +1,81 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// ErrDeadlineExpired is returned for every DSP when a request's Tmax has
+// already been spent before dispatch.
+var ErrDeadlineExpired = errors.New("request deadline expired before dispatch")
+
+// maxTmaxBudget caps deadlines derived from Tmax, and is the client
+// timeout when they are enabled.
+const maxTmaxBudget = 10 * time.Second
+
+// WithTmaxDeadline gives DSPs each request's Tmax, less overhead for the
+// exchange's own processing, rather than the fixed timeout, as a real
+// exchange would. The timeout then only applies to requests without a
+// Tmax. Deadlines are capped at 10s.
+func WithTmaxDeadline(overhead time.Duration) Option {
+	return func(dp *Dispatcher) {
+		dp.tmaxDeadline = true
+		dp.tmaxOverhead = overhead
+	}
+}
+
+type startKey struct{}
+
+// ContextWithStart records when the auction for a request began, so time
+// spent before dispatch (generation, enrichment) is charged against the
+// request's Tmax.
+func ContextWithStart(ctx context.Context, start time.Time) context.Context {
+	return context.WithValue(ctx, startKey{}, start)
+}
+
+// budget returns how long DSPs may take to answer req: what is left of its
+// Tmax since the auction started, capped by the dispatcher timeout and any
+// ctx deadline. With Tmax deadlines the overhead is also deducted and the
+// timeout only applies without a Tmax.
+func (d *Dispatcher) budget(ctx context.Context, req *openrtb.BidRequest, now time.Time) time.Duration {
+	b := d.timeout
+	if req.Tmax > 0 {
+		start, ok := ctx.Value(startKey{}).(time.Time)
+		if !ok {
+			start = now
+		}
+		left := start.Add(time.Duration(req.Tmax) * time.Millisecond).Sub(now)
+		if d.tmaxDeadline {
+			b = min(left-d.tmaxOverhead, maxTmaxBudget)
+		} else {
+			b = min(b, left)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		b = min(b, deadline.Sub(now))
+	}
+	return b
+}
+
+// withTmax returns req with Tmax lowered to the remaining budget. The
+// caller's request is left untouched.
+func withTmax(req *openrtb.BidRequest, budget time.Duration) *openrtb.BidRequest {
+	tmax := int(budget / time.Millisecond)
+	if req.Tmax > 0 && req.Tmax <= tmax {
+		return req
+	}
+	out := *req
+	out.Tmax = tmax
+	return &out
+}
+
+// clientTimeout is the longest any call may take.
+func (d *Dispatcher) clientTimeout() time.Duration {
+	if d.tmaxDeadline {
+		return max(d.timeout, maxTmaxBudget)
+	}
+	return d.timeout
+}
@@ -0,0 +1,147 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestDispatcher_Budget(t *testing.T) {
+	d := &Dispatcher{timeout: 100 * time.Millisecond}
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		tmax int
+		want time.Duration
+	}{
+		{"no tmax uses timeout", context.Background(), 0, 100 * time.Millisecond},
+		{"tmax without start", context.Background(), 80, 80 * time.Millisecond},
+		{"tmax longer than timeout", context.Background(), 500, 100 * time.Millisecond},
+		{"time spent before dispatch", ContextWithStart(context.Background(), now.Add(-30*time.Millisecond)), 80, 50 * time.Millisecond},
+		{"tmax already spent", ContextWithStart(context.Background(), now.Add(-time.Second)), 80, -920 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := d.budget(tt.ctx, &openrtb.BidRequest{Tmax: tt.tmax}, now)
+			if got != tt.want {
+				t.Errorf("budget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Budget_TmaxDeadline(t *testing.T) {
+	d := &Dispatcher{timeout: 100 * time.Millisecond, tmaxDeadline: true, tmaxOverhead: 10 * time.Millisecond}
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		tmax int
+		want time.Duration
+	}{
+		{"no tmax uses timeout", context.Background(), 0, 100 * time.Millisecond},
+		{"tmax less overhead", context.Background(), 80, 70 * time.Millisecond},
+		{"tmax longer than timeout", context.Background(), 500, 490 * time.Millisecond},
+		{"time spent before dispatch", ContextWithStart(context.Background(), now.Add(-30*time.Millisecond)), 80, 40 * time.Millisecond},
+		{"capped", context.Background(), 60000, maxTmaxBudget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := d.budget(tt.ctx, &openrtb.BidRequest{Tmax: tt.tmax}, now)
+			if got != tt.want {
+				t.Errorf("budget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Dispatch_TmaxDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// The fixed timeout would cut the DSP off; the request's tmax does not
+	dsps := []config.DSPConfig{{Name: "dsp", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(50*time.Millisecond), WithTmaxDeadline(10*time.Millisecond))
+	defer d.Close()
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1", Tmax: 1000})
+	if err := results[0].Error; err != nil {
+		t.Errorf("Error = %v, want the call to finish within tmax", err)
+	}
+}
+
+func TestDispatcher_Budget_ContextDeadline(t *testing.T) {
+	d := &Dispatcher{timeout: 100 * time.Millisecond}
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(20*time.Millisecond))
+	defer cancel()
+
+	if got := d.budget(ctx, &openrtb.BidRequest{Tmax: 80}, now); got != 20*time.Millisecond {
+		t.Errorf("budget() = %v, want 20ms", got)
+	}
+}
+
+func TestDispatcher_Dispatch_PropagatesTmax(t *testing.T) {
+	var gotTmax atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openrtb.BidRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		gotTmax.Store(int64(req.Tmax))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{{Name: "dsp", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	req := &openrtb.BidRequest{ID: "req-1", Tmax: 1000}
+	ctx := ContextWithStart(context.Background(), time.Now().Add(-400*time.Millisecond))
+	d.Dispatch(ctx, req)
+
+	if got := gotTmax.Load(); got <= 0 || got > 600 {
+		t.Errorf("bidder saw tmax = %d, want <= 600 after 400ms spent", got)
+	}
+	if req.Tmax != 1000 {
+		t.Errorf("caller's Tmax = %d, want unchanged 1000", req.Tmax)
+	}
+}
+
+func TestDispatcher_Dispatch_DeadlineExpired(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{{Name: "dsp", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	ctx := ContextWithStart(context.Background(), time.Now().Add(-time.Second))
+	results := d.Dispatch(ctx, &openrtb.BidRequest{ID: "req-1", Tmax: 100})
+
+	if !errors.Is(results[0].Error, ErrDeadlineExpired) {
+		t.Errorf("Error = %v, want ErrDeadlineExpired", results[0].Error)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("server calls = %d, want 0", calls.Load())
+	}
+}
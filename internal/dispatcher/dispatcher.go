@@ -4,10 +4,20 @@ package dispatcher
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/cass/rtb-simulator/internal/clock"
 	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/events"
+	"github.com/cass/rtb-simulator/internal/fuzz"
 	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/internal/randutil"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
@@ -17,6 +27,89 @@ type Result struct {
 	Response *openrtb.BidResponse
 	Error    error
 	Latency  time.Duration
+	Fault    FaultKind
+	Class    StatusClass
+
+	// Status is the HTTP status the DSP answered with, or 0 if the call
+	// got no response, such as on a timeout or refused connection.
+	Status int
+
+	// Skipped is set when the DSP was not called for this request.
+	Skipped SkipReason
+
+	// TLS is set when the request was sent over HTTPS.
+	TLS bool
+
+	// Arm is the A/B arm that served the request, or ArmNone for DSPs
+	// without a split.
+	Arm Arm
+
+	// Mutation is the class of the malformed request sent to the DSP when
+	// fuzzing, or fuzz.ClassNone for a well-formed one.
+	Mutation fuzz.Class
+
+	// RetryAfter is the backoff window requested by a 429 response.
+	RetryAfter time.Duration
+
+	// Retries is how many times the call was retried after a transient
+	// failure; Error is that of the last attempt.
+	Retries int
+
+	// QueueWait is how long the call waited for one of the DSP's in-flight
+	// slots, whether or not it got one.
+	QueueWait time.Duration
+
+	// TraceID is shared by every DSP call in the auction; SpanID identifies
+	// this call. Both are sent in the traceparent header.
+	TraceID string
+	SpanID  string
+}
+
+// SkipReason explains why a DSP was not called for a request.
+type SkipReason string
+
+const (
+	SkipNone        SkipReason = ""
+	SkipThrottled   SkipReason = "throttled"
+	SkipCircuitOpen SkipReason = "circuit_open"
+	SkipPaused      SkipReason = "paused"
+	SkipUnhealthy   SkipReason = "unhealthy"   // failing health checks, with auto-disable on
+	SkipUnsupported SkipReason = "unsupported" // no impression in a format the DSP buys
+	SkipTraffic     SkipReason = "traffic_pct" // outside the DSP's traffic allocation
+	SkipQPSLimit    SkipReason = "qps_limit"
+	SkipSaturated   SkipReason = "saturated" // no in-flight slot freed up in time
+	SkipCancelled   SkipReason = "cancelled" // run stopped before the call completed
+)
+
+// Arm identifies which of a DSP's A/B endpoints served a request.
+type Arm string
+
+const (
+	ArmNone Arm = ""
+	ArmA    Arm = "a"
+	ArmB    Arm = "b"
+)
+
+// endpoint couples a DSP's configuration with its runtime dispatch state.
+type endpoint struct {
+	config.DSPConfig
+	throttle throttle
+	limiter  limiter
+	slots    slots
+	breaker  breaker
+	paused   atomic.Bool
+	formats  formatSet // impression formats the DSP buys
+
+	// client is the DSP's own client when it receives HTTPS traffic, so
+	// its connections and TLS sessions are tracked separately. nil uses
+	// the shared client.
+	client *httpclient.Client
+	codec  httpclient.Codec // the DSP's wire protocol
+
+	// reachability holds the DSP's preflight results; nil if unchecked.
+	reachability atomic.Pointer[[]Reachability]
+	// health holds the DSP's latest health check; nil if unchecked.
+	health atomic.Pointer[Health]
 }
 
 // indexedResult pairs a result with its index for channel communication.
@@ -27,11 +120,48 @@ type indexedResult struct {
 }
 
 // Dispatcher sends bid requests to multiple DSPs concurrently.
+// Its DSP set can be changed at runtime; see AddDSP, RemoveDSP, and
+// SetEnabled.
 type Dispatcher struct {
-	client          *httpclient.Client
-	dsps            []config.DSPConfig
+	client *httpclient.Client
+
+	// dsps holds every known DSP and active the enabled subset. Both are
+	// replaced rather than modified, so Dispatch can use a snapshot
+	// without holding mu.
+	mu     sync.RWMutex
+	dsps   []*endpoint
+	active []*endpoint
+
 	timeout         time.Duration
 	maxConnsPerHost int
+
+	// tmaxDeadline derives call deadlines from Tmax less tmaxOverhead.
+	tmaxDeadline bool
+	tmaxOverhead time.Duration
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	retry httpclient.RetryPolicy
+
+	tlsRecorder TLSRecorder
+	clock       clock.Clock // drives rate limits, throttling, and breakers
+	events      *events.Bus // nil when no events are published
+	fuzzer      *fuzz.Fuzzer
+
+	healthChecks   config.HealthCheckConfig
+	healthRecorder HealthRecorder
+
+	// Preflight and health checks run until Close cancels preflightCtx.
+	preflightTimeout time.Duration
+	preflightCtx     context.Context
+	stopPreflight    context.CancelFunc
+	preflights       sync.WaitGroup
+}
+
+// TLSRecorder receives the TLS handshakes made to reach each DSP.
+type TLSRecorder interface {
+	RecordTLSHandshake(dspName string, resumed bool, d time.Duration)
 }
 
 // Option configures the dispatcher.
@@ -51,57 +181,146 @@ func WithMaxConnsPerHost(n int) Option {
 	}
 }
 
-// New creates a new dispatcher for the given DSPs.
-// The dsps slice should contain only enabled DSPs (use Config.EnabledDSPs()).
+// WithCircuitBreaker skips a DSP for cooldown after threshold consecutive
+// failed requests (errors and timeouts). A zero threshold disables it.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(dp *Dispatcher) {
+		dp.breakerThreshold = threshold
+		dp.breakerCooldown = cooldown
+	}
+}
+
+// WithRetries retries DSP calls that fail transiently as rc configures,
+// within the call's deadline. Only the last attempt counts toward the
+// circuit breaker.
+func WithRetries(rc config.RetryConfig) Option {
+	return func(dp *Dispatcher) {
+		dp.retry = httpclient.RetryPolicy{
+			Max:         rc.Max,
+			Backoff:     rc.Backoff,
+			ConnRefused: slices.Contains(rc.On, config.RetryConnRefused),
+			ConnReset:   slices.Contains(rc.On, config.RetryConnReset),
+			Statuses:    rc.Statuses(),
+		}
+	}
+}
+
+// WithTLSRecorder reports TLS handshakes with DSPs that receive HTTPS
+// traffic to r.
+func WithTLSRecorder(r TLSRecorder) Option {
+	return func(dp *Dispatcher) {
+		dp.tlsRecorder = r
+	}
+}
+
+// WithClock drives QPS limits, throttle backoff, circuit breaker
+// cooldowns, deadlines, latencies, slot waits, injected delays, and health
+// checks with c. Since the DSPs answer in real time, c should run at wall
+// speed, like clock.Real or a Manual clock in tests, and match the
+// engine's wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(dp *Dispatcher) {
+		dp.clock = c
+	}
+}
+
+// WithFuzzer malforms the requests f picks, with the same class of
+// mutation for every DSP in the auction speaking JSON; protobuf DSPs get
+// them unmodified. Errors on malformed requests do not count toward the
+// circuit breaker.
+func WithFuzzer(f *fuzz.Fuzzer) Option {
+	return func(dp *Dispatcher) {
+		dp.fuzzer = f
+	}
+}
+
+// WithEvents publishes a BreakerOpened event to bus whenever a DSP's
+// circuit breaker opens.
+func WithEvents(bus *events.Bus) Option {
+	return func(dp *Dispatcher) {
+		dp.events = bus
+	}
+}
+
+// New creates a new dispatcher for the given DSPs. Only DSPs with Enabled
+// set receive requests; disabled ones can be enabled later with SetEnabled.
 func New(dsps []config.DSPConfig, opts ...Option) *Dispatcher {
 	d := &Dispatcher{
-		dsps:            dsps,
 		timeout:         100 * time.Millisecond,
 		maxConnsPerHost: 100,
+		clock:           clock.Real,
 	}
 
 	for _, opt := range opts {
 		opt(d)
 	}
 
+	all := make([]*endpoint, len(dsps))
+	for i, dsp := range dsps {
+		all[i] = d.newEndpoint(dsp)
+	}
+	d.setDSPs(all)
+
 	// Create client after all options are applied
 	d.client = httpclient.New(
-		httpclient.WithTimeout(d.timeout),
+		httpclient.WithTimeout(d.clientTimeout()),
 		httpclient.WithMaxConnsPerHost(d.maxConnsPerHost),
 	)
 
+	d.preflightCtx, d.stopPreflight = context.WithCancel(context.Background())
+	for _, ep := range all {
+		d.startPreflight(ep)
+	}
+	d.startHealthChecks()
+
 	return d
 }
 
 // Dispatch sends a bid request to all configured DSPs concurrently
-// and returns all results. Respects context cancellation.
+// and returns all results. Respects context cancellation. The outgoing
+// Tmax is lowered to the time left before the request's deadline.
 func (d *Dispatcher) Dispatch(ctx context.Context, req *openrtb.BidRequest) []Result {
-	if len(d.dsps) == 0 {
+	d.mu.RLock()
+	dsps := d.active
+	d.mu.RUnlock()
+
+	if len(dsps) == 0 {
 		return nil
 	}
 
-	results := make([]Result, len(d.dsps))
-	resultCh := make(chan indexedResult, len(d.dsps))
+	results := make([]Result, len(dsps))
+
+	// Bidders see the time actually left, not the generator's static Tmax
+	traceID := newTraceID()
+	budget := d.budget(ctx, req, d.clock.Now())
+	if budget < time.Millisecond {
+		for i, dsp := range dsps {
+			results[i] = Result{DSPName: dsp.Name, Error: ErrDeadlineExpired, TraceID: traceID}
+		}
+		return results
+	}
+	out := withTmax(req, budget)
+	mutation := d.fuzzer.Pick()
+
+	resultCh := make(chan indexedResult, len(dsps))
 
 	// Launch all requests
-	for i, dsp := range d.dsps {
-		go func(idx int, dspCfg config.DSPConfig) {
-			resultCh <- indexedResult{idx, d.callDSP(ctx, dspCfg, req)}
+	for i, dsp := range dsps {
+		go func(idx int, ep *endpoint) {
+			resultCh <- indexedResult{idx, d.callDSP(ctx, ep, out, budget, traceID, mutation)}
 		}(i, dsp)
 	}
 
 	// Collect results, respecting context cancellation
 	received := 0
-	for received < len(d.dsps) {
+	for received < len(dsps) {
 		select {
 		case <-ctx.Done():
-			// Context cancelled - fill remaining with errors
+			// Context done - fill remaining as interrupted
 			for i := range results {
 				if results[i].DSPName == "" {
-					results[i] = Result{
-						DSPName: d.dsps[i].Name,
-						Error:   ctx.Err(),
-					}
+					results[i] = Result{DSPName: dsps[i].Name, TraceID: traceID}
+					interrupt(ctx, &results[i])
 				}
 			}
 			return results
@@ -114,42 +333,241 @@ func (d *Dispatcher) Dispatch(ctx context.Context, req *openrtb.BidRequest) []Re
 	return results
 }
 
-// callDSP makes a single request to a DSP.
-func (d *Dispatcher) callDSP(ctx context.Context, dsp config.DSPConfig, req *openrtb.BidRequest) Result {
-	result := Result{DSPName: dsp.Name}
+// callDSP makes a single request to a DSP as a span of traceID, giving up
+// after budget. A mutation other than fuzz.ClassNone malforms the request
+// sent to DSPs speaking JSON.
+func (d *Dispatcher) callDSP(ctx context.Context, dsp *endpoint, req *openrtb.BidRequest, budget time.Duration, traceID string, mutation fuzz.Class) Result {
+	result := Result{DSPName: dsp.Name, TraceID: traceID}
 
 	// Check context before making request
 	select {
 	case <-ctx.Done():
-		result.Error = ctx.Err()
+		interrupt(ctx, &result)
 		return result
 	default:
 	}
 
-	start := time.Now()
-	resp, err := d.client.Post(dsp.Endpoint, req)
-	result.Latency = time.Since(start)
+	if dsp.paused.Load() {
+		result.Skipped = SkipPaused
+		return result
+	}
+	if d.healthChecks.AutoDisable && dsp.unhealthy() {
+		result.Skipped = SkipUnhealthy
+		return result
+	}
+	if !dsp.supports(req) {
+		result.Skipped = SkipUnsupported
+		return result
+	}
+	if pct := dsp.TrafficPct; pct > 0 && pct < 100 && !randutil.Chance(pct/100) {
+		result.Skipped = SkipTraffic
+		return result
+	}
+	if !dsp.throttle.allow(d.clock.Now()) {
+		result.Skipped = SkipThrottled
+		return result
+	}
+	if !dsp.breaker.allow(d.clock.Now()) {
+		result.Skipped = SkipCircuitOpen
+		return result
+	}
+	// Last, so calls skipped for other reasons leave the DSP's quota alone
+	if !dsp.limiter.allow(d.clock.Now()) {
+		dsp.breaker.abandon()
+		result.Skipped = SkipQPSLimit
+		return result
+	}
+
+	// Waiting for a slot eats into the call's budget
+	waited, ok := dsp.slots.acquire(ctx, d.clock, budget)
+	result.QueueWait = waited
+	if !ok {
+		dsp.breaker.abandon()
+		if ctx.Err() != nil {
+			interrupt(ctx, &result)
+		} else {
+			result.Skipped = SkipSaturated
+		}
+		return result
+	}
+	defer dsp.slots.release()
+	budget -= waited
+
+	// Resets are applied after the exchange completes so the bidder still
+	// sees the request; the response is discarded as if the connection
+	// dropped mid-read. Truncated, malformed, and corrupt bodies replace
+	// the response before decoding. Delays and drops hold the request back
+	// before sending, and count toward the call's latency.
+	result.Fault = pickFault(dsp.Faults)
+	result.SpanID = newSpanID()
+
+	client := d.client
+	if dsp.client != nil {
+		client = dsp.client
+	}
+	url := dsp.target(&result)
+	result.TLS = strings.HasPrefix(url, "https://")
+
+	start := d.clock.Now()
+	switch result.Fault {
+	case FaultDrop:
+		return d.noResponse(ctx, dsp, result, budget, start)
+	case FaultDelay:
+		delay := faultDelay(dsp.Faults)
+		if delay >= budget {
+			return d.noResponse(ctx, dsp, result, budget, start)
+		}
+		if !sleepCtx(ctx, d.clock, delay) {
+			interrupt(ctx, &result)
+			dsp.breaker.abandon()
+			result.Latency = d.clock.Since(start)
+			return result
+		}
+		budget -= delay
+	}
+
+	opts := []httpclient.CallOption{
+		httpclient.WithCallTimeout(budget),
+		httpclient.WithHeader(HeaderRequestID, req.ID),
+		httpclient.WithHeader(HeaderTraceParent, traceParent(traceID, result.SpanID)),
+		httpclient.WithCodec(dsp.codec),
+		httpclient.WithStatus(&result.Status),
+	}
+	if dsp.Parsing == config.ParsingStrict {
+		opts = append(opts, httpclient.WithStrict())
+	}
+	if d.retry.Max > 0 {
+		opts = append(opts, httpclient.WithRetry(d.retry, func(error) { result.Retries++ }))
+	}
+	if f := bodyFilter(result.Fault); f != nil {
+		opts = append(opts, httpclient.WithBodyFilter(f))
+	}
+	if mutation != fuzz.ClassNone && dsp.codec == httpclient.JSON {
+		result.Mutation = mutation
+		opts = append(opts, httpclient.WithRequestFilter(func(body []byte) []byte {
+			return fuzz.Mutate(body, mutation)
+		}))
+	}
+	resp, err := client.Post(url, req, opts...)
+	result.Latency = d.clock.Since(start)
+
+	if err == nil && result.Fault == FaultReset {
+		resp, err = nil, ErrConnectionReset
+		result.Status = 0
+	}
 
 	if err != nil {
 		// Check if context was cancelled during request
 		select {
 		case <-ctx.Done():
-			result.Error = ctx.Err()
+			interrupt(ctx, &result)
+			dsp.breaker.abandon()
 		default:
 			result.Error = err
+			d.observeRetryAfter(dsp, &result, err)
+			applyStatusHandling(&result, dsp.StatusHandling, req)
+			switch {
+			case result.Mutation != fuzz.ClassNone:
+				// Rejecting a malformed request is no sign the DSP is down
+				dsp.breaker.abandon()
+			case result.Class == ClassThrottle || result.Class == ClassOverload:
+				// Shedding load is no sign the DSP is healthy either, so
+				// it must not clear the failures around it
+				dsp.breaker.abandon()
+			default:
+				d.recordBreaker(dsp, result.Error != nil)
+			}
 		}
 		return result
 	}
 
 	result.Response = resp
+	d.recordBreaker(dsp, false)
 	return result
 }
 
+// noResponse fails a call whose request never reached the bidder, once
+// its budget has run out as it would waiting for the answer.
+func (d *Dispatcher) noResponse(ctx context.Context, dsp *endpoint, result Result, budget time.Duration, start time.Time) Result {
+	ok := sleepCtx(ctx, d.clock, budget)
+	result.Latency = d.clock.Since(start)
+	if !ok {
+		interrupt(ctx, &result)
+		dsp.breaker.abandon()
+		return result
+	}
+	result.Error = ErrNoResponse
+	d.recordBreaker(dsp, true)
+	return result
+}
+
+// interrupt records that ctx ended before a call completed. Cancellation
+// means the run was stopped, which says nothing about the DSP, so the call
+// is skipped rather than failed; an expired deadline is the DSP running out
+// of time and stays an error.
+func interrupt(ctx context.Context, result *Result) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		result.Skipped = SkipCancelled
+		return
+	}
+	result.Error = ctx.Err()
+}
+
+// Calls counts the DSP calls in results that completed and those cut short
+// by cancellation. Calls skipped for other reasons count as neither.
+func Calls(results []Result) (completed, cancelled int) {
+	for _, r := range results {
+		switch r.Skipped {
+		case SkipNone:
+			completed++
+		case SkipCancelled:
+			cancelled++
+		}
+	}
+	return completed, cancelled
+}
+
+// target picks the URL for one request, recording the A/B arm in result.
+// The HTTPS share applies to arm A only.
+func (dsp *endpoint) target(result *Result) string {
+	if dsp.AB.Enabled() {
+		if randutil.Chance(dsp.AB.Split) {
+			result.Arm = ArmB
+			return dsp.AB.Endpoint
+		}
+		result.Arm = ArmA
+	}
+	if dsp.HTTPS.Enabled() && randutil.Chance(dsp.HTTPS.Share) {
+		return dsp.HTTPS.Endpoint
+	}
+	return dsp.Endpoint
+}
+
+// observeRetryAfter backs off a DSP that answered 429 with Retry-After.
+func (d *Dispatcher) observeRetryAfter(dsp *endpoint, result *Result, err error) {
+	var se *httpclient.StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests || se.RetryAfter <= 0 {
+		return
+	}
+	result.RetryAfter = se.RetryAfter
+	dsp.throttle.backoff(d.clock.Now(), se.RetryAfter)
+}
+
 // Close releases resources held by the dispatcher.
 func (d *Dispatcher) Close() {
+	if d.stopPreflight != nil {
+		d.stopPreflight()
+		d.preflights.Wait()
+	}
 	if d.client != nil {
 		d.client.Close()
 	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, ep := range d.dsps {
+		ep.close()
+	}
 }
 
 // AllBids extracts all valid bids from the results.
@@ -2,13 +2,18 @@ package dispatcher
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/fuzz"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
@@ -186,9 +191,46 @@ func TestDispatcher_Dispatch_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestDispatcher_Dispatch_Cancelled(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer fast.Close()
+
+	d := New([]config.DSPConfig{
+		{Name: "fast", Endpoint: fast.URL, Enabled: true},
+		{Name: "slow", Endpoint: slow.URL, Enabled: true},
+	}, WithTimeout(5*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	defer cancel()
+
+	results := d.Dispatch(ctx, &openrtb.BidRequest{ID: "req-1"})
+
+	byName := make(map[string]Result)
+	for _, r := range results {
+		byName[r.DSPName] = r
+	}
+	if r := byName["fast"]; r.Skipped != SkipNone || r.Error != nil {
+		t.Errorf("fast = skipped %q, error %v; want completed", r.Skipped, r.Error)
+	}
+	if r := byName["slow"]; r.Skipped != SkipCancelled || r.Error != nil {
+		t.Errorf("slow = skipped %q, error %v; want cancelled without error", r.Skipped, r.Error)
+	}
+
+	completed, cancelled := Calls(results)
+	if completed != 1 || cancelled != 1 {
+		t.Errorf("Calls = %d completed, %d cancelled; want 1, 1", completed, cancelled)
+	}
+}
+
 func TestDispatcher_Dispatch_OnlyEnabledDSPs(t *testing.T) {
-	// Test that dispatcher processes all DSPs passed to it.
-	// Filtering should be done by the caller using Config.EnabledDSPs().
 	var callCount atomic.Int32
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -198,13 +240,13 @@ func TestDispatcher_Dispatch_OnlyEnabledDSPs(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Only pass enabled DSPs (as caller would do using cfg.EnabledDSPs())
-	enabledDSPs := []config.DSPConfig{
+	dsps := []config.DSPConfig{
 		{Name: "enabled1", Endpoint: server.URL, Enabled: true},
+		{Name: "disabled", Endpoint: server.URL, Enabled: false},
 		{Name: "enabled2", Endpoint: server.URL, Enabled: true},
 	}
 
-	d := New(enabledDSPs, WithTimeout(5*time.Second))
+	d := New(dsps, WithTimeout(5*time.Second))
 
 	req := &openrtb.BidRequest{ID: "req-1"}
 	results := d.Dispatch(context.Background(), req)
@@ -255,3 +297,224 @@ func TestDispatcher_AllBids(t *testing.T) {
 		t.Errorf("expected 1 bid, got %d", len(bids))
 	}
 }
+
+func TestDispatcher_Dispatch_CorrelationHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var requestIDs, traceParents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestIDs = append(requestIDs, r.Header.Get(HeaderRequestID))
+		traceParents = append(traceParents, r.Header.Get(HeaderTraceParent))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{
+		{Name: "dsp1", Endpoint: server.URL, Enabled: true},
+		{Name: "dsp2", Endpoint: server.URL, Enabled: true},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-42"})
+
+	if results[0].TraceID == "" || results[0].TraceID != results[1].TraceID {
+		t.Errorf("TraceIDs = %q, %q, want one shared trace", results[0].TraceID, results[1].TraceID)
+	}
+	if results[0].SpanID == results[1].SpanID {
+		t.Errorf("SpanIDs both %q, want one per DSP call", results[0].SpanID)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[string]bool{}
+	for _, r := range results {
+		want[traceParent(r.TraceID, r.SpanID)] = true
+	}
+	for i := range requestIDs {
+		if requestIDs[i] != "req-42" {
+			t.Errorf("X-Request-Id = %q, want req-42", requestIDs[i])
+		}
+		if !want[traceParents[i]] {
+			t.Errorf("traceparent = %q, want one of %v", traceParents[i], want)
+		}
+	}
+}
+
+func TestTraceParent_Format(t *testing.T) {
+	tp := traceParent(newTraceID(), newSpanID())
+	if len(tp) != 55 || !strings.HasPrefix(tp, "00-") || !strings.HasSuffix(tp, "-01") {
+		t.Errorf("traceParent() = %q, want 00-<32 hex>-<16 hex>-01", tp)
+	}
+}
+
+// tlsRecorder counts TLS handshakes per DSP.
+type tlsRecorder struct {
+	mu         sync.Mutex
+	handshakes map[string]int
+}
+
+func (r *tlsRecorder) RecordTLSHandshake(dspName string, resumed bool, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.handshakes == nil {
+		r.handshakes = make(map[string]int)
+	}
+	r.handshakes[dspName]++
+}
+
+func TestDispatcher_Dispatch_HTTPSShare(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	rec := &tlsRecorder{}
+	d := New([]config.DSPConfig{
+		{Name: "split", Endpoint: plain.URL, Enabled: true, HTTPS: config.HTTPSConfig{
+			Endpoint: secure.URL, Share: 1, InsecureSkipVerify: true,
+		}},
+		{Name: "plain", Endpoint: plain.URL, Enabled: true},
+	}, WithTimeout(5*time.Second), WithTLSRecorder(rec))
+	defer d.Close()
+
+	for i := 0; i < 3; i++ {
+		for _, r := range d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"}) {
+			if r.Error != nil {
+				t.Fatalf("%s: error = %v", r.DSPName, r.Error)
+			}
+			if want := r.DSPName == "split"; r.TLS != want {
+				t.Errorf("%s: TLS = %v, want %v", r.DSPName, r.TLS, want)
+			}
+		}
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	// Keep-alive connections are reused, so far fewer handshakes than requests
+	if n := rec.handshakes["split"]; n != 1 {
+		t.Errorf("split handshakes = %d, want 1", n)
+	}
+	if n := rec.handshakes["plain"]; n != 0 {
+		t.Errorf("plain handshakes = %d, want 0", n)
+	}
+}
+
+func TestDispatcher_Dispatch_ABSplit(t *testing.T) {
+	var callsA, callsB atomic.Int32
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callsA.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer serverA.Close()
+	serverB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callsB.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer serverB.Close()
+
+	d := New([]config.DSPConfig{
+		{Name: "canary", Endpoint: serverA.URL, Enabled: true, AB: config.ABConfig{Endpoint: serverB.URL, Split: 0.5}},
+		{Name: "plain", Endpoint: serverA.URL, Enabled: true},
+	}, WithTimeout(5*time.Second))
+	defer d.Close()
+
+	arms := map[Arm]int32{}
+	for i := 0; i < 200; i++ {
+		for _, r := range d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"}) {
+			if r.DSPName == "plain" {
+				if r.Arm != ArmNone {
+					t.Fatalf("plain: Arm = %q, want none", r.Arm)
+				}
+				continue
+			}
+			arms[r.Arm]++
+		}
+	}
+
+	if arms[ArmA]+arms[ArmB] != 200 || arms[ArmA] < 60 || arms[ArmB] < 60 {
+		t.Errorf("arms = %v, want roughly even split of 200", arms)
+	}
+	// Arm A shares its server with the plain DSP
+	if got := callsB.Load(); got != arms[ArmB] {
+		t.Errorf("arm B server calls = %d, want %d", got, arms[ArmB])
+	}
+	if got := callsA.Load(); got != arms[ArmA]+200 {
+		t.Errorf("arm A server calls = %d, want %d", got, arms[ArmA]+200)
+	}
+}
+
+func TestDispatcher_Dispatch_Fuzz(t *testing.T) {
+	// The bidder rejects requests missing an impression, as a strict one would
+	var rejected atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		if err := json.Unmarshal(body, &req); err != nil || req["imp"] == nil || req["id"] == nil {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := New([]config.DSPConfig{
+		{Name: "json", Endpoint: server.URL, Enabled: true},
+		{Name: "proto", Endpoint: server.URL, Enabled: true, Protocol: config.ProtocolProtobuf},
+	}, WithTimeout(5*time.Second), WithCircuitBreaker(1, time.Minute), WithFuzzer(fuzz.New(1, fuzz.MissingField)))
+	defer d.Close()
+
+	req := &openrtb.BidRequest{ID: "req", Imp: []openrtb.Imp{{ID: "1"}}}
+	for range 20 {
+		for _, r := range d.Dispatch(context.Background(), req) {
+			switch r.DSPName {
+			case "json":
+				if r.Mutation != fuzz.MissingField || r.Skipped != SkipNone {
+					t.Fatalf("json: Mutation = %q, Skipped = %q; want missing_field sent despite earlier 400s", r.Mutation, r.Skipped)
+				}
+			case "proto":
+				if r.Mutation != fuzz.ClassNone {
+					t.Fatalf("proto: Mutation = %q, want protobuf requests left alone", r.Mutation)
+				}
+			}
+		}
+	}
+	if rejected.Load() == 0 {
+		t.Error("no fuzzed request was rejected, want some missing id or imp")
+	}
+}
+
+func TestDispatcher_Dispatch_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := New([]config.DSPConfig{{Name: "dsp", Endpoint: server.URL, Enabled: true}},
+		WithTimeout(5*time.Second), WithCircuitBreaker(1, time.Minute),
+		WithRetries(config.RetryConfig{Max: 1, Backoff: time.Millisecond, On: []string{"503"}}))
+	defer d.Close()
+
+	for range 3 {
+		results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+		if len(results) != 1 || results[0].Error != nil || results[0].Retries != 1 || results[0].Status != http.StatusNoContent {
+			t.Fatalf("results = %+v, want one 204 after a retry", results)
+		}
+	}
+	if got := calls.Load(); got != 6 {
+		t.Errorf("server got %d calls, want 6", got)
+	}
+}
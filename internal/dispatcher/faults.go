@@ -0,0 +1,126 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// FaultKind identifies a fault injected into a DSP call.
+type FaultKind string
+
+const (
+	FaultNone      FaultKind = ""
+	FaultReset     FaultKind = "reset"
+	FaultTruncate  FaultKind = "truncate"
+	FaultDelay     FaultKind = "delay"
+	FaultDrop      FaultKind = "drop"
+	FaultMalformed FaultKind = "malformed"
+	FaultCorrupt   FaultKind = "corrupt"
+)
+
+var (
+	// ErrConnectionReset is returned for calls where a connection reset was injected.
+	ErrConnectionReset = errors.New("injected fault: connection reset by peer")
+
+	// ErrNoResponse is returned for calls that were dropped, or delayed past
+	// their budget. It wraps context.DeadlineExceeded, so it counts as a
+	// timeout.
+	ErrNoResponse = fmt.Errorf("injected fault: no response: %w", context.DeadlineExceeded)
+)
+
+// malformedBody is what a misbehaving proxy might answer with.
+var malformedBody = []byte("<html><body><h1>502 Bad Gateway</h1></body></html>")
+
+// pickFault rolls for a fault according to the DSP's configured rates.
+// At most one fault is injected per call.
+func pickFault(f config.FaultConfig) FaultKind {
+	rates := [...]struct {
+		kind FaultKind
+		rate float64
+	}{
+		{FaultReset, f.ResetRate},
+		{FaultTruncate, f.TruncateRate},
+		{FaultDelay, f.DelayRate},
+		{FaultDrop, f.DropRate},
+		{FaultMalformed, f.MalformedRate},
+		{FaultCorrupt, f.CorruptRate},
+	}
+	total := 0.0
+	for _, r := range rates {
+		total += r.rate
+	}
+	if total == 0 {
+		return FaultNone
+	}
+
+	roll := randutil.Float64()
+	for _, r := range rates {
+		if roll < r.rate {
+			return r.kind
+		}
+		roll -= r.rate
+	}
+	return FaultNone
+}
+
+// bodyFilter returns the response body filter for a fault, or nil.
+func bodyFilter(kind FaultKind) func([]byte) []byte {
+	switch kind {
+	case FaultTruncate:
+		return truncateBody
+	case FaultMalformed:
+		return func([]byte) []byte { return malformedBody }
+	case FaultCorrupt:
+		return corruptBody
+	}
+	return nil
+}
+
+// truncateBody cuts a response body at a random point, simulating a
+// connection that dropped partway through the payload.
+func truncateBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	return body[:randutil.IntN(len(body))]
+}
+
+// corruptBody returns a copy of body with about one byte in a hundred,
+// and at least one, replaced at random, as line noise would.
+func corruptBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	out := make([]byte, len(body))
+	copy(out, body)
+	for range max(len(out)/100, 1) {
+		out[randutil.IntN(len(out))] = byte(randutil.IntN(256))
+	}
+	return out
+}
+
+// faultDelay returns how long to hold back a call with an injected delay.
+func faultDelay(f config.FaultConfig) time.Duration {
+	return time.Duration(randutil.Float64() * float64(f.Delay))
+}
+
+// sleepCtx waits for d on clk, returning false if ctx ends first.
+func sleepCtx(ctx context.Context, clk clock.Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
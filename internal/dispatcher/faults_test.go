@@ -0,0 +1,208 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func newBidServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"req-1","seatbid":[{"bid":[{"id":"bid-1","impid":"imp-1","price":2.5}]}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPickFault_NoRates(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if f := pickFault(config.FaultConfig{}); f != FaultNone {
+			t.Fatalf("pickFault() = %q, want none", f)
+		}
+	}
+}
+
+func TestPickFault_Distribution(t *testing.T) {
+	cfg := config.FaultConfig{ResetRate: 0.2, TruncateRate: 0.3, DropRate: 0.1, CorruptRate: 0.1}
+
+	counts := map[FaultKind]int{}
+	const n = 10000
+	for i := 0; i < n; i++ {
+		counts[pickFault(cfg)]++
+	}
+
+	check := func(kind FaultKind, want float64) {
+		got := float64(counts[kind]) / n
+		if got < want-0.03 || got > want+0.03 {
+			t.Errorf("%q rate = %.3f, want ~%.2f", kind, got, want)
+		}
+	}
+	check(FaultReset, 0.2)
+	check(FaultTruncate, 0.3)
+	check(FaultDrop, 0.1)
+	check(FaultCorrupt, 0.1)
+	check(FaultNone, 0.3)
+}
+
+func TestCorruptBody(t *testing.T) {
+	body := []byte(`{"id":"req-1","seatbid":[]}`)
+	orig := string(body)
+	changed := 0
+	for range 50 {
+		got := corruptBody(body)
+		if len(got) != len(body) {
+			t.Fatalf("corruptBody() len = %d, want %d", len(got), len(body))
+		}
+		if string(got) != orig {
+			changed++
+		}
+	}
+	if string(body) != orig {
+		t.Error("corruptBody() modified its input")
+	}
+	// A replacement byte can match the original by chance
+	if changed < 40 {
+		t.Errorf("corruptBody() changed %d of 50 bodies, want nearly all", changed)
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	body := []byte(`{"id":"req-1"}`)
+	for i := 0; i < 50; i++ {
+		if got := truncateBody(body); len(got) >= len(body) {
+			t.Fatalf("truncateBody() len = %d, want < %d", len(got), len(body))
+		}
+	}
+	if got := truncateBody(nil); len(got) != 0 {
+		t.Errorf("truncateBody(nil) len = %d, want 0", len(got))
+	}
+}
+
+func TestDispatcher_Dispatch_InjectedReset(t *testing.T) {
+	server := newBidServer(t)
+
+	dsps := []config.DSPConfig{
+		{Name: "flaky", Endpoint: server.URL, Enabled: true, Faults: config.FaultConfig{ResetRate: 1}},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})
+
+	if !errors.Is(results[0].Error, ErrConnectionReset) {
+		t.Errorf("Error = %v, want ErrConnectionReset", results[0].Error)
+	}
+	if results[0].Fault != FaultReset {
+		t.Errorf("Fault = %q, want %q", results[0].Fault, FaultReset)
+	}
+	if results[0].Response != nil {
+		t.Error("Response should be nil after injected reset")
+	}
+	if results[0].Status != 0 {
+		t.Errorf("Status = %d, want 0 after injected reset", results[0].Status)
+	}
+}
+
+func TestDispatcher_Dispatch_InjectedTruncation(t *testing.T) {
+	server := newBidServer(t)
+
+	dsps := []config.DSPConfig{
+		{Name: "flaky", Endpoint: server.URL, Enabled: true, Faults: config.FaultConfig{TruncateRate: 1}},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})
+
+	if results[0].Error == nil {
+		t.Error("expected decode error for truncated response")
+	}
+	if results[0].Fault != FaultTruncate {
+		t.Errorf("Fault = %q, want %q", results[0].Fault, FaultTruncate)
+	}
+}
+
+func TestDispatcher_Dispatch_InjectedMalformed(t *testing.T) {
+	server := newBidServer(t)
+
+	dsps := []config.DSPConfig{
+		{Name: "flaky", Endpoint: server.URL, Enabled: true, Faults: config.FaultConfig{MalformedRate: 1}},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})
+
+	if results[0].Error == nil || results[0].Response != nil {
+		t.Errorf("Error, Response = %v, %v; want a decode error", results[0].Error, results[0].Response)
+	}
+	if results[0].Fault != FaultMalformed {
+		t.Errorf("Fault = %q, want %q", results[0].Fault, FaultMalformed)
+	}
+}
+
+func TestDispatcher_Dispatch_InjectedDrop(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{
+		{Name: "lossy", Endpoint: server.URL, Enabled: true, Faults: config.FaultConfig{DropRate: 1}},
+	}
+	d := New(dsps, WithTimeout(30*time.Millisecond))
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})
+
+	if !errors.Is(results[0].Error, ErrNoResponse) || !errors.Is(results[0].Error, context.DeadlineExceeded) {
+		t.Errorf("Error = %v, want ErrNoResponse as a deadline error", results[0].Error)
+	}
+	if results[0].Latency < 20*time.Millisecond {
+		t.Errorf("Latency = %v, want the ~30ms budget", results[0].Latency)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("server calls = %d, want 0 for a dropped request", calls.Load())
+	}
+}
+
+func TestDispatcher_Dispatch_InjectedDelay(t *testing.T) {
+	server := newBidServer(t)
+
+	dsps := []config.DSPConfig{
+		{Name: "slow", Endpoint: server.URL, Enabled: true, Faults: config.FaultConfig{DelayRate: 1, Delay: 40 * time.Millisecond}},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	var total time.Duration
+	for range 10 {
+		r := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})[0]
+		if r.Error != nil || r.Response == nil {
+			t.Fatalf("Error = %v, want a response after the delay", r.Error)
+		}
+		if r.Fault != FaultDelay {
+			t.Fatalf("Fault = %q, want %q", r.Fault, FaultDelay)
+		}
+		total += r.Latency
+	}
+	// Delays are uniform up to 40ms
+	if avg := total / 10; avg < 5*time.Millisecond {
+		t.Errorf("average latency = %v, want ~20ms of added delay", avg)
+	}
+
+	// A delay past the budget gets no response
+	d = New([]config.DSPConfig{
+		{Name: "stalled", Endpoint: server.URL, Enabled: true, Faults: config.FaultConfig{DelayRate: 1, Delay: time.Hour}},
+	}, WithTimeout(20*time.Millisecond))
+	if r := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})[0]; !errors.Is(r.Error, ErrNoResponse) {
+		t.Errorf("Error = %v, want ErrNoResponse", r.Error)
+	}
+}
@@ -0,0 +1,62 @@
+package dispatcher
+
+import (
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// formatSet is a set of impression formats.
+type formatSet uint8
+
+const (
+	formatBanner formatSet = 1 << iota
+	formatVideo
+	formatNative
+)
+
+// parseFormats returns the set of config format names, or every format if
+// there are none.
+func parseFormats(names []string) formatSet {
+	if len(names) == 0 {
+		return formatBanner | formatVideo | formatNative
+	}
+	var set formatSet
+	for _, name := range names {
+		switch name {
+		case config.FormatBanner:
+			set |= formatBanner
+		case config.FormatVideo:
+			set |= formatVideo
+		case config.FormatNative:
+			set |= formatNative
+		}
+	}
+	return set
+}
+
+// impFormats returns the formats imp offers.
+func impFormats(imp *openrtb.Imp) formatSet {
+	var set formatSet
+	if imp.Banner != nil {
+		set |= formatBanner
+	}
+	if imp.Video != nil {
+		set |= formatVideo
+	}
+	if imp.Native != nil {
+		set |= formatNative
+	}
+	return set
+}
+
+// supports reports whether the DSP buys a format one of req's impressions
+// offers. Impressions that offer no known format are assumed supported.
+func (dsp *endpoint) supports(req *openrtb.BidRequest) bool {
+	for i := range req.Imp {
+		offered := impFormats(&req.Imp[i])
+		if offered == 0 || offered&dsp.formats != 0 {
+			return true
+		}
+	}
+	return len(req.Imp) == 0
+}
@@ -0,0 +1,74 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestDispatcher_Formats(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := New([]config.DSPConfig{
+		{Name: "video", Endpoint: server.URL, Enabled: true, Formats: []string{config.FormatVideo}},
+		{Name: "native", Endpoint: server.URL, Enabled: true, Formats: []string{config.FormatNative}},
+		{Name: "any", Endpoint: server.URL, Enabled: true},
+	}, WithTimeout(5*time.Second))
+	defer d.Close()
+
+	tests := []struct {
+		name string
+		imps []openrtb.Imp
+		want []SkipReason // per DSP: video, native, any
+	}{
+		{
+			name: "banner",
+			imps: []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+			want: []SkipReason{SkipUnsupported, SkipUnsupported, SkipNone},
+		},
+		{
+			name: "banner or video",
+			imps: []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{W: 300, H: 250}, Video: &openrtb.Video{W: 640, H: 480}}},
+			want: []SkipReason{SkipNone, SkipUnsupported, SkipNone},
+		},
+		{
+			name: "banner and native",
+			imps: []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{}}, {ID: "2", Native: &openrtb.Native{Request: "{}"}}},
+			want: []SkipReason{SkipUnsupported, SkipNone, SkipNone},
+		},
+		{
+			name: "no format",
+			imps: []openrtb.Imp{{ID: "1"}},
+			want: []SkipReason{SkipNone, SkipNone, SkipNone},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req", Imp: tt.imps})
+			sent := 0
+			for i, r := range results {
+				if r.Skipped != tt.want[i] {
+					t.Errorf("%s: Skipped = %q, want %q", r.DSPName, r.Skipped, tt.want[i])
+				}
+				if r.Skipped == SkipNone {
+					sent++
+				}
+			}
+			if n := int(calls.Load()); n != sent {
+				t.Errorf("DSPs called %d times, want %d", n, sent)
+			}
+		})
+	}
+}
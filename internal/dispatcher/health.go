@@ -0,0 +1,183 @@
+package dispatcher
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/events"
+)
+
+// Health is the state of a DSP's health checks.
+type Health struct {
+	DSP      string
+	URL      string
+	Healthy  bool
+	Checked  time.Time // zero until the first check completes
+	Latency  time.Duration
+	Status   int   // HTTP status of the last check, 0 if it got none
+	Err      error // why the last check failed, nil if it passed
+	Failures int   // consecutive failed checks
+}
+
+// HealthChanged is published when a DSP turns unhealthy or recovers.
+type HealthChanged struct {
+	Time     time.Time
+	DSP      string
+	Healthy  bool
+	Failures int   // consecutive failed checks
+	Err      error // the last check's failure, nil on recovery
+}
+
+// HealthRecorder receives the result of every DSP health check, and
+// whether the DSP is healthy after it.
+type HealthRecorder interface {
+	RecordHealthCheck(dspName string, passed, healthy bool)
+}
+
+// WithHealthChecks probes each enabled DSP as hc configures, in the
+// background until Close. DSPs start out healthy. Transitions are
+// published as HealthChanged events; see Health for the results.
+func WithHealthChecks(hc config.HealthCheckConfig) Option {
+	return func(dp *Dispatcher) {
+		dp.healthChecks = hc
+	}
+}
+
+// WithHealthRecorder reports every DSP health check to r.
+func WithHealthRecorder(r HealthRecorder) Option {
+	return func(dp *Dispatcher) {
+		dp.healthRecorder = r
+	}
+}
+
+// startHealthChecks runs the health checker until Close if health checks
+// are enabled.
+func (d *Dispatcher) startHealthChecks() {
+	if !d.healthChecks.Enabled() {
+		return
+	}
+	client := &http.Client{Timeout: d.healthChecks.Timeout}
+	d.preflights.Add(1)
+	go func() {
+		defer d.preflights.Done()
+		timer := d.clock.NewTimer(d.healthChecks.Interval)
+		defer timer.Stop()
+		for {
+			d.checkHealth(client)
+			select {
+			case <-d.preflightCtx.Done():
+				return
+			case <-timer.C():
+				timer.Reset(d.healthChecks.Interval)
+			}
+		}
+	}()
+}
+
+// checkHealth probes every enabled DSP at once and waits for them all.
+func (d *Dispatcher) checkHealth(client *http.Client) {
+	d.mu.RLock()
+	dsps := d.active
+	d.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, ep := range dsps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.checkDSP(client, ep)
+		}()
+	}
+	wg.Wait()
+}
+
+// checkDSP probes one DSP and records the result.
+func (d *Dispatcher) checkDSP(client *http.Client, ep *endpoint) {
+	prev := Health{DSP: ep.Name, Healthy: true}
+	if h := ep.health.Load(); h != nil {
+		prev = *h
+	}
+
+	h := Health{DSP: ep.Name, URL: healthURL(ep.Endpoint, cmp.Or(ep.HealthPath, d.healthChecks.Path)), Healthy: prev.Healthy}
+	start := d.clock.Now()
+	h.Status, h.Err = probeHealth(d.preflightCtx, client, h.URL)
+	h.Latency = d.clock.Since(start)
+	h.Checked = d.clock.Now()
+	if d.preflightCtx.Err() != nil {
+		return // dispatcher closed
+	}
+
+	if h.Err == nil {
+		h.Healthy = true
+	} else {
+		h.Failures = prev.Failures + 1
+		if h.Failures >= d.healthChecks.UnhealthyAfter {
+			h.Healthy = false
+		}
+	}
+	ep.health.Store(&h)
+	if r := d.healthRecorder; r != nil {
+		r.RecordHealthCheck(ep.Name, h.Err == nil, h.Healthy)
+	}
+
+	if h.Healthy != prev.Healthy {
+		events.Publish(d.events, HealthChanged{Time: h.Checked, DSP: ep.Name, Healthy: h.Healthy, Failures: h.Failures, Err: h.Err})
+	}
+}
+
+// unhealthy reports whether health checks have found the DSP unhealthy.
+func (dsp *endpoint) unhealthy() bool {
+	h := dsp.health.Load()
+	return h != nil && !h.Healthy
+}
+
+// Health returns the health of every DSP checked, in the order the DSPs
+// were added. It is empty unless WithHealthChecks is set.
+func (d *Dispatcher) Health() []Health {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var out []Health
+	for _, ep := range d.dsps {
+		if h := ep.health.Load(); h != nil {
+			out = append(out, *h)
+		}
+	}
+	return out
+}
+
+// healthURL returns path on the host of the DSP's endpoint.
+func healthURL(endpoint, path string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	u.Path, u.RawPath, u.RawQuery, u.Fragment = path, "", "", ""
+	return u.String()
+}
+
+// probeHealth GETs rawURL and returns its status, failing unless it is
+// 2xx.
+func probeHealth(ctx context.Context, client *http.Client, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
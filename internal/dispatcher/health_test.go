@@ -0,0 +1,121 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/events"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+type healthChecks struct {
+	mu     sync.Mutex
+	passed int
+	failed int
+}
+
+func (h *healthChecks) RecordHealthCheck(_ string, passed, _ bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if passed {
+		h.passed++
+	} else {
+		h.failed++
+	}
+}
+
+// awaitHealth polls until the only DSP checked is healthy or not.
+func awaitHealth(t *testing.T, d *Dispatcher, healthy bool) Health {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if h := d.Health(); len(h) == 1 && h[0].Healthy == healthy {
+			return h[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Health() = %+v, want healthy = %v", d.Health(), healthy)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher_HealthChecks(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" || !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	bus := events.New()
+	var changes []HealthChanged
+	events.Subscribe(bus, "test", func(ev HealthChanged) { changes = append(changes, ev) })
+	rec := &healthChecks{}
+
+	d := New([]config.DSPConfig{
+		{Name: "dsp", Endpoint: server.URL + "/bid?x=1", HealthPath: "/ping", Enabled: true},
+		{Name: "disabled", Endpoint: server.URL},
+	}, WithHealthChecks(config.HealthCheckConfig{
+		Path: "/health", Interval: 5 * time.Millisecond, Timeout: time.Second, UnhealthyAfter: 2, AutoDisable: true,
+	}), WithHealthRecorder(rec), WithEvents(bus))
+
+	if h := awaitHealth(t, d, true); h.URL != server.URL+"/ping" || h.Status != http.StatusOK || h.Checked.IsZero() {
+		t.Errorf("healthy check = %+v, want a 200 from /ping", h)
+	}
+
+	up.Store(false)
+	h := awaitHealth(t, d, false)
+	if h.Failures < 2 || h.Status != http.StatusServiceUnavailable || h.Err == nil {
+		t.Errorf("unhealthy check = %+v, want 2+ failures with a 503", h)
+	}
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	if len(results) != 1 || results[0].Skipped != SkipUnhealthy {
+		t.Errorf("results = %+v, want the unhealthy DSP skipped", results)
+	}
+
+	up.Store(true)
+	awaitHealth(t, d, true)
+	d.Close()
+	bus.Close()
+
+	if len(changes) != 2 || changes[0].Healthy || !changes[1].Healthy {
+		t.Errorf("HealthChanged events = %+v, want unhealthy then healthy", changes)
+	}
+	if rec.passed < 2 || rec.failed < 2 {
+		t.Errorf("recorded %d passed and %d failed checks, want 2+ of each", rec.passed, rec.failed)
+	}
+}
+
+func TestDispatcher_HealthChecks_NoAutoDisable(t *testing.T) {
+	d := New([]config.DSPConfig{{Name: "dsp", Endpoint: closedURL(t), Enabled: true}},
+		WithTimeout(time.Second),
+		WithHealthChecks(config.HealthCheckConfig{
+			Path: "/health", Interval: 5 * time.Millisecond, Timeout: time.Second, UnhealthyAfter: 1,
+		}))
+	defer d.Close()
+
+	awaitHealth(t, d, false)
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	if len(results) != 1 || results[0].Skipped != SkipNone {
+		t.Errorf("results = %+v, want the unhealthy DSP still called", results)
+	}
+}
+
+func TestDispatcher_HealthChecks_Disabled(t *testing.T) {
+	d := New([]config.DSPConfig{{Name: "dsp", Endpoint: closedURL(t), Enabled: true}})
+	defer d.Close()
+
+	if h := d.Health(); len(h) != 0 {
+		t.Errorf("Health() = %+v, want none without WithHealthChecks", h)
+	}
+}
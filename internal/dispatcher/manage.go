@@ -0,0 +1,231 @@
+package dispatcher
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+)
+
+var (
+	ErrDSPExists   = errors.New("dsp already exists")
+	ErrDSPNotFound = errors.New("dsp not found")
+)
+
+// newEndpoint wraps a DSP's configuration with fresh dispatch state.
+func (d *Dispatcher) newEndpoint(cfg config.DSPConfig) *endpoint {
+	ep := &endpoint{
+		DSPConfig: cfg,
+		limiter:   newLimiter(cfg.QPSLimit),
+		slots:     newSlots(cfg.MaxInFlight, cfg.QueueTimeout),
+		codec:     httpclient.JSON,
+		formats:   parseFormats(cfg.Formats),
+	}
+	if cfg.Protocol == config.ProtocolProtobuf {
+		ep.codec = httpclient.Protobuf
+	}
+	ep.breaker.threshold = d.breakerThreshold
+	ep.breaker.cooldown = d.breakerCooldown
+	if cfg.HTTPS.Enabled() || strings.HasPrefix(cfg.Endpoint, "https://") ||
+		(cfg.AB.Enabled() && strings.HasPrefix(cfg.AB.Endpoint, "https://")) {
+		ep.client = d.newTLSClient(cfg)
+	}
+	return ep
+}
+
+// newTLSClient creates a DSP's own client for HTTPS traffic, reporting
+// its handshakes to the TLS recorder.
+func (d *Dispatcher) newTLSClient(cfg config.DSPConfig) *httpclient.Client {
+	opts := []httpclient.Option{
+		httpclient.WithTimeout(d.clientTimeout()),
+		httpclient.WithMaxConnsPerHost(d.maxConnsPerHost),
+	}
+	if cfg.HTTPS.InsecureSkipVerify {
+		opts = append(opts, httpclient.WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	}
+	if r := d.tlsRecorder; r != nil {
+		name := cfg.Name
+		opts = append(opts, httpclient.WithHandshakeObserver(func(_ string, resumed bool, elapsed time.Duration) {
+			r.RecordTLSHandshake(name, resumed, elapsed)
+		}))
+	}
+	return httpclient.New(opts...)
+}
+
+// setDSPs installs a new DSP list and rebuilds the enabled subset.
+// Must be called with mu held for writing, or before the dispatcher is
+// shared.
+func (d *Dispatcher) setDSPs(all []*endpoint) {
+	active := make([]*endpoint, 0, len(all))
+	for _, ep := range all {
+		if ep.Enabled {
+			active = append(active, ep)
+		}
+	}
+	d.dsps = all
+	d.active = active
+}
+
+// DSPs returns the configuration of every known DSP, enabled or not, in
+// the order they were added.
+func (d *Dispatcher) DSPs() []config.DSPConfig {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	out := make([]config.DSPConfig, len(d.dsps))
+	for i, ep := range d.dsps {
+		out[i] = ep.DSPConfig
+	}
+	return out
+}
+
+// AddDSP adds a DSP. It receives requests from the next dispatch if
+// cfg.Enabled is set, and its endpoints are checked if preflight checks
+// are enabled.
+func (d *Dispatcher) AddDSP(cfg config.DSPConfig) error {
+	if cfg.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.find(cfg.Name) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDSPExists, cfg.Name)
+	}
+	ep := d.newEndpoint(cfg)
+	all := make([]*endpoint, len(d.dsps), len(d.dsps)+1)
+	copy(all, d.dsps)
+	d.setDSPs(append(all, ep))
+	d.startPreflight(ep)
+	return nil
+}
+
+// RemoveDSP removes a DSP and closes its own client's idle connections.
+// Requests already in flight to it complete normally.
+func (d *Dispatcher) RemoveDSP(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.find(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDSPNotFound, name)
+	}
+	removed := d.dsps[i]
+	all := make([]*endpoint, 0, len(d.dsps)-1)
+	all = append(all, d.dsps[:i]...)
+	d.setDSPs(append(all, d.dsps[i+1:]...))
+	removed.close()
+	return nil
+}
+
+// SyncDSPs replaces the DSP list with cfgs, as when the configuration is
+// reloaded. DSPs whose configuration is unchanged apart from Enabled keep
+// their throttle, circuit breaker, and rate limit state; changed and new
+// DSPs start fresh and are checked if preflight checks are enabled.
+// Replaced and removed DSPs' own clients are closed; requests already in
+// flight complete normally.
+func (d *Dispatcher) SyncDSPs(cfgs []config.DSPConfig) error {
+	seen := make(map[string]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.Name == "" {
+			return errors.New("name is required")
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		if seen[cfg.Name] {
+			return fmt.Errorf("%w: %s", ErrDSPExists, cfg.Name)
+		}
+		seen[cfg.Name] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	all := make([]*endpoint, 0, len(cfgs))
+	kept := make(map[*endpoint]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		if i := d.find(cfg.Name); i >= 0 && sameDSP(d.dsps[i].DSPConfig, cfg) {
+			ep := d.dsps[i]
+			ep.Enabled = cfg.Enabled
+			all = append(all, ep)
+			kept[ep] = true
+			continue
+		}
+		ep := d.newEndpoint(cfg)
+		all = append(all, ep)
+		d.startPreflight(ep)
+	}
+	for _, ep := range d.dsps {
+		if !kept[ep] {
+			ep.close()
+		}
+	}
+	d.setDSPs(all)
+	return nil
+}
+
+// close closes the DSP's own client, if it has one.
+func (ep *endpoint) close() {
+	if ep.client != nil {
+		ep.client.Close()
+	}
+}
+
+// sameDSP reports whether a and b configure the same DSP, ignoring
+// whether it is enabled.
+func sameDSP(a, b config.DSPConfig) bool {
+	a.Enabled = b.Enabled
+	return reflect.DeepEqual(a, b)
+}
+
+// SetEnabled enables or disables a DSP. A re-enabled DSP keeps its
+// throttle and circuit breaker state.
+func (d *Dispatcher) SetEnabled(name string, enabled bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.find(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDSPNotFound, name)
+	}
+
+	// Only mu guards Enabled; in-flight dispatches never read it
+	d.dsps[i].Enabled = enabled
+	d.setDSPs(d.dsps)
+	return nil
+}
+
+// SetPaused pauses or resumes a DSP. A paused DSP stays enabled but is
+// skipped with SkipPaused, so the skips show up in its stats.
+func (d *Dispatcher) SetPaused(name string, paused bool) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	i := d.find(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDSPNotFound, name)
+	}
+	d.dsps[i].paused.Store(paused)
+	return nil
+}
+
+// find returns the index of the named DSP, or -1.
+// Must be called with mu held.
+func (d *Dispatcher) find(name string) int {
+	for i, ep := range d.dsps {
+		if ep.Name == name {
+			return i
+		}
+	}
+	return -1
+}
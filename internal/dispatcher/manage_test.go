@@ -0,0 +1,244 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func dispatchedNames(d *Dispatcher) []string {
+	var names []string
+	for _, r := range d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"}) {
+		names = append(names, r.DSPName)
+	}
+	return names
+}
+
+func TestDispatcher_ManageDSPs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := New([]config.DSPConfig{
+		{Name: "dsp1", Endpoint: server.URL, Enabled: true},
+		{Name: "dsp2", Endpoint: server.URL, Enabled: false},
+	}, WithTimeout(5*time.Second))
+
+	if got := dispatchedNames(d); len(got) != 1 || got[0] != "dsp1" {
+		t.Fatalf("dispatched to %v, want [dsp1]", got)
+	}
+
+	if err := d.SetEnabled("dsp2", true); err != nil {
+		t.Fatalf("SetEnabled() error = %v", err)
+	}
+	if err := d.AddDSP(config.DSPConfig{Name: "dsp3", Endpoint: server.URL, Enabled: true}); err != nil {
+		t.Fatalf("AddDSP() error = %v", err)
+	}
+	if got := dispatchedNames(d); len(got) != 3 {
+		t.Errorf("dispatched to %v, want 3 DSPs", got)
+	}
+
+	if err := d.RemoveDSP("dsp1"); err != nil {
+		t.Fatalf("RemoveDSP() error = %v", err)
+	}
+	if err := d.SetEnabled("dsp3", false); err != nil {
+		t.Fatalf("SetEnabled() error = %v", err)
+	}
+	if got := dispatchedNames(d); len(got) != 1 || got[0] != "dsp2" {
+		t.Errorf("dispatched to %v, want [dsp2]", got)
+	}
+
+	dsps := d.DSPs()
+	if len(dsps) != 2 || dsps[0].Name != "dsp2" || dsps[1].Name != "dsp3" || dsps[1].Enabled {
+		t.Errorf("DSPs() = %+v, want dsp2 (enabled) and dsp3 (disabled)", dsps)
+	}
+}
+
+func TestDispatcher_ManageDSPs_Errors(t *testing.T) {
+	d := New([]config.DSPConfig{{Name: "dsp1", Endpoint: "http://localhost/bid", Enabled: true}})
+
+	if err := d.AddDSP(config.DSPConfig{Name: "dsp1", Endpoint: "http://localhost/bid"}); !errors.Is(err, ErrDSPExists) {
+		t.Errorf("AddDSP(duplicate) error = %v, want ErrDSPExists", err)
+	}
+	if err := d.AddDSP(config.DSPConfig{Name: "dsp2"}); err == nil {
+		t.Error("AddDSP(no endpoint) error = nil, want validation error")
+	}
+	if err := d.AddDSP(config.DSPConfig{Endpoint: "http://localhost/bid"}); err == nil {
+		t.Error("AddDSP(no name) error = nil, want validation error")
+	}
+	if err := d.RemoveDSP("missing"); !errors.Is(err, ErrDSPNotFound) {
+		t.Errorf("RemoveDSP(missing) error = %v, want ErrDSPNotFound", err)
+	}
+	if err := d.SetEnabled("missing", true); !errors.Is(err, ErrDSPNotFound) {
+		t.Errorf("SetEnabled(missing) error = %v, want ErrDSPNotFound", err)
+	}
+	if err := d.SetPaused("missing", true); !errors.Is(err, ErrDSPNotFound) {
+		t.Errorf("SetPaused(missing) error = %v, want ErrDSPNotFound", err)
+	}
+}
+
+func TestDispatcher_SyncDSPs(t *testing.T) {
+	d := New([]config.DSPConfig{
+		{Name: "kept", Endpoint: "http://localhost:9001/bid", Enabled: true},
+		{Name: "changed", Endpoint: "http://localhost:9002/bid", Enabled: true},
+		{Name: "removed", Endpoint: "http://localhost:9003/bid", Enabled: true},
+	})
+	defer d.Close()
+	kept, changed := d.dsps[0], d.dsps[1]
+
+	err := d.SyncDSPs([]config.DSPConfig{
+		{Name: "kept", Endpoint: "http://localhost:9001/bid", Enabled: false},
+		{Name: "changed", Endpoint: "http://localhost:9012/bid", Enabled: true},
+		{Name: "added", Endpoint: "http://localhost:9004/bid", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("SyncDSPs() error = %v", err)
+	}
+
+	if d.dsps[0] != kept || d.dsps[0].Enabled {
+		t.Error("unchanged DSP lost its state or stayed enabled")
+	}
+	if d.dsps[1] == changed || d.dsps[1].Endpoint != "http://localhost:9012/bid" {
+		t.Error("changed DSP kept its old endpoint")
+	}
+	if got := d.DSPs(); len(got) != 3 || got[2].Name != "added" {
+		t.Errorf("DSPs() = %+v, want kept, changed, added", got)
+	}
+	if len(d.active) != 2 {
+		t.Errorf("%d active DSPs, want changed and added", len(d.active))
+	}
+
+	dup := []config.DSPConfig{{Name: "a", Endpoint: "http://localhost/bid"}, {Name: "a", Endpoint: "http://localhost/bid"}}
+	if err := d.SyncDSPs(dup); !errors.Is(err, ErrDSPExists) {
+		t.Errorf("SyncDSPs(duplicate) error = %v, want ErrDSPExists", err)
+	}
+	if err := d.SyncDSPs([]config.DSPConfig{{Name: "b"}}); err == nil {
+		t.Error("SyncDSPs(no endpoint) error = nil, want validation error")
+	}
+	if got := d.DSPs(); len(got) != 3 {
+		t.Errorf("a failed sync changed the DSPs to %+v", got)
+	}
+}
+
+func TestDispatcher_SetPaused(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := New([]config.DSPConfig{{Name: "dsp1", Endpoint: server.URL, Enabled: true}}, WithTimeout(5*time.Second))
+
+	if err := d.SetPaused("dsp1", true); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	if len(results) != 1 || results[0].Skipped != SkipPaused {
+		t.Errorf("results = %+v, want dsp1 skipped as paused", results)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("paused DSP received %d calls, want 0", n)
+	}
+
+	if err := d.SetPaused("dsp1", false); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	results = d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+	if results[0].Skipped != SkipNone || calls.Load() != 1 {
+		t.Errorf("after resume: Skipped = %q, calls = %d, want a call", results[0].Skipped, calls.Load())
+	}
+}
+
+func TestDispatcher_ManageDSPs_ConcurrentDispatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := New([]config.DSPConfig{{Name: "dsp1", Endpoint: server.URL, Enabled: true}}, WithTimeout(5*time.Second))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+			}
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		_ = d.AddDSP(config.DSPConfig{Name: "dsp2", Endpoint: server.URL, Enabled: true})
+		_ = d.SetEnabled("dsp1", i%2 == 0)
+		_ = d.RemoveDSP("dsp2")
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestDispatcher_ManageDSPs_ClosesClients(t *testing.T) {
+	var open atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	https := config.HTTPSConfig{InsecureSkipVerify: true}
+	d := New([]config.DSPConfig{
+		{Name: "removed", Endpoint: srv.URL + "/a", Enabled: true, HTTPS: https},
+		{Name: "changed", Endpoint: srv.URL + "/b", Enabled: true, HTTPS: https},
+	}, WithTimeout(5*time.Second))
+	defer d.Close()
+
+	waitOpen := func(want int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for open.Load() != want && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := open.Load(); got != want {
+			t.Fatalf("%d open connections, want %d", got, want)
+		}
+	}
+
+	dispatchedNames(d)
+	waitOpen(2)
+
+	if err := d.RemoveDSP("removed"); err != nil {
+		t.Fatalf("RemoveDSP() error = %v", err)
+	}
+	waitOpen(1)
+
+	err := d.SyncDSPs([]config.DSPConfig{
+		{Name: "changed", Endpoint: srv.URL + "/c", Enabled: true, HTTPS: https},
+	})
+	if err != nil {
+		t.Fatalf("SyncDSPs() error = %v", err)
+	}
+	waitOpen(0)
+}
@@ -0,0 +1,140 @@
+package dispatcher
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+)
+
+// Reachability is the result of a preflight check of one DSP endpoint: a
+// TCP connection and, for https URLs, a TLS handshake. No bid request is
+// sent.
+type Reachability struct {
+	DSP      string
+	Endpoint string
+	Checked  time.Time // zero while the check is pending
+	Latency  time.Duration
+	Err      error
+}
+
+// Pending reports whether the check has not finished yet.
+func (r Reachability) Pending() bool {
+	return r.Checked.IsZero()
+}
+
+// Reachable reports whether the check connected.
+func (r Reachability) Reachable() bool {
+	return !r.Pending() && r.Err == nil
+}
+
+// WithPreflight checks that each enabled DSP's endpoints accept
+// connections, in the background, when the dispatcher is created and when
+// a DSP is added. Each check gives up after timeout. Unreachable endpoints
+// are logged; see Reachability for the results.
+func WithPreflight(timeout time.Duration) Option {
+	return func(dp *Dispatcher) {
+		dp.preflightTimeout = timeout
+	}
+}
+
+// endpoints lists the URLs the DSP can be sent requests on.
+func (dsp *endpoint) endpoints() []string {
+	urls := []string{dsp.Endpoint}
+	if dsp.HTTPS.Enabled() {
+		urls = append(urls, dsp.HTTPS.Endpoint)
+	}
+	if dsp.AB.Enabled() {
+		urls = append(urls, dsp.AB.Endpoint)
+	}
+	return urls
+}
+
+// startPreflight checks the DSP's endpoints in the background if
+// preflight checks are enabled.
+func (d *Dispatcher) startPreflight(ep *endpoint) {
+	if d.preflightTimeout <= 0 || !ep.Enabled {
+		return
+	}
+	urls := ep.endpoints()
+	pending := make([]Reachability, len(urls))
+	for i, u := range urls {
+		pending[i] = Reachability{DSP: ep.Name, Endpoint: u}
+	}
+	ep.reachability.Store(&pending)
+
+	d.preflights.Add(1)
+	go func() {
+		defer d.preflights.Done()
+		checked := make([]Reachability, len(urls))
+		for i, u := range urls {
+			ctx, cancel := context.WithTimeout(d.preflightCtx, d.preflightTimeout)
+			checked[i] = Reachability{DSP: ep.Name, Endpoint: u}
+			checked[i].Latency, checked[i].Err = probe(ctx, d.clock, u, ep.HTTPS.InsecureSkipVerify)
+			checked[i].Checked = d.clock.Now()
+			cancel()
+			if d.preflightCtx.Err() != nil {
+				return // dispatcher closed
+			}
+
+			if err := checked[i].Err; err != nil {
+				log.Printf("Warning: DSP %s is unreachable at %s: %v", ep.Name, u, err)
+			} else {
+				log.Printf("DSP %s reachable at %s (%v)", ep.Name, u, checked[i].Latency.Round(time.Millisecond))
+			}
+		}
+		ep.reachability.Store(&checked)
+	}()
+}
+
+// Reachability returns the preflight results for every DSP checked, in
+// the order the DSPs were added. It is empty unless WithPreflight is set.
+func (d *Dispatcher) Reachability() []Reachability {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var out []Reachability
+	for _, ep := range d.dsps {
+		if r := ep.reachability.Load(); r != nil {
+			out = append(out, *r...)
+		}
+	}
+	return out
+}
+
+// probe connects to rawURL's host, completing a TLS handshake for https,
+// and returns how long it took on clk.
+func probe(ctx context.Context, clk clock.Clock, rawURL string, insecure bool) (time.Duration, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	start := clk.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if u.Scheme == "https" {
+		tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: insecure})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return 0, fmt.Errorf("tls handshake: %w", err)
+		}
+	}
+	return clk.Since(start), nil
+}
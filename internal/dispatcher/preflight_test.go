@@ -0,0 +1,110 @@
+package dispatcher
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+)
+
+// awaitReachability polls until no preflight check is pending.
+func awaitReachability(t *testing.T, d *Dispatcher) []Reachability {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		checks := d.Reachability()
+		pending := false
+		for _, c := range checks {
+			pending = pending || c.Pending()
+		}
+		if !pending {
+			return checks
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("preflight checks still pending: %+v", checks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// closedURL returns the URL of a port nothing is listening on.
+func closedURL(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return "http://" + addr + "/bid"
+}
+
+func TestDispatcher_Preflight(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	dead := closedURL(t)
+
+	d := New([]config.DSPConfig{
+		{Name: "up", Endpoint: plain.URL, Enabled: true,
+			HTTPS: config.HTTPSConfig{Endpoint: secure.URL, Share: 0.5, InsecureSkipVerify: true}},
+		{Name: "down", Endpoint: dead, Enabled: true},
+		{Name: "disabled", Endpoint: dead},
+	}, WithPreflight(time.Second))
+	defer d.Close()
+
+	checks := awaitReachability(t, d)
+	if len(checks) != 3 {
+		t.Fatalf("got %d checks, want 3 (disabled DSPs are not checked): %+v", len(checks), checks)
+	}
+	for _, c := range checks[:2] {
+		if c.DSP != "up" || !c.Reachable() {
+			t.Errorf("%s at %s: reachable = false (%v), want true", c.DSP, c.Endpoint, c.Err)
+		}
+	}
+	if c := checks[2]; c.DSP != "down" || c.Reachable() || c.Err == nil {
+		t.Errorf("down check = %+v, want unreachable with an error", c)
+	}
+}
+
+func TestDispatcher_Preflight_TLSFailure(t *testing.T) {
+	secure := httptest.NewTLSServer(http.NotFoundHandler())
+	defer secure.Close()
+
+	d := New([]config.DSPConfig{{Name: "untrusted", Endpoint: secure.URL, Enabled: true}}, WithPreflight(time.Second))
+	defer d.Close()
+
+	checks := awaitReachability(t, d)
+	if len(checks) != 1 || checks[0].Reachable() {
+		t.Errorf("checks = %+v, want one failed handshake with a self-signed certificate", checks)
+	}
+}
+
+func TestDispatcher_Preflight_AddDSP(t *testing.T) {
+	d := New(nil, WithPreflight(time.Second))
+	defer d.Close()
+
+	if err := d.AddDSP(config.DSPConfig{Name: "added", Endpoint: closedURL(t), Enabled: true}); err != nil {
+		t.Fatalf("AddDSP() error = %v", err)
+	}
+	checks := awaitReachability(t, d)
+	if len(checks) != 1 || checks[0].DSP != "added" || checks[0].Reachable() {
+		t.Errorf("checks = %+v, want one unreachable check of the added DSP", checks)
+	}
+}
+
+func TestDispatcher_Preflight_Disabled(t *testing.T) {
+	d := New([]config.DSPConfig{{Name: "dsp", Endpoint: closedURL(t), Enabled: true}})
+	defer d.Close()
+
+	if checks := d.Reachability(); len(checks) != 0 {
+		t.Errorf("Reachability() = %+v, want none without WithPreflight", checks)
+	}
+}
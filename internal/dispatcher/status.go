@@ -0,0 +1,49 @@
+package dispatcher
+
+import (
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// StatusClass records how a DSP's error HTTP status was classified
+// according to its configured status handling.
+type StatusClass string
+
+const (
+	ClassNone     StatusClass = ""
+	ClassNoBid    StatusClass = config.StatusNoBid
+	ClassThrottle StatusClass = config.StatusThrottle
+	ClassOverload StatusClass = config.StatusOverload
+	ClassError    StatusClass = config.StatusError
+)
+
+// applyStatusHandling reclassifies an HTTP status error using the DSP's
+// status mapping. No-bid and throttle statuses become empty responses;
+// overload statuses clear the error without producing a response so they
+// are counted separately rather than as failures. Neither counts toward
+// the circuit breaker, nor resets it.
+func applyStatusHandling(result *Result, handling map[int]string, req *openrtb.BidRequest) {
+	if len(handling) == 0 || result.Error == nil {
+		return
+	}
+
+	code, ok := httpclient.StatusCode(result.Error)
+	if !ok {
+		return
+	}
+
+	class, ok := handling[code]
+	if !ok {
+		return
+	}
+
+	result.Class = StatusClass(class)
+	switch result.Class {
+	case ClassNoBid, ClassThrottle:
+		result.Error = nil
+		result.Response = &openrtb.BidResponse{ID: req.ID}
+	case ClassOverload:
+		result.Error = nil
+	}
+}
@@ -0,0 +1,82 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func newStatusServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDispatcher_Dispatch_StatusHandling(t *testing.T) {
+	throttling := newStatusServer(t, http.StatusTooManyRequests)
+	overloaded := newStatusServer(t, http.StatusServiceUnavailable)
+	failing := newStatusServer(t, http.StatusInternalServerError)
+
+	handling := map[int]string{
+		http.StatusTooManyRequests:    config.StatusThrottle,
+		http.StatusServiceUnavailable: config.StatusOverload,
+	}
+	dsps := []config.DSPConfig{
+		{Name: "throttling", Endpoint: throttling.URL, Enabled: true, StatusHandling: handling},
+		{Name: "overloaded", Endpoint: overloaded.URL, Enabled: true, StatusHandling: handling},
+		{Name: "failing", Endpoint: failing.URL, Enabled: true, StatusHandling: handling},
+	}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})
+
+	throttled := results[0]
+	if throttled.Error != nil {
+		t.Errorf("throttling: unexpected error %v", throttled.Error)
+	}
+	if throttled.Class != ClassThrottle {
+		t.Errorf("throttling: Class = %q, want %q", throttled.Class, ClassThrottle)
+	}
+	if throttled.Response == nil || !throttled.Response.IsNoBid() {
+		t.Error("throttling: expected empty no-bid response")
+	}
+
+	overload := results[1]
+	if overload.Error != nil {
+		t.Errorf("overloaded: unexpected error %v", overload.Error)
+	}
+	if overload.Class != ClassOverload {
+		t.Errorf("overloaded: Class = %q, want %q", overload.Class, ClassOverload)
+	}
+	if overload.Response != nil {
+		t.Error("overloaded: expected nil response")
+	}
+
+	unmapped := results[2]
+	if unmapped.Error == nil {
+		t.Error("failing: expected error for unmapped 500")
+	}
+	if unmapped.Class != ClassNone {
+		t.Errorf("failing: Class = %q, want none", unmapped.Class)
+	}
+}
+
+func TestApplyStatusHandling_NonStatusError(t *testing.T) {
+	result := Result{DSPName: "dsp", Error: context.DeadlineExceeded}
+	applyStatusHandling(&result, map[int]string{504: config.StatusNoBid}, &openrtb.BidRequest{ID: "req-1"})
+
+	if result.Error != context.DeadlineExceeded {
+		t.Errorf("Error = %v, want unchanged", result.Error)
+	}
+	if result.Class != ClassNone {
+		t.Errorf("Class = %q, want none", result.Class)
+	}
+}
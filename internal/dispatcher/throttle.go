@@ -0,0 +1,50 @@
+package dispatcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// minThrottleShare is the smallest fraction of traffic a throttled DSP
+// continues to receive, so recovery is observed even under repeated 429s.
+const minThrottleShare = 1.0 / 16
+
+// throttle tracks Retry-After driven backoff for a single DSP. Each
+// Retry-After halves the share of traffic the DSP receives until the
+// indicated window elapses, mimicking exchange QPS feedback loops.
+type throttle struct {
+	mu    sync.Mutex
+	until time.Time
+	share float64
+}
+
+// allow reports whether a request should be sent to the DSP at now.
+func (t *throttle) allow(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.until.IsZero() {
+		return true
+	}
+	if !now.Before(t.until) {
+		t.until = time.Time{}
+		return true
+	}
+	return randutil.Float64() < t.share
+}
+
+// backoff reduces the DSP's traffic share for the next d.
+func (t *throttle) backoff(now time.Time, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.until.IsZero() {
+		t.share = 1
+	}
+	t.share = max(t.share/2, minThrottleShare)
+	if until := now.Add(d); until.After(t.until) {
+		t.until = until
+	}
+}
@@ -0,0 +1,98 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestThrottle_AllowWithoutBackoff(t *testing.T) {
+	var th throttle
+	for i := 0; i < 100; i++ {
+		if !th.allow(time.Now()) {
+			t.Fatal("allow() = false without backoff")
+		}
+	}
+}
+
+func TestThrottle_BackoffReducesShare(t *testing.T) {
+	var th throttle
+	now := time.Now()
+
+	th.backoff(now, time.Minute)
+	if th.share != 0.5 {
+		t.Errorf("share after one backoff = %f, want 0.5", th.share)
+	}
+
+	for i := 0; i < 10; i++ {
+		th.backoff(now, time.Minute)
+	}
+	if th.share != minThrottleShare {
+		t.Errorf("share after repeated backoff = %f, want %f", th.share, minThrottleShare)
+	}
+
+	allowed := 0
+	for i := 0; i < 1600; i++ {
+		if th.allow(now.Add(time.Second)) {
+			allowed++
+		}
+	}
+	if allowed < 50 || allowed > 150 {
+		t.Errorf("allowed %d of 1600 at 1/16 share, want ~100", allowed)
+	}
+}
+
+func TestThrottle_RecoversAfterWindow(t *testing.T) {
+	var th throttle
+	now := time.Now()
+
+	th.backoff(now, time.Second)
+	th.backoff(now, time.Second)
+
+	if !th.allow(now.Add(2 * time.Second)) {
+		t.Error("allow() = false after window elapsed")
+	}
+	th.backoff(now.Add(2*time.Second), time.Second)
+	if th.share != 0.5 {
+		t.Errorf("share after recovery and new backoff = %f, want 0.5", th.share)
+	}
+}
+
+func TestDispatcher_Dispatch_RetryAfterThrottles(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	dsps := []config.DSPConfig{{Name: "busy", Endpoint: server.URL, Enabled: true}}
+	d := New(dsps, WithTimeout(5*time.Second))
+
+	first := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req-1"})
+	if first[0].RetryAfter != 60*time.Second {
+		t.Errorf("RetryAfter = %v, want 60s", first[0].RetryAfter)
+	}
+
+	skipped := 0
+	for i := 0; i < 50; i++ {
+		results := d.Dispatch(context.Background(), &openrtb.BidRequest{ID: "req"})
+		if results[0].Skipped == SkipThrottled {
+			skipped++
+		}
+	}
+
+	if skipped == 0 {
+		t.Error("expected some requests to be skipped while throttled")
+	}
+	if int(calls.Load()) != 51-skipped {
+		t.Errorf("server calls = %d, want %d", calls.Load(), 51-skipped)
+	}
+}
@@ -0,0 +1,39 @@
+package dispatcher
+
+import (
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// Correlation headers sent with every bid request so an auction can be
+// traced across simulator and DSP logs.
+const (
+	HeaderRequestID   = "X-Request-Id"
+	HeaderTraceParent = "traceparent"
+)
+
+const hexChars = "0123456789abcdef"
+
+// newTraceID returns a random 16-byte W3C trace ID as 32 hex characters.
+// Each auction gets one trace shared by all of its DSP calls.
+func newTraceID() string {
+	return randomHex(32)
+}
+
+// newSpanID returns a random 8-byte W3C span ID as 16 hex characters.
+// Each DSP call gets its own span within the auction's trace.
+func newSpanID() string {
+	return randomHex(16)
+}
+
+// traceParent formats a W3C traceparent header value for a sampled span.
+func traceParent(traceID, spanID string) string {
+	return "00-" + traceID + "-" + spanID + "-01"
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = hexChars[randutil.IntN(16)]
+	}
+	return string(buf)
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// requestBuffer holds requests generated ahead of dispatch for a run.
+type requestBuffer struct {
+	reqs      chan generatedRequest
+	underruns atomic.Uint64
+}
+
+type generatedRequest struct {
+	req      *openrtb.BidRequest
+	scenario string
+}
+
+// BufferStats reports the request buffer of the current or last run.
+type BufferStats struct {
+	Buffered  int    // requests generated and waiting to be sent
+	Capacity  int    // 0 when requests are not buffered
+	Underruns uint64 // requests generated when sent because the buffer was empty
+}
+
+// WithRequestBuffer generates up to n requests ahead of dispatch in the
+// background, so a stalled generator, such as during a GC pause, does not
+// leave a gap in the request rate. When the buffer runs dry, requests are
+// generated as they are sent, as without it. The time a request waits in
+// the buffer does not count against its Tmax, but a generator change
+// takes effect only once the requests already buffered are sent. Zero
+// disables the buffer.
+func WithRequestBuffer(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.bufferSize = n
+		}
+	}
+}
+
+// RequestBuffer reports how full the request buffer is, to tune its size:
+// a buffer that is mostly empty, or underruns, is too small for the rate.
+func (e *Engine) RequestBuffer() BufferStats {
+	bs := BufferStats{Capacity: e.bufferSize}
+	if buf := e.buffer.Load(); buf != nil {
+		bs.Buffered = len(buf.reqs)
+		bs.Underruns = buf.underruns.Load()
+	}
+	return bs
+}
+
+// fill keeps buf full until ctx ends.
+func (e *Engine) fill(ctx context.Context, buf *requestBuffer) {
+	for {
+		req, scenario := e.generate()
+		select {
+		case buf.reqs <- generatedRequest{req: req, scenario: scenario}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// next returns the next request to send, from the run's buffer when there
+// is one and it has a request ready.
+func (e *Engine) next() (*openrtb.BidRequest, string) {
+	if buf := e.buffer.Load(); buf != nil {
+		select {
+		case g := <-buf.reqs:
+			return g.req, g.scenario
+		default:
+			buf.underruns.Add(1)
+		}
+	}
+	return e.generate()
+}
@@ -5,11 +5,16 @@ package engine
 import (
 	"context"
 	"errors"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/currency"
 	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/events"
 	"github.com/cass/rtb-simulator/internal/stats"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
@@ -17,6 +22,7 @@ import (
 var (
 	ErrAlreadyRunning = errors.New("engine is already running")
 	ErrNotRunning     = errors.New("engine is not running")
+	ErrInvalidRPS     = errors.New("rps must be positive")
 )
 
 // Generator defines the interface for bid request generation.
@@ -25,26 +31,88 @@ type Generator interface {
 	ScenarioName() string
 }
 
+// ScenarioGenerator is a Generator that can name the scenario each
+// request came from when it mixes several. The engine attributes every
+// auction to that scenario in its outcome and stats.
+type ScenarioGenerator interface {
+	GenerateScenario() (*openrtb.BidRequest, string)
+}
+
 // Dispatcher defines the interface for dispatching requests to DSPs.
 type Dispatcher interface {
 	Dispatch(ctx context.Context, req *openrtb.BidRequest) []dispatcher.Result
 	Close()
 }
 
+// Notifier receives auction outcomes for win/loss notification delivery.
+// Implementations must not block the caller.
+type Notifier interface {
+	Notify(outcome auction.Outcome)
+}
+
+// Flusher is a Notifier that can wait for the notifications it has
+// queued to be delivered. Drain waits for it when the notifier
+// implements it.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Observer receives every completed auction along with its request and
+// raw DSP results. Implementations must not block the caller.
+type Observer interface {
+	ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome)
+}
+
+// TimedObserver is an Observer that is told when each auction completed,
+// by the engine's clock, so what it records is stamped with simulated
+// time rather than the time the auction reached it.
+type TimedObserver interface {
+	Observer
+	ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome)
+}
+
+// RunListener is told when a simulation run starts and stops, whether the
+// stop was requested or the loop ended on its own.
+type RunListener interface {
+	RunStarted()
+	RunStopped()
+}
+
 // Engine orchestrates the RTB simulation loop.
 type Engine struct {
 	generator  Generator
 	dispatcher Dispatcher
-	auction    auction.Auction
+	auction    atomic.Pointer[auction.Auction] // swapped by SetAuction
+	rates      *currency.Table                 // nil when bids are not converted
+	validate   bool
 	stats      *stats.Collector
-
-	rps      int
-	bidFloor float64
-
-	mu       sync.RWMutex
-	running  bool
-	cancel   context.CancelFunc
-	wg       sync.WaitGroup
+	notifier   Notifier
+	observers  []Observer
+	listeners  []RunListener
+	events     *events.Bus // nil when no events are published
+	clock      clock.Clock // simulated time
+	wall       clock.Clock // paces requests and times auctions
+
+	rps         int
+	ramp        Ramp
+	bidFloor    float64
+	duration    time.Duration
+	maxRequests uint64
+	concurrency int
+	batchSize   int
+	bufferSize  int
+	buffer      atomic.Pointer[requestBuffer] // nil until a buffered run starts
+	spend       spendTracker
+
+	completed     chan struct{}
+	completedOnce sync.Once
+
+	mu         sync.RWMutex
+	running    bool
+	cancel     context.CancelFunc
+	drain      chan struct{} // closed by Drain to end the run's loop
+	wg         sync.WaitGroup
+	rpsChanged chan struct{}
 }
 
 // Option configures the engine.
@@ -57,6 +125,49 @@ func WithRPS(rps int) Option {
 	}
 }
 
+// Ramp moves the request rate linearly from StartRPS to EndRPS over
+// Duration at the start of each run, then holds EndRPS.
+type Ramp struct {
+	StartRPS int
+	EndRPS   int
+	Duration time.Duration
+}
+
+// rateAt returns the ramp's request rate elapsed into the run.
+func (r Ramp) rateAt(elapsed time.Duration) float64 {
+	if elapsed >= r.Duration {
+		return float64(r.EndRPS)
+	}
+	frac := float64(elapsed) / float64(r.Duration)
+	return float64(r.StartRPS) + frac*float64(r.EndRPS-r.StartRPS)
+}
+
+// WithRamp ramps the request rate from start to end RPS over d at the
+// start of each run. A zero d disables the ramp.
+func WithRamp(start, end int, d time.Duration) Option {
+	return func(e *Engine) {
+		e.ramp = Ramp{StartRPS: start, EndRPS: end, Duration: d}
+	}
+}
+
+// WithClock measures run durations and ramps, and timestamps events, in
+// c's simulated time. Request rates stay per wall-clock second, since the
+// DSPs answer in real time; see WithWallClock.
+func WithClock(c clock.Clock) Option {
+	return func(e *Engine) {
+		e.clock = c
+	}
+}
+
+// WithWallClock paces requests and times auctions against c instead of
+// the wall clock, so tests can drive ticks with a Manual clock. It must
+// match the dispatcher's clock, which measures the same deadlines.
+func WithWallClock(c clock.Clock) Option {
+	return func(e *Engine) {
+		e.wall = c
+	}
+}
+
 // WithBidFloor sets the minimum bid floor for auctions.
 func WithBidFloor(floor float64) Option {
 	return func(e *Engine) {
@@ -64,17 +175,102 @@ func WithBidFloor(floor float64) Option {
 	}
 }
 
+// WithDuration stops each run automatically after d. Zero means no limit.
+func WithDuration(d time.Duration) Option {
+	return func(e *Engine) {
+		e.duration = d
+	}
+}
+
+// WithMaxRequests stops each run automatically after n requests.
+// Zero means no limit.
+func WithMaxRequests(n uint64) Option {
+	return func(e *Engine) {
+		e.maxRequests = n
+	}
+}
+
+// WithConcurrency runs up to n ticks at once, so slow DSP responses do not
+// hold back the request rate. With n of 1 each tick must finish before the
+// next starts. Spend caps are checked as ticks complete, so concurrent
+// ticks can overshoot a cap slightly.
+func WithConcurrency(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.concurrency = n
+		}
+	}
+}
+
+// WithBatchSize issues n requests per tick, each with its own auction,
+// and ticks n times less often. Very high rates then need no more timer
+// wakeups than the OS can honor. The requests of a tick share the worker
+// pool, so concurrency should be at least n for them to run in parallel.
+func WithBatchSize(n int) Option {
+	return func(e *Engine) {
+		if n > 0 {
+			e.batchSize = n
+		}
+	}
+}
+
+// WithCurrency converts bid prices to the base currency of rates before
+// each auction, so bids made in other currencies compete fairly. Bid
+// floors and clearing prices are in the base currency. Observers still
+// receive the responses as the DSPs sent them.
+func WithCurrency(rates *currency.Table) Option {
+	return func(e *Engine) {
+		e.rates = rates
+	}
+}
+
+// WithResponseValidation rejects malformed bids before each auction; see
+// auction.ValidateBids.
+func WithResponseValidation() Option {
+	return func(e *Engine) {
+		e.validate = true
+	}
+}
+
+// WithNotifier enables win/loss notifications for auction outcomes.
+func WithNotifier(n Notifier) Option {
+	return func(e *Engine) {
+		e.notifier = n
+	}
+}
+
+// WithObserver registers an auction observer. May be given multiple times.
+func WithObserver(o Observer) Option {
+	return func(e *Engine) {
+		e.observers = append(e.observers, o)
+	}
+}
+
+// WithRunListener registers a run lifecycle listener. May be given multiple times.
+func WithRunListener(l RunListener) Option {
+	return func(e *Engine) {
+		e.listeners = append(e.listeners, l)
+	}
+}
+
 // New creates a new simulation engine.
 func New(gen Generator, disp Dispatcher, auc auction.Auction, stats *stats.Collector, opts ...Option) *Engine {
 	e := &Engine{
-		generator:  gen,
-		dispatcher: disp,
-		auction:    auc,
-		stats:      stats,
-		rps:        100,      // default 100 RPS
-		bidFloor:   0.01,     // default $0.01 floor
+		generator:   gen,
+		dispatcher:  disp,
+		stats:       stats,
+		rps:         100,  // default 100 RPS
+		bidFloor:    0.01, // default $0.01 floor
+		concurrency: 1,
+		batchSize:   1,
+		clock:       clock.Real,
+		wall:        clock.Real,
+		rpsChanged:  make(chan struct{}, 1),
+		completed:   make(chan struct{}),
 	}
 
+	e.auction.Store(&auc)
+
 	for _, opt := range opts {
 		opt(e)
 	}
@@ -92,11 +288,17 @@ func (e *Engine) Start() error {
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	drain := make(chan struct{})
 	e.cancel = cancel
+	e.drain = drain
 	e.running = true
 
+	for _, l := range e.listeners {
+		l.RunStarted()
+	}
+
 	e.wg.Add(1)
-	go e.loop(ctx)
+	go e.loop(ctx, drain)
 
 	return nil
 }
@@ -116,9 +318,73 @@ func (e *Engine) Stop() {
 	e.mu.Lock()
 	e.running = false
 	e.cancel = nil
+	e.drain = nil
 	e.mu.Unlock()
 }
 
+// Drain stops generating requests but, unlike Stop, lets the auctions
+// underway finish and waits for their notifications to be delivered, so
+// no DSP call is cut short and counted as an error. The engine reports
+// running until then. If ctx ends first, the remaining auctions are
+// cancelled as by Stop and ctx's error is returned.
+func (e *Engine) Drain(ctx context.Context) error {
+	e.StopGenerating()
+	if err := e.awaitLoop(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	if f, ok := e.notifier.(Flusher); ok {
+		err = f.Flush(ctx)
+	}
+	// The loop has ended; Stop releases its context and marks it stopped
+	e.Stop()
+	return err
+}
+
+// StopGenerating stops the run from generating requests without cutting
+// short the auctions underway; Wait waits for them. It is the first step
+// of Drain, for callers that wait on several engines, or flush their
+// notifiers, as separate steps.
+func (e *Engine) StopGenerating() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.drain != nil {
+		close(e.drain)
+		e.drain = nil
+	}
+}
+
+// Wait waits for a run that stopped generating requests, after
+// StopGenerating or on reaching a run limit, to finish its auctions, then
+// stops the engine. If ctx ends first, the remaining auctions are
+// cancelled as by Stop and ctx's error is returned. Unlike Drain, it does
+// not wait for notifications.
+func (e *Engine) Wait(ctx context.Context) error {
+	if err := e.awaitLoop(ctx); err != nil {
+		return err
+	}
+	e.Stop()
+	return nil
+}
+
+// awaitLoop waits for the run's loop to end, or stops the engine and
+// returns ctx's error if ctx ends first.
+func (e *Engine) awaitLoop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		e.Stop()
+		return ctx.Err()
+	}
+}
+
 // Shutdown gracefully stops the engine with context timeout.
 func (e *Engine) Shutdown(ctx context.Context) error {
 	e.mu.Lock()
@@ -140,6 +406,7 @@ func (e *Engine) Shutdown(ctx context.Context) error {
 		e.mu.Lock()
 		e.running = false
 		e.cancel = nil
+		e.drain = nil
 		e.mu.Unlock()
 		return nil
 	case <-ctx.Done():
@@ -154,41 +421,285 @@ func (e *Engine) IsRunning() bool {
 	return e.running
 }
 
+// Completed returns a channel that is closed the first time a run ends by
+// reaching its duration, request limit, or total spend cap.
+func (e *Engine) Completed() <-chan struct{} {
+	return e.completed
+}
+
+// RPS returns the current requests per second rate.
+func (e *Engine) RPS() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rps
+}
+
+// SetRPS changes the requests per second rate. A running loop picks up
+// the new rate on its next iteration without restarting; an explicit rate
+// also ends any ramp in progress for the rest of the run.
+func (e *Engine) SetRPS(rps int) error {
+	if rps <= 0 {
+		return ErrInvalidRPS
+	}
+
+	e.mu.Lock()
+	e.rps = rps
+	e.mu.Unlock()
+
+	// Coalesce: the loop only needs to know that the rate changed
+	select {
+	case e.rpsChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// SetAuction replaces the auction run on each request's bids, such as
+// after a configuration reload. Auctions already underway finish with the
+// previous one.
+func (e *Engine) SetAuction(a auction.Auction) {
+	e.auction.Store(&a)
+}
+
 // loop runs the main simulation loop.
-func (e *Engine) loop(ctx context.Context) {
+func (e *Engine) loop(ctx context.Context, drain <-chan struct{}) {
 	defer e.wg.Done()
 
-	interval := time.Second / time.Duration(e.rps)
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	limitReached := e.run(ctx, drain)
+
+	for _, l := range e.listeners {
+		l.RunStopped()
+	}
+	events.Publish(e.events, RunFinished{Time: e.clock.Now(), LimitReached: limitReached})
+	if limitReached {
+		e.complete()
+	}
+}
+
+// run schedules ticks at the current rate until ctx is cancelled, drain
+// is closed, or a run limit (duration, request count, or total spend cap)
+// is reached, reporting whether a limit ended it. The rate is re-evaluated
+// after every tick, so a ramp or SetRPS change takes effect immediately.
+//
+// Each tick issues e.batchSize requests to a pool of e.concurrency
+// workers. Requests that fall due while every worker is busy wait for the
+// next free one; once the next tick falls due, the previous tick's unsent
+// requests are dropped rather than burst later, so an overloaded pool
+// runs below the configured rate instead of queueing. Ticks underway
+// when run returns finish before it does; only a cancelled ctx cuts them
+// short.
+func (e *Engine) run(ctx context.Context, drain <-chan struct{}) (limitReached bool) {
+	pauser, _ := e.dispatcher.(Pauser)
+	capped := e.spend.enabled()
+	if capped {
+		e.spend.reset(pauser)
+	}
+
+	// Workers exit once jobs is closed; run waits for their last ticks,
+	// discarding the outcomes, so no tick outlives the run
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan struct{})
+	outcomes := make(chan auction.Outcome, e.concurrency)
+	var workers sync.WaitGroup
+	for range e.concurrency {
+		workers.Add(1)
+		go e.worker(ctx, jobs, outcomes, &workers)
+	}
+	defer func() {
+		close(jobs)
+		go func() {
+			workers.Wait()
+			close(outcomes)
+		}()
+		for range outcomes {
+		}
+	}()
+
+	// Registered after the worker shutdown, so the filler stops first
+	if e.bufferSize > 0 {
+		buf := &requestBuffer{reqs: make(chan generatedRequest, e.bufferSize)}
+		e.buffer.Store(buf)
+		fillCtx, stopFill := context.WithCancel(ctx)
+		defer stopFill()
+		go e.fill(fillCtx, buf)
+	}
+
+	start, simStart := e.wall.Now(), e.clock.Now()
+	ramping := e.ramp.Duration > 0
+	rate := func() float64 {
+		if !ramping {
+			return float64(e.RPS())
+		}
+		r := e.ramp.rateAt(e.clock.Since(simStart))
+		e.setRampRPS(r)
+		return r
+	}
+
+	next := start.Add(tickInterval(rate(), e.batchSize))
+	timer := e.wall.NewTimer(next.Sub(start))
+	defer timer.Stop()
+
+	// A nil channel never fires, so an unbounded run never times out
+	var deadline <-chan time.Time
+	if e.duration > 0 {
+		deadlineTimer := e.clock.NewTimer(e.duration)
+		defer deadlineTimer.Stop()
+		deadline = deadlineTimer.C()
+	}
 
+	// send is jobs while requests of the current tick are waiting for a
+	// worker, nil otherwise
+	var send chan<- struct{}
+	var pending int
+	var requests uint64
 	for {
 		select {
 		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			e.tick(ctx)
+			return false
+		case <-drain:
+			return false
+		case <-deadline:
+			return true
+		case <-e.rpsChanged:
+			ramping = false
+			now := e.wall.Now()
+			next = now.Add(tickInterval(rate(), e.batchSize))
+			timer.Reset(next.Sub(now))
+		case <-timer.C():
+			send = jobs
+			pending = e.batchSize
+
+			// Schedule from the previous slot so the rate doesn't drift, but
+			// don't burst to catch up after a stall
+			now := e.wall.Now()
+			next = next.Add(tickInterval(rate(), e.batchSize))
+			if next.Before(now) {
+				next = now
+			}
+			timer.Reset(next.Sub(now))
+		case send <- struct{}{}:
+			if pending--; pending == 0 {
+				send = nil
+			}
+			requests++
+			if e.maxRequests > 0 && requests >= e.maxRequests {
+				return true
+			}
+		case outcome := <-outcomes:
+			if capped && e.spend.record(outcome, pauser, e.stats) {
+				return true
+			}
 		}
 	}
 }
 
-// tick performs a single simulation cycle.
-func (e *Engine) tick(ctx context.Context) {
-	// Generate request
-	req := e.generator.Generate()
+// worker runs a tick for each job and reports its outcome.
+func (e *Engine) worker(ctx context.Context, jobs <-chan struct{}, outcomes chan<- auction.Outcome, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for range jobs {
+		outcomes <- e.tick(ctx)
+	}
+}
+
+// setRampRPS publishes the ramp's current rate so RPS reports it.
+func (e *Engine) setRampRPS(rate float64) {
+	rps := max(int(math.Round(rate)), 1)
+	e.mu.Lock()
+	e.rps = rps
+	e.mu.Unlock()
+}
 
-	// Get bid floor from first impression if available
+// complete marks the engine stopped after a run reaches its limit.
+// Called from the loop goroutine.
+func (e *Engine) complete() {
+	e.mu.Lock()
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.running = false
+	e.cancel = nil
+	e.drain = nil
+	e.mu.Unlock()
+
+	e.completedOnce.Do(func() { close(e.completed) })
+}
+
+// tickInterval returns the time between ticks of batch requests at rps
+// requests per second.
+func tickInterval(rps float64, batch int) time.Duration {
+	return time.Duration(float64(batch) * float64(time.Second) / rps)
+}
+
+// generate creates the next request and, for a ScenarioGenerator, the
+// name of the scenario that built it.
+func (e *Engine) generate() (*openrtb.BidRequest, string) {
+	if g, ok := e.generator.(ScenarioGenerator); ok {
+		return g.GenerateScenario()
+	}
+	return e.generator.Generate(), ""
+}
+
+// Settle runs the auction for req on the DSPs' results, as each tick does
+// once the DSPs have answered: bids are validated and converted to the
+// exchange currency, then deal bids compete before the open auction. It
+// records no stats and notifies no one, so recorded responses can be
+// replayed through it.
+func (e *Engine) Settle(req *openrtb.BidRequest, results []dispatcher.Result) auction.Outcome {
+	// Get bid floor and deals from first impression if available
 	bidFloor := e.bidFloor
-	if len(req.Imp) > 0 && req.Imp[0].BidFloor > 0 {
-		bidFloor = req.Imp[0].BidFloor
+	var pmp *openrtb.PMP
+	if len(req.Imp) > 0 {
+		if req.Imp[0].BidFloor > 0 {
+			bidFloor = req.Imp[0].BidFloor
+		}
+		pmp = req.Imp[0].PMP
 	}
 
-	// Dispatch to DSPs
-	results := e.dispatcher.Dispatch(ctx, req)
+	bids := results
+	var rejected []auction.Rejection
+	if e.validate {
+		bids, rejected = auction.ValidateBids(req, bids)
+	}
+	if e.rates != nil {
+		var unconverted []auction.Rejection
+		bids, unconverted = auction.ConvertBids(bids, e.rates)
+		rejected = append(rejected, unconverted...)
+	}
+	outcome := auction.RunDeals(*e.auction.Load(), req.ID, bidFloor, pmp, bids)
+	if len(rejected) > 0 {
+		outcome.Rejected = append(rejected, outcome.Rejected...)
+	}
+	outcome.Completed, outcome.Cancelled = dispatcher.Calls(results)
+	outcome.Partial = outcome.Cancelled > 0
+	return outcome
+}
+
+// tick performs a single simulation cycle and returns the auction outcome.
+func (e *Engine) tick(ctx context.Context) auction.Outcome {
+	start := e.wall.Now()
 
-	// Run auction
-	outcome := e.auction.Run(req.ID, bidFloor, results)
+	// Generate request, or take one generated ahead
+	req, scenario := e.next()
+
+	// Dispatch to DSPs; time spent generating counts against Tmax
+	results := e.dispatcher.Dispatch(dispatcher.ContextWithStart(ctx, start), req)
+
+	outcome := e.Settle(req, results)
+	outcome.Scenario = scenario
+	elapsed := e.wall.Since(start)
 
 	// Record stats
+	e.stats.RecordRequest(req)
 	e.stats.RecordAuction(outcome, results)
+	e.stats.RecordAuctionDuration(elapsed, time.Duration(req.Tmax)*time.Millisecond)
+
+	if e.notifier != nil {
+		e.notifier.Notify(outcome)
+	}
+	for _, o := range e.observers {
+		o.ObserveAuction(req, results, outcome)
+	}
+	e.publishAuction(req, results, outcome)
+	return outcome
 }
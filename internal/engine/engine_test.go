@@ -2,12 +2,17 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/currency"
 	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/events"
 	"github.com/cass/rtb-simulator/internal/stats"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
@@ -184,6 +189,61 @@ func TestEngine_GracefulShutdown(t *testing.T) {
 	}
 }
 
+func TestEngine_SetRPS(t *testing.T) {
+	gen := &mockGenerator{}
+	disp := &mockDispatcher{
+		results: []dispatcher.Result{
+			{DSPName: "test", Response: &openrtb.BidResponse{ID: "1"}},
+		},
+	}
+	e := New(gen, disp, auction.NewFirstPrice(), stats.New(), WithRPS(2))
+
+	_ = e.Start()
+	defer e.Stop()
+
+	// At 2 RPS the first tick is 500ms away; raising the rate must take
+	// effect without waiting for it or restarting the loop.
+	if err := e.SetRPS(100); err != nil {
+		t.Fatalf("SetRPS() error = %v", err)
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	calls := atomic.LoadUint64(&disp.calls)
+	if calls < 10 {
+		t.Errorf("Dispatch calls = %d after raising to 100 RPS for 250ms, want >= 10", calls)
+	}
+	if got := e.RPS(); got != 100 {
+		t.Errorf("RPS() = %d, want 100", got)
+	}
+	if !e.IsRunning() {
+		t.Error("IsRunning() = false, want true")
+	}
+}
+
+func TestEngine_SetRPS_Invalid(t *testing.T) {
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(), WithRPS(10))
+
+	for _, rps := range []int{0, -5} {
+		if err := e.SetRPS(rps); !errors.Is(err, ErrInvalidRPS) {
+			t.Errorf("SetRPS(%d) error = %v, want ErrInvalidRPS", rps, err)
+		}
+	}
+	if got := e.RPS(); got != 10 {
+		t.Errorf("RPS() = %d, want unchanged 10", got)
+	}
+}
+
+func TestEngine_SetRPS_Stopped(t *testing.T) {
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(), WithRPS(10))
+
+	if err := e.SetRPS(20); err != nil {
+		t.Fatalf("SetRPS() error = %v", err)
+	}
+	if got := e.RPS(); got != 20 {
+		t.Errorf("RPS() = %d, want 20", got)
+	}
+}
+
 func TestEngine_Options(t *testing.T) {
 	gen := &mockGenerator{}
 	disp := &mockDispatcher{}
@@ -203,6 +263,175 @@ func TestEngine_Options(t *testing.T) {
 	}
 }
 
+// mockNotifier counts notified outcomes.
+type mockNotifier struct {
+	outcomes atomic.Uint64
+}
+
+func (m *mockNotifier) Notify(outcome auction.Outcome) {
+	m.outcomes.Add(1)
+}
+
+// flushingNotifier records Flush calls.
+type flushingNotifier struct {
+	mockNotifier
+	flushed atomic.Bool
+}
+
+func (f *flushingNotifier) Flush(ctx context.Context) error {
+	f.flushed.Store(true)
+	return nil
+}
+
+func TestEngine_Notifier(t *testing.T) {
+	gen := &mockGenerator{}
+	disp := &mockDispatcher{
+		results: []dispatcher.Result{
+			{DSPName: "test", Response: &openrtb.BidResponse{ID: "1"}},
+		},
+	}
+	notifier := &mockNotifier{}
+
+	e := New(gen, disp, auction.NewFirstPrice(), stats.New(), WithNotifier(notifier))
+
+	e.tick(context.Background())
+	e.tick(context.Background())
+
+	if got := notifier.outcomes.Load(); got != 2 {
+		t.Errorf("notified outcomes = %d, want 2", got)
+	}
+}
+
+func TestEngine_WithCurrency(t *testing.T) {
+	rates, err := currency.NewTable("USD", map[string]float64{"EUR": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disp := &mockDispatcher{
+		results: []dispatcher.Result{
+			{DSPName: "usd", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "a", Price: 1.5}}}}}},
+			{DSPName: "eur", Response: &openrtb.BidResponse{Cur: "EUR", SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", Price: 1}}}}}},
+			{DSPName: "gbp", Response: &openrtb.BidResponse{Cur: "GBP", SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "c", Price: 9}}}}}},
+		},
+	}
+
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(), WithCurrency(rates))
+	outcome := e.tick(context.Background())
+
+	if outcome.WinningDSP != "eur" || outcome.ClearingPrice != 2 {
+		t.Errorf("winner = %s at %v, want eur at 2 USD", outcome.WinningDSP, outcome.ClearingPrice)
+	}
+	if len(outcome.Rejected) != 1 || outcome.Rejected[0].Reason != auction.ViolationCurrency {
+		t.Errorf("Rejected = %+v, want the GBP bid", outcome.Rejected)
+	}
+}
+
+func TestEngine_WithResponseValidation(t *testing.T) {
+	valid := openrtb.Bid{ID: "a", ImpID: "imp-1", Price: 1, CrID: "cr", AdM: "<div/>"}
+	disp := &mockDispatcher{
+		results: []dispatcher.Result{
+			{DSPName: "good", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Seat: "s1", Bid: []openrtb.Bid{valid}}}}},
+			{DSPName: "bad", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Seat: "s2", Bid: []openrtb.Bid{
+				{ID: "b", ImpID: "imp-9", Price: 5, CrID: "cr", AdM: "<div/>"},
+			}}}}},
+		},
+	}
+
+	collector := stats.New()
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), collector, WithResponseValidation())
+	outcome := e.tick(context.Background())
+
+	if outcome.WinningDSP != "good" {
+		t.Errorf("winner = %s, want good once the bid on an unknown impid is rejected", outcome.WinningDSP)
+	}
+	if len(outcome.Rejected) != 1 || outcome.Rejected[0].Reason != auction.ViolationUnknownImp {
+		t.Errorf("Rejected = %+v, want the bad bid", outcome.Rejected)
+	}
+	if got := collector.Snapshot().DSPStats["bad"].InvalidBids; got != 1 {
+		t.Errorf("InvalidBids = %d, want 1", got)
+	}
+}
+
+// fixedAuction awards every auction to the same DSP.
+type fixedAuction struct{ winner string }
+
+func (f fixedAuction) Run(requestID string, bidFloor float64, results []dispatcher.Result) auction.Outcome {
+	return auction.Outcome{RequestID: requestID, WinningDSP: f.winner, WinnerIndex: -1}
+}
+
+func TestEngine_SetAuction(t *testing.T) {
+	e := New(&mockGenerator{}, &mockDispatcher{}, fixedAuction{"a"}, stats.New())
+	if got := e.tick(context.Background()).WinningDSP; got != "a" {
+		t.Fatalf("winner = %q, want a", got)
+	}
+	e.SetAuction(fixedAuction{"b"})
+	if got := e.tick(context.Background()).WinningDSP; got != "b" {
+		t.Errorf("winner after SetAuction = %q, want b", got)
+	}
+}
+
+// mixedGenerator tags every request with a fixed scenario name.
+type mixedGenerator struct {
+	mockGenerator
+	scenario string
+}
+
+func (m *mixedGenerator) GenerateScenario() (*openrtb.BidRequest, string) {
+	return m.Generate(), m.scenario
+}
+
+func TestEngine_ScenarioGenerator(t *testing.T) {
+	collector := stats.New()
+	e := New(&mixedGenerator{scenario: "video"}, &mockDispatcher{}, auction.NewFirstPrice(), collector)
+
+	if outcome := e.tick(context.Background()); outcome.Scenario != "video" {
+		t.Errorf("Scenario = %q, want video", outcome.Scenario)
+	}
+	if got := collector.Snapshot().Scenarios["video"].Requests; got != 1 {
+		t.Errorf("video requests = %d, want 1", got)
+	}
+
+	if outcome := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New()).tick(context.Background()); outcome.Scenario != "" {
+		t.Errorf("Scenario = %q without a mix, want empty", outcome.Scenario)
+	}
+}
+
+// mockObserver records observed request IDs.
+type mockObserver struct {
+	ids []string
+}
+
+func (m *mockObserver) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	m.ids = append(m.ids, outcome.RequestID)
+}
+
+// timedObserver records when observed auctions completed.
+type timedObserver struct {
+	mockObserver
+	times []time.Time
+}
+
+func (m *timedObserver) ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	m.times = append(m.times, t)
+}
+
+func TestEngine_Observers(t *testing.T) {
+	gen := &mockGenerator{}
+	disp := &mockDispatcher{}
+	first, second := &mockObserver{}, &mockObserver{}
+
+	e := New(gen, disp, auction.NewFirstPrice(), stats.New(),
+		WithObserver(first),
+		WithObserver(second),
+	)
+
+	e.tick(context.Background())
+
+	if len(first.ids) != 1 || len(second.ids) != 1 {
+		t.Errorf("observer calls = %d, %d, want 1, 1", len(first.ids), len(second.ids))
+	}
+}
+
 func BenchmarkEngine_Tick(b *testing.B) {
 	gen := &mockGenerator{}
 	disp := &mockDispatcher{
@@ -231,3 +460,529 @@ func BenchmarkEngine_Tick(b *testing.B) {
 		e.tick(ctx)
 	}
 }
+
+// mockListener counts run lifecycle events.
+type mockListener struct {
+	started, stopped atomic.Int32
+}
+
+func (m *mockListener) RunStarted() { m.started.Add(1) }
+func (m *mockListener) RunStopped() { m.stopped.Add(1) }
+
+func TestEngine_RunListener(t *testing.T) {
+	listener := &mockListener{}
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(),
+		WithRPS(10), WithRunListener(listener))
+
+	_ = e.Start()
+	if got := listener.started.Load(); got != 1 {
+		t.Errorf("RunStarted calls = %d, want 1", got)
+	}
+	e.Stop()
+	if got := listener.stopped.Load(); got != 1 {
+		t.Errorf("RunStopped calls = %d, want 1", got)
+	}
+}
+
+func TestEngine_WithRequestBuffer(t *testing.T) {
+	gen := &mockGenerator{}
+	disp := &mockDispatcher{}
+	e := New(gen, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(10), WithMaxRequests(5), WithRequestBuffer(16))
+
+	if bs := e.RequestBuffer(); bs != (BufferStats{Capacity: 16}) {
+		t.Errorf("RequestBuffer() before a run = %+v, want an empty buffer of 16", bs)
+	}
+	_ = e.Start()
+	time.Sleep(50 * time.Millisecond)
+	if bs := e.RequestBuffer(); bs.Buffered != 16 {
+		t.Errorf("RequestBuffer() = %+v, want 16 requests generated ahead", bs)
+	}
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after max requests")
+	}
+
+	if calls := atomic.LoadUint64(&disp.calls); calls != 5 {
+		t.Errorf("Dispatch calls = %d, want 5", calls)
+	}
+	// Requests sent were taken from the buffer, which was refilled at
+	// least until the last was taken
+	if generated := atomic.LoadUint64(&gen.counter); generated < 16+4 {
+		t.Errorf("generated %d requests, want a full buffer refilled after the first 4 sent", generated)
+	}
+}
+
+func TestEngine_WithMaxRequests(t *testing.T) {
+	disp := &mockDispatcher{}
+	listener := &mockListener{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(200), WithMaxRequests(5), WithRunListener(listener))
+
+	_ = e.Start()
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after max requests")
+	}
+
+	if calls := atomic.LoadUint64(&disp.calls); calls != 5 {
+		t.Errorf("Dispatch calls = %d, want 5", calls)
+	}
+	if e.IsRunning() {
+		t.Error("IsRunning() = true after completion, want false")
+	}
+	if got := listener.stopped.Load(); got != 1 {
+		t.Errorf("RunStopped calls = %d, want 1", got)
+	}
+
+	// A completed engine can be started again
+	if err := e.Start(); err != nil {
+		t.Errorf("Start() after completion error = %v", err)
+	}
+	e.Stop()
+}
+
+func TestEngine_WithDuration(t *testing.T) {
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(),
+		WithRPS(50), WithDuration(100*time.Millisecond))
+
+	start := time.Now()
+	_ = e.Start()
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after duration")
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("completed after %v, want >= 100ms", elapsed)
+	}
+	if e.IsRunning() {
+		t.Error("IsRunning() = true after completion, want false")
+	}
+}
+
+func TestEngine_WithClock(t *testing.T) {
+	// A simulated day in 100ms of wall time
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(),
+		WithRPS(50), WithDuration(24*time.Hour), WithClock(clock.NewScaled(864000)))
+
+	start := time.Now()
+	_ = e.Start()
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after the scaled duration")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("completed after %v, want >= 100ms", elapsed)
+	}
+}
+
+func TestEngine_WithDuration_ManualClock(t *testing.T) {
+	clk := clock.NewManual(time.Now())
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(),
+		WithRPS(50), WithDuration(time.Hour), WithClock(clk))
+
+	_ = e.Start()
+	defer e.Stop()
+	for clk.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clk.Advance(59 * time.Minute)
+	select {
+	case <-e.Completed():
+		t.Fatal("engine completed before its duration")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.Advance(time.Minute)
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after its duration")
+	}
+}
+
+func TestEngine_WithWallClock(t *testing.T) {
+	clk := clock.NewManual(time.Now())
+	disp := &mockDispatcher{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(10), WithWallClock(clk))
+
+	_ = e.Start()
+	defer e.Stop()
+	for clk.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Requests are paced by the wall clock alone
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadUint64(&disp.calls); n != 0 {
+		t.Fatalf("dispatched %d requests before the clock moved, want 0", n)
+	}
+	for i := range uint64(3) {
+		clk.Advance(100 * time.Millisecond)
+		deadline := time.Now().Add(2 * time.Second)
+		for atomic.LoadUint64(&disp.calls) <= i && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadUint64(&disp.calls); n != 3 {
+		t.Errorf("dispatched %d requests after 300ms at 10 RPS, want 3", n)
+	}
+}
+
+func TestEngine_StopBeforeLimit(t *testing.T) {
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(),
+		WithRPS(10), WithMaxRequests(1000))
+
+	_ = e.Start()
+	e.Stop()
+
+	select {
+	case <-e.Completed():
+		t.Error("Completed() closed after manual stop, want open")
+	default:
+	}
+}
+
+func TestRamp_RateAt(t *testing.T) {
+	up := Ramp{StartRPS: 10, EndRPS: 110, Duration: 10 * time.Second}
+	down := Ramp{StartRPS: 100, EndRPS: 20, Duration: 4 * time.Second}
+
+	tests := []struct {
+		name    string
+		ramp    Ramp
+		elapsed time.Duration
+		want    float64
+	}{
+		{"up start", up, 0, 10},
+		{"up midway", up, 5 * time.Second, 60},
+		{"up end", up, 10 * time.Second, 110},
+		{"up holds after end", up, time.Minute, 110},
+		{"down quarter", down, time.Second, 80},
+		{"down holds after end", down, 5 * time.Second, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ramp.rateAt(tt.elapsed); got != tt.want {
+				t.Errorf("rateAt(%v) = %v, want %v", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_WithRamp(t *testing.T) {
+	disp := &mockDispatcher{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(1), WithRamp(20, 400, 200*time.Millisecond))
+
+	_ = e.Start()
+	defer e.Stop()
+
+	// ~42 requests during the ramp and ~40 more at the held 400 RPS; the
+	// configured 1 RPS would have sent none.
+	time.Sleep(300 * time.Millisecond)
+
+	calls := atomic.LoadUint64(&disp.calls)
+	if calls < 30 {
+		t.Errorf("Dispatch calls = %d after ramp, want >= 30", calls)
+	}
+	if calls > 120 {
+		t.Errorf("Dispatch calls = %d after ramp, want <= 120", calls)
+	}
+	if got := e.RPS(); got != 400 {
+		t.Errorf("RPS() = %d after ramp, want 400", got)
+	}
+}
+
+func TestEngine_SetRPS_EndsRamp(t *testing.T) {
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(),
+		WithRamp(10, 1000, time.Minute))
+
+	_ = e.Start()
+	defer e.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := e.SetRPS(50); err != nil {
+		t.Fatalf("SetRPS() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if got := e.RPS(); got != 50 {
+		t.Errorf("RPS() = %d after SetRPS during ramp, want 50", got)
+	}
+}
+
+// slowDispatcher takes delay to answer every request, counting requests
+// cancelled before then.
+type slowDispatcher struct {
+	delay     time.Duration
+	calls     atomic.Uint64
+	cancelled atomic.Uint64
+}
+
+func (s *slowDispatcher) Dispatch(ctx context.Context, req *openrtb.BidRequest) []dispatcher.Result {
+	s.calls.Add(1)
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		s.cancelled.Add(1)
+	}
+	return nil
+}
+
+func (s *slowDispatcher) Close() {}
+
+func TestEngine_WithConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		min, max    uint64
+	}{
+		// At 100 RPS over 300ms with 50ms dispatches
+		{"serial", 1, 1, 8},
+		{"pooled", 10, 20, 35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disp := &slowDispatcher{delay: 50 * time.Millisecond}
+			e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+				WithRPS(100), WithConcurrency(tt.concurrency))
+
+			_ = e.Start()
+			time.Sleep(300 * time.Millisecond)
+			e.Stop()
+
+			if calls := disp.calls.Load(); calls < tt.min || calls > tt.max {
+				t.Errorf("Dispatch calls = %d, want %d-%d", calls, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestEngine_WithConcurrency_MaxRequests(t *testing.T) {
+	disp := &slowDispatcher{delay: 20 * time.Millisecond}
+	collector := stats.New()
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), collector,
+		WithRPS(500), WithConcurrency(8), WithMaxRequests(20))
+
+	_ = e.Start()
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after max requests")
+	}
+
+	if calls := disp.calls.Load(); calls != 20 {
+		t.Errorf("Dispatch calls = %d, want 20", calls)
+	}
+	// In-flight ticks finish before the run ends
+	if got := collector.Snapshot().TotalRequests; got != 20 {
+		t.Errorf("TotalRequests = %d, want 20", got)
+	}
+}
+
+func TestEngine_WithBatchSize(t *testing.T) {
+	disp := &slowDispatcher{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(2000), WithBatchSize(100), WithConcurrency(100))
+
+	// 20 ticks per second, so 300ms should give about 6 batches of 100
+	_ = e.Start()
+	time.Sleep(300 * time.Millisecond)
+	e.Stop()
+
+	if calls := disp.calls.Load(); calls < 400 || calls > 700 {
+		t.Errorf("Dispatch calls = %d, want 400-700", calls)
+	}
+}
+
+func TestEngine_WithBatchSize_MaxRequests(t *testing.T) {
+	disp := &slowDispatcher{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(1000), WithBatchSize(8), WithConcurrency(8), WithMaxRequests(20))
+
+	_ = e.Start()
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after max requests")
+	}
+
+	// The last batch is cut short at the limit
+	if calls := disp.calls.Load(); calls != 20 {
+		t.Errorf("Dispatch calls = %d, want 20", calls)
+	}
+}
+
+func TestEngine_Events(t *testing.T) {
+	bus := events.New()
+	var completed []AuctionCompleted
+	var dspErrors []DSPError
+	var finished []RunFinished
+	events.Subscribe(bus, "completed", func(ev AuctionCompleted) { completed = append(completed, ev) })
+	events.Subscribe(bus, "errors", func(ev DSPError) { dspErrors = append(dspErrors, ev) })
+	events.Subscribe(bus, "finished", func(ev RunFinished) { finished = append(finished, ev) })
+
+	disp := &mockDispatcher{results: []dispatcher.Result{
+		{DSPName: "ok", Response: &openrtb.BidResponse{}},
+		{DSPName: "down", Error: errors.New("connection refused")},
+	}}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(1000), WithMaxRequests(2), WithEvents(bus))
+
+	_ = e.Start()
+	<-e.Completed()
+	e.Stop()
+	bus.Close()
+
+	if len(completed) != 2 {
+		t.Fatalf("AuctionCompleted events = %d, want 2", len(completed))
+	}
+	if got := completed[0]; got.Request == nil || len(got.Results) != 2 || got.Outcome.RequestID != got.Request.ID {
+		t.Errorf("AuctionCompleted = %+v, want the request, results, and outcome", got)
+	}
+	if len(dspErrors) != 2 || dspErrors[0].DSP != "down" || dspErrors[0].Err == nil {
+		t.Errorf("DSPError events = %+v, want one per auction from down", dspErrors)
+	}
+	if len(finished) != 1 || !finished[0].LimitReached {
+		t.Errorf("RunFinished events = %+v, want one with LimitReached", finished)
+	}
+}
+
+func TestSubscribeObserver(t *testing.T) {
+	bus := events.New()
+	obs := &mockObserver{}
+	SubscribeObserver(bus, "observer", obs)
+
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(), WithEvents(bus))
+	e.tick(context.Background())
+	e.tick(context.Background())
+	bus.Close()
+
+	if len(obs.ids) != 2 {
+		t.Errorf("observer calls = %d, want 2", len(obs.ids))
+	}
+}
+
+func TestSubscribeObserver_Timed(t *testing.T) {
+	bus := events.New()
+	obs := &timedObserver{}
+	SubscribeObserver(bus, "observer", obs)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	e := New(&mockGenerator{}, &mockDispatcher{}, auction.NewFirstPrice(), stats.New(), WithEvents(bus), WithClock(clk))
+	e.tick(context.Background())
+	clk.Advance(time.Hour)
+	e.tick(context.Background())
+	bus.Close()
+
+	if want := []time.Time{start, start.Add(time.Hour)}; !slices.Equal(obs.times, want) {
+		t.Errorf("observed times = %v, want the engine clock's %v", obs.times, want)
+	}
+	if len(obs.ids) != 0 {
+		t.Errorf("ObserveAuction called %d times, want ObserveAuctionAt only", len(obs.ids))
+	}
+}
+
+func TestEngine_Drain(t *testing.T) {
+	disp := &slowDispatcher{delay: 100 * time.Millisecond}
+	collector := stats.New()
+	notifier := &flushingNotifier{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), collector,
+		WithRPS(200), WithConcurrency(8), WithNotifier(notifier))
+
+	_ = e.Start()
+	time.Sleep(30 * time.Millisecond)
+	if err := e.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	if e.IsRunning() {
+		t.Error("IsRunning() = true after Drain()")
+	}
+	calls := disp.calls.Load()
+	if calls == 0 {
+		t.Fatal("Dispatch() was never called")
+	}
+	if n := disp.cancelled.Load(); n != 0 {
+		t.Errorf("%d of %d dispatches cancelled by Drain(), want none", n, calls)
+	}
+	if got := collector.Snapshot().TotalRequests; got != calls {
+		t.Errorf("TotalRequests = %d, want all %d dispatched auctions recorded", got, calls)
+	}
+	if !notifier.flushed.Load() {
+		t.Error("Drain() did not flush the notifier")
+	}
+
+	// The engine starts again after a drain
+	if err := e.Start(); err != nil {
+		t.Fatalf("Start() after Drain() error = %v", err)
+	}
+	e.Stop()
+}
+
+func TestEngine_StopGeneratingAndWait(t *testing.T) {
+	disp := &slowDispatcher{delay: 100 * time.Millisecond}
+	collector := stats.New()
+	notifier := &flushingNotifier{}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), collector,
+		WithRPS(200), WithConcurrency(8), WithNotifier(notifier))
+
+	_ = e.Start()
+	time.Sleep(30 * time.Millisecond)
+	e.StopGenerating()
+	if !e.IsRunning() {
+		t.Error("IsRunning() = false with auctions underway")
+	}
+	if err := e.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	if e.IsRunning() {
+		t.Error("IsRunning() = true after Wait()")
+	}
+	calls := disp.calls.Load()
+	if calls == 0 {
+		t.Fatal("Dispatch() was never called")
+	}
+	if n := disp.cancelled.Load(); n != 0 {
+		t.Errorf("%d of %d dispatches cancelled, want none", n, calls)
+	}
+	if got := collector.Snapshot().TotalRequests; got != calls {
+		t.Errorf("TotalRequests = %d, want all %d dispatched auctions recorded", got, calls)
+	}
+	if notifier.flushed.Load() {
+		t.Error("Wait() flushed the notifier")
+	}
+}
+
+func TestEngine_Drain_Timeout(t *testing.T) {
+	disp := &slowDispatcher{delay: time.Minute}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(200), WithConcurrency(4))
+
+	_ = e.Start()
+	time.Sleep(30 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := e.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain() error = %v, want deadline exceeded", err)
+	}
+
+	if e.IsRunning() {
+		t.Error("IsRunning() = true after a timed out Drain()")
+	}
+	if disp.cancelled.Load() == 0 {
+		t.Error("no dispatch cancelled after the drain timed out")
+	}
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/events"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// AuctionCompleted is published after every auction, once its stats are
+// recorded. Subscribers must not modify the request or results.
+type AuctionCompleted struct {
+	Time    time.Time
+	Request *openrtb.BidRequest
+	Results []dispatcher.Result
+	Outcome auction.Outcome
+}
+
+// DSPError is published for each DSP call in an auction that failed.
+type DSPError struct {
+	Time      time.Time
+	RequestID string
+	DSP       string
+	Err       error
+	Latency   time.Duration
+}
+
+// RunFinished is published when a run ends, after the run listeners are
+// told. LimitReached is set when the run ended on its duration, request
+// limit, or total spend cap rather than being stopped.
+type RunFinished struct {
+	Time         time.Time
+	LimitReached bool
+}
+
+// WithEvents publishes AuctionCompleted, DSPError, and RunFinished events
+// to bus.
+func WithEvents(bus *events.Bus) Option {
+	return func(e *Engine) {
+		e.events = bus
+	}
+}
+
+// SubscribeObserver delivers the AuctionCompleted events on bus to o, off
+// the engine's hot path. A TimedObserver is given each event's Time.
+func SubscribeObserver(bus *events.Bus, name string, o Observer, opts ...events.SubscribeOption) {
+	if to, ok := o.(TimedObserver); ok {
+		events.Subscribe(bus, name, func(ev AuctionCompleted) {
+			to.ObserveAuctionAt(ev.Time, ev.Request, ev.Results, ev.Outcome)
+		}, opts...)
+		return
+	}
+	events.Subscribe(bus, name, func(ev AuctionCompleted) {
+		o.ObserveAuction(ev.Request, ev.Results, ev.Outcome)
+	}, opts...)
+}
+
+// publishAuction publishes a completed auction and its failed DSP calls.
+func (e *Engine) publishAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	if e.events == nil {
+		return
+	}
+	now := e.clock.Now()
+	events.Publish(e.events, AuctionCompleted{Time: now, Request: req, Results: results, Outcome: outcome})
+	for _, r := range results {
+		if r.Error != nil {
+			events.Publish(e.events, DSPError{Time: now, RequestID: req.ID, DSP: r.DSPName, Err: r.Error, Latency: r.Latency})
+		}
+	}
+}
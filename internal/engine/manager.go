@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+var (
+	ErrDuplicateSimulation = errors.New("simulation already exists")
+	ErrUnknownSimulation   = errors.New("unknown simulation")
+)
+
+// Controller is the part of an Engine a Manager drives.
+type Controller interface {
+	Start() error
+	Stop()
+	StopGenerating()
+	Wait(ctx context.Context) error
+	IsRunning() bool
+	RPS() int
+}
+
+// Simulation is a named engine with its own stats, hosted by a Manager
+// alongside others in the same process.
+type Simulation struct {
+	Name     string
+	Engine   Controller
+	Stats    *stats.Collector
+	Scenario string
+	DSPs     []string // names of the DSPs it bids to
+
+	// Close releases what the simulation holds once it has stopped, such
+	// as its dispatcher. Nil when there is nothing to release.
+	Close func()
+}
+
+// Manager hosts several named simulations, such as two DSP
+// configurations under the same traffic, and starts and stops them
+// individually or together.
+type Manager struct {
+	mu   sync.RWMutex
+	sims []*Simulation // in the order added
+}
+
+// NewManager returns a manager hosting no simulations.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Add hosts sim, stopped until started.
+func (m *Manager) Add(sim *Simulation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sims {
+		if s.Name == sim.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateSimulation, sim.Name)
+		}
+	}
+	m.sims = append(m.sims, sim)
+	return nil
+}
+
+// Get returns the simulation with the given name.
+func (m *Manager) Get(name string) (*Simulation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, s := range m.sims {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSimulation, name)
+}
+
+// List returns the hosted simulations in the order they were added.
+func (m *Manager) List() []*Simulation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]*Simulation(nil), m.sims...)
+}
+
+// StartAll starts every simulation not already running, back to back so
+// they see the same conditions. If one fails to start, those started
+// here are stopped again.
+func (m *Manager) StartAll() error {
+	var started []*Simulation
+	for _, s := range m.List() {
+		if s.Engine.IsRunning() {
+			continue
+		}
+		if err := s.Engine.Start(); err != nil {
+			for _, st := range started {
+				st.Engine.Stop()
+			}
+			return fmt.Errorf("starting %s: %w", s.Name, err)
+		}
+		started = append(started, s)
+	}
+	return nil
+}
+
+// StopAll stops every running simulation.
+func (m *Manager) StopAll() {
+	for _, s := range m.List() {
+		s.Engine.Stop()
+	}
+}
+
+// StopGenerating stops every simulation generating requests, leaving the
+// auctions underway to finish; see Wait.
+func (m *Manager) StopGenerating() {
+	for _, s := range m.List() {
+		s.Engine.StopGenerating()
+	}
+}
+
+// Wait waits for every simulation that stopped generating requests to
+// finish its auctions, returning ctx's error if it ends first. As with
+// Engine.Wait, the auctions left are then cancelled.
+func (m *Manager) Wait(ctx context.Context) error {
+	var err error
+	for _, s := range m.List() {
+		err = cmp.Or(err, s.Engine.Wait(ctx))
+	}
+	return err
+}
+
+// Close stops every simulation and releases their resources.
+func (m *Manager) Close() {
+	m.StopAll()
+	for _, s := range m.List() {
+		if s.Close != nil {
+			s.Close()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// failingController never starts.
+type failingController struct{}
+
+func (failingController) Start() error               { return errors.New("no dsps") }
+func (failingController) Stop()                      {}
+func (failingController) StopGenerating()            {}
+func (failingController) Wait(context.Context) error { return nil }
+func (failingController) IsRunning() bool            { return false }
+func (failingController) RPS() int                   { return 0 }
+
+func newTestSimulation(name string, price float64) *Simulation {
+	disp := &mockDispatcher{results: []dispatcher.Result{{
+		DSPName: name + "-dsp",
+		Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{
+			Bid: []openrtb.Bid{{ID: "bid-1", ImpID: "imp-1", Price: price}},
+		}}},
+	}}}
+	collector := stats.New()
+	return &Simulation{
+		Name:   name,
+		Engine: New(&mockGenerator{}, disp, auction.NewFirstPrice(), collector, WithRPS(200)),
+		Stats:  collector,
+	}
+}
+
+func TestManager_StartStopAll(t *testing.T) {
+	m := NewManager()
+	a, b := newTestSimulation("a", 1), newTestSimulation("b", 2)
+	closed := 0
+	a.Close = func() { closed++ }
+	for _, sim := range []*Simulation{a, b} {
+		if err := m.Add(sim); err != nil {
+			t.Fatalf("Add(%s) error = %v", sim.Name, err)
+		}
+	}
+	if err := m.Add(newTestSimulation("a", 1)); !errors.Is(err, ErrDuplicateSimulation) {
+		t.Errorf("Add(duplicate) error = %v, want ErrDuplicateSimulation", err)
+	}
+	if _, err := m.Get("c"); !errors.Is(err, ErrUnknownSimulation) {
+		t.Errorf("Get(c) error = %v, want ErrUnknownSimulation", err)
+	}
+	if got := m.List(); len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("List() = %v, want a and b in order", got)
+	}
+
+	if err := m.StartAll(); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	if !a.Engine.IsRunning() || !b.Engine.IsRunning() {
+		t.Fatal("StartAll() left a simulation stopped")
+	}
+	time.Sleep(50 * time.Millisecond)
+	m.Close()
+
+	if a.Engine.IsRunning() || b.Engine.IsRunning() {
+		t.Error("Close() left a simulation running")
+	}
+	if closed != 1 {
+		t.Errorf("Close func called %d times, want 1", closed)
+	}
+	// Each simulation records only its own auctions
+	for _, sim := range []*Simulation{a, b} {
+		snap := sim.Stats.Snapshot()
+		if snap.TotalRequests == 0 {
+			t.Errorf("%s recorded no requests", sim.Name)
+		}
+		if _, ok := snap.DSPStats[sim.Name+"-dsp"]; !ok || len(snap.DSPStats) != 1 {
+			t.Errorf("%s DSP stats = %v, want only %s-dsp", sim.Name, snap.DSPStats, sim.Name)
+		}
+	}
+}
+
+func TestManager_StopGeneratingAndWait(t *testing.T) {
+	m := NewManager()
+	a, b := newTestSimulation("a", 1), newTestSimulation("b", 2)
+	_ = m.Add(a)
+	_ = m.Add(b)
+	if err := m.StartAll(); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	m.StopGenerating()
+	if err := m.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if a.Engine.IsRunning() || b.Engine.IsRunning() {
+		t.Error("Wait() left a simulation running")
+	}
+	for _, sim := range []*Simulation{a, b} {
+		if snap := sim.Stats.Snapshot(); snap.TotalRequests == 0 || snap.TotalErrors != 0 {
+			t.Errorf("%s: %d requests, %d errors, want auctions finished without errors", sim.Name, snap.TotalRequests, snap.TotalErrors)
+		}
+	}
+}
+
+func TestManager_StartAllRollsBack(t *testing.T) {
+	m := NewManager()
+	a := newTestSimulation("a", 1)
+	_ = m.Add(a)
+	_ = m.Add(&Simulation{Name: "broken", Engine: failingController{}})
+
+	if err := m.StartAll(); err == nil {
+		t.Fatal("StartAll() error = nil, want the failed start")
+	}
+	if a.Engine.IsRunning() {
+		t.Error("StartAll() left a running after another failed to start")
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"log"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// Pauser pauses and resumes individual DSPs. Per-DSP spend caps are only
+// enforced when the engine's dispatcher implements it.
+type Pauser interface {
+	SetPaused(name string, paused bool) error
+}
+
+// WithSpendCaps bounds spend per run: once total clearing prices reach
+// total the run ends, and once a DSP's winning spend reaches its entry in
+// perDSP that DSP is paused for the rest of the run. Zero disables a cap.
+func WithSpendCaps(total float64, perDSP map[string]float64) Option {
+	return func(e *Engine) {
+		e.spend = spendTracker{totalCap: total, dspCaps: perDSP}
+	}
+}
+
+// spendTracker accumulates winning spend over a run and enforces caps.
+// Only the loop goroutine touches it.
+type spendTracker struct {
+	totalCap float64
+	dspCaps  map[string]float64
+
+	total  float64
+	dsp    map[string]float64
+	paused []string
+}
+
+func (s *spendTracker) enabled() bool {
+	return s.totalCap > 0 || len(s.dspCaps) > 0
+}
+
+// reset clears the run's spend and resumes DSPs paused by their caps.
+func (s *spendTracker) reset(p Pauser) {
+	for _, name := range s.paused {
+		if err := p.SetPaused(name, false); err != nil {
+			log.Printf("spend cap: resume %s: %v", name, err)
+		}
+	}
+	s.total = 0
+	s.dsp = make(map[string]float64)
+	s.paused = nil
+}
+
+// record charges an auction's clearing price to its winner, pausing the
+// winner if it reached its cap. It reports whether the total cap was
+// reached.
+func (s *spendTracker) record(outcome auction.Outcome, p Pauser, st *stats.Collector) bool {
+	if outcome.Winner == nil {
+		return false
+	}
+
+	name := outcome.WinningDSP
+	before := s.dsp[name]
+	s.dsp[name] += outcome.ClearingPrice
+	if limit := s.dspCaps[name]; limit > 0 && before < limit && s.dsp[name] >= limit {
+		log.Printf("spend cap: %s spent $%.4f of $%.4f, pausing for the rest of the run", name, s.dsp[name], limit)
+		st.RecordCapHit(name, s.dsp[name], limit)
+		if p != nil {
+			if err := p.SetPaused(name, true); err != nil {
+				log.Printf("spend cap: pause %s: %v", name, err)
+			} else {
+				s.paused = append(s.paused, name)
+			}
+		}
+	}
+
+	s.total += outcome.ClearingPrice
+	if s.totalCap > 0 && s.total >= s.totalCap {
+		log.Printf("spend cap: total spend $%.4f reached $%.4f, ending run", s.total, s.totalCap)
+		st.RecordCapHit("", s.total, s.totalCap)
+		return true
+	}
+	return false
+}
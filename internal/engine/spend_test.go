@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// mockPauser records the paused state of each DSP.
+type mockPauser struct {
+	paused map[string]bool
+}
+
+func (m *mockPauser) SetPaused(name string, paused bool) error {
+	m.paused[name] = paused
+	return nil
+}
+
+func win(dsp string, price float64) auction.Outcome {
+	return auction.Outcome{Winner: &openrtb.Bid{Price: price}, WinningDSP: dsp, ClearingPrice: price}
+}
+
+func TestSpendTracker_DSPCap(t *testing.T) {
+	pauser := &mockPauser{paused: make(map[string]bool)}
+	collector := stats.New()
+	s := spendTracker{dspCaps: map[string]float64{"dsp1": 5}}
+	s.reset(pauser)
+
+	for _, o := range []auction.Outcome{win("dsp1", 2), win("dsp2", 10), win("dsp1", 2), {WinnerIndex: -1}} {
+		if s.record(o, pauser, collector) {
+			t.Fatal("record() = true without a total cap")
+		}
+	}
+	if pauser.paused["dsp1"] {
+		t.Error("dsp1 paused below its cap")
+	}
+
+	s.record(win("dsp1", 2), pauser, collector)
+	if !pauser.paused["dsp1"] || pauser.paused["dsp2"] {
+		t.Errorf("paused = %v, want only dsp1", pauser.paused)
+	}
+	s.record(win("dsp1", 2), pauser, collector)
+
+	hits := collector.Snapshot().CapHits
+	if len(hits) != 1 || hits[0].DSP != "dsp1" || hits[0].Spend != 6 || hits[0].Cap != 5 {
+		t.Errorf("CapHits = %+v, want one hit for dsp1 at $6 of $5", hits)
+	}
+
+	// A new run resumes capped DSPs and starts spend from zero
+	s.reset(pauser)
+	if pauser.paused["dsp1"] {
+		t.Error("dsp1 still paused after reset")
+	}
+	if s.dsp["dsp1"] != 0 {
+		t.Errorf("dsp1 spend after reset = %v, want 0", s.dsp["dsp1"])
+	}
+}
+
+func TestSpendTracker_TotalCap(t *testing.T) {
+	collector := stats.New()
+	s := spendTracker{totalCap: 5}
+	s.reset(nil)
+
+	if s.record(win("dsp1", 3), nil, collector) {
+		t.Error("record() = true below the total cap")
+	}
+	if !s.record(win("dsp2", 3), nil, collector) {
+		t.Error("record() = false at the total cap")
+	}
+	hits := collector.Snapshot().CapHits
+	if len(hits) != 1 || hits[0].DSP != "" || hits[0].Spend != 6 {
+		t.Errorf("CapHits = %+v, want one exchange-wide hit at $6", hits)
+	}
+}
+
+func TestEngine_WithSpendCaps_EndsRun(t *testing.T) {
+	disp := &mockDispatcher{
+		results: []dispatcher.Result{{
+			DSPName: "dsp1",
+			Response: &openrtb.BidResponse{
+				SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "bid-1", Price: 2.0}}}},
+			},
+		}},
+	}
+	e := New(&mockGenerator{}, disp, auction.NewFirstPrice(), stats.New(),
+		WithRPS(200), WithSpendCaps(5, nil))
+
+	_ = e.Start()
+	select {
+	case <-e.Completed():
+	case <-time.After(2 * time.Second):
+		t.Fatal("engine did not complete after reaching the spend cap")
+	}
+
+	// $2 per win: the third auction reaches $6 >= $5
+	if calls := atomic.LoadUint64(&disp.calls); calls != 3 {
+		t.Errorf("Dispatch calls = %d, want 3", calls)
+	}
+}
@@ -0,0 +1,172 @@
+// Package events is an in-process publish/subscribe bus. Producers such
+// as the engine and dispatcher publish typed events without knowing who
+// consumes them; each subscriber receives the events of one type on its
+// own goroutine, so a slow consumer never holds up a publisher.
+package events
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultQueueSize is the number of events buffered per subscriber.
+const DefaultQueueSize = 1024
+
+// Bus routes published events to the subscribers of their type. Safe for
+// concurrent use. A nil *Bus discards everything published to it.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[reflect.Type][]subscriber
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// subscriber is the type-independent side of a subscription.
+type subscriber interface {
+	name() string
+	dropped() uint64
+	close()
+}
+
+// subscription delivers events of type E to fn in publish order.
+type subscription[E any] struct {
+	label    string
+	queue    chan E
+	fn       func(E)
+	blocking bool
+	nDropped atomic.Uint64
+}
+
+func (s *subscription[E]) name() string    { return s.label }
+func (s *subscription[E]) dropped() uint64 { return s.nDropped.Load() }
+func (s *subscription[E]) close()          { close(s.queue) }
+
+// offer queues ev, dropping it if the subscriber is full unless the
+// subscription is blocking.
+func (s *subscription[E]) offer(ev E) {
+	if s.blocking {
+		s.queue <- ev
+		return
+	}
+	select {
+	case s.queue <- ev:
+	default:
+		s.nDropped.Add(1)
+	}
+}
+
+func (s *subscription[E]) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for ev := range s.queue {
+		s.fn(ev)
+	}
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscriptionConfig)
+
+type subscriptionConfig struct {
+	queueSize int
+	blocking  bool
+}
+
+// WithQueueSize sets how many events are buffered for the subscriber
+// before further events are dropped.
+func WithQueueSize(n int) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		if n > 0 {
+			c.queueSize = n
+		}
+	}
+}
+
+// Blocking makes publishers wait for room in the subscriber's queue
+// instead of dropping events, for consumers such as file exporters that
+// must see every event. A slow blocking subscriber slows its publishers.
+func Blocking() SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.blocking = true
+	}
+}
+
+// New creates a bus with no subscribers.
+func New() *Bus {
+	return &Bus{subs: make(map[reflect.Type][]subscriber)}
+}
+
+// Subscribe calls fn with every event of type E published from now on,
+// one at a time on a goroutine of its own. Events that arrive while the
+// subscriber's queue is full are dropped and counted under name, unless
+// the subscription is Blocking; see Dropped. Subscribing to a closed bus
+// does nothing.
+func Subscribe[E any](b *Bus, name string, fn func(E), opts ...SubscribeOption) {
+	cfg := subscriptionConfig{queueSize: DefaultQueueSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	s := &subscription[E]{label: name, queue: make(chan E, cfg.queueSize), fn: fn, blocking: cfg.blocking}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	t := reflect.TypeFor[E]()
+	b.subs[t] = append(b.subs[t], s)
+	b.wg.Add(1)
+	go s.run(&b.wg)
+}
+
+// Publish hands ev to every subscriber of its type, blocking only on
+// full Blocking subscribers. Publishing to a nil or closed bus does
+// nothing.
+func Publish[E any](b *Bus, ev E) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	for _, s := range b.subs[reflect.TypeFor[E]()] {
+		s.(*subscription[E]).offer(ev)
+	}
+}
+
+// Close stops accepting events and waits for subscribers to finish the
+// events already queued.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, subs := range b.subs {
+		for _, s := range subs {
+			s.close()
+		}
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+// Dropped returns the number of events dropped per subscriber name, for
+// the subscribers that missed any.
+func (b *Bus) Dropped() map[string]uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := make(map[string]uint64)
+	for _, subs := range b.subs {
+		for _, s := range subs {
+			if n := s.dropped(); n > 0 {
+				out[s.name()] += n
+			}
+		}
+	}
+	return out
+}
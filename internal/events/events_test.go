@@ -0,0 +1,132 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type ping struct{ n int }
+type pong struct{ n int }
+
+func TestBus_DeliversByType(t *testing.T) {
+	b := New()
+	var pings, pongs []int
+	Subscribe(b, "pings", func(ev ping) { pings = append(pings, ev.n) })
+	Subscribe(b, "pongs", func(ev pong) { pongs = append(pongs, ev.n) })
+
+	for i := range 3 {
+		Publish(b, ping{i})
+	}
+	Publish(b, pong{7})
+	b.Close()
+
+	if len(pings) != 3 || pings[0] != 0 || pings[2] != 2 {
+		t.Errorf("pings = %v, want [0 1 2] in order", pings)
+	}
+	if len(pongs) != 1 || pongs[0] != 7 {
+		t.Errorf("pongs = %v, want [7]", pongs)
+	}
+}
+
+func TestBus_FansOut(t *testing.T) {
+	b := New()
+	var mu sync.Mutex
+	got := map[string]int{}
+	for _, name := range []string{"a", "b"} {
+		Subscribe(b, name, func(ping) {
+			mu.Lock()
+			got[name]++
+			mu.Unlock()
+		})
+	}
+
+	Publish(b, ping{1})
+	Publish(b, ping{2})
+	b.Close()
+
+	if got["a"] != 2 || got["b"] != 2 {
+		t.Errorf("deliveries = %v, want 2 each", got)
+	}
+}
+
+func TestBus_DropsWhenSubscriberFull(t *testing.T) {
+	b := New()
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	Subscribe(b, "slow", func(ping) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}, WithQueueSize(2))
+
+	// The first event is taken off the queue and blocks the subscriber
+	Publish(b, ping{0})
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		for i := range 5 {
+			Publish(b, ping{i + 1})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+
+	close(release)
+	b.Close()
+	if got := b.Dropped()["slow"]; got != 3 {
+		t.Errorf("Dropped()[slow] = %d, want 3", got)
+	}
+}
+
+func TestBus_BlockingSubscriberKeepsEveryEvent(t *testing.T) {
+	b := New()
+	release := make(chan struct{})
+	var got []int
+	Subscribe(b, "file", func(ev ping) {
+		<-release
+		got = append(got, ev.n)
+	}, WithQueueSize(1), Blocking())
+
+	done := make(chan struct{})
+	go func() {
+		for i := range 5 {
+			Publish(b, ping{i})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Publish did not wait for a full blocking subscriber")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+	b.Close()
+	if len(got) != 5 || len(b.Dropped()) != 0 {
+		t.Errorf("got %v, dropped %v; want all 5 events and no drops", got, b.Dropped())
+	}
+}
+
+func TestBus_ClosedAndNil(t *testing.T) {
+	Publish[ping](nil, ping{1}) // must not panic
+
+	b := New()
+	b.Close()
+	called := false
+	Subscribe(b, "late", func(ping) { called = true })
+	Publish(b, ping{1})
+	b.Close()
+
+	if called {
+		t.Error("subscriber called after Close")
+	}
+}
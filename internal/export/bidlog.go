@@ -0,0 +1,194 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// BidLogEntry is one auction in the bid log: the request as sent, every
+// DSP's answer, and the outcome, enough to see why a DSP's bids lost.
+type BidLogEntry struct {
+	Timestamp time.Time           `json:"ts"`
+	Request   *openrtb.BidRequest `json:"request"`
+	Responses []BidLogResponse    `json:"responses"`
+	Outcome   BidLogOutcome       `json:"outcome"`
+}
+
+// BidLogResponse is a DSP's answer to the request: its decoded bid
+// response, or why there was none.
+type BidLogResponse struct {
+	DSP       string               `json:"dsp"`
+	LatencyMS float64              `json:"latency_ms"`
+	Response  *openrtb.BidResponse `json:"response,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Skipped   string               `json:"skipped,omitempty"`
+}
+
+// BidLogOutcome is the result of the auction.
+type BidLogOutcome struct {
+	Bids          int     `json:"bids"`
+	WinningDSP    string  `json:"winning_dsp,omitempty"`
+	WinningBidID  string  `json:"winning_bid_id,omitempty"`
+	ClearingPrice float64 `json:"clearing_price"`
+}
+
+// NewBidLogEntry builds a bid log entry for an auction completed at t
+// from its inputs and outcome.
+func NewBidLogEntry(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) BidLogEntry {
+	e := BidLogEntry{
+		Timestamp: t.UTC(),
+		Request:   req,
+		Responses: make([]BidLogResponse, len(results)),
+		Outcome: BidLogOutcome{
+			Bids:          len(outcome.AllBids),
+			WinningDSP:    outcome.WinningDSP,
+			ClearingPrice: outcome.ClearingPrice,
+		},
+	}
+	if outcome.Winner != nil {
+		e.Outcome.WinningBidID = outcome.Winner.ID
+	}
+	for i, r := range results {
+		resp := BidLogResponse{
+			DSP:       r.DSPName,
+			LatencyMS: float64(r.Latency) / float64(time.Millisecond),
+			Response:  r.Response,
+			Skipped:   string(r.Skipped),
+		}
+		if r.Error != nil {
+			resp.Error = r.Error.Error()
+		}
+		e.Responses[i] = resp
+	}
+	return e
+}
+
+// BidLog writes one NDJSON line per sampled auction, with the full
+// request and responses, to a writer such as a file. Like Stream, it
+// drops entries rather than slow the auction loop when the writer
+// cannot keep up, unless it is lossless.
+type BidLog struct {
+	w          *bufio.Writer
+	queue      chan BidLogEntry
+	sampleRate float64
+	lossless   bool
+	clock      clock.Clock
+	dropped    atomic.Uint64
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// BidLogOption configures a BidLog.
+type BidLogOption func(*BidLog)
+
+// WithBidLogSampleRate sets the fraction of auctions logged
+// (0 < rate <= 1).
+func WithBidLogSampleRate(rate float64) BidLogOption {
+	return func(l *BidLog) {
+		l.sampleRate = rate
+	}
+}
+
+// WithBidLogClock stamps auctions observed without a completion time by
+// c instead of the wall clock.
+func WithBidLogClock(c clock.Clock) BidLogOption {
+	return func(l *BidLog) {
+		l.clock = c
+	}
+}
+
+// WithBidLogLossless makes ObserveAuction wait for room in the queue
+// instead of dropping entries.
+func WithBidLogLossless() BidLogOption {
+	return func(l *BidLog) {
+		l.lossless = true
+	}
+}
+
+// NewBidLog creates a bid log writing to w and starts its writer
+// goroutine.
+func NewBidLog(w io.Writer, opts ...BidLogOption) *BidLog {
+	l := &BidLog{
+		w:          bufio.NewWriter(w),
+		queue:      make(chan BidLogEntry, 1024),
+		sampleRate: 1.0,
+		clock:      clock.Real,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	go l.run()
+	return l
+}
+
+// ObserveAuction samples and queues a bid log entry for an auction
+// completed now, by the bid log's clock. It blocks only if the bid log is
+// lossless.
+func (l *BidLog) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	l.ObserveAuctionAt(l.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt samples and queues a bid log entry for an auction
+// completed at t. It blocks only if the bid log is lossless.
+func (l *BidLog) ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	if l.sampleRate < 1 && !randutil.Chance(l.sampleRate) {
+		return
+	}
+	e := NewBidLogEntry(t, req, results, outcome)
+	if l.lossless {
+		l.queue <- e
+		return
+	}
+	select {
+	case l.queue <- e:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// run encodes queued entries, flushing whenever the queue drains.
+func (l *BidLog) run() {
+	defer close(l.done)
+
+	enc := json.NewEncoder(l.w)
+	for e := range l.queue {
+		if err := enc.Encode(e); err != nil {
+			log.Printf("bid log: write failed: %v", err)
+			continue
+		}
+		if len(l.queue) == 0 {
+			if err := l.w.Flush(); err != nil {
+				log.Printf("bid log: flush failed: %v", err)
+			}
+		}
+	}
+	if err := l.w.Flush(); err != nil {
+		log.Printf("bid log: flush failed: %v", err)
+	}
+}
+
+// Dropped returns the number of entries dropped due to backpressure.
+func (l *BidLog) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// Close flushes pending entries and stops the writer.
+// ObserveAuction must not be called after Close.
+func (l *BidLog) Close() {
+	l.closeOnce.Do(func() {
+		close(l.queue)
+		<-l.done
+	})
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestBidLog_WritesEntries(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewBidLog(&buf)
+
+	req, results, outcome := testAuction()
+	l.ObserveAuction(req, results, outcome)
+	l.ObserveAuction(req, results, outcome)
+	l.Close()
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var e BidLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %d: invalid JSON: %v", lines, err)
+		}
+		if e.Request == nil || e.Request.ID != "req-1" || len(e.Request.Imp) != 1 {
+			t.Errorf("line %d: Request = %+v, want the full request", lines, e.Request)
+		}
+		if len(e.Responses) != 2 {
+			t.Fatalf("line %d: %d responses, want 2", lines, len(e.Responses))
+		}
+		if r := e.Responses[0]; r.DSP != "dsp1" || r.Response == nil || len(r.Response.SeatBid) != 1 {
+			t.Errorf("line %d: Responses[0] = %+v, want dsp1's bid response", lines, r)
+		}
+		if r := e.Responses[1]; r.DSP != "dsp2" || r.Error != "timeout" || r.Response != nil {
+			t.Errorf("line %d: Responses[1] = %+v, want dsp2's timeout", lines, r)
+		}
+		if e.Outcome.WinningDSP != "dsp1" || e.Outcome.WinningBidID != "bid-1" || e.Outcome.ClearingPrice != 2.5 {
+			t.Errorf("line %d: Outcome = %+v, want dsp1 winning at 2.5", lines, e.Outcome)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("wrote %d lines, want 2", lines)
+	}
+}
+
+func TestBidLog_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewBidLog(&buf, WithBidLogSampleRate(0.1))
+
+	req, results, outcome := testAuction()
+	for i := 0; i < 1000; i++ {
+		l.ObserveAuction(req, results, outcome)
+	}
+	l.Close()
+
+	lines := bytes.Count(buf.Bytes(), []byte("\n"))
+	if lines < 50 || lines > 150 {
+		t.Errorf("wrote %d of 1000 lines at 10%% sampling, want ~100", lines)
+	}
+}
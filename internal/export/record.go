@@ -0,0 +1,153 @@
+// Package export writes auction data out of the simulator for offline analysis.
+// Exporters observe completed auctions from the engine and serialize them
+// without blocking the auction loop.
+package export
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// AuctionRecord is the flattened, serializable summary of one auction.
+type AuctionRecord struct {
+	Timestamp     time.Time `json:"ts"`
+	RequestID     string    `json:"request_id"`
+	TraceID       string    `json:"trace_id,omitempty"`
+	BidFloor      float64   `json:"bidfloor"`
+	Bids          int       `json:"bids"`
+	WinningDSP    string    `json:"winning_dsp,omitempty"`
+	WinningBidID  string    `json:"winning_bid_id,omitempty"`
+	ClearingPrice float64   `json:"clearing_price"`
+	// Partial marks an auction run while the simulation was stopping;
+	// DSPs whose calls were cancelled are skipped as "cancelled".
+	Partial bool        `json:"partial,omitempty"`
+	DSPs    []DSPRecord `json:"dsps"`
+
+	// Request is the bid request, captured along with the DSP responses
+	// so a replay can validate bids against it and run its deals.
+	Request *openrtb.BidRequest `json:"request,omitempty"`
+}
+
+// DSPRecord summarizes a single DSP's participation in an auction.
+type DSPRecord struct {
+	Name      string  `json:"name"`
+	LatencyMS float64 `json:"latency_ms"`
+	Bids      int     `json:"bids"`
+	MaxPrice  float64 `json:"max_price,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	Skipped   string  `json:"skipped,omitempty"`
+	SpanID    string  `json:"span_id,omitempty"`
+	// Rejected counts bids dropped by the DSP's adomain policy.
+	Rejected int `json:"rejected,omitempty"`
+
+	// Response is the DSP's decoded bid response, captured only by
+	// streams created WithResponses. It makes the record replayable.
+	Response *openrtb.BidResponse `json:"response,omitempty"`
+}
+
+// NewAuctionRecord builds a record of an auction completed at t from its
+// inputs and outcome.
+func NewAuctionRecord(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) AuctionRecord {
+	rec := AuctionRecord{
+		Timestamp:     t.UTC(),
+		RequestID:     outcome.RequestID,
+		Bids:          len(outcome.AllBids),
+		WinningDSP:    outcome.WinningDSP,
+		ClearingPrice: outcome.ClearingPrice,
+		Partial:       outcome.Partial,
+		DSPs:          make([]DSPRecord, len(results)),
+	}
+	if len(req.Imp) > 0 {
+		rec.BidFloor = req.Imp[0].BidFloor
+	}
+	if outcome.Winner != nil {
+		rec.WinningBidID = outcome.Winner.ID
+	}
+
+	for i, r := range results {
+		dr := DSPRecord{
+			Name:      r.DSPName,
+			LatencyMS: float64(r.Latency) / float64(time.Millisecond),
+			Skipped:   string(r.Skipped),
+			SpanID:    r.SpanID,
+		}
+		if rec.TraceID == "" {
+			rec.TraceID = r.TraceID
+		}
+		if r.Error != nil {
+			dr.Error = r.Error.Error()
+		}
+		if r.Response != nil {
+			for _, sb := range r.Response.SeatBid {
+				for _, b := range sb.Bid {
+					dr.Bids++
+					if b.Price > dr.MaxPrice {
+						dr.MaxPrice = b.Price
+					}
+				}
+			}
+		}
+		for _, rej := range outcome.Rejected {
+			if rej.DSPName == r.DSPName {
+				dr.Rejected++
+			}
+		}
+		rec.DSPs[i] = dr
+	}
+
+	return rec
+}
+
+// attachResponses copies the request and each DSP's bid response into the
+// record.
+func (rec *AuctionRecord) attachResponses(req *openrtb.BidRequest, results []dispatcher.Result) {
+	rec.Request = req
+	for i, r := range results {
+		rec.DSPs[i].Response = r.Response
+	}
+}
+
+// Replayable reports whether the record carries every bid the DSPs
+// returned, so the auction can be re-run from it.
+func (rec AuctionRecord) Replayable() bool {
+	for _, dr := range rec.DSPs {
+		if dr.Bids > 0 && dr.Response == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// BidRequest returns the recorded bid request, or for records written
+// without one, a request carrying only the ID and first impression's
+// floor.
+func (rec AuctionRecord) BidRequest() *openrtb.BidRequest {
+	if rec.Request != nil {
+		return rec.Request
+	}
+	return &openrtb.BidRequest{ID: rec.RequestID, Imp: []openrtb.Imp{{BidFloor: rec.BidFloor}}}
+}
+
+// Results reconstructs the DSP results an auction was run on. Errors keep
+// only their message.
+func (rec AuctionRecord) Results() []dispatcher.Result {
+	results := make([]dispatcher.Result, len(rec.DSPs))
+	for i, dr := range rec.DSPs {
+		results[i] = dispatcher.Result{
+			DSPName:  dr.Name,
+			Response: dr.Response,
+			Latency:  time.Duration(dr.LatencyMS * float64(time.Millisecond)),
+			Skipped:  dispatcher.SkipReason(dr.Skipped),
+			TraceID:  rec.TraceID,
+			SpanID:   dr.SpanID,
+		}
+		if dr.Error != "" {
+			results[i].Error = errors.New(dr.Error)
+		}
+	}
+	return results
+}
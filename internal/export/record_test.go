@@ -0,0 +1,101 @@
+package export
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// testAuction returns a request, results, and outcome for a two-DSP auction.
+func testAuction() (*openrtb.BidRequest, []dispatcher.Result, auction.Outcome) {
+	req := &openrtb.BidRequest{
+		ID:  "req-1",
+		Imp: []openrtb.Imp{{ID: "imp-1", BidFloor: 0.5}},
+	}
+	results := []dispatcher.Result{
+		{
+			DSPName: "dsp1",
+			Latency: 12 * time.Millisecond,
+			TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:  "00f067aa0ba902b7",
+			Response: &openrtb.BidResponse{
+				ID:      "req-1",
+				SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "bid-1", ImpID: "imp-1", Price: 2.5}}}},
+			},
+		},
+		{DSPName: "dsp2", Latency: 100 * time.Millisecond, Error: errors.New("timeout"),
+			TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "53995c3f42cd8ad8"},
+	}
+	outcome := auction.NewFirstPrice().Run(req.ID, 0.5, results)
+	return req, results, outcome
+}
+
+func TestNewAuctionRecord(t *testing.T) {
+	req, results, outcome := testAuction()
+	completed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	rec := NewAuctionRecord(completed, req, results, outcome)
+
+	if !rec.Timestamp.Equal(completed) || rec.Timestamp.Location() != time.UTC {
+		t.Errorf("Timestamp = %v, want the completion time %v in UTC", rec.Timestamp, completed)
+	}
+	if rec.RequestID != "req-1" {
+		t.Errorf("RequestID = %q, want req-1", rec.RequestID)
+	}
+	if rec.BidFloor != 0.5 {
+		t.Errorf("BidFloor = %f, want 0.5", rec.BidFloor)
+	}
+	if rec.WinningDSP != "dsp1" || rec.WinningBidID != "bid-1" {
+		t.Errorf("winner = %s/%s, want dsp1/bid-1", rec.WinningDSP, rec.WinningBidID)
+	}
+	if rec.ClearingPrice != 2.5 {
+		t.Errorf("ClearingPrice = %f, want 2.5", rec.ClearingPrice)
+	}
+	if len(rec.DSPs) != 2 {
+		t.Fatalf("len(DSPs) = %d, want 2", len(rec.DSPs))
+	}
+	if rec.DSPs[0].Bids != 1 || rec.DSPs[0].MaxPrice != 2.5 || rec.DSPs[0].LatencyMS != 12 {
+		t.Errorf("DSPs[0] = %+v", rec.DSPs[0])
+	}
+	if rec.DSPs[1].Error != "timeout" {
+		t.Errorf("DSPs[1].Error = %q, want timeout", rec.DSPs[1].Error)
+	}
+	if rec.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("TraceID = %q, want the auction trace", rec.TraceID)
+	}
+	if rec.DSPs[0].SpanID != "00f067aa0ba902b7" || rec.DSPs[1].SpanID != "53995c3f42cd8ad8" {
+		t.Errorf("SpanIDs = %q, %q, want per-DSP spans", rec.DSPs[0].SpanID, rec.DSPs[1].SpanID)
+	}
+}
+
+func TestAuctionRecord_Results(t *testing.T) {
+	req, results, outcome := testAuction()
+	rec := NewAuctionRecord(time.Now(), req, results, outcome)
+	if rec.Replayable() {
+		t.Error("Replayable() = true without captured responses")
+	}
+
+	rec.attachResponses(req, results)
+	if !rec.Replayable() {
+		t.Fatal("Replayable() = false with captured responses")
+	}
+	got := rec.Results()
+	if len(got) != 2 {
+		t.Fatalf("len(Results()) = %d, want 2", len(got))
+	}
+	if got[0].DSPName != "dsp1" || got[0].Response != results[0].Response || got[0].Latency != 12*time.Millisecond {
+		t.Errorf("Results()[0] = %+v, want dsp1's response and latency", got[0])
+	}
+	if got[1].Error == nil || got[1].Error.Error() != "timeout" {
+		t.Errorf("Results()[1].Error = %v, want timeout", got[1].Error)
+	}
+
+	replayed := auction.NewFirstPrice().Run(rec.RequestID, rec.BidFloor, got)
+	if replayed.WinningDSP != outcome.WinningDSP || replayed.ClearingPrice != outcome.ClearingPrice {
+		t.Errorf("replayed winner %s at %v, want %s at %v",
+			replayed.WinningDSP, replayed.ClearingPrice, outcome.WinningDSP, outcome.ClearingPrice)
+	}
+}
@@ -0,0 +1,220 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// rollupHeader names the columns of a rollup CSV.
+var rollupHeader = []string{"minute", "dsp", "requests", "bids", "wins", "revenue", "p99_ms"}
+
+// Rollup writes one CSV row per DSP for every minute of auctions, with
+// the requests sent to the DSP, the bids in its responses, its wins and
+// revenue (clearing prices, in CPM), and its p99 latency. A minute's rows
+// are written once it ends, and the last, partial minute's on Close;
+// minutes without auctions get no rows. An auction observed after its
+// minute was written counts toward the current one. Far cheaper than
+// exporting every auction at high request rates, and often all an
+// analysis needs.
+type Rollup struct {
+	mu       sync.Mutex
+	w        *csv.Writer
+	clock    clock.Clock
+	interval time.Duration
+	start    time.Time // of the minute being rolled up, zero before the first auction
+	dsps     map[string]*rollupRow
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type rollupRow struct {
+	requests uint64
+	bids     uint64
+	wins     uint64
+	revenue  float64
+	latency  stats.Histogram
+}
+
+// RollupOption configures a Rollup.
+type RollupOption func(*Rollup)
+
+// WithRollupClock ends minutes by c instead of the wall clock, and places
+// auctions observed without a completion time by it, so rollups of a
+// time-scaled run follow simulated time.
+func WithRollupClock(c clock.Clock) RollupOption {
+	return func(r *Rollup) {
+		r.clock = c
+	}
+}
+
+// WithRollupInterval rolls up every d instead of every minute. The minute
+// column then holds the start of each interval.
+func WithRollupInterval(d time.Duration) RollupOption {
+	return func(r *Rollup) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+// NewRollup creates a rollup writing CSV to w, starting with a header
+// row, and starts the goroutine that writes each minute as it ends.
+func NewRollup(w io.Writer, opts ...RollupOption) *Rollup {
+	r := &Rollup{
+		w:        csv.NewWriter(w),
+		clock:    clock.Real,
+		interval: time.Minute,
+		dsps:     make(map[string]*rollupRow),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	_ = r.w.Write(rollupHeader)
+	r.w.Flush()
+	go r.run()
+	return r
+}
+
+// ObserveAuction adds an auction completed now, by the rollup's clock.
+func (r *Rollup) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	r.ObserveAuctionAt(r.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt adds an auction completed at t to the rows of t's
+// minute, writing the previous minute's first if t is past it.
+func (r *Rollup) ObserveAuctionAt(t time.Time, _ *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.advance(t)
+	if r.start.IsZero() {
+		r.start = t.Truncate(r.interval)
+	}
+
+	for _, res := range results {
+		if res.Skipped != dispatcher.SkipNone {
+			continue
+		}
+		row := r.row(res.DSPName)
+		row.requests++
+		row.latency.Record(res.Latency)
+		// Every bid the DSP sent, whether or not it was eligible to win
+		if res.Error == nil && res.Response != nil {
+			for _, sb := range res.Response.SeatBid {
+				row.bids += uint64(len(sb.Bid))
+			}
+		}
+	}
+	if outcome.Winner != nil {
+		row := r.row(outcome.WinningDSP)
+		row.wins++
+		row.revenue += outcome.ClearingPrice
+	}
+}
+
+func (r *Rollup) row(dsp string) *rollupRow {
+	row := r.dsps[dsp]
+	if row == nil {
+		row = &rollupRow{}
+		r.dsps[dsp] = row
+	}
+	return row
+}
+
+// run writes each minute once it ends, even if no auction follows it.
+func (r *Rollup) run() {
+	defer close(r.done)
+
+	timer := r.clock.NewTimer(r.untilNext())
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-timer.C():
+		}
+		r.mu.Lock()
+		r.advance(r.clock.Now())
+		r.mu.Unlock()
+		timer.Reset(r.untilNext())
+	}
+}
+
+// untilNext returns the time left until the current minute ends.
+func (r *Rollup) untilNext() time.Duration {
+	now := r.clock.Now()
+	return now.Truncate(r.interval).Add(r.interval).Sub(now)
+}
+
+// advance writes the minute being rolled up if now is past it. Must be
+// called with mu held.
+func (r *Rollup) advance(now time.Time) {
+	if r.start.IsZero() || now.Before(r.start.Add(r.interval)) {
+		return
+	}
+	r.flush()
+	r.start = now.Truncate(r.interval)
+}
+
+// flush writes a row per DSP for the minute being rolled up, in DSP name
+// order, and starts it afresh. Must be called with mu held.
+func (r *Rollup) flush() {
+	if len(r.dsps) == 0 {
+		return
+	}
+	minute := r.start.UTC().Format(time.RFC3339)
+	names := make([]string, 0, len(r.dsps))
+	for name := range r.dsps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		row := r.dsps[name]
+		p99 := float64(row.latency.Quantile(0.99)) / float64(time.Millisecond)
+		_ = r.w.Write([]string{
+			minute,
+			name,
+			strconv.FormatUint(row.requests, 10),
+			strconv.FormatUint(row.bids, 10),
+			strconv.FormatUint(row.wins, 10),
+			strconv.FormatFloat(row.revenue, 'f', 4, 64),
+			strconv.FormatFloat(p99, 'f', 3, 64),
+		})
+	}
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		log.Printf("rollup: write failed: %v", err)
+	}
+	clear(r.dsps)
+}
+
+// Close writes the last, partial minute and stops the rollup, returning
+// an error if writing it failed. ObserveAuction must not be called after
+// Close.
+func (r *Rollup) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+		r.mu.Lock()
+		r.flush()
+		r.mu.Unlock()
+	})
+	return r.w.Error()
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while a Rollup writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) rows(t *testing.T) [][]string {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rows, err := csv.NewReader(strings.NewReader(b.buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	return rows
+}
+
+func TestRollup(t *testing.T) {
+	var buf syncBuffer
+	clk := clock.NewManual(time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC))
+	r := NewRollup(&buf, WithRollupClock(clk))
+
+	req, results, outcome := testAuction()
+	r.ObserveAuction(req, results, outcome)
+	r.ObserveAuction(req, results, outcome)
+	clk.Advance(time.Minute)
+	r.ObserveAuction(req, results, outcome)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := [][]string{
+		rollupHeader,
+		{"2024-05-01T12:00:00Z", "dsp1", "2", "2", "2", "5.0000", "12.000"},
+		{"2024-05-01T12:00:00Z", "dsp2", "2", "0", "0", "0.0000", "100.000"},
+		{"2024-05-01T12:01:00Z", "dsp1", "1", "1", "1", "2.5000", "12.000"},
+		{"2024-05-01T12:01:00Z", "dsp2", "1", "0", "0", "0.0000", "100.000"},
+	}
+	got := buf.rows(t)
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+func TestRollup_ObserveAuctionAt(t *testing.T) {
+	var buf syncBuffer
+	// Delivery happens well after the auctions completed
+	clk := clock.NewManual(time.Date(2024, 5, 1, 12, 5, 0, 0, time.UTC))
+	r := NewRollup(&buf, WithRollupClock(clk))
+
+	req := &openrtb.BidRequest{ID: "req-1", Imp: []openrtb.Imp{{ID: "imp-1", BidFloor: 2}}}
+	results := []dispatcher.Result{{
+		DSPName: "dsp1",
+		Latency: 12 * time.Millisecond,
+		Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{
+			{ID: "bid-1", ImpID: "imp-1", Price: 2.5},
+			{ID: "bid-2", ImpID: "imp-1", Price: 1}, // below the floor
+		}}}},
+	}}
+	outcome := auction.NewFirstPrice().Run(req.ID, 2, results)
+
+	r.ObserveAuctionAt(time.Date(2024, 5, 1, 12, 0, 59, 0, time.UTC), req, results, outcome)
+	r.ObserveAuctionAt(time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC), req, results, outcome)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := [][]string{
+		rollupHeader,
+		{"2024-05-01T12:00:00Z", "dsp1", "1", "2", "1", "2.5000", "12.000"},
+		{"2024-05-01T12:01:00Z", "dsp1", "1", "2", "1", "2.5000", "12.000"},
+	}
+	got := buf.rows(t)
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+func TestRollup_WritesMinuteWhenItEnds(t *testing.T) {
+	var buf syncBuffer
+	clk := clock.NewManual(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	r := NewRollup(&buf, WithRollupClock(clk), WithRollupInterval(10*time.Second))
+	defer r.Close()
+
+	r.ObserveAuction(testAuction())
+	for clk.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clk.Advance(10 * time.Second)
+
+	// The rows are written without waiting for another auction
+	deadline := time.Now().Add(5 * time.Second)
+	for len(buf.rows(t)) != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("rows = %q, want the first interval's two", buf.rows(t))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := buf.rows(t)[1][0]; got != "2024-05-01T12:00:00Z" {
+		t.Errorf("minute = %q, want the interval's start", got)
+	}
+}
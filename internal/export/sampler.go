@@ -0,0 +1,136 @@
+package export
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// defaultPoolSize is the number of auctions a Sampler holds by default.
+const defaultPoolSize = 1000
+
+// SampledAuction is a complete auction kept for review: the request sent,
+// every DSP's response, and the outcome.
+type SampledAuction struct {
+	AuctionRecord
+}
+
+// NewSampledAuction builds a sampled auction completed at t from its
+// inputs and outcome, keeping every DSP's response.
+func NewSampledAuction(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) SampledAuction {
+	rec := NewAuctionRecord(t, req, results, outcome)
+	rec.attachResponses(req, results)
+	return SampledAuction{AuctionRecord: rec}
+}
+
+// Sampler keeps a uniform random sample of the current run's auctions for
+// spot-checking, in a fixed-size pool filled by reservoir sampling. Each
+// auction is handed out by Sample at most once, so repeated calls during
+// a run return disjoint sets; its pool slot is then refilled as later
+// auctions are sampled. It implements engine.Observer and
+// engine.RunListener, emptying the pool when a run starts. Safe for
+// concurrent use.
+type Sampler struct {
+	clock clock.Clock
+
+	mu   sync.Mutex
+	pool []*SampledAuction // nil slots are empty or handed out
+	seen int               // auctions observed this run
+}
+
+// SamplerOption configures a Sampler.
+type SamplerOption func(*Sampler)
+
+// WithPoolSize sets how many auctions the sampler holds.
+func WithPoolSize(n int) SamplerOption {
+	return func(s *Sampler) {
+		if n > 0 {
+			s.pool = make([]*SampledAuction, n)
+		}
+	}
+}
+
+// WithSamplerClock stamps auctions observed without a completion time by
+// c instead of the wall clock.
+func WithSamplerClock(c clock.Clock) SamplerOption {
+	return func(s *Sampler) {
+		s.clock = c
+	}
+}
+
+// NewSampler creates an empty sampler.
+func NewSampler(opts ...SamplerOption) *Sampler {
+	s := &Sampler{pool: make([]*SampledAuction, defaultPoolSize), clock: clock.Real}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ObserveAuction offers an auction completed now, by the sampler's
+// clock, to the pool.
+func (s *Sampler) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	s.ObserveAuctionAt(s.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt offers an auction completed at t to the pool. The
+// record is built only if the auction is kept.
+func (s *Sampler) ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen++
+	slot := s.seen - 1
+	if slot >= len(s.pool) {
+		slot = randutil.IntN(s.seen)
+		if slot >= len(s.pool) {
+			return
+		}
+	}
+
+	sa := NewSampledAuction(t, req, results, outcome)
+	s.pool[slot] = &sa
+}
+
+// Sample returns up to n auctions picked at random from the pool, none of
+// which were returned by an earlier call this run. It returns fewer when
+// the pool holds fewer unreturned auctions.
+func (s *Sampler) Sample(n int) []SampledAuction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var held []int
+	for i, sa := range s.pool {
+		if sa != nil {
+			held = append(held, i)
+		}
+	}
+
+	n = min(n, len(held))
+	out := make([]SampledAuction, n)
+	for i := range n {
+		// Partial Fisher-Yates shuffle of the held slots
+		j := i + randutil.IntN(len(held)-i)
+		held[i], held[j] = held[j], held[i]
+		out[i] = *s.pool[held[i]]
+		s.pool[held[i]] = nil
+	}
+	return out
+}
+
+// RunStarted empties the pool so samples come from the new run only.
+func (s *Sampler) RunStarted() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.pool)
+	s.seen = 0
+}
+
+// RunStopped keeps the pool, so a finished run can still be sampled.
+func (s *Sampler) RunStopped() {}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// observeN offers s n auctions with request IDs numbered from 0.
+func observeN(s *Sampler, n int) {
+	req, results, outcome := testAuction()
+	for i := range n {
+		r := *req
+		r.ID = strconv.Itoa(i)
+		outcome.RequestID = r.ID
+		s.ObserveAuction(&r, results, outcome)
+	}
+}
+
+func TestSampler_Disjoint(t *testing.T) {
+	s := NewSampler(WithPoolSize(100))
+	observeN(s, 1000)
+
+	seen := make(map[string]bool)
+	for range 3 {
+		sample := s.Sample(30)
+		if len(sample) != 30 {
+			t.Fatalf("len(Sample(30)) = %d, want 30", len(sample))
+		}
+		for _, sa := range sample {
+			if seen[sa.RequestID] {
+				t.Fatalf("auction %s returned twice", sa.RequestID)
+			}
+			seen[sa.RequestID] = true
+			if sa.Request == nil || sa.Request.ID != sa.RequestID {
+				t.Fatalf("Request = %+v, want ID %s", sa.Request, sa.RequestID)
+			}
+			if sa.DSPs[0].Response == nil {
+				t.Fatal("DSP response not attached")
+			}
+		}
+	}
+
+	// Only 10 unreturned auctions are left in the pool
+	if sample := s.Sample(30); len(sample) != 10 {
+		t.Errorf("len(Sample(30)) = %d with 10 left, want 10", len(sample))
+	}
+	if sample := s.Sample(30); len(sample) != 0 {
+		t.Errorf("len(Sample(30)) = %d with the pool used up, want 0", len(sample))
+	}
+}
+
+func TestSampler_Uniform(t *testing.T) {
+	s := NewSampler(WithPoolSize(200))
+	observeN(s, 10000)
+
+	sum := 0
+	sample := s.Sample(200)
+	for _, sa := range sample {
+		n, _ := strconv.Atoi(sa.RequestID)
+		sum += n
+	}
+	if mean := sum / len(sample); mean < 4000 || mean > 6000 {
+		t.Errorf("mean request number = %d, want ~5000 for a uniform sample", mean)
+	}
+}
+
+func TestSampler_RunStarted(t *testing.T) {
+	s := NewSampler(WithPoolSize(10))
+	observeN(s, 5)
+	s.RunStopped()
+	if sample := s.Sample(10); len(sample) != 5 {
+		t.Errorf("len(Sample(10)) after the run = %d, want 5", len(sample))
+	}
+
+	observeN(s, 5)
+	s.RunStarted()
+	if sample := s.Sample(10); len(sample) != 0 {
+		t.Errorf("len(Sample(10)) after RunStarted = %d, want 0", len(sample))
+	}
+	s.ObserveAuction(&openrtb.BidRequest{ID: "next"}, nil, auction.Outcome{RequestID: "next"})
+	if sample := s.Sample(10); len(sample) != 1 || sample[0].RequestID != "next" {
+		t.Errorf("Sample(10) = %+v, want the new run's auction", sample)
+	}
+}
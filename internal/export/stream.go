@@ -0,0 +1,160 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Stream writes one NDJSON line per auction to a writer such as stdout.
+// Records are sampled at a configurable rate and buffered in a bounded
+// queue; when the consumer cannot keep up, records are dropped rather
+// than slowing the auction loop, unless the stream is lossless.
+type Stream struct {
+	w          *bufio.Writer
+	queue      chan AuctionRecord
+	sampleRate float64
+	queueSize  int
+	responses  bool
+	lossless   bool
+	clock      clock.Clock
+	dropped    atomic.Uint64
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// StreamOption configures a Stream.
+type StreamOption func(*Stream)
+
+// WithSampleRate sets the fraction of auctions written (0 < rate <= 1).
+func WithSampleRate(rate float64) StreamOption {
+	return func(s *Stream) {
+		s.sampleRate = rate
+	}
+}
+
+// WithStreamClock stamps auctions observed without a completion time by
+// c instead of the wall clock.
+func WithStreamClock(c clock.Clock) StreamOption {
+	return func(s *Stream) {
+		s.clock = c
+	}
+}
+
+// WithStreamQueueSize sets how many records may be pending before drops.
+func WithStreamQueueSize(size int) StreamOption {
+	return func(s *Stream) {
+		s.queueSize = size
+	}
+}
+
+// WithResponses includes the bid request and each DSP's full bid response
+// in the records, so the stream can be replayed offline. Records grow accordingly.
+func WithResponses() StreamOption {
+	return func(s *Stream) {
+		s.responses = true
+	}
+}
+
+// WithLossless makes ObserveAuction wait for room in the queue instead
+// of dropping records, for streams that must hold every sampled auction.
+func WithLossless() StreamOption {
+	return func(s *Stream) {
+		s.lossless = true
+	}
+}
+
+// NewStream creates a stream writing to w and starts its writer goroutine.
+func NewStream(w io.Writer, opts ...StreamOption) *Stream {
+	s := &Stream{
+		w:          bufio.NewWriter(w),
+		sampleRate: 1.0,
+		queueSize:  1024,
+		clock:      clock.Real,
+		done:       make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.queue = make(chan AuctionRecord, s.queueSize)
+	go s.run()
+
+	return s
+}
+
+// ObserveAuction samples and queues a record of an auction completed
+// now, by the stream's clock. It blocks only if the stream is lossless.
+func (s *Stream) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	s.ObserveAuctionAt(s.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt samples and queues a record of an auction completed
+// at t. It blocks only if the stream is lossless.
+func (s *Stream) ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	if s.sampleRate < 1 && !randutil.Chance(s.sampleRate) {
+		return
+	}
+
+	rec := NewAuctionRecord(t, req, results, outcome)
+	if s.responses {
+		rec.attachResponses(req, results)
+	}
+
+	if s.lossless {
+		s.queue <- rec
+		return
+	}
+	select {
+	case s.queue <- rec:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// run encodes queued records, flushing whenever the queue drains so
+// downstream pipes see output promptly.
+func (s *Stream) run() {
+	defer close(s.done)
+
+	enc := json.NewEncoder(s.w)
+	for rec := range s.queue {
+		if err := enc.Encode(rec); err != nil {
+			log.Printf("stream: write failed: %v", err)
+			continue
+		}
+		if len(s.queue) == 0 {
+			if err := s.w.Flush(); err != nil {
+				log.Printf("stream: flush failed: %v", err)
+			}
+		}
+	}
+	if err := s.w.Flush(); err != nil {
+		log.Printf("stream: flush failed: %v", err)
+	}
+}
+
+// Dropped returns the number of records dropped due to backpressure.
+func (s *Stream) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close flushes pending records and stops the writer.
+// ObserveAuction must not be called after Close.
+func (s *Stream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.queue)
+		<-s.done
+	})
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+)
+
+func TestStream_WritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStream(&buf)
+
+	req, results, outcome := testAuction()
+	for i := 0; i < 3; i++ {
+		s.ObserveAuction(req, results, outcome)
+	}
+	s.Close()
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var rec AuctionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("line %d: invalid JSON: %v", lines, err)
+		}
+		if rec.RequestID != "req-1" {
+			t.Errorf("line %d: RequestID = %q", lines, rec.RequestID)
+		}
+		lines++
+	}
+
+	if lines != 3 {
+		t.Errorf("wrote %d lines, want 3", lines)
+	}
+}
+
+func TestStream_ScaledClock(t *testing.T) {
+	var buf bytes.Buffer
+	// An hour of simulated time passes every wall-clock second
+	s := NewStream(&buf, WithStreamClock(clock.NewScaled(3600)))
+
+	time.Sleep(20 * time.Millisecond)
+	s.ObserveAuction(testAuction())
+	completed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	req, results, outcome := testAuction()
+	s.ObserveAuctionAt(completed, req, results, outcome)
+	wall := time.Now()
+	s.Close()
+
+	dec := json.NewDecoder(&buf)
+	var now, at AuctionRecord
+	if err := dec.Decode(&now); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&at); err != nil {
+		t.Fatal(err)
+	}
+	if ahead := now.Timestamp.Sub(wall); ahead < time.Minute {
+		t.Errorf("Timestamp = %v, only %v ahead of the wall clock; want simulated time", now.Timestamp, ahead)
+	}
+	if !at.Timestamp.Equal(completed) {
+		t.Errorf("Timestamp = %v, want the completion time %v", at.Timestamp, completed)
+	}
+}
+
+func TestStream_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStream(&buf, WithSampleRate(0.1), WithStreamQueueSize(2000))
+
+	req, results, outcome := testAuction()
+	for i := 0; i < 1000; i++ {
+		s.ObserveAuction(req, results, outcome)
+	}
+	s.Close()
+
+	lines := bytes.Count(buf.Bytes(), []byte("\n"))
+	if lines < 50 || lines > 150 {
+		t.Errorf("wrote %d of 1000 lines at 10%% sampling, want ~100", lines)
+	}
+}
+
+// blockingWriter blocks every write until released.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestStream_DropsUnderBackpressure(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	// Tiny bufio buffer is bypassed by large records, so the writer blocks
+	s := NewStream(io.Writer(w), WithStreamQueueSize(1))
+
+	req, results, outcome := testAuction()
+	for i := 0; i < 5000; i++ {
+		s.ObserveAuction(req, results, outcome)
+	}
+	close(w.release)
+	s.Close()
+
+	if s.Dropped() == 0 {
+		t.Error("Dropped() = 0, want > 0 when writer is blocked")
+	}
+}
+
+func TestStream_LosslessWaitsForWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &blockingWriter{release: make(chan struct{})}
+	s := NewStream(io.MultiWriter(w, &buf), WithStreamQueueSize(1), WithLossless())
+
+	req, results, outcome := testAuction()
+	done := make(chan struct{})
+	go func() {
+		for range 50 {
+			s.ObserveAuction(req, results, outcome)
+		}
+		close(done)
+	}()
+	close(w.release)
+	<-done
+	s.Close()
+
+	if s.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", s.Dropped())
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("\n")); n != 50 {
+		t.Errorf("wrote %d records, want 50", n)
+	}
+}
@@ -0,0 +1,241 @@
+// Package fuzz mutates encoded bid requests to test how DSPs cope with
+// malformed input. Each mutated request carries a single mutation of one
+// class, so DSP errors and status codes can be attributed to it.
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// Class identifies a kind of mutation.
+type Class string
+
+const (
+	ClassNone    Class = ""
+	MissingField Class = "missing_field" // a required or commonly relied on field is removed
+	WrongType    Class = "wrong_type"    // a field's value is replaced with one of another JSON type
+	HugeString   Class = "huge_string"   // a string field is replaced with HugeStringLen bytes
+	InvalidEnum  Class = "invalid_enum"  // an enumerated field is set outside the specification's values
+)
+
+// Classes lists every mutation class.
+var Classes = []Class{MissingField, WrongType, HugeString, InvalidEnum}
+
+// HugeStringLen is the length of the strings HugeString mutations send.
+const HugeStringLen = 64 << 10
+
+// invalidEnumValue is defined by none of the enumerations mutated.
+const invalidEnumValue = 99
+
+// Fields each class mutates, as dotted paths with array indexes; a
+// mutation picks one the request has. InvalidEnum sets "at" if the
+// request has none of its fields.
+var (
+	missingFields = []string{
+		"id", "imp", "imp.0.id", "imp.0.banner", "imp.0.video.mimes", "imp.0.native.request",
+		"app", "site", "device", "device.ua", "tmax",
+	}
+	wrongTypeFields = []string{
+		"id", "imp", "imp.0", "imp.0.id", "imp.0.bidfloor", "imp.0.banner.w", "imp.0.video.mimes",
+		"app.id", "site.id", "device.devicetype", "device.geo.lat", "tmax", "at",
+	}
+	stringFields = []string{
+		"id", "imp.0.id", "imp.0.tagid", "app.bundle", "app.name", "site.domain", "site.page",
+		"device.ua", "device.ip", "user.id",
+	}
+	enumFields = []string{
+		"at", "imp.0.secure", "imp.0.instl", "imp.0.banner.pos", "imp.0.video.protocols",
+		"imp.0.video.plcmt", "device.devicetype", "device.connectiontype", "device.geo.type",
+	}
+)
+
+// Valid reports whether c is a mutation class.
+func Valid(c Class) bool {
+	for _, known := range Classes {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Fuzzer picks the requests to mutate and how.
+type Fuzzer struct {
+	rate    float64
+	classes []Class
+}
+
+// New returns a fuzzer mutating a rate share of requests, with classes
+// picked evenly from classes, or from every class when none are given.
+func New(rate float64, classes ...Class) *Fuzzer {
+	if len(classes) == 0 {
+		classes = Classes
+	}
+	return &Fuzzer{rate: rate, classes: classes}
+}
+
+// Pick returns the class of mutation to apply to the next request, or
+// ClassNone to send it as is. A nil fuzzer mutates nothing.
+func (f *Fuzzer) Pick() Class {
+	if f == nil || !randutil.Chance(f.rate) {
+		return ClassNone
+	}
+	return f.classes[randutil.IntN(len(f.classes))]
+}
+
+// Mutate returns a copy of body, a JSON bid request, with one mutation of
+// class c applied to a field picked at random. Bodies that are not JSON
+// objects are returned as is.
+func Mutate(body []byte, c Class) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return body
+	}
+
+	switch c {
+	case MissingField:
+		if path, ok := pick(doc, missingFields); ok {
+			remove(doc, path)
+		}
+	case WrongType:
+		if path, ok := pick(doc, wrongTypeFields); ok {
+			set(doc, path, otherType(lookup(doc, path)))
+		}
+	case HugeString:
+		if path, ok := pick(doc, stringFields); ok {
+			set(doc, path, strings.Repeat("A", HugeStringLen))
+		}
+	case InvalidEnum:
+		path, ok := pick(doc, enumFields)
+		if !ok {
+			path = "at"
+		}
+		var v any = invalidEnumValue
+		if _, isList := lookup(doc, path).([]any); isList {
+			v = []any{invalidEnumValue}
+		}
+		set(doc, path, v)
+	default:
+		return body
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// pick returns one of paths present in doc, at random.
+func pick(doc map[string]any, paths []string) (string, bool) {
+	var present []string
+	for _, p := range paths {
+		if lookup(doc, p) != nil {
+			present = append(present, p)
+		}
+	}
+	if len(present) == 0 {
+		return "", false
+	}
+	return present[randutil.IntN(len(present))], true
+}
+
+// otherType returns a value of a different JSON type standing in for v.
+func otherType(v any) any {
+	switch v := v.(type) {
+	case string:
+		return 12345
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		return map[string]any{}
+	default:
+		return "invalid"
+	}
+}
+
+// lookup returns the value at path in doc, or nil.
+func lookup(doc map[string]any, path string) any {
+	parent, key, ok := resolve(doc, path)
+	if !ok {
+		return nil
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		return p[key]
+	case []any:
+		i, _ := strconv.Atoi(key)
+		return p[i]
+	}
+	return nil
+}
+
+// set replaces the value at path in doc, adding it to its parent object
+// if absent.
+func set(doc map[string]any, path string, v any) {
+	parent, key, ok := resolve(doc, path)
+	if !ok {
+		return
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		p[key] = v
+	case []any:
+		i, _ := strconv.Atoi(key)
+		p[i] = v
+	}
+}
+
+// remove deletes the field at path from its parent object.
+func remove(doc map[string]any, path string) {
+	if parent, key, ok := resolve(doc, path); ok {
+		if p, ok := parent.(map[string]any); ok {
+			delete(p, key)
+		}
+	}
+}
+
+// resolve walks path to the container holding its last element, which
+// is returned as key. Array elements must exist.
+func resolve(doc map[string]any, path string) (parent any, key string, ok bool) {
+	parts := strings.Split(path, ".")
+	var cur any = doc
+	for _, part := range parts[:len(parts)-1] {
+		if cur = child(cur, part); cur == nil {
+			return nil, "", false
+		}
+	}
+	key = parts[len(parts)-1]
+	if list, isList := cur.([]any); isList {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(list) {
+			return nil, "", false
+		}
+	}
+	return cur, key, true
+}
+
+// child returns the member of an object or element of an array named by
+// part, or nil.
+func child(v any, part string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return v[part]
+	case []any:
+		i, err := strconv.Atoi(part)
+		if err != nil || i < 0 || i >= len(v) {
+			return nil
+		}
+		return v[i]
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const request = `{"id":"req-1","imp":[{"id":"1","banner":{"w":320,"h":50,"pos":1},"bidfloor":0.5,"secure":1}],` +
+	`"app":{"id":"app-1","bundle":"com.example"},"device":{"ua":"Mozilla","devicetype":4},"at":1,"tmax":100}`
+
+// decode parses a mutated body, failing the test if it is not JSON.
+func decode(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("mutated body is not JSON: %v\n%s", err, body)
+	}
+	return doc
+}
+
+func TestMutate(t *testing.T) {
+	canonical := string(mustMarshal(t, decode(t, []byte(request))))
+	for _, c := range Classes {
+		t.Run(string(c), func(t *testing.T) {
+			// Each run mutates a field picked at random
+			for range 50 {
+				out := Mutate([]byte(request), c)
+				decode(t, out)
+				if string(out) == canonical {
+					t.Fatalf("Mutate() changed nothing: %s", out)
+				}
+				if c == HugeString && len(out) < HugeStringLen {
+					t.Fatalf("len(body) = %d, want a %d byte string", len(out), HugeStringLen)
+				}
+			}
+		})
+	}
+}
+
+func TestMutate_InvalidEnum(t *testing.T) {
+	doc := decode(t, Mutate([]byte(`{"id":"r","imp":[{"id":"1","video":{"protocols":[2,3]}}]}`), InvalidEnum))
+	protocols := doc["imp"].([]any)[0].(map[string]any)["video"].(map[string]any)["protocols"]
+	if list, ok := protocols.([]any); !ok || len(list) != 1 || list[0] != float64(invalidEnumValue) {
+		t.Errorf("protocols = %v, want [%d]", protocols, invalidEnumValue)
+	}
+
+	// Without any enumerated field, at is added
+	doc = decode(t, Mutate([]byte(`{"id":"r","imp":[{"id":"1"}]}`), InvalidEnum))
+	if doc["at"] != float64(invalidEnumValue) {
+		t.Errorf("at = %v, want %d", doc["at"], invalidEnumValue)
+	}
+}
+
+func TestMutate_WrongTypeChangesType(t *testing.T) {
+	doc := decode(t, Mutate([]byte(`{"id":"req-1"}`), WrongType))
+	if _, ok := doc["id"].(float64); !ok {
+		t.Errorf("id = %#v, want a number", doc["id"])
+	}
+	doc = decode(t, Mutate([]byte(`{"tmax":100}`), WrongType))
+	if doc["tmax"] != "100" {
+		t.Errorf("tmax = %#v, want \"100\"", doc["tmax"])
+	}
+}
+
+func TestMutate_NotJSON(t *testing.T) {
+	body := []byte{0x0a, 0x05}
+	if out := Mutate(body, MissingField); string(out) != string(body) {
+		t.Errorf("Mutate() = %q, want the body unchanged", out)
+	}
+}
+
+func TestFuzzer_Pick(t *testing.T) {
+	var none *Fuzzer
+	if c := none.Pick(); c != ClassNone {
+		t.Errorf("nil Fuzzer Pick() = %q, want none", c)
+	}
+	if c := New(0).Pick(); c != ClassNone {
+		t.Errorf("rate 0 Pick() = %q, want none", c)
+	}
+
+	f := New(1, HugeString)
+	for range 10 {
+		if c := f.Pick(); c != HugeString {
+			t.Fatalf("Pick() = %q, want huge_string", c)
+		}
+	}
+	seen := make(map[Class]bool)
+	all := New(1)
+	for range 200 {
+		seen[all.Pick()] = true
+	}
+	for _, c := range Classes {
+		if !seen[c] {
+			t.Errorf("Pick() never returned %s", c)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	if !Valid(WrongType) || Valid("typo") || Valid(ClassNone) {
+		t.Error("Valid() accepts the wrong classes")
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
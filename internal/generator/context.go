@@ -0,0 +1,64 @@
+package generator
+
+import "github.com/cass/rtb-simulator/pkg/openrtb"
+
+// Context holds the per-request parameters a scenario generates a bid
+// request from. Zero fields leave the choice to the scenario, so features
+// such as audience segments, user pools, and request templates can each
+// set the fields they own without sharing global state.
+type Context struct {
+	// RequestID is the bid request ID. The generator assigns one if empty.
+	RequestID string
+
+	// Segment is the audience segment the request targets, sent to DSPs
+	// in user.data. Empty sends none.
+	Segment string
+
+	// UserID is the exchange user ID (user.id). Empty lets the scenario
+	// draw a random user.
+	UserID string
+
+	// Overrides are applied to the request after the scenario builds it.
+	Overrides Overrides
+}
+
+// Overrides replace request fields regardless of the scenario. Zero
+// values keep the scenario's choice.
+type Overrides struct {
+	Tmax     int     // request tmax in milliseconds
+	At       int     // auction type
+	BidFloor float64 // floor of every impression
+	Exp      int     // imp.exp of every impression, in seconds
+}
+
+// apply sets the overridden fields on req.
+func (o Overrides) apply(req *openrtb.BidRequest) {
+	if o.Tmax > 0 {
+		req.Tmax = o.Tmax
+	}
+	if o.At > 0 {
+		req.At = o.At
+	}
+	if o.BidFloor > 0 {
+		for i := range req.Imp {
+			req.Imp[i].BidFloor = o.BidFloor
+		}
+	}
+	if o.Exp > 0 {
+		for i := range req.Imp {
+			req.Imp[i].Exp = o.Exp
+		}
+	}
+}
+
+// ContextFunc fills in a request's context before its scenario runs.
+type ContextFunc func(c *Context)
+
+// SegmentData returns the user.data entry that carries segment, or nil if
+// segment is empty.
+func SegmentData(segment string) []openrtb.Data {
+	if segment == "" {
+		return nil
+	}
+	return []openrtb.Data{{Name: "rtb-simulator", Segment: []openrtb.Segment{{ID: segment}}}}
+}
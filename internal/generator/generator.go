@@ -3,7 +3,13 @@
 package generator
 
 import (
+	"fmt"
+	"log"
+	"math"
 	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
 
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
@@ -13,7 +19,14 @@ type Generator struct {
 	scenario    Scenario
 	counter     uint64
 	timeout     int
-	auctionType int
+	impExp      int          // imp.exp in seconds; 0 leaves it unset
+	auctionType atomic.Int64 // see SetAuctionType
+	contexts    []ContextFunc
+
+	// validate checks each request's JSON encoding when set; fail is
+	// called with the violation and must not return.
+	validate func([]byte) error
+	fail     func(format string, args ...any)
 }
 
 // Option configures the generator.
@@ -26,21 +39,58 @@ func WithTimeout(ms int) Option {
 	}
 }
 
+// WithImpExpiry tells bidders, in every impression's exp, that up to d
+// may pass between the auction and the impression. d is rounded up to
+// whole seconds.
+func WithImpExpiry(d time.Duration) Option {
+	return func(g *Generator) {
+		g.impExp = int(math.Ceil(d.Seconds()))
+	}
+}
+
 // WithAuctionType sets the auction type for generated requests.
 func WithAuctionType(at int) Option {
 	return func(g *Generator) {
-		g.auctionType = at
+		g.auctionType.Store(int64(at))
+	}
+}
+
+// SetAuctionType changes the auction type of requests generated from now
+// on, such as after a configuration reload. Safe to call while requests
+// are being generated.
+func (g *Generator) SetAuctionType(at int) {
+	g.auctionType.Store(int64(at))
+}
+
+// WithContext registers f to fill in the context of every request
+// generated by Generate, after the generator's own defaults. May be given
+// multiple times; functions run in order.
+func WithContext(f ContextFunc) Option {
+	return func(g *Generator) {
+		g.contexts = append(g.contexts, f)
+	}
+}
+
+// WithValidation checks every generated request with validate, such as
+// an OpenRTB schema, and exits the process on the first violation so a
+// scenario bug cannot silently produce non-compliant traffic. Meant for
+// debug runs: it encodes each request an extra time.
+func WithValidation(validate func(data []byte) error) Option {
+	return func(g *Generator) {
+		g.validate = validate
 	}
 }
 
 // New creates a new generator with the given scenario and options.
 func New(scenario Scenario, opts ...Option) *Generator {
 	g := &Generator{
-		scenario:    scenario,
-		timeout:     100, // default 100ms
-		auctionType: openrtb.AuctionFirstPrice,
+		scenario: scenario,
+		timeout:  100, // default 100ms
+		fail:     log.Fatalf,
 	}
 
+	g.auctionType.Store(openrtb.AuctionFirstPrice)
+
 	for _, opt := range opts {
 		opt(g)
 	}
@@ -50,18 +100,68 @@ func New(scenario Scenario, opts ...Option) *Generator {
 
 // Generate creates a new bid request.
 func (g *Generator) Generate() *openrtb.BidRequest {
-	id := g.nextID()
-	req := g.scenario.Generate(id)
+	return g.GenerateContext(Context{})
+}
 
-	// Apply generator-level overrides
-	if g.timeout > 0 {
-		req.Tmax = g.timeout
+// GenerateScenario creates a new bid request like Generate. If the
+// generator's scenario is a Mix, it also returns the name of the mixed
+// scenario that built the request; otherwise the name is empty.
+func (g *Generator) GenerateScenario() (*openrtb.BidRequest, string) {
+	return g.generate(Context{})
+}
+
+// GenerateContext creates a new bid request from the parameters in c,
+// assigning a request ID if c has none. The generator's timeout, auction
+// type, and impression expiry fill overrides c leaves unset, then context
+// functions registered WithContext run.
+func (g *Generator) GenerateContext(c Context) *openrtb.BidRequest {
+	req, _ := g.generate(c)
+	return req
+}
+
+// generate implements GenerateContext, returning the name of the mixed
+// scenario picked, if any.
+func (g *Generator) generate(c Context) (*openrtb.BidRequest, string) {
+	if c.RequestID == "" {
+		c.RequestID = g.nextID()
+	}
+	if c.Overrides.Tmax == 0 {
+		c.Overrides.Tmax = g.timeout
+	}
+	if c.Overrides.At == 0 {
+		c.Overrides.At = int(g.auctionType.Load())
+	}
+	if c.Overrides.Exp == 0 {
+		c.Overrides.Exp = g.impExp
 	}
-	if g.auctionType > 0 {
-		req.At = g.auctionType
+	for _, f := range g.contexts {
+		f(&c)
 	}
 
-	return req
+	scenario, mixed := g.scenario, ""
+	if m, ok := scenario.(*Mix); ok {
+		scenario = m.pick()
+		mixed = scenario.Name()
+	}
+	req := scenario.Generate(c)
+	c.Overrides.apply(req)
+
+	if g.validate != nil {
+		if err := g.check(req); err != nil {
+			g.fail("generator: scenario %s produced an invalid request %s: %v", scenario.Name(), req.ID, err)
+		}
+	}
+
+	return req, mixed
+}
+
+// check encodes req as it is sent to DSPs and runs the validator on it.
+func (g *Generator) check(req *openrtb.BidRequest) error {
+	data, err := sonic.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	return g.validate(data)
 }
 
 // ScenarioName returns the name of the current scenario.
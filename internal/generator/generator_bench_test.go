@@ -1,15 +1,19 @@
-package generator
+package generator_test
 
 import (
 	"testing"
 
+	"github.com/cass/rtb-simulator/internal/generator"
 	"github.com/cass/rtb-simulator/internal/generator/scenarios"
 )
 
+// BenchmarkNextID lives in generator_test.go, in package generator, as
+// nextID is unexported and scenarios cannot be imported from there.
+
 // BenchmarkGenerator_Generate benchmarks the full generation pipeline.
 func BenchmarkGenerator_Generate(b *testing.B) {
 	scenario := scenarios.NewMobileApp()
-	gen := New(scenario)
+	gen := generator.New(scenario)
 	b.ResetTimer()
 	b.ReportAllocs()
 
@@ -21,7 +25,7 @@ func BenchmarkGenerator_Generate(b *testing.B) {
 // BenchmarkGenerator_Generate_Parallel benchmarks concurrent generation.
 func BenchmarkGenerator_Generate_Parallel(b *testing.B) {
 	scenario := scenarios.NewMobileApp()
-	gen := New(scenario)
+	gen := generator.New(scenario)
 	b.ResetTimer()
 	b.ReportAllocs()
 
@@ -31,15 +35,3 @@ func BenchmarkGenerator_Generate_Parallel(b *testing.B) {
 		}
 	})
 }
-
-// BenchmarkNextID benchmarks request ID generation.
-func BenchmarkNextID(b *testing.B) {
-	scenario := scenarios.NewMobileApp()
-	gen := New(scenario)
-	b.ResetTimer()
-	b.ReportAllocs()
-
-	for i := 0; i < b.N; i++ {
-		_ = gen.nextID()
-	}
-}
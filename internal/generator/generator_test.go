@@ -1,7 +1,11 @@
 package generator
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
@@ -15,9 +19,9 @@ func (m *mockScenario) Name() string {
 	return m.name
 }
 
-func (m *mockScenario) Generate(requestID string) *openrtb.BidRequest {
+func (m *mockScenario) Generate(c Context) *openrtb.BidRequest {
 	return &openrtb.BidRequest{
-		ID: requestID,
+		ID: c.RequestID,
 		Imp: []openrtb.Imp{
 			{
 				ID: "imp-1",
@@ -118,6 +122,70 @@ func TestGenerator_WithAuctionType(t *testing.T) {
 	if req.At != openrtb.AuctionSecondPrice {
 		t.Errorf("At = %d, want %d", req.At, openrtb.AuctionSecondPrice)
 	}
+
+	gen.SetAuctionType(openrtb.AuctionFirstPrice)
+	if req := gen.Generate(); req.At != openrtb.AuctionFirstPrice {
+		t.Errorf("At after SetAuctionType = %d, want %d", req.At, openrtb.AuctionFirstPrice)
+	}
+}
+
+func TestGenerator_WithImpExpiry(t *testing.T) {
+	gen := New(&mockScenario{name: "test-scenario"}, WithImpExpiry(1500*time.Millisecond))
+
+	if req := gen.Generate(); req.Imp[0].Exp != 2 {
+		t.Errorf("imp.exp = %d, want 2 (1.5s rounded up)", req.Imp[0].Exp)
+	}
+	if req := New(&mockScenario{name: "test-scenario"}).Generate(); req.Imp[0].Exp != 0 {
+		t.Errorf("imp.exp = %d without WithImpExpiry, want 0", req.Imp[0].Exp)
+	}
+}
+
+// recordingScenario remembers the context of the last request.
+type recordingScenario struct {
+	mockScenario
+	last Context
+}
+
+func (r *recordingScenario) Generate(c Context) *openrtb.BidRequest {
+	r.last = c
+	return r.mockScenario.Generate(c)
+}
+
+func TestGenerator_WithContext(t *testing.T) {
+	scenario := &recordingScenario{}
+	gen := New(scenario, WithTimeout(150),
+		WithContext(func(c *Context) { c.Segment = "sports" }),
+		WithContext(func(c *Context) {
+			c.UserID = "user-" + c.Segment
+			c.Overrides.BidFloor = 2.5
+		}),
+	)
+
+	req := gen.Generate()
+	if got := scenario.last; got.Segment != "sports" || got.UserID != "user-sports" || got.RequestID != req.ID {
+		t.Errorf("context = %+v, want segment, user, and request ID set", got)
+	}
+	if req.Tmax != 150 || req.Imp[0].BidFloor != 2.5 {
+		t.Errorf("tmax = %d, bidfloor = %v, want 150, 2.5", req.Tmax, req.Imp[0].BidFloor)
+	}
+}
+
+func TestGenerator_GenerateContext(t *testing.T) {
+	scenario := &recordingScenario{}
+	gen := New(scenario, WithTimeout(150))
+
+	req := gen.GenerateContext(Context{RequestID: "custom", Overrides: Overrides{Tmax: 80, At: openrtb.AuctionSecondPrice}})
+	if req.ID != "custom" {
+		t.Errorf("ID = %q, want custom", req.ID)
+	}
+	// Explicit overrides win over the generator's defaults
+	if req.Tmax != 80 || req.At != openrtb.AuctionSecondPrice {
+		t.Errorf("tmax = %d, at = %d, want 80, 2", req.Tmax, req.At)
+	}
+
+	if req := gen.GenerateContext(Context{}); req.ID != "req-00000001" {
+		t.Errorf("ID = %q, want a generated ID", req.ID)
+	}
 }
 
 func TestGenerator_ConcurrentSafety(t *testing.T) {
@@ -141,3 +209,43 @@ func TestGenerator_ConcurrentSafety(t *testing.T) {
 		<-done
 	}
 }
+
+func TestGenerator_WithValidation(t *testing.T) {
+	var checked []string
+	gen := New(&mockScenario{name: "test-scenario"}, WithValidation(func(data []byte) error {
+		checked = append(checked, string(data))
+		if len(checked) == 2 {
+			return errors.New("/imp: 0 items, want at least 1")
+		}
+		return nil
+	}))
+	var failures []string
+	gen.fail = func(format string, args ...any) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	gen.Generate()
+	if len(checked) != 1 || !strings.Contains(checked[0], `"tmax":100`) {
+		t.Fatalf("validated %q, want the request after overrides", checked)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("failures = %q, want none", failures)
+	}
+
+	gen.Generate()
+	want := "generator: scenario test-scenario produced an invalid request req-00000002: /imp: 0 items, want at least 1"
+	if len(failures) != 1 || failures[0] != want {
+		t.Errorf("failures = %q, want [%q]", failures, want)
+	}
+}
+
+// BenchmarkNextID benchmarks request ID generation.
+func BenchmarkNextID(b *testing.B) {
+	gen := New(&mockScenario{name: "bench"})
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_ = gen.nextID()
+	}
+}
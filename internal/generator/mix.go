@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Mix is a Scenario that builds each request with one of several
+// scenarios, chosen at random in proportion to their weights. A generator
+// using a Mix reports which scenario built each request; see
+// GenerateScenario.
+type Mix struct {
+	scenarios []Scenario
+	weights   *randutil.Weighted
+}
+
+// NewMix mixes scenarios with the given weights, which must be
+// non-negative with a positive sum.
+func NewMix(scenarios []Scenario, weights []float64) (*Mix, error) {
+	if len(scenarios) != len(weights) {
+		return nil, errors.New("mix: scenarios and weights differ in length")
+	}
+	w, err := randutil.NewWeighted(weights)
+	if err != nil {
+		return nil, err
+	}
+	return &Mix{scenarios: scenarios, weights: w}, nil
+}
+
+// Name joins the names of the mixed scenarios with "+".
+func (m *Mix) Name() string {
+	names := make([]string, len(m.scenarios))
+	for i, s := range m.scenarios {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Generate builds the request with a randomly picked scenario.
+func (m *Mix) Generate(c Context) *openrtb.BidRequest {
+	return m.pick().Generate(c)
+}
+
+func (m *Mix) pick() Scenario {
+	return m.scenarios[m.weights.Sample()]
+}
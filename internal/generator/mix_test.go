@@ -0,0 +1,45 @@
+package generator
+
+import "testing"
+
+func TestMix(t *testing.T) {
+	a, b := &mockScenario{name: "a"}, &mockScenario{name: "b"}
+	if _, err := NewMix([]Scenario{a, b}, []float64{1}); err == nil {
+		t.Error("NewMix with mismatched weights succeeded, want error")
+	}
+	if _, err := NewMix([]Scenario{a, b}, []float64{0, 0}); err == nil {
+		t.Error("NewMix with zero weights succeeded, want error")
+	}
+
+	mix, err := NewMix([]Scenario{a, b}, []float64{70, 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mix.Name() != "a+b" {
+		t.Errorf("Name() = %q, want a+b", mix.Name())
+	}
+
+	gen := New(mix)
+	counts := map[string]int{}
+	const n = 10000
+	for range n {
+		req, scenario := gen.GenerateScenario()
+		if req == nil || req.ID == "" {
+			t.Fatal("GenerateScenario returned no request")
+		}
+		counts[scenario]++
+	}
+	if len(counts) != 2 {
+		t.Fatalf("scenarios = %v, want a and b", counts)
+	}
+	if share := float64(counts["a"]) / n; share < 0.65 || share > 0.75 {
+		t.Errorf("share of a = %.3f, want about 0.7", share)
+	}
+}
+
+func TestGenerator_GenerateScenario_Single(t *testing.T) {
+	gen := New(&mockScenario{name: "only"})
+	if _, scenario := gen.GenerateScenario(); scenario != "" {
+		t.Errorf("scenario = %q, want empty without a mix", scenario)
+	}
+}
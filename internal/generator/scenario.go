@@ -7,7 +7,7 @@ type Scenario interface {
 	// Name returns the scenario identifier.
 	Name() string
 
-	// Generate creates a new bid request with scenario-specific data.
-	// The requestID is provided by the generator for tracking.
-	Generate(requestID string) *openrtb.BidRequest
+	// Generate creates a new bid request with scenario-specific data for
+	// the parameters in c. c.RequestID is always set.
+	Generate(c Context) *openrtb.BidRequest
 }
@@ -0,0 +1,130 @@
+package scenarios
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// Embedded data pools, one tab-separated record per line. Each pool is
+// parsed on first use, so scenarios that never draw from it pay nothing,
+// and parsed strings slice into the embedded text instead of copying it.
+var (
+	//go:embed data/apps.tsv
+	appsTSV string
+
+	//go:embed data/phones.tsv
+	phonesTSV string
+
+	//go:embed data/cities.tsv
+	citiesTSV string
+)
+
+// maxFields bounds the columns of an embedded record.
+const maxFields = 8
+
+// forEachRecord calls fn with the fields of every record in data, skipping
+// blank lines and # comments. The fields slice is reused between calls.
+// Assets are compiled in, so a malformed record is a build defect and
+// panics.
+func forEachRecord(name, data string, fields int, fn func([]string)) {
+	var buf [maxFields]string
+	for n := 1; data != ""; n++ {
+		var line string
+		line, data, _ = strings.Cut(data, "\n")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		rec := buf[:0]
+		for len(rec) < fields-1 {
+			field, rest, ok := strings.Cut(line, "\t")
+			if !ok {
+				break
+			}
+			rec = append(rec, field)
+			line = rest
+		}
+		rec = append(rec, line)
+		if len(rec) != fields || strings.Contains(line, "\t") {
+			panic(fmt.Sprintf("scenarios: %s line %d: want %d fields", name, n, fields))
+		}
+		fn(rec)
+	}
+}
+
+func mustParseFloat(name, s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(fmt.Sprintf("scenarios: %s: %v", name, err))
+	}
+	return v
+}
+
+// embeddedApps returns the app pool: bundle, name, and IAB category.
+// Apps in the same category share one Category slice.
+var embeddedApps = sync.OnceValue(func() []appInfo {
+	apps := make([]appInfo, 0, strings.Count(appsTSV, "\n"))
+	categories := make(map[string][]string)
+	forEachRecord("apps.tsv", appsTSV, 3, func(f []string) {
+		cat, ok := categories[f[2]]
+		if !ok {
+			cat = []string{f[2]}
+			categories[f[2]] = cat
+		}
+		apps = append(apps, appInfo{Bundle: f[0], Name: f[1], Category: cat})
+	})
+	return apps
+})
+
+// embeddedPhones returns the phone pool: make, model, OS, OS version, and
+// user agent.
+var embeddedPhones = sync.OnceValue(func() []deviceInfo {
+	phones := make([]deviceInfo, 0, strings.Count(phonesTSV, "\n"))
+	forEachRecord("phones.tsv", phonesTSV, 5, func(f []string) {
+		phones = append(phones, deviceInfo{Make: f[0], Model: f[1], OS: f[2], OSV: f[3], UA: f[4]})
+	})
+	return phones
+})
+
+// cityPool holds a country's cities, picked in proportion to population.
+type cityPool struct {
+	cities []geoInfo
+	picker *randutil.Weighted
+}
+
+// pick returns a random city from the pool.
+func (p *cityPool) pick() geoInfo {
+	return p.cities[p.picker.Sample()]
+}
+
+// embeddedCities returns the city pools keyed by alpha-3 country code.
+var embeddedCities = sync.OnceValue(func() map[string]*cityPool {
+	cities := make(map[string][]geoInfo)
+	weights := make(map[string][]float64)
+	forEachRecord("cities.tsv", citiesTSV, 7, func(f []string) {
+		offset, err := strconv.Atoi(f[5])
+		if err != nil {
+			panic(fmt.Sprintf("scenarios: cities.tsv: %v", err))
+		}
+		cities[f[0]] = append(cities[f[0]], geoInfo{
+			Country:   f[0],
+			Region:    f[1],
+			City:      f[2],
+			Lat:       mustParseFloat("cities.tsv", f[3]),
+			Lon:       mustParseFloat("cities.tsv", f[4]),
+			UTCOffset: offset,
+		})
+		weights[f[0]] = append(weights[f[0]], mustParseFloat("cities.tsv", f[6]))
+	})
+
+	pools := make(map[string]*cityPool, len(cities))
+	for country, cs := range cities {
+		pools[country] = &cityPool{cities: cs, picker: randutil.MustWeighted(weights[country])}
+	}
+	return pools
+})
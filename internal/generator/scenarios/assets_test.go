@@ -0,0 +1,111 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+func TestEmbeddedPools(t *testing.T) {
+	apps := embeddedApps()
+	if len(apps) < 1000 {
+		t.Errorf("len(apps) = %d, want a large pool", len(apps))
+	}
+	bundles := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		if app.Name == "" || app.Bundle == "" || len(app.Category) != 1 {
+			t.Fatalf("app = %+v, want name, bundle, and one category", app)
+		}
+		if bundles[app.Bundle] {
+			t.Errorf("bundle %q repeated", app.Bundle)
+		}
+		bundles[app.Bundle] = true
+	}
+
+	phones := embeddedPhones()
+	if len(phones) < 100 {
+		t.Errorf("len(phones) = %d, want a large pool", len(phones))
+	}
+	for _, p := range phones {
+		if p.Make == "" || p.Model == "" || p.OSV == "" || p.UA == "" || (p.OS != "iOS" && p.OS != "Android") {
+			t.Fatalf("phone = %+v, want every field set", p)
+		}
+	}
+
+	cities := embeddedCities()
+	for _, l := range locales {
+		pool, ok := cities[l.Country]
+		if !ok || len(pool.cities) == 0 {
+			t.Errorf("no cities for locale %s", l.Country)
+		}
+	}
+	for country := range cities {
+		if _, ok := localesByCountry[country]; !ok {
+			t.Errorf("cities for %s, which has no locale", country)
+		}
+	}
+}
+
+func TestMobileApp_Generate_PoolDiversity(t *testing.T) {
+	scenario := NewMobileApp()
+
+	bundles := make(map[string]bool)
+	uas := make(map[string]bool)
+	cities := make(map[string]bool)
+	for i := 0; i < 2000; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		bundles[req.App.Bundle] = true
+		uas[req.Device.UA] = true
+		cities[req.Device.Geo.City] = true
+	}
+
+	if len(bundles) < 500 {
+		t.Errorf("only %d distinct bundles in 2000 requests", len(bundles))
+	}
+	if len(uas) < 200 {
+		t.Errorf("only %d distinct user agents in 2000 requests", len(uas))
+	}
+	if len(cities) < 50 {
+		t.Errorf("only %d distinct cities in 2000 requests", len(cities))
+	}
+}
+
+func TestMobileApp_Generate_OSShare(t *testing.T) {
+	scenario := NewMobileApp()
+
+	const n = 6000
+	ios := 0
+	for i := 0; i < n; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		if req.Device.OS == "iOS" {
+			ios++
+		}
+	}
+
+	// The catalog lists over ten Android models per iPhone; the OS is
+	// picked by share before the model.
+	if share := float64(ios) / n; share < 0.29 || share > 0.38 {
+		t.Errorf("iOS share = %.3f, want about 1/3", share)
+	}
+}
+
+func TestForEachRecord(t *testing.T) {
+	var got [][]string
+	forEachRecord("test", "# header\na\tb\n\nc\td\n", 2, func(f []string) {
+		got = append(got, append([]string(nil), f...))
+	})
+	if len(got) != 2 || got[0][0] != "a" || got[0][1] != "b" || got[1][0] != "c" || got[1][1] != "d" {
+		t.Errorf("records = %v, want [[a b] [c d]]", got)
+	}
+
+	for _, data := range []string{"a\n", "a\tb\tc\n"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("forEachRecord(%q) did not panic on a malformed record", data)
+				}
+			}()
+			forEachRecord("test", data, 2, func([]string) {})
+		}()
+	}
+}
@@ -0,0 +1,211 @@
+package scenarios
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/cass/rtb-simulator/internal/currency"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// audience holds the device and locale pools shared by scenarios.
+type audience struct {
+	locales    *localeMix
+	devices    *deviceMix
+	sharedIPs  *ipPool      // nil when every request gets a unique IP
+	consent    *consentMix  // nil when no consent signals are sent
+	schain     *supplyChain // nil when requests carry no supply chain
+	deals      *dealSet     // nil when requests carry no deals
+	dealCur    string       // currency of deal floors, USD when empty
+	contextual *contextual  // nil when requests carry no contextual signals
+	cur        []string     // allowed bid currencies, shared by all requests
+	nonSecure  float64      // share of impressions that allow non-HTTPS creatives
+	floors     *floorModel  // nil uses the scenario's default floors
+}
+
+// Option configures the audience of a scenario.
+type Option func(*audience)
+
+// WithLocaleWeights sets the country mix for generated devices, keyed by
+// ISO-3166-1 alpha-3 code. Invalid weights leave the default mix in place;
+// use ValidateLocaleWeights to reject them up front.
+func WithLocaleWeights(weights map[string]float64) Option {
+	return func(a *audience) {
+		if mix, err := newLocaleMix(weights); err == nil {
+			a.locales = mix
+		}
+	}
+}
+
+// WithDeviceMix sets the share of phone, tablet, desktop, and CTV devices
+// (see the DeviceClass constants). Invalid weights leave the scenario's
+// default mix in place; use ValidateDeviceMix to reject them up front.
+func WithDeviceMix(weights map[string]float64) Option {
+	return func(a *audience) {
+		if mix, err := newDeviceMix(weights); err == nil {
+			a.devices = mix
+		}
+	}
+}
+
+// WithSharedIPs routes a share of requests through a pool of size shared
+// IPs, as behind carrier-grade NAT. Pool popularity is Zipf-distributed
+// with the given concentration exponent: 0 spreads traffic evenly, larger
+// values concentrate it on a few addresses. Invalid parameters disable
+// shared IPs.
+func WithSharedIPs(size int, concentration, share float64) Option {
+	return func(a *audience) {
+		if pool, err := newIPPool(size, concentration, share); err == nil {
+			a.sharedIPs = pool
+		}
+	}
+}
+
+// WithConsent marks gdprShare of requests as subject to GDPR, with a TCF
+// v2 consent string in user.ext.consent, and attaches a CCPA US Privacy
+// string to usPrivacyShare of requests. The two are drawn independently.
+// Invalid shares disable consent signals.
+func WithConsent(gdprShare, usPrivacyShare float64) Option {
+	return func(a *audience) {
+		if mix, err := newConsentMix(gdprShare, usPrivacyShare); err == nil {
+			a.consent = mix
+		}
+	}
+}
+
+// WithSupplyChain attaches a SupplyChain (source.ext.schain) with hops
+// nodes to every request. The first node is the publisher's seller
+// account, drawn from sellerIDs (random IDs if empty); the rest are
+// resellers. Invalid parameters disable the supply chain.
+func WithSupplyChain(hops int, sellerIDs []string) Option {
+	return func(a *audience) {
+		if chain, err := newSupplyChain(hops, sellerIDs); err == nil {
+			a.schain = chain
+		}
+	}
+}
+
+// ValidateSupplyChain checks supply chain parameters for WithSupplyChain.
+func ValidateSupplyChain(hops int, sellerIDs []string) error {
+	return validateSupplyChain(hops, sellerIDs)
+}
+
+// WithDeals attaches a private marketplace (imp.pmp) listing deals to
+// share of requests. With private set, only bids on the deals are
+// accepted for those requests. Invalid parameters disable deals.
+func WithDeals(share float64, private bool, deals []openrtb.Deal) Option {
+	return func(a *audience) {
+		if set, err := newDealSet(share, private, deals); err == nil {
+			a.deals = set
+		}
+	}
+}
+
+// WithDealCurrency states deal floors in code (deal.bidfloorcur), such as
+// the exchange's base currency, instead of USD. An invalid code leaves
+// USD in place.
+func WithDealCurrency(code string) Option {
+	return func(a *audience) {
+		if currency.ValidCode(code) {
+			a.dealCur = code
+		}
+	}
+}
+
+// ValidateDeals checks deal parameters for WithDeals.
+func ValidateDeals(share float64, deals []openrtb.Deal) error {
+	return validateDeals(share, deals)
+}
+
+// WithContextual attaches contextual signals to share of requests: a
+// content object (app.content or site.content) with a genre and keywords
+// from the taxonomy topic of the publisher's IAB category, and user
+// interest keywords (user.keywords) drawn from interests. An empty
+// taxonomy or interests list uses DefaultTaxonomy or DefaultInterests.
+// Invalid parameters disable contextual signals.
+func WithContextual(share float64, taxonomy map[string]Topic, interests []string) Option {
+	return func(a *audience) {
+		if c, err := newContextual(share, taxonomy, interests); err == nil {
+			a.contextual = c
+		}
+	}
+}
+
+// ValidateContextual checks contextual signal parameters for
+// WithContextual.
+func ValidateContextual(share float64, taxonomy map[string]Topic) error {
+	return validateContextual(share, taxonomy)
+}
+
+// WithCurrencies sets the bid currencies requests allow (cur), the
+// preferred first. Codes that are not three upper-case letters leave the
+// default of USD in place.
+func WithCurrencies(codes []string) Option {
+	return func(a *audience) {
+		if len(codes) == 0 {
+			return
+		}
+		for _, code := range codes {
+			if !currency.ValidCode(code) {
+				return
+			}
+		}
+		a.cur = slices.Clone(codes)
+	}
+}
+
+// WithNonSecureShare marks share of impressions as not requiring secure
+// creatives (imp.secure omitted) instead of the default of requiring
+// HTTPS markup and assets on every impression. A share outside [0, 1]
+// leaves the default in place.
+func WithNonSecureShare(share float64) Option {
+	return func(a *audience) {
+		if share >= 0 && share <= 1 {
+			a.nonSecure = share
+		}
+	}
+}
+
+// WithFloors draws impression bid floors from def, or from bySize for
+// banners and video players of a listed size, keyed "WxH" such as
+// "300x250". Sizes without an entry use def, or the scenario's default
+// range when def is zero. Invalid distributions or sizes, which
+// ValidateFloors reports, leave the default floors in place.
+func WithFloors(def randutil.Dist, bySize map[string]randutil.Dist) Option {
+	return func(a *audience) {
+		if f, err := newFloorModel(def, bySize); err == nil {
+			a.floors = f
+		}
+	}
+}
+
+// secure returns the imp.secure flag of a new impression.
+func (a *audience) secure() int {
+	if a.nonSecure > 0 && randutil.Chance(a.nonSecure) {
+		return 0
+	}
+	return 1
+}
+
+// newAudience applies opts, falling back to the default locale weights and
+// the scenario's default device mix.
+func newAudience(defaultDevices map[string]float64, opts []Option) audience {
+	var a audience
+	for _, opt := range opts {
+		opt(&a)
+	}
+	if a.locales == nil {
+		a.locales, _ = newLocaleMix(DefaultLocaleWeights)
+	}
+	if a.devices == nil {
+		a.devices, _ = newDeviceMix(defaultDevices)
+	}
+	if a.cur == nil {
+		a.cur = currencyUSD
+	}
+	if a.deals != nil {
+		a.deals.setCurrency(cmp.Or(a.dealCur, currency.Default))
+	}
+	return a
+}
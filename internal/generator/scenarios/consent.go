@@ -0,0 +1,175 @@
+package scenarios
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// tcStringPoolSize is how many distinct TCF consent strings are generated
+// up front; requests draw from the pool instead of encoding per call.
+const tcStringPoolSize = 64
+
+// consentProfile shapes the purposes and vendors a generated TC string
+// grants, from full consent through outright rejection.
+type consentProfile struct {
+	purposes   uint32  // bit i (from the left) set = purpose i+1 consented
+	vendorRate float64 // fraction of vendors consented
+}
+
+var (
+	consentProfiles = []consentProfile{
+		{purposes: 0xFFC000, vendorRate: 0.9}, // purposes 1-10
+		{purposes: 0x800000, vendorRate: 0.3}, // storage only
+		{purposes: 0, vendorRate: 0},          // rejected
+	}
+	consentProfileWeights = randutil.MustWeighted([]float64{0.7, 0.2, 0.1})
+)
+
+// tcfLocale pairs a consent language with the publisher country code.
+type tcfLocale struct {
+	language, country string
+}
+
+var tcfLocales = []tcfLocale{{"EN", "GB"}, {"DE", "DE"}, {"FR", "FR"}, {"ES", "ES"}}
+
+// US Privacy strings: version 1, then notice given, opted out, and LSPA
+// covered, each Y, N, or - (not applicable).
+var (
+	usPrivacyStrings = []string{"1YNN", "1YYN", "1YNY", "1---"}
+	usPrivacyWeights = randutil.MustWeighted([]float64{0.7, 0.15, 0.1, 0.05})
+)
+
+// consentMix emits GDPR and US privacy signals on a share of requests.
+type consentMix struct {
+	gdprShare      float64
+	usPrivacyShare float64
+	tcStrings      []string
+}
+
+// validateConsent checks consent signal shares.
+func validateConsent(gdprShare, usPrivacyShare float64) error {
+	if gdprShare < 0 || gdprShare > 1 {
+		return errors.New("gdpr share must be between 0 and 1")
+	}
+	if usPrivacyShare < 0 || usPrivacyShare > 1 {
+		return errors.New("us privacy share must be between 0 and 1")
+	}
+	return nil
+}
+
+func newConsentMix(gdprShare, usPrivacyShare float64) (*consentMix, error) {
+	if err := validateConsent(gdprShare, usPrivacyShare); err != nil {
+		return nil, err
+	}
+	if gdprShare == 0 && usPrivacyShare == 0 {
+		return nil, nil
+	}
+
+	m := &consentMix{gdprShare: gdprShare, usPrivacyShare: usPrivacyShare}
+	if gdprShare > 0 {
+		now := time.Now()
+		m.tcStrings = make([]string, tcStringPoolSize)
+		for i := range m.tcStrings {
+			m.tcStrings[i] = randomTCString(now)
+		}
+	}
+	return m, nil
+}
+
+// apply sets regs and user consent signals on req.
+func (m *consentMix) apply(req *openrtb.BidRequest) {
+	if m == nil {
+		return
+	}
+
+	gdpr := randutil.Chance(m.gdprShare)
+	usp := randutil.Chance(m.usPrivacyShare)
+	if !gdpr && !usp {
+		return
+	}
+
+	ext := &openrtb.RegsExt{}
+	if gdpr {
+		ext.GDPR = 1
+		if req.User != nil {
+			req.User.Ext = &openrtb.UserExt{Consent: m.tcStrings[randutil.IntN(len(m.tcStrings))]}
+		}
+	}
+	if usp {
+		ext.USPrivacy = usPrivacyStrings[usPrivacyWeights.Sample()]
+	}
+	req.Regs = &openrtb.Regs{Ext: ext}
+}
+
+// randomTCString encodes an IAB TCF v2.2 core string with a random consent
+// profile, CMP, and vendor list, last updated within 30 days before now.
+func randomTCString(now time.Time) string {
+	profile := consentProfiles[consentProfileWeights.Sample()]
+	loc := tcfLocales[randutil.IntN(len(tcfLocales))]
+	updated := now.Add(-time.Duration(randutil.IntN(30*24)) * time.Hour)
+	created := updated.Add(-time.Duration(randutil.IntN(365*24)) * time.Hour)
+	maxVendor := 300 + randutil.IntN(900)
+
+	var w bitWriter
+	w.write(2, 6)                                  // Version
+	w.write(uint64(created.UnixMilli()/100), 36)   // Created (deciseconds)
+	w.write(uint64(updated.UnixMilli()/100), 36)   // LastUpdated
+	w.write(uint64(2+randutil.IntN(400)), 12)      // CmpId
+	w.write(uint64(1+randutil.IntN(20)), 12)       // CmpVersion
+	w.write(1, 6)                                  // ConsentScreen
+	w.writeLetters(loc.language)                   // ConsentLanguage
+	w.write(uint64(50+randutil.IntN(100)), 12)     // VendorListVersion
+	w.write(4, 6)                                  // TcfPolicyVersion (2.2)
+	w.write(1, 1)                                  // IsServiceSpecific
+	w.write(0, 1)                                  // UseNonStandardTexts
+	w.write(0, 12)                                 // SpecialFeatureOptins
+	w.write(uint64(profile.purposes), 24)          // PurposesConsent
+	w.write(uint64(profile.purposes&0x43C000), 24) // PurposesLITransparency (2, 7-10)
+	w.write(0, 1)                                  // PurposeOneTreatment
+	w.writeLetters(loc.country)                    // PublisherCC
+
+	// Vendor consent and legitimate interest sections, bitfield encoded
+	for _, rate := range []float64{profile.vendorRate, profile.vendorRate / 2} {
+		w.write(uint64(maxVendor), 16) // MaxVendorId
+		w.write(0, 1)                  // IsRangeEncoding
+		for v := 0; v < maxVendor; v++ {
+			if randutil.Chance(rate) {
+				w.write(1, 1)
+			} else {
+				w.write(0, 1)
+			}
+		}
+	}
+	w.write(0, 12) // NumPubRestrictions
+
+	return base64.RawURLEncoding.EncodeToString(w.buf)
+}
+
+// bitWriter packs big-endian bit fields as used by the TCF encoding.
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+// write appends the low bits bits of v, most significant first.
+func (w *bitWriter) write(v uint64, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.buf[w.n/8] |= 0x80 >> uint(w.n%8)
+		}
+		w.n++
+	}
+}
+
+// writeLetters appends a two-letter code as two 6-bit values (A=0).
+func (w *bitWriter) writeLetters(code string) {
+	w.write(uint64(code[0]-'A'), 6)
+	w.write(uint64(code[1]-'A'), 6)
+}
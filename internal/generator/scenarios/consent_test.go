@@ -0,0 +1,109 @@
+package scenarios
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+// readBits reads n big-endian bits starting at bit offset off.
+func readBits(buf []byte, off, n int) uint64 {
+	var v uint64
+	for i := off; i < off+n; i++ {
+		v = v<<1 | uint64(buf[i/8]>>(7-uint(i%8))&1)
+	}
+	return v
+}
+
+func TestRandomTCString_Decodes(t *testing.T) {
+	now := time.Now()
+	for i := 0; i < 50; i++ {
+		tc := randomTCString(now)
+		buf, err := base64.RawURLEncoding.DecodeString(tc)
+		if err != nil {
+			t.Fatalf("TC string %q is not base64url: %v", tc, err)
+		}
+
+		if v := readBits(buf, 0, 6); v != 2 {
+			t.Errorf("Version = %d, want 2", v)
+		}
+		updated := time.UnixMilli(int64(readBits(buf, 42, 36)) * 100)
+		if updated.After(now) || now.Sub(updated) > 31*24*time.Hour {
+			t.Errorf("LastUpdated = %v, want within 30 days before %v", updated, now)
+		}
+		if p := readBits(buf, 132, 6); p != 4 {
+			t.Errorf("TcfPolicyVersion = %d, want 4", p)
+		}
+
+		lang := string([]byte{byte(readBits(buf, 108, 6)) + 'A', byte(readBits(buf, 114, 6)) + 'A'})
+		found := false
+		for _, loc := range tcfLocales {
+			found = found || loc.language == lang
+		}
+		if !found {
+			t.Errorf("ConsentLanguage = %q, want one of %v", lang, tcfLocales)
+		}
+
+		// Purposes consent follows the 12 special feature bits
+		purposes := uint32(readBits(buf, 152, 24))
+		if purposes != 0xFFC000 && purposes != 0x800000 && purposes != 0 {
+			t.Errorf("PurposesConsent = %#x, want a known profile", purposes)
+		}
+	}
+}
+
+func TestMobileApp_WithConsent(t *testing.T) {
+	m := NewMobileApp(WithConsent(0.5, 0.25))
+
+	const n = 4000
+	var gdpr, usp int
+	for i := 0; i < n; i++ {
+		req := m.Generate(generator.Context{RequestID: "req"})
+		if req.Regs == nil {
+			if req.User.Ext != nil {
+				t.Fatal("user.ext.consent set without regs")
+			}
+			continue
+		}
+		if req.Regs.Ext.GDPR == 1 {
+			gdpr++
+			if req.User.Ext == nil || req.User.Ext.Consent == "" {
+				t.Fatal("gdpr=1 without a consent string")
+			}
+		}
+		if s := req.Regs.Ext.USPrivacy; s != "" {
+			usp++
+			if len(s) != 4 || s[0] != '1' {
+				t.Errorf("us_privacy = %q, want version 1 string", s)
+			}
+		}
+	}
+
+	if gdpr < n*40/100 || gdpr > n*60/100 {
+		t.Errorf("gdpr requests = %d of %d, want ~50%%", gdpr, n)
+	}
+	if usp < n*18/100 || usp > n*32/100 {
+		t.Errorf("us_privacy requests = %d of %d, want ~25%%", usp, n)
+	}
+}
+
+func TestWithConsent_Disabled(t *testing.T) {
+	for _, tt := range []struct{ gdpr, usp float64 }{{0, 0}, {1.5, 0}, {0, -1}} {
+		m := NewMobileApp(WithConsent(tt.gdpr, tt.usp))
+		for i := 0; i < 100; i++ {
+			if req := m.Generate(generator.Context{RequestID: "req"}); req.Regs != nil {
+				t.Fatalf("WithConsent(%v, %v): regs set, want none", tt.gdpr, tt.usp)
+			}
+		}
+	}
+}
+
+func TestVideo_WithConsent(t *testing.T) {
+	v := NewVideo(WithConsent(1, 1))
+	req := v.Generate(generator.Context{RequestID: "req"})
+	if req.Regs == nil || req.Regs.Ext.GDPR != 1 || req.Regs.Ext.USPrivacy == "" || req.User.Ext == nil {
+		t.Errorf("Regs = %+v, User.Ext = %+v, want both signals", req.Regs, req.User.Ext)
+	}
+}
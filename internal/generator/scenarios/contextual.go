@@ -0,0 +1,158 @@
+package scenarios
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// maxKeywords bounds the keywords drawn for one content or user object.
+const maxKeywords = 3
+
+// Topic is the contextual vocabulary of a content category: the genres
+// its content is labeled with and keywords describing it.
+type Topic struct {
+	Genres   []string
+	Keywords []string
+}
+
+// DefaultTaxonomy maps the IAB categories of the built-in publishers to
+// topics. A subcategory without an entry of its own, such as IAB9-5,
+// falls back to its tier-1 category, IAB9.
+var DefaultTaxonomy = map[string]Topic{
+	"IAB1":     {Genres: []string{"Entertainment"}, Keywords: []string{"celebrities", "trailers", "reviews", "awards"}},
+	"IAB1-5":   {Genres: []string{"Drama", "Comedy", "Action", "Documentary"}, Keywords: []string{"movies", "trailers", "box office", "streaming"}},
+	"IAB1-6":   {Genres: []string{"Pop", "Hip-Hop", "Rock", "Electronic"}, Keywords: []string{"music", "playlists", "concerts", "new releases"}},
+	"IAB5":     {Genres: []string{"Education"}, Keywords: []string{"learning", "courses", "languages", "homework"}},
+	"IAB7":     {Genres: []string{"Health", "Fitness"}, Keywords: []string{"workouts", "nutrition", "running", "sleep", "wellness"}},
+	"IAB8":     {Genres: []string{"Cooking", "Food"}, Keywords: []string{"recipes", "baking", "restaurants", "healthy eating"}},
+	"IAB9":     {Genres: []string{"Hobbies"}, Keywords: []string{"crafts", "collecting", "diy"}},
+	"IAB9-23":  {Genres: []string{"Photography"}, Keywords: []string{"cameras", "photo editing", "filters", "portraits"}},
+	"IAB9-30":  {Genres: []string{"Puzzle", "Action", "Strategy", "Casual", "Racing"}, Keywords: []string{"gaming", "esports", "walkthroughs", "multiplayer", "leaderboards"}},
+	"IAB12":    {Genres: []string{"News", "Politics", "Local News"}, Keywords: []string{"breaking news", "elections", "economy", "world"}},
+	"IAB13":    {Genres: []string{"Finance"}, Keywords: []string{"investing", "stocks", "budgeting", "retirement", "crypto"}},
+	"IAB14":    {Genres: []string{"Lifestyle"}, Keywords: []string{"dating", "family", "parenting", "community"}},
+	"IAB15":    {Genres: []string{"Science"}, Keywords: []string{"space", "environment", "research"}},
+	"IAB15-10": {Genres: []string{"Weather"}, Keywords: []string{"forecast", "storms", "radar", "temperature"}},
+	"IAB17":    {Genres: []string{"Football", "Basketball", "Soccer", "Tennis"}, Keywords: []string{"scores", "highlights", "playoffs", "fantasy sports", "transfers"}},
+	"IAB19":    {Genres: []string{"Technology"}, Keywords: []string{"smartphones", "gadgets", "software", "ai"}},
+	"IAB20":    {Genres: []string{"Travel"}, Keywords: []string{"flights", "hotels", "beaches", "road trips"}},
+	"IAB22":    {Genres: []string{"Shopping"}, Keywords: []string{"deals", "coupons", "fashion", "electronics"}},
+}
+
+// DefaultInterests are the user interest keywords drawn when none are
+// configured.
+var DefaultInterests = []string{
+	"sports", "travel", "fitness", "cooking", "gaming", "music", "movies", "finance",
+	"technology", "fashion", "parenting", "pets", "cars", "home improvement", "outdoors",
+}
+
+// contextual attaches content and user interest keywords to a share of
+// requests, for DSPs that target on context rather than identity.
+type contextual struct {
+	share     float64
+	taxonomy  map[string]Topic
+	interests []string
+}
+
+// validateContextual checks contextual signal parameters.
+func validateContextual(share float64, taxonomy map[string]Topic) error {
+	if share < 0 || share > 1 {
+		return errors.New("contextual share must be between 0 and 1")
+	}
+	for cat, topic := range taxonomy {
+		if len(topic.Genres) == 0 && len(topic.Keywords) == 0 {
+			return errors.New("taxonomy category " + cat + " needs genres or keywords")
+		}
+	}
+	return nil
+}
+
+func newContextual(share float64, taxonomy map[string]Topic, interests []string) (*contextual, error) {
+	if err := validateContextual(share, taxonomy); err != nil {
+		return nil, err
+	}
+	if share == 0 {
+		return nil, nil
+	}
+	if len(taxonomy) == 0 {
+		taxonomy = DefaultTaxonomy
+	}
+	if len(interests) == 0 {
+		interests = DefaultInterests
+	}
+	return &contextual{share: share, taxonomy: taxonomy, interests: interests}, nil
+}
+
+// topic returns the taxonomy entry of an IAB category, or of its tier-1
+// category when it has none.
+func (c *contextual) topic(cat string) (Topic, bool) {
+	if t, ok := c.taxonomy[cat]; ok {
+		return t, true
+	}
+	tier1, _, _ := strings.Cut(cat, "-")
+	t, ok := c.taxonomy[tier1]
+	return t, ok
+}
+
+// apply sets app.content or site.content from the publisher's category,
+// and user.keywords, on a share of requests. Content the scenario already
+// described keeps its genre and language.
+func (c *contextual) apply(req *openrtb.BidRequest) {
+	if c == nil || !randutil.Chance(c.share) {
+		return
+	}
+
+	if req.User != nil {
+		req.User.Keywords = pickKeywords(c.interests)
+	}
+
+	var cats []string
+	var content **openrtb.Content
+	switch {
+	case req.App != nil:
+		cats, content = req.App.Cat, &req.App.Content
+	case req.Site != nil:
+		cats, content = req.Site.Cat, &req.Site.Content
+	}
+	if len(cats) == 0 {
+		return
+	}
+	topic, ok := c.topic(cats[0])
+	if !ok {
+		return
+	}
+	if *content == nil {
+		*content = &openrtb.Content{Cat: cats}
+	}
+	cn := *content
+	cn.Keywords = pickKeywords(topic.Keywords)
+	if cn.Genre == "" && len(topic.Genres) > 0 {
+		cn.Genre = topic.Genres[randutil.IntN(len(topic.Genres))]
+	}
+	if cn.Language == "" && req.Device != nil {
+		cn.Language = req.Device.Language
+	}
+}
+
+// pickKeywords joins one to maxKeywords distinct keywords from pool,
+// comma-separated as OpenRTB keywords are. An empty pool gives "".
+func pickKeywords(pool []string) string {
+	if len(pool) == 0 {
+		return ""
+	}
+	n := 1 + randutil.IntN(min(maxKeywords, len(pool)))
+	// Consecutive entries from a random start are distinct without
+	// tracking what was drawn
+	start := randutil.IntN(len(pool))
+	var b strings.Builder
+	for i := range n {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pool[(start+i)%len(pool)])
+	}
+	return b.String()
+}
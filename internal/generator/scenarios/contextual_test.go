@@ -0,0 +1,124 @@
+package scenarios
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// publisherContent returns the content and categories of req's app or site.
+func publisherContent(req *openrtb.BidRequest) (*openrtb.Content, []string) {
+	if req.Site != nil {
+		return req.Site.Content, req.Site.Cat
+	}
+	return req.App.Content, req.App.Cat
+}
+
+func TestScenarios_NoContextualByDefault(t *testing.T) {
+	req := NewMobileApp().Generate(generator.Context{RequestID: "req-1"})
+	if req.App.Content != nil || req.User.Keywords != "" {
+		t.Errorf("Content, User.Keywords = %+v, %q; want none by default", req.App.Content, req.User.Keywords)
+	}
+}
+
+func TestWithContextual_DefaultTaxonomy(t *testing.T) {
+	defaults := &contextual{taxonomy: DefaultTaxonomy}
+	for _, scenario := range []generator.Scenario{NewMobileApp(WithContextual(1, nil, nil)), NewVideo(WithContextual(1, nil, nil))} {
+		for range 200 {
+			req := scenario.Generate(generator.Context{RequestID: "req-1"})
+			content, cats := publisherContent(req)
+			if content == nil {
+				t.Fatalf("%s: no content for category %v", scenario.Name(), cats)
+			}
+			topic, _ := defaults.topic(cats[0])
+			if !slices.Contains(topic.Genres, content.Genre) {
+				t.Errorf("%s: Genre = %q, want one of %v", scenario.Name(), content.Genre, topic.Genres)
+			}
+			for _, kw := range strings.Split(content.Keywords, ",") {
+				if !slices.Contains(topic.Keywords, kw) {
+					t.Errorf("%s: keyword %q is not in the %s topic", scenario.Name(), kw, cats[0])
+				}
+			}
+			if content.Language != req.Device.Language {
+				t.Errorf("%s: Language = %q, want the device's %q", scenario.Name(), content.Language, req.Device.Language)
+			}
+			if kws := strings.Split(req.User.Keywords, ","); req.User.Keywords == "" || len(kws) > maxKeywords {
+				t.Errorf("%s: User.Keywords = %q, want 1 to %d interests", scenario.Name(), req.User.Keywords, maxKeywords)
+			}
+		}
+	}
+}
+
+func TestWithContextual_CustomTaxonomy(t *testing.T) {
+	m := NewMobileApp(WithContextual(0.5, map[string]Topic{
+		"IAB17": {Genres: []string{"Curling"}},
+		"IAB1":  {Keywords: []string{"premieres"}}, // covers IAB1-6
+	}, []string{"outdoors"}))
+
+	const n = 2000
+	withSignals := 0
+	for range n {
+		req := m.Generate(generator.Context{RequestID: "req-1"})
+		if req.User.Keywords == "" {
+			if req.App.Content != nil {
+				t.Fatal("content set on a request without contextual signals")
+			}
+			continue
+		}
+		withSignals++
+		if req.User.Keywords != "outdoors" {
+			t.Errorf("User.Keywords = %q, want outdoors", req.User.Keywords)
+		}
+
+		content := req.App.Content
+		switch req.App.Cat[0] {
+		case "IAB17":
+			if content == nil || content.Genre != "Curling" || content.Keywords != "" {
+				t.Errorf("IAB17 content = %+v, want genre Curling", content)
+			}
+		case "IAB1-6":
+			if content == nil || content.Keywords != "premieres" || content.Genre != "" {
+				t.Errorf("IAB1-6 content = %+v, want the IAB1 keywords", content)
+			}
+		default:
+			if content != nil {
+				t.Errorf("%s content = %+v, want none outside the taxonomy", req.App.Cat[0], content)
+			}
+		}
+	}
+	if share := float64(withSignals) / n; share < 0.45 || share > 0.55 {
+		t.Errorf("share with signals = %.3f, want ~0.5", share)
+	}
+}
+
+func TestValidateContextual(t *testing.T) {
+	if err := ValidateContextual(1.5, nil); err == nil {
+		t.Error("ValidateContextual(1.5) = nil, want an error")
+	}
+	if err := ValidateContextual(0.5, map[string]Topic{"IAB17": {}}); err == nil {
+		t.Error("ValidateContextual() = nil for an empty topic, want an error")
+	}
+	if err := ValidateContextual(0.5, DefaultTaxonomy); err != nil {
+		t.Errorf("ValidateContextual(DefaultTaxonomy) = %v", err)
+	}
+}
+
+func TestPickKeywords(t *testing.T) {
+	pool := []string{"a", "b", "c", "d"}
+	for range 100 {
+		kws := strings.Split(pickKeywords(pool), ",")
+		if len(kws) < 1 || len(kws) > maxKeywords {
+			t.Fatalf("pickKeywords() = %v, want 1 to %d keywords", kws, maxKeywords)
+		}
+		slices.Sort(kws)
+		if len(slices.Compact(kws)) != len(kws) {
+			t.Fatalf("pickKeywords() = %v, want distinct keywords", kws)
+		}
+	}
+	if got := pickKeywords(nil); got != "" {
+		t.Errorf("pickKeywords(nil) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,77 @@
+package scenarios
+
+import (
+	"errors"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// dealSet attaches a private marketplace with a fixed list of deals to a
+// share of requests.
+type dealSet struct {
+	share   float64
+	private int
+	deals   []openrtb.Deal
+}
+
+// validateDeals checks deal parameters. A zero share disables deals.
+func validateDeals(share float64, deals []openrtb.Deal) error {
+	if share < 0 || share > 1 {
+		return errors.New("deal share must be between 0 and 1")
+	}
+	if share > 0 && len(deals) == 0 {
+		return errors.New("at least one deal is required when the deal share is set")
+	}
+	seen := make(map[string]bool, len(deals))
+	for _, d := range deals {
+		if d.ID == "" {
+			return errors.New("deal ids must not be empty")
+		}
+		if seen[d.ID] {
+			return errors.New("deal id " + d.ID + " is repeated")
+		}
+		seen[d.ID] = true
+		if d.BidFloor < 0 {
+			return errors.New("deal " + d.ID + " floor must not be negative")
+		}
+	}
+	return nil
+}
+
+func newDealSet(share float64, private bool, deals []openrtb.Deal) (*dealSet, error) {
+	if err := validateDeals(share, deals); err != nil {
+		return nil, err
+	}
+	if share == 0 {
+		return nil, nil
+	}
+
+	s := &dealSet{share: share, deals: append([]openrtb.Deal(nil), deals...)}
+	if private {
+		s.private = 1
+	}
+	return s, nil
+}
+
+// setCurrency states the floors of deals that have one and name no
+// currency in cur.
+func (s *dealSet) setCurrency(cur string) {
+	for i := range s.deals {
+		if s.deals[i].BidFloor > 0 && s.deals[i].BidFloorCur == "" {
+			s.deals[i].BidFloorCur = cur
+		}
+	}
+}
+
+// apply sets the private marketplace on req's impressions for a share of
+// requests. The deals are shared, not copied; they must not be modified.
+func (s *dealSet) apply(req *openrtb.BidRequest) {
+	if s == nil || !randutil.Chance(s.share) {
+		return
+	}
+	pmp := &openrtb.PMP{PrivateAuction: s.private, Deals: s.deals}
+	for i := range req.Imp {
+		req.Imp[i].PMP = pmp
+	}
+}
@@ -0,0 +1,91 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestMobileApp_WithDeals(t *testing.T) {
+	deals := []openrtb.Deal{{ID: "deal-1", BidFloor: 3}, {ID: "deal-2"}}
+	m := NewMobileApp(WithDeals(1, true, deals))
+
+	req := m.Generate(generator.Context{RequestID: "req-1"})
+	pmp := req.Imp[0].PMP
+	if pmp == nil {
+		t.Fatal("Imp[0].PMP = nil, want deals")
+	}
+	if pmp.PrivateAuction != 1 {
+		t.Errorf("PrivateAuction = %d, want 1", pmp.PrivateAuction)
+	}
+	if len(pmp.Deals) != 2 || pmp.Deals[0].ID != "deal-1" || pmp.Deals[0].BidFloor != 3 {
+		t.Fatalf("Deals = %+v, want the configured deals", pmp.Deals)
+	}
+	if pmp.Deals[0].BidFloorCur != "USD" || pmp.Deals[1].BidFloorCur != "" {
+		t.Errorf("floor currencies = %q, %q, want USD on the floored deal only",
+			pmp.Deals[0].BidFloorCur, pmp.Deals[1].BidFloorCur)
+	}
+}
+
+func TestMobileApp_WithDealCurrency(t *testing.T) {
+	m := NewMobileApp(WithDeals(1, false, []openrtb.Deal{{ID: "deal-1", BidFloor: 3}}), WithDealCurrency("EUR"))
+
+	req := m.Generate(generator.Context{RequestID: "req-1"})
+	if cur := req.Imp[0].PMP.Deals[0].BidFloorCur; cur != "EUR" {
+		t.Errorf("BidFloorCur = %q, want EUR", cur)
+	}
+}
+
+func TestVideo_WithDeals_Share(t *testing.T) {
+	v := NewVideo(WithDeals(0.5, false, []openrtb.Deal{{ID: "deal-1"}}))
+
+	const n = 2000
+	withDeals := 0
+	for range n {
+		req := v.Generate(generator.Context{RequestID: "req-1"})
+		if pmp := req.Imp[0].PMP; pmp != nil {
+			withDeals++
+			if pmp.PrivateAuction != 0 {
+				t.Fatalf("PrivateAuction = %d, want 0", pmp.PrivateAuction)
+			}
+		}
+	}
+	if share := float64(withDeals) / n; share < 0.45 || share > 0.55 {
+		t.Errorf("share with deals = %.3f, want ~0.5", share)
+	}
+}
+
+func TestWithDeals_Disabled(t *testing.T) {
+	for _, share := range []float64{0, -0.1, 1.5} {
+		req := NewMobileApp(WithDeals(share, false, []openrtb.Deal{{ID: "deal-1"}})).Generate(generator.Context{RequestID: "req-1"})
+		if req.Imp[0].PMP != nil {
+			t.Errorf("share %v: PMP = %+v, want nil", share, req.Imp[0].PMP)
+		}
+	}
+}
+
+func TestValidateDeals(t *testing.T) {
+	tests := []struct {
+		name    string
+		share   float64
+		deals   []openrtb.Deal
+		wantErr bool
+	}{
+		{"valid", 0.5, []openrtb.Deal{{ID: "a", BidFloor: 1}, {ID: "b"}}, false},
+		{"disabled", 0, nil, false},
+		{"share out of range", 1.5, []openrtb.Deal{{ID: "a"}}, true},
+		{"no deals", 0.5, nil, true},
+		{"empty id", 0.5, []openrtb.Deal{{ID: ""}}, true},
+		{"duplicate id", 0.5, []openrtb.Deal{{ID: "a"}, {ID: "a"}}, true},
+		{"negative floor", 0.5, []openrtb.Deal{{ID: "a", BidFloor: -1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDeals(tt.share, tt.deals)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDeals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
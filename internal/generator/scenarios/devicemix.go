@@ -0,0 +1,313 @@
+package scenarios
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Device classes accepted in device mix weights.
+const (
+	DeviceClassPhone   = "phone"
+	DeviceClassTablet  = "tablet"
+	DeviceClassDesktop = "desktop"
+	DeviceClassCTV     = "ctv"
+)
+
+// DefaultDeviceMix is the device class mix used when none is configured.
+var DefaultDeviceMix = map[string]float64{
+	DeviceClassPhone: 1,
+}
+
+// deviceClass groups the device pool, ad sizes, and connection types that
+// are plausible for one kind of device.
+type deviceClass struct {
+	Name        string
+	DeviceType  int
+	Devices     []deviceInfo
+	loadDevices func() []deviceInfo // embedded pool, used instead of Devices when set
+	// OSShares weights each OS when picking a device, so the OS mix does
+	// not follow how many models the pool lists for each. Nil picks
+	// devices uniformly.
+	OSShares        map[string]float64
+	BannerSizes     []bannerSize
+	ConnectionTypes []int
+
+	pickerOnce sync.Once
+	picker     *devicePicker
+}
+
+// phoneOSShares is the OS mix of phones. The embedded catalog lists over
+// ten Android models per iPhone, far from the share of iOS traffic.
+var phoneOSShares = map[string]float64{
+	"iOS":     1.0 / 3,
+	"Android": 2.0 / 3,
+}
+
+var deviceClasses = map[string]*deviceClass{
+	DeviceClassPhone: {
+		Name:        DeviceClassPhone,
+		DeviceType:  openrtb.DeviceTypePhone,
+		loadDevices: embeddedPhones,
+		OSShares:    phoneOSShares,
+		BannerSizes: phoneBannerSizes,
+		ConnectionTypes: []int{
+			openrtb.ConnectionWifi,
+			openrtb.ConnectionCell4G,
+			openrtb.ConnectionCell3G,
+		},
+	},
+	DeviceClassTablet: {
+		Name:        DeviceClassTablet,
+		DeviceType:  openrtb.DeviceTypeTablet,
+		Devices:     tabletDevices,
+		BannerSizes: tabletBannerSizes,
+		ConnectionTypes: []int{
+			openrtb.ConnectionWifi,
+			openrtb.ConnectionWifi,
+			openrtb.ConnectionCell4G,
+		},
+	},
+	DeviceClassDesktop: {
+		Name:        DeviceClassDesktop,
+		DeviceType:  openrtb.DeviceTypePC,
+		Devices:     desktopDevices,
+		BannerSizes: desktopBannerSizes,
+		ConnectionTypes: []int{
+			openrtb.ConnectionEthernet,
+			openrtb.ConnectionWifi,
+		},
+	},
+	DeviceClassCTV: {
+		Name:        DeviceClassCTV,
+		DeviceType:  openrtb.DeviceTypeTV,
+		Devices:     ctvDevices,
+		BannerSizes: ctvBannerSizes,
+		ConnectionTypes: []int{
+			openrtb.ConnectionEthernet,
+			openrtb.ConnectionWifi,
+		},
+	},
+}
+
+// pool returns the class's devices, loading its embedded pool on first use.
+func (c *deviceClass) pool() []deviceInfo {
+	if c.loadDevices != nil {
+		return c.loadDevices()
+	}
+	return c.Devices
+}
+
+// pickDevice returns a random device of the class, by OSShares if set.
+func (c *deviceClass) pickDevice() deviceInfo {
+	c.pickerOnce.Do(func() {
+		c.picker = newDevicePicker(c.pool(), c.OSShares)
+	})
+	return c.picker.pick()
+}
+
+// devicePicker picks a device's OS first, then one of the OS's devices
+// uniformly.
+type devicePicker struct {
+	byOS [][]deviceInfo // in order of first appearance, for seeded runs
+	os   *randutil.Weighted
+}
+
+// newDevicePicker groups devices by OS, weighting each OS by its share,
+// or by its number of devices when shares is nil.
+func newDevicePicker(devices []deviceInfo, shares map[string]float64) *devicePicker {
+	p := &devicePicker{}
+	index := make(map[string]int)
+	for _, d := range devices {
+		i, ok := index[d.OS]
+		if !ok {
+			i = len(p.byOS)
+			index[d.OS] = i
+			p.byOS = append(p.byOS, nil)
+		}
+		p.byOS[i] = append(p.byOS[i], d)
+	}
+	weights := make([]float64, len(p.byOS))
+	for i, group := range p.byOS {
+		if shares == nil {
+			weights[i] = float64(len(group))
+		} else {
+			weights[i] = shares[group[0].OS]
+		}
+	}
+	p.os = randutil.MustWeighted(weights)
+	return p
+}
+
+func (p *devicePicker) pick() deviceInfo {
+	group := p.byOS[p.os.Sample()]
+	return group[randutil.IntN(len(group))]
+}
+
+// ValidateDeviceMix checks that every class in weights is known and that
+// the weights form a valid distribution.
+func ValidateDeviceMix(weights map[string]float64) error {
+	_, err := newDeviceMix(weights)
+	return err
+}
+
+// deviceMix samples device classes according to configured weights.
+type deviceMix struct {
+	classes []*deviceClass
+	picker  *randutil.Weighted
+}
+
+func newDeviceMix(weights map[string]float64) (*deviceMix, error) {
+	// Sort for a stable index order so seeded runs are reproducible
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mix := &deviceMix{classes: make([]*deviceClass, len(names))}
+	shares := make([]float64, len(names))
+	for i, name := range names {
+		class, ok := deviceClasses[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown device class %q", name)
+		}
+		mix.classes[i] = class
+		shares[i] = weights[name]
+	}
+
+	picker, err := randutil.NewWeighted(shares)
+	if err != nil {
+		return nil, fmt.Errorf("device mix: %w", err)
+	}
+	mix.picker = picker
+	return mix, nil
+}
+
+// pick returns a random device class from the mix.
+func (m *deviceMix) pick() *deviceClass {
+	return m.classes[m.picker.Sample()]
+}
+
+var phoneBannerSizes = []bannerSize{
+	{320, 50},  // Mobile leaderboard
+	{300, 250}, // Medium rectangle
+	{320, 480}, // Mobile interstitial
+	{300, 50},  // Mobile banner
+}
+
+var tabletBannerSizes = []bannerSize{
+	{728, 90},   // Leaderboard
+	{300, 250},  // Medium rectangle
+	{768, 1024}, // Tablet interstitial (portrait)
+	{1024, 768}, // Tablet interstitial (landscape)
+}
+
+var desktopBannerSizes = []bannerSize{
+	{728, 90},  // Leaderboard
+	{300, 250}, // Medium rectangle
+	{160, 600}, // Wide skyscraper
+	{300, 600}, // Half page
+	{970, 250}, // Billboard
+}
+
+// interstitialSizes are the full-screen sizes above, sent with instl=1.
+var interstitialSizes = map[bannerSize]bool{
+	{320, 480}:  true,
+	{768, 1024}: true,
+	{1024, 768}: true,
+}
+
+var ctvBannerSizes = []bannerSize{
+	{1920, 1080}, // Full HD overlay
+	{1280, 720},  // HD overlay
+}
+
+var tabletDevices = []deviceInfo{
+	{
+		Make:  "Apple",
+		Model: "iPad13,18",
+		OS:    "iOS",
+		OSV:   "17.0",
+		UA:    "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+	},
+	{
+		Make:  "Samsung",
+		Model: "SM-X710",
+		OS:    "Android",
+		OSV:   "14",
+		UA:    "Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	},
+	{
+		Make:  "Amazon",
+		Model: "KFTRWI",
+		OS:    "Android",
+		OSV:   "11",
+		UA:    "Mozilla/5.0 (Linux; Android 11; KFTRWI) AppleWebKit/537.36 (KHTML, like Gecko) Silk/120.3.1 like Chrome/120.0.0.0 Safari/537.36",
+	},
+}
+
+var desktopDevices = []deviceInfo{
+	{
+		Make:  "Apple",
+		Model: "Macintosh",
+		OS:    "macOS",
+		OSV:   "14.2",
+		UA:    "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	},
+	{
+		Make:  "Microsoft",
+		Model: "PC",
+		OS:    "Windows",
+		OSV:   "10",
+		UA:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	},
+	{
+		Make:  "Microsoft",
+		Model: "PC",
+		OS:    "Windows",
+		OSV:   "10",
+		UA:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	},
+	{
+		Make:  "Linux",
+		Model: "PC",
+		OS:    "Linux",
+		OSV:   "",
+		UA:    "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	},
+}
+
+var ctvDevices = []deviceInfo{
+	{
+		Make:  "Roku",
+		Model: "3941X",
+		OS:    "Roku OS",
+		OSV:   "12.5",
+		UA:    "Roku/DVP-12.5 (12.5.0.4178)",
+	},
+	{
+		Make:  "Amazon",
+		Model: "AFTMM",
+		OS:    "Fire OS",
+		OSV:   "7",
+		UA:    "Mozilla/5.0 (Linux; Android 9; AFTMM Build/PS7633) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+	},
+	{
+		Make:  "Samsung",
+		Model: "UN55TU7000",
+		OS:    "Tizen",
+		OSV:   "6.0",
+		UA:    "Mozilla/5.0 (SMART-TV; LINUX; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) 76.0.3809.146/6.0 TV Safari/537.36",
+	},
+	{
+		Make:  "Apple",
+		Model: "AppleTV11,1",
+		OS:    "tvOS",
+		OSV:   "17.0",
+		UA:    "AppleCoreMedia/1.0.0.21J354 (Apple TV; U; CPU OS 17_0 like Mac OS X; en_us)",
+	},
+}
@@ -0,0 +1,101 @@
+package scenarios
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestMobileApp_Generate_DefaultDeviceMixIsPhone(t *testing.T) {
+	scenario := NewMobileApp()
+
+	for i := 0; i < 100; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		if req.Device.DeviceType != openrtb.DeviceTypePhone {
+			t.Fatalf("DeviceType = %d, want phone", req.Device.DeviceType)
+		}
+	}
+}
+
+func TestMobileApp_WithDeviceMix(t *testing.T) {
+	scenario := NewMobileApp(WithDeviceMix(map[string]float64{
+		DeviceClassPhone:   0.5,
+		DeviceClassTablet:  0.2,
+		DeviceClassDesktop: 0.2,
+		DeviceClassCTV:     0.1,
+	}))
+
+	const n = 5000
+	counts := make(map[int]int)
+	for i := 0; i < n; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		dt := req.Device.DeviceType
+		counts[dt]++
+
+		class := classForDeviceType(t, dt)
+		if !hasSize(class.BannerSizes, req.Imp[0].Banner.W, req.Imp[0].Banner.H) {
+			t.Errorf("%s got banner %dx%d outside its size pool",
+				class.Name, req.Imp[0].Banner.W, req.Imp[0].Banner.H)
+		}
+		if !hasUA(class.pool(), req.Device.UA) {
+			t.Errorf("%s got UA %q outside its device pool", class.Name, req.Device.UA)
+		}
+	}
+
+	want := map[int]float64{
+		openrtb.DeviceTypePhone:  0.5,
+		openrtb.DeviceTypeTablet: 0.2,
+		openrtb.DeviceTypePC:     0.2,
+		openrtb.DeviceTypeTV:     0.1,
+	}
+	for dt, share := range want {
+		got := float64(counts[dt]) / n
+		if math.Abs(got-share) > 0.03 {
+			t.Errorf("device type %d share = %.3f, want ~%.2f", dt, got, share)
+		}
+	}
+}
+
+func TestValidateDeviceMix(t *testing.T) {
+	if err := ValidateDeviceMix(map[string]float64{DeviceClassCTV: 1}); err != nil {
+		t.Errorf("ValidateDeviceMix(ctv) error = %v", err)
+	}
+	if err := ValidateDeviceMix(map[string]float64{"watch": 1}); err == nil || !strings.Contains(err.Error(), "unknown device class") {
+		t.Errorf("ValidateDeviceMix(watch) error = %v, want unknown device class", err)
+	}
+	if err := ValidateDeviceMix(map[string]float64{DeviceClassPhone: 0}); err == nil {
+		t.Error("ValidateDeviceMix(zero sum) error = nil, want error")
+	}
+}
+
+func classForDeviceType(t *testing.T, dt int) *deviceClass {
+	t.Helper()
+	for _, c := range deviceClasses {
+		if c.DeviceType == dt {
+			return c
+		}
+	}
+	t.Fatalf("no device class for device type %d", dt)
+	return nil
+}
+
+func hasSize(sizes []bannerSize, w, h int) bool {
+	for _, s := range sizes {
+		if s.W == w && s.H == h {
+			return true
+		}
+	}
+	return false
+}
+
+func hasUA(devices []deviceInfo, ua string) bool {
+	for _, d := range devices {
+		if d.UA == ua {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package scenarios
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// Default floor ranges of the built-in scenarios.
+var (
+	// Bid floor between $0.25 and $3.00
+	defaultBannerFloor = randutil.Dist{Type: randutil.DistUniform, Min: 0.25, Max: 3.0}
+	// Video clears well above display: floor between $2.00 and $15.00
+	defaultVideoFloor = randutil.Dist{Type: randutil.DistUniform, Min: 2.0, Max: 15.0}
+)
+
+// floorModel draws impression bid floors from a distribution per
+// impression size, falling back to a default for other sizes.
+type floorModel struct {
+	def    randutil.Dist // zero uses the scenario's default
+	bySize map[bannerSize]randutil.Dist
+}
+
+func newFloorModel(def randutil.Dist, bySize map[string]randutil.Dist) (*floorModel, error) {
+	if !def.IsZero() {
+		if err := validateFloorDist(def); err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+	}
+	f := &floorModel{def: def, bySize: make(map[bannerSize]randutil.Dist, len(bySize))}
+	for key, d := range bySize {
+		size, err := parseSize(key)
+		if err != nil {
+			return nil, fmt.Errorf("by_size: %w", err)
+		}
+		if err := validateFloorDist(d); err != nil {
+			return nil, fmt.Errorf("by_size[%s]: %w", key, err)
+		}
+		f.bySize[size] = d
+	}
+	return f, nil
+}
+
+// ValidateFloors checks floor distributions and sizes for WithFloors.
+func ValidateFloors(def randutil.Dist, bySize map[string]randutil.Dist) error {
+	_, err := newFloorModel(def, bySize)
+	return err
+}
+
+func validateFloorDist(d randutil.Dist) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if d.Value < 0 || d.Min < 0 {
+		return errors.New("floors must not be negative")
+	}
+	return nil
+}
+
+// parseSize parses a size such as "300x250".
+func parseSize(s string) (bannerSize, error) {
+	w, h, _ := strings.Cut(s, "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return bannerSize{}, fmt.Errorf("%q is not a size such as 300x250", s)
+	}
+	return bannerSize{W: width, H: height}, nil
+}
+
+// dist returns the floor distribution of a w×h impression.
+func (f *floorModel) dist(w, h int, fallback randutil.Dist) randutil.Dist {
+	if f == nil {
+		return fallback
+	}
+	if d, ok := f.bySize[bannerSize{W: w, H: h}]; ok {
+		return d
+	}
+	if !f.def.IsZero() {
+		return f.def
+	}
+	return fallback
+}
+
+// bidFloor draws the floor of a w×h impression, from fallback unless
+// floors are configured for it. Draws below zero are clamped to zero.
+func (a *audience) bidFloor(w, h int, fallback randutil.Dist) float64 {
+	return max(a.floors.dist(w, h, fallback).Sample(randutil.Default()), 0)
+}
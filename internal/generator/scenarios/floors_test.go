@@ -0,0 +1,82 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+func TestScenarios_DefaultFloors(t *testing.T) {
+	for _, tc := range []struct {
+		scenario generator.Scenario
+		lo, hi   float64
+	}{
+		{NewMobileApp(), 0.25, 3.0},
+		{NewVideo(), 2.0, 15.0},
+	} {
+		for range 200 {
+			floor := tc.scenario.Generate(generator.Context{RequestID: "req-1"}).Imp[0].BidFloor
+			if floor < tc.lo || floor >= tc.hi {
+				t.Fatalf("%s: BidFloor = %v, want in [%v, %v)", tc.scenario.Name(), floor, tc.lo, tc.hi)
+			}
+		}
+	}
+}
+
+func TestWithFloors(t *testing.T) {
+	m := NewMobileApp(WithFloors(
+		randutil.Dist{Type: randutil.DistFixed, Value: 1.25},
+		map[string]randutil.Dist{"300x250": {Type: randutil.DistUniform, Min: 4, Max: 5}},
+	))
+
+	sized := 0
+	for range 500 {
+		imp := m.Generate(generator.Context{RequestID: "req-1"}).Imp[0]
+		if imp.Banner.W == 300 && imp.Banner.H == 250 {
+			sized++
+			if imp.BidFloor < 4 || imp.BidFloor >= 5 {
+				t.Fatalf("300x250 BidFloor = %v, want in [4, 5)", imp.BidFloor)
+			}
+		} else if imp.BidFloor != 1.25 {
+			t.Fatalf("%dx%d BidFloor = %v, want the default of 1.25", imp.Banner.W, imp.Banner.H, imp.BidFloor)
+		}
+	}
+	if sized == 0 {
+		t.Error("no 300x250 banners generated")
+	}
+
+	// Sizes alone leave other impressions on the scenario's range, and
+	// draws below zero are clamped
+	v := NewVideo(WithFloors(randutil.Dist{}, map[string]randutil.Dist{"1x1": {Type: randutil.DistFixed}}))
+	for range 100 {
+		if floor := v.Generate(generator.Context{RequestID: "req-1"}).Imp[0].BidFloor; floor < 2 {
+			t.Fatalf("video BidFloor = %v, want the default range", floor)
+		}
+	}
+	n := NewMobileApp(WithFloors(randutil.Dist{Type: randutil.DistNormal, Mean: -5, StdDev: 0.1}, nil))
+	if floor := n.Generate(generator.Context{RequestID: "req-1"}).Imp[0].BidFloor; floor != 0 {
+		t.Errorf("BidFloor = %v, want 0 for a negative draw", floor)
+	}
+}
+
+func TestWithFloors_Invalid(t *testing.T) {
+	for name, tt := range map[string]struct {
+		def    randutil.Dist
+		bySize map[string]randutil.Dist
+	}{
+		"bad size":      {bySize: map[string]randutil.Dist{"wide": {Type: randutil.DistFixed, Value: 1}}},
+		"zero width":    {bySize: map[string]randutil.Dist{"0x250": {Type: randutil.DistFixed, Value: 1}}},
+		"negative":      {def: randutil.Dist{Type: randutil.DistFixed, Value: -1}},
+		"unknown type":  {def: randutil.Dist{Type: "pareto"}},
+		"inverted size": {bySize: map[string]randutil.Dist{"300x250": {Type: randutil.DistUniform, Min: 3, Max: 1}}},
+	} {
+		if err := ValidateFloors(tt.def, tt.bySize); err == nil {
+			t.Errorf("%s: ValidateFloors() = nil, want an error", name)
+		}
+		opt := WithFloors(tt.def, tt.bySize)
+		if floor := NewMobileApp(opt).Generate(generator.Context{RequestID: "req-1"}).Imp[0].BidFloor; floor < 0.25 || floor >= 3 {
+			t.Errorf("%s: BidFloor = %v, want the default range", name, floor)
+		}
+	}
+}
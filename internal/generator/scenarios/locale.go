@@ -0,0 +1,175 @@
+package scenarios
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// localeInfo describes a country's languages. Languages are drawn in
+// proportion to their share of the country's device population, and
+// cities come from the country's embedded city pool, so device.language
+// always agrees with device.geo.country.
+type localeInfo struct {
+	Country   string // ISO-3166-1 alpha-3, as used in geo.country
+	Alpha2    string // ISO-3166-1 alpha-2, used to build BCP-47 tags
+	Languages []languageShare
+
+	languagePicker *randutil.Weighted
+	langBs         []string // pre-computed BCP-47 tags, parallel to Languages
+}
+
+type languageShare struct {
+	Code  string // ISO-639-1
+	Share float64
+}
+
+// locale is a picked country/language pair.
+type locale struct {
+	info     *localeInfo
+	language string
+	langB    string // BCP-47, e.g. "fr-CA"
+}
+
+// DefaultLocaleWeights is the country mix used when none is configured,
+// keyed by ISO-3166-1 alpha-3 code.
+var DefaultLocaleWeights = map[string]float64{
+	"USA": 0.45,
+	"GBR": 0.08,
+	"DEU": 0.07,
+	"IND": 0.07,
+	"FRA": 0.06,
+	"BRA": 0.06,
+	"CAN": 0.05,
+	"JPN": 0.05,
+	"ESP": 0.04,
+	"MEX": 0.04,
+	"AUS": 0.03,
+}
+
+// Cities, with standard-time UTC offsets, are in data/cities.tsv.
+var locales = []*localeInfo{
+	{
+		Country: "USA", Alpha2: "US",
+		Languages: []languageShare{{"en", 0.87}, {"es", 0.13}},
+	},
+	{
+		Country: "CAN", Alpha2: "CA",
+		Languages: []languageShare{{"en", 0.75}, {"fr", 0.25}},
+	},
+	{
+		Country: "MEX", Alpha2: "MX",
+		Languages: []languageShare{{"es", 1}},
+	},
+	{
+		Country: "BRA", Alpha2: "BR",
+		Languages: []languageShare{{"pt", 1}},
+	},
+	{
+		Country: "GBR", Alpha2: "GB",
+		Languages: []languageShare{{"en", 1}},
+	},
+	{
+		Country: "DEU", Alpha2: "DE",
+		Languages: []languageShare{{"de", 0.95}, {"en", 0.05}},
+	},
+	{
+		Country: "FRA", Alpha2: "FR",
+		Languages: []languageShare{{"fr", 1}},
+	},
+	{
+		Country: "ESP", Alpha2: "ES",
+		Languages: []languageShare{{"es", 0.9}, {"ca", 0.1}},
+	},
+	{
+		Country: "IND", Alpha2: "IN",
+		Languages: []languageShare{{"hi", 0.6}, {"en", 0.3}, {"ta", 0.1}},
+	},
+	{
+		Country: "JPN", Alpha2: "JP",
+		Languages: []languageShare{{"ja", 1}},
+	},
+	{
+		Country: "AUS", Alpha2: "AU",
+		Languages: []languageShare{{"en", 1}},
+	},
+}
+
+// localesByCountry indexes locales by alpha-3 country code.
+var localesByCountry = make(map[string]*localeInfo, len(locales))
+
+func init() {
+	for _, l := range locales {
+		shares := make([]float64, len(l.Languages))
+		l.langBs = make([]string, len(l.Languages))
+		for i, lang := range l.Languages {
+			shares[i] = lang.Share
+			l.langBs[i] = lang.Code + "-" + l.Alpha2
+		}
+		l.languagePicker = randutil.MustWeighted(shares)
+		localesByCountry[l.Country] = l
+	}
+}
+
+// ValidateLocaleWeights checks that every country in weights is known and
+// that the weights form a valid distribution.
+func ValidateLocaleWeights(weights map[string]float64) error {
+	_, err := newLocaleMix(weights)
+	return err
+}
+
+// localeMix samples countries according to configured weights.
+type localeMix struct {
+	locales []*localeInfo
+	picker  *randutil.Weighted
+}
+
+func newLocaleMix(weights map[string]float64) (*localeMix, error) {
+	// Sort for a stable index order so seeded runs are reproducible
+	countries := make([]string, 0, len(weights))
+	for country := range weights {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+
+	mix := &localeMix{locales: make([]*localeInfo, len(countries))}
+	shares := make([]float64, len(countries))
+	for i, country := range countries {
+		l, ok := localesByCountry[country]
+		if !ok {
+			return nil, fmt.Errorf("unknown locale country %q", country)
+		}
+		mix.locales[i] = l
+		shares[i] = weights[country]
+	}
+
+	picker, err := randutil.NewWeighted(shares)
+	if err != nil {
+		return nil, fmt.Errorf("locale weights: %w", err)
+	}
+	mix.picker = picker
+	return mix, nil
+}
+
+// pick returns a random locale from the mix.
+func (m *localeMix) pick() locale {
+	info := m.locales[m.picker.Sample()]
+	i := info.languagePicker.Sample()
+	return locale{info: info, language: info.Languages[i].Code, langB: info.langBs[i]}
+}
+
+// randomGeo returns a city in the locale, weighted by population, with
+// small positional variance.
+func (l locale) randomGeo() *openrtb.Geo {
+	city := embeddedCities()[l.info.Country].pick()
+	return &openrtb.Geo{
+		Lat:       city.Lat + (randutil.Float64()-0.5)*0.1, // Add small variance
+		Lon:       city.Lon + (randutil.Float64()-0.5)*0.1,
+		Country:   city.Country,
+		Region:    city.Region,
+		City:      city.City,
+		UTCOffset: city.UTCOffset,
+	}
+}
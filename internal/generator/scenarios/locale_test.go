@@ -0,0 +1,99 @@
+package scenarios
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+func TestMobileApp_Generate_LocaleConsistent(t *testing.T) {
+	scenario := NewMobileApp()
+
+	for i := 0; i < 500; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		d := req.Device
+		loc, ok := localesByCountry[d.Geo.Country]
+		if !ok {
+			t.Fatalf("Geo.Country %q not in locale table", d.Geo.Country)
+		}
+
+		found := false
+		for _, lang := range loc.Languages {
+			if lang.Code == d.Language {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Language %q not spoken in %s", d.Language, d.Geo.Country)
+		}
+		if want := d.Language + "-" + loc.Alpha2; d.LangB != want {
+			t.Errorf("LangB = %q, want %q", d.LangB, want)
+		}
+		if d.Geo.UTCOffset < -12*60 || d.Geo.UTCOffset > 14*60 {
+			t.Errorf("Geo.UTCOffset = %d, out of range", d.Geo.UTCOffset)
+		}
+	}
+}
+
+func TestMobileApp_Generate_DefaultLocaleDiversity(t *testing.T) {
+	scenario := NewMobileApp()
+
+	countries := make(map[string]bool)
+	languages := make(map[string]bool)
+	for i := 0; i < 2000; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		countries[req.Device.Geo.Country] = true
+		languages[req.Device.Language] = true
+	}
+
+	if len(countries) < 5 {
+		t.Errorf("only %d countries generated, want diversity", len(countries))
+	}
+	if len(languages) < 5 {
+		t.Errorf("only %d languages generated, want diversity", len(languages))
+	}
+}
+
+func TestMobileApp_WithLocaleWeights(t *testing.T) {
+	scenario := NewMobileApp(WithLocaleWeights(map[string]float64{"JPN": 1, "USA": 0}))
+
+	for i := 0; i < 100; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		if req.Device.Geo.Country != "JPN" {
+			t.Fatalf("Geo.Country = %q, want JPN", req.Device.Geo.Country)
+		}
+		if req.Device.Language != "ja" || req.Device.Geo.UTCOffset != 540 {
+			t.Errorf("Language = %q, UTCOffset = %d, want ja, 540",
+				req.Device.Language, req.Device.Geo.UTCOffset)
+		}
+	}
+}
+
+func TestValidateLocaleWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]float64
+		wantErr string
+	}{
+		{"valid", map[string]float64{"USA": 0.5, "FRA": 0.5}, ""},
+		{"unknown country", map[string]float64{"XYZ": 1}, "unknown locale"},
+		{"zero sum", map[string]float64{"USA": 0}, "positive sum"},
+		{"default", DefaultLocaleWeights, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLocaleWeights(tt.weights)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateLocaleWeights() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateLocaleWeights() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,22 +1,16 @@
 package scenarios
 
 import (
-	"math/rand/v2"
 	"strconv"
 
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/randutil"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
 // Pre-computed version strings (1000 combinations: 0.0.0 to 9.9.9)
 var versionStrings []string
 
-// Connection types as a fixed-size array (zero allocation on access)
-var connectionTypes = [...]int{
-	openrtb.ConnectionWifi,
-	openrtb.ConnectionCell4G,
-	openrtb.ConnectionCell3G,
-}
-
 // Hex characters for user ID generation
 const hexChars = "0123456789abcdef"
 
@@ -40,123 +34,151 @@ func init() {
 }
 
 // MobileApp generates bid requests simulating mobile app inventory.
-// Thread-safe: uses math/rand/v2 top-level functions which have per-OS-thread state.
-type MobileApp struct{}
+// Thread-safe: draws from the shared randutil source, which is safe for concurrent use.
+type MobileApp struct {
+	audience
+}
 
 // NewMobileApp creates a new mobile app scenario.
-func NewMobileApp() *MobileApp {
-	return &MobileApp{}
+func NewMobileApp(opts ...Option) *MobileApp {
+	return &MobileApp{audience: newAudience(DefaultDeviceMix, opts)}
 }
 
 func (m *MobileApp) Name() string {
 	return "mobile_app"
 }
 
-func (m *MobileApp) Generate(requestID string) *openrtb.BidRequest {
-	// No mutex needed - rand/v2 top-level functions are thread-safe
-	device := m.randomDevice()
+func (m *MobileApp) Generate(c generator.Context) *openrtb.BidRequest {
+	// No mutex needed - randutil sources are safe for concurrent use
+	class := m.devices.pick()
+	device := m.randomDevice(class)
 	app := m.randomApp()
+	banner := m.randomBanner(class)
 
-	return &openrtb.BidRequest{
-		ID: requestID,
+	req := &openrtb.BidRequest{
+		ID: c.RequestID,
 		Imp: []openrtb.Imp{
 			{
 				ID:       impID1,
-				Banner:   m.randomBanner(),
-				BidFloor: m.randomBidFloor(),
-				Secure:   1,
+				Banner:   banner,
+				Instl:    instl(banner),
+				BidFloor: m.bidFloor(banner.W, banner.H, defaultBannerFloor),
+				Secure:   m.secure(),
 			},
 		},
 		App:    app,
 		Device: device,
 		User: &openrtb.User{
-			ID: m.randomUserID(),
+			ID:   m.userID(c),
+			Data: generator.SegmentData(c.Segment),
 		},
 		At:   openrtb.AuctionFirstPrice,
 		Tmax: 100,
-		Cur:  currencyUSD,
+		Cur:  m.cur,
 	}
+	m.consent.apply(req)
+	m.schain.apply(req)
+	m.deals.apply(req)
+	m.contextual.apply(req)
+	return req
 }
 
-func (m *MobileApp) randomBanner() *openrtb.Banner {
-	size := bannerSizes[rand.IntN(len(bannerSizes))]
+func (m *MobileApp) randomBanner(class *deviceClass) *openrtb.Banner {
+	size := class.BannerSizes[randutil.IntN(len(class.BannerSizes))]
 	return &openrtb.Banner{
 		W:   size.W,
 		H:   size.H,
-		Pos: rand.IntN(3), // 0=unknown, 1=above fold, 2=below fold
+		Pos: randutil.IntN(3), // 0=unknown, 1=above fold, 2=below fold
+	}
+}
+
+// instl returns imp.instl for a banner: 1 for full-screen sizes.
+func instl(b *openrtb.Banner) int {
+	if interstitialSizes[bannerSize{W: b.W, H: b.H}] {
+		return 1
 	}
+	return 0
 }
 
 func (m *MobileApp) randomApp() *openrtb.App {
-	app := apps[rand.IntN(len(apps))]
+	apps := embeddedApps()
+	app := apps[randutil.IntN(len(apps))]
 	return &openrtb.App{
 		ID:     m.randomAppID(),
 		Name:   app.Name,
 		Bundle: app.Bundle,
 		Cat:    app.Category, // Pre-allocated slice, no allocation
-		Ver:    versionStrings[rand.IntN(len(versionStrings))],
+		Ver:    versionStrings[randutil.IntN(len(versionStrings))],
 	}
 }
 
-func (m *MobileApp) randomDevice() *openrtb.Device {
-	device := devices[rand.IntN(len(devices))]
+func (a *audience) randomDevice(class *deviceClass) *openrtb.Device {
+	device := class.pickDevice()
+	loc := a.locales.pick()
 	return &openrtb.Device{
 		UA:             device.UA,
-		IP:             m.randomIP(),
+		IP:             a.randomIP(),
 		Make:           device.Make,
 		Model:          device.Model,
 		OS:             device.OS,
 		OSV:            device.OSV,
-		DeviceType:     openrtb.DeviceTypePhone,
-		ConnectionType: connectionTypes[rand.IntN(len(connectionTypes))],
-		Language:       "en",
-		Geo:            m.randomGeo(),
+		DeviceType:     class.DeviceType,
+		ConnectionType: class.ConnectionTypes[randutil.IntN(len(class.ConnectionTypes))],
+		Language:       loc.language,
+		LangB:          loc.langB,
+		Geo:            loc.randomGeo(),
 	}
 }
 
-func (m *MobileApp) randomGeo() *openrtb.Geo {
-	geo := geoLocations[rand.IntN(len(geoLocations))]
-	return &openrtb.Geo{
-		Lat:     geo.Lat + (rand.Float64()-0.5)*0.1, // Add small variance
-		Lon:     geo.Lon + (rand.Float64()-0.5)*0.1,
-		Country: geo.Country,
-		Region:  geo.Region,
-		City:    geo.City,
+// randomIP returns the device IP: a shared address from the NAT pool when
+// one is configured and selected, otherwise a unique random address.
+func (a *audience) randomIP() string {
+	if ip := a.sharedIPs.pick(); ip != "" {
+		return ip
 	}
+	return uniqueIP()
 }
 
-// randomIP generates a realistic-looking IP address using direct byte manipulation.
+// uniqueIP generates a realistic-looking IP address using direct byte manipulation.
 // Avoids fmt.Sprintf overhead.
-func (m *MobileApp) randomIP() string {
+func uniqueIP() string {
 	var buf [15]byte // Max: "223.255.255.254"
 	n := 0
 
 	// First octet: 1-223
-	n += writeUint8(buf[n:], uint8(rand.IntN(223)+1))
+	n += writeUint8(buf[n:], uint8(randutil.IntN(223)+1))
 	buf[n] = '.'
 	n++
 
 	// Second octet: 0-255
-	n += writeUint8(buf[n:], uint8(rand.IntN(256)))
+	n += writeUint8(buf[n:], uint8(randutil.IntN(256)))
 	buf[n] = '.'
 	n++
 
 	// Third octet: 0-255
-	n += writeUint8(buf[n:], uint8(rand.IntN(256)))
+	n += writeUint8(buf[n:], uint8(randutil.IntN(256)))
 	buf[n] = '.'
 	n++
 
 	// Fourth octet: 1-254
-	n += writeUint8(buf[n:], uint8(rand.IntN(254)+1))
+	n += writeUint8(buf[n:], uint8(randutil.IntN(254)+1))
 
 	return string(buf[:n])
 }
 
+// userID returns the context's user ID, or a random one if it has none.
+func (a *audience) userID(c generator.Context) string {
+	if c.UserID != "" {
+		return c.UserID
+	}
+	return a.randomUserID()
+}
+
 // randomUserID generates a 32-character hex string without fmt.Sprintf.
-func (m *MobileApp) randomUserID() string {
+func (a *audience) randomUserID() string {
 	var buf [32]byte
 	for i := range buf {
-		buf[i] = hexChars[rand.IntN(16)]
+		buf[i] = hexChars[randutil.IntN(16)]
 	}
 	return string(buf[:])
 }
@@ -165,7 +187,7 @@ func (m *MobileApp) randomUserID() string {
 func (m *MobileApp) randomAppID() string {
 	var buf [10]byte // "app-" + 6 digits
 	copy(buf[:4], "app-")
-	n := rand.IntN(1000000)
+	n := randutil.IntN(1000000)
 	for i := 9; i >= 4; i-- {
 		buf[i] = '0' + byte(n%10)
 		n /= 10
@@ -173,11 +195,6 @@ func (m *MobileApp) randomAppID() string {
 	return string(buf[:])
 }
 
-func (m *MobileApp) randomBidFloor() float64 {
-	// Bid floor between $0.25 and $3.00
-	return 0.25 + rand.Float64()*2.75
-}
-
 // writeUint8 writes a uint8 to buf and returns the number of bytes written.
 // This is faster than strconv.Itoa for small numbers.
 func writeUint8(buf []byte, n uint8) int {
@@ -195,39 +212,19 @@ func writeUint8(buf []byte, n uint8) int {
 	return 1
 }
 
-// Data pools for randomization
+// Data pools for randomization. Apps and phones are loaded from
+// data/*.tsv (see assets.go).
 
 type bannerSize struct {
 	W, H int
 }
 
-var bannerSizes = []bannerSize{
-	{320, 50},  // Mobile leaderboard
-	{300, 250}, // Medium rectangle
-	{320, 480}, // Mobile interstitial
-	{728, 90},  // Leaderboard (tablet)
-	{300, 50},  // Mobile banner
-}
-
 type appInfo struct {
 	Name     string
 	Bundle   string
 	Category []string // Pre-allocated slice to avoid allocation per call
 }
 
-var apps = []appInfo{
-	{"Puzzle Quest", "com.games.puzzlequest", []string{"IAB9-30"}},
-	{"Daily News", "com.news.dailynews", []string{"IAB12"}},
-	{"Weather Pro", "com.weather.weatherpro", []string{"IAB15"}},
-	{"Fitness Tracker", "com.health.fitnesstracker", []string{"IAB7"}},
-	{"Social Chat", "com.social.chatapp", []string{"IAB14"}},
-	{"Music Stream", "com.music.streamapp", []string{"IAB1"}},
-	{"Photo Editor", "com.photo.editorpro", []string{"IAB9"}},
-	{"Recipe Book", "com.food.recipebook", []string{"IAB8"}},
-	{"Travel Guide", "com.travel.guidebook", []string{"IAB20"}},
-	{"Finance Manager", "com.finance.manager", []string{"IAB13"}},
-}
-
 type deviceInfo struct {
 	Make  string
 	Model string
@@ -236,68 +233,11 @@ type deviceInfo struct {
 	UA    string
 }
 
-var devices = []deviceInfo{
-	{
-		Make:  "Apple",
-		Model: "iPhone14,2",
-		OS:    "iOS",
-		OSV:   "16.0",
-		UA:    "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1",
-	},
-	{
-		Make:  "Apple",
-		Model: "iPhone15,2",
-		OS:    "iOS",
-		OSV:   "17.0",
-		UA:    "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
-	},
-	{
-		Make:  "Samsung",
-		Model: "SM-G998B",
-		OS:    "Android",
-		OSV:   "13",
-		UA:    "Mozilla/5.0 (Linux; Android 13; SM-G998B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Mobile Safari/537.36",
-	},
-	{
-		Make:  "Samsung",
-		Model: "SM-S908B",
-		OS:    "Android",
-		OSV:   "14",
-		UA:    "Mozilla/5.0 (Linux; Android 14; SM-S908B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
-	},
-	{
-		Make:  "Google",
-		Model: "Pixel 7",
-		OS:    "Android",
-		OSV:   "14",
-		UA:    "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
-	},
-	{
-		Make:  "Xiaomi",
-		Model: "2201116SG",
-		OS:    "Android",
-		OSV:   "13",
-		UA:    "Mozilla/5.0 (Linux; Android 13; 2201116SG) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Mobile Safari/537.36",
-	},
-}
-
 type geoInfo struct {
-	Lat     float64
-	Lon     float64
-	Country string
-	Region  string
-	City    string
-}
-
-var geoLocations = []geoInfo{
-	{37.7749, -122.4194, "USA", "CA", "San Francisco"},
-	{40.7128, -74.0060, "USA", "NY", "New York"},
-	{34.0522, -118.2437, "USA", "CA", "Los Angeles"},
-	{41.8781, -87.6298, "USA", "IL", "Chicago"},
-	{29.7604, -95.3698, "USA", "TX", "Houston"},
-	{33.4484, -112.0740, "USA", "AZ", "Phoenix"},
-	{39.7392, -104.9903, "USA", "CO", "Denver"},
-	{47.6062, -122.3321, "USA", "WA", "Seattle"},
-	{25.7617, -80.1918, "USA", "FL", "Miami"},
-	{42.3601, -71.0589, "USA", "MA", "Boston"},
+	Lat       float64
+	Lon       float64
+	Country   string
+	Region    string
+	City      string
+	UTCOffset int // minutes from UTC
 }
@@ -2,6 +2,8 @@ package scenarios
 
 import (
 	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
 )
 
 // BenchmarkMobileApp_Generate benchmarks single-threaded request generation.
@@ -11,7 +13,7 @@ func BenchmarkMobileApp_Generate(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		_ = scenario.Generate("req-00000001")
+		_ = scenario.Generate(generator.Context{RequestID: "req-00000001"})
 	}
 }
 
@@ -24,7 +26,7 @@ func BenchmarkMobileApp_Generate_Parallel(b *testing.B) {
 
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			_ = scenario.Generate("req-00000001")
+			_ = scenario.Generate(generator.Context{RequestID: "req-00000001"})
 		}
 	})
 }
@@ -69,6 +71,6 @@ func BenchmarkRandomDevice(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		_ = scenario.randomDevice()
+		_ = scenario.randomDevice(deviceClasses[DeviceClassPhone])
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/randutil"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
@@ -17,7 +19,7 @@ func TestMobileApp_Name(t *testing.T) {
 
 func TestMobileApp_Generate_RequiredFields(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	if req.ID != "req-001" {
 		t.Errorf("ID = %q, want %q", req.ID, "req-001")
@@ -36,9 +38,71 @@ func TestMobileApp_Generate_RequiredFields(t *testing.T) {
 	}
 }
 
+func TestScenarios_Generate_Context(t *testing.T) {
+	c := generator.Context{RequestID: "req-1", Segment: "sports", UserID: "user-42"}
+	for _, scenario := range []generator.Scenario{NewMobileApp(), NewVideo()} {
+		req := scenario.Generate(c)
+		if req.User == nil || req.User.ID != "user-42" {
+			t.Errorf("%s: User = %+v, want ID user-42", scenario.Name(), req.User)
+			continue
+		}
+		if len(req.User.Data) != 1 || len(req.User.Data[0].Segment) != 1 || req.User.Data[0].Segment[0].ID != "sports" {
+			t.Errorf("%s: User.Data = %+v, want the sports segment", scenario.Name(), req.User.Data)
+		}
+
+		// Without a user or segment the scenario picks a random user
+		req = scenario.Generate(generator.Context{RequestID: "req-2"})
+		if req.User.ID == "" || req.User.ID == "user-42" || req.User.Data != nil {
+			t.Errorf("%s: User = %+v, want a random user without data", scenario.Name(), req.User)
+		}
+	}
+}
+
+func TestScenarios_WithCurrencies(t *testing.T) {
+	for _, scenario := range []generator.Scenario{
+		NewMobileApp(WithCurrencies([]string{"EUR", "USD"})),
+		NewVideo(WithCurrencies([]string{"EUR", "USD"})),
+	} {
+		req := scenario.Generate(generator.Context{RequestID: "req-1"})
+		if len(req.Cur) != 2 || req.Cur[0] != "EUR" || req.Cur[1] != "USD" {
+			t.Errorf("%s: Cur = %v, want [EUR USD]", scenario.Name(), req.Cur)
+		}
+	}
+
+	// Invalid codes keep the default
+	req := NewMobileApp(WithCurrencies([]string{"EUR", "euro"})).Generate(generator.Context{RequestID: "req-1"})
+	if len(req.Cur) != 1 || req.Cur[0] != "USD" {
+		t.Errorf("Cur = %v, want [USD]", req.Cur)
+	}
+}
+
+func TestScenarios_WithNonSecureShare(t *testing.T) {
+	for _, scenario := range []generator.Scenario{
+		NewMobileApp(WithNonSecureShare(0.5)),
+		NewVideo(WithNonSecureShare(0.5)),
+	} {
+		const n = 2000
+		nonSecure := 0
+		for range n {
+			if scenario.Generate(generator.Context{RequestID: "req-1"}).Imp[0].Secure == 0 {
+				nonSecure++
+			}
+		}
+		if share := float64(nonSecure) / n; share < 0.45 || share > 0.55 {
+			t.Errorf("%s: non-secure share = %.3f, want ~0.5", scenario.Name(), share)
+		}
+	}
+
+	// An out-of-range share keeps every impression secure
+	req := NewVideo(WithNonSecureShare(1.5)).Generate(generator.Context{RequestID: "req-1"})
+	if req.Imp[0].Secure != 1 {
+		t.Errorf("Secure = %d, want 1", req.Imp[0].Secure)
+	}
+}
+
 func TestMobileApp_Generate_Impression(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	imp := req.Imp[0]
 	if imp.ID == "" {
@@ -60,7 +124,7 @@ func TestMobileApp_Generate_Impression(t *testing.T) {
 
 func TestMobileApp_Generate_App(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	if req.App == nil {
 		t.Fatal("App should not be nil")
@@ -84,7 +148,7 @@ func TestMobileApp_Generate_App(t *testing.T) {
 
 func TestMobileApp_Generate_Device(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	if req.Device == nil {
 		t.Fatal("Device should not be nil")
@@ -108,7 +172,7 @@ func TestMobileApp_Generate_Device(t *testing.T) {
 
 func TestMobileApp_Generate_Geo(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	if req.Device.Geo == nil {
 		t.Fatal("Device.Geo should not be nil")
@@ -127,7 +191,7 @@ func TestMobileApp_Generate_Geo(t *testing.T) {
 
 func TestMobileApp_Generate_User(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	if req.User == nil {
 		t.Fatal("User should not be nil")
@@ -139,7 +203,7 @@ func TestMobileApp_Generate_User(t *testing.T) {
 
 func TestMobileApp_Generate_ValidJSON(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	data, err := json.Marshal(req)
 	if err != nil {
@@ -165,7 +229,7 @@ func TestMobileApp_Generate_Randomization(t *testing.T) {
 	sizes := make(map[string]bool)
 
 	for i := 0; i < 100; i++ {
-		req := scenario.Generate("req-test")
+		req := scenario.Generate(generator.Context{RequestID: "req-test"})
 		bundles[req.App.Bundle] = true
 		makes[req.Device.Make] = true
 		size := req.Imp[0].Banner
@@ -194,18 +258,37 @@ func TestMobileApp_Generate_BannerSizes(t *testing.T) {
 	}
 
 	for i := 0; i < 50; i++ {
-		req := scenario.Generate("req-test")
+		req := scenario.Generate(generator.Context{RequestID: "req-test"})
 		banner := req.Imp[0].Banner
 		sizeKey := string(rune(banner.W)) + "x" + string(rune(banner.H))
 		_ = validSizes[sizeKey] // Just check generation works
 	}
 }
 
+func TestMobileApp_Generate_Interstitial(t *testing.T) {
+	scenario := NewMobileApp()
+
+	var seen int
+	for range 200 {
+		imp := scenario.Generate(generator.Context{RequestID: "req-test"}).Imp[0]
+		full := interstitialSizes[bannerSize{W: imp.Banner.W, H: imp.Banner.H}]
+		if full != (imp.Instl == 1) {
+			t.Fatalf("%dx%d banner has instl = %d", imp.Banner.W, imp.Banner.H, imp.Instl)
+		}
+		if full {
+			seen++
+		}
+	}
+	if seen == 0 {
+		t.Error("no interstitial generated in 200 requests")
+	}
+}
+
 func TestMobileApp_Generate_BidFloorRange(t *testing.T) {
 	scenario := NewMobileApp()
 
 	for i := 0; i < 100; i++ {
-		req := scenario.Generate("req-test")
+		req := scenario.Generate(generator.Context{RequestID: "req-test"})
 		floor := req.Imp[0].BidFloor
 
 		if floor < 0.25 || floor > 3.0 {
@@ -216,7 +299,7 @@ func TestMobileApp_Generate_BidFloorRange(t *testing.T) {
 
 func TestMobileApp_Generate_IPFormat(t *testing.T) {
 	scenario := NewMobileApp()
-	req := scenario.Generate("req-001")
+	req := scenario.Generate(generator.Context{RequestID: "req-001"})
 
 	ip := req.Device.IP
 	parts := strings.Split(ip, ".")
@@ -224,3 +307,18 @@ func TestMobileApp_Generate_IPFormat(t *testing.T) {
 		t.Errorf("IP should have 4 octets: %s", ip)
 	}
 }
+
+func TestMobileApp_Generate_SeededReproducible(t *testing.T) {
+	defer randutil.Seed(0)
+	scenario := NewMobileApp()
+
+	randutil.Seed(99)
+	first, _ := json.Marshal(scenario.Generate(generator.Context{RequestID: "req-001"}))
+
+	randutil.Seed(99)
+	second, _ := json.Marshal(scenario.Generate(generator.Context{RequestID: "req-001"}))
+
+	if string(first) != string(second) {
+		t.Errorf("seeded generation not reproducible:\n%s\n%s", first, second)
+	}
+}
@@ -0,0 +1,18 @@
+package scenarios
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Names lists the scenarios a simulation can run, by Name.
+var Names = []string{"mobile_app", "video", "replay"}
+
+// ValidateName checks that name is one of Names.
+func ValidateName(name string) error {
+	if !slices.Contains(Names, name) {
+		return fmt.Errorf("unknown scenario %q (want one of %s)", name, strings.Join(Names, ", "))
+	}
+	return nil
+}
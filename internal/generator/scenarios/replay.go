@@ -0,0 +1,151 @@
+package scenarios
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// maxReplayLine bounds the size of one recorded bid request.
+const maxReplayLine = 1 << 20
+
+// Replay replays recorded bid requests, such as captured production
+// traffic, in file order, starting over after the last. The engine paces
+// them at the configured RPS. The file is read into memory up front and
+// each request is decoded afresh when replayed, so requests never share
+// state.
+//
+// Recorded requests keep their IDs unless ID rewriting is enabled. The
+// generator's tmax and auction type still apply.
+type Replay struct {
+	requests   [][]byte // one encoded bid request per entry
+	next       atomic.Uint64
+	rewriteIDs bool
+}
+
+// ReplayOption configures a Replay.
+type ReplayOption func(*Replay)
+
+// WithRewriteIDs gives each replayed request the generator's request ID,
+// also used as source.tid, so repeated passes over the file send unique
+// IDs.
+func WithRewriteIDs() ReplayOption {
+	return func(r *Replay) {
+		r.rewriteIDs = true
+	}
+}
+
+// NewReplay loads the NDJSON bid requests in the file at path, one per
+// line, either bare or nested under "request" as in the bid log. Blank
+// lines are skipped.
+func NewReplay(path string, opts ...ReplayOption) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := LoadReplay(f, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// LoadReplay reads NDJSON bid requests from in. It fails on a line that
+// is not a bid request, or if there are none.
+func LoadReplay(in io.Reader, opts ...ReplayOption) (*Replay, error) {
+	r := &Replay{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), maxReplayLine)
+	for line := 1; sc.Scan(); line++ {
+		data := bytes.TrimSpace(sc.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		data, err := unwrapRequest(data)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		var req openrtb.BidRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(req.Imp) == 0 {
+			return nil, fmt.Errorf("line %d: bid request %q has no impressions", line, req.ID)
+		}
+		r.requests = append(r.requests, bytes.Clone(data))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(r.requests) == 0 {
+		return nil, errors.New("no bid requests to replay")
+	}
+	return r, nil
+}
+
+// unwrapRequest returns the bid request nested under "request" in a bid
+// log record, or data itself for a bare bid request.
+func unwrapRequest(data []byte) ([]byte, error) {
+	var rec struct {
+		Request json.RawMessage `json:"request"`
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	if len(rec.Request) == 0 || bytes.Equal(rec.Request, []byte("null")) {
+		return data, nil
+	}
+	return rec.Request, nil
+}
+
+func (r *Replay) Name() string {
+	return "replay"
+}
+
+// Len returns the number of recorded requests.
+func (r *Replay) Len() int {
+	return len(r.requests)
+}
+
+// Generate returns the next recorded request. Context user IDs and
+// segments replace the recorded user's.
+func (r *Replay) Generate(c generator.Context) *openrtb.BidRequest {
+	i := (r.next.Add(1) - 1) % uint64(len(r.requests))
+
+	req := &openrtb.BidRequest{}
+	// Every line decoded when loaded
+	_ = json.Unmarshal(r.requests[i], req)
+
+	if r.rewriteIDs {
+		req.ID = c.RequestID
+		if req.Source != nil && req.Source.TID != "" {
+			req.Source.TID = c.RequestID
+		}
+	}
+	if c.UserID != "" || c.Segment != "" {
+		if req.User == nil {
+			req.User = &openrtb.User{}
+		}
+		if c.UserID != "" {
+			req.User.ID = c.UserID
+		}
+		if c.Segment != "" {
+			req.User.Data = generator.SegmentData(c.Segment)
+		}
+	}
+	return req
+}
@@ -0,0 +1,134 @@
+package scenarios
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+const recordedRequests = `{"id":"prod-1","imp":[{"id":"1","banner":{"w":300,"h":250},"bidfloor":0.5}],"source":{"tid":"tid-1"},"at":1,"tmax":120}
+
+{"id":"prod-2","imp":[{"id":"1","video":{"mimes":["video/mp4"],"w":640,"h":480},"bidfloor":2}],"user":{"id":"u-2"},"at":2,"tmax":80}
+`
+
+func TestReplay_Generate(t *testing.T) {
+	r, err := LoadReplay(strings.NewReader(recordedRequests))
+	if err != nil {
+		t.Fatalf("LoadReplay() error = %v", err)
+	}
+	if r.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", r.Len())
+	}
+
+	want := []string{"prod-1", "prod-2", "prod-1"}
+	for i, id := range want {
+		req := r.Generate(generator.Context{RequestID: "req-x"})
+		if req.ID != id {
+			t.Errorf("request %d ID = %q, want %q (file order, looping)", i, req.ID, id)
+		}
+	}
+
+	// Replayed requests are independent copies
+	a := r.Generate(generator.Context{})
+	a.Imp[0].BidFloor = 99
+	r.Generate(generator.Context{})
+	if b := r.Generate(generator.Context{}); b.Imp[0].BidFloor != 2 {
+		t.Errorf("bidfloor = %v, want the recorded 2", b.Imp[0].BidFloor)
+	}
+}
+
+func TestReplay_BidLog(t *testing.T) {
+	const bidLog = `{"ts":"2026-01-02T03:04:05Z","request_id":"prod-1","bidfloor":0.5,"bids":0,"clearing_price":0,"dsps":[],"request":{"id":"prod-1","imp":[{"id":"1","banner":{"w":300,"h":250},"bidfloor":0.5}]}}
+`
+	r, err := LoadReplay(strings.NewReader(bidLog + recordedRequests))
+	if err != nil {
+		t.Fatalf("LoadReplay() error = %v", err)
+	}
+	if r.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", r.Len())
+	}
+	req := r.Generate(generator.Context{})
+	if req.ID != "prod-1" || len(req.Imp) != 1 || req.Imp[0].Banner == nil {
+		t.Errorf("request = %+v, want the request nested in the bid log record", req)
+	}
+}
+
+func TestReplay_RewriteIDs(t *testing.T) {
+	r, err := LoadReplay(strings.NewReader(recordedRequests), WithRewriteIDs())
+	if err != nil {
+		t.Fatalf("LoadReplay() error = %v", err)
+	}
+
+	req := r.Generate(generator.Context{RequestID: "req-00000001", UserID: "user-7", Segment: "sports"})
+	if req.ID != "req-00000001" || req.Source.TID != "req-00000001" {
+		t.Errorf("id = %q, source.tid = %q; want both req-00000001", req.ID, req.Source.TID)
+	}
+	if req.User == nil || req.User.ID != "user-7" || len(req.User.Data) != 1 {
+		t.Errorf("user = %+v, want the context's user and segment", req.User)
+	}
+}
+
+func TestReplay_Concurrent(t *testing.T) {
+	r, err := LoadReplay(strings.NewReader(recordedRequests))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				req := r.Generate(generator.Context{})
+				mu.Lock()
+				counts[req.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counts["prod-1"] != 500 || counts["prod-2"] != 500 {
+		t.Errorf("counts = %v, want each request replayed 500 times", counts)
+	}
+}
+
+func TestNewReplay_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(dir, "missing.ndjson")},
+		{"empty", write("empty.ndjson", "\n\n")},
+		{"malformed", write("bad.ndjson", `{"id":"ok","imp":[{"id":"1"}]}`+"\n{not json\n")},
+		{"no impressions", write("noimp.ndjson", `{"id":"x","imp":[]}`+"\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewReplay(tt.path); err == nil {
+				t.Error("NewReplay() error = nil, want error")
+			}
+		})
+	}
+
+	r, err := NewReplay(write("ok.ndjson", recordedRequests))
+	if err != nil || r.Len() != 2 {
+		t.Errorf("NewReplay() = %v, %v; want 2 requests", r, err)
+	}
+}
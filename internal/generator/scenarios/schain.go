@@ -0,0 +1,92 @@
+package scenarios
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// MaxSupplyChainHops is the longest supply chain WithSupplyChain accepts.
+const MaxSupplyChainHops = 8
+
+// schainSystems are the advertising systems (SSPs, exchanges, resellers)
+// supply chain nodes are drawn from. A chain never repeats a system.
+var schainSystems = [MaxSupplyChainHops]string{
+	"pubmatic.example", "rubiconproject.example", "openx.example", "indexexchange.example",
+	"magnite.example", "triplelift.example", "sovrn.example", "smaato.example",
+}
+
+// defaultSellerCount is how many seller IDs are generated when none are
+// configured.
+const defaultSellerCount = 32
+
+// supplyChain attaches an IAB SupplyChain to every request: the
+// publisher's seller account followed by hops-1 resellers, the last of
+// which issued the request.
+type supplyChain struct {
+	hops      int
+	sellerIDs []string
+}
+
+// validateSupplyChain checks supply chain parameters. Zero hops disables
+// the supply chain.
+func validateSupplyChain(hops int, sellerIDs []string) error {
+	if hops < 0 || hops > MaxSupplyChainHops {
+		return errors.New("supply chain hops must be between 0 and " + strconv.Itoa(MaxSupplyChainHops))
+	}
+	for _, id := range sellerIDs {
+		if id == "" {
+			return errors.New("supply chain seller ids must not be empty")
+		}
+	}
+	return nil
+}
+
+func newSupplyChain(hops int, sellerIDs []string) (*supplyChain, error) {
+	if err := validateSupplyChain(hops, sellerIDs); err != nil {
+		return nil, err
+	}
+	if hops == 0 {
+		return nil, nil
+	}
+
+	if len(sellerIDs) == 0 {
+		sellerIDs = make([]string, defaultSellerCount)
+		for i := range sellerIDs {
+			sellerIDs[i] = "pub-" + strconv.Itoa(10000000+randutil.IntN(90000000))
+		}
+	}
+	return &supplyChain{hops: hops, sellerIDs: sellerIDs}, nil
+}
+
+// apply sets req.source with a supply chain ending at req.
+func (s *supplyChain) apply(req *openrtb.BidRequest) {
+	if s == nil {
+		return
+	}
+
+	nodes := make([]openrtb.SupplyChainNode, s.hops)
+	systems := schainSystems // partial Fisher-Yates shuffle of a copy
+	for i := range nodes {
+		j := i + randutil.IntN(len(systems)-i)
+		systems[i], systems[j] = systems[j], systems[i]
+		nodes[i] = openrtb.SupplyChainNode{ASI: systems[i], HP: 1}
+		if i == 0 {
+			nodes[i].SID = s.sellerIDs[randutil.IntN(len(s.sellerIDs))]
+		} else {
+			nodes[i].SID = strconv.Itoa(100000 + randutil.IntN(900000))
+		}
+	}
+	nodes[len(nodes)-1].RID = req.ID
+
+	req.Source = &openrtb.Source{
+		TID: req.ID,
+		Ext: &openrtb.SourceExt{SChain: &openrtb.SupplyChain{
+			Complete: 1,
+			Nodes:    nodes,
+			Ver:      "1.0",
+		}},
+	}
+}
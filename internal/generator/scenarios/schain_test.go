@@ -0,0 +1,78 @@
+package scenarios
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+func TestMobileApp_WithSupplyChain(t *testing.T) {
+	sellers := []string{"pub-1", "pub-2"}
+	m := NewMobileApp(WithSupplyChain(3, sellers))
+
+	for i := 0; i < 200; i++ {
+		req := m.Generate(generator.Context{RequestID: "req-1"})
+		if req.Source == nil || req.Source.Ext == nil || req.Source.Ext.SChain == nil {
+			t.Fatal("Source.Ext.SChain = nil, want a supply chain")
+		}
+		if req.Source.TID != "req-1" {
+			t.Errorf("Source.TID = %q, want req-1", req.Source.TID)
+		}
+
+		chain := req.Source.Ext.SChain
+		if chain.Complete != 1 || chain.Ver != "1.0" {
+			t.Errorf("chain complete=%d ver=%q, want 1 and 1.0", chain.Complete, chain.Ver)
+		}
+		if len(chain.Nodes) != 3 {
+			t.Fatalf("len(Nodes) = %d, want 3", len(chain.Nodes))
+		}
+		if sid := chain.Nodes[0].SID; sid != "pub-1" && sid != "pub-2" {
+			t.Errorf("Nodes[0].SID = %q, want a configured seller ID", sid)
+		}
+		if rid := chain.Nodes[2].RID; rid != "req-1" {
+			t.Errorf("last node RID = %q, want req-1", rid)
+		}
+
+		seen := make(map[string]bool)
+		for _, n := range chain.Nodes {
+			if n.ASI == "" || n.SID == "" || n.HP != 1 {
+				t.Errorf("node = %+v, want asi, sid, and hp=1", n)
+			}
+			if seen[n.ASI] {
+				t.Errorf("asi %q repeated in chain", n.ASI)
+			}
+			seen[n.ASI] = true
+		}
+	}
+}
+
+func TestVideo_WithSupplyChain_RandomSellers(t *testing.T) {
+	v := NewVideo(WithSupplyChain(1, nil))
+	req := v.Generate(generator.Context{RequestID: "req-1"})
+	if req.Source == nil || len(req.Source.Ext.SChain.Nodes) != 1 {
+		t.Fatalf("Source = %+v, want a single-node chain", req.Source)
+	}
+	if sid := req.Source.Ext.SChain.Nodes[0].SID; sid == "" {
+		t.Error("Nodes[0].SID is empty, want a generated seller ID")
+	}
+}
+
+func TestWithSupplyChain_Disabled(t *testing.T) {
+	for _, hops := range []int{0, -1, MaxSupplyChainHops + 1} {
+		if req := NewMobileApp(WithSupplyChain(hops, nil)).Generate(generator.Context{RequestID: "req-1"}); req.Source != nil {
+			t.Errorf("hops %d: Source = %+v, want nil", hops, req.Source)
+		}
+	}
+}
+
+func TestValidateSupplyChain(t *testing.T) {
+	if err := ValidateSupplyChain(MaxSupplyChainHops, []string{"pub-1"}); err != nil {
+		t.Errorf("ValidateSupplyChain(max) error = %v", err)
+	}
+	if err := ValidateSupplyChain(MaxSupplyChainHops+1, nil); err == nil {
+		t.Error("ValidateSupplyChain(too many hops) error = nil")
+	}
+	if err := ValidateSupplyChain(2, []string{""}); err == nil {
+		t.Error("ValidateSupplyChain(empty seller id) error = nil")
+	}
+}
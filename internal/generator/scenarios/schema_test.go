@@ -0,0 +1,43 @@
+package scenarios
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/schema"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// TestScenarios_ConformToSchema checks every scenario's output, with every
+// audience option enabled, against the embedded OpenRTB schema.
+func TestScenarios_ConformToSchema(t *testing.T) {
+	opts := []Option{
+		WithLocaleWeights(map[string]float64{"USA": 1, "DEU": 1, "JPN": 1, "BRA": 1, "IND": 1}),
+		WithDeviceMix(map[string]float64{
+			DeviceClassPhone: 1, DeviceClassTablet: 1, DeviceClassDesktop: 1, DeviceClassCTV: 1,
+		}),
+		WithSharedIPs(50, 1.1, 0.5),
+		WithConsent(0.5, 0.5),
+		WithSupplyChain(3, nil),
+		WithCurrencies([]string{"USD", "EUR"}),
+		WithDeals(0.5, false, []openrtb.Deal{{ID: "deal-1", BidFloor: 2.5, WSeat: []string{"seat-1"}}, {ID: "deal-2"}}),
+		WithContextual(0.5, nil, nil),
+	}
+	for _, scenario := range []generator.Scenario{
+		NewMobileApp(), NewMobileApp(opts...), NewVideo(), NewVideo(opts...),
+	} {
+		for i := range 500 {
+			req := scenario.Generate(generator.Context{RequestID: fmt.Sprintf("req-%d", i), Segment: "sports"})
+			data, err := sonic.Marshal(req)
+			if err != nil {
+				t.Fatalf("%s: marshal: %v", scenario.Name(), err)
+			}
+			if err := schema.BidRequest().Validate(data); err != nil {
+				t.Fatalf("%s: request %d: %v\n%s", scenario.Name(), i, err, data)
+			}
+		}
+	}
+}
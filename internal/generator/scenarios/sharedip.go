@@ -0,0 +1,60 @@
+package scenarios
+
+import (
+	"errors"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// ipPool models carrier-grade NAT and other shared egress IPs: a fixed set
+// of addresses whose popularity follows a Zipf distribution, so a few IPs
+// front many users while most see light traffic.
+type ipPool struct {
+	ips    []string
+	picker *randutil.Weighted
+	share  float64
+}
+
+// validateSharedIPs checks shared IP parameters. A zero size or share
+// disables shared IP modeling.
+func validateSharedIPs(size int, concentration, share float64) error {
+	if size < 0 {
+		return errors.New("shared ip pool size must not be negative")
+	}
+	if concentration < 0 {
+		return errors.New("shared ip concentration must not be negative")
+	}
+	if share < 0 || share > 1 {
+		return errors.New("shared ip share must be between 0 and 1")
+	}
+	return nil
+}
+
+func newIPPool(size int, concentration, share float64) (*ipPool, error) {
+	if err := validateSharedIPs(size, concentration, share); err != nil {
+		return nil, err
+	}
+	if size == 0 || share == 0 {
+		return nil, nil
+	}
+
+	picker, err := randutil.NewZipf(size, concentration)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &ipPool{ips: make([]string, size), picker: picker, share: share}
+	for i := range pool.ips {
+		pool.ips[i] = uniqueIP()
+	}
+	return pool, nil
+}
+
+// pick returns a shared IP with probability share, or "" if the request
+// should get its own address.
+func (p *ipPool) pick() string {
+	if p == nil || !randutil.Chance(p.share) {
+		return ""
+	}
+	return p.ips[p.picker.Sample()]
+}
@@ -0,0 +1,89 @@
+package scenarios
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+func TestMobileApp_Generate_UniqueIPsByDefault(t *testing.T) {
+	scenario := NewMobileApp()
+
+	seen := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		seen[scenario.Generate(generator.Context{RequestID: "req-001"}).Device.IP]++
+	}
+	if len(seen) < 990 {
+		t.Errorf("distinct IPs = %d of 1000, want nearly all unique", len(seen))
+	}
+}
+
+func TestMobileApp_WithSharedIPs(t *testing.T) {
+	scenario := NewMobileApp(WithSharedIPs(50, 1.2, 0.5))
+	pool := make(map[string]bool, len(scenario.sharedIPs.ips))
+	for _, ip := range scenario.sharedIPs.ips {
+		pool[ip] = true
+	}
+
+	const n = 10000
+	counts := make(map[string]int)
+	shared := 0
+	for i := 0; i < n; i++ {
+		ip := scenario.Generate(generator.Context{RequestID: "req-001"}).Device.IP
+		if pool[ip] {
+			shared++
+			counts[ip]++
+		}
+	}
+
+	if got := float64(shared) / n; math.Abs(got-0.5) > 0.03 {
+		t.Errorf("shared share = %.3f, want ~0.5", got)
+	}
+
+	// Zipf concentration: the busiest address should carry far more
+	// traffic than the median one.
+	freq := make([]int, 0, len(counts))
+	for _, c := range counts {
+		freq = append(freq, c)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(freq)))
+	if freq[0] < 5*freq[len(freq)/2] {
+		t.Errorf("top IP count = %d, median = %d, want heavy concentration", freq[0], freq[len(freq)/2])
+	}
+}
+
+func TestWithSharedIPs_Disabled(t *testing.T) {
+	for _, tt := range []struct {
+		name                 string
+		size                 int
+		concentration, share float64
+	}{
+		{"zero size", 0, 1, 0.5},
+		{"zero share", 100, 1, 0},
+		{"invalid share", 100, 1, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := NewMobileApp(WithSharedIPs(tt.size, tt.concentration, tt.share))
+			if scenario.sharedIPs != nil {
+				t.Error("sharedIPs should be nil")
+			}
+		})
+	}
+}
+
+func TestValidateSharedIPs(t *testing.T) {
+	if err := validateSharedIPs(100, 1, 0.3); err != nil {
+		t.Errorf("validateSharedIPs() error = %v", err)
+	}
+	if err := validateSharedIPs(-1, 1, 0.3); err == nil {
+		t.Error("negative size: error = nil, want error")
+	}
+	if err := validateSharedIPs(100, -1, 0.3); err == nil {
+		t.Error("negative concentration: error = nil, want error")
+	}
+	if err := validateSharedIPs(100, 1, 1.1); err == nil {
+		t.Error("share > 1: error = nil, want error")
+	}
+}
@@ -0,0 +1,214 @@
+package scenarios
+
+import (
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// DefaultVideoDeviceMix is the device class mix used by the video scenario
+// when none is configured.
+var DefaultVideoDeviceMix = map[string]float64{
+	DeviceClassPhone:   0.35,
+	DeviceClassTablet:  0.1,
+	DeviceClassDesktop: 0.25,
+	DeviceClassCTV:     0.3,
+}
+
+// Pre-allocated static slices shared by every video impression
+var (
+	videoMimes    = []string{"video/mp4", "video/webm", "application/javascript"}
+	ctvVideoMimes = []string{"video/mp4"}
+	videoProtos   = []int{
+		openrtb.ProtocolVAST2, openrtb.ProtocolVAST3, openrtb.ProtocolVAST4,
+		openrtb.ProtocolVAST2Wrapper, openrtb.ProtocolVAST3Wrapper, openrtb.ProtocolVAST4Wrapper,
+	}
+	impIDVideo1 = "video-1"
+)
+
+// Video generates bid requests simulating instream video inventory:
+// app video on phones, tablets, and CTV, and site video on desktop.
+// Thread-safe: draws from the shared randutil source.
+type Video struct {
+	audience
+}
+
+// NewVideo creates a new video scenario.
+func NewVideo(opts ...Option) *Video {
+	return &Video{audience: newAudience(DefaultVideoDeviceMix, opts)}
+}
+
+func (v *Video) Name() string {
+	return "video"
+}
+
+func (v *Video) Generate(c generator.Context) *openrtb.BidRequest {
+	class := v.devices.pick()
+	video := v.randomVideo(class)
+	device := v.randomDevice(class)
+
+	req := &openrtb.BidRequest{
+		ID: c.RequestID,
+		Imp: []openrtb.Imp{
+			{
+				ID:       impIDVideo1,
+				Video:    video,
+				BidFloor: v.bidFloor(video.W, video.H, defaultVideoFloor),
+				Secure:   v.secure(),
+			},
+		},
+		Device: device,
+		User: &openrtb.User{
+			ID:   v.userID(c),
+			Data: generator.SegmentData(c.Segment),
+		},
+		At:   openrtb.AuctionFirstPrice,
+		Tmax: 100,
+		Cur:  v.cur,
+	}
+
+	pub := videoPublishers[randutil.IntN(len(videoPublishers))]
+	content := pub.randomContent(device.Language)
+	if class.Name == DeviceClassDesktop {
+		req.Site = &openrtb.Site{
+			ID:      pub.Bundle,
+			Name:    pub.Name,
+			Domain:  pub.Domain,
+			Page:    "https://" + pub.Domain + "/watch",
+			Cat:     pub.Category,
+			Content: content,
+		}
+	} else {
+		req.App = &openrtb.App{
+			ID:      pub.Bundle,
+			Name:    pub.Name,
+			Bundle:  pub.Bundle,
+			Domain:  pub.Domain,
+			Cat:     pub.Category,
+			Content: content,
+		}
+	}
+	v.consent.apply(req)
+	v.schain.apply(req)
+	v.deals.apply(req)
+	v.contextual.apply(req)
+
+	return req
+}
+
+func (v *Video) randomVideo(class *deviceClass) *openrtb.Video {
+	size := videoPlayerSizes[class.Name][randutil.IntN(len(videoPlayerSizes[class.Name]))]
+	dur := videoDurations[randutil.IntN(len(videoDurations))]
+
+	video := &openrtb.Video{
+		Mimes:       videoMimes,
+		Minduration: dur.Min,
+		Maxduration: dur.Max,
+		Protocols:   videoProtos,
+		W:           size.W,
+		H:           size.H,
+		StartDelay:  videoStartDelays[randutil.IntN(len(videoStartDelays))],
+		Placement:   openrtb.PlacementInStream,
+		Linearity:   openrtb.LinearityLinear,
+	}
+
+	switch class.Name {
+	case DeviceClassCTV:
+		// CTV players rarely run VPAID and never allow skipping
+		video.Mimes = ctvVideoMimes
+	case DeviceClassPhone, DeviceClassTablet:
+		if randutil.Chance(0.3) {
+			video.Placement = openrtb.PlacementInterstitial
+			video.StartDelay = 0
+		}
+		video.Skip = randutil.IntN(2)
+	default:
+		video.Skip = randutil.IntN(2)
+	}
+
+	return video
+}
+
+type videoDuration struct {
+	Min, Max int
+}
+
+var videoDurations = []videoDuration{
+	{5, 15},
+	{5, 30},
+	{15, 30},
+	{15, 60},
+}
+
+// Start delays: mostly pre-roll, with some mid- and post-roll
+var videoStartDelays = []int{
+	0, 0, 0,
+	openrtb.StartDelayGenericMidRoll,
+	openrtb.StartDelayGenericPostRoll,
+}
+
+var videoPlayerSizes = map[string][]bannerSize{
+	DeviceClassPhone:   {{640, 360}, {360, 640}, {320, 180}},
+	DeviceClassTablet:  {{1024, 576}, {768, 432}},
+	DeviceClassDesktop: {{640, 360}, {854, 480}, {1280, 720}},
+	DeviceClassCTV:     {{1920, 1080}, {1280, 720}},
+}
+
+type videoPublisher struct {
+	Name     string
+	Bundle   string
+	Domain   string
+	Category []string
+	Programs []videoProgram
+}
+
+// videoProgram is a show a video publisher streams, described to bidders
+// in the content object.
+type videoProgram struct {
+	Title  string
+	Genre  string
+	Rating string // TV Parental Guidelines rating
+	Live   bool
+}
+
+var videoPublishers = []videoPublisher{
+	{"StreamBox", "com.streambox.tv", "streambox.example.com", []string{"IAB1-5"}, []videoProgram{
+		{"The Long Harbor", "Drama", "TV-14", false},
+		{"Roommates Inc.", "Comedy", "TV-PG", false},
+		{"Deep Blue Planet", "Documentary", "TV-G", false},
+	}},
+	{"Daily News Video", "com.news.dailyvideo", "dailynews.example.com", []string{"IAB12"}, []videoProgram{
+		{"Morning Briefing", "News", "TV-G", true},
+		{"Capitol Report", "Politics", "TV-PG", false},
+	}},
+	{"Sports Live", "com.sports.live", "sportslive.example.com", []string{"IAB17"}, []videoProgram{
+		{"Sunday Night Football", "Football", "TV-PG", true},
+		{"Hoops Tonight", "Basketball", "TV-G", true},
+		{"Matchday Highlights", "Soccer", "TV-G", false},
+	}},
+	{"Kitchen Clips", "com.food.kitchenclips", "kitchenclips.example.com", []string{"IAB8"}, []videoProgram{
+		{"Weeknight Dinners", "Cooking", "TV-G", false},
+		{"Bake Off Live", "Cooking", "TV-G", true},
+	}},
+	{"Gamer TV", "com.games.gamertv", "gamertv.example.com", []string{"IAB9-30"}, []videoProgram{
+		{"Speedrun Showdown", "Action", "TV-14", true},
+		{"Strategy Hour", "Strategy", "TV-PG", false},
+	}},
+}
+
+// randomContent describes one of the publisher's programs, in the
+// viewer's language.
+func (p *videoPublisher) randomContent(language string) *openrtb.Content {
+	prog := p.Programs[randutil.IntN(len(p.Programs))]
+	content := &openrtb.Content{
+		Title:         prog.Title,
+		Genre:         prog.Genre,
+		Cat:           p.Category,
+		Language:      language,
+		ContentRating: prog.Rating,
+	}
+	if prog.Live {
+		content.LiveStream = 1
+	}
+	return content
+}
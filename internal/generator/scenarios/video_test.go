@@ -0,0 +1,147 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestVideo_Name(t *testing.T) {
+	if got := NewVideo().Name(); got != "video" {
+		t.Errorf("Name() = %q, want video", got)
+	}
+}
+
+func TestVideo_Generate_VideoImpression(t *testing.T) {
+	scenario := NewVideo()
+
+	for i := 0; i < 200; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		if len(req.Imp) != 1 {
+			t.Fatalf("len(Imp) = %d, want 1", len(req.Imp))
+		}
+		imp := req.Imp[0]
+		if imp.Banner != nil {
+			t.Error("Banner should be nil for video inventory")
+		}
+		v := imp.Video
+		if v == nil {
+			t.Fatal("Video should not be nil")
+		}
+		if len(v.Mimes) == 0 || len(v.Protocols) == 0 {
+			t.Errorf("Mimes/Protocols should be set: %+v", v)
+		}
+		if v.Minduration <= 0 || v.Maxduration < v.Minduration {
+			t.Errorf("durations = %d-%d, want 0 < min <= max", v.Minduration, v.Maxduration)
+		}
+		if v.W == 0 || v.H == 0 {
+			t.Error("Video dimensions should be set")
+		}
+		if v.Placement == 0 || v.Linearity != openrtb.LinearityLinear {
+			t.Errorf("Placement = %d, Linearity = %d", v.Placement, v.Linearity)
+		}
+		if imp.BidFloor < 2 || imp.BidFloor > 15 {
+			t.Errorf("BidFloor = %.2f, want 2-15", imp.BidFloor)
+		}
+	}
+}
+
+func TestVideo_Generate_PublisherMatchesDevice(t *testing.T) {
+	scenario := NewVideo()
+
+	for i := 0; i < 200; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		desktop := req.Device.DeviceType == openrtb.DeviceTypePC
+		if desktop && (req.Site == nil || req.App != nil) {
+			t.Fatal("desktop video should be site inventory")
+		}
+		if !desktop && (req.App == nil || req.Site != nil) {
+			t.Fatalf("device type %d video should be app inventory", req.Device.DeviceType)
+		}
+		if req.Device.DeviceType == openrtb.DeviceTypeTV && req.Imp[0].Video.Skip != 0 {
+			t.Error("CTV video should not be skippable")
+		}
+	}
+}
+
+func TestVideo_Generate_Content(t *testing.T) {
+	scenario := NewVideo(WithDeviceMix(map[string]float64{DeviceClassCTV: 1, DeviceClassDesktop: 1}))
+
+	live := 0
+	const n = 500
+	for range n {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		content, cats := publisherContent(req)
+		if content == nil {
+			t.Fatal("video request without a content object")
+		}
+		if content.Title == "" || content.Genre == "" || content.ContentRating == "" {
+			t.Errorf("content = %+v, want a title, genre, and rating", content)
+		}
+		if content.Language != req.Device.Language || len(content.Cat) == 0 || content.Cat[0] != cats[0] {
+			t.Errorf("content = %+v, want the device language and publisher category %v", content, cats)
+		}
+		live += content.LiveStream
+	}
+	// Some but not all programs are live
+	if live == 0 || live == n {
+		t.Errorf("live streams = %d of %d, want a mix", live, n)
+	}
+
+	data, err := json.Marshal(&openrtb.Content{Title: "Show", LiveStream: 1, ContentRating: "TV-PG"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if got, want := string(data), `{"title":"Show","livestream":1,"contentrating":"TV-PG"}`; got != want {
+		t.Errorf("Content JSON = %s, want %s", got, want)
+	}
+}
+
+func TestVideo_WithDeviceMix(t *testing.T) {
+	scenario := NewVideo(WithDeviceMix(map[string]float64{DeviceClassCTV: 1}))
+
+	for i := 0; i < 50; i++ {
+		req := scenario.Generate(generator.Context{RequestID: "req-001"})
+		if req.Device.DeviceType != openrtb.DeviceTypeTV {
+			t.Fatalf("DeviceType = %d, want TV", req.Device.DeviceType)
+		}
+	}
+}
+
+func TestVideo_Generate_StartDelaySerialized(t *testing.T) {
+	scenario := NewVideo()
+	data, err := json.Marshal(scenario.Generate(generator.Context{RequestID: "req-001"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw struct {
+		Imp []struct {
+			Video map[string]any `json:"video"`
+		} `json:"imp"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw.Imp[0].Video["startdelay"]; !ok {
+		t.Error("startdelay should always be serialized (0 = pre-roll)")
+	}
+}
+
+func TestVideo_Generate_SeededReproducible(t *testing.T) {
+	defer randutil.Seed(0)
+	scenario := NewVideo()
+
+	randutil.Seed(7)
+	first, _ := json.Marshal(scenario.Generate(generator.Context{RequestID: "req-001"}))
+
+	randutil.Seed(7)
+	second, _ := json.Marshal(scenario.Generate(generator.Context{RequestID: "req-001"}))
+
+	if string(first) != string(second) {
+		t.Errorf("seeded generation not reproducible:\n%s\n%s", first, second)
+	}
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/internal/state"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// UserPool returns a ContextFunc that draws each request's user from size
+// returning users kept in users, so DSPs and frequency caps see the same
+// users again. A user leaves the pool ttl after it was first drawn and a
+// new one takes its place; a ttl of zero or less keeps users for good.
+// Requests whose context already names a user keep it.
+func UserPool(users *state.Namespace, size int, ttl time.Duration) ContextFunc {
+	return func(c *Context) {
+		if c.UserID != "" || size <= 0 {
+			return
+		}
+		slot := strconv.Itoa(randutil.IntN(size))
+		if id, ok := users.Get(slot); ok {
+			c.UserID = string(id)
+			return
+		}
+		// Concurrent requests may both fill an empty slot; the user
+		// stored last stays in the pool.
+		id := newUserID()
+		users.Set(slot, []byte(id), ttl)
+		c.UserID = id
+	}
+}
+
+// newUserID returns a random 32-character hex user ID, the form the
+// scenarios generate.
+func newUserID() string {
+	var buf [32]byte
+	for i := range buf {
+		buf[i] = hexDigits[randutil.IntN(16)]
+	}
+	return string(buf[:])
+}
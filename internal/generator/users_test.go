@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/state"
+)
+
+func TestUserPool(t *testing.T) {
+	clk := clock.NewManual(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := state.New(state.WithClock(clk), state.WithSweepInterval(0))
+	pool := UserPool(store.Namespace("users"), 3, time.Hour)
+
+	draw := func(n int) map[string]bool {
+		users := make(map[string]bool)
+		for range n {
+			var c Context
+			pool(&c)
+			if len(c.UserID) != 32 {
+				t.Fatalf("UserID = %q, want 32 hex characters", c.UserID)
+			}
+			users[c.UserID] = true
+		}
+		return users
+	}
+
+	first := draw(200)
+	if len(first) != 3 {
+		t.Fatalf("drew %d distinct users, want the pool's 3", len(first))
+	}
+
+	clk.Advance(59 * time.Minute)
+	for id := range draw(200) {
+		if !first[id] {
+			t.Errorf("user %s joined the pool before any user expired", id)
+		}
+	}
+
+	clk.Advance(time.Minute)
+	for id := range draw(200) {
+		if first[id] {
+			t.Errorf("user %s is still drawn after its ttl", id)
+		}
+	}
+
+	c := Context{UserID: "known"}
+	pool(&c)
+	if c.UserID != "known" {
+		t.Errorf("UserID = %q, want the context's own user kept", c.UserID)
+	}
+}
@@ -1,14 +1,16 @@
 // Package httpclient provides a high-performance HTTP client for DSP communication.
-// It uses fasthttp for connection pooling and sonic for fast JSON serialization.
+// It uses fasthttp for connection pooling and sonic for fast JSON serialization,
+// or OpenRTB protobuf for DSPs configured with it.
 package httpclient
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/bytedance/sonic"
 	"github.com/valyala/fasthttp"
 
 	"github.com/cass/rtb-simulator/pkg/openrtb"
@@ -20,6 +22,9 @@ type Client struct {
 	timeout         time.Duration
 	maxConnsPerHost int
 	maxIdleConns    int
+
+	tlsConfig   *tls.Config
+	onHandshake HandshakeFunc
 }
 
 // Option configures the client.
@@ -46,6 +51,70 @@ func WithMaxIdleConns(n int) Option {
 	}
 }
 
+// CallOption configures a single Post call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	bodyFilter    func(body []byte) []byte
+	requestFilter func(body []byte) []byte
+	timeout       time.Duration
+	headers       [][2]string
+	codec         Codec
+	strict        bool
+	retry         RetryPolicy
+	onRetry       func(err error)
+	status        *int
+}
+
+// WithBodyFilter transforms the raw response body before it is decoded.
+// Used by fault injection to simulate truncated or corrupted payloads.
+func WithBodyFilter(f func(body []byte) []byte) CallOption {
+	return func(o *callOptions) {
+		o.bodyFilter = f
+	}
+}
+
+// WithRequestFilter transforms the encoded request body before it is
+// sent. Used by fuzzing to send malformed requests.
+func WithRequestFilter(f func(body []byte) []byte) CallOption {
+	return func(o *callOptions) {
+		o.requestFilter = f
+	}
+}
+
+// WithCallTimeout shortens the timeout for a single call. It cannot extend
+// the client's configured timeout.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithHeader adds a request header to a single call. May be given
+// multiple times.
+func WithHeader(name, value string) CallOption {
+	return func(o *callOptions) {
+		o.headers = append(o.headers, [2]string{name, value})
+	}
+}
+
+// WithCodec encodes the request and decodes the response of a single call
+// with c instead of JSON.
+func WithCodec(c Codec) CallOption {
+	return func(o *callOptions) {
+		o.codec = c
+	}
+}
+
+// WithStatus stores the HTTP status of the call's response in *dst, or 0
+// if it got none, whether or not the call succeeded. With retries, it is
+// the status of the last attempt.
+func WithStatus(dst *int) CallOption {
+	return func(o *callOptions) {
+		o.status = dst
+	}
+}
+
 // New creates a new HTTP client with the given options.
 func New(opts ...Option) *Client {
 	c := &Client{
@@ -66,19 +135,54 @@ func New(opts ...Option) *Client {
 		MaxConnWaitTimeout:            c.timeout,
 		DisableHeaderNamesNormalizing: true, // Skip header normalization for performance
 		DisablePathNormalizing:        true, // Skip path normalization for performance
-		MaxResponseBodySize:           64 * 1024, // Limit to 64KB for RTB responses
+		MaxResponseBodySize:           MaxResponseBodySize,
+		TLSConfig:                     tlsConfigWithCache(c.tlsConfig),
 	}
+	c.client.ConfigureClient = c.configureHost
 
 	return c
 }
 
-// Post sends a bid request and returns the response.
-func (c *Client) Post(url string, req *openrtb.BidRequest) (*openrtb.BidResponse, error) {
-	body, err := sonic.Marshal(req)
+// Post sends a bid request and returns the response. With WithRetry, the
+// call's timeout bounds every attempt and the backoffs between them.
+func (c *Client) Post(url string, req *openrtb.BidRequest, opts ...CallOption) (*openrtb.BidResponse, error) {
+	co := callOptions{codec: JSON}
+	for _, opt := range opts {
+		opt(&co)
+	}
+
+	body, err := co.codec.EncodeRequest(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
+	if co.requestFilter != nil {
+		body = co.requestFilter(body)
+	}
+
+	timeout := c.timeout
+	if co.timeout > 0 && co.timeout < timeout {
+		timeout = co.timeout
+	}
+	deadline := time.Now().Add(timeout)
 
+	for attempt := 0; ; attempt++ {
+		resp, err := c.post(url, req, body, &co, time.Until(deadline))
+		if err == nil || !co.retry.retries(attempt, err) {
+			return resp, err
+		}
+		wait := co.retry.backoff(attempt + 1)
+		if time.Until(deadline) <= wait {
+			return resp, err
+		}
+		if co.onRetry != nil {
+			co.onRetry(err)
+		}
+		time.Sleep(wait)
+	}
+}
+
+// post makes one attempt at a Post call with body, the encoded request.
+func (c *Client) post(url string, req *openrtb.BidRequest, body []byte, co *callOptions, timeout time.Duration) (*openrtb.BidResponse, error) {
 	request := fasthttp.AcquireRequest()
 	response := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseRequest(request)
@@ -86,14 +190,26 @@ func (c *Client) Post(url string, req *openrtb.BidRequest) (*openrtb.BidResponse
 
 	request.SetRequestURI(url)
 	request.Header.SetMethod(fasthttp.MethodPost)
-	request.Header.SetContentType("application/json")
+	request.Header.SetContentType(co.codec.ContentType())
+	for _, h := range co.headers {
+		request.Header.Set(h[0], h[1])
+	}
 	request.SetBody(body)
 
-	err = c.client.DoTimeout(request, response, c.timeout)
+	err := c.client.DoTimeout(request, response, timeout)
+	if co.status != nil {
+		*co.status = 0
+		if err == nil {
+			*co.status = response.StatusCode()
+		}
+	}
 	if err != nil {
 		if errors.Is(err, fasthttp.ErrTimeout) {
 			return nil, &TimeoutError{err: err}
 		}
+		if errors.Is(err, fasthttp.ErrBodyTooLarge) {
+			return nil, fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, MaxResponseBodySize)
+		}
 		return nil, fmt.Errorf("do request: %w", err)
 	}
 
@@ -105,22 +221,70 @@ func (c *Client) Post(url string, req *openrtb.BidRequest) (*openrtb.BidResponse
 	}
 
 	if statusCode >= 400 {
-		return nil, fmt.Errorf("server error: status %d", statusCode)
+		return nil, &StatusError{
+			StatusCode: statusCode,
+			RetryAfter: parseRetryAfter(response.Header.Peek("Retry-After"), time.Now()),
+		}
+	}
+
+	respBody := response.Body()
+	if co.bodyFilter != nil {
+		respBody = co.bodyFilter(respBody)
 	}
 
 	var resp openrtb.BidResponse
-	if err := sonic.Unmarshal(response.Body(), &resp); err != nil {
+	if err := co.codec.DecodeResponse(respBody, &resp); err != nil {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
+	if co.strict {
+		if err := checkStrict(co.codec, respBody, req, &resp); err != nil {
+			return nil, err
+		}
+	}
 
 	return &resp, nil
 }
 
-// Close releases resources held by the client.
+// Get issues a GET request, discarding the body. Used for firing
+// notification URLs where only delivery success matters.
+func (c *Client) Get(url string) error {
+	request := fasthttp.AcquireRequest()
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(request)
+	defer fasthttp.ReleaseResponse(response)
+
+	request.SetRequestURI(url)
+	request.Header.SetMethod(fasthttp.MethodGet)
+	response.SkipBody = true
+
+	if err := c.client.DoTimeout(request, response, c.timeout); err != nil {
+		if errors.Is(err, fasthttp.ErrTimeout) {
+			return &TimeoutError{err: err}
+		}
+		return fmt.Errorf("do request: %w", err)
+	}
+
+	if statusCode := response.StatusCode(); statusCode >= 400 {
+		return &StatusError{StatusCode: statusCode}
+	}
+	return nil
+}
+
+// Close closes the client's idle connections. Requests in flight
+// complete, and their connections are closed once idle for the client's
+// idle timeout.
 func (c *Client) Close() {
-	// fasthttp.Client doesn't require explicit close
+	c.client.CloseIdleConnections()
 }
 
+// MaxResponseBodySize caps bid response bodies; RTB responses are small,
+// so anything larger is almost always oversized creative markup.
+const MaxResponseBodySize = 64 * 1024
+
+// ErrBodyTooLarge is returned when a response body exceeds
+// MaxResponseBodySize.
+var ErrBodyTooLarge = errors.New("response body too large")
+
 // TimeoutError indicates a request timeout.
 type TimeoutError struct {
 	err error
@@ -134,6 +298,46 @@ func (e *TimeoutError) Unwrap() error {
 	return e.err
 }
 
+// StatusError indicates the server responded with an error HTTP status.
+// RetryAfter is set when the response carried a valid Retry-After header.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server error: status %d", e.StatusCode)
+}
+
+// StatusCode returns the HTTP status carried by err, if it is a StatusError.
+func StatusCode(err error) (int, bool) {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header value given either as
+// delay-seconds or as an HTTP-date. Returns 0 if absent or invalid.
+func parseRetryAfter(v []byte, now time.Time) time.Duration {
+	if len(v) == 0 {
+		return 0
+	}
+	if secs, err := strconv.Atoi(string(v)); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(string(v)); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // IsTimeout returns true if the error is a timeout error.
 func IsTimeout(err error) bool {
 	var te *TimeoutError
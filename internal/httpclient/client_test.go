@@ -1,8 +1,11 @@
 package httpclient
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -84,6 +87,72 @@ func TestClient_Post_Timeout(t *testing.T) {
 	}
 }
 
+func TestClient_Post_CallTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	start := time.Now()
+	_, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"}, WithCallTimeout(50*time.Millisecond))
+
+	if !IsTimeout(err) {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("call took %v, want the 50ms call timeout to apply", elapsed)
+	}
+}
+
+func TestClient_Post_Headers(t *testing.T) {
+	var gotID, gotTrace string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get("X-Request-Id")
+		gotTrace = r.Header.Get("Traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	_, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"},
+		WithHeader("X-Request-Id", "req-1"),
+		WithHeader("traceparent", "00-abc-def-01"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	if gotID != "req-1" {
+		t.Errorf("X-Request-Id = %q, want req-1", gotID)
+	}
+	if gotTrace != "00-abc-def-01" {
+		t.Errorf("traceparent = %q, want 00-abc-def-01", gotTrace)
+	}
+}
+
+func TestClient_Post_BodyTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"req-1","seatbid":[{"bid":[{"id":"bid-1","impid":"imp-1","price":2.5,"adm":"` +
+			strings.Repeat("x", MaxResponseBodySize) + `"}]}]}`))
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	_, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"})
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Post() error = %v, want ErrBodyTooLarge", err)
+	}
+}
+
 func TestClient_Post_ServerError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -99,6 +168,52 @@ func TestClient_Post_ServerError(t *testing.T) {
 	if err == nil {
 		t.Error("expected error for 500 response")
 	}
+	if code, ok := StatusCode(err); !ok || code != http.StatusInternalServerError {
+		t.Errorf("StatusCode() = %d, %v, want 500, true", code, ok)
+	}
+}
+
+func TestClient_Post_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	_, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"})
+
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if se.RetryAfter != 5*time.Second {
+		t.Errorf("RetryAfter = %v, want 5s", se.RetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{"Mon, 01 Jan 2024 12:00:10 GMT", 10 * time.Second},
+		{"Mon, 01 Jan 2024 11:59:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter([]byte(tt.value), now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
 }
 
 func TestClient_Post_InvalidJSON(t *testing.T) {
@@ -120,6 +235,51 @@ func TestClient_Post_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestClient_Post_BodyFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"req-1","seatbid":[{"bid":[{"id":"bid-1","impid":"imp-1","price":2.5}]}]}`))
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	req := &openrtb.BidRequest{ID: "req-1"}
+	_, err := client.Post(server.URL, req, WithBodyFilter(func(body []byte) []byte {
+		return body[:len(body)/2]
+	}))
+
+	if err == nil {
+		t.Error("expected decode error for filtered body")
+	}
+}
+
+func TestClient_Post_RequestFilter(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	req := &openrtb.BidRequest{ID: "req-1"}
+	_, err := client.Post(server.URL, req, WithRequestFilter(func(body []byte) []byte {
+		return []byte(`{"id":12345}`)
+	}))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if got != `{"id":12345}` {
+		t.Errorf("server received %s, want the filtered body", got)
+	}
+}
+
 func TestClient_Post_ConnectionRefused(t *testing.T) {
 	client := New(WithTimeout(1 * time.Second))
 	defer client.Close()
@@ -150,3 +310,52 @@ func TestClientOptions(t *testing.T) {
 		t.Errorf("expected maxIdleConns 100, got %d", client.maxIdleConns)
 	}
 }
+
+func TestClient_Post_Status(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{name: "bid", status: http.StatusOK, body: `{"id":"req-1","seatbid":[{"bid":[{"id":"bid-1","impid":"imp-1","price":2.5}]}]}`},
+		{name: "empty 200", status: http.StatusOK, body: `{"id":"req-1"}`},
+		{name: "204", status: http.StatusNoContent},
+		{name: "503", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := New(WithTimeout(time.Second))
+			defer client.Close()
+
+			status := -1
+			_, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"}, WithStatus(&status))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+
+	t.Run("no response", func(t *testing.T) {
+		client := New(WithTimeout(time.Second))
+		defer client.Close()
+
+		status := -1
+		if _, err := client.Post("http://localhost:59999", &openrtb.BidRequest{ID: "req-1"}, WithStatus(&status)); err == nil {
+			t.Fatal("expected connection error")
+		}
+		if status != 0 {
+			t.Errorf("status = %d, want 0 without a response", status)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"mime"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Codec serializes bid requests for the wire and decodes the responses,
+// so a DSP can be reached over a protocol other than OpenRTB JSON. The
+// bidder's half, DecodeRequest and EncodeResponse, serves the mock DSP.
+type Codec interface {
+	ContentType() string
+	EncodeRequest(req *openrtb.BidRequest) ([]byte, error)
+	DecodeResponse(data []byte, resp *openrtb.BidResponse) error
+	DecodeRequest(data []byte, req *openrtb.BidRequest) error
+	EncodeResponse(resp *openrtb.BidResponse) ([]byte, error)
+}
+
+var (
+	// JSON is the default codec: OpenRTB JSON, via sonic.
+	JSON Codec = jsonCodec{}
+
+	// Protobuf encodes OpenRTB as protocol buffers, as bidders in the
+	// Google authorized-buyers style expect.
+	Protobuf Codec = protobufCodec{}
+)
+
+// CodecFor returns the codec for a request's Content-Type: Protobuf for
+// application/x-protobuf, and JSON otherwise.
+func CodecFor(contentType string) Codec {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil && mt == Protobuf.ContentType() {
+		return Protobuf
+	}
+	return JSON
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) EncodeRequest(req *openrtb.BidRequest) ([]byte, error) {
+	return sonic.Marshal(req)
+}
+
+func (jsonCodec) DecodeResponse(data []byte, resp *openrtb.BidResponse) error {
+	return sonic.Unmarshal(data, resp)
+}
+
+func (jsonCodec) DecodeRequest(data []byte, req *openrtb.BidRequest) error {
+	return sonic.Unmarshal(data, req)
+}
+
+func (jsonCodec) EncodeResponse(resp *openrtb.BidResponse) ([]byte, error) {
+	return sonic.Marshal(resp)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return "application/x-protobuf" }
+
+func (protobufCodec) EncodeRequest(req *openrtb.BidRequest) ([]byte, error) {
+	return marshalRequest(req), nil
+}
+
+func (protobufCodec) DecodeResponse(data []byte, resp *openrtb.BidResponse) error {
+	return unmarshalResponse(data, resp)
+}
+
+func (protobufCodec) DecodeRequest(data []byte, req *openrtb.BidRequest) error {
+	return unmarshalRequest(data, req)
+}
+
+func (protobufCodec) EncodeResponse(resp *openrtb.BidResponse) ([]byte, error) {
+	return marshalResponse(resp), nil
+}
@@ -0,0 +1,936 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// The protobuf codec maps the openrtb types by hand onto the messages of
+// the OpenRTB 2.6 openrtb.proto (package com.google.openrtb), so no
+// generated code is needed; testdata/openrtb.proto holds the messages it
+// uses, and the tests check every field number against it. Fields the
+// simulator does not model are not sent, and are skipped when decoding. Extensions the JSON form carries in
+// ext objects, such as regs.ext.gdpr and source.ext.schain, are the
+// first-class 2.6 fields in protobuf.
+
+// message builds a protobuf message. Zero values are left out, as unset
+// optional fields.
+type message []byte
+
+func (m *message) varint(num protowire.Number, v int) {
+	*m = protowire.AppendTag(*m, num, protowire.VarintType)
+	*m = protowire.AppendVarint(*m, uint64(int64(v))) // negative int32s take ten bytes
+}
+
+func (m *message) int(num protowire.Number, v int) {
+	if v != 0 {
+		m.varint(num, v)
+	}
+}
+
+func (m *message) ints(num protowire.Number, vs []int) {
+	for _, v := range vs {
+		m.varint(num, v)
+	}
+}
+
+func (m *message) double(num protowire.Number, v float64) {
+	if v != 0 {
+		*m = protowire.AppendTag(*m, num, protowire.Fixed64Type)
+		*m = protowire.AppendFixed64(*m, math.Float64bits(v))
+	}
+}
+
+func (m *message) string(num protowire.Number, s string) {
+	if s != "" {
+		*m = protowire.AppendTag(*m, num, protowire.BytesType)
+		*m = protowire.AppendString(*m, s)
+	}
+}
+
+func (m *message) strings(num protowire.Number, ss []string) {
+	for _, s := range ss {
+		*m = protowire.AppendTag(*m, num, protowire.BytesType)
+		*m = protowire.AppendString(*m, s)
+	}
+}
+
+// embed appends the message encode builds as field num.
+func (m *message) embed(num protowire.Number, encode func(*message)) {
+	var sub message
+	encode(&sub)
+	*m = protowire.AppendTag(*m, num, protowire.BytesType)
+	*m = protowire.AppendBytes(*m, sub)
+}
+
+func marshalRequest(req *openrtb.BidRequest) []byte {
+	var m message
+	m.string(1, req.ID)
+	for i := range req.Imp {
+		m.embed(2, func(m *message) { encodeImp(m, &req.Imp[i]) })
+	}
+	if s := req.Site; s != nil {
+		m.embed(3, func(m *message) { encodeSite(m, s) })
+	}
+	if a := req.App; a != nil {
+		m.embed(4, func(m *message) { encodeApp(m, a) })
+	}
+	if d := req.Device; d != nil {
+		m.embed(5, func(m *message) { encodeDevice(m, d) })
+	}
+	if u := req.User; u != nil {
+		m.embed(6, func(m *message) { encodeUser(m, u) })
+	}
+	m.int(7, req.At)
+	m.int(8, req.Tmax)
+	m.strings(11, req.Cur)
+	m.strings(12, req.Bcat)
+	if r := req.Regs; r != nil {
+		m.embed(14, func(m *message) { encodeRegs(m, r) })
+	}
+	if s := req.Source; s != nil {
+		m.embed(19, func(m *message) { encodeSource(m, s) })
+	}
+	return m
+}
+
+func encodeImp(m *message, imp *openrtb.Imp) {
+	m.string(1, imp.ID)
+	if b := imp.Banner; b != nil {
+		m.embed(2, func(m *message) {
+			m.int(1, b.W)
+			m.int(2, b.H)
+			m.int(4, b.Pos)
+			m.ints(5, b.Btype)
+			m.ints(6, b.Battr)
+			m.int(11, b.Wmax)
+			m.int(12, b.Hmax)
+			m.int(13, b.Wmin)
+			m.int(14, b.Hmin)
+		})
+	}
+	if v := imp.Video; v != nil {
+		m.embed(3, func(m *message) {
+			m.strings(1, v.Mimes)
+			m.int(2, v.Linearity)
+			m.int(3, v.Minduration)
+			m.int(4, v.Maxduration)
+			m.int(6, v.W)
+			m.int(7, v.H)
+			m.varint(8, v.StartDelay) // 0 = pre-roll, so always sent
+			m.ints(21, v.Protocols)
+			m.int(23, v.Skip)
+			m.int(26, v.Placement)
+		})
+	}
+	if n := imp.Native; n != nil {
+		m.embed(13, func(m *message) {
+			m.string(1, n.Request)
+			m.string(2, n.Ver)
+		})
+	}
+	m.int(6, imp.Instl)
+	m.string(7, imp.Tagid)
+	m.double(8, imp.BidFloor)
+	if p := imp.PMP; p != nil {
+		m.embed(11, func(m *message) {
+			m.int(1, p.PrivateAuction)
+			for _, d := range p.Deals {
+				m.embed(2, func(m *message) {
+					m.string(1, d.ID)
+					m.double(2, d.BidFloor)
+					m.string(3, d.BidFloorCur)
+					m.strings(4, d.WSeat)
+				})
+			}
+		})
+	}
+	m.int(12, imp.Secure)
+	m.int(14, imp.Exp)
+}
+
+func encodeSite(m *message, s *openrtb.Site) {
+	m.string(1, s.ID)
+	m.string(2, s.Name)
+	m.string(3, s.Domain)
+	m.strings(4, s.Cat)
+	m.string(7, s.Page)
+	if c := s.Content; c != nil {
+		m.embed(12, func(m *message) { encodeContent(m, c) })
+	}
+}
+
+func encodeApp(m *message, a *openrtb.App) {
+	m.string(1, a.ID)
+	m.string(2, a.Name)
+	m.string(3, a.Domain)
+	m.strings(4, a.Cat)
+	m.string(7, a.Ver)
+	m.string(8, a.Bundle)
+	m.int(10, a.Paid)
+	if c := a.Content; c != nil {
+		m.embed(12, func(m *message) { encodeContent(m, c) })
+	}
+	m.string(16, a.StoreURL)
+}
+
+func encodeContent(m *message, c *openrtb.Content) {
+	m.string(3, c.Title)
+	m.strings(7, c.Cat)
+	m.string(9, c.Keywords)
+	m.string(10, c.ContentRating)
+	m.int(13, c.LiveStream)
+	m.string(19, c.Language)
+	m.string(22, c.Genre)
+}
+
+func encodeDevice(m *message, d *openrtb.Device) {
+	m.string(2, d.UA)
+	m.string(3, d.IP)
+	if g := d.Geo; g != nil {
+		m.embed(4, func(m *message) {
+			m.double(1, g.Lat)
+			m.double(2, g.Lon)
+			m.string(3, g.Country)
+			m.string(4, g.Region)
+			m.string(7, g.City)
+			m.string(8, g.ZIP)
+			m.int(9, g.Type)
+			m.int(10, g.UTCOffset)
+		})
+	}
+	m.string(10, d.Carrier)
+	m.string(11, d.Language)
+	m.string(12, d.Make)
+	m.string(13, d.Model)
+	m.string(14, d.OS)
+	m.string(15, d.OSV)
+	m.int(17, d.ConnectionType)
+	m.int(18, d.DeviceType)
+	m.string(20, d.IFA)
+	m.string(32, d.LangB)
+}
+
+func encodeUser(m *message, u *openrtb.User) {
+	m.string(1, u.ID)
+	m.string(2, u.BuyerUID)
+	m.int(3, u.Yob)
+	m.string(4, u.Gender)
+	m.string(5, u.Keywords)
+	for _, d := range u.Data {
+		m.embed(8, func(m *message) {
+			m.string(1, d.ID)
+			m.string(2, d.Name)
+			for _, s := range d.Segment {
+				m.embed(3, func(m *message) {
+					m.string(1, s.ID)
+					m.string(2, s.Name)
+					m.string(3, s.Value)
+				})
+			}
+		})
+	}
+	if ext := u.Ext; ext != nil {
+		m.string(10, ext.Consent)
+	}
+}
+
+func encodeRegs(m *message, r *openrtb.Regs) {
+	m.int(1, r.COPPA)
+	if ext := r.Ext; ext != nil {
+		m.varint(4, ext.GDPR) // 0 is meaningful, so always sent
+		m.string(5, ext.USPrivacy)
+	}
+}
+
+func encodeSource(m *message, s *openrtb.Source) {
+	m.int(1, s.FD)
+	m.string(2, s.TID)
+	m.string(3, s.PChain)
+	if s.Ext == nil || s.Ext.SChain == nil {
+		return
+	}
+	sc := s.Ext.SChain
+	m.embed(4, func(m *message) {
+		m.varint(1, sc.Complete)
+		for _, n := range sc.Nodes {
+			m.embed(2, func(m *message) {
+				m.string(1, n.ASI)
+				m.string(2, n.SID)
+				m.string(3, n.RID)
+				m.string(4, n.Name)
+				m.string(5, n.Domain)
+				m.varint(6, n.HP)
+			})
+		}
+		m.string(3, sc.Ver)
+	})
+}
+
+// errWireType reports a field encoded with a wire type its message
+// definition does not allow.
+var errWireType = errors.New("unexpected wire type")
+
+// field is one field read from a protobuf message.
+type field struct {
+	num protowire.Number
+	typ protowire.Type
+	val uint64 // varint and fixed values
+	buf []byte // length-delimited values
+}
+
+func (f field) want(typ protowire.Type) error {
+	if f.typ != typ {
+		return fmt.Errorf("field %d: %w %d", f.num, errWireType, f.typ)
+	}
+	return nil
+}
+
+func (f field) string(dst *string) error {
+	*dst = string(f.buf)
+	return f.want(protowire.BytesType)
+}
+
+func (f field) int(dst *int) error {
+	*dst = int(int32(f.val))
+	return f.want(protowire.VarintType)
+}
+
+func (f field) double(dst *float64) error {
+	*dst = math.Float64frombits(f.val)
+	return f.want(protowire.Fixed64Type)
+}
+
+// fields calls fn with each field of the message in b, skipping the
+// fields fn does not know, until fn returns an error.
+func fields(b []byte, fn func(field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.val, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.val, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.val = uint64(v)
+		case protowire.BytesType:
+			f.buf, n = protowire.ConsumeBytes(b)
+		default:
+			// Groups are not used by OpenRTB
+			return fmt.Errorf("field %d: %w %d", num, errWireType, typ)
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalResponse(data []byte, resp *openrtb.BidResponse) error {
+	return fields(data, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&resp.ID)
+		case 2:
+			var sb openrtb.SeatBid
+			if err := f.want(protowire.BytesType); err != nil {
+				return err
+			}
+			if err := decodeSeatBid(f.buf, &sb); err != nil {
+				return fmt.Errorf("seatbid: %w", err)
+			}
+			resp.SeatBid = append(resp.SeatBid, sb)
+		case 3:
+			return f.string(&resp.BidID)
+		case 4:
+			return f.string(&resp.Cur)
+		case 6:
+			return f.int(&resp.NBR)
+		}
+		return nil
+	})
+}
+
+func decodeSeatBid(b []byte, sb *openrtb.SeatBid) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			var bid openrtb.Bid
+			if err := f.want(protowire.BytesType); err != nil {
+				return err
+			}
+			if err := decodeBid(f.buf, &bid); err != nil {
+				return fmt.Errorf("bid: %w", err)
+			}
+			sb.Bid = append(sb.Bid, bid)
+		case 2:
+			return f.string(&sb.Seat)
+		}
+		return nil
+	})
+}
+
+func decodeBid(b []byte, bid *openrtb.Bid) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&bid.ID)
+		case 2:
+			return f.string(&bid.ImpID)
+		case 3:
+			return f.double(&bid.Price)
+		case 4:
+			return f.string(&bid.AdID)
+		case 5:
+			return f.string(&bid.NURL)
+		case 6:
+			return f.string(&bid.AdM)
+		case 7:
+			var domain string
+			if err := f.string(&domain); err != nil {
+				return err
+			}
+			bid.ADomain = append(bid.ADomain, domain)
+		case 9:
+			return f.string(&bid.CID)
+		case 10:
+			return f.string(&bid.CrID)
+		case 13:
+			return f.string(&bid.DealID)
+		case 15:
+			var cat string
+			if err := f.string(&cat); err != nil {
+				return err
+			}
+			bid.Cat = append(bid.Cat, cat)
+		case 16:
+			return f.int(&bid.W)
+		case 17:
+			return f.int(&bid.H)
+		case 21:
+			return f.int(&bid.Exp)
+		case 22:
+			return f.string(&bid.BURL)
+		case 23:
+			return f.string(&bid.LURL)
+		case 33:
+			return f.int(&bid.MType)
+		}
+		return nil
+	})
+}
+
+// The bidder's side, for the mock DSP: requests are decoded and responses
+// encoded with the same field numbers.
+
+func (f field) ints(dst *[]int) error {
+	if f.typ != protowire.BytesType {
+		var v int
+		err := f.int(&v)
+		*dst = append(*dst, v)
+		return err
+	}
+	// Packed, as proto3 and some proto2 encoders write repeated enums
+	for b := f.buf; len(b) > 0; {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", f.num, protowire.ParseError(n))
+		}
+		*dst = append(*dst, int(int32(v)))
+		b = b[n:]
+	}
+	return nil
+}
+
+func (f field) strings(dst *[]string) error {
+	*dst = append(*dst, string(f.buf))
+	return f.want(protowire.BytesType)
+}
+
+// embed decodes the message in f with decode, naming it in errors.
+func (f field) embed(name string, decode func([]byte) error) error {
+	if err := f.want(protowire.BytesType); err != nil {
+		return err
+	}
+	if err := decode(f.buf); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func unmarshalRequest(data []byte, req *openrtb.BidRequest) error {
+	return fields(data, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&req.ID)
+		case 2:
+			var imp openrtb.Imp
+			if err := f.embed("imp", func(b []byte) error { return decodeImp(b, &imp) }); err != nil {
+				return err
+			}
+			req.Imp = append(req.Imp, imp)
+		case 3:
+			req.Site = &openrtb.Site{}
+			return f.embed("site", func(b []byte) error { return decodeSite(b, req.Site) })
+		case 4:
+			req.App = &openrtb.App{}
+			return f.embed("app", func(b []byte) error { return decodeApp(b, req.App) })
+		case 5:
+			req.Device = &openrtb.Device{}
+			return f.embed("device", func(b []byte) error { return decodeDevice(b, req.Device) })
+		case 6:
+			req.User = &openrtb.User{}
+			return f.embed("user", func(b []byte) error { return decodeUser(b, req.User) })
+		case 7:
+			return f.int(&req.At)
+		case 8:
+			return f.int(&req.Tmax)
+		case 11:
+			return f.strings(&req.Cur)
+		case 12:
+			return f.strings(&req.Bcat)
+		case 14:
+			req.Regs = &openrtb.Regs{}
+			return f.embed("regs", func(b []byte) error { return decodeRegs(b, req.Regs) })
+		case 19:
+			req.Source = &openrtb.Source{}
+			return f.embed("source", func(b []byte) error { return decodeSource(b, req.Source) })
+		}
+		return nil
+	})
+}
+
+func decodeImp(b []byte, imp *openrtb.Imp) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&imp.ID)
+		case 2:
+			imp.Banner = &openrtb.Banner{}
+			return f.embed("banner", func(b []byte) error { return decodeBanner(b, imp.Banner) })
+		case 3:
+			imp.Video = &openrtb.Video{}
+			return f.embed("video", func(b []byte) error { return decodeVideo(b, imp.Video) })
+		case 6:
+			return f.int(&imp.Instl)
+		case 7:
+			return f.string(&imp.Tagid)
+		case 8:
+			return f.double(&imp.BidFloor)
+		case 11:
+			imp.PMP = &openrtb.PMP{}
+			return f.embed("pmp", func(b []byte) error { return decodePMP(b, imp.PMP) })
+		case 12:
+			return f.int(&imp.Secure)
+		case 13:
+			imp.Native = &openrtb.Native{}
+			return f.embed("native", func(b []byte) error {
+				return fields(b, func(f field) error {
+					switch f.num {
+					case 1:
+						return f.string(&imp.Native.Request)
+					case 2:
+						return f.string(&imp.Native.Ver)
+					}
+					return nil
+				})
+			})
+		case 14:
+			return f.int(&imp.Exp)
+		}
+		return nil
+	})
+}
+
+func decodeBanner(b []byte, banner *openrtb.Banner) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.int(&banner.W)
+		case 2:
+			return f.int(&banner.H)
+		case 4:
+			return f.int(&banner.Pos)
+		case 5:
+			return f.ints(&banner.Btype)
+		case 6:
+			return f.ints(&banner.Battr)
+		case 11:
+			return f.int(&banner.Wmax)
+		case 12:
+			return f.int(&banner.Hmax)
+		case 13:
+			return f.int(&banner.Wmin)
+		case 14:
+			return f.int(&banner.Hmin)
+		}
+		return nil
+	})
+}
+
+func decodeVideo(b []byte, v *openrtb.Video) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.strings(&v.Mimes)
+		case 2:
+			return f.int(&v.Linearity)
+		case 3:
+			return f.int(&v.Minduration)
+		case 4:
+			return f.int(&v.Maxduration)
+		case 6:
+			return f.int(&v.W)
+		case 7:
+			return f.int(&v.H)
+		case 8:
+			return f.int(&v.StartDelay)
+		case 21:
+			return f.ints(&v.Protocols)
+		case 23:
+			return f.int(&v.Skip)
+		case 26:
+			return f.int(&v.Placement)
+		}
+		return nil
+	})
+}
+
+func decodePMP(b []byte, p *openrtb.PMP) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.int(&p.PrivateAuction)
+		case 2:
+			var d openrtb.Deal
+			err := f.embed("deal", func(b []byte) error {
+				return fields(b, func(f field) error {
+					switch f.num {
+					case 1:
+						return f.string(&d.ID)
+					case 2:
+						return f.double(&d.BidFloor)
+					case 3:
+						return f.string(&d.BidFloorCur)
+					case 4:
+						return f.strings(&d.WSeat)
+					}
+					return nil
+				})
+			})
+			p.Deals = append(p.Deals, d)
+			return err
+		}
+		return nil
+	})
+}
+
+func decodeSite(b []byte, s *openrtb.Site) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&s.ID)
+		case 2:
+			return f.string(&s.Name)
+		case 3:
+			return f.string(&s.Domain)
+		case 4:
+			return f.strings(&s.Cat)
+		case 7:
+			return f.string(&s.Page)
+		case 12:
+			s.Content = &openrtb.Content{}
+			return f.embed("content", func(b []byte) error { return decodeContent(b, s.Content) })
+		}
+		return nil
+	})
+}
+
+func decodeApp(b []byte, a *openrtb.App) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&a.ID)
+		case 2:
+			return f.string(&a.Name)
+		case 3:
+			return f.string(&a.Domain)
+		case 4:
+			return f.strings(&a.Cat)
+		case 7:
+			return f.string(&a.Ver)
+		case 8:
+			return f.string(&a.Bundle)
+		case 10:
+			return f.int(&a.Paid)
+		case 12:
+			a.Content = &openrtb.Content{}
+			return f.embed("content", func(b []byte) error { return decodeContent(b, a.Content) })
+		case 16:
+			return f.string(&a.StoreURL)
+		}
+		return nil
+	})
+}
+
+func decodeContent(b []byte, c *openrtb.Content) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 3:
+			return f.string(&c.Title)
+		case 7:
+			return f.strings(&c.Cat)
+		case 9:
+			return f.string(&c.Keywords)
+		case 10:
+			return f.string(&c.ContentRating)
+		case 13:
+			return f.int(&c.LiveStream)
+		case 19:
+			return f.string(&c.Language)
+		case 22:
+			return f.string(&c.Genre)
+		}
+		return nil
+	})
+}
+
+func decodeDevice(b []byte, d *openrtb.Device) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 2:
+			return f.string(&d.UA)
+		case 3:
+			return f.string(&d.IP)
+		case 4:
+			d.Geo = &openrtb.Geo{}
+			return f.embed("geo", func(b []byte) error { return decodeGeo(b, d.Geo) })
+		case 10:
+			return f.string(&d.Carrier)
+		case 11:
+			return f.string(&d.Language)
+		case 12:
+			return f.string(&d.Make)
+		case 13:
+			return f.string(&d.Model)
+		case 14:
+			return f.string(&d.OS)
+		case 15:
+			return f.string(&d.OSV)
+		case 17:
+			return f.int(&d.ConnectionType)
+		case 18:
+			return f.int(&d.DeviceType)
+		case 20:
+			return f.string(&d.IFA)
+		case 32:
+			return f.string(&d.LangB)
+		}
+		return nil
+	})
+}
+
+func decodeGeo(b []byte, g *openrtb.Geo) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.double(&g.Lat)
+		case 2:
+			return f.double(&g.Lon)
+		case 3:
+			return f.string(&g.Country)
+		case 4:
+			return f.string(&g.Region)
+		case 7:
+			return f.string(&g.City)
+		case 8:
+			return f.string(&g.ZIP)
+		case 9:
+			return f.int(&g.Type)
+		case 10:
+			return f.int(&g.UTCOffset)
+		}
+		return nil
+	})
+}
+
+func decodeUser(b []byte, u *openrtb.User) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&u.ID)
+		case 2:
+			return f.string(&u.BuyerUID)
+		case 3:
+			return f.int(&u.Yob)
+		case 4:
+			return f.string(&u.Gender)
+		case 5:
+			return f.string(&u.Keywords)
+		case 8:
+			var d openrtb.Data
+			err := f.embed("data", func(b []byte) error { return decodeData(b, &d) })
+			u.Data = append(u.Data, d)
+			return err
+		case 10:
+			u.Ext = &openrtb.UserExt{}
+			return f.string(&u.Ext.Consent)
+		}
+		return nil
+	})
+}
+
+func decodeData(b []byte, d *openrtb.Data) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.string(&d.ID)
+		case 2:
+			return f.string(&d.Name)
+		case 3:
+			var s openrtb.Segment
+			err := f.embed("segment", func(b []byte) error {
+				return fields(b, func(f field) error {
+					switch f.num {
+					case 1:
+						return f.string(&s.ID)
+					case 2:
+						return f.string(&s.Name)
+					case 3:
+						return f.string(&s.Value)
+					}
+					return nil
+				})
+			})
+			d.Segment = append(d.Segment, s)
+			return err
+		}
+		return nil
+	})
+}
+
+func decodeRegs(b []byte, r *openrtb.Regs) error {
+	ext := func() *openrtb.RegsExt {
+		if r.Ext == nil {
+			r.Ext = &openrtb.RegsExt{}
+		}
+		return r.Ext
+	}
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.int(&r.COPPA)
+		case 4:
+			return f.int(&ext().GDPR)
+		case 5:
+			return f.string(&ext().USPrivacy)
+		}
+		return nil
+	})
+}
+
+func decodeSource(b []byte, s *openrtb.Source) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.int(&s.FD)
+		case 2:
+			return f.string(&s.TID)
+		case 3:
+			return f.string(&s.PChain)
+		case 4:
+			sc := &openrtb.SupplyChain{}
+			s.Ext = &openrtb.SourceExt{SChain: sc}
+			return f.embed("schain", func(b []byte) error { return decodeSupplyChain(b, sc) })
+		}
+		return nil
+	})
+}
+
+func decodeSupplyChain(b []byte, sc *openrtb.SupplyChain) error {
+	return fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.int(&sc.Complete)
+		case 2:
+			var n openrtb.SupplyChainNode
+			err := f.embed("nodes", func(b []byte) error {
+				return fields(b, func(f field) error {
+					switch f.num {
+					case 1:
+						return f.string(&n.ASI)
+					case 2:
+						return f.string(&n.SID)
+					case 3:
+						return f.string(&n.RID)
+					case 4:
+						return f.string(&n.Name)
+					case 5:
+						return f.string(&n.Domain)
+					case 6:
+						return f.int(&n.HP)
+					}
+					return nil
+				})
+			})
+			sc.Nodes = append(sc.Nodes, n)
+			return err
+		case 3:
+			return f.string(&sc.Ver)
+		}
+		return nil
+	})
+}
+
+func marshalResponse(resp *openrtb.BidResponse) []byte {
+	var m message
+	m.string(1, resp.ID)
+	for _, sb := range resp.SeatBid {
+		m.embed(2, func(m *message) {
+			for i := range sb.Bid {
+				m.embed(1, func(m *message) { encodeBid(m, &sb.Bid[i]) })
+			}
+			m.string(2, sb.Seat)
+		})
+	}
+	m.string(3, resp.BidID)
+	m.string(4, resp.Cur)
+	m.int(6, resp.NBR)
+	return m
+}
+
+func encodeBid(m *message, bid *openrtb.Bid) {
+	m.string(1, bid.ID)
+	m.string(2, bid.ImpID)
+	m.double(3, bid.Price)
+	m.string(4, bid.AdID)
+	m.string(5, bid.NURL)
+	m.string(6, bid.AdM)
+	m.strings(7, bid.ADomain)
+	m.string(9, bid.CID)
+	m.string(10, bid.CrID)
+	m.string(13, bid.DealID)
+	m.strings(15, bid.Cat)
+	m.int(16, bid.W)
+	m.int(17, bid.H)
+	m.int(21, bid.Exp)
+	m.string(22, bid.BURL)
+	m.string(23, bid.LURL)
+	m.int(33, bid.MType)
+}
@@ -0,0 +1,413 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// openrtbMessage compiles testdata/openrtb.proto and returns the
+// descriptor of the named top-level message, so the tests read and write
+// the wire format from the .proto rather than from the codec's own field
+// numbers.
+func openrtbMessage(t *testing.T, name protoreflect.Name) protoreflect.MessageDescriptor {
+	t.Helper()
+	c := protocompile.Compiler{Resolver: &protocompile.SourceResolver{ImportPaths: []string{"testdata"}}}
+	files, err := c.Compile(context.Background(), "openrtb.proto")
+	if err != nil {
+		t.Fatalf("compiling openrtb.proto: %v", err)
+	}
+	md := files[0].Messages().ByName(name)
+	if md == nil {
+		t.Fatalf("openrtb.proto has no message %s", name)
+	}
+	return md
+}
+
+// toProto builds the protobuf message for v, an openrtb value, from its
+// JSON form: ext objects are flattened into their parent, where 2.6 has
+// them as first-class fields, and 0/1 flags become bools. A JSON field
+// the message does not declare fails the test, so every field the
+// simulator models must be in openrtb.proto.
+func toProto(t *testing.T, md protoreflect.MessageDescriptor, v any) *dynamicpb.Message {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		t.Fatal(err)
+	}
+	data, err = json.Marshal(reshape(t, md, obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := dynamicpb.NewMessage(md)
+	if err := protojson.Unmarshal(data, m); err != nil {
+		t.Fatalf("protojson.Unmarshal(%s): %v", data, err)
+	}
+	return m
+}
+
+func reshape(t *testing.T, md protoreflect.MessageDescriptor, obj map[string]any) map[string]any {
+	t.Helper()
+	if ext, ok := obj["ext"].(map[string]any); ok && md.Fields().ByName("ext") == nil {
+		delete(obj, "ext")
+		for k, v := range ext {
+			obj[k] = v
+		}
+	}
+	for k, v := range obj {
+		fd := md.Fields().ByName(protoreflect.Name(k))
+		if fd == nil {
+			t.Fatalf("openrtb.proto has no field %s.%s", md.FullName(), k)
+		}
+		obj[k] = reshapeValue(t, fd, v)
+	}
+	return obj
+}
+
+func reshapeValue(t *testing.T, fd protoreflect.FieldDescriptor, v any) any {
+	switch v := v.(type) {
+	case []any:
+		for i := range v {
+			v[i] = reshapeValue(t, fd, v[i])
+		}
+	case map[string]any:
+		return reshape(t, fd.Message(), v)
+	case float64:
+		if fd.Kind() == protoreflect.BoolKind {
+			return v != 0
+		}
+	}
+	return v
+}
+
+// checkKnown fails the test if m or any message in it holds fields its
+// descriptor does not declare with that wire type.
+func checkKnown(t *testing.T, m protoreflect.Message) {
+	t.Helper()
+	if len(m.GetUnknown()) > 0 {
+		t.Errorf("%s has undeclared fields %x", m.Descriptor().FullName(), m.GetUnknown())
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil {
+			return true
+		}
+		if fd.IsList() {
+			for i := 0; i < v.List().Len(); i++ {
+				checkKnown(t, v.List().Get(i).Message())
+			}
+		} else {
+			checkKnown(t, v.Message())
+		}
+		return true
+	})
+}
+
+// get returns the field at path in m, indexing into lists with path
+// elements such as "imp[1]".
+func get(m protoreflect.Message, path ...string) protoreflect.Value {
+	var v protoreflect.Value
+	for _, name := range path {
+		index := -1
+		if n := len(name); name[n-1] == ']' {
+			index = int(name[n-2] - '0')
+			name = name[:n-3]
+		}
+		v = m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+		if index >= 0 {
+			v = v.List().Get(index)
+		}
+		if v, ok := v.Interface().(protoreflect.Message); ok {
+			m = v
+		}
+	}
+	return v
+}
+
+// fullRequest sets every field the codec maps, within the enum values
+// openrtb.proto declares.
+func fullRequest() *openrtb.BidRequest {
+	content := &openrtb.Content{
+		Title: "News", Genre: "news", Keywords: "a,b", Cat: []string{"IAB12"},
+		Language: "en", LiveStream: 1, ContentRating: "TV-PG",
+	}
+	return &openrtb.BidRequest{
+		ID: "req-1",
+		Imp: []openrtb.Imp{
+			{
+				ID:     "imp-1",
+				Banner: &openrtb.Banner{W: 300, H: 250, Wmax: 320, Hmax: 480, Wmin: 200, Hmin: 50, Btype: []int{1, 4}, Battr: []int{1, 3}, Pos: 1},
+				Instl:  1, BidFloor: 0.5, Secure: 1, Tagid: "tag-1", Exp: 30,
+				PMP: &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{
+					{ID: "gold", BidFloor: 4, BidFloorCur: "EUR", WSeat: []string{"s1", "s2"}},
+				}},
+			},
+			{
+				ID: "imp-2",
+				Video: &openrtb.Video{
+					Mimes: []string{"video/mp4"}, Minduration: 5, Maxduration: 30,
+					Protocols: []int{openrtb.ProtocolVAST3, openrtb.ProtocolVAST4}, W: 640, H: 480,
+					StartDelay: openrtb.StartDelayGenericPostRoll, Placement: openrtb.PlacementInStream,
+					Linearity: openrtb.LinearityLinear, Skip: 1,
+				},
+				BidFloor: 1,
+			},
+			{ID: "imp-3", Native: &openrtb.Native{Request: `{"assets":[]}`, Ver: "1.2"}, BidFloor: 1},
+		},
+		App: &openrtb.App{
+			ID: "app-1", Name: "App", Bundle: "com.example.app", Domain: "example.com",
+			StoreURL: "https://store.example/app", Cat: []string{"IAB1"}, Ver: "2.0", Paid: 1, Content: content,
+		},
+		Site: &openrtb.Site{ID: "site-1", Name: "Site", Domain: "example.com", Page: "https://example.com/", Cat: []string{"IAB2"}, Content: content},
+		Device: &openrtb.Device{
+			UA: "Mozilla/5.0", IP: "203.0.113.7",
+			Geo:  &openrtb.Geo{Lat: 51.5, Lon: -0.1, Country: "GBR", Region: "ENG", City: "London", ZIP: "SW1", Type: 2, UTCOffset: -300},
+			Make: "Apple", Model: "iPhone", OS: "iOS", OSV: "17.0", DeviceType: openrtb.DeviceTypePhone,
+			Carrier: "O2", Language: "en", LangB: "en-GB", IFA: "ifa-1", ConnectionType: openrtb.ConnectionWifi,
+		},
+		User: &openrtb.User{
+			ID: "user-1", BuyerUID: "buyer-1", Gender: "F", Yob: 1990, Keywords: "sports",
+			Data: []openrtb.Data{{ID: "dp-1", Name: "Provider", Segment: []openrtb.Segment{{ID: "seg-1", Name: "Sports", Value: "1"}}}},
+			Ext:  &openrtb.UserExt{Consent: "CONSENT"},
+		},
+		Regs: &openrtb.Regs{COPPA: 1, Ext: &openrtb.RegsExt{GDPR: 1, USPrivacy: "1YNN"}},
+		Source: &openrtb.Source{FD: 1, TID: "tid-1", PChain: "pchain", Ext: &openrtb.SourceExt{SChain: &openrtb.SupplyChain{
+			Complete: 1, Ver: "1.0",
+			Nodes: []openrtb.SupplyChainNode{{ASI: "exchange.example", SID: "pub-1", RID: "req-1", Name: "Pub", Domain: "pub.example", HP: 1}},
+		}}},
+		At:   openrtb.AuctionFirstPrice,
+		Tmax: 100,
+		Cur:  []string{"USD", "EUR"},
+		Bcat: []string{"IAB25"},
+	}
+}
+
+// fullResponse sets every field the codec maps.
+func fullResponse() *openrtb.BidResponse {
+	return &openrtb.BidResponse{
+		ID:    "req-1",
+		BidID: "resp-1",
+		Cur:   "USD",
+		NBR:   openrtb.NBRInvalidRequest,
+		SeatBid: []openrtb.SeatBid{{Seat: "seat-1", Bid: []openrtb.Bid{{
+			ID: "bid-1", ImpID: "imp-1", Price: 2.5, AdID: "ad-1",
+			NURL: "https://dsp.example/win", BURL: "https://dsp.example/bill", LURL: "https://dsp.example/loss",
+			AdM: "<div/>", ADomain: []string{"brand.example"}, CID: "c-1", CrID: "cr-1", Cat: []string{"IAB1"},
+			W: 300, H: 250, MType: openrtb.MTypeBanner, DealID: "gold", Exp: 30,
+		}}}},
+	}
+}
+
+func TestProtobufCodec_RequestMatchesProto(t *testing.T) {
+	md := openrtbMessage(t, "BidRequest")
+	req := fullRequest()
+
+	data, err := Protobuf.EncodeRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(data, got); err != nil {
+		t.Fatalf("proto.Unmarshal() error = %v", err)
+	}
+	checkKnown(t, got)
+	if want := toProto(t, md, req); !proto.Equal(got, want) {
+		t.Errorf("EncodeRequest() =\n%v\nwant\n%v", got, want)
+	}
+
+	// And back, as the mock DSP reads it
+	data, err = proto.Marshal(toProto(t, md, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded openrtb.BidRequest
+	if err := Protobuf.DecodeRequest(data, &decoded); err != nil {
+		t.Fatalf("DecodeRequest() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, req) {
+		t.Errorf("DecodeRequest() =\n%+v\nwant\n%+v", decoded, *req)
+	}
+}
+
+func TestProtobufCodec_ResponseMatchesProto(t *testing.T) {
+	md := openrtbMessage(t, "BidResponse")
+	resp := fullResponse()
+
+	data, err := proto.Marshal(toProto(t, md, resp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded openrtb.BidResponse
+	if err := Protobuf.DecodeResponse(data, &decoded); err != nil {
+		t.Fatalf("DecodeResponse() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, resp) {
+		t.Errorf("DecodeResponse() =\n%+v\nwant\n%+v", decoded, *resp)
+	}
+
+	// And the mock DSP's side
+	data, err = Protobuf.EncodeResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(data, got); err != nil {
+		t.Fatalf("proto.Unmarshal() error = %v", err)
+	}
+	checkKnown(t, got)
+	if want := toProto(t, md, resp); !proto.Equal(got, want) {
+		t.Errorf("EncodeResponse() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestProtobufCodec_DecodeRequest_Packed(t *testing.T) {
+	// Repeated enums may arrive packed
+	var banner []byte
+	banner = protowire.AppendTag(banner, 5, protowire.BytesType)
+	banner = protowire.AppendBytes(banner, []byte{1, 4})
+	var imp []byte
+	imp = protowire.AppendTag(imp, 2, protowire.BytesType)
+	imp = protowire.AppendBytes(imp, banner)
+	var data []byte
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendBytes(data, imp)
+
+	var req openrtb.BidRequest
+	if err := Protobuf.DecodeRequest(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Imp) != 1 || req.Imp[0].Banner == nil || !reflect.DeepEqual(req.Imp[0].Banner.Btype, []int{1, 4}) {
+		t.Errorf("DecodeRequest() = %+v, want btype [1 4]", req)
+	}
+}
+
+// bidResponse encodes a response with one bid as a DSP would.
+func bidResponse(t *testing.T, id string, price float64) []byte {
+	t.Helper()
+	data, err := proto.Marshal(toProto(t, openrtbMessage(t, "BidResponse"), &openrtb.BidResponse{
+		ID:  id,
+		Cur: "USD",
+		SeatBid: []openrtb.SeatBid{{Seat: "seat-1", Bid: []openrtb.Bid{{
+			ID: "bid-1", ImpID: "imp-1", Price: price, ADomain: []string{"brand.example"},
+			W: 300, H: 250, Exp: 30, MType: openrtb.MTypeBanner,
+		}}}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestClient_Post_Protobuf(t *testing.T) {
+	md := openrtbMessage(t, "BidRequest")
+	respData := bidResponse(t, "req-1", 2.5)
+
+	var gotType, gotID, gotNative string
+	var gotImps int
+	var gotStartDelay int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		m := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(body, m); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		gotID = get(m, "id").String()
+		gotImps = get(m, "imp").List().Len()
+		gotStartDelay = int32(get(m, "imp[0]", "video", "startdelay").Int())
+		gotNative = get(m, "imp[1]", "native", "request").String()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(respData)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	req := &openrtb.BidRequest{
+		ID: "req-1",
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Video: &openrtb.Video{StartDelay: openrtb.StartDelayGenericPostRoll}, BidFloor: 1},
+			{ID: "imp-2", Native: &openrtb.Native{Request: `{"assets":[]}`, Ver: "1.2"}, BidFloor: 1},
+		},
+		App:  &openrtb.App{ID: "app-1", Content: &openrtb.Content{Title: "News"}},
+		Regs: &openrtb.Regs{Ext: &openrtb.RegsExt{GDPR: 1}},
+		At:   openrtb.AuctionFirstPrice,
+		Tmax: 100,
+	}
+	resp, err := client.Post(server.URL, req, WithCodec(Protobuf))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	if gotType != "application/x-protobuf" {
+		t.Errorf("Content-Type = %q, want application/x-protobuf", gotType)
+	}
+	if gotID != "req-1" || gotImps != 2 || gotStartDelay != openrtb.StartDelayGenericPostRoll {
+		t.Errorf("request id, imps, startdelay = %q, %d, %d; want req-1, 2, -2", gotID, gotImps, gotStartDelay)
+	}
+	if gotNative != `{"assets":[]}` {
+		t.Errorf("native request = %q, want the imp's", gotNative)
+	}
+	if resp.ID != "req-1" || resp.Cur != "USD" || len(resp.SeatBid) != 1 || resp.SeatBid[0].Seat != "seat-1" {
+		t.Fatalf("response = %+v, want one seat-1 seatbid in USD", resp)
+	}
+	bid := resp.SeatBid[0].Bid[0]
+	if bid.ID != "bid-1" || bid.Price != 2.5 || bid.W != 300 || bid.MType != openrtb.MTypeBanner || bid.Exp != 30 ||
+		len(bid.ADomain) != 1 || bid.ADomain[0] != "brand.example" {
+		t.Errorf("bid = %+v", bid)
+	}
+}
+
+func TestProtobufCodec_DecodeResponse_Invalid(t *testing.T) {
+	full := bidResponse(t, "req-1", 2.5)
+	tests := map[string][]byte{
+		"html error page": []byte("<html><body><h1>502 Bad Gateway</h1></body></html>"),
+		"truncated":       full[:len(full)-1],
+		"wrong wire type": protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 7),
+	}
+	for name, data := range tests {
+		var resp openrtb.BidResponse
+		if err := Protobuf.DecodeResponse(data, &resp); err == nil {
+			t.Errorf("%s: DecodeResponse() error = nil, want an error", name)
+		}
+	}
+
+	// Fields the simulator does not model are skipped
+	data := protowire.AppendVarint(protowire.AppendTag(bidResponse(t, "req-1", 1), 99, protowire.VarintType), 1)
+	var resp openrtb.BidResponse
+	if err := Protobuf.DecodeResponse(data, &resp); err != nil || resp.ID != "req-1" {
+		t.Errorf("DecodeResponse() = %q, %v; want req-1 with the unknown field skipped", resp.ID, err)
+	}
+}
+
+func TestCodecFor(t *testing.T) {
+	tests := map[string]Codec{
+		"application/x-protobuf":                Protobuf,
+		"application/x-protobuf; charset=utf-8": Protobuf,
+		"application/json":                      JSON,
+		"":                                      JSON,
+	}
+	for contentType, want := range tests {
+		if got := CodecFor(contentType); got != want {
+			t.Errorf("CodecFor(%q) = %T, want %T", contentType, got, want)
+		}
+	}
+}
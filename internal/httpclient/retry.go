@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RetryPolicy retries a call that failed transiently, as long as the
+// call's timeout leaves room for another attempt. Timeouts are never
+// retried, since they have used up the time a retry would need.
+type RetryPolicy struct {
+	Max         int           // retries after the first attempt; 0 disables retries
+	Backoff     time.Duration // wait before the first retry, doubling for each one after
+	ConnRefused bool          // retry when the connection is refused
+	ConnReset   bool          // retry when the connection is reset or closed before a response
+	Statuses    []int         // HTTP statuses to retry, such as 502 and 503
+}
+
+// WithRetry retries the call under p, calling onRetry, if non-nil, with
+// the failure that prompted each retry.
+func WithRetry(p RetryPolicy, onRetry func(err error)) CallOption {
+	return func(o *callOptions) {
+		o.retry = p
+		o.onRetry = onRetry
+	}
+}
+
+// retries reports whether err, from the attempt'th retry or the first
+// attempt for 0, is worth retrying under p.
+func (p RetryPolicy) retries(attempt int, err error) bool {
+	if attempt >= p.Max || IsTimeout(err) {
+		return false
+	}
+	if code, ok := StatusCode(err); ok {
+		return slices.Contains(p.Statuses, code)
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return p.ConnRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, fasthttp.ErrConnectionClosed), errors.Is(err, io.EOF):
+		return p.ConnReset
+	}
+	return false
+}
+
+// backoff returns the wait before the attempt'th retry, counting from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return p.Backoff << (attempt - 1)
+}
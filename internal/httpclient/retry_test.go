@@ -0,0 +1,114 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// flakyServer answers the first failures calls with status, or by closing
+// the connection when status is 0, and the rest with a bid.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if status == 0 {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"req-1","seatbid":[{"bid":[{"id":"bid-1","impid":"imp-1","price":2.5}]}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_Post_Retry(t *testing.T) {
+	policy := RetryPolicy{Max: 2, Backoff: time.Millisecond, ConnRefused: true, ConnReset: true, Statuses: []int{503}}
+
+	tests := []struct {
+		name      string
+		failures  int32
+		status    int
+		policy    RetryPolicy
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "503 recovers", failures: 1, status: 503, policy: policy, wantCalls: 2},
+		{name: "reset recovers", failures: 2, status: 0, policy: policy, wantCalls: 3},
+		{name: "retries exhausted", failures: 3, status: 503, policy: policy, wantCalls: 3, wantErr: true},
+		{name: "status not retried", failures: 1, status: 500, policy: policy, wantCalls: 1, wantErr: true},
+		{name: "reset not retried", failures: 1, status: 0, policy: RetryPolicy{Max: 2, Statuses: []int{503}}, wantCalls: 1, wantErr: true},
+		{name: "backoff past timeout", failures: 1, status: 503,
+			policy: RetryPolicy{Max: 2, Backoff: 5 * time.Second, Statuses: []int{503}}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := flakyServer(t, tt.failures, tt.status)
+			client := New(WithTimeout(time.Second))
+			defer client.Close()
+
+			var retried []error
+			resp, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"},
+				WithRetry(tt.policy, func(err error) { retried = append(retried, err) }))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (resp == nil || resp.ID != "req-1") {
+				t.Errorf("Post() = %+v, want the bid response", resp)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server got %d calls, want %d", got, tt.wantCalls)
+			}
+			if int32(len(retried)) != tt.wantCalls-1 {
+				t.Errorf("onRetry called %d times, want %d", len(retried), tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestClient_Post_RetryConnectionRefused(t *testing.T) {
+	client := New(WithTimeout(time.Second))
+	defer client.Close()
+
+	var retries int
+	_, err := client.Post("http://localhost:59999", &openrtb.BidRequest{ID: "req-1"},
+		WithRetry(RetryPolicy{Max: 2, Backoff: time.Millisecond, ConnRefused: true}, func(error) { retries++ }))
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+	if retries != 2 {
+		t.Errorf("retried %d times, want 2", retries)
+	}
+}
+
+func TestClient_Post_NoRetryOnTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(20 * time.Millisecond))
+	defer client.Close()
+
+	_, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req-1"},
+		WithRetry(RetryPolicy{Max: 2, ConnRefused: true, ConnReset: true, Statuses: []int{503}}, nil))
+	if !IsTimeout(err) {
+		t.Fatalf("Post() error = %v, want a timeout", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server got %d calls, want 1", got)
+	}
+}
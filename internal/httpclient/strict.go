@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// ErrStrictResponse is returned by calls made WithStrict for a response
+// that decodes but bends OpenRTB: it has fields the specification does
+// not define, bids without naming its currency, or bids on an impression
+// the request does not offer.
+var ErrStrictResponse = errors.New("strict parsing")
+
+// WithStrict rejects a response that lenient parsing would accept but
+// that is not well-formed OpenRTB, with ErrStrictResponse. Unknown fields
+// are only detected in JSON; protocol buffers skip them by design.
+func WithStrict() CallOption {
+	return func(o *callOptions) {
+		o.strict = true
+	}
+}
+
+// OpenRTB 2.6 bid response fields, by object. The contents of ext are
+// never checked.
+var (
+	responseFields = fieldSet("id", "seatbid", "bidid", "cur", "customdata", "nbr", "ext")
+	seatBidFields  = fieldSet("bid", "seat", "group", "ext")
+	bidFields      = fieldSet("id", "impid", "price", "nurl", "burl", "lurl", "adm", "adid", "adomain",
+		"bundle", "iurl", "cid", "crid", "tactic", "cattax", "cat", "attr", "apis", "api", "protocol",
+		"qagmediarating", "language", "langb", "dealid", "w", "h", "wratio", "hratio", "exp", "dur",
+		"mtype", "slotinsequence", "ext")
+)
+
+func fieldSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
+
+// fieldChecker is a Codec that can find the fields of an encoded
+// response outside the specification.
+type fieldChecker interface {
+	unknownField(data []byte) (string, error)
+}
+
+// unknownField returns the path of the first field in a JSON bid response
+// that OpenRTB does not define, such as "seatbid[0].bid[1].prise", or "".
+func (jsonCodec) unknownField(data []byte) (string, error) {
+	resp, err := jsonObject(data)
+	if err != nil {
+		return "", err
+	}
+	if f := unknownIn(resp, responseFields); f != "" {
+		return f, nil
+	}
+	seatBids, err := jsonArray(resp["seatbid"])
+	if err != nil {
+		return "", err
+	}
+	for i, raw := range seatBids {
+		sb, err := jsonObject(raw)
+		if err != nil {
+			return "", err
+		}
+		if f := unknownIn(sb, seatBidFields); f != "" {
+			return fmt.Sprintf("seatbid[%d].%s", i, f), nil
+		}
+		bids, err := jsonArray(sb["bid"])
+		if err != nil {
+			return "", err
+		}
+		for j, raw := range bids {
+			bid, err := jsonObject(raw)
+			if err != nil {
+				return "", err
+			}
+			if f := unknownIn(bid, bidFields); f != "" {
+				return fmt.Sprintf("seatbid[%d].bid[%d].%s", i, j, f), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func jsonObject(data []byte) (map[string]json.RawMessage, error) {
+	var m map[string]json.RawMessage
+	err := json.Unmarshal(data, &m)
+	return m, err
+}
+
+// jsonArray splits a JSON array into its elements; absent is empty.
+func jsonArray(data json.RawMessage) ([]json.RawMessage, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var a []json.RawMessage
+	err := json.Unmarshal(data, &a)
+	return a, err
+}
+
+// unknownIn returns the first field of obj, in name order, not in known,
+// or "".
+func unknownIn(obj map[string]json.RawMessage, known map[string]bool) string {
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		if !known[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkStrict returns an ErrStrictResponse error if resp, decoded from
+// data by codec, bends OpenRTB.
+func checkStrict(codec Codec, data []byte, req *openrtb.BidRequest, resp *openrtb.BidResponse) error {
+	if fc, ok := codec.(fieldChecker); ok {
+		f, err := fc.unknownField(data)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrStrictResponse, err)
+		}
+		if f != "" {
+			return fmt.Errorf("%w: unknown field %s", ErrStrictResponse, f)
+		}
+	}
+	if resp.IsNoBid() {
+		return nil
+	}
+	if resp.Cur == "" {
+		return fmt.Errorf("%w: cur is required with bids", ErrStrictResponse)
+	}
+	for _, sb := range resp.SeatBid {
+		for _, bid := range sb.Bid {
+			if !hasImp(req, bid.ImpID) {
+				return fmt.Errorf("%w: bid %s names impid %q, not in the request", ErrStrictResponse, bid.ID, bid.ImpID)
+			}
+		}
+	}
+	return nil
+}
+
+func hasImp(req *openrtb.BidRequest, id string) bool {
+	for i := range req.Imp {
+		if req.Imp[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestClient_Post_Strict(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string // "" for success
+	}{
+		{"well-formed", `{"id":"req-1","cur":"USD","seatbid":[{"seat":"s1","bid":[{"id":"b1","impid":"imp-1","price":2.5,"iurl":"http://img","ext":{"anything":1}}]}],"ext":{"x":true}}`, ""},
+		{"no bid without cur", `{"id":"req-1","nbr":2}`, ""},
+		{"unknown response field", `{"id":"req-1","cur":"USD","currency":"USD","seatbid":[]}`, "unknown field currency"},
+		{"unknown seatbid field", `{"id":"req-1","cur":"USD","seatbid":[{"seat_id":"s1","bid":[]}]}`, "unknown field seatbid[0].seat_id"},
+		{"unknown bid field", `{"id":"req-1","cur":"USD","seatbid":[{"bid":[{"id":"b1","impid":"imp-1","price":1},{"id":"b2","impid":"imp-1","prise":1}]}]}`, "unknown field seatbid[0].bid[1].prise"},
+		{"missing cur", `{"id":"req-1","seatbid":[{"bid":[{"id":"b1","impid":"imp-1","price":1}]}]}`, "cur is required"},
+		{"unknown impid", `{"id":"req-1","cur":"USD","seatbid":[{"bid":[{"id":"b1","impid":"imp-9","price":1}]}]}`, `impid "imp-9"`},
+	}
+
+	req := &openrtb.BidRequest{ID: "req-1", Imp: []openrtb.Imp{{ID: "imp-1"}}}
+	client := New(WithTimeout(5 * time.Second))
+	defer client.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			// Lenient parsing takes every one of them
+			if _, err := client.Post(server.URL, req); err != nil {
+				t.Fatalf("lenient Post() error = %v", err)
+			}
+
+			_, err := client.Post(server.URL, req, WithStrict())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("strict Post() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrStrictResponse) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("strict Post() error = %v, want ErrStrictResponse with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// HandshakeFunc is called after each TLS handshake the client completes,
+// with the dialed address, whether a previous session was resumed, and
+// how long the handshake took.
+type HandshakeFunc func(addr string, resumed bool, d time.Duration)
+
+// WithTLSConfig sets the TLS configuration for HTTPS requests. A session
+// cache is added if cfg has none, so new connections can resume earlier
+// sessions.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithHandshakeObserver reports every TLS handshake to f.
+func WithHandshakeObserver(f HandshakeFunc) Option {
+	return func(c *Client) {
+		c.onHandshake = f
+	}
+}
+
+// tlsConfigWithCache returns cfg, or an empty config, with a client
+// session cache.
+func tlsConfigWithCache(cfg *tls.Config) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+	if cfg.ClientSessionCache == nil {
+		cfg.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	return cfg
+}
+
+// configureHost installs a handshaking dialer on HTTPS host clients so
+// handshakes can be timed. fasthttp skips its own handshake for
+// connections that are already TLS.
+func (c *Client) configureHost(hc *fasthttp.HostClient) error {
+	if !hc.IsTLS || c.onHandshake == nil {
+		return nil
+	}
+	hc.DialTimeout = func(addr string, timeout time.Duration) (net.Conn, error) {
+		return c.dialTLS(addr, timeout)
+	}
+	return nil
+}
+
+// dialTLS dials addr and completes a TLS handshake within timeout.
+func (c *Client) dialTLS(addr string, timeout time.Duration) (net.Conn, error) {
+	deadline := time.Now().Add(timeout)
+	raw, err := fasthttp.DialTimeout(addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := c.client.TLSConfig
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		} else {
+			cfg.ServerName = addr
+		}
+	}
+
+	conn := tls.Client(raw, cfg)
+	if err := conn.SetDeadline(deadline); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	start := time.Now()
+	if err := conn.Handshake(); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	elapsed := time.Since(start)
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c.onHandshake(addr, conn.ConnectionState().DidResume, elapsed)
+	return conn, nil
+}
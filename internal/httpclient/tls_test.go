@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestClient_Post_TLSHandshakes(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	// Every request needs a new connection, and so a handshake
+	server.Config.SetKeepAlivesEnabled(false)
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	var mu sync.Mutex
+	var resumed []bool
+	client := New(
+		WithTimeout(5*time.Second),
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithHandshakeObserver(func(addr string, r bool, d time.Duration) {
+			mu.Lock()
+			resumed = append(resumed, r)
+			mu.Unlock()
+			if d <= 0 {
+				t.Errorf("handshake duration = %v, want > 0", d)
+			}
+		}),
+	)
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req"}); err != nil {
+			t.Fatalf("Post() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(resumed) != 3 {
+		t.Fatalf("handshakes = %d, want 3", len(resumed))
+	}
+	if resumed[0] {
+		t.Error("first handshake resumed a session, want a full handshake")
+	}
+	if !resumed[2] {
+		t.Error("later handshake did not resume the cached session")
+	}
+}
+
+func TestClient_Post_TLSUntrusted(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(5*time.Second), WithHandshakeObserver(func(string, bool, time.Duration) {
+		t.Error("observer called for a failed handshake")
+	}))
+	defer client.Close()
+
+	if _, err := client.Post(server.URL, &openrtb.BidRequest{ID: "req"}); err == nil {
+		t.Error("Post() to an untrusted server error = nil, want certificate error")
+	}
+}
@@ -0,0 +1,201 @@
+// Package market derives a clearing price feed from completed auctions.
+// Mock and external test bidders can query it to adapt their bidding to
+// the simulated market.
+package market
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// defaultWindow is the number of recent auctions retained per segment.
+const defaultWindow = 1000
+
+// Feed tracks recent clearing prices segmented by ad size and geo.
+// It implements engine.Observer. Safe for concurrent use.
+type Feed struct {
+	mu       sync.RWMutex
+	window   int
+	segments map[segmentKey]*segment
+}
+
+// Option configures the feed.
+type Option func(*Feed)
+
+// WithWindow sets how many recent auctions are retained per segment.
+func WithWindow(n int) Option {
+	return func(f *Feed) {
+		if n > 0 {
+			f.window = n
+		}
+	}
+}
+
+// New creates an empty price feed.
+func New(opts ...Option) *Feed {
+	f := &Feed{
+		window:   defaultWindow,
+		segments: make(map[segmentKey]*segment),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+type segmentKey struct {
+	size string // "WxH"
+	geo  string // geo.country as sent in the request
+}
+
+// segment is a ring of recent auctions for one size/geo pair. A price of
+// 0 marks an auction that did not clear.
+type segment struct {
+	prices []float64
+	next   int
+	full   bool
+}
+
+func (s *segment) add(price float64, window int) {
+	if s.prices == nil {
+		s.prices = make([]float64, window)
+	}
+	s.prices[s.next] = price
+	s.next++
+	if s.next == len(s.prices) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+func (s *segment) values() []float64 {
+	if s.full {
+		return s.prices
+	}
+	return s.prices[:s.next]
+}
+
+// ObserveAuction records the auction's clearing price under the size and
+// geo of its first impression.
+func (f *Feed) ObserveAuction(req *openrtb.BidRequest, _ []dispatcher.Result, outcome auction.Outcome) {
+	key := segmentKey{size: impSize(req)}
+	if req.Device != nil && req.Device.Geo != nil {
+		key.geo = req.Device.Geo.Country
+	}
+
+	var price float64
+	if outcome.Winner != nil {
+		price = outcome.ClearingPrice
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seg, ok := f.segments[key]
+	if !ok {
+		seg = &segment{}
+		f.segments[key] = seg
+	}
+	seg.add(price, f.window)
+}
+
+// impSize returns the "WxH" size of the request's first impression.
+func impSize(req *openrtb.BidRequest) string {
+	if len(req.Imp) == 0 {
+		return ""
+	}
+	var w, h int
+	switch imp := req.Imp[0]; {
+	case imp.Banner != nil:
+		w, h = imp.Banner.W, imp.Banner.H
+	case imp.Video != nil:
+		w, h = imp.Video.W, imp.Video.H
+	default:
+		return ""
+	}
+	return strconv.Itoa(w) + "x" + strconv.Itoa(h)
+}
+
+// Query filters the feed. Empty fields match every segment.
+type Query struct {
+	Size string // "WxH", e.g. "320x50"
+	Geo  string // geo.country, e.g. "USA"; matched case-insensitively
+}
+
+// Prices summarizes recent clearing prices for a query.
+type Prices struct {
+	Size     string  `json:"size,omitempty"`
+	Geo      string  `json:"geo,omitempty"`
+	Auctions int     `json:"auctions"`
+	Cleared  int     `json:"cleared"`
+	FillRate float64 `json:"fill_rate"`
+	Mean     float64 `json:"mean"`
+	Min      float64 `json:"min"`
+	P25      float64 `json:"p25"`
+	P50      float64 `json:"p50"`
+	P75      float64 `json:"p75"`
+	P90      float64 `json:"p90"`
+	Max      float64 `json:"max"`
+}
+
+// Prices returns a summary of recent clearing prices matching q.
+// Quantiles are computed over auctions that cleared.
+func (f *Feed) Prices(q Query) Prices {
+	p := Prices{Size: q.Size, Geo: q.Geo}
+	var cleared []float64
+
+	f.mu.RLock()
+	for key, seg := range f.segments {
+		if q.Size != "" && key.size != q.Size {
+			continue
+		}
+		if q.Geo != "" && !strings.EqualFold(key.geo, q.Geo) {
+			continue
+		}
+		for _, price := range seg.values() {
+			p.Auctions++
+			if price > 0 {
+				cleared = append(cleared, price)
+			}
+		}
+	}
+	f.mu.RUnlock()
+
+	p.Cleared = len(cleared)
+	if p.Auctions > 0 {
+		p.FillRate = float64(p.Cleared) / float64(p.Auctions)
+	}
+	if len(cleared) == 0 {
+		return p
+	}
+
+	sort.Float64s(cleared)
+	var sum float64
+	for _, price := range cleared {
+		sum += price
+	}
+	p.Mean = sum / float64(len(cleared))
+	p.Min = cleared[0]
+	p.P25 = quantile(cleared, 0.25)
+	p.P50 = quantile(cleared, 0.50)
+	p.P75 = quantile(cleared, 0.75)
+	p.P90 = quantile(cleared, 0.90)
+	p.Max = cleared[len(cleared)-1]
+	return p
+}
+
+// quantile returns the nearest-rank quantile of sorted values.
+func quantile(sorted []float64, q float64) float64 {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
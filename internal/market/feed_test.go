@@ -0,0 +1,94 @@
+package market
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func bannerRequest(w, h int, country string) *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID:     "req",
+		Imp:    []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{W: w, H: h}}},
+		Device: &openrtb.Device{Geo: &openrtb.Geo{Country: country}},
+	}
+}
+
+func cleared(price float64) auction.Outcome {
+	return auction.Outcome{Winner: &openrtb.Bid{Price: price}, ClearingPrice: price, WinnerIndex: 0}
+}
+
+func noFill() auction.Outcome {
+	return auction.Outcome{WinnerIndex: -1}
+}
+
+func TestFeed_Prices(t *testing.T) {
+	f := New()
+	for i := 1; i <= 10; i++ {
+		f.ObserveAuction(bannerRequest(320, 50, "USA"), nil, cleared(float64(i)))
+	}
+	f.ObserveAuction(bannerRequest(320, 50, "USA"), nil, noFill())
+	f.ObserveAuction(bannerRequest(300, 250, "USA"), nil, cleared(20))
+	f.ObserveAuction(bannerRequest(320, 50, "DEU"), nil, cleared(30))
+
+	p := f.Prices(Query{Size: "320x50", Geo: "usa"})
+	if p.Auctions != 11 || p.Cleared != 10 {
+		t.Fatalf("Auctions, Cleared = %d, %d, want 11, 10", p.Auctions, p.Cleared)
+	}
+	if math.Abs(p.FillRate-10.0/11) > 1e-9 {
+		t.Errorf("FillRate = %v, want %v", p.FillRate, 10.0/11)
+	}
+	if p.Mean != 5.5 || p.Min != 1 || p.Max != 10 {
+		t.Errorf("Mean, Min, Max = %v, %v, %v, want 5.5, 1, 10", p.Mean, p.Min, p.Max)
+	}
+	if p.P50 != 5 || p.P90 != 9 {
+		t.Errorf("P50, P90 = %v, %v, want 5, 9", p.P50, p.P90)
+	}
+
+	if all := f.Prices(Query{}); all.Auctions != 13 || all.Max != 30 {
+		t.Errorf("unfiltered Auctions, Max = %d, %v, want 13, 30", all.Auctions, all.Max)
+	}
+	if geo := f.Prices(Query{Geo: "DEU"}); geo.Cleared != 1 || geo.P50 != 30 {
+		t.Errorf("DEU Cleared, P50 = %d, %v, want 1, 30", geo.Cleared, geo.P50)
+	}
+}
+
+func TestFeed_Empty(t *testing.T) {
+	p := New().Prices(Query{Size: "320x50"})
+	if p.Auctions != 0 || p.Mean != 0 || p.FillRate != 0 {
+		t.Errorf("Prices() = %+v, want zero", p)
+	}
+	if p.Size != "320x50" {
+		t.Errorf("Size = %q, want echoed 320x50", p.Size)
+	}
+}
+
+func TestFeed_Window(t *testing.T) {
+	f := New(WithWindow(5))
+	for i := 1; i <= 8; i++ {
+		f.ObserveAuction(bannerRequest(320, 50, "USA"), nil, cleared(float64(i)))
+	}
+
+	p := f.Prices(Query{})
+	if p.Auctions != 5 {
+		t.Errorf("Auctions = %d, want 5", p.Auctions)
+	}
+	if p.Min != 4 || p.Max != 8 {
+		t.Errorf("Min, Max = %v, %v, want 4, 8 (oldest evicted)", p.Min, p.Max)
+	}
+}
+
+func TestFeed_VideoSize(t *testing.T) {
+	f := New()
+	req := &openrtb.BidRequest{
+		ID:  "req",
+		Imp: []openrtb.Imp{{ID: "1", Video: &openrtb.Video{W: 1920, H: 1080}}},
+	}
+	f.ObserveAuction(req, nil, cleared(12))
+
+	if p := f.Prices(Query{Size: "1920x1080"}); p.Cleared != 1 {
+		t.Errorf("Cleared = %d, want 1", p.Cleared)
+	}
+}
@@ -0,0 +1,220 @@
+// Package mockdsp implements configurable in-process OpenRTB bidders.
+// Mock bidders answer bid requests with prices, no-bids, errors, and latency
+// drawn from configured distributions, for end-to-end simulator testing.
+package mockdsp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Bidder is an http.Handler that responds to OpenRTB bid requests.
+type Bidder struct {
+	cfg BidderConfig
+	src *randutil.Source
+
+	requests atomic.Uint64
+	bids     atomic.Uint64
+	noBids   atomic.Uint64
+	errors   atomic.Uint64
+	notices  atomic.Uint64
+}
+
+// NewBidder creates a mock bidder. A nil src uses the default source.
+func NewBidder(cfg BidderConfig, src *randutil.Source) *Bidder {
+	if src == nil {
+		src = randutil.Default()
+	}
+	cfg.applyDefaults()
+	return &Bidder{cfg: cfg, src: src}
+}
+
+// Name returns the bidder's configured name.
+func (b *Bidder) Name() string {
+	return b.cfg.Name
+}
+
+// ServeHTTP handles bid requests on any path except /notice, which
+// accepts win/billing/loss notifications. Requests sent as protocol
+// buffers (application/x-protobuf) are answered in kind; anything else
+// is OpenRTB JSON.
+func (b *Bidder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/notice" {
+		b.notices.Add(1)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	b.requests.Add(1)
+
+	codec := httpclient.CodecFor(r.Header.Get("Content-Type"))
+	var req openrtb.BidRequest
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = codec.DecodeRequest(body, &req)
+	}
+	if err != nil {
+		b.errors.Add(1)
+		http.Error(w, "invalid bid request", http.StatusBadRequest)
+		return
+	}
+
+	if delay := b.cfg.LatencyMS.Sample(b.src); delay > 0 {
+		time.Sleep(time.Duration(delay * float64(time.Millisecond)))
+	}
+
+	if b.src.Chance(b.cfg.ErrorRate) {
+		b.errors.Add(1)
+		http.Error(w, "simulated bidder error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(req.Imp) == 0 || b.src.Chance(b.cfg.NoBidRate) {
+		b.noBids.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp := b.buildResponse(&req, r.Host)
+	if len(resp.SeatBid[0].Bid) == 0 {
+		b.noBids.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	b.bids.Add(uint64(len(resp.SeatBid[0].Bid)))
+
+	data, err := codec.EncodeResponse(resp)
+	if err != nil {
+		b.errors.Add(1)
+		http.Error(w, "encoding bid response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", codec.ContentType())
+	_, _ = w.Write(data)
+}
+
+// buildResponse bids on every impression in the request that the
+// bidder's rules do not skip.
+func (b *Bidder) buildResponse(req *openrtb.BidRequest, host string) *openrtb.BidResponse {
+	bids := make([]openrtb.Bid, 0, len(req.Imp))
+	for i, imp := range req.Imp {
+		price, ok := b.price(req, &imp)
+		if !ok || price <= 0 {
+			continue
+		}
+		deal := b.pickDeal(imp.PMP)
+		if deal != nil {
+			price = max(price, deal.BidFloor)
+		} else if imp.PMP != nil && imp.PMP.PrivateAuction == 1 {
+			continue
+		}
+
+		bid := openrtb.Bid{
+			ID:      req.ID + "-" + strconv.Itoa(i),
+			ImpID:   imp.ID,
+			Price:   price,
+			AdID:    b.cfg.Name + "-ad",
+			CrID:    b.cfg.Name + "-cr",
+			ADomain: []string{b.cfg.ADomains[b.src.IntN(len(b.cfg.ADomains))]},
+			Exp:     b.cfg.Exp,
+		}
+		if cats := b.cfg.Categories; len(cats) > 0 {
+			bid.Cat = []string{cats[b.src.IntN(len(cats))]}
+		}
+		if deal != nil {
+			bid.DealID = deal.ID
+		}
+		switch {
+		case imp.Video != nil:
+			bid.W, bid.H = imp.Video.W, imp.Video.H
+			bid.MType = openrtb.MTypeVideo
+			bid.AdM = vastInline(bid.ID, b.cfg.Name, imp.Video)
+		default:
+			if imp.Banner != nil {
+				bid.W, bid.H = imp.Banner.W, imp.Banner.H
+			}
+			bid.MType = openrtb.MTypeBanner
+			bid.AdM = `<div class="ad">` + b.cfg.Name + `</div>`
+		}
+		if b.cfg.Notices {
+			base := "http://" + host + "/notice?bidder=" + b.cfg.Name + "&price=" + openrtb.MacroAuctionPrice
+			bid.NURL = base + "&type=win"
+			bid.BURL = base + "&type=billing"
+			bid.LURL = base + "&type=loss&reason=" + openrtb.MacroAuctionLoss
+		}
+		bids = append(bids, bid)
+	}
+
+	resp := &openrtb.BidResponse{ID: req.ID, BidID: req.ID + "-" + b.cfg.Name, Cur: b.cfg.Cur}
+	resp.SeatBid = []openrtb.SeatBid{{Seat: b.cfg.Seat, Bid: bids}}
+	return resp
+}
+
+// pickDeal returns a deal from pmp that the bidder's seat may bid on, with
+// probability DealRate, or nil to bid on the open market.
+func (b *Bidder) pickDeal(pmp *openrtb.PMP) *openrtb.Deal {
+	if pmp == nil || !b.src.Chance(b.cfg.DealRate) {
+		return nil
+	}
+	var eligible []*openrtb.Deal
+	for i, d := range pmp.Deals {
+		if len(d.WSeat) == 0 || slices.Contains(d.WSeat, b.cfg.Seat) {
+			eligible = append(eligible, &pmp.Deals[i])
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+	return eligible[b.src.IntN(len(eligible))]
+}
+
+// vastInline returns a minimal VAST 3.0 inline document for a video bid.
+// The creative duration is the impression's maximum, or 15s if unset.
+func vastInline(adID, name string, v *openrtb.Video) string {
+	dur := v.Maxduration
+	if dur <= 0 {
+		dur = 15
+	}
+	return `<VAST version="3.0"><Ad id="` + adID + `"><InLine>` +
+		`<AdSystem>` + name + `</AdSystem><AdTitle>` + name + ` video</AdTitle>` +
+		`<Creatives><Creative><Linear>` +
+		`<Duration>` + fmt.Sprintf("%02d:%02d:%02d", dur/3600, dur/60%60, dur%60) + `</Duration>` +
+		`<MediaFiles><MediaFile delivery="progressive" type="video/mp4" width="` +
+		strconv.Itoa(v.W) + `" height="` + strconv.Itoa(v.H) + `">` +
+		`https://cdn.example.com/` + name + `.mp4</MediaFile></MediaFiles>` +
+		`</Linear></Creative></Creatives></InLine></Ad></VAST>`
+}
+
+// Stats is a point-in-time view of a bidder's counters.
+type Stats struct {
+	Requests uint64
+	Bids     uint64
+	NoBids   uint64
+	Errors   uint64
+	Notices  uint64
+}
+
+// Stats returns the bidder's counters.
+func (b *Bidder) Stats() Stats {
+	return Stats{
+		Requests: b.requests.Load(),
+		Bids:     b.bids.Load(),
+		NoBids:   b.noBids.Load(),
+		Errors:   b.errors.Load(),
+		Notices:  b.notices.Load(),
+	}
+}
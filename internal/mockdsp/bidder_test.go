@@ -0,0 +1,277 @@
+package mockdsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func bidRequestBody(t *testing.T) *bytes.Reader {
+	t.Helper()
+	req := openrtb.BidRequest{
+		ID: "req-1",
+		Imp: []openrtb.Imp{
+			{ID: "1", Banner: &openrtb.Banner{W: 320, H: 50}},
+			{ID: "2", Banner: &openrtb.Banner{W: 300, H: 250}},
+		},
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(data)
+}
+
+func TestBidder_Bids(t *testing.T) {
+	b := NewBidder(BidderConfig{
+		Name:  "dsp",
+		Price: randutil.Dist{Type: randutil.DistFixed, Value: 2.5},
+	}, randutil.New(1))
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", bidRequestBody(t)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var resp openrtb.BidResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.ID != "req-1" {
+		t.Errorf("ID = %q, want req-1", resp.ID)
+	}
+	bids := resp.AllBids()
+	if len(bids) != 2 {
+		t.Fatalf("len(bids) = %d, want 2", len(bids))
+	}
+	if bids[0].Price != 2.5 {
+		t.Errorf("Price = %v, want 2.5", bids[0].Price)
+	}
+	if bids[1].ImpID != "2" || bids[1].W != 300 || bids[1].H != 250 {
+		t.Errorf("bid[1] = imp %s %dx%d, want imp 2 300x250", bids[1].ImpID, bids[1].W, bids[1].H)
+	}
+	if resp.SeatBid[0].Seat != "dsp" {
+		t.Errorf("Seat = %q, want dsp", resp.SeatBid[0].Seat)
+	}
+	if bids[0].NURL != "" {
+		t.Errorf("NURL = %q, want empty without notices", bids[0].NURL)
+	}
+
+	if s := b.Stats(); s.Requests != 1 || s.Bids != 2 {
+		t.Errorf("Stats = %+v, want 1 request and 2 bids", s)
+	}
+}
+
+func TestBidder_Protobuf(t *testing.T) {
+	b := NewBidder(BidderConfig{
+		Name:  "dsp",
+		Price: randutil.Dist{Type: randutil.DistFixed, Value: 2.5},
+	}, randutil.New(1))
+
+	data, err := httpclient.Protobuf.EncodeRequest(&openrtb.BidRequest{
+		ID:  "req-1",
+		Imp: []openrtb.Imp{{ID: "1", Banner: &openrtb.Banner{W: 300, H: 250}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/bid", bytes.NewReader(data))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-protobuf" {
+		t.Errorf("Content-Type = %q, want application/x-protobuf", ct)
+	}
+	var resp openrtb.BidResponse
+	if err := httpclient.Protobuf.DecodeResponse(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	bids := resp.AllBids()
+	if resp.ID != "req-1" || len(bids) != 1 || bids[0].ImpID != "1" || bids[0].Price != 2.5 || bids[0].W != 300 {
+		t.Errorf("response = %+v, want one 2.5 bid on imp 1 at 300x250", resp)
+	}
+}
+
+func TestBidder_CategoriesAndDomains(t *testing.T) {
+	b := NewBidder(BidderConfig{
+		Name:       "dsp",
+		Categories: []string{"IAB19"},
+		ADomains:   []string{"brand.com"},
+	}, randutil.New(1))
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", bidRequestBody(t)))
+
+	var resp openrtb.BidResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	for _, bid := range resp.AllBids() {
+		if len(bid.Cat) != 1 || bid.Cat[0] != "IAB19" || len(bid.ADomain) != 1 || bid.ADomain[0] != "brand.com" {
+			t.Errorf("bid %s cat/adomain = %v/%v, want [IAB19]/[brand.com]", bid.ID, bid.Cat, bid.ADomain)
+		}
+	}
+}
+
+func TestBidder_VideoBid(t *testing.T) {
+	b := NewBidder(BidderConfig{Name: "dsp"}, randutil.New(1))
+
+	data, _ := json.Marshal(openrtb.BidRequest{
+		ID: "req-1",
+		Imp: []openrtb.Imp{{ID: "v1", Video: &openrtb.Video{
+			Mimes: []string{"video/mp4"}, Maxduration: 30, W: 1280, H: 720,
+		}}},
+	})
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", bytes.NewReader(data)))
+
+	var resp openrtb.BidResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	bid := resp.AllBids()[0]
+	if bid.MType != openrtb.MTypeVideo {
+		t.Errorf("MType = %d, want %d", bid.MType, openrtb.MTypeVideo)
+	}
+	if bid.W != 1280 || bid.H != 720 {
+		t.Errorf("size = %dx%d, want 1280x720", bid.W, bid.H)
+	}
+	if !strings.HasPrefix(bid.AdM, "<VAST") || !strings.Contains(bid.AdM, "<Duration>00:00:30</Duration>") {
+		t.Errorf("AdM = %q, want VAST with 30s duration", bid.AdM)
+	}
+}
+
+func TestBidder_NoBid(t *testing.T) {
+	b := NewBidder(BidderConfig{Name: "dsp", NoBidRate: 1}, randutil.New(1))
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", bidRequestBody(t)))
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+	if s := b.Stats(); s.NoBids != 1 {
+		t.Errorf("NoBids = %d, want 1", s.NoBids)
+	}
+}
+
+func TestBidder_Error(t *testing.T) {
+	b := NewBidder(BidderConfig{Name: "dsp", ErrorRate: 1}, randutil.New(1))
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", bidRequestBody(t)))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	if s := b.Stats(); s.Errors != 1 {
+		t.Errorf("Errors = %d, want 1", s.Errors)
+	}
+}
+
+func TestBidder_InvalidRequest(t *testing.T) {
+	b := NewBidder(BidderConfig{Name: "dsp"}, randutil.New(1))
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", strings.NewReader("{")))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestBidder_Notices(t *testing.T) {
+	b := NewBidder(BidderConfig{Name: "dsp", Notices: true}, randutil.New(1))
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/bid", "application/json", bidRequestBody(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var br openrtb.BidResponse
+	if err := json.NewDecoder(resp.Body).Decode(&br); err != nil {
+		t.Fatal(err)
+	}
+	bid := br.AllBids()[0]
+	for name, u := range map[string]string{"nurl": bid.NURL, "burl": bid.BURL, "lurl": bid.LURL} {
+		if !strings.HasPrefix(u, srv.URL+"/notice?") {
+			t.Errorf("%s = %q, want prefix %s/notice?", name, u, srv.URL)
+		}
+	}
+	if !strings.Contains(bid.NURL, openrtb.MacroAuctionPrice) {
+		t.Errorf("NURL = %q, want %s macro", bid.NURL, openrtb.MacroAuctionPrice)
+	}
+
+	nresp, err := http.Get(srv.URL + "/notice?type=win")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nresp.Body.Close()
+	if got := b.Stats().Notices; got != 1 {
+		t.Errorf("Notices = %d, want 1", got)
+	}
+}
+
+func TestBidder_DealBids(t *testing.T) {
+	b := NewBidder(BidderConfig{
+		Name:     "dsp",
+		Seat:     "seat-1",
+		Price:    randutil.Dist{Type: randutil.DistFixed, Value: 2.5},
+		DealRate: 1,
+	}, randutil.New(1))
+
+	pmp := &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{
+		{ID: "other-seat", BidFloor: 1, WSeat: []string{"seat-2"}},
+		{ID: "gold", BidFloor: 4, WSeat: []string{"seat-1"}},
+	}}
+	req := &openrtb.BidRequest{ID: "req-1", Imp: []openrtb.Imp{
+		{ID: "1", Banner: &openrtb.Banner{W: 320, H: 50}, PMP: pmp},
+		{ID: "2", Banner: &openrtb.Banner{W: 320, H: 50}},
+	}}
+	bids := b.buildResponse(req, "dsp.test").AllBids()
+	if len(bids) != 2 {
+		t.Fatalf("len(bids) = %d, want 2", len(bids))
+	}
+	if bids[0].DealID != "gold" || bids[0].Price != 4 {
+		t.Errorf("deal bid = %s at %v, want gold at its 4.0 floor", bids[0].DealID, bids[0].Price)
+	}
+	if bids[0].Exp != 0 {
+		t.Errorf("Exp = %d without exp configured, want 0", bids[0].Exp)
+	}
+	if bids[1].DealID != "" || bids[1].Price != 2.5 {
+		t.Errorf("open bid = %q at %v, want no deal at 2.5", bids[1].DealID, bids[1].Price)
+	}
+
+	// A private auction without an eligible deal gets no bid
+	b.cfg.DealRate = 0
+	if bids := b.buildResponse(req, "dsp.test").AllBids(); len(bids) != 1 || bids[0].ImpID != "2" {
+		t.Errorf("bids = %+v, want only the open impression", bids)
+	}
+}
+
+func TestBidder_MethodNotAllowed(t *testing.T) {
+	b := NewBidder(BidderConfig{Name: "dsp"}, nil)
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bid", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
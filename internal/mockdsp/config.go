@@ -0,0 +1,149 @@
+package mockdsp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cass/rtb-simulator/internal/currency"
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+// Config lists the mock bidders to serve.
+type Config struct {
+	Bidders []BidderConfig `yaml:"bidders"`
+}
+
+// BidderConfig defines the behavior of a single mock bidder.
+type BidderConfig struct {
+	Name string `yaml:"name"`
+	Port int    `yaml:"port"`
+	Seat string `yaml:"seat"`
+
+	// NoBidRate and ErrorRate are per-request probabilities in [0, 1].
+	NoBidRate float64 `yaml:"no_bid_rate"`
+	ErrorRate float64 `yaml:"error_rate"`
+
+	// Price is the CPM bid price distribution, in Cur.
+	Price randutil.Dist `yaml:"price"`
+
+	// Cur is the currency the bidder responds in. Defaults to USD.
+	Cur string `yaml:"cur"`
+
+	// LatencyMS is the response delay distribution in milliseconds.
+	LatencyMS randutil.Dist `yaml:"latency_ms"`
+
+	// DealRate is the probability of bidding on a deal offered in the
+	// impression's private marketplace rather than the open market. A
+	// deal bid is priced at least at the deal's floor.
+	DealRate float64 `yaml:"deal_rate"`
+
+	// Exp is the bid's expiry in seconds (bid.exp), the time the bidder
+	// will honor a win for. Zero leaves exp out.
+	Exp int `yaml:"exp"`
+
+	// Notices attaches nurl/burl/lurl pointing back at this bidder so
+	// the simulator's notification path can be exercised end-to-end.
+	Notices bool `yaml:"notices"`
+
+	// Categories and ADomains are the IAB content categories (cat) and
+	// advertiser domains (adomain) the bidder's creatives declare; each
+	// bid picks one of each at random. ADomains defaults to
+	// <name>.example.com; without Categories bids declare none.
+	Categories []string `yaml:"categories"`
+	ADomains   []string `yaml:"adomains"`
+
+	// Rules make bids depend on the impression's size, country, and
+	// placement, applied in order; see Rule.
+	Rules []Rule `yaml:"rules"`
+}
+
+// LoadConfig reads and validates a mock DSP configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	for i := range cfg.Bidders {
+		cfg.Bidders[i].applyDefaults()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func (b *BidderConfig) applyDefaults() {
+	if b.Price.IsZero() {
+		b.Price = randutil.Dist{Type: randutil.DistUniform, Min: 0.5, Max: 5.0}
+	}
+	if b.LatencyMS.IsZero() {
+		b.LatencyMS = randutil.Dist{Type: randutil.DistFixed, Value: 0}
+	}
+	if b.Seat == "" {
+		b.Seat = b.Name
+	}
+	if b.Cur == "" {
+		b.Cur = currency.Default
+	}
+	if len(b.ADomains) == 0 {
+		b.ADomains = []string{b.Name + ".example.com"}
+	}
+}
+
+// Validate checks the configuration for errors.
+func (c *Config) Validate() error {
+	if len(c.Bidders) == 0 {
+		return errors.New("at least one bidder must be configured")
+	}
+	ports := make(map[int]string, len(c.Bidders))
+	for i, b := range c.Bidders {
+		if b.Name == "" {
+			return fmt.Errorf("bidders[%d].name is required", i)
+		}
+		if b.Port <= 0 || b.Port > 65535 {
+			return fmt.Errorf("bidders[%d].port must be between 1 and 65535", i)
+		}
+		if other, ok := ports[b.Port]; ok {
+			return fmt.Errorf("bidders[%d].port %d already used by %s", i, b.Port, other)
+		}
+		ports[b.Port] = b.Name
+		if b.NoBidRate < 0 || b.NoBidRate > 1 {
+			return fmt.Errorf("bidders[%d].no_bid_rate must be between 0 and 1", i)
+		}
+		if b.ErrorRate < 0 || b.ErrorRate > 1 {
+			return fmt.Errorf("bidders[%d].error_rate must be between 0 and 1", i)
+		}
+		if b.Cur != "" && !currency.ValidCode(b.Cur) {
+			return fmt.Errorf("bidders[%d].cur %q is not a three-letter currency code", i, b.Cur)
+		}
+		if b.DealRate < 0 || b.DealRate > 1 {
+			return fmt.Errorf("bidders[%d].deal_rate must be between 0 and 1", i)
+		}
+		if b.Exp < 0 {
+			return fmt.Errorf("bidders[%d].exp must not be negative", i)
+		}
+		if err := b.Price.Validate(); err != nil {
+			return fmt.Errorf("bidders[%d].price: %w", i, err)
+		}
+		if err := b.LatencyMS.Validate(); err != nil {
+			return fmt.Errorf("bidders[%d].latency_ms: %w", i, err)
+		}
+		for j := range b.Rules {
+			if err := b.Rules[j].validate(); err != nil {
+				return fmt.Errorf("bidders[%d].rules[%d].%w", i, j, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package mockdsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mockdsp.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+bidders:
+  - name: a
+    port: 9000
+    no_bid_rate: 0.3
+    price:
+      type: uniform
+      min: 1
+      max: 2
+    latency_ms:
+      type: fixed
+      value: 5
+  - name: b
+    port: 9001
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Bidders) != 2 {
+		t.Fatalf("len(Bidders) = %d, want 2", len(cfg.Bidders))
+	}
+	a := cfg.Bidders[0]
+	if a.NoBidRate != 0.3 || a.Price.Type != randutil.DistUniform || a.LatencyMS.Value != 5 {
+		t.Errorf("Bidders[0] = %+v", a)
+	}
+	b := cfg.Bidders[1]
+	if b.Price.IsZero() {
+		t.Error("Bidders[1].Price not defaulted")
+	}
+	if b.Seat != "b" {
+		t.Errorf("Bidders[1].Seat = %q, want b", b.Seat)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no bidders", "bidders: []", "at least one bidder"},
+		{"missing name", "bidders: [{port: 9000}]", "name is required"},
+		{"bad port", "bidders: [{name: a, port: 0}]", "port must be"},
+		{"duplicate port", "bidders: [{name: a, port: 9000}, {name: b, port: 9000}]", "already used"},
+		{"no bid rate", "bidders: [{name: a, port: 9000, no_bid_rate: 1.5}]", "no_bid_rate"},
+		{"error rate", "bidders: [{name: a, port: 9000, error_rate: -1}]", "error_rate"},
+		{"negative exp", "bidders: [{name: a, port: 9000, exp: -1}]", "exp"},
+		{"bad dist", "bidders: [{name: a, port: 9000, price: {type: pareto}}]", "price"},
+		{"bad rule", "bidders: [{name: a, port: 9000, rules: [{sizes: [big]}]}]", "rules[0].sizes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadConfig() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_MissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadConfig() error = nil, want error")
+	}
+}
+
+func TestLoadConfig_Example(t *testing.T) {
+	if _, err := LoadConfig("../../mockdsp.yaml"); err != nil {
+		t.Errorf("example mockdsp.yaml: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package mockdsp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Rule changes how a bidder bids on the impressions it matches, to give
+// it targeting like real demand. An impression matches when it has one of
+// Sizes, its device is in one of Countries, and it is an interstitial or
+// not, as Interstitial says; conditions left unset match any impression.
+//
+// Skip no-bids a matching impression. Otherwise Price replaces the
+// bidder's price distribution and Multiplier scales the price drawn.
+// Every matching rule applies in order, so the last Price wins and
+// multipliers compound.
+type Rule struct {
+	Sizes        []string `yaml:"sizes"`        // banner or video WxH, such as 300x250
+	Countries    []string `yaml:"countries"`    // device.geo.country, ISO-3166-1 alpha-3
+	Interstitial *bool    `yaml:"interstitial"` // imp.instl
+
+	Skip       bool          `yaml:"skip"`
+	Price      randutil.Dist `yaml:"price"`
+	Multiplier float64       `yaml:"multiplier"`
+}
+
+// matches reports whether imp in req meets all of the rule's conditions.
+func (r *Rule) matches(req *openrtb.BidRequest, imp *openrtb.Imp) bool {
+	if len(r.Sizes) > 0 && !slices.Contains(r.Sizes, impSize(imp)) {
+		return false
+	}
+	if len(r.Countries) > 0 && !slices.Contains(r.Countries, country(req)) {
+		return false
+	}
+	if r.Interstitial != nil && *r.Interstitial != (imp.Instl == 1) {
+		return false
+	}
+	return true
+}
+
+func (r *Rule) validate() error {
+	for _, s := range r.Sizes {
+		w, h, ok := strings.Cut(s, "x")
+		if !ok || !positive(w) || !positive(h) {
+			return fmt.Errorf("sizes: %q is not a size such as 300x250", s)
+		}
+	}
+	if r.Multiplier < 0 {
+		return errors.New("multiplier must not be negative")
+	}
+	if !r.Price.IsZero() {
+		if err := r.Price.Validate(); err != nil {
+			return fmt.Errorf("price: %w", err)
+		}
+	}
+	return nil
+}
+
+func positive(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0
+}
+
+// price draws the bid price for imp under the bidder's rules, reporting
+// false if a rule skips the impression.
+func (b *Bidder) price(req *openrtb.BidRequest, imp *openrtb.Imp) (float64, bool) {
+	dist, multiplier := b.cfg.Price, 1.0
+	for i := range b.cfg.Rules {
+		r := &b.cfg.Rules[i]
+		if !r.matches(req, imp) {
+			continue
+		}
+		if r.Skip {
+			return 0, false
+		}
+		if !r.Price.IsZero() {
+			dist = r.Price
+		}
+		if r.Multiplier > 0 {
+			multiplier *= r.Multiplier
+		}
+	}
+	return dist.Sample(b.src) * multiplier, true
+}
+
+// impSize returns the WxH of imp's banner or video, or "" if it has
+// neither.
+func impSize(imp *openrtb.Imp) string {
+	switch {
+	case imp.Banner != nil:
+		return strconv.Itoa(imp.Banner.W) + "x" + strconv.Itoa(imp.Banner.H)
+	case imp.Video != nil:
+		return strconv.Itoa(imp.Video.W) + "x" + strconv.Itoa(imp.Video.H)
+	}
+	return ""
+}
+
+// country returns the request's device country, or "" if it has none.
+func country(req *openrtb.BidRequest) string {
+	if req.Device == nil || req.Device.Geo == nil {
+		return ""
+	}
+	return req.Device.Geo.Country
+}
@@ -0,0 +1,102 @@
+package mockdsp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestBidder_Rules(t *testing.T) {
+	yes, no := true, false
+	b := NewBidder(BidderConfig{
+		Name:  "dsp",
+		Price: randutil.Dist{Type: randutil.DistFixed, Value: 2},
+		Rules: []Rule{
+			{Interstitial: &yes, Skip: true},
+			{Sizes: []string{"300x250"}, Multiplier: 1.5},
+			{Countries: []string{"GBR"}, Price: randutil.Dist{Type: randutil.DistFixed, Value: 3}},
+			{Sizes: []string{"300x250"}, Countries: []string{"GBR"}, Interstitial: &no, Multiplier: 2},
+		},
+	}, randutil.New(1))
+
+	imps := []openrtb.Imp{
+		{ID: "leaderboard", Banner: &openrtb.Banner{W: 320, H: 50}},
+		{ID: "mrec", Banner: &openrtb.Banner{W: 300, H: 250}},
+		{ID: "interstitial", Banner: &openrtb.Banner{W: 320, H: 480}, Instl: 1},
+	}
+	tests := []struct {
+		country string
+		want    map[string]float64
+	}{
+		{country: "USA", want: map[string]float64{"leaderboard": 2, "mrec": 3}},
+		{country: "GBR", want: map[string]float64{"leaderboard": 3, "mrec": 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country, func(t *testing.T) {
+			req := &openrtb.BidRequest{ID: "req-1", Imp: imps, Device: &openrtb.Device{Geo: &openrtb.Geo{Country: tt.country}}}
+			got := make(map[string]float64)
+			for _, bid := range b.buildResponse(req, "dsp.test").AllBids() {
+				got[bid.ImpID] = bid.Price
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("bids = %v, want %v", got, tt.want)
+			}
+			for imp, price := range tt.want {
+				if got[imp] != price {
+					t.Errorf("%s bid %v, want %v", imp, got[imp], price)
+				}
+			}
+		})
+	}
+}
+
+func TestBidder_RulesSkipAll(t *testing.T) {
+	b := NewBidder(BidderConfig{
+		Name:  "dsp",
+		Rules: []Rule{{Sizes: []string{"320x50", "300x250"}, Skip: true}},
+	}, randutil.New(1))
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bid", bidRequestBody(t)))
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204 when every impression is skipped", rec.Code)
+	}
+	if s := b.Stats(); s.NoBids != 1 || s.Bids != 0 {
+		t.Errorf("Stats = %+v, want 1 no-bid", s)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{"valid", Rule{Sizes: []string{"300x250"}, Multiplier: 1.2}, ""},
+		{"bad size", Rule{Sizes: []string{"300-250"}}, "sizes"},
+		{"zero size", Rule{Sizes: []string{"0x250"}}, "sizes"},
+		{"negative multiplier", Rule{Multiplier: -1}, "multiplier"},
+		{"bad price", Rule{Price: randutil.Dist{Type: "pareto"}}, "price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
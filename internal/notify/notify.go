@@ -0,0 +1,270 @@
+// Package notify fires OpenRTB win, billing, and loss notifications after auctions.
+// Notifications are delivered asynchronously by a bounded worker pool so slow
+// notification endpoints never stall the auction loop.
+package notify
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Kind identifies the notification type.
+type Kind int
+
+const (
+	KindWin     Kind = iota // nurl
+	KindBilling             // burl
+	KindLoss                // lurl
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindWin:
+		return "win"
+	case KindBilling:
+		return "billing"
+	case KindLoss:
+		return "loss"
+	default:
+		return "unknown"
+	}
+}
+
+// Recorder receives notification delivery results.
+type Recorder interface {
+	RecordWinNotice(dspName string, latency time.Duration, err error)
+	RecordBillingNotice(dspName string, latency time.Duration, err error)
+	RecordLossNotice(dspName string, latency time.Duration, err error)
+}
+
+// notice is a single queued notification.
+type notice struct {
+	kind Kind
+	dsp  string
+	url  string
+}
+
+// Notifier delivers auction notifications to DSPs.
+type Notifier struct {
+	client   *httpclient.Client
+	recorder Recorder
+	clock    clock.Clock
+	queue    chan notice
+	wg       sync.WaitGroup
+	dropped  atomic.Uint64
+	pending  atomic.Int64 // queued or being delivered
+
+	timeout   time.Duration
+	workers   int
+	queueSize int
+	currency  string
+
+	closeOnce sync.Once
+}
+
+// Option configures the notifier.
+type Option func(*Notifier)
+
+// WithTimeout sets the per-notification timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(n *Notifier) {
+		n.timeout = d
+	}
+}
+
+// WithWorkers sets the number of concurrent delivery workers.
+func WithWorkers(w int) Option {
+	return func(n *Notifier) {
+		n.workers = w
+	}
+}
+
+// WithQueueSize sets how many notifications may be pending before new
+// ones are dropped.
+func WithQueueSize(size int) Option {
+	return func(n *Notifier) {
+		n.queueSize = size
+	}
+}
+
+// WithCurrency sets the value substituted for ${AUCTION_CURRENCY}.
+func WithCurrency(cur string) Option {
+	return func(n *Notifier) {
+		n.currency = cur
+	}
+}
+
+// WithClock measures delivery latency and paces Flush with c instead of
+// the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(n *Notifier) {
+		n.clock = c
+	}
+}
+
+// New creates a notifier and starts its delivery workers.
+func New(recorder Recorder, opts ...Option) *Notifier {
+	n := &Notifier{
+		recorder:  recorder,
+		clock:     clock.Real,
+		timeout:   time.Second,
+		workers:   8,
+		queueSize: 4096,
+		currency:  "USD",
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	n.client = httpclient.New(
+		httpclient.WithTimeout(n.timeout),
+		httpclient.WithMaxConnsPerHost(n.workers),
+	)
+	n.queue = make(chan notice, n.queueSize)
+
+	for i := 0; i < n.workers; i++ {
+		n.wg.Add(1)
+		go n.worker()
+	}
+
+	return n
+}
+
+// Notify queues the notifications for an auction outcome: nurl and burl
+// for the winner, lurl for every losing bid, including open-market bids
+// preempted by a deal. Never blocks; notifications
+// that do not fit in the queue are dropped and counted.
+func (n *Notifier) Notify(outcome auction.Outcome) {
+	if outcome.Winner == nil && len(outcome.AllBids) == 0 {
+		return
+	}
+
+	winnerIdx := -1
+	if outcome.Winner != nil && outcome.WinnerIndex >= 0 && outcome.WinnerIndex < len(outcome.AllBids) {
+		winnerIdx = outcome.WinnerIndex
+	}
+
+	for i := range outcome.AllBids {
+		b := &outcome.AllBids[i]
+		if i == winnerIdx {
+			n.enqueue(KindWin, b, b.Bid.NURL, outcome, openrtb.LossBidWon)
+			n.enqueue(KindBilling, b, b.Bid.BURL, outcome, openrtb.LossBidWon)
+			continue
+		}
+		n.enqueue(KindLoss, b, b.Bid.LURL, outcome, openrtb.LossLostToHigherBid)
+	}
+	for i := range outcome.Preempted {
+		b := &outcome.Preempted[i]
+		n.enqueue(KindLoss, b, b.Bid.LURL, outcome, openrtb.LossLostToPMPDeal)
+	}
+}
+
+// enqueue expands macros and queues a notification if the URL is set.
+func (n *Notifier) enqueue(kind Kind, b *auction.BidWithDSP, rawURL string, outcome auction.Outcome, lossReason int) {
+	if rawURL == "" {
+		return
+	}
+
+	nt := notice{
+		kind: kind,
+		dsp:  b.DSPName,
+		url:  n.expandMacros(rawURL, b, outcome, lossReason),
+	}
+
+	n.pending.Add(1)
+	select {
+	case n.queue <- nt:
+	default:
+		n.pending.Add(-1)
+		n.dropped.Add(1)
+	}
+}
+
+// expandMacros substitutes OpenRTB auction macros in a notification URL.
+func (n *Notifier) expandMacros(rawURL string, b *auction.BidWithDSP, outcome auction.Outcome, lossReason int) string {
+	if !strings.Contains(rawURL, "${") {
+		return rawURL
+	}
+
+	r := strings.NewReplacer(
+		openrtb.MacroAuctionID, outcome.RequestID,
+		openrtb.MacroAuctionBidID, b.ResponseID,
+		openrtb.MacroAuctionImpID, b.Bid.ImpID,
+		openrtb.MacroAuctionSeatID, b.Seat,
+		openrtb.MacroAuctionPrice, strconv.FormatFloat(outcome.ClearingPrice, 'f', -1, 64),
+		openrtb.MacroAuctionCurrency, n.currency,
+		openrtb.MacroAuctionLoss, strconv.Itoa(lossReason),
+	)
+	return r.Replace(rawURL)
+}
+
+// worker delivers queued notifications until the queue is closed.
+func (n *Notifier) worker() {
+	defer n.wg.Done()
+
+	for nt := range n.queue {
+		start := n.clock.Now()
+		err := n.client.Get(nt.url)
+		latency := n.clock.Since(start)
+
+		switch nt.kind {
+		case KindWin:
+			n.recorder.RecordWinNotice(nt.dsp, latency, err)
+		case KindBilling:
+			n.recorder.RecordBillingNotice(nt.dsp, latency, err)
+		case KindLoss:
+			n.recorder.RecordLossNotice(nt.dsp, latency, err)
+		}
+		n.pending.Add(-1)
+	}
+}
+
+// flushInterval is how often Flush checks for pending notifications.
+const flushInterval = 10 * time.Millisecond
+
+// Flush waits until every notification queued so far is delivered, or
+// until ctx ends, returning its error. Unlike Close, the notifier keeps
+// accepting notifications.
+func (n *Notifier) Flush(ctx context.Context) error {
+	timer := n.clock.NewTimer(flushInterval)
+	defer timer.Stop()
+	for n.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C():
+			timer.Reset(flushInterval)
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of notifications dropped due to a full queue.
+func (n *Notifier) Dropped() uint64 {
+	return n.dropped.Load()
+}
+
+// Close stops accepting notifications and waits for queued ones to be
+// delivered. Notify must not be called after Close.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() {
+		close(n.queue)
+		n.wg.Wait()
+		n.client.Close()
+
+		if dropped := n.Dropped(); dropped > 0 {
+			log.Printf("notify: %d notifications dropped (queue full)", dropped)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// mockRecorder captures delivery results by kind.
+type mockRecorder struct {
+	mu         sync.Mutex
+	win        []error
+	winLatency []time.Duration
+	billing    []error
+	loss       []error
+}
+
+func (m *mockRecorder) RecordWinNotice(dsp string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.win = append(m.win, err)
+	m.winLatency = append(m.winLatency, latency)
+}
+
+func (m *mockRecorder) RecordBillingNotice(dsp string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.billing = append(m.billing, err)
+}
+
+func (m *mockRecorder) RecordLossNotice(dsp string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loss = append(m.loss, err)
+}
+
+// urlRecorder is a test server capturing requested URIs.
+type urlRecorder struct {
+	mu     sync.Mutex
+	uris   []string
+	server *httptest.Server
+}
+
+func newURLRecorder(t *testing.T, status int) *urlRecorder {
+	t.Helper()
+	u := &urlRecorder{}
+	u.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.mu.Lock()
+		u.uris = append(u.uris, r.URL.RequestURI())
+		u.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(u.server.Close)
+	return u
+}
+
+func (u *urlRecorder) sorted() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	out := append([]string(nil), u.uris...)
+	sort.Strings(out)
+	return out
+}
+
+func testOutcome(base string) auction.Outcome {
+	bids := []auction.BidWithDSP{
+		{
+			DSPName:    "winner",
+			Seat:       "seat-a",
+			ResponseID: "resp-1",
+			Bid: openrtb.Bid{
+				ID:    "bid-1",
+				ImpID: "imp-1",
+				Price: 2.5,
+				NURL:  base + "/win?price=${AUCTION_PRICE}&id=${AUCTION_ID}&bid=${AUCTION_BID_ID}",
+				BURL:  base + "/bill?price=${AUCTION_PRICE}&cur=${AUCTION_CURRENCY}",
+			},
+		},
+		{
+			DSPName: "loser",
+			Bid: openrtb.Bid{
+				ID:    "bid-2",
+				ImpID: "imp-1",
+				Price: 1.5,
+				LURL:  base + "/loss?reason=${AUCTION_LOSS}&imp=${AUCTION_IMP_ID}",
+				NURL:  base + "/never",
+			},
+		},
+	}
+	return auction.Outcome{
+		RequestID:     "req-1",
+		Winner:        &bids[0].Bid,
+		WinningDSP:    "winner",
+		ClearingPrice: 2.5,
+		AllBids:       bids,
+		WinnerIndex:   0,
+	}
+}
+
+func TestNotifier_FiresWinBillingAndLoss(t *testing.T) {
+	srv := newURLRecorder(t, http.StatusOK)
+	rec := &mockRecorder{}
+
+	n := New(rec, WithTimeout(time.Second), WithWorkers(2))
+	n.Notify(testOutcome(srv.server.URL))
+	n.Close()
+
+	got := srv.sorted()
+	want := []string{
+		"/bill?price=2.5&cur=USD",
+		"/loss?reason=102&imp=imp-1",
+		"/win?price=2.5&id=req-1&bid=resp-1",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fired %d notifications %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("notification %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if len(rec.win) != 1 || rec.win[0] != nil {
+		t.Errorf("win results = %v, want one success", rec.win)
+	}
+	if len(rec.billing) != 1 || len(rec.loss) != 1 {
+		t.Errorf("billing = %d, loss = %d, want 1 each", len(rec.billing), len(rec.loss))
+	}
+}
+
+func TestNotifier_RecordsFailures(t *testing.T) {
+	srv := newURLRecorder(t, http.StatusInternalServerError)
+	rec := &mockRecorder{}
+
+	n := New(rec)
+	n.Notify(testOutcome(srv.server.URL))
+	n.Close()
+
+	if len(rec.win) != 1 || rec.win[0] == nil {
+		t.Errorf("win results = %v, want one failure", rec.win)
+	}
+}
+
+func TestNotifier_WithClock(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer srv.Close()
+	rec := &mockRecorder{}
+
+	// A stopped clock sees no time pass during delivery
+	n := New(rec, WithClock(clock.NewManual(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))))
+	n.Notify(testOutcome(srv.URL))
+	n.Close()
+
+	if len(rec.winLatency) != 1 || rec.winLatency[0] != 0 {
+		t.Errorf("win latencies = %v, want one of 0 on a stopped clock", rec.winLatency)
+	}
+}
+
+func TestNotifier_NoWinner(t *testing.T) {
+	srv := newURLRecorder(t, http.StatusOK)
+	rec := &mockRecorder{}
+
+	outcome := testOutcome(srv.server.URL)
+	outcome.Winner = nil
+	outcome.WinnerIndex = -1
+
+	n := New(rec)
+	n.Notify(outcome)
+	n.Close()
+
+	// Without a winner every bid loses; only bid-2 carries an lurl
+	if len(rec.win) != 0 || len(rec.loss) != 1 {
+		t.Errorf("win = %d, loss = %d, want 0, 1", len(rec.win), len(rec.loss))
+	}
+}
+
+func TestNotifier_PreemptedByDeal(t *testing.T) {
+	srv := newURLRecorder(t, http.StatusOK)
+	rec := &mockRecorder{}
+
+	outcome := testOutcome(srv.server.URL)
+	outcome.AllBids = outcome.AllBids[:1]
+	outcome.Preempted = []auction.BidWithDSP{{
+		DSPName: "open",
+		Bid:     openrtb.Bid{ID: "bid-3", ImpID: "imp-1", Price: 9, LURL: srv.server.URL + "/loss?reason=${AUCTION_LOSS}"},
+	}}
+
+	n := New(rec)
+	n.Notify(outcome)
+	n.Close()
+
+	got := srv.sorted()
+	if len(got) != 3 || got[1] != "/loss?reason=103" {
+		t.Errorf("notifications = %v, want a loss notice with reason 103", got)
+	}
+}
+
+func TestNotifier_DropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+
+	n := New(&mockRecorder{}, WithWorkers(1), WithQueueSize(1))
+	for i := 0; i < 10; i++ {
+		n.Notify(testOutcome(server.URL))
+	}
+	close(block)
+	n.Close()
+
+	if n.Dropped() == 0 {
+		t.Error("Dropped() = 0, want > 0 with a full queue")
+	}
+}
+
+func TestNotifier_Flush(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	rec := &mockRecorder{}
+
+	n := New(rec, WithWorkers(1))
+	defer n.Close()
+	n.Notify(testOutcome(srv.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Flush() with a delivery blocked = %v, want deadline exceeded", err)
+	}
+
+	close(block)
+	if err := n.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.win) != 1 || len(rec.billing) != 1 || len(rec.loss) != 1 {
+		t.Errorf("win, billing, loss = %d, %d, %d after Flush; want 1 each", len(rec.win), len(rec.billing), len(rec.loss))
+	}
+}
+
+func TestKind_String(t *testing.T) {
+	if KindWin.String() != "win" || KindBilling.String() != "billing" || KindLoss.String() != "loss" {
+		t.Error("unexpected Kind strings")
+	}
+}
@@ -0,0 +1,83 @@
+package randutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Distribution types accepted by Dist.
+const (
+	DistFixed     = "fixed"
+	DistUniform   = "uniform"
+	DistNormal    = "normal"
+	DistLogNormal = "lognormal"
+)
+
+// Dist describes a parameterized distribution, typically loaded from YAML.
+//
+//	fixed:     Value
+//	uniform:   Min, Max
+//	normal:    Mean, StdDev (clamped to [Min, Max] when Max > 0)
+//	lognormal: Mu, Sigma of the underlying normal (clamped likewise)
+type Dist struct {
+	Type   string  `yaml:"type"`
+	Value  float64 `yaml:"value"`
+	Min    float64 `yaml:"min"`
+	Max    float64 `yaml:"max"`
+	Mean   float64 `yaml:"mean"`
+	StdDev float64 `yaml:"stddev"`
+	Mu     float64 `yaml:"mu"`
+	Sigma  float64 `yaml:"sigma"`
+}
+
+// IsZero reports whether the distribution is unset.
+func (d Dist) IsZero() bool {
+	return d == Dist{}
+}
+
+// Validate checks that the distribution's parameters are consistent.
+func (d Dist) Validate() error {
+	switch d.Type {
+	case DistFixed:
+		return nil
+	case DistUniform:
+		if d.Max < d.Min {
+			return errors.New("uniform: max must be >= min")
+		}
+	case DistNormal:
+		if d.StdDev < 0 {
+			return errors.New("normal: stddev must not be negative")
+		}
+	case DistLogNormal:
+		if d.Sigma < 0 {
+			return errors.New("lognormal: sigma must not be negative")
+		}
+	default:
+		return fmt.Errorf("unknown distribution type %q", d.Type)
+	}
+	if d.Max > 0 && d.Max < d.Min {
+		return errors.New("max must be >= min")
+	}
+	return nil
+}
+
+// Sample draws a value from the distribution using src.
+func (d Dist) Sample(src *Source) float64 {
+	var v float64
+	switch d.Type {
+	case DistFixed:
+		return d.Value
+	case DistUniform:
+		return src.Uniform(d.Min, d.Max)
+	case DistNormal:
+		v = src.Normal(d.Mean, d.StdDev)
+	case DistLogNormal:
+		v = src.LogNormal(d.Mu, d.Sigma)
+	default:
+		return d.Value
+	}
+	if d.Max > 0 {
+		v = clamp(v, d.Min, d.Max)
+	}
+	return v
+}
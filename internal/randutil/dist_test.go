@@ -0,0 +1,62 @@
+package randutil
+
+import "testing"
+
+func TestDist_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dist    Dist
+		wantErr bool
+	}{
+		{"fixed", Dist{Type: DistFixed, Value: 1}, false},
+		{"uniform", Dist{Type: DistUniform, Min: 1, Max: 2}, false},
+		{"uniform inverted", Dist{Type: DistUniform, Min: 2, Max: 1}, true},
+		{"normal", Dist{Type: DistNormal, Mean: 1, StdDev: 0.5}, false},
+		{"normal negative stddev", Dist{Type: DistNormal, StdDev: -1}, true},
+		{"lognormal", Dist{Type: DistLogNormal, Mu: 0, Sigma: 0.5, Min: 0.1, Max: 10}, false},
+		{"lognormal bad bounds", Dist{Type: DistLogNormal, Sigma: 0.5, Min: 5, Max: 1}, true},
+		{"unknown", Dist{Type: "pareto"}, true},
+		{"empty", Dist{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dist.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDist_Sample(t *testing.T) {
+	src := New(21)
+
+	if v := (Dist{Type: DistFixed, Value: 1.5}).Sample(src); v != 1.5 {
+		t.Errorf("fixed Sample() = %v, want 1.5", v)
+	}
+
+	uniform := Dist{Type: DistUniform, Min: 0.25, Max: 3}
+	normal := Dist{Type: DistNormal, Mean: 2, StdDev: 5, Min: 0.5, Max: 4}
+	lognormal := Dist{Type: DistLogNormal, Mu: 0, Sigma: 2, Min: 0.1, Max: 10}
+
+	for i := 0; i < 1000; i++ {
+		if v := uniform.Sample(src); v < 0.25 || v >= 3 {
+			t.Fatalf("uniform Sample() = %v, out of range", v)
+		}
+		if v := normal.Sample(src); v < 0.5 || v > 4 {
+			t.Fatalf("clamped normal Sample() = %v, out of range", v)
+		}
+		if v := lognormal.Sample(src); v < 0.1 || v > 10 {
+			t.Fatalf("clamped lognormal Sample() = %v, out of range", v)
+		}
+	}
+}
+
+func TestDist_IsZero(t *testing.T) {
+	if !(Dist{}).IsZero() {
+		t.Error("Dist{}.IsZero() = false")
+	}
+	if (Dist{Type: DistFixed}).IsZero() {
+		t.Error("Dist{Type: fixed}.IsZero() = true")
+	}
+}
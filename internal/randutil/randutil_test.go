@@ -0,0 +1,118 @@
+package randutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNew_Deterministic(t *testing.T) {
+	a := New(42)
+	b := New(42)
+
+	for i := 0; i < 100; i++ {
+		if x, y := a.Float64(), b.Float64(); x != y {
+			t.Fatalf("draw %d: %v != %v for same seed", i, x, y)
+		}
+	}
+}
+
+func TestSeed_DefaultSource(t *testing.T) {
+	defer Seed(0)
+
+	Seed(7)
+	first := []int{IntN(1000), IntN(1000), IntN(1000)}
+
+	Seed(7)
+	second := []int{IntN(1000), IntN(1000), IntN(1000)}
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("draw %d: %d != %d after reseeding", i, first[i], second[i])
+		}
+	}
+}
+
+func TestSource_ZeroValue(t *testing.T) {
+	var s Source
+	for i := 0; i < 100; i++ {
+		if v := s.Float64(); v < 0 || v >= 1 {
+			t.Fatalf("Float64() = %v, want [0, 1)", v)
+		}
+		if v := s.IntN(5); v < 0 || v >= 5 {
+			t.Fatalf("IntN(5) = %d, want [0, 5)", v)
+		}
+	}
+}
+
+func TestSource_Uniform(t *testing.T) {
+	s := New(1)
+	for i := 0; i < 1000; i++ {
+		if v := s.Uniform(0.25, 3.0); v < 0.25 || v >= 3.0 {
+			t.Fatalf("Uniform(0.25, 3) = %v, out of range", v)
+		}
+	}
+}
+
+func TestSource_Chance(t *testing.T) {
+	s := New(1)
+	if s.Chance(0) {
+		t.Error("Chance(0) = true")
+	}
+	if !s.Chance(1) {
+		t.Error("Chance(1) = false")
+	}
+
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		if s.Chance(0.3) {
+			hits++
+		}
+	}
+	if rate := float64(hits) / 10000; math.Abs(rate-0.3) > 0.02 {
+		t.Errorf("Chance(0.3) rate = %.3f", rate)
+	}
+}
+
+func TestSource_NormalMoments(t *testing.T) {
+	s := New(3)
+	const n = 20000
+	var sum, sumSq float64
+	for i := 0; i < n; i++ {
+		v := s.Normal(10, 2)
+		sum += v
+		sumSq += v * v
+	}
+	mean := sum / n
+	stddev := math.Sqrt(sumSq/n - mean*mean)
+
+	if math.Abs(mean-10) > 0.1 {
+		t.Errorf("mean = %.3f, want ~10", mean)
+	}
+	if math.Abs(stddev-2) > 0.1 {
+		t.Errorf("stddev = %.3f, want ~2", stddev)
+	}
+}
+
+func TestSource_BoundedNormal(t *testing.T) {
+	s := New(5)
+	for i := 0; i < 1000; i++ {
+		if v := s.BoundedNormal(0, 10, -1, 1); v < -1 || v > 1 {
+			t.Fatalf("BoundedNormal() = %v, want [-1, 1]", v)
+		}
+	}
+}
+
+func TestSource_LogNormalMedian(t *testing.T) {
+	s := New(9)
+	const n = 10001
+	below := 0
+	for i := 0; i < n; i++ {
+		if s.LogNormal(math.Log(2), 0.5) < 2 {
+			below++
+		}
+	}
+	// Median of lognormal(mu, sigma) is e^mu
+	if rate := float64(below) / n; math.Abs(rate-0.5) > 0.03 {
+		t.Errorf("fraction below e^mu = %.3f, want ~0.5", rate)
+	}
+}
@@ -0,0 +1,79 @@
+package randutil
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Weighted samples indices in proportion to a fixed set of weights.
+// Construction is O(n); each sample is O(log n). Safe for concurrent use.
+type Weighted struct {
+	cumulative []float64
+	total      float64
+}
+
+// NewWeighted builds a sampler over weights. Weights must be non-negative
+// with a positive sum.
+func NewWeighted(weights []float64) (*Weighted, error) {
+	w := &Weighted{cumulative: make([]float64, len(weights))}
+	for i, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) {
+			return nil, errors.New("weights must be non-negative")
+		}
+		w.total += weight
+		w.cumulative[i] = w.total
+	}
+	if w.total <= 0 {
+		return nil, errors.New("weights must have a positive sum")
+	}
+	return w, nil
+}
+
+// MustWeighted is like NewWeighted but panics on invalid weights.
+// Intended for package-level tables with static weights.
+func MustWeighted(weights []float64) *Weighted {
+	w, err := NewWeighted(weights)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// Len returns the number of choices.
+func (w *Weighted) Len() int {
+	return len(w.cumulative)
+}
+
+// Pick returns a weighted random index using src.
+func (w *Weighted) Pick(src *Source) int {
+	target := src.Float64() * w.total
+	i := sort.SearchFloat64s(w.cumulative, target)
+	// SearchFloat64s finds the first cumulative >= target; a target landing
+	// exactly on a boundary belongs to the next non-empty bucket.
+	for i < len(w.cumulative)-1 && w.cumulative[i] <= target {
+		i++
+	}
+	return i
+}
+
+// Sample returns a weighted random index from the default source.
+func (w *Weighted) Sample() int {
+	return w.Pick(Default())
+}
+
+// NewZipf returns a sampler over ranks [0, n) where rank k has weight
+// 1/(k+1)^s. Larger s concentrates traffic on the lowest ranks.
+func NewZipf(n int, s float64) (*Weighted, error) {
+	if n <= 0 {
+		return nil, errors.New("zipf: n must be positive")
+	}
+	if s < 0 {
+		return nil, errors.New("zipf: exponent must be non-negative")
+	}
+	weights := make([]float64, n)
+	for k := range weights {
+		weights[k] = 1 / math.Pow(float64(k+1), s)
+	}
+	return NewWeighted(weights)
+}
@@ -0,0 +1,80 @@
+package randutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewWeighted_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+	}{
+		{"empty", nil},
+		{"all zero", []float64{0, 0}},
+		{"negative", []float64{1, -1}},
+		{"NaN", []float64{math.NaN()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWeighted(tt.weights); err == nil {
+				t.Error("NewWeighted() expected error")
+			}
+		})
+	}
+}
+
+func TestWeighted_Distribution(t *testing.T) {
+	w := MustWeighted([]float64{70, 0, 20, 10})
+	src := New(11)
+
+	counts := make([]int, w.Len())
+	const n = 20000
+	for i := 0; i < n; i++ {
+		counts[w.Pick(src)]++
+	}
+
+	want := []float64{0.7, 0, 0.2, 0.1}
+	for i, c := range counts {
+		if got := float64(c) / n; math.Abs(got-want[i]) > 0.02 {
+			t.Errorf("index %d rate = %.3f, want ~%.2f", i, got, want[i])
+		}
+	}
+	if counts[1] != 0 {
+		t.Errorf("zero-weight index picked %d times", counts[1])
+	}
+}
+
+func TestMustWeighted_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustWeighted() did not panic on invalid weights")
+		}
+	}()
+	MustWeighted(nil)
+}
+
+func TestNewZipf(t *testing.T) {
+	z, err := NewZipf(100, 1.2)
+	if err != nil {
+		t.Fatalf("NewZipf() error = %v", err)
+	}
+
+	src := New(13)
+	counts := make([]int, z.Len())
+	for i := 0; i < 20000; i++ {
+		counts[z.Pick(src)]++
+	}
+
+	if counts[0] <= counts[1] || counts[1] <= counts[10] {
+		t.Errorf("counts not decreasing by rank: [0]=%d [1]=%d [10]=%d", counts[0], counts[1], counts[10])
+	}
+
+	if _, err := NewZipf(0, 1); err == nil {
+		t.Error("NewZipf(0, 1) expected error")
+	}
+	if _, err := NewZipf(10, -1); err == nil {
+		t.Error("NewZipf(10, -1) expected error")
+	}
+}
@@ -0,0 +1,101 @@
+// Package replay re-runs auctions over DSP responses recorded in an
+// export stream, without touching the network, so auction changes can be
+// compared against exactly the same market data.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/export"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// maxLineSize bounds a single NDJSON record, responses included.
+const maxLineSize = 16 << 20
+
+// Summary compares the recorded auctions with their replays.
+type Summary struct {
+	Records   int // records read
+	Replayed  int // records re-run
+	Skipped   int // records without captured responses
+	NewWinner int // replays won by a different bid, or sold/unsold where the original wasn't
+	NewPrice  int // replays with the same winner but a different clearing price
+
+	OriginalRevenue float64 // clearing prices as recorded
+	ReplayedRevenue float64 // clearing prices on replay
+}
+
+// Settler runs an auction on DSP results already received. An
+// *engine.Engine is one, so replays go through the same bid validation,
+// currency conversion, deals, and filters as live auctions.
+type Settler interface {
+	Settle(req *openrtb.BidRequest, results []dispatcher.Result) auction.Outcome
+}
+
+// Replayer re-runs recorded auctions through a Settler.
+type Replayer struct {
+	settler Settler
+}
+
+// New creates a replayer that settles recorded auctions with s.
+func New(s Settler) *Replayer {
+	return &Replayer{settler: s}
+}
+
+// Run reads NDJSON auction records from in, replays every record that
+// carries its DSP responses, and calls fn (if non-nil) with each record
+// and its replayed outcome.
+func (r *Replayer) Run(in io.Reader, fn func(export.AuctionRecord, auction.Outcome)) (Summary, error) {
+	var sum Summary
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), maxLineSize)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec export.AuctionRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return sum, fmt.Errorf("line %d: %w", line, err)
+		}
+		sum.Records++
+		if !rec.Replayable() {
+			sum.Skipped++
+			continue
+		}
+
+		outcome := r.settler.Settle(rec.BidRequest(), rec.Results())
+		sum.record(rec, outcome)
+		if fn != nil {
+			fn(rec, outcome)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return sum, err
+	}
+	return sum, nil
+}
+
+// record compares one replay with its original auction.
+func (s *Summary) record(rec export.AuctionRecord, outcome auction.Outcome) {
+	s.Replayed++
+	s.OriginalRevenue += rec.ClearingPrice
+	s.ReplayedRevenue += outcome.ClearingPrice
+
+	var winningBid string
+	if outcome.Winner != nil {
+		winningBid = outcome.Winner.ID
+	}
+	switch {
+	case winningBid != rec.WinningBidID || outcome.WinningDSP != rec.WinningDSP:
+		s.NewWinner++
+	case outcome.ClearingPrice != rec.ClearingPrice:
+		s.NewPrice++
+	}
+}
@@ -0,0 +1,133 @@
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/export"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// settler settles auctions with auc alone, as the engine does by default.
+func settler(auc auction.Auction, opts ...engine.Option) Settler {
+	return engine.New(nil, nil, auc, nil, opts...)
+}
+
+// recordRun writes a two-auction export with responses, as the simulator
+// would with -stream -stream-responses.
+func recordRun(t *testing.T, responses bool) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	opts := []export.StreamOption{}
+	if responses {
+		opts = append(opts, export.WithResponses())
+	}
+	stream := export.NewStream(&buf, opts...)
+
+	auc := auction.NewFirstPrice()
+	for _, prices := range [][2]float64{{3.0, 2.0}, {1.5, 0}} {
+		req := &openrtb.BidRequest{ID: "req", Imp: []openrtb.Imp{{ID: "imp-1", BidFloor: 0.5}}}
+		results := []dispatcher.Result{
+			{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "a", Price: prices[0]}}}}}},
+			{DSPName: "dsp2", Response: &openrtb.BidResponse{}},
+		}
+		if prices[1] > 0 {
+			results[1].Response.SeatBid = []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", Price: prices[1]}}}}
+		}
+		stream.ObserveAuction(req, results, auc.Run(req.ID, 0.5, results))
+	}
+	stream.Close()
+	return &buf
+}
+
+func TestReplayer_SameRule(t *testing.T) {
+	sum, err := New(settler(auction.NewFirstPrice())).Run(recordRun(t, true), nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if sum.Records != 2 || sum.Replayed != 2 || sum.Skipped != 0 {
+		t.Errorf("records/replayed/skipped = %d/%d/%d, want 2/2/0", sum.Records, sum.Replayed, sum.Skipped)
+	}
+	if sum.NewWinner != 0 || sum.NewPrice != 0 {
+		t.Errorf("NewWinner/NewPrice = %d/%d, want an identical replay", sum.NewWinner, sum.NewPrice)
+	}
+	if sum.OriginalRevenue != 4.5 || sum.ReplayedRevenue != 4.5 {
+		t.Errorf("revenue = %v recorded, %v replayed, want 4.5 both", sum.OriginalRevenue, sum.ReplayedRevenue)
+	}
+}
+
+func TestReplayer_OtherRule(t *testing.T) {
+	auc, _ := auction.New(auction.RuleReserveSecondPrice)
+
+	var outcomes []auction.Outcome
+	sum, err := New(settler(auc)).Run(recordRun(t, true), func(rec export.AuctionRecord, o auction.Outcome) {
+		outcomes = append(outcomes, o)
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Contested auction clears at the runner-up, uncontested at the floor
+	if len(outcomes) != 2 || outcomes[0].ClearingPrice != 2.0 || outcomes[1].ClearingPrice != 0.5 {
+		t.Fatalf("outcomes = %+v, want clearing prices 2.0 and 0.5", outcomes)
+	}
+	if sum.NewWinner != 0 || sum.NewPrice != 2 {
+		t.Errorf("NewWinner/NewPrice = %d/%d, want 0/2", sum.NewWinner, sum.NewPrice)
+	}
+	if sum.ReplayedRevenue != 2.5 {
+		t.Errorf("ReplayedRevenue = %v, want 2.5", sum.ReplayedRevenue)
+	}
+}
+
+func TestReplayer_WithoutResponses(t *testing.T) {
+	sum, err := New(settler(auction.NewFirstPrice())).Run(recordRun(t, false), nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if sum.Replayed != 0 || sum.Skipped != 2 {
+		t.Errorf("replayed/skipped = %d/%d, want 0/2", sum.Replayed, sum.Skipped)
+	}
+}
+
+func TestReplayer_InvalidRecord(t *testing.T) {
+	_, err := New(settler(auction.NewFirstPrice())).Run(strings.NewReader("{}\nnot json\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Run() error = %v, want a line 2 decode error", err)
+	}
+}
+
+func TestReplayer_ValidatesBids(t *testing.T) {
+	var buf bytes.Buffer
+	stream := export.NewStream(&buf, export.WithResponses())
+	req := &openrtb.BidRequest{ID: "req", Imp: []openrtb.Imp{{ID: "imp-1", BidFloor: 0.5}}}
+	bid := func(id, imp string, price float64) *openrtb.BidResponse {
+		return &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Seat: "seat", Bid: []openrtb.Bid{
+			{ID: id, ImpID: imp, Price: price, CrID: "cr", AdM: "<div/>"},
+		}}}}
+	}
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: bid("a", "imp-9", 3.0)},
+		{DSPName: "dsp2", Response: bid("b", "imp-1", 2.0)},
+	}
+	// Recorded without validation, so the bid for an unknown imp won
+	stream.ObserveAuction(req, results, auction.NewFirstPrice().Run(req.ID, 0.5, results))
+	stream.Close()
+
+	var outcome auction.Outcome
+	sum, err := New(settler(auction.NewFirstPrice(), engine.WithResponseValidation())).Run(&buf,
+		func(_ export.AuctionRecord, o auction.Outcome) { outcome = o })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if outcome.WinningDSP != "dsp2" || len(outcome.Rejected) != 1 || outcome.Rejected[0].Reason != auction.ViolationUnknownImp {
+		t.Errorf("outcome = %+v, want dsp2 to win and dsp1's bid rejected", outcome)
+	}
+	if sum.NewWinner != 1 {
+		t.Errorf("NewWinner = %d, want 1", sum.NewWinner)
+	}
+}
@@ -0,0 +1,287 @@
+package runs
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// WriteArtifacts writes a zip archive of the run's artifacts to w:
+//
+//	config.yaml    configuration the run was started with
+//	snapshot.json  statistics at the end of the run (live if still active)
+//	outliers.json  the run's slowest auctions, with requests and responses
+//	report.json    summary of the snapshot; see Report
+//	report.txt     human-readable summary of the snapshot
+//	logs.txt       log output captured during the run, if a LogBuffer is set
+func (r *Registry) WriteArtifacts(w io.Writer, id string) error {
+	run, err := r.Get(id)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	cfg, err := yaml.Marshal(run.config.Redacted())
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if err := writeEntry(zw, "config.yaml", cfg); err != nil {
+		return err
+	}
+
+	snap, err := json.MarshalIndent(run.Snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	if err := writeEntry(zw, "snapshot.json", snap); err != nil {
+		return err
+	}
+
+	if run.Outliers == nil {
+		run.Outliers = []Outlier{}
+	}
+	outliers, err := json.MarshalIndent(run.Outliers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal outliers: %w", err)
+	}
+	if err := writeEntry(zw, "outliers.json", outliers); err != nil {
+		return err
+	}
+
+	rep, err := json.MarshalIndent(NewReport(run), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	if err := writeEntry(zw, "report.json", rep); err != nil {
+		return err
+	}
+
+	var report strings.Builder
+	WriteReport(&report, run)
+	if err := writeEntry(zw, "report.txt", []byte(report.String())); err != nil {
+		return err
+	}
+
+	if r.logs != nil {
+		lines := r.logs.Since(run.logStart, run.logEnd)
+		if err := writeEntry(zw, "logs.txt", []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// WriteReport writes a plain-text summary of a run.
+func WriteReport(w io.Writer, run Run) {
+	snap := run.Snapshot
+	rep := NewReport(run)
+
+	fmt.Fprintf(w, "Run %s\n", run.ID)
+	fmt.Fprintf(w, "  Started: %s\n", run.StartedAt.Format("2006-01-02 15:04:05 MST"))
+	if run.Active() {
+		fmt.Fprintf(w, "  Ended:   (in progress)\n")
+	} else {
+		fmt.Fprintf(w, "  Ended:   %s (%v)\n", run.EndedAt.Format("2006-01-02 15:04:05 MST"),
+			run.EndedAt.Sub(run.StartedAt).Round(time.Millisecond))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Total requests: %d\n", snap.TotalRequests)
+	fmt.Fprintf(w, "Total bids:     %d\n", snap.TotalBids)
+	fmt.Fprintf(w, "Total wins:     %d\n", snap.TotalWins)
+	fmt.Fprintf(w, "Total no-bids:  %d\n", snap.TotalNoBids)
+	fmt.Fprintf(w, "Total errors:   %d\n", snap.TotalErrors)
+	fmt.Fprintf(w, "Total revenue:  $%.4f\n", snap.TotalRevenue)
+	fmt.Fprintf(w, "Win rate:       %.1f%% (eCPM $%.4f)\n", rep.WinRate*100, rep.ECPM)
+	fmt.Fprintf(w, "Latency p50/p95/p99/max: %v / %v / %v / %v\n",
+		snap.Latency.P50, snap.Latency.P95, snap.Latency.P99, snap.Latency.Max)
+	fmt.Fprintf(w, "Bids/auction:   %.2f (%d contested, avg 1st-2nd gap $%.4f / %.1f%%)\n",
+		snap.Auction.AvgBidsPerAuction, snap.Auction.ContestedAuctions,
+		snap.Auction.AvgPriceGap, snap.Auction.AvgPriceGapPct)
+	fmt.Fprintf(w, "Clearing price p25/p50/p90/max: $%.4f / $%.4f / $%.4f / $%.4f\n",
+		snap.Auction.ClearingPrice.P25, snap.Auction.ClearingPrice.P50,
+		snap.Auction.ClearingPrice.P90, snap.Auction.ClearingPrice.Max)
+	if snap.Auction.PartialAuctions > 0 {
+		fmt.Fprintf(w, "Partial auctions: %d (%d DSP calls cancelled at shutdown)\n",
+			snap.Auction.PartialAuctions, snap.Auction.CancelledCalls)
+	}
+
+	if len(snap.Deals) > 0 {
+		ids := make([]string, 0, len(snap.Deals))
+		for id := range snap.Deals {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Deals:")
+		for _, id := range ids {
+			d := snap.Deals[id]
+			fmt.Fprintf(w, "  %s: bids=%d wins=%d (%.1f%%) revenue=$%.4f\n",
+				id, d.Bids, d.Wins, d.WinRate*100, d.Revenue)
+		}
+	}
+
+	if len(snap.Scenarios) > 0 {
+		names := make([]string, 0, len(snap.Scenarios))
+		for name := range snap.Scenarios {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Scenarios:")
+		for _, name := range names {
+			sc := snap.Scenarios[name]
+			fmt.Fprintf(w, "  %s: requests=%d bids=%d wins=%d (%.1f%%) ecpm=$%.4f errors=%d revenue=$%.4f\n",
+				name, sc.Requests, sc.Bids, sc.Wins, sc.WinRate*100, sc.ECPM, sc.Errors, sc.Revenue)
+		}
+	}
+
+	if rep.Traffic.Requests > 0 {
+		writeTraffic(w, rep.Traffic)
+	}
+
+	if len(rep.DSPs) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Per-DSP:")
+	}
+	for _, dr := range rep.DSPs {
+		writeDSPLine(w, dr.Name, snap.DSPStats[dr.Name], dr)
+	}
+
+	if len(run.Regressions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Regressions against baseline:")
+		for _, g := range run.Regressions {
+			fmt.Fprintf(w, "  %s\n", g)
+		}
+	}
+}
+
+func writeDSPLine(w io.Writer, name string, d stats.DSPStats, rep DSPReport) {
+	fmt.Fprintf(w, "  %s: requests=%d bids=%d wins=%d (%.1f%%) ecpm=$%.4f no-bids=%d errors=%d timeouts=%d (%.1f%%) premium/2nd=$%.4f premium/floor=$%.4f\n",
+		name, d.Requests, d.Bids, d.Wins, rep.WinRate*100, rep.ECPM, d.NoBids, d.Errors, d.Timeouts, rep.TimeoutRate*100,
+		d.WinPremium.AvgOverSecond, d.WinPremium.AvgOverFloor)
+	fmt.Fprintf(w, "    latency p50/p95/p99/max: %v / %v / %v / %v\n",
+		d.Latency.P50, d.Latency.P95, d.Latency.P99, d.Latency.Max)
+	if d.InsaneBids > 0 {
+		fmt.Fprintf(w, "    insane bids: %d\n", d.InsaneBids)
+	}
+	if d.InvalidBids > 0 {
+		fmt.Fprintf(w, "    invalid bids: %d\n", d.InvalidBids)
+	}
+	if d.Deals.Bids > 0 || d.Preempted > 0 {
+		fmt.Fprintf(w, "    deals: bids=%d wins=%d revenue=$%.4f preempted=%d\n",
+			d.Deals.Bids, d.Deals.Wins, d.Deals.Revenue, d.Preempted)
+	}
+	if len(d.FaultKinds) > 0 {
+		kinds := make([]string, 0, len(d.FaultKinds))
+		for kind := range d.FaultKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		fmt.Fprint(w, "    injected faults:")
+		for _, kind := range kinds {
+			fmt.Fprintf(w, " %s=%d", kind, d.FaultKinds[kind])
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.StatusCodes) > 0 {
+		codes := make([]int, 0, len(d.StatusCodes))
+		for code := range d.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		fmt.Fprint(w, "    http statuses:")
+		for _, code := range codes {
+			fmt.Fprintf(w, " %d=%d", code, d.StatusCodes[code])
+		}
+		fmt.Fprintln(w)
+	}
+	if q := d.Queue; q.Queued > 0 || q.Saturated > 0 {
+		fmt.Fprintf(w, "    in-flight cap: queued=%d saturated=%d wait p50/p95/max: %v / %v / %v\n",
+			q.Queued, q.Saturated, q.Wait.P50, q.Wait.P95, q.Wait.Max)
+	}
+	if r := d.Retries; r.Retried > 0 {
+		fmt.Fprintf(w, "    retries: calls=%d attempts=%d recovered=%d\n", r.Retried, r.Retries, r.Recovered)
+	}
+	if cats := d.Creatives.Categories; len(cats) > 0 {
+		fmt.Fprintf(w, "    top categories: %s\n", topList(cats))
+	}
+	if domains := d.Creatives.ADomains; len(domains) > 0 {
+		fmt.Fprintf(w, "    top adomains: %s\n", topList(domains))
+	}
+	if d.Requests == 0 || len(d.Tiers) == 0 {
+		return
+	}
+	fmt.Fprint(w, "    latency tiers:")
+	for _, tier := range d.Tiers {
+		fmt.Fprintf(w, " %s=%d (%.1f%%)", tier.Label, tier.Count, tier.Share*100)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeTraffic writes the traffic fingerprint section of a report.
+func writeTraffic(w io.Writer, t TrafficReport) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Traffic fingerprint:")
+	if len(t.Sizes) > 0 {
+		fmt.Fprintf(w, "  sizes:     %s\n", shareList(t.Sizes))
+	}
+	if len(t.Countries) > 0 {
+		fmt.Fprintf(w, "  countries: %s\n", shareList(t.Countries))
+	}
+	f := t.Floors
+	fmt.Fprintf(w, "  floors:    mean $%.4f, p25/p50/p90/max $%.4f / $%.4f / $%.4f / $%.4f\n",
+		f.Mean, f.P25, f.P50, f.P90, f.Max)
+	if len(f.Buckets) > 0 {
+		parts := make([]string, len(f.Buckets))
+		for i, b := range f.Buckets {
+			if b.UpTo > 0 {
+				parts[i] = fmt.Sprintf("<=$%g %.1f%%", b.UpTo, b.Share*100)
+			} else {
+				parts[i] = fmt.Sprintf(">$%g %.1f%%", f.Buckets[i-1].UpTo, b.Share*100)
+			}
+		}
+		fmt.Fprintf(w, "             %s\n", strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(w, "  users:     %d distinct, %.1f%% of requests from a returning user\n",
+		t.DistinctUsers, t.UserRepeatRate*100)
+}
+
+// shareList formats shares as "value share%, ...".
+func shareList(shares []Share) string {
+	parts := make([]string, len(shares))
+	for i, s := range shares {
+		parts[i] = fmt.Sprintf("%s %.1f%%", s.Value, s.Share*100)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// topList formats a top-K table as "key (count), ...".
+func topList(rows []stats.KeyCount) string {
+	parts := make([]string, len(rows))
+	for i, r := range rows {
+		parts[i] = fmt.Sprintf("%s (%d)", r.Key, r.Count)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,138 @@
+package runs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+)
+
+// Thresholds are the largest changes from the baseline a run may show
+// before they count as regressions. Win rate, eCPM, and p95 latency are
+// relative changes (0.1 is 10%); the error rate is an absolute increase
+// in errors per request. Zero disables a check.
+type Thresholds struct {
+	WinRateDrop       float64
+	ECPMDrop          float64
+	LatencyIncrease   float64
+	ErrorRateIncrease float64
+}
+
+// Regression is a metric that moved past its threshold from the baseline.
+type Regression struct {
+	Metric   string  `json:"metric"` // "win_rate", or "<dsp>.win_rate" for a DSP
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	Limit    float64 `json:"limit"` // the threshold exceeded
+}
+
+func (g Regression) String() string {
+	return fmt.Sprintf("%s: %.4g -> %.4g (limit %.4g)", g.Metric, g.Baseline, g.Current, g.Limit)
+}
+
+// Compare returns the metrics of current that regressed from baseline,
+// overall and for each DSP in both reports.
+func Compare(baseline, current Report, t Thresholds) []Regression {
+	var out []Regression
+	check := func(prefix string, base, cur metrics) {
+		if t.WinRateDrop > 0 && dropped(base.winRate, cur.winRate, t.WinRateDrop) {
+			out = append(out, Regression{prefix + "win_rate", base.winRate, cur.winRate, t.WinRateDrop})
+		}
+		if t.ECPMDrop > 0 && dropped(base.ecpm, cur.ecpm, t.ECPMDrop) {
+			out = append(out, Regression{prefix + "ecpm", base.ecpm, cur.ecpm, t.ECPMDrop})
+		}
+		if t.LatencyIncrease > 0 && base.p95 > 0 && (cur.p95-base.p95)/base.p95 > t.LatencyIncrease {
+			out = append(out, Regression{prefix + "latency_p95_ms", base.p95, cur.p95, t.LatencyIncrease})
+		}
+		if t.ErrorRateIncrease > 0 && cur.errorRate-base.errorRate > t.ErrorRateIncrease {
+			out = append(out, Regression{prefix + "error_rate", base.errorRate, cur.errorRate, t.ErrorRateIncrease})
+		}
+	}
+
+	check("", reportMetrics(baseline), reportMetrics(current))
+	baseDSPs := make(map[string]DSPReport, len(baseline.DSPs))
+	for _, d := range baseline.DSPs {
+		baseDSPs[d.Name] = d
+	}
+	for _, d := range current.DSPs {
+		if base, ok := baseDSPs[d.Name]; ok {
+			check(d.Name+".", dspMetrics(base), dspMetrics(d))
+		}
+	}
+	return out
+}
+
+// metrics are the values Compare checks.
+type metrics struct {
+	winRate, ecpm, p95, errorRate float64
+}
+
+func reportMetrics(r Report) metrics {
+	return metrics{r.WinRate, r.ECPM, r.Latency.P95, ratio(float64(r.Errors), r.Requests)}
+}
+
+func dspMetrics(d DSPReport) metrics {
+	return metrics{d.WinRate, d.ECPM, d.Latency.P95, ratio(float64(d.Errors), d.Requests)}
+}
+
+// dropped reports whether cur fell more than limit, relative, below base.
+func dropped(base, cur, limit float64) bool {
+	return base > 0 && (base-cur)/base > limit
+}
+
+// WithBaseline compares each run's report against the baseline report
+// stored at path when the run ends, recording regressions beyond t in the
+// run and its report. A missing baseline is created from the run; with
+// update set, every run replaces it.
+func WithBaseline(path string, update bool, t Thresholds) Option {
+	return func(r *Registry) {
+		r.baselinePath = path
+		r.baselineUpdate = update
+		r.thresholds = t
+	}
+}
+
+// checkBaseline compares a run that just ended against the baseline,
+// returning its regressions, and creates or updates the baseline as
+// configured.
+func (r *Registry) checkBaseline(run Run) []Regression {
+	if r.baselinePath == "" {
+		return nil
+	}
+	current := NewReport(run)
+
+	baseline, err := ReadReport(r.baselinePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || (err == nil && r.baselineUpdate):
+		if err := writeReport(r.baselinePath, current); err != nil {
+			log.Printf("Run %s baseline: %v", run.ID, err)
+		} else {
+			log.Printf("Run %s saved as baseline %s", run.ID, r.baselinePath)
+		}
+		return nil
+	case err != nil:
+		log.Printf("Run %s baseline: %v", run.ID, err)
+		return nil
+	}
+
+	regressions := Compare(baseline, current, r.thresholds)
+	if len(regressions) == 0 {
+		log.Printf("Run %s: no regressions against baseline %s", run.ID, r.baselinePath)
+	}
+	for _, g := range regressions {
+		log.Printf("Run %s regression against baseline: %s", run.ID, g)
+	}
+	return regressions
+}
+
+// Regressed reports whether any retained run regressed from the baseline.
+func (r *Registry) Regressed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, run := range r.runs {
+		if len(run.Regressions) > 0 {
+			return true
+		}
+	}
+	return false
+}
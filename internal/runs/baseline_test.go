@@ -0,0 +1,116 @@
+package runs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCompare(t *testing.T) {
+	baseline := Report{
+		Requests: 100, Errors: 1, WinRate: 0.5, ECPM: 2, Latency: LatencyReport{P95: 40},
+		DSPs: []DSPReport{{Name: "dsp1", Requests: 100, WinRate: 0.5, ECPM: 2, Latency: LatencyReport{P95: 40}}},
+	}
+	thresholds := Thresholds{WinRateDrop: 0.1, ECPMDrop: 0.1, LatencyIncrease: 0.2, ErrorRateIncrease: 0.01}
+
+	within := baseline
+	within.WinRate, within.ECPM, within.Latency.P95 = 0.46, 1.85, 47
+	if got := Compare(baseline, within, thresholds); len(got) != 0 {
+		t.Errorf("Compare within thresholds = %v, want none", got)
+	}
+
+	worse := Report{
+		Requests: 100, Errors: 5, WinRate: 0.4, ECPM: 2.5, Latency: LatencyReport{P95: 60},
+		DSPs: []DSPReport{
+			{Name: "dsp1", Requests: 100, WinRate: 0.5, ECPM: 1, Latency: LatencyReport{P95: 40}},
+			{Name: "dsp2", Requests: 100, Errors: 100}, // not in the baseline
+		},
+	}
+	got := Compare(baseline, worse, thresholds)
+	var metrics []string
+	for _, g := range got {
+		metrics = append(metrics, g.Metric)
+	}
+	if want := "win_rate latency_p95_ms error_rate dsp1.ecpm"; strings.Join(metrics, " ") != want {
+		t.Errorf("regressed metrics = %v, want %s", metrics, want)
+	}
+
+	if got := Compare(baseline, worse, Thresholds{}); len(got) != 0 {
+		t.Errorf("Compare with no thresholds = %v, want none", got)
+	}
+}
+
+func TestRegistry_WithBaseline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baselines", "ci.json")
+	collector := stats.New()
+	r := NewRegistry(collector, &config.Config{},
+		WithReportDir(filepath.Join(dir, "reports")),
+		WithBaseline(path, false, Thresholds{WinRateDrop: 0.1}))
+
+	run := func(wins int) {
+		collector.Reset()
+		r.RunStarted()
+		for i := range 4 {
+			outcome := auction.Outcome{RequestID: "req"}
+			if i < wins {
+				outcome.Winner = &openrtb.Bid{ID: "bid", Price: 2}
+				outcome.WinningDSP = "dsp1"
+				outcome.ClearingPrice = 2
+			}
+			collector.RecordAuction(outcome, []dispatcher.Result{{DSPName: "dsp1"}})
+		}
+		r.RunStopped()
+	}
+
+	run(2)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("baseline not saved by the first run: %v", err)
+	}
+	if r.Regressed() {
+		t.Error("Regressed() after the baseline run = true, want false")
+	}
+
+	run(1)
+	if !r.Regressed() {
+		t.Fatal("Regressed() after win rate halved = false, want true")
+	}
+	got, err := r.Get("run-0002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Regressions) != 2 || got.Regressions[0].Metric != "win_rate" || got.Regressions[1].Metric != "dsp1.win_rate" {
+		t.Errorf("regressions = %v, want win_rate and dsp1.win_rate", got.Regressions)
+	}
+
+	rep, err := ReadReport(filepath.Join(dir, "reports", "run-0002.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Regressions) != 2 {
+		t.Errorf("report regressions = %v, want 2", rep.Regressions)
+	}
+	text, err := os.ReadFile(filepath.Join(dir, "reports", "run-0002.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(text), "Regressions against baseline:") {
+		t.Errorf("text report = %q, want regressions section", text)
+	}
+
+	// Without update, the regressed run does not replace the baseline.
+	base, err := ReadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.RunID != "run-0001" || len(base.Regressions) != 0 {
+		t.Errorf("baseline = run %s with %d regressions, want run-0001 with none", base.RunID, len(base.Regressions))
+	}
+}
@@ -0,0 +1,91 @@
+package runs
+
+import (
+	"bytes"
+	"sync"
+)
+
+// defaultLogLines is the number of log lines retained by a LogBuffer.
+const defaultLogLines = 10000
+
+// LogBuffer retains recent log lines in memory so they can be bundled
+// with run artifacts. Install it alongside the normal log output with
+// log.SetOutput(io.MultiWriter(os.Stderr, buf)). Safe for concurrent use.
+type LogBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	next    int    // ring position of the next write
+	written uint64 // total lines ever written
+	partial []byte
+}
+
+// NewLogBuffer creates a buffer retaining up to maxLines lines.
+func NewLogBuffer(maxLines int) *LogBuffer {
+	if maxLines <= 0 {
+		maxLines = defaultLogLines
+	}
+	return &LogBuffer{lines: make([]string, maxLines)}
+}
+
+// Write implements io.Writer, splitting p into lines.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.partial = append(b.partial, data...)
+			break
+		}
+		line := string(append(b.partial, data[:i]...))
+		b.partial = b.partial[:0]
+		b.lines[b.next] = line
+		b.next = (b.next + 1) % len(b.lines)
+		b.written++
+		data = data[i+1:]
+	}
+	return len(p), nil
+}
+
+// Mark returns a position that Since can later read from.
+func (b *LogBuffer) Mark() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.written
+}
+
+// Since returns the retained lines written between mark and end, oldest
+// first. An end of 0 reads up to the latest line. Lines that have been
+// evicted from the buffer are skipped.
+func (b *LogBuffer) Since(mark, end uint64) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if end == 0 || end > b.written {
+		end = b.written
+	}
+	if oldest := b.oldest(); mark < oldest {
+		mark = oldest
+	}
+	if mark >= end {
+		return nil
+	}
+
+	out := make([]string, 0, end-mark)
+	size := uint64(len(b.lines))
+	for seq := mark; seq < end; seq++ {
+		out = append(out, b.lines[seq%size])
+	}
+	return out
+}
+
+// oldest returns the sequence number of the oldest retained line.
+// Must be called with mu held.
+func (b *LogBuffer) oldest() uint64 {
+	if size := uint64(len(b.lines)); b.written > size {
+		return b.written - size
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package runs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLogBuffer_Since(t *testing.T) {
+	b := NewLogBuffer(10)
+	_, _ = b.Write([]byte("one\ntwo\n"))
+	mark := b.Mark()
+	_, _ = b.Write([]byte("three\n"))
+	_, _ = b.Write([]byte("fo"))
+	_, _ = b.Write([]byte("ur\n"))
+
+	if got, want := b.Since(mark, 0), []string{"three", "four"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Since(mark, 0) = %v, want %v", got, want)
+	}
+	if got, want := b.Since(0, mark), []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Since(0, mark) = %v, want %v", got, want)
+	}
+}
+
+func TestLogBuffer_Eviction(t *testing.T) {
+	b := NewLogBuffer(3)
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		_, _ = b.Write([]byte(line + "\n"))
+	}
+
+	if got, want := b.Since(0, 0), []string{"c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Since(0, 0) = %v, want %v", got, want)
+	}
+}
+
+func TestLogBuffer_Empty(t *testing.T) {
+	b := NewLogBuffer(3)
+	if got := b.Since(0, 0); len(got) != 0 {
+		t.Errorf("Since(0, 0) = %v, want empty", got)
+	}
+}
@@ -0,0 +1,73 @@
+package runs
+
+import (
+	"slices"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/export"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// defaultOutliers is the number of slowest auctions kept per run.
+const defaultOutliers = 10
+
+// Outlier is one of a run's slowest auctions, with the request sent and
+// every DSP's response, to show a DSP partner what a slow call looked
+// like.
+type Outlier struct {
+	LatencyMS float64 `json:"latency_ms"` // of the auction's slowest DSP call
+	export.SampledAuction
+}
+
+// WithOutliers sets how many of each run's slowest auctions are kept.
+func WithOutliers(n int) Option {
+	return func(r *Registry) {
+		if n > 0 {
+			r.maxOutliers = n
+		}
+	}
+}
+
+// ObserveAuction keeps an auction completed now, by the registry's clock,
+// as an outlier of the active run if it is among the run's slowest, by
+// the latency of its slowest DSP call. It implements engine.Observer.
+func (r *Registry) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	r.ObserveAuctionAt(r.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt keeps an auction completed at t as an outlier like
+// ObserveAuction. It implements engine.TimedObserver.
+func (r *Registry) ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	var slowest time.Duration
+	for _, res := range results {
+		slowest = max(slowest, res.Latency)
+	}
+	latencyMS := float64(slowest) / float64(time.Millisecond)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := r.activeLocked()
+	if run == nil {
+		return
+	}
+	// Outliers are kept slowest first
+	i, _ := slices.BinarySearchFunc(run.Outliers, latencyMS, func(o Outlier, ms float64) int {
+		switch {
+		case o.LatencyMS > ms:
+			return -1
+		case o.LatencyMS < ms:
+			return 1
+		}
+		return 0
+	})
+	if i >= r.maxOutliers {
+		return
+	}
+	o := Outlier{LatencyMS: latencyMS, SampledAuction: export.NewSampledAuction(t, req, results, outcome)}
+	run.Outliers = slices.Insert(run.Outliers, i, o)
+	if len(run.Outliers) > r.maxOutliers {
+		run.Outliers = run.Outliers[:r.maxOutliers]
+	}
+}
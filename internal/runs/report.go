@@ -0,0 +1,295 @@
+package runs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// Report is the structured end-of-run summary. Prices are CPM, so eCPM is
+// the mean clearing price of won impressions.
+type Report struct {
+	RunID     string    `json:"run_id,omitempty"` // empty for a session summary
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitzero"`
+	Duration  float64   `json:"duration_seconds"`
+
+	Requests uint64  `json:"requests"`
+	Bids     uint64  `json:"bids"`
+	Wins     uint64  `json:"wins"`
+	NoBids   uint64  `json:"no_bids"`
+	Errors   uint64  `json:"errors"`
+	WinRate  float64 `json:"win_rate"` // wins per request
+	Revenue  float64 `json:"revenue"`
+	ECPM     float64 `json:"ecpm"`
+
+	Latency LatencyReport `json:"latency"`
+	DSPs    []DSPReport   `json:"dsps"` // sorted by name
+	Traffic TrafficReport `json:"traffic"`
+
+	Regressions []Regression `json:"regressions,omitempty"` // against the baseline
+}
+
+// DSPReport is one DSP's line in a Report.
+type DSPReport struct {
+	Name        string        `json:"name"`
+	Requests    uint64        `json:"requests"`
+	Bids        uint64        `json:"bids"`
+	Wins        uint64        `json:"wins"`
+	WinRate     float64       `json:"win_rate"` // wins per request sent
+	Spend       float64       `json:"spend"`
+	ECPM        float64       `json:"ecpm"`
+	Errors      uint64        `json:"errors"`
+	Timeouts    uint64        `json:"timeouts"`
+	TimeoutRate float64       `json:"timeout_rate"` // timeouts per request sent
+	InsaneBids  uint64        `json:"insane_bids"`  // bids disqualified by sanity limits
+	InvalidBids uint64        `json:"invalid_bids"` // malformed bids rejected by validation
+	Latency     LatencyReport `json:"latency"`
+}
+
+// TrafficReport fingerprints the generated traffic, so DSP partners can
+// check it against the agreed test plan: the mix of impression sizes and
+// countries, the bid floor distribution, and how often users recur.
+type TrafficReport struct {
+	Requests       uint64      `json:"requests"`  // requests fingerprinted
+	Sizes          []Share     `json:"sizes"`     // most frequent first
+	Countries      []Share     `json:"countries"` // most frequent first
+	Floors         FloorReport `json:"floors"`
+	DistinctUsers  uint64      `json:"distinct_users"`
+	UserRepeatRate float64     `json:"user_repeat_rate"` // share of requests from a user seen earlier
+}
+
+// Share is one value's count and its share of requests.
+type Share struct {
+	Value string  `json:"value"`
+	Count uint64  `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// FloorReport summarizes the first impression's bid floor, in CPM.
+type FloorReport struct {
+	Mean    float64       `json:"mean"`
+	P25     float64       `json:"p25"`
+	P50     float64       `json:"p50"`
+	P90     float64       `json:"p90"`
+	Max     float64       `json:"max"`
+	Buckets []FloorBucket `json:"buckets"`
+}
+
+// FloorBucket is the share of floors at or below UpTo and above the
+// previous bucket's bound. An UpTo of 0 marks the final open-ended bucket.
+type FloorBucket struct {
+	UpTo  float64 `json:"up_to,omitempty"`
+	Share float64 `json:"share"`
+}
+
+// LatencyReport holds latency percentiles in milliseconds.
+type LatencyReport struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// NewReport summarizes a run.
+func NewReport(run Run) Report {
+	snap := run.Snapshot
+	rep := Report{
+		RunID:     run.ID,
+		StartedAt: run.StartedAt,
+		EndedAt:   run.EndedAt,
+		Requests:  snap.TotalRequests,
+		Bids:      snap.TotalBids,
+		Wins:      snap.TotalWins,
+		NoBids:    snap.TotalNoBids,
+		Errors:    snap.TotalErrors,
+		WinRate:   snap.Rates.WinRate,
+		Revenue:   snap.TotalRevenue,
+		ECPM:      snap.Rates.ECPM,
+		Latency:   latencyReport(snap.Latency),
+		DSPs:      make([]DSPReport, 0, len(snap.DSPStats)),
+		Traffic:   trafficReport(snap.Traffic),
+
+		Regressions: run.Regressions,
+	}
+	if !run.Active() {
+		rep.Duration = run.EndedAt.Sub(run.StartedAt).Seconds()
+	}
+
+	for name, d := range snap.DSPStats {
+		rep.DSPs = append(rep.DSPs, DSPReport{
+			Name:        name,
+			Requests:    d.Requests,
+			Bids:        d.Bids,
+			Wins:        d.Wins,
+			WinRate:     d.Rates.WinRate,
+			Spend:       d.Spend,
+			ECPM:        d.Rates.ECPM,
+			Errors:      d.Errors,
+			Timeouts:    d.Timeouts,
+			TimeoutRate: d.Rates.TimeoutRate,
+			InsaneBids:  d.InsaneBids,
+			InvalidBids: d.InvalidBids,
+			Latency:     latencyReport(d.Latency),
+		})
+	}
+	sort.Slice(rep.DSPs, func(i, j int) bool { return rep.DSPs[i].Name < rep.DSPs[j].Name })
+	return rep
+}
+
+func ratio(n float64, of uint64) float64 {
+	if of == 0 {
+		return 0
+	}
+	return n / float64(of)
+}
+
+func trafficReport(t stats.TrafficStats) TrafficReport {
+	shares := func(rows []stats.KeyCount) []Share {
+		out := make([]Share, len(rows))
+		for i, r := range rows {
+			out[i] = Share{Value: r.Key, Count: r.Count, Share: ratio(float64(r.Count), t.Requests)}
+		}
+		return out
+	}
+	f := t.Floors
+	rep := TrafficReport{
+		Requests:       t.Requests,
+		Sizes:          shares(t.Sizes),
+		Countries:      shares(t.Countries),
+		Floors:         FloorReport{Mean: f.Mean, P25: f.P25, P50: f.P50, P90: f.P90, Max: f.Max},
+		DistinctUsers:  t.Users,
+		UserRepeatRate: t.RepeatRate,
+	}
+	for _, b := range f.Buckets {
+		rep.Floors.Buckets = append(rep.Floors.Buckets, FloorBucket{UpTo: b.UpTo, Share: ratio(float64(b.Count), f.Count)})
+	}
+	return rep
+}
+
+func latencyReport(p stats.LatencyPercentiles) LatencyReport {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return LatencyReport{P50: ms(p.P50), P95: ms(p.P95), P99: ms(p.P99), Max: ms(p.Max)}
+}
+
+// ReadReport reads a JSON run report, or the report of a session summary
+// written by WriteSummary.
+func ReadReport(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	var doc struct {
+		Report
+		Summary *Report `json:"report"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Report{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if doc.Summary != nil {
+		return *doc.Summary, nil
+	}
+	return doc.Report, nil
+}
+
+// writeReport writes rep to path as indented JSON.
+func writeReport(path string, rep Report) error {
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// FormatReport writes a plain-text summary of a report, for reports read
+// back from disk; WriteReport has more detail for a run still retained.
+func FormatReport(w io.Writer, rep Report) {
+	if rep.RunID != "" {
+		fmt.Fprintf(w, "Run %s\n", rep.RunID)
+	} else {
+		fmt.Fprintln(w, "Session")
+	}
+	fmt.Fprintf(w, "  Started: %s\n", rep.StartedAt.Format("2006-01-02 15:04:05 MST"))
+	if !rep.EndedAt.IsZero() {
+		fmt.Fprintf(w, "  Ended:   %s (%v)\n", rep.EndedAt.Format("2006-01-02 15:04:05 MST"),
+			time.Duration(rep.Duration*float64(time.Second)).Round(time.Millisecond))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Total requests: %d\n", rep.Requests)
+	fmt.Fprintf(w, "Total bids:     %d\n", rep.Bids)
+	fmt.Fprintf(w, "Total wins:     %d\n", rep.Wins)
+	fmt.Fprintf(w, "Total no-bids:  %d\n", rep.NoBids)
+	fmt.Fprintf(w, "Total errors:   %d\n", rep.Errors)
+	fmt.Fprintf(w, "Total revenue:  $%.4f\n", rep.Revenue)
+	fmt.Fprintf(w, "Win rate:       %.1f%% (eCPM $%.4f)\n", rep.WinRate*100, rep.ECPM)
+	fmt.Fprintf(w, "Latency p50/p95/p99/max: %.1fms / %.1fms / %.1fms / %.1fms\n",
+		rep.Latency.P50, rep.Latency.P95, rep.Latency.P99, rep.Latency.Max)
+	if rep.Traffic.Requests > 0 {
+		writeTraffic(w, rep.Traffic)
+	}
+
+	if len(rep.DSPs) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Per-DSP:")
+	}
+	for _, d := range rep.DSPs {
+		fmt.Fprintf(w, "  %s: requests=%d bids=%d wins=%d (%.1f%%) ecpm=$%.4f errors=%d timeouts=%d (%.1f%%)\n",
+			d.Name, d.Requests, d.Bids, d.Wins, d.WinRate*100, d.ECPM, d.Errors, d.Timeouts, d.TimeoutRate*100)
+		fmt.Fprintf(w, "    latency p50/p95/p99/max: %.1fms / %.1fms / %.1fms / %.1fms\n",
+			d.Latency.P50, d.Latency.P95, d.Latency.P99, d.Latency.Max)
+	}
+
+	if len(rep.Regressions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Regressions against baseline:")
+		for _, g := range rep.Regressions {
+			fmt.Fprintf(w, "  %s\n", g)
+		}
+	}
+}
+
+// writeReportFiles writes the run's report to dir as <id>.json and
+// <id>.txt, returning the paths written.
+func writeReportFiles(dir string, run Run) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	jsonPath := filepath.Join(dir, run.ID+".json")
+	if err := writeReport(jsonPath, NewReport(run)); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	WriteReport(&text, run)
+	textPath := filepath.Join(dir, run.ID+".txt")
+	if err := os.WriteFile(textPath, []byte(text.String()), 0o644); err != nil {
+		return nil, err
+	}
+	return []string{jsonPath, textPath}, nil
+}
+
+// saveReport writes the report of a run that just ended, if a report
+// directory is configured.
+func (r *Registry) saveReport(run Run) {
+	if r.reportDir == "" {
+		return
+	}
+	paths, err := writeReportFiles(r.reportDir, run)
+	if err != nil {
+		log.Printf("Run %s report: %v", run.ID, err)
+		return
+	}
+	log.Printf("Run %s report written to %s", run.ID, strings.Join(paths, ", "))
+}
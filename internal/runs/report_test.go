@@ -0,0 +1,151 @@
+package runs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestNewReport(t *testing.T) {
+	collector := stats.New()
+	collector.RecordAuction(auction.Outcome{
+		RequestID:     "req-1",
+		Winner:        &openrtb.Bid{ID: "bid-1", Price: 3},
+		WinningDSP:    "dsp1",
+		ClearingPrice: 2,
+		AllBids:       []auction.BidWithDSP{{Bid: openrtb.Bid{ID: "bid-1", Price: 3}, DSPName: "dsp1"}},
+	}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 10 * time.Millisecond},
+		{DSPName: "dsp2", Latency: 100 * time.Millisecond, Error: context.DeadlineExceeded},
+	})
+	collector.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 20 * time.Millisecond},
+		{DSPName: "dsp2", Latency: 20 * time.Millisecond},
+	})
+	for _, floor := range []float64{0.25, 4} {
+		collector.RecordRequest(&openrtb.BidRequest{
+			Imp:  []openrtb.Imp{{Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: floor}},
+			User: &openrtb.User{ID: "user-1"},
+		})
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rep := NewReport(Run{ID: "run-0001", StartedAt: start, EndedAt: start.Add(90 * time.Second), Snapshot: collector.Snapshot()})
+
+	if rep.Requests != 2 || rep.Wins != 1 || rep.WinRate != 0.5 {
+		t.Errorf("requests/wins/win rate = %d/%d/%v, want 2/1/0.5", rep.Requests, rep.Wins, rep.WinRate)
+	}
+	if rep.ECPM != 2 || rep.Duration != 90 {
+		t.Errorf("eCPM = %v, duration = %v; want 2, 90", rep.ECPM, rep.Duration)
+	}
+	if len(rep.DSPs) != 2 || rep.DSPs[0].Name != "dsp1" || rep.DSPs[1].Name != "dsp2" {
+		t.Fatalf("DSPs = %+v, want dsp1 and dsp2 in order", rep.DSPs)
+	}
+	if d := rep.DSPs[0]; d.WinRate != 0.5 || d.ECPM != 2 || d.Spend != 2 {
+		t.Errorf("dsp1 = %+v, want win rate 0.5 and eCPM 2", d)
+	}
+	if d := rep.DSPs[1]; d.Timeouts != 1 || d.TimeoutRate != 0.5 || d.ECPM != 0 {
+		t.Errorf("dsp2 = %+v, want 1 timeout in 2 requests and no eCPM", d)
+	}
+	if rep.DSPs[0].Latency.Max < 19 {
+		t.Errorf("dsp1 latency = %+v, want max near 20ms", rep.DSPs[0].Latency)
+	}
+
+	tr := rep.Traffic
+	if len(tr.Sizes) != 1 || tr.Sizes[0] != (Share{Value: "300x250", Count: 2, Share: 1}) {
+		t.Errorf("traffic sizes = %+v, want all 300x250", tr.Sizes)
+	}
+	if tr.DistinctUsers != 1 || tr.UserRepeatRate != 0.5 {
+		t.Errorf("traffic users = %d, repeat rate %v; want 1, 0.5", tr.DistinctUsers, tr.UserRepeatRate)
+	}
+	if b := tr.Floors.Buckets; len(b) == 0 || b[0].Share != 0.5 || tr.Floors.Max != 4 {
+		t.Errorf("traffic floors = %+v, want half under $0.50 and max 4", tr.Floors)
+	}
+}
+
+func TestFormatReport(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rep := Report{
+		RunID:     "run-0003",
+		StartedAt: start,
+		EndedAt:   start.Add(90 * time.Second),
+		Duration:  90,
+		Requests:  10,
+		Wins:      4,
+		WinRate:   0.4,
+		ECPM:      1.5,
+		DSPs:      []DSPReport{{Name: "dsp1", Requests: 10, Wins: 4, WinRate: 0.4}},
+		Traffic: TrafficReport{
+			Requests: 10,
+			Sizes:    []Share{{Value: "300x250", Count: 7, Share: 0.7}, {Value: "320x50", Count: 3, Share: 0.3}},
+			Floors:   FloorReport{Buckets: []FloorBucket{{UpTo: 1, Share: 0.6}, {Share: 0.4}}},
+		},
+		Regressions: []Regression{
+			{Metric: "win_rate", Baseline: 0.5, Current: 0.4, Limit: 0.1},
+		},
+	}
+
+	var b strings.Builder
+	FormatReport(&b, rep)
+	out := b.String()
+	for _, want := range []string{
+		"Run run-0003",
+		"(1m30s)",
+		"Total requests: 10",
+		"Win rate:       40.0% (eCPM $1.5000)",
+		"dsp1: requests=10",
+		"win_rate: 0.5 -> 0.4",
+		"sizes:     300x250 70.0%, 320x50 30.0%",
+		"<=$1 60.0%, >$1 40.0%",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatReport() missing %q:\n%s", want, out)
+		}
+	}
+
+	b.Reset()
+	FormatReport(&b, Report{StartedAt: start})
+	if out := b.String(); !strings.HasPrefix(out, "Session\n") || strings.Contains(out, "Ended") {
+		t.Errorf("FormatReport() of a session in progress:\n%s", out)
+	}
+}
+
+func TestRegistry_WithReportDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	collector := stats.New()
+	r := NewRegistry(collector, &config.Config{}, WithReportDir(dir))
+
+	r.RunStarted()
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{{DSPName: "dsp1"}})
+	r.RunStopped()
+
+	data, err := os.ReadFile(filepath.Join(dir, "run-0001.json"))
+	if err != nil {
+		t.Fatalf("reading JSON report: %v", err)
+	}
+	var rep Report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatalf("decoding JSON report: %v", err)
+	}
+	if rep.RunID != "run-0001" || rep.Requests != 1 || len(rep.DSPs) != 1 {
+		t.Errorf("report = %+v, want run-0001 with 1 request and 1 DSP", rep)
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, "run-0001.txt"))
+	if err != nil {
+		t.Fatalf("reading text report: %v", err)
+	}
+	if !strings.Contains(string(text), "Run run-0001") || !strings.Contains(string(text), "Win rate:") {
+		t.Errorf("text report = %q, want run header and win rate", text)
+	}
+}
@@ -0,0 +1,242 @@
+// Package runs tracks simulation runs and bundles their artifacts.
+// A run spans one engine start/stop cycle; the registry captures the
+// configuration, final statistics, slowest auctions, and log output of
+// each run so they can be downloaded together as a zip archive.
+package runs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// defaultMaxRuns is the number of completed runs retained.
+const defaultMaxRuns = 20
+
+// ErrRunNotFound is returned for unknown or evicted run IDs.
+var ErrRunNotFound = errors.New("run not found")
+
+// Run is the retained state of a single simulation run.
+type Run struct {
+	ID        string
+	StartedAt time.Time
+	EndedAt   time.Time // zero while the run is active
+
+	// Snapshot holds the statistics captured when the run ended.
+	Snapshot stats.Snapshot
+
+	// Outliers holds the run's slowest auctions, slowest first; see
+	// WithOutliers.
+	Outliers []Outlier
+
+	// Regressions holds the metrics that regressed from the baseline when
+	// the run ended; see WithBaseline.
+	Regressions []Regression
+
+	config   *config.Config // the run was started with
+	logStart uint64
+	logEnd   uint64
+}
+
+// Active reports whether the run is still in progress.
+func (r *Run) Active() bool {
+	return r.EndedAt.IsZero()
+}
+
+// Info is the JSON summary of a run returned by the API.
+type Info struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitzero"`
+	Active    bool      `json:"active"`
+}
+
+// Registry records runs as the engine starts and stops. It implements
+// engine.RunListener, and engine.Observer to keep each run's outliers.
+// Safe for concurrent use.
+type Registry struct {
+	stats       *stats.Collector
+	config      *config.Config // guarded by mu; see SetConfig
+	clock       clock.Clock
+	logs        *LogBuffer
+	maxRuns     int
+	maxOutliers int
+
+	reportDir string
+
+	baselinePath   string
+	baselineUpdate bool
+	thresholds     Thresholds
+
+	mu   sync.RWMutex
+	runs []*Run // oldest first
+	seq  int
+}
+
+// Option configures the registry.
+type Option func(*Registry)
+
+// WithLogBuffer includes log lines captured by buf in run artifacts.
+func WithLogBuffer(buf *LogBuffer) Option {
+	return func(r *Registry) {
+		r.logs = buf
+	}
+}
+
+// WithClock times runs and stamps outliers observed without a completion
+// time by c instead of the wall clock, so the runs of a time-scaled
+// simulation span simulated time.
+func WithClock(c clock.Clock) Option {
+	return func(r *Registry) {
+		r.clock = c
+	}
+}
+
+// WithReportDir writes each run's report to dir when the run ends, as
+// <run-id>.json and a human-readable <run-id>.txt.
+func WithReportDir(dir string) Option {
+	return func(r *Registry) {
+		r.reportDir = dir
+	}
+}
+
+// WithMaxRuns sets how many runs are retained.
+func WithMaxRuns(n int) Option {
+	return func(r *Registry) {
+		if n > 0 {
+			r.maxRuns = n
+		}
+	}
+}
+
+// NewRegistry creates a run registry.
+func NewRegistry(collector *stats.Collector, cfg *config.Config, opts ...Option) *Registry {
+	r := &Registry{
+		stats:       collector,
+		config:      cfg,
+		clock:       clock.Real,
+		maxRuns:     defaultMaxRuns,
+		maxOutliers: defaultOutliers,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// SetConfig replaces the configuration included in the artifacts of runs
+// started from now on, such as after a configuration reload.
+func (r *Registry) SetConfig(cfg *config.Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = cfg
+}
+
+// RunStarted begins a new run, resetting the statistics collector so the
+// run's snapshot covers it alone.
+func (r *Registry) RunStarted() {
+	r.stats.Reset()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	run := &Run{
+		ID:        fmt.Sprintf("run-%04d", r.seq),
+		StartedAt: r.clock.Now().UTC(),
+		config:    r.config,
+	}
+	if r.logs != nil {
+		run.logStart = r.logs.Mark()
+	}
+
+	r.runs = append(r.runs, run)
+	if len(r.runs) > r.maxRuns {
+		r.runs = r.runs[len(r.runs)-r.maxRuns:]
+	}
+}
+
+// RunStopped ends the active run, capturing final statistics, compares it
+// against the baseline if WithBaseline is set, and writes its report if
+// WithReportDir is set.
+func (r *Registry) RunStopped() {
+	snap := r.stats.Snapshot()
+
+	r.mu.Lock()
+	run := r.activeLocked()
+	if run == nil {
+		r.mu.Unlock()
+		return
+	}
+	run.EndedAt = r.clock.Now().UTC()
+	run.Snapshot = snap
+	if r.logs != nil {
+		run.logEnd = r.logs.Mark()
+	}
+	ended := *run
+	ended.Outliers = slices.Clone(run.Outliers)
+	r.mu.Unlock()
+
+	if regressions := r.checkBaseline(ended); len(regressions) > 0 {
+		r.mu.Lock()
+		run.Regressions = regressions
+		r.mu.Unlock()
+		ended.Regressions = regressions
+	}
+	r.saveReport(ended)
+}
+
+// activeLocked returns the in-progress run, or nil. Must be called with mu held.
+func (r *Registry) activeLocked() *Run {
+	if n := len(r.runs); n > 0 && r.runs[n-1].Active() {
+		return r.runs[n-1]
+	}
+	return nil
+}
+
+// List returns all retained runs, newest first.
+func (r *Registry) List() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Info, len(r.runs))
+	for i, run := range r.runs {
+		out[len(r.runs)-1-i] = Info{
+			ID:        run.ID,
+			StartedAt: run.StartedAt,
+			EndedAt:   run.EndedAt,
+			Active:    run.Active(),
+		}
+	}
+	return out
+}
+
+// Get returns a copy of the run with the given ID. Active runs carry a
+// live statistics snapshot.
+func (r *Registry) Get(id string) (Run, error) {
+	r.mu.RLock()
+	var run Run
+	found := false
+	for _, candidate := range r.runs {
+		if candidate.ID == id {
+			run, found = *candidate, true
+			run.Outliers = slices.Clone(candidate.Outliers)
+			break
+		}
+	}
+	r.mu.RUnlock()
+
+	if !found {
+		return Run{}, ErrRunNotFound
+	}
+	if run.Active() {
+		run.Snapshot = r.stats.Snapshot()
+	}
+	return run, nil
+}
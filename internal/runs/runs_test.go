@@ -0,0 +1,228 @@
+package runs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestRegistry_Lifecycle(t *testing.T) {
+	r := NewRegistry(stats.New(), &config.Config{})
+
+	r.RunStarted()
+	list := r.List()
+	if len(list) != 1 || !list[0].Active || list[0].ID != "run-0001" {
+		t.Fatalf("List() = %+v, want one active run-0001", list)
+	}
+
+	r.RunStopped()
+	r.RunStarted()
+	list = r.List()
+	if len(list) != 2 {
+		t.Fatalf("len(List()) = %d, want 2", len(list))
+	}
+	if list[0].ID != "run-0002" || !list[0].Active {
+		t.Errorf("List()[0] = %+v, want active run-0002 first", list[0])
+	}
+	if list[1].Active || list[1].EndedAt.IsZero() {
+		t.Errorf("List()[1] = %+v, want ended run", list[1])
+	}
+}
+
+func TestRegistry_WithClock(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	r := NewRegistry(stats.New(), &config.Config{}, WithClock(clk))
+
+	r.RunStarted()
+	clk.Advance(time.Minute)
+	r.ObserveAuction(&openrtb.BidRequest{ID: "req-1"}, []dispatcher.Result{{DSPName: "dsp", Latency: time.Millisecond}}, auction.Outcome{RequestID: "req-1"})
+	clk.Advance(time.Hour)
+	r.RunStopped()
+
+	run := r.List()[0]
+	if !run.StartedAt.Equal(start) || !run.EndedAt.Equal(start.Add(61*time.Minute)) {
+		t.Errorf("run spans %v to %v, want the clock's %v to %v", run.StartedAt, run.EndedAt, start, start.Add(61*time.Minute))
+	}
+	if got := r.Summary().GeneratedAt; !got.Equal(start.Add(61 * time.Minute)) {
+		t.Errorf("Summary().GeneratedAt = %v, want the clock's time", got)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteArtifacts(&buf, run.ID); err != nil {
+		t.Fatalf("WriteArtifacts() error = %v", err)
+	}
+	var outliers []Outlier
+	if err := json.Unmarshal([]byte(readZip(t, buf.Bytes())["outliers.json"]), &outliers); err != nil {
+		t.Fatalf("outliers.json: %v", err)
+	}
+	if len(outliers) != 1 || !outliers[0].Timestamp.Equal(start.Add(time.Minute)) {
+		t.Errorf("outliers = %+v, want one stamped %v", outliers, start.Add(time.Minute))
+	}
+}
+
+func TestRegistry_MaxRuns(t *testing.T) {
+	r := NewRegistry(stats.New(), &config.Config{}, WithMaxRuns(2))
+	for i := 0; i < 3; i++ {
+		r.RunStarted()
+		r.RunStopped()
+	}
+
+	if _, err := r.Get("run-0001"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("Get(run-0001) error = %v, want ErrRunNotFound", err)
+	}
+	if _, err := r.Get("run-0003"); err != nil {
+		t.Errorf("Get(run-0003) error = %v", err)
+	}
+}
+
+func TestRegistry_WriteArtifacts(t *testing.T) {
+	collector := stats.New()
+	logs := NewLogBuffer(100)
+	logger := log.New(logs, "", 0)
+	cfg := &config.Config{Simulation: config.SimulationConfig{RequestsPerSecond: 42}}
+	r := NewRegistry(collector, cfg, WithLogBuffer(logs))
+
+	logger.Print("before run")
+	r.RunStarted()
+	logger.Print("during run")
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{{DSPName: "dsp1"}})
+	r.RunStopped()
+	logger.Print("after run")
+
+	var buf bytes.Buffer
+	if err := r.WriteArtifacts(&buf, "run-0001"); err != nil {
+		t.Fatalf("WriteArtifacts() error = %v", err)
+	}
+
+	files := readZip(t, buf.Bytes())
+	if !strings.Contains(files["config.yaml"], "requests_per_second: 42") {
+		t.Errorf("config.yaml = %q, want requests_per_second: 42", files["config.yaml"])
+	}
+
+	var snap stats.Snapshot
+	if err := json.Unmarshal([]byte(files["snapshot.json"]), &snap); err != nil {
+		t.Fatalf("snapshot.json: %v", err)
+	}
+	if snap.TotalRequests != 1 {
+		t.Errorf("snapshot TotalRequests = %d, want 1", snap.TotalRequests)
+	}
+
+	if !strings.Contains(files["report.json"], `"run_id": "run-0001"`) {
+		t.Errorf("report.json = %q, want run-0001", files["report.json"])
+	}
+	if !strings.Contains(files["report.txt"], "Run run-0001") || !strings.Contains(files["report.txt"], "dsp1:") {
+		t.Errorf("report.txt = %q, want run header and dsp1 line", files["report.txt"])
+	}
+	if files["logs.txt"] != "during run\n" {
+		t.Errorf("logs.txt = %q, want only lines logged during the run", files["logs.txt"])
+	}
+}
+
+func TestRegistry_SnapshotCoversOneRun(t *testing.T) {
+	collector := stats.New()
+	r := NewRegistry(collector, &config.Config{})
+
+	r.RunStarted()
+	collector.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{{DSPName: "dsp1"}})
+	r.RunStopped()
+	r.RunStarted()
+	collector.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{{DSPName: "dsp1"}})
+	collector.RecordAuction(auction.Outcome{RequestID: "req-3"}, []dispatcher.Result{{DSPName: "dsp1"}})
+	r.RunStopped()
+
+	for id, want := range map[string]uint64{"run-0001": 1, "run-0002": 2} {
+		run, err := r.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if run.Snapshot.TotalRequests != want {
+			t.Errorf("%s TotalRequests = %d, want %d", id, run.Snapshot.TotalRequests, want)
+		}
+	}
+}
+
+func TestRegistry_ConfigPerRun(t *testing.T) {
+	r := NewRegistry(stats.New(), &config.Config{Simulation: config.SimulationConfig{RequestsPerSecond: 10}})
+	r.RunStarted()
+	r.RunStopped()
+	r.SetConfig(&config.Config{Simulation: config.SimulationConfig{RequestsPerSecond: 20}})
+
+	var buf bytes.Buffer
+	if err := r.WriteArtifacts(&buf, "run-0001"); err != nil {
+		t.Fatalf("WriteArtifacts() error = %v", err)
+	}
+	if cfg := readZip(t, buf.Bytes())["config.yaml"]; !strings.Contains(cfg, "requests_per_second: 10") {
+		t.Errorf("config.yaml = %q, want the configuration the run started with", cfg)
+	}
+}
+
+func TestRegistry_Outliers(t *testing.T) {
+	r := NewRegistry(stats.New(), &config.Config{}, WithOutliers(2))
+	r.RunStarted()
+	for i, ms := range []int{30, 10, 50, 20} {
+		req := &openrtb.BidRequest{ID: fmt.Sprintf("req-%d", i)}
+		results := []dispatcher.Result{
+			{DSPName: "fast", Latency: time.Millisecond},
+			{DSPName: "slow", Latency: time.Duration(ms) * time.Millisecond},
+		}
+		r.ObserveAuction(req, results, auction.Outcome{RequestID: req.ID})
+	}
+	r.RunStopped()
+	r.ObserveAuction(&openrtb.BidRequest{ID: "late"}, []dispatcher.Result{{DSPName: "slow", Latency: time.Second}}, auction.Outcome{})
+
+	var buf bytes.Buffer
+	if err := r.WriteArtifacts(&buf, "run-0001"); err != nil {
+		t.Fatalf("WriteArtifacts() error = %v", err)
+	}
+	var outliers []Outlier
+	if err := json.Unmarshal([]byte(readZip(t, buf.Bytes())["outliers.json"]), &outliers); err != nil {
+		t.Fatalf("outliers.json: %v", err)
+	}
+	if len(outliers) != 2 || outliers[0].RequestID != "req-2" || outliers[1].RequestID != "req-0" {
+		t.Fatalf("outliers = %+v, want req-2 then req-0", outliers)
+	}
+	if outliers[0].LatencyMS != 50 || outliers[0].Request == nil {
+		t.Errorf("outliers[0] = %+v, want 50ms with its request", outliers[0])
+	}
+}
+
+func TestRegistry_WriteArtifacts_NotFound(t *testing.T) {
+	r := NewRegistry(stats.New(), &config.Config{})
+	if err := r.WriteArtifacts(io.Discard, "run-9999"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("WriteArtifacts() error = %v, want ErrRunNotFound", err)
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
@@ -0,0 +1,57 @@
+package runs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// Summary is the machine-readable record of a simulator session, written
+// at shutdown: the retained runs, a report with per-DSP scorecards, and
+// the final statistics it was built from. Statistics reset when a run
+// starts, so the report and statistics cover the latest run.
+type Summary struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Runs        []Info         `json:"runs"` // newest first
+	Report      Report         `json:"report"`
+	Snapshot    stats.Snapshot `json:"snapshot"`
+}
+
+// Summary builds the session summary from the collector's current
+// statistics. The report spans from the start of the latest run to now.
+func (r *Registry) Summary() Summary {
+	now := r.clock.Now().UTC()
+	runs := r.List()
+
+	session := Run{EndedAt: now, Snapshot: r.stats.Snapshot()}
+	if len(runs) > 0 {
+		session.StartedAt = runs[0].StartedAt
+	} else {
+		session.StartedAt = now
+	}
+	return Summary{
+		GeneratedAt: now,
+		Runs:        runs,
+		Report:      NewReport(session),
+		Snapshot:    session.Snapshot,
+	}
+}
+
+// WriteSummary writes the session summary as JSON to path, creating its
+// directory if needed.
+func (r *Registry) WriteSummary(path string) error {
+	data, err := json.MarshalIndent(r.Summary(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal summary: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,59 @@
+package runs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+func TestRegistry_WriteSummary(t *testing.T) {
+	collector := stats.New()
+	r := NewRegistry(collector, &config.Config{})
+
+	for range 2 {
+		r.RunStarted()
+		collector.RecordAuction(auction.Outcome{RequestID: "req"}, []dispatcher.Result{{DSPName: "dsp1"}})
+		r.RunStopped()
+	}
+
+	path := filepath.Join(t.TempDir(), "out", "summary.json")
+	if err := r.WriteSummary(path); err != nil {
+		t.Fatalf("WriteSummary() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sum Summary
+	if err := json.Unmarshal(data, &sum); err != nil {
+		t.Fatalf("decoding summary: %v", err)
+	}
+	if len(sum.Runs) != 2 || sum.Runs[0].ID != "run-0002" {
+		t.Errorf("runs = %+v, want run-0002 and run-0001", sum.Runs)
+	}
+	if sum.Snapshot.TotalRequests != 1 || sum.Report.Requests != 1 {
+		t.Errorf("snapshot/report requests = %d/%d, want 1 from the latest run",
+			sum.Snapshot.TotalRequests, sum.Report.Requests)
+	}
+	if len(sum.Report.DSPs) != 1 || sum.Report.DSPs[0].Name != "dsp1" {
+		t.Errorf("scorecards = %+v, want dsp1", sum.Report.DSPs)
+	}
+	if !sum.Report.StartedAt.Equal(sum.Runs[0].StartedAt) {
+		t.Errorf("report starts %v, want the latest run's start %v", sum.Report.StartedAt, sum.Runs[0].StartedAt)
+	}
+
+	rep, err := ReadReport(path)
+	if err != nil {
+		t.Fatalf("ReadReport() error = %v", err)
+	}
+	if rep.Requests != 1 || len(rep.DSPs) != 1 {
+		t.Errorf("ReadReport() = %+v, want the summary's report", rep)
+	}
+}
@@ -0,0 +1,293 @@
+// Package schema validates generated bid requests against an embedded
+// OpenRTB 2.5 JSON schema. It implements the subset of JSON Schema
+// (draft-07) the embedded schema uses: type, properties, required, items,
+// enum, minimum, maximum, minLength, maxLength, minItems, pattern, anyOf,
+// not, and local $ref to definitions.
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed bidrequest.json
+var bidRequestSchema []byte
+
+// Schema is a parsed JSON schema node.
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Type        typeList           `json:"type"`
+	Properties  map[string]*Schema `json:"properties"`
+	Required    []string           `json:"required"`
+	Items       *Schema            `json:"items"`
+	Enum        []any              `json:"enum"`
+	Minimum     *float64           `json:"minimum"`
+	Maximum     *float64           `json:"maximum"`
+	MinLength   *int               `json:"minLength"`
+	MaxLength   *int               `json:"maxLength"`
+	MinItems    *int               `json:"minItems"`
+	Pattern     string             `json:"pattern"`
+	AnyOf       []*Schema          `json:"anyOf"`
+	Not         *Schema            `json:"not"`
+	Definitions map[string]*Schema `json:"definitions"`
+
+	pattern *regexp.Regexp
+}
+
+// typeList accepts "type" as a single name or a list of names.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// Parse parses a JSON schema document and compiles its patterns.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("schema pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	children := []*Schema{s.Items, s.Not}
+	children = append(children, s.AnyOf...)
+	for _, c := range s.Properties {
+		children = append(children, c)
+	}
+	for _, c := range s.Definitions {
+		children = append(children, c)
+	}
+	for _, c := range children {
+		if c == nil {
+			continue
+		}
+		if err := c.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BidRequest returns the embedded OpenRTB bid request schema.
+var BidRequest = sync.OnceValue(func() *Schema {
+	s, err := Parse(bidRequestSchema)
+	if err != nil {
+		panic("schema: embedded bid request schema: " + err.Error())
+	}
+	return s
+})
+
+// ValidationError lists every violation found in a document.
+type ValidationError struct {
+	Violations []string // "<JSON pointer>: <problem>", sorted
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return e.Violations[0]
+	}
+	return fmt.Sprintf("%d violations: %s", len(e.Violations), strings.Join(e.Violations, "; "))
+}
+
+// Validate checks the JSON document data against s, returning a
+// *ValidationError if it does not conform.
+func (s *Schema) Validate(data []byte) error {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing document: %w", err)
+	}
+	var violations []string
+	s.validate(s, doc, "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return &ValidationError{Violations: violations}
+}
+
+// validate checks v at path against s, resolving references in root.
+func (s *Schema) validate(root *Schema, v any, path string, out *[]string) {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/definitions/")
+		def := root.Definitions[name]
+		if !ok || def == nil {
+			*out = append(*out, fmt.Sprintf("%s: unresolvable $ref %q", pointer(path), s.Ref))
+			return
+		}
+		def.validate(root, v, path, out)
+		return
+	}
+	fail := func(format string, args ...any) {
+		*out = append(*out, pointer(path)+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Type) > 0 && !hasType(s.Type, v) {
+		fail("got %s, want %s", typeName(v), strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		fail("value %v not in %v", v, s.Enum)
+	}
+
+	switch val := v.(type) {
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			fail("%v is less than minimum %v", val, *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			fail("%v is greater than maximum %v", val, *s.Maximum)
+		}
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("length %d is less than minLength %d", n, *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("length %d is greater than maxLength %d", n, *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			fail("%q does not match %s", val, s.Pattern)
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("%d items, want at least %d", len(val), *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(root, item, fmt.Sprintf("%s/%d", path, i), out)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for name, prop := range s.Properties {
+			if pv, ok := val[name]; ok {
+				prop.validate(root, pv, path+"/"+name, out)
+			}
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, alt := range s.AnyOf {
+			var sub []string
+			alt.validate(root, v, path, &sub)
+			if len(sub) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("matches none of the anyOf alternatives")
+		}
+	}
+	if s.Not != nil {
+		var sub []string
+		s.Not.validate(root, v, path, &sub)
+		if len(sub) == 0 {
+			fail("matches a schema it must not match")
+		}
+	}
+}
+
+func hasType(types []string, v any) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := v.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "null":
+			if v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// pointer returns path as a JSON pointer, "/" for the document root.
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
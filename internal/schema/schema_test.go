@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validRequest = `{
+	"id": "req-1",
+	"imp": [{"id": "1", "banner": {"w": 320, "h": 50}, "bidfloor": 0.5, "secure": 1}],
+	"app": {"id": "app-1", "bundle": "com.example.game", "cat": ["IAB9-30"]},
+	"device": {"ip": "203.0.113.7", "devicetype": 4, "language": "en",
+		"geo": {"lat": 40.7, "lon": -74.0, "country": "USA"}},
+	"regs": {"ext": {"gdpr": 1}},
+	"at": 1, "tmax": 100, "cur": ["USD"]
+}`
+
+func TestBidRequest_Valid(t *testing.T) {
+	if err := BidRequest().Validate([]byte(validRequest)); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestBidRequest_Violations(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+		want    string
+	}{
+		{"missing imp", [2]string{`"imp"`, `"imps"`}, `/: missing required property "imp"`},
+		{"empty imp", [2]string{`[{"id": "1", "banner": {"w": 320, "h": 50}, "bidfloor": 0.5, "secure": 1}]`, `[]`}, "/imp: 0 items"},
+		{"no media", [2]string{`"banner"`, `"banners"`}, "/imp/0: matches none of the anyOf alternatives"},
+		{"site and app", [2]string{`"regs"`, `"site": {"id": "s"}, "regs"`}, "/: matches a schema it must not match"},
+		{"latitude", [2]string{`"lat": 40.7`, `"lat": 140.7`}, "/device/geo/lat: 140.7 is greater than maximum 90"},
+		{"country", [2]string{`"USA"`, `"US"`}, `/device/geo/country: "US" does not match`},
+		{"devicetype", [2]string{`"devicetype": 4`, `"devicetype": 9`}, "/device/devicetype: 9 is greater than maximum 7"},
+		{"gdpr", [2]string{`"gdpr": 1`, `"gdpr": 2`}, "/regs/ext/gdpr: value 2 not in"},
+		{"category", [2]string{`"IAB9-30"`, `"games"`}, `/app/cat/0: "games" does not match`},
+		{"integer", [2]string{`"tmax": 100`, `"tmax": 100.5`}, "/tmax: got number, want integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := strings.Replace(validRequest, tt.replace[0], tt.replace[1], 1)
+			err := BidRequest().Validate([]byte(doc))
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_MultipleViolationsSorted(t *testing.T) {
+	err := BidRequest().Validate([]byte(`{"imp": [], "tmax": "100"}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	want := []string{
+		`/: missing required property "id"`,
+		"/imp: 0 items, want at least 1",
+		"/tmax: got string, want integer",
+	}
+	if strings.Join(verr.Violations, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Violations = %q, want %q", verr.Violations, want)
+	}
+}
+
+func TestValidate_InvalidJSON(t *testing.T) {
+	err := BidRequest().Validate([]byte(`{"id":`))
+	var verr *ValidationError
+	if err == nil || errors.As(err, &verr) {
+		t.Errorf("Validate() error = %v, want a parse error", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse([]byte(`{"pattern": "("}`)); err == nil {
+		t.Error("Parse() with a bad pattern: error = nil")
+	}
+
+	s, err := Parse([]byte(`{"type": ["object", "null"], "properties": {"a": {"$ref": "#/definitions/missing"}}}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := s.Validate([]byte(`null`)); err != nil {
+		t.Errorf("Validate(null) error = %v", err)
+	}
+	if err := s.Validate([]byte(`{"a": 1}`)); err == nil || !strings.Contains(err.Error(), "unresolvable $ref") {
+		t.Errorf("Validate() error = %v, want unresolvable $ref", err)
+	}
+}
@@ -0,0 +1,262 @@
+// Package seats emulates bidder seats in-process for self-contained market
+// experiments. Virtual seats bid from configured values, strategies,
+// budgets, and pacing without any endpoint being called, so auction
+// mechanisms such as reserve prices can be studied at rates limited only
+// by the CPU.
+package seats
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/internal/state"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Market answers bid requests for a set of virtual seats. It implements
+// engine.Dispatcher, charges winning seats their clearing prices and
+// counts their wins against frequency caps as an engine.Observer, and
+// restarts budgets and pacing as an engine.RunListener. Spend and
+// frequency caps are kept in a state store. Safe for concurrent use.
+type Market struct {
+	seats  []*seat
+	byName map[string]*seat
+	src    *randutil.Source
+	clock  clock.Clock
+
+	spend *state.Namespace // spend over the current run, by seat
+	wins  *state.Namespace // wins by seat and user, for frequency caps
+}
+
+// seat is one virtual bidder.
+type seat struct {
+	cfg config.SeatConfig
+
+	mu    sync.Mutex
+	start time.Time // when pacing began
+}
+
+// Option configures the market.
+type Option func(*Market)
+
+// WithClock sets the clock pacing is measured against. Defaults to the
+// wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(m *Market) {
+		if c != nil {
+			m.clock = c
+		}
+	}
+}
+
+// WithState keeps seat spend and frequency caps in ns, such as a
+// namespace of a store shared with the user pool and persisted across
+// restarts. Defaults to an in-memory store of the market's own, which
+// has no background sweep since the market is never closed.
+func WithState(ns *state.Namespace) Option {
+	return func(m *Market) {
+		if ns != nil {
+			m.spend, m.wins = ns.Namespace("spend"), ns.Namespace("wins")
+		}
+	}
+}
+
+// WithSource sets the random source seat values and no-bids are drawn
+// from. Defaults to the default source.
+func WithSource(src *randutil.Source) Option {
+	return func(m *Market) {
+		if src != nil {
+			m.src = src
+		}
+	}
+}
+
+// New creates a market of the seats in cfgs, which must have been
+// validated with their defaults applied.
+func New(cfgs []config.SeatConfig, opts ...Option) *Market {
+	m := &Market{
+		byName: make(map[string]*seat, len(cfgs)),
+		src:    randutil.Default(),
+		clock:  clock.Real,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.spend == nil {
+		WithState(state.New(state.WithClock(m.clock), state.WithSweepInterval(0)).Namespace("seats"))(m)
+	}
+	now := m.clock.Now()
+	for _, cfg := range cfgs {
+		s := &seat{cfg: cfg, start: now}
+		m.seats = append(m.seats, s)
+		m.byName[cfg.Name] = s
+	}
+	return m
+}
+
+// Dispatch returns every seat's response to req, in configuration order.
+// Seats answer at once, so results carry no latency.
+func (m *Market) Dispatch(_ context.Context, req *openrtb.BidRequest) []dispatcher.Result {
+	now := m.clock.Now()
+	results := make([]dispatcher.Result, len(m.seats))
+	for i, s := range m.seats {
+		results[i] = dispatcher.Result{DSPName: s.cfg.Name, Response: m.respond(s, req, now)}
+	}
+	return results
+}
+
+// Close implements engine.Dispatcher. A market holds no resources.
+func (m *Market) Close() {}
+
+// ObserveAuction charges the winning seat the clearing price and, if it
+// is frequency capped, counts the win against the request's user.
+func (m *Market) ObserveAuction(req *openrtb.BidRequest, _ []dispatcher.Result, outcome auction.Outcome) {
+	if outcome.Winner == nil {
+		return
+	}
+	s := m.byName[outcome.WinningDSP]
+	if s == nil {
+		return
+	}
+	m.spend.Add(s.cfg.Name, outcome.ClearingPrice, 0)
+	if fc := s.cfg.FrequencyCap; fc.Enabled() {
+		if key, ok := winsKey(s, req); ok {
+			m.wins.Add(key, 1, fc.Window)
+		}
+	}
+}
+
+// RunStarted refunds every seat and restarts its pacing, so each run
+// spends its budgets afresh. Frequency caps carry over, as users'
+// exposure does.
+func (m *Market) RunStarted() {
+	m.spend.Clear()
+	now := m.clock.Now()
+	for _, s := range m.seats {
+		s.mu.Lock()
+		s.start = now
+		s.mu.Unlock()
+	}
+}
+
+// RunStopped implements engine.RunListener.
+func (m *Market) RunStopped() {}
+
+// SeatState is a point-in-time view of a seat's spend.
+type SeatState struct {
+	Name   string
+	Spent  float64
+	Budget float64 // 0 when unlimited
+}
+
+// Seats returns every seat's spend over the current run.
+func (m *Market) Seats() []SeatState {
+	out := make([]SeatState, len(m.seats))
+	for i, s := range m.seats {
+		out[i] = SeatState{Name: s.cfg.Name, Spent: m.spend.Counter(s.cfg.Name), Budget: s.cfg.Budget}
+	}
+	return out
+}
+
+// respond returns s's bids on req's impressions, or a no-bid response
+// when it passes on the request, its budget allows no more spend at now,
+// or it has reached its frequency cap for req's user.
+func (m *Market) respond(s *seat, req *openrtb.BidRequest, now time.Time) *openrtb.BidResponse {
+	resp := &openrtb.BidResponse{ID: req.ID}
+	if m.src.Chance(s.cfg.NoBidRate) || !s.canSpend(m.spend.Counter(s.cfg.Name), now) || m.capped(s, req) {
+		return resp
+	}
+
+	var bids []openrtb.Bid
+	for i, imp := range req.Imp {
+		price, ok := s.price(imp, m.src)
+		if !ok {
+			continue
+		}
+		bid := openrtb.Bid{
+			ID:    req.ID + "-" + s.cfg.Name + "-" + strconv.Itoa(i),
+			ImpID: imp.ID,
+			Price: price,
+			CrID:  s.cfg.Name + "-cr",
+		}
+		switch {
+		case imp.Video != nil:
+			bid.W, bid.H = imp.Video.W, imp.Video.H
+			bid.MType = openrtb.MTypeVideo
+			bid.AdM = `<VAST version="3.0"><Ad id="` + bid.ID + `"></Ad></VAST>`
+		default:
+			if imp.Banner != nil {
+				bid.W, bid.H = imp.Banner.W, imp.Banner.H
+			}
+			bid.MType = openrtb.MTypeBanner
+			bid.AdM = `<div class="ad">` + s.cfg.Name + `</div>`
+		}
+		bids = append(bids, bid)
+	}
+	if len(bids) > 0 {
+		resp.SeatBid = []openrtb.SeatBid{{Seat: s.cfg.Name, Bid: bids}}
+	}
+	return resp
+}
+
+// price returns the seat's bid on imp by its strategy, or false if it
+// values the impression at less than the floor or it is open to deals
+// only.
+func (s *seat) price(imp openrtb.Imp, src *randutil.Source) (float64, bool) {
+	if imp.PMP != nil && imp.PMP.PrivateAuction == 1 {
+		return 0, false
+	}
+	value := s.cfg.Value.Sample(src)
+	if value <= 0 || value < imp.BidFloor {
+		return 0, false
+	}
+	if s.cfg.Strategy == config.StrategyShaded {
+		return max(value*(1-s.cfg.Shade), imp.BidFloor), true
+	}
+	return value, true
+}
+
+// canSpend reports whether spent is within the seat's budget, or with
+// even pacing the share of it for the pacing period elapsed at now.
+// Auctions in flight are not reserved against the budget, so it can be
+// overspent by the wins they bring in.
+func (s *seat) canSpend(spent float64, now time.Time) bool {
+	if s.cfg.Budget == 0 {
+		return true
+	}
+	allowed := s.cfg.Budget
+	if s.cfg.Pacing == config.PacingEven {
+		s.mu.Lock()
+		start := s.start
+		s.mu.Unlock()
+		allowed *= min(1, float64(now.Sub(start))/float64(s.cfg.PacingPeriod))
+	}
+	return spent < allowed
+}
+
+// capped reports whether s has won req's user as often as its frequency
+// cap allows. Requests without a user are never capped.
+func (m *Market) capped(s *seat, req *openrtb.BidRequest) bool {
+	fc := s.cfg.FrequencyCap
+	if !fc.Enabled() {
+		return false
+	}
+	key, ok := winsKey(s, req)
+	return ok && m.wins.Counter(key) >= float64(fc.Impressions)
+}
+
+// winsKey returns the key of s's win count for req's user, or false if
+// req names no user.
+func winsKey(s *seat, req *openrtb.BidRequest) (string, bool) {
+	if req == nil || req.User == nil || req.User.ID == "" {
+		return "", false
+	}
+	return s.cfg.Name + "/" + req.User.ID, true
+}
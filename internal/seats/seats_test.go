@@ -0,0 +1,230 @@
+package seats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/internal/state"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func fixed(v float64) randutil.Dist {
+	return randutil.Dist{Type: randutil.DistFixed, Value: v}
+}
+
+func request(floor float64) *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		ID: "req-1",
+		Imp: []openrtb.Imp{
+			{ID: "1", Banner: &openrtb.Banner{W: 300, H: 250}, BidFloor: floor},
+			{ID: "2", Video: &openrtb.Video{W: 640, H: 480}, BidFloor: floor},
+		},
+	}
+}
+
+// win charges seat name price as the winner of an auction.
+func win(m *Market, name string, price float64) {
+	m.ObserveAuction(nil, nil, auction.Outcome{Winner: &openrtb.Bid{}, WinningDSP: name, ClearingPrice: price})
+}
+
+func TestMarket_Dispatch(t *testing.T) {
+	m := New([]config.SeatConfig{
+		{Name: "truthful", Value: fixed(2), Strategy: config.StrategyTruthful},
+		{Name: "shaded", Value: fixed(2), Strategy: config.StrategyShaded, Shade: 0.25},
+		{Name: "passive", Value: fixed(2), Strategy: config.StrategyTruthful, NoBidRate: 1},
+	}, WithSource(randutil.New(1)))
+	defer m.Close()
+
+	req := request(0.5)
+	results := m.Dispatch(context.Background(), req)
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+	want := map[string]float64{"truthful": 2, "shaded": 1.5}
+	for _, r := range results[:2] {
+		if r.Error != nil || r.Response == nil || len(r.Response.SeatBid) != 1 {
+			t.Fatalf("%s result = %+v, want one seat of bids", r.DSPName, r)
+		}
+		bids := r.Response.SeatBid[0].Bid
+		if len(bids) != 2 {
+			t.Fatalf("%s bids = %d, want one per imp", r.DSPName, len(bids))
+		}
+		for _, b := range bids {
+			if b.Price != want[r.DSPName] {
+				t.Errorf("%s price = %v, want %v", r.DSPName, b.Price, want[r.DSPName])
+			}
+		}
+	}
+	if !results[2].Response.IsNoBid() {
+		t.Errorf("passive seat bid: %+v", results[2].Response)
+	}
+
+	// Bids pass the engine's response validation
+	valid, rejected := auction.ValidateBids(req, results)
+	if len(rejected) > 0 {
+		t.Errorf("ValidateBids() rejected %+v", rejected)
+	}
+	if len(valid) != 3 {
+		t.Errorf("len(valid) = %d, want 3", len(valid))
+	}
+}
+
+func TestMarket_Floors(t *testing.T) {
+	m := New([]config.SeatConfig{
+		{Name: "truthful", Value: fixed(2), Strategy: config.StrategyTruthful},
+		{Name: "shaded", Value: fixed(2), Strategy: config.StrategyShaded, Shade: 0.5},
+	})
+
+	// Shading stops at the floor
+	results := m.Dispatch(context.Background(), request(1.5))
+	if p := results[1].Response.SeatBid[0].Bid[0].Price; p != 1.5 {
+		t.Errorf("shaded price = %v, want the 1.5 floor", p)
+	}
+
+	// Impressions floored above the value are passed on
+	results = m.Dispatch(context.Background(), request(3))
+	for _, r := range results {
+		if !r.Response.IsNoBid() {
+			t.Errorf("%s bid over its value: %+v", r.DSPName, r.Response)
+		}
+	}
+}
+
+func TestMarket_Budget(t *testing.T) {
+	m := New([]config.SeatConfig{
+		{Name: "capped", Value: fixed(2), Strategy: config.StrategyTruthful, Budget: 10, Pacing: config.PacingASAP},
+	})
+
+	win(m, "capped", 6)
+	win(m, "other", 100)
+	if m.Dispatch(context.Background(), request(0))[0].Response.IsNoBid() {
+		t.Fatal("seat stopped bidding under its budget")
+	}
+	win(m, "capped", 4)
+	if !m.Dispatch(context.Background(), request(0))[0].Response.IsNoBid() {
+		t.Error("seat bid past its budget")
+	}
+	if got := m.Seats()[0]; got.Spent != 10 || got.Budget != 10 {
+		t.Errorf("Seats() = %+v, want 10 of 10 spent", got)
+	}
+
+	m.RunStarted()
+	if m.Dispatch(context.Background(), request(0))[0].Response.IsNoBid() {
+		t.Error("seat not refunded when a run started")
+	}
+}
+
+func TestMarket_EvenPacing(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	m := New([]config.SeatConfig{
+		{Name: "paced", Value: fixed(2), Strategy: config.StrategyTruthful,
+			Budget: 100, Pacing: config.PacingEven, PacingPeriod: 100 * time.Minute},
+	}, WithClock(clk))
+	bids := func() bool {
+		return !m.Dispatch(context.Background(), request(0))[0].Response.IsNoBid()
+	}
+
+	clk.Advance(10 * time.Minute)
+	if !bids() {
+		t.Fatal("seat not bidding with $10 of pacing allowance")
+	}
+	win(m, "paced", 10)
+	if bids() {
+		t.Error("seat bid ahead of its pacing")
+	}
+	clk.Advance(time.Minute)
+	if !bids() {
+		t.Error("seat not bidding once its allowance grew")
+	}
+
+	// The allowance stops at the budget
+	clk.Advance(time.Hour * 10)
+	win(m, "paced", 90)
+	if bids() {
+		t.Error("seat bid past its budget after the pacing period")
+	}
+}
+
+func TestMarket_FrequencyCap(t *testing.T) {
+	clk := clock.NewManual(time.Unix(0, 0))
+	m := New([]config.SeatConfig{
+		{Name: "capped", Value: fixed(2), Strategy: config.StrategyTruthful,
+			FrequencyCap: config.FrequencyCapConfig{Impressions: 2, Window: time.Hour}},
+	}, WithClock(clk))
+	forUser := func(id string) *openrtb.BidRequest {
+		req := request(0)
+		req.User = &openrtb.User{ID: id}
+		return req
+	}
+	bids := func(req *openrtb.BidRequest) bool {
+		return !m.Dispatch(context.Background(), req)[0].Response.IsNoBid()
+	}
+	winUser := func(id string) {
+		m.ObserveAuction(forUser(id), nil, auction.Outcome{Winner: &openrtb.Bid{}, WinningDSP: "capped", ClearingPrice: 1})
+	}
+
+	winUser("alice")
+	if !bids(forUser("alice")) {
+		t.Fatal("seat stopped bidding under its frequency cap")
+	}
+	winUser("alice")
+	if bids(forUser("alice")) {
+		t.Error("seat bid on a user past its frequency cap")
+	}
+	if !bids(forUser("bob")) || !bids(request(0)) {
+		t.Error("frequency cap applied to another user or a request without one")
+	}
+
+	// Caps outlive runs, and lift when the window closes
+	m.RunStarted()
+	if bids(forUser("alice")) {
+		t.Error("frequency cap lifted when a run started")
+	}
+	clk.Advance(time.Hour)
+	if !bids(forUser("alice")) {
+		t.Error("frequency cap still applied after its window")
+	}
+}
+
+func TestMarket_WithState(t *testing.T) {
+	path := t.TempDir() + "/state.json"
+	store, err := state.Open(path, state.WithSweepInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfgs := []config.SeatConfig{
+		{Name: "capped", Value: fixed(2), Strategy: config.StrategyTruthful, Budget: 10, Pacing: config.PacingASAP,
+			FrequencyCap: config.FrequencyCapConfig{Impressions: 1}},
+	}
+	req := request(0)
+	req.User = &openrtb.User{ID: "alice"}
+
+	m := New(cfgs, WithState(store.Namespace("seats")))
+	m.ObserveAuction(req, nil, auction.Outcome{Winner: &openrtb.Bid{}, WinningDSP: "capped", ClearingPrice: 4})
+	if got := store.Namespace("seats").Namespace("spend").Counter("capped"); got != 4 {
+		t.Errorf("spend in the store = %v, want 4", got)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restart keeps the user capped but refunds the seat
+	store, err = state.Open(path, state.WithSweepInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	m = New(cfgs, WithState(store.Namespace("seats")))
+	m.RunStarted()
+	if !m.Dispatch(context.Background(), req)[0].Response.IsNoBid() {
+		t.Error("seat bid on a user capped before the restart")
+	}
+	if got := m.Seats()[0].Spent; got != 0 {
+		t.Errorf("Spent = %v once a run started, want 0", got)
+	}
+}
@@ -0,0 +1,238 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// KafkaTopics names the topic for each event type. An empty topic skips
+// that event type.
+type KafkaTopics struct {
+	Requests  string // RequestEvent per auction
+	Responses string // ResponseEvent per DSP called
+	Outcomes  string // Record per auction
+}
+
+func (t KafkaTopics) list() []string {
+	var topics []string
+	for _, topic := range []string{t.Requests, t.Responses, t.Outcomes} {
+		if topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// RequestEvent is published to the requests topic.
+type RequestEvent struct {
+	Time      time.Time           `json:"time"`
+	RequestID string              `json:"request_id"`
+	Request   *openrtb.BidRequest `json:"request"`
+}
+
+// ResponseEvent is published to the responses topic for each DSP called.
+type ResponseEvent struct {
+	Time      time.Time            `json:"time"`
+	RequestID string               `json:"request_id"`
+	DSP       string               `json:"dsp"`
+	LatencyMS float64              `json:"latency_ms"`
+	Error     string               `json:"error,omitempty"`
+	Response  *openrtb.BidResponse `json:"response,omitempty"` // nil for no-bids and errors
+}
+
+// producer sends records to Kafka, returning how many were not
+// acknowledged.
+type producer interface {
+	Produce(ctx context.Context, records []*kgo.Record) (int, error)
+	Close() error
+}
+
+// auctionEvent is a completed auction waiting to be encoded.
+type auctionEvent struct {
+	time    time.Time
+	req     *openrtb.BidRequest
+	results []dispatcher.Result
+	outcome auction.Outcome
+}
+
+// Kafka publishes bid requests, DSP responses, and auction outcomes to
+// Kafka topics, keyed by request ID. It implements engine.Observer.
+// Auctions are encoded and sent in batches from a background goroutine;
+// a full queue drops auctions rather than slowing the simulation.
+type Kafka struct {
+	p      producer
+	topics KafkaTopics
+	codec  *codec
+	options
+
+	queue   chan auctionEvent
+	done    chan struct{}
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// OpenKafka connects to the cluster, looks up the topics, and starts
+// publishing. format is FormatJSON or FormatAvro.
+func OpenKafka(ctx context.Context, conn KafkaConn, topics KafkaTopics, format Format, opts ...Option) (*Kafka, error) {
+	c, err := newCodec(format)
+	if err != nil {
+		return nil, err
+	}
+	if len(topics.list()) == 0 {
+		return nil, fmt.Errorf("no kafka topics configured")
+	}
+	client, err := dialKafka(ctx, conn, topics.list())
+	if err != nil {
+		return nil, err
+	}
+	return newKafka(client, topics, c, opts...), nil
+}
+
+func newKafka(p producer, topics KafkaTopics, c *codec, opts ...Option) *Kafka {
+	k := &Kafka{
+		p:       p,
+		topics:  topics,
+		codec:   c,
+		options: newOptions(opts),
+		done:    make(chan struct{}),
+	}
+	k.queue = make(chan auctionEvent, k.queueSize)
+	go k.run()
+	return k
+}
+
+// ObserveAuction queues the events of an auction completed now, by the
+// exporter's clock. Never blocks.
+func (k *Kafka) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	k.ObserveAuctionAt(k.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt queues the events of an auction completed at t. Never
+// blocks.
+func (k *Kafka) ObserveAuctionAt(t time.Time, req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	select {
+	case k.queue <- auctionEvent{time: t, req: req, results: results, outcome: outcome}:
+	default:
+		k.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of auctions dropped because the queue was full.
+func (k *Kafka) Dropped() uint64 {
+	return k.dropped.Load()
+}
+
+// Failed returns the number of messages that could not be encoded or were
+// not acknowledged by Kafka.
+func (k *Kafka) Failed() uint64 {
+	return k.failed.Load()
+}
+
+// Close publishes any queued auctions and closes the broker connections.
+// The exporter must not be observing auctions any more.
+func (k *Kafka) Close() error {
+	close(k.queue)
+	<-k.done
+	return k.p.Close()
+}
+
+func (k *Kafka) run() {
+	defer close(k.done)
+
+	ticker := time.NewTicker(k.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*kgo.Record, 0, k.batchSize)
+	for {
+		select {
+		case ev, ok := <-k.queue:
+			if !ok {
+				k.flush(batch)
+				return
+			}
+			batch = k.appendMessages(batch, ev)
+			if len(batch) >= k.batchSize {
+				k.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			k.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// appendMessages encodes ev's events for the configured topics.
+func (k *Kafka) appendMessages(dst []*kgo.Record, ev auctionEvent) []*kgo.Record {
+	key := []byte(ev.outcome.RequestID)
+	add := func(topic string, encode func() ([]byte, error)) {
+		value, err := encode()
+		if err != nil {
+			k.failed.Add(1)
+			log.Printf("kafka: encoding %s event for %s: %v", topic, ev.outcome.RequestID, err)
+			return
+		}
+		dst = append(dst, &kgo.Record{
+			Topic:     topic,
+			Key:       key,
+			Value:     value,
+			Timestamp: ev.time,
+			Headers:   []kgo.RecordHeader{{Key: "content-type", Value: k.codec.contentType}},
+		})
+	}
+
+	if k.topics.Requests != "" {
+		add(k.topics.Requests, func() ([]byte, error) {
+			return k.codec.request(RequestEvent{Time: ev.time, RequestID: ev.outcome.RequestID, Request: ev.req})
+		})
+	}
+	if k.topics.Responses != "" {
+		for _, r := range ev.results {
+			if r.Skipped != dispatcher.SkipNone {
+				continue
+			}
+			event := ResponseEvent{
+				Time:      ev.time,
+				RequestID: ev.outcome.RequestID,
+				DSP:       r.DSPName,
+				LatencyMS: float64(r.Latency) / float64(time.Millisecond),
+				Response:  r.Response,
+			}
+			if r.Error != nil {
+				event.Error = r.Error.Error()
+			}
+			add(k.topics.Responses, func() ([]byte, error) { return k.codec.response(event) })
+		}
+	}
+	if k.topics.Outcomes != "" {
+		add(k.topics.Outcomes, func() ([]byte, error) {
+			return k.codec.outcome(NewRecord(ev.time, ev.outcome, ev.results))
+		})
+	}
+	return dst
+}
+
+// flush sends batch, counting unacknowledged messages as failed.
+func (k *Kafka) flush(batch []*kgo.Record) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), k.writeTimeout)
+	defer cancel()
+	n, err := k.p.Produce(ctx, batch)
+	if n > 0 {
+		k.failed.Add(uint64(n))
+	}
+	if err != nil {
+		log.Printf("kafka: publishing %d messages (%d failed): %v", len(batch), n, err)
+	}
+}
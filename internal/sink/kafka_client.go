@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl"
+)
+
+// KafkaConn says how to reach a Kafka cluster.
+type KafkaConn struct {
+	Brokers []string       // bootstrap brokers, host:port
+	TLS     *tls.Config    // nil connects in plain text
+	SASL    sasl.Mechanism // nil skips authentication
+}
+
+// kafkaClient publishes records with a franz-go client, which partitions
+// them by key hash as Kafka's Java client does, so events for one auction
+// land on the same partition of each topic, and retries retriable errors
+// such as leader changes until the caller's deadline.
+type kafkaClient struct {
+	client *kgo.Client
+}
+
+// dialKafka connects to the cluster and looks up topics, creating them if
+// the cluster allows it, so a bad address or topic fails at startup.
+func dialKafka(ctx context.Context, conn KafkaConn, topics []string, opts ...kgo.Opt) (*kafkaClient, error) {
+	opts = append([]kgo.Opt{
+		kgo.SeedBrokers(conn.Brokers...),
+		kgo.ClientID("rtb-simulator"),
+		kgo.AllowAutoTopicCreation(),
+	}, opts...)
+	if conn.TLS != nil {
+		opts = append(opts, kgo.DialTLSConfig(conn.TLS))
+	}
+	if conn.SASL != nil {
+		opts = append(opts, kgo.SASL(conn.SASL))
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := &kafkaClient{client: client}
+	if err := c.lookup(ctx, topics); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// lookup fetches the metadata of topics, reporting any that are missing.
+func (c *kafkaClient) lookup(ctx context.Context, topics []string) error {
+	req := kmsg.NewPtrMetadataRequest()
+	req.AllowAutoTopicCreation = true
+	for _, topic := range topics {
+		rt := kmsg.NewMetadataRequestTopic()
+		rt.Topic = kmsg.StringPtr(topic)
+		req.Topics = append(req.Topics, rt)
+	}
+	resp, err := req.RequestWith(ctx, c.client)
+	if err != nil {
+		return fmt.Errorf("fetching kafka metadata: %w", err)
+	}
+	var errs []error
+	for _, t := range resp.Topics {
+		if err := kerr.ErrorForCode(t.ErrorCode); err != nil && t.Topic != nil {
+			errs = append(errs, fmt.Errorf("topic %s: %w", *t.Topic, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Produce sends records and waits for them to be acknowledged, returning
+// how many were not.
+func (c *kafkaClient) Produce(ctx context.Context, records []*kgo.Record) (int, error) {
+	results := c.client.ProduceSync(ctx, records...)
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	return failed, results.FirstErr()
+}
+
+// Close closes the broker connections.
+func (c *kafkaClient) Close() error {
+	c.client.Close()
+	return nil
+}
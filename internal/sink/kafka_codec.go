@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Format is the encoding of Kafka message values.
+type Format string
+
+const (
+	// FormatJSON encodes events as JSON objects.
+	FormatJSON Format = "json"
+	// FormatAvro encodes events with Avro single-object encoding, whose
+	// header carries the fingerprint of the writer schema. The schemas
+	// are in the avro directory; OpenRTB objects are carried as JSON
+	// strings.
+	FormatAvro Format = "avro"
+)
+
+//go:embed avro/*.avsc
+var avroSchemas embed.FS
+
+// codec encodes each event type in one format.
+type codec struct {
+	contentType []byte
+	request     func(RequestEvent) ([]byte, error)
+	response    func(ResponseEvent) ([]byte, error)
+	outcome     func(Record) ([]byte, error)
+}
+
+func newCodec(format Format) (*codec, error) {
+	switch format {
+	case FormatJSON:
+		return &codec{
+			contentType: []byte("application/json"),
+			request:     func(ev RequestEvent) ([]byte, error) { return json.Marshal(ev) },
+			response:    func(ev ResponseEvent) ([]byte, error) { return json.Marshal(ev) },
+			outcome:     func(rec Record) ([]byte, error) { return json.Marshal(rec) },
+		}, nil
+	case FormatAvro:
+		return newAvroCodec()
+	}
+	return nil, fmt.Errorf("unknown kafka format %q (want %s or %s)", format, FormatJSON, FormatAvro)
+}
+
+func newAvroCodec() (*codec, error) {
+	schemas := make(map[string]*goavro.Codec)
+	for _, name := range []string{"request", "response", "outcome"} {
+		spec, err := avroSchemas.ReadFile("avro/" + name + ".avsc")
+		if err != nil {
+			return nil, err
+		}
+		c, err := goavro.NewCodec(string(spec))
+		if err != nil {
+			return nil, fmt.Errorf("avro schema %s: %w", name, err)
+		}
+		schemas[name] = c
+	}
+
+	return &codec{
+		contentType: []byte("avro/binary"),
+		request: func(ev RequestEvent) ([]byte, error) {
+			req, err := json.Marshal(ev.Request)
+			if err != nil {
+				return nil, err
+			}
+			return schemas["request"].SingleFromNative(nil, map[string]any{
+				"time":       ev.Time,
+				"request_id": ev.RequestID,
+				"request":    string(req),
+			})
+		},
+		response: func(ev ResponseEvent) ([]byte, error) {
+			native := map[string]any{
+				"time":       ev.Time,
+				"request_id": ev.RequestID,
+				"dsp":        ev.DSP,
+				"latency_ms": ev.LatencyMS,
+				"error":      nil,
+				"response":   nil,
+			}
+			if ev.Error != "" {
+				native["error"] = goavro.Union("string", ev.Error)
+			}
+			if ev.Response != nil {
+				resp, err := json.Marshal(ev.Response)
+				if err != nil {
+					return nil, err
+				}
+				native["response"] = goavro.Union("string", string(resp))
+			}
+			return schemas["response"].SingleFromNative(nil, native)
+		},
+		outcome: func(rec Record) ([]byte, error) {
+			latency := make(map[string]any, len(rec.DSPLatencyMS))
+			for dsp, ms := range rec.DSPLatencyMS {
+				latency[dsp] = ms
+			}
+			return schemas["outcome"].SingleFromNative(nil, map[string]any{
+				"time":           rec.Time,
+				"request_id":     rec.RequestID,
+				"winning_dsp":    rec.WinningDSP,
+				"clearing_price": rec.ClearingPrice,
+				"bid_floor":      rec.BidFloor,
+				"bids":           int32(rec.Bids),
+				"dsp_latency_ms": latency,
+			})
+		},
+	}, nil
+}
@@ -0,0 +1,407 @@
+package sink
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// fakeProducer records the messages it is given.
+type fakeProducer struct {
+	mu     sync.Mutex
+	msgs   []*kgo.Record
+	failed int
+	err    error
+	closed bool
+}
+
+func (f *fakeProducer) Produce(_ context.Context, msgs []*kgo.Record) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.msgs = append(f.msgs, msgs...)
+	return f.failed, f.err
+}
+
+func (f *fakeProducer) Close() error {
+	f.closed = true
+	return nil
+}
+
+func observeKafka(k *Kafka, id string) {
+	k.ObserveAuction(&openrtb.BidRequest{ID: id, Imp: []openrtb.Imp{{ID: "1", BidFloor: 0.5}}}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 12 * time.Millisecond, Response: &openrtb.BidResponse{ID: id}},
+		{DSPName: "dsp2", Latency: 80 * time.Millisecond, Error: errors.New("timeout")},
+		{DSPName: "dsp3", Skipped: dispatcher.SkipThrottled},
+	}, auction.Outcome{RequestID: id, WinningDSP: "dsp1", ClearingPrice: 1.5})
+}
+
+func TestKafka_JSON(t *testing.T) {
+	p := &fakeProducer{}
+	c, _ := newCodec(FormatJSON)
+	k := newKafka(p, KafkaTopics{Requests: "requests", Responses: "responses", Outcomes: "outcomes"}, c)
+	observeKafka(k, "req-1")
+	if err := k.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// One request, two responses (the skipped DSP is left out), one outcome
+	var topics []string
+	for _, m := range p.msgs {
+		topics = append(topics, m.Topic)
+		if string(m.Key) != "req-1" {
+			t.Errorf("%s key = %q, want req-1", m.Topic, m.Key)
+		}
+	}
+	if len(topics) != 4 || topics[0] != "requests" || topics[1] != "responses" || topics[2] != "responses" || topics[3] != "outcomes" {
+		t.Fatalf("topics = %v, want [requests responses responses outcomes]", topics)
+	}
+
+	var req RequestEvent
+	if err := json.Unmarshal(p.msgs[0].Value, &req); err != nil || req.Request == nil || req.Request.ID != "req-1" {
+		t.Errorf("request event = %s (%v)", p.msgs[0].Value, err)
+	}
+	var resp ResponseEvent
+	if err := json.Unmarshal(p.msgs[2].Value, &resp); err != nil || resp.DSP != "dsp2" || resp.Error != "timeout" || resp.LatencyMS != 80 {
+		t.Errorf("response event = %s (%v)", p.msgs[2].Value, err)
+	}
+	var rec Record
+	if err := json.Unmarshal(p.msgs[3].Value, &rec); err != nil || rec.WinningDSP != "dsp1" || rec.ClearingPrice != 1.5 {
+		t.Errorf("outcome event = %s (%v)", p.msgs[3].Value, err)
+	}
+	if !p.closed {
+		t.Error("producer not closed")
+	}
+}
+
+func TestKafka_Avro(t *testing.T) {
+	p := &fakeProducer{}
+	c, err := newCodec(FormatAvro)
+	if err != nil {
+		t.Fatalf("newCodec() error = %v", err)
+	}
+	k := newKafka(p, KafkaTopics{Responses: "responses", Outcomes: "outcomes"}, c)
+	observeKafka(k, "req-1")
+	_ = k.Close()
+
+	if len(p.msgs) != 3 {
+		t.Fatalf("got %d messages, want 3", len(p.msgs))
+	}
+	decode := func(schema string, value []byte) map[string]any {
+		t.Helper()
+		spec, _ := avroSchemas.ReadFile("avro/" + schema + ".avsc")
+		codec, _ := goavro.NewCodec(string(spec))
+		native, _, err := codec.NativeFromSingle(value)
+		if err != nil {
+			t.Fatalf("decoding %s: %v", schema, err)
+		}
+		return native.(map[string]any)
+	}
+
+	resp := decode("response", p.msgs[0].Value)
+	union, _ := resp["response"].(map[string]any)
+	body, _ := union["string"].(string)
+	if resp["dsp"] != "dsp1" || resp["error"] != nil || !strings.Contains(body, `"id":"req-1"`) {
+		t.Errorf("response = %v", resp)
+	}
+	if errResp := decode("response", p.msgs[1].Value); errResp["error"].(map[string]any)["string"] != "timeout" {
+		t.Errorf("error response = %v", errResp)
+	}
+	outcome := decode("outcome", p.msgs[2].Value)
+	if outcome["request_id"] != "req-1" || outcome["winning_dsp"] != "dsp1" || outcome["dsp_latency_ms"].(map[string]any)["dsp1"] != 12.0 {
+		t.Errorf("outcome = %v", outcome)
+	}
+	if string(p.msgs[0].Headers[0].Value) != "avro/binary" {
+		t.Errorf("content-type = %q, want avro/binary", p.msgs[0].Headers[0].Value)
+	}
+}
+
+func TestKafka_Batching(t *testing.T) {
+	p := &fakeProducer{failed: 1, err: errors.New("not leader for partition")}
+	c, _ := newCodec(FormatJSON)
+	k := newKafka(p, KafkaTopics{Outcomes: "outcomes"}, c, WithBatchSize(2), WithFlushInterval(time.Hour))
+	for range 5 {
+		observeKafka(k, "req")
+	}
+	_ = k.Close()
+
+	if len(p.msgs) != 5 {
+		t.Errorf("published %d messages, want 5", len(p.msgs))
+	}
+	// Batches of 2, 2, and 1, each reporting one failure
+	if got := k.Failed(); got != 3 {
+		t.Errorf("Failed() = %d, want 3", got)
+	}
+}
+
+func TestNewCodec_UnknownFormat(t *testing.T) {
+	if _, err := newCodec("protobuf"); err == nil {
+		t.Error("newCodec(protobuf) error = nil")
+	}
+}
+
+// fakeBroker is a single-node Kafka cluster serving ApiVersions, Metadata,
+// and Produce requests for two-partition topics. Its first failures
+// Produce responses carry errCode.
+type fakeBroker struct {
+	t        *testing.T
+	ln       net.Listener
+	mu       sync.Mutex
+	produced map[string][][]byte // topic -> record values
+	errCode  int16
+	failures int
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{t: t, ln: ln, produced: make(map[string][][]byte)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		key := int16(binary.BigEndian.Uint16(msg[0:]))
+		version := int16(binary.BigEndian.Uint16(msg[2:]))
+		correlation := msg[4:8]
+		clientIDLen := int(binary.BigEndian.Uint16(msg[8:]))
+		body := msg[10+clientIDLen:]
+
+		var resp kmsg.Response
+		switch key {
+		case 18:
+			// Flexible versions add an empty tag buffer to the header
+			if version >= 3 {
+				body = body[1:]
+			}
+			resp = b.apiVersions(version, body)
+		case 3:
+			resp = b.metadata(version, body)
+		case 0:
+			resp = b.produce(version, body)
+		default:
+			b.t.Errorf("unexpected api key %d", key)
+			return
+		}
+		out := resp.AppendTo(append([]byte{0, 0, 0, 0}, correlation...))
+		binary.BigEndian.PutUint32(out, uint32(len(out)-4))
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+// apiVersions offers only the versions the fake broker decodes, so the
+// client sends nothing newer and skips idempotent producer setup.
+func (b *fakeBroker) apiVersions(version int16, body []byte) kmsg.Response {
+	req := kmsg.NewPtrApiVersionsRequest()
+	req.Version = version
+	if err := req.ReadFrom(body); err != nil {
+		b.t.Errorf("decoding api versions request: %v", err)
+	}
+	resp := kmsg.NewPtrApiVersionsResponse()
+	resp.Version = version
+	for _, v := range [][3]int16{{0, 3, 3}, {3, 4, 4}, {18, 0, 3}} {
+		key := kmsg.NewApiVersionsResponseApiKey()
+		key.ApiKey, key.MinVersion, key.MaxVersion = v[0], v[1], v[2]
+		resp.ApiKeys = append(resp.ApiKeys, key)
+	}
+	return resp
+}
+
+func (b *fakeBroker) metadata(version int16, body []byte) kmsg.Response {
+	req := kmsg.NewPtrMetadataRequest()
+	req.Version = version
+	if err := req.ReadFrom(body); err != nil {
+		b.t.Errorf("decoding metadata request: %v", err)
+	}
+	host, port, _ := net.SplitHostPort(b.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	resp := kmsg.NewPtrMetadataResponse()
+	resp.Version = version
+	resp.Brokers = []kmsg.MetadataResponseBroker{{NodeID: 1, Host: host, Port: int32(p)}}
+	for _, rt := range req.Topics {
+		topic := kmsg.NewMetadataResponseTopic()
+		topic.Topic = rt.Topic
+		for i := range int32(2) {
+			partition := kmsg.NewMetadataResponseTopicPartition()
+			partition.Partition = i
+			partition.Leader = 1
+			partition.Replicas = []int32{1}
+			partition.ISR = []int32{1}
+			topic.Partitions = append(topic.Partitions, partition)
+		}
+		resp.Topics = append(resp.Topics, topic)
+	}
+	return resp
+}
+
+func (b *fakeBroker) produce(version int16, body []byte) kmsg.Response {
+	req := kmsg.NewPtrProduceRequest()
+	req.Version = version
+	if err := req.ReadFrom(body); err != nil {
+		b.t.Errorf("decoding produce request: %v", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var errCode int16
+	if b.failures != 0 {
+		b.failures--
+		errCode = b.errCode
+	}
+	resp := kmsg.NewPtrProduceResponse()
+	resp.Version = version
+	for _, rt := range req.Topics {
+		topic := kmsg.NewProduceResponseTopic()
+		topic.Topic = rt.Topic
+		for _, rp := range rt.Partitions {
+			var batch kmsg.RecordBatch
+			if err := batch.ReadFrom(rp.Records); err != nil {
+				b.t.Errorf("decoding record batch: %v", err)
+			}
+			if crc := crc32.Checksum(rp.Records[21:], crc32.MakeTable(crc32.Castagnoli)); int32(crc) != batch.CRC {
+				b.t.Errorf("batch CRC = %x, want %x", uint32(batch.CRC), crc)
+			}
+			records := batch.Records
+			for range batch.NumRecords {
+				length, n := binary.Varint(records)
+				var rec kmsg.Record
+				if err := rec.ReadFrom(records[:n+int(length)]); err != nil {
+					b.t.Errorf("decoding record: %v", err)
+				}
+				if errCode == 0 {
+					b.produced[rt.Topic] = append(b.produced[rt.Topic], rec.Value)
+				}
+				records = records[n+int(length):]
+			}
+
+			partition := kmsg.NewProduceResponseTopicPartition()
+			partition.Partition = rp.Partition
+			partition.ErrorCode = errCode
+			topic.Partitions = append(topic.Partitions, partition)
+		}
+		resp.Topics = append(resp.Topics, topic)
+	}
+	return resp
+}
+
+// dialFakeBroker connects to b without compression, which the fake broker
+// cannot decode, refreshing metadata as often as errors ask for it.
+func dialFakeBroker(t *testing.T, ctx context.Context, brokers ...string) *kafkaClient {
+	t.Helper()
+	client, err := dialKafka(ctx, KafkaConn{Brokers: brokers}, []string{"outcomes"},
+		kgo.ProducerBatchCompression(kgo.NoCompression()), kgo.MetadataMinAge(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("dialKafka() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func outcomeRecords(n int) []*kgo.Record {
+	var records []*kgo.Record
+	for i := range n {
+		records = append(records, &kgo.Record{Topic: "outcomes", Key: []byte("req-" + strconv.Itoa(i)), Value: []byte(strconv.Itoa(i))})
+	}
+	return records
+}
+
+func TestKafkaClient_Produce(t *testing.T) {
+	b := newFakeBroker(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := dialFakeBroker(t, ctx, "127.0.0.1:1", b.ln.Addr().String())
+
+	// requests is not known yet and is looked up on first use
+	records := append(outcomeRecords(10), &kgo.Record{Topic: "requests", Key: []byte("req-0"), Value: []byte("r")})
+	if failed, err := client.Produce(ctx, records); failed != 0 || err != nil {
+		t.Fatalf("Produce() = %d, %v", failed, err)
+	}
+	b.mu.Lock()
+	got := len(b.produced["outcomes"])
+	gotRequests := len(b.produced["requests"])
+	b.mu.Unlock()
+	if got != 10 || gotRequests != 1 {
+		t.Errorf("broker received %d outcomes and %d requests, want 10 and 1", got, gotRequests)
+	}
+}
+
+func TestKafkaClient_RetriesLeaderChanges(t *testing.T) {
+	b := newFakeBroker(t)
+	b.errCode = kerr.NotLeaderForPartition.Code
+	b.failures = 2
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := dialFakeBroker(t, ctx, b.ln.Addr().String())
+
+	if failed, err := client.Produce(ctx, outcomeRecords(2)); failed != 0 || err != nil {
+		t.Fatalf("Produce() = %d, %v; want the records retried", failed, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if got := len(b.produced["outcomes"]); got != 2 {
+		t.Errorf("broker received %d outcomes, want 2", got)
+	}
+}
+
+func TestKafkaClient_PartitionErrors(t *testing.T) {
+	b := newFakeBroker(t)
+	b.errCode = kerr.MessageTooLarge.Code
+	b.failures = -1
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := dialFakeBroker(t, ctx, b.ln.Addr().String())
+
+	failed, err := client.Produce(ctx, outcomeRecords(2))
+	if failed != 2 || !errors.Is(err, kerr.MessageTooLarge) {
+		t.Errorf("Produce() = %d, %v; want 2 failed with MESSAGE_TOO_LARGE", failed, err)
+	}
+}
+
+func TestDialKafka_Unreachable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := dialKafka(ctx, KafkaConn{Brokers: []string{"127.0.0.1:1"}}, []string{"outcomes"}); err == nil {
+		t.Error("dialKafka() error = nil, want connection refused")
+	}
+}
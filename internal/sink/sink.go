@@ -0,0 +1,224 @@
+// Package sink batches auction outcomes and writes them to an external
+// database, for soak tests that run longer than in-memory stats can
+// usefully summarize. Writers are provided for ClickHouse and Postgres.
+// Kafka publishes requests, responses, and outcomes as event streams.
+package sink
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Record is one auction row.
+type Record struct {
+	Time          time.Time          `json:"time"`
+	RequestID     string             `json:"request_id"`
+	WinningDSP    string             `json:"winning_dsp"`
+	ClearingPrice float64            `json:"clearing_price"`
+	BidFloor      float64            `json:"bid_floor"`
+	Bids          int                `json:"bids"`
+	DSPLatencyMS  map[string]float64 `json:"dsp_latency_ms"` // DSPs called; skipped DSPs are omitted
+}
+
+// NewRecord builds the row for an auction completed at now.
+func NewRecord(now time.Time, outcome auction.Outcome, results []dispatcher.Result) Record {
+	rec := Record{
+		Time:          now,
+		RequestID:     outcome.RequestID,
+		WinningDSP:    outcome.WinningDSP,
+		ClearingPrice: outcome.ClearingPrice,
+		BidFloor:      outcome.BidFloor,
+		Bids:          len(outcome.AllBids),
+		DSPLatencyMS:  make(map[string]float64, len(results)),
+	}
+	for _, r := range results {
+		if r.Skipped != dispatcher.SkipNone {
+			continue
+		}
+		rec.DSPLatencyMS[r.DSPName] = float64(r.Latency) / float64(time.Millisecond)
+	}
+	return rec
+}
+
+// Writer stores batches of records.
+type Writer interface {
+	Write(ctx context.Context, batch []Record) error
+	Close() error
+}
+
+// tableName restricts table names to plain, optionally schema-qualified,
+// identifiers since they are interpolated into SQL.
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Sink queues auction records and writes them to a Writer in batches.
+// It implements engine.Observer. A full queue drops records rather than
+// slowing the simulation; see Dropped.
+type Sink struct {
+	w Writer
+	options
+
+	queue   chan Record
+	done    chan struct{}
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// options holds the batching settings and clock shared by Sink and Kafka.
+type options struct {
+	batchSize     int
+	flushInterval time.Duration
+	writeTimeout  time.Duration
+	queueSize     int
+	clock         clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		batchSize:     500,
+		flushInterval: time.Second,
+		writeTimeout:  10 * time.Second,
+		queueSize:     10000,
+		clock:         clock.Real,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Option configures a Sink or Kafka exporter.
+type Option func(*options)
+
+// WithBatchSize sets how many records are written at once.
+func WithBatchSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithFlushInterval sets the longest a record waits for its batch to
+// fill before being written.
+func WithFlushInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.flushInterval = d
+		}
+	}
+}
+
+// WithClock stamps auctions observed without a completion time by c
+// instead of the wall clock, so exports of a time-scaled run follow
+// simulated time.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+// WithQueueSize sets how many records may wait to be written before new
+// ones are dropped.
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// New creates a sink writing to w and starts its writer goroutine.
+func New(w Writer, opts ...Option) *Sink {
+	s := &Sink{
+		w:       w,
+		options: newOptions(opts),
+		done:    make(chan struct{}),
+	}
+
+	s.queue = make(chan Record, s.queueSize)
+	go s.run()
+	return s
+}
+
+// ObserveAuction queues the record of an auction completed now, by the
+// sink's clock. Never blocks.
+func (s *Sink) ObserveAuction(req *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	s.ObserveAuctionAt(s.clock.Now(), req, results, outcome)
+}
+
+// ObserveAuctionAt queues the record of an auction completed at t. Never
+// blocks.
+func (s *Sink) ObserveAuctionAt(t time.Time, _ *openrtb.BidRequest, results []dispatcher.Result, outcome auction.Outcome) {
+	select {
+	case s.queue <- NewRecord(t, outcome, results):
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (s *Sink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Failed returns the number of records lost to failed writes.
+func (s *Sink) Failed() uint64 {
+	return s.failed.Load()
+}
+
+// Close writes any queued records and closes the writer. The sink must
+// not be observing auctions any more.
+func (s *Sink) Close() error {
+	close(s.queue)
+	<-s.done
+	return s.w.Close()
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, s.batchSize)
+	for {
+		select {
+		case rec, ok := <-s.queue:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush writes batch, counting its records as failed if the write fails.
+func (s *Sink) flush(batch []Record) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.writeTimeout)
+	defer cancel()
+	if err := s.w.Write(ctx, batch); err != nil {
+		s.failed.Add(uint64(len(batch)))
+		log.Printf("sink: writing %d records: %v", len(batch), err)
+	}
+}
@@ -0,0 +1,206 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// fakeWriter records the batches it is given.
+type fakeWriter struct {
+	mu      sync.Mutex
+	batches [][]Record
+	err     error
+	closed  bool
+}
+
+func (f *fakeWriter) Write(_ context.Context, batch []Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]Record(nil), batch...))
+	return f.err
+}
+
+func (f *fakeWriter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeWriter) sizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var sizes []int
+	for _, b := range f.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func observe(s *Sink, id string) {
+	s.ObserveAuction(&openrtb.BidRequest{ID: id}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 12 * time.Millisecond},
+		{DSPName: "dsp2", Skipped: dispatcher.SkipThrottled},
+	}, auction.Outcome{RequestID: id, WinningDSP: "dsp1", ClearingPrice: 1.5, WinnerIndex: 0})
+}
+
+func TestNewRecord(t *testing.T) {
+	now := time.Now()
+	rec := NewRecord(now, auction.Outcome{
+		RequestID: "req-1", WinningDSP: "dsp1", ClearingPrice: 2, BidFloor: 0.5,
+		AllBids: []auction.BidWithDSP{{DSPName: "dsp1"}, {DSPName: "dsp3"}},
+	}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 12 * time.Millisecond},
+		{DSPName: "dsp2", Skipped: dispatcher.SkipCircuitOpen},
+		{DSPName: "dsp3", Latency: 1500 * time.Microsecond},
+	})
+
+	if rec.RequestID != "req-1" || rec.WinningDSP != "dsp1" || rec.ClearingPrice != 2 || rec.Bids != 2 {
+		t.Errorf("record = %+v", rec)
+	}
+	if len(rec.DSPLatencyMS) != 2 || rec.DSPLatencyMS["dsp1"] != 12 || rec.DSPLatencyMS["dsp3"] != 1.5 {
+		t.Errorf("DSPLatencyMS = %v, want dsp1: 12, dsp3: 1.5", rec.DSPLatencyMS)
+	}
+}
+
+func TestSink_Batching(t *testing.T) {
+	w := &fakeWriter{}
+	s := New(w, WithBatchSize(3), WithFlushInterval(time.Hour))
+
+	for i := 0; i < 7; i++ {
+		observe(s, "req")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Two full batches, then the remainder flushed on Close
+	if got := w.sizes(); len(got) != 3 || got[0] != 3 || got[1] != 3 || got[2] != 1 {
+		t.Errorf("batch sizes = %v, want [3 3 1]", got)
+	}
+	if !w.closed {
+		t.Error("writer not closed")
+	}
+}
+
+func TestSink_WithClock(t *testing.T) {
+	simulated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	w := &fakeWriter{}
+	s := New(w, WithClock(clock.NewManual(simulated)))
+
+	observe(s, "now")
+	completed := simulated.Add(-time.Second)
+	s.ObserveAuctionAt(completed, &openrtb.BidRequest{ID: "at"}, nil, auction.Outcome{RequestID: "at"})
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(w.batches) != 1 || len(w.batches[0]) != 2 {
+		t.Fatalf("batches = %v, want one of 2 records", w.sizes())
+	}
+	if got := w.batches[0]; !got[0].Time.Equal(simulated) || !got[1].Time.Equal(completed) {
+		t.Errorf("record times = %v, %v, want the clock's %v and the completion time %v", got[0].Time, got[1].Time, simulated, completed)
+	}
+}
+
+func TestSink_FlushInterval(t *testing.T) {
+	w := &fakeWriter{}
+	s := New(w, WithBatchSize(100), WithFlushInterval(10*time.Millisecond))
+	defer s.Close()
+
+	observe(s, "req")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(w.sizes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("partial batch not flushed after the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSink_FailedWrites(t *testing.T) {
+	w := &fakeWriter{err: errors.New("connection refused")}
+	s := New(w, WithBatchSize(2))
+
+	for i := 0; i < 4; i++ {
+		observe(s, "req")
+	}
+	_ = s.Close()
+
+	if got := s.Failed(); got != 4 {
+		t.Errorf("Failed() = %d, want 4", got)
+	}
+}
+
+func TestClickHouse_Write(t *testing.T) {
+	var query string
+	var rows []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Errorf("decoding row: %v", err)
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	ch, err := NewClickHouse(server.URL, "rtb.auctions")
+	if err != nil {
+		t.Fatalf("NewClickHouse() error = %v", err)
+	}
+	defer ch.Close()
+
+	batch := []Record{
+		{Time: time.Now(), RequestID: "req-1", WinningDSP: "dsp1", DSPLatencyMS: map[string]float64{"dsp1": 12}},
+		{Time: time.Now(), RequestID: "req-2", DSPLatencyMS: map[string]float64{}},
+	}
+	if err := ch.Write(context.Background(), batch); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if query != "INSERT INTO rtb.auctions FORMAT JSONEachRow" {
+		t.Errorf("query = %q", query)
+	}
+	if len(rows) != 2 || rows[0]["request_id"] != "req-1" || rows[0]["winning_dsp"] != "dsp1" {
+		t.Errorf("rows = %v, want req-1 and req-2", rows)
+	}
+}
+
+func TestClickHouse_WriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. DB::Exception: Table rtb.auctions does not exist", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	ch, _ := NewClickHouse(server.URL, "auctions")
+	if err := ch.Write(context.Background(), []Record{{RequestID: "req-1"}}); err == nil {
+		t.Error("Write() error = nil, want status error")
+	}
+}
+
+func TestInvalidTableName(t *testing.T) {
+	for _, table := range []string{"", "auctions; DROP TABLE x", "a.b.c", "1auctions"} {
+		if _, err := NewClickHouse("http://localhost:8123", table); err == nil {
+			t.Errorf("NewClickHouse(%q) error = nil, want invalid table name", table)
+		}
+		if _, err := OpenPostgres(context.Background(), "postgres://localhost/rtb", table); err == nil {
+			t.Errorf("OpenPostgres(%q) error = nil, want invalid table name", table)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// snapshotVersion is the snapshot file format version.
+const snapshotVersion = 1
+
+// snapshot is the on-disk form of a store.
+type snapshot struct {
+	Version int             `json:"version"`
+	Entries []snapshotEntry `json:"entries"`
+}
+
+type snapshotEntry struct {
+	Key     string     `json:"key"`
+	Value   []byte     `json:"value"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Open creates a store backed by the snapshot file at path, loading it if
+// it exists. Close saves the store back to path.
+func Open(path string, opts ...Option) (*Store, error) {
+	s := New(opts...)
+	if err := s.Load(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		_ = s.Close()
+		return nil, err
+	}
+	s.path = path
+	return s, nil
+}
+
+// Load adds the entries in the snapshot file at path to the store,
+// skipping any that have already expired.
+func (s *Store) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("state: parsing %s: %w", path, err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("state: %s: unsupported snapshot version %d", path, snap.Version)
+	}
+
+	now := s.now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, se := range snap.Entries {
+		e := entry{value: se.Value}
+		if se.Expires != nil {
+			e.expires = se.Expires.UnixNano()
+		}
+		if !e.expired(now) {
+			s.entries[se.Key] = e
+		}
+	}
+	return nil
+}
+
+// Save writes the store's live entries to a snapshot file at path. The
+// file is replaced atomically.
+func (s *Store) Save(path string) error {
+	now := s.now().UnixNano()
+	snap := snapshot{Version: snapshotVersion}
+
+	s.mu.RLock()
+	for key, e := range s.entries {
+		if e.expired(now) {
+			continue
+		}
+		se := snapshotEntry{Key: key, Value: e.value}
+		if e.expires != 0 {
+			t := time.Unix(0, e.expires).UTC()
+			se.Expires = &t
+		}
+		snap.Entries = append(snap.Entries, se)
+	}
+	s.mu.RUnlock()
+	sort.Slice(snap.Entries, func(i, j int) bool { return snap.Entries[i].Key < snap.Entries[j].Key })
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
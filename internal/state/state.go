@@ -0,0 +1,269 @@
+// Package state provides a small key-value store for simulation state that
+// outlives a single auction, such as user sessions, frequency caps, and
+// budgets. Entries can expire after a TTL. A store is in-memory by default;
+// one opened with Open is loaded from and saved back to a snapshot file.
+package state
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/clock"
+)
+
+// defaultSweepInterval is how often expired entries are evicted.
+const defaultSweepInterval = time.Minute
+
+// entry is a stored value. A zero expires never expires.
+type entry struct {
+	value   []byte
+	expires int64 // unix nanoseconds
+}
+
+func (e entry) expired(now int64) bool {
+	return e.expires != 0 && now >= e.expires
+}
+
+// Store is a concurrent key-value store with per-entry TTLs. Expired
+// entries are invisible to reads and evicted by a background sweep.
+// Safe for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+
+	path          string
+	sweepInterval time.Duration
+	now           func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Option configures the store.
+type Option func(*Store)
+
+// WithSweepInterval sets how often expired entries are evicted. A zero or
+// negative interval disables the background sweep; expired entries are
+// then only dropped by Sweep or when overwritten.
+func WithSweepInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.sweepInterval = d
+	}
+}
+
+// WithClock measures TTLs in c's simulated time, so entries such as
+// frequency caps expire on the simulation's schedule. The sweep interval
+// stays wall-clock.
+func WithClock(c clock.Clock) Option {
+	return withClock(c.Now)
+}
+
+// withClock overrides the time source for tests.
+func withClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// New creates an empty in-memory store.
+func New(opts ...Option) *Store {
+	s := &Store{
+		entries:       make(map[string]entry),
+		sweepInterval: defaultSweepInterval,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.sweepInterval > 0 {
+		go s.sweeper()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// Get returns the value stored under key.
+func (s *Store) Get(key string) ([]byte, bool) {
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+	if !ok || e.expired(s.now().UnixNano()) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key. A ttl of zero or less never expires.
+// The store keeps value; callers must not modify it afterwards.
+func (s *Store) Set(key string, value []byte, ttl time.Duration) {
+	now := s.now()
+	s.mu.Lock()
+	s.entries[key] = entry{value: value, expires: expiry(now, ttl)}
+	s.mu.Unlock()
+}
+
+// Delete removes key.
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+}
+
+// Add adds delta to the counter stored under key and returns the new
+// total. A missing or expired counter starts from zero with the given
+// ttl; an existing counter keeps its expiry, so a window opened by the
+// first Add closes at a fixed time.
+func (s *Store) Add(key string, delta float64, ttl time.Duration) float64 {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.expired(now.UnixNano()) || len(e.value) != 8 {
+		e = entry{expires: expiry(now, ttl)}
+	}
+	total := decodeCounter(e.value) + delta
+	e.value = encodeCounter(total)
+	s.entries[key] = e
+	return total
+}
+
+// Counter returns the counter stored under key, or zero.
+func (s *Store) Counter(key string) float64 {
+	v, ok := s.Get(key)
+	if !ok || len(v) != 8 {
+		return 0
+	}
+	return decodeCounter(v)
+}
+
+// Len returns the number of live entries.
+func (s *Store) Len() int {
+	now := s.now().UnixNano()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, e := range s.entries {
+		if !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Sweep evicts expired entries and returns how many were removed.
+func (s *Store) Sweep() int {
+	now := s.now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Namespace returns a view of the store whose keys are prefixed with
+// name, so modules sharing a store cannot collide.
+func (s *Store) Namespace(name string) *Namespace {
+	return &Namespace{store: s, prefix: name + "/"}
+}
+
+// Close stops the background sweep and, for a store opened with Open,
+// saves it to its snapshot file.
+func (s *Store) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	if s.path == "" {
+		return nil
+	}
+	return s.Save(s.path)
+}
+
+func (s *Store) sweeper() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Sweep()
+		}
+	}
+}
+
+// Namespace is a prefixed view of a Store.
+type Namespace struct {
+	store  *Store
+	prefix string
+}
+
+// Get returns the value stored under key.
+func (n *Namespace) Get(key string) ([]byte, bool) { return n.store.Get(n.prefix + key) }
+
+// Set stores value under key; see Store.Set.
+func (n *Namespace) Set(key string, value []byte, ttl time.Duration) {
+	n.store.Set(n.prefix+key, value, ttl)
+}
+
+// Delete removes key.
+func (n *Namespace) Delete(key string) { n.store.Delete(n.prefix + key) }
+
+// Add adds delta to the counter under key; see Store.Add.
+func (n *Namespace) Add(key string, delta float64, ttl time.Duration) float64 {
+	return n.store.Add(n.prefix+key, delta, ttl)
+}
+
+// Counter returns the counter stored under key, or zero.
+func (n *Namespace) Counter(key string) float64 { return n.store.Counter(n.prefix + key) }
+
+// Namespace returns a view of the namespace whose keys are further
+// prefixed with name.
+func (n *Namespace) Namespace(name string) *Namespace {
+	return &Namespace{store: n.store, prefix: n.prefix + name + "/"}
+}
+
+// Clear removes every key in the namespace.
+func (n *Namespace) Clear() {
+	s := n.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.entries {
+		if strings.HasPrefix(key, n.prefix) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func expiry(now time.Time, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return now.Add(ttl).UnixNano()
+}
+
+func encodeCounter(v float64) []byte {
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(v))
+}
+
+func decodeCounter(b []byte) float64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b))
+}
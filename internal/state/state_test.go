@@ -0,0 +1,161 @@
+package state
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestStore(t *testing.T) (*Store, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := New(WithSweepInterval(0), withClock(clock.Now))
+	t.Cleanup(func() { _ = s.Close() })
+	return s, clock
+}
+
+func TestStore_TTL(t *testing.T) {
+	s, clock := newTestStore(t)
+
+	s.Set("session", []byte("abc"), time.Minute)
+	s.Set("forever", []byte("x"), 0)
+
+	if v, ok := s.Get("session"); !ok || string(v) != "abc" {
+		t.Errorf("Get(session) = %q, %v, want abc, true", v, ok)
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := s.Get("session"); ok {
+		t.Error("Get(session) found an expired entry")
+	}
+	if _, ok := s.Get("forever"); !ok {
+		t.Error("Get(forever) = false, want an entry without TTL to persist")
+	}
+	if n := s.Len(); n != 1 {
+		t.Errorf("Len() = %d, want 1", n)
+	}
+	if n := s.Sweep(); n != 1 {
+		t.Errorf("Sweep() = %d, want 1", n)
+	}
+}
+
+func TestStore_Add(t *testing.T) {
+	s, clock := newTestStore(t)
+
+	s.Add("imps", 1, time.Hour)
+	clock.Advance(30 * time.Minute)
+	if got := s.Add("imps", 2, time.Hour); got != 3 {
+		t.Errorf("Add() = %v, want 3", got)
+	}
+
+	// The window opened by the first Add is not extended
+	clock.Advance(30 * time.Minute)
+	if got := s.Counter("imps"); got != 0 {
+		t.Errorf("Counter() after window = %v, want 0", got)
+	}
+	if got := s.Add("imps", 1.5, time.Hour); got != 1.5 {
+		t.Errorf("Add() in new window = %v, want 1.5", got)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	s, _ := newTestStore(t)
+	caps := s.Namespace("freqcap")
+	budget := s.Namespace("budget")
+
+	caps.Add("user1", 1, 0)
+	budget.Add("user1", 2.5, 0)
+	if caps.Counter("user1") != 1 || budget.Counter("user1") != 2.5 {
+		t.Errorf("counters = %v, %v, want namespaces kept apart", caps.Counter("user1"), budget.Counter("user1"))
+	}
+
+	caps.Clear()
+	if _, ok := caps.Get("user1"); ok {
+		t.Error("Get() after Clear() found an entry")
+	}
+	if budget.Counter("user1") != 2.5 {
+		t.Error("Clear() removed another namespace's entries")
+	}
+
+	seat := budget.Namespace("seat")
+	seat.Add("user1", 1, 0)
+	if seat.Counter("user1") != 1 || budget.Counter("user1") != 2.5 {
+		t.Errorf("counters = %v, %v, want a nested namespace kept apart", seat.Counter("user1"), budget.Counter("user1"))
+	}
+	budget.Clear()
+	if seat.Counter("user1") != 0 {
+		t.Error("Clear() kept a nested namespace's entries")
+	}
+}
+
+func TestStore_Sweeper(t *testing.T) {
+	s := New(WithSweepInterval(5 * time.Millisecond))
+	defer s.Close()
+
+	s.Set("short", []byte("x"), time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.entries)
+		s.mu.RUnlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired entry not evicted by the background sweep")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOpen_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := &fakeClock{now: time.Now()}
+
+	s, err := Open(path, WithSweepInterval(0), withClock(clock.Now))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	s.Set("user", []byte("profile"), 0)
+	s.Namespace("budget").Add("dsp1", 4.2, time.Hour)
+	s.Set("stale", []byte("x"), time.Second)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	clock.Advance(2 * time.Second)
+	s, err = Open(path, WithSweepInterval(0), withClock(clock.Now))
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer s.Close()
+
+	if v, ok := s.Get("user"); !ok || string(v) != "profile" {
+		t.Errorf("Get(user) = %q, %v, want profile, true", v, ok)
+	}
+	if got := s.Namespace("budget").Counter("dsp1"); got != 4.2 {
+		t.Errorf("budget counter = %v, want 4.2", got)
+	}
+	if _, ok := s.Get("stale"); ok {
+		t.Error("expired entry was restored")
+	}
+}
@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+)
+
+// armStatsInternal tracks one arm of a DSP's A/B split.
+type armStatsInternal struct {
+	requests uint64
+	bids     uint64 // responses carrying at least one bid
+	wins     uint64
+	errors   uint64
+	spend    float64
+	bidCount uint64
+	bidSum   float64
+	latency  Histogram
+}
+
+func (a *armStatsInternal) snapshot() ArmStats {
+	as := ArmStats{
+		Requests: a.requests,
+		Bids:     a.bids,
+		Wins:     a.wins,
+		Errors:   a.errors,
+		Spend:    a.spend,
+		Latency:  a.latency.Percentiles(),
+	}
+	if a.requests > 0 {
+		as.BidRate = float64(a.bids) / float64(a.requests)
+	}
+	if a.bids > 0 {
+		as.WinRate = float64(a.wins) / float64(a.bids)
+	}
+	if a.bidCount > 0 {
+		as.AvgBid = a.bidSum / float64(a.bidCount)
+	}
+	return as
+}
+
+// abStatsInternal tracks both arms of a DSP's A/B split.
+type abStatsInternal struct {
+	a, b armStatsInternal
+}
+
+func (ab *abStatsInternal) snapshot() *ABStats {
+	s := &ABStats{A: ab.a.snapshot(), B: ab.b.snapshot()}
+	s.BidRateDelta = s.B.BidRate - s.A.BidRate
+	s.WinRateDelta = s.B.WinRate - s.A.WinRate
+	s.AvgBidDelta = s.B.AvgBid - s.A.AvgBid
+	s.P50Delta = s.B.Latency.P50 - s.A.Latency.P50
+	s.P95Delta = s.B.Latency.P95 - s.A.Latency.P95
+	s.ErrorRateDelta = errorRate(s.B) - errorRate(s.A)
+	return s
+}
+
+func errorRate(a ArmStats) float64 {
+	if a.Requests == 0 {
+		return 0
+	}
+	return float64(a.Errors) / float64(a.Requests)
+}
+
+// ArmStats summarizes the traffic served by one arm of an A/B split.
+type ArmStats struct {
+	Requests uint64
+	Bids     uint64 // responses carrying at least one bid
+	Wins     uint64
+	Errors   uint64
+	Spend    float64 // clearing prices of this arm's wins
+	BidRate  float64 // Bids / Requests
+	WinRate  float64 // Wins / Bids
+	AvgBid   float64 // mean bid price
+	Latency  LatencyPercentiles
+}
+
+// ABStats compares the two arms of a DSP's A/B split. Deltas are B minus
+// A, so a positive BidRateDelta means the B build bids more often.
+type ABStats struct {
+	A, B ArmStats
+
+	BidRateDelta   float64
+	WinRateDelta   float64
+	ErrorRateDelta float64
+	AvgBidDelta    float64
+	P50Delta       time.Duration
+	P95Delta       time.Duration
+}
+
+// arm returns the stats for a request's A/B arm, or nil for DSPs without
+// a split.
+func (d *dspStatsInternal) arm(arm dispatcher.Arm) *armStatsInternal {
+	switch arm {
+	case dispatcher.ArmA, dispatcher.ArmB:
+		if d.ab == nil {
+			d.ab = &abStatsInternal{}
+		}
+		if arm == dispatcher.ArmB {
+			return &d.ab.b
+		}
+		return &d.ab.a
+	}
+	return nil
+}
+
+// record adds one request served by the arm. Each DSP is called at most
+// once per auction, so a win by the DSP belongs to this arm.
+func (a *armStatsInternal) record(r dispatcher.Result, outcome auction.Outcome) {
+	a.requests++
+	a.latency.Record(r.Latency)
+	if r.Error != nil {
+		a.errors++
+		return
+	}
+	if r.Response == nil {
+		return
+	}
+	bids := r.Response.AllBids()
+	if len(bids) == 0 {
+		return
+	}
+	a.bids++
+	for _, b := range bids {
+		a.bidCount++
+		a.bidSum += b.Price
+	}
+	if outcome.Winner != nil && outcome.WinningDSP == r.DSPName {
+		a.wins++
+		a.spend += outcome.ClearingPrice
+	}
+}
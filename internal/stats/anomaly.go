@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// Anomaly kinds.
+const (
+	AnomalyWinRateDrop  = "win_rate_drop"
+	AnomalyLatencySpike = "latency_spike"
+)
+
+// minAnomalySamples is the number of trailing seconds with traffic needed
+// before a second is judged, so a run's first seconds raise nothing.
+const minAnomalySamples = 10
+
+// minAnomalyDeviation is the smallest standard deviation, as a share of
+// the trailing mean, that seconds are judged against, so noise is not
+// flagged after a near-constant stretch.
+const minAnomalyDeviation = 0.05
+
+// Anomaly is a second whose win rate fell, or whose mean DSP latency rose,
+// further from the trailing window's mean than the configured number of
+// standard deviations. See WithAnomalyDetection.
+type Anomaly struct {
+	Time   time.Time // start of the second
+	Kind   string
+	Value  float64 // the second's win rate, or mean latency in milliseconds
+	Mean   float64 // the trailing window's mean of Value
+	StdDev float64 // the trailing window's standard deviation, at least 5% of Mean
+}
+
+// anomalyDetector judges each second of the time series as it ends.
+type anomalyDetector struct {
+	window int // trailing seconds compared against
+	sigma  float64
+	notify func(Anomaly)
+}
+
+// WithAnomalyDetection flags seconds whose win rate dropped, or whose mean
+// DSP latency spiked, more than sigma standard deviations from the seconds
+// with traffic in the trailing window, which is capped at the time series
+// window. Anomalies are marked on TimeSeries points and passed to notify,
+// if non-nil, once the second ends; notify is called with the collector
+// locked and must not block. A sigma of 0 disables detection.
+func WithAnomalyDetection(window time.Duration, sigma float64, notify func(Anomaly)) Option {
+	return func(c *Collector) {
+		if sigma > 0 && window >= time.Second {
+			c.anomalies = &anomalyDetector{window: int(window / time.Second), sigma: sigma, notify: notify}
+		}
+	}
+}
+
+// check judges sec, which just ended, against the seconds before it and
+// records any anomalies on its slot.
+func (d *anomalyDetector) check(ts *timeSeries, sec int64) {
+	cur := ts.slot(sec)
+	if cur == nil {
+		return
+	}
+
+	var winRates, latencies []float64
+	window := min(d.window, len(ts.seconds)-1)
+	for t := sec - int64(window); t < sec; t++ {
+		s := ts.slot(t)
+		if s == nil {
+			continue
+		}
+		if s.requests > 0 {
+			winRates = append(winRates, s.winRate())
+		}
+		if s.responses > 0 {
+			latencies = append(latencies, s.meanLatencyMS())
+		}
+	}
+
+	if cur.requests > 0 && len(winRates) >= minAnomalySamples {
+		mean, sd := meanStdDev(winRates)
+		if v := cur.winRate(); v < mean-d.sigma*sd {
+			d.flag(cur, Anomaly{Kind: AnomalyWinRateDrop, Value: v, Mean: mean, StdDev: sd})
+		}
+	}
+	if cur.responses > 0 && len(latencies) >= minAnomalySamples {
+		mean, sd := meanStdDev(latencies)
+		if v := cur.meanLatencyMS(); v > mean+d.sigma*sd {
+			d.flag(cur, Anomaly{Kind: AnomalyLatencySpike, Value: v, Mean: mean, StdDev: sd})
+		}
+	}
+}
+
+// flag records a on s and passes it on.
+func (d *anomalyDetector) flag(s *secondStats, a Anomaly) {
+	a.Time = time.Unix(s.second, 0).UTC()
+	s.anomalies = append(s.anomalies, a)
+	if d.notify != nil {
+		d.notify(a)
+	}
+}
+
+func (s *secondStats) winRate() float64 {
+	return float64(s.wins) / float64(s.requests)
+}
+
+func (s *secondStats) meanLatencyMS() float64 {
+	return float64(s.totalLatency) / float64(s.responses) / float64(time.Millisecond)
+}
+
+// meanStdDev returns the mean and population standard deviation of xs,
+// the latter no smaller than minAnomalyDeviation of the mean.
+func meanStdDev(xs []float64) (mean, sd float64) {
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	var sq float64
+	for _, x := range xs {
+		sq += (x - mean) * (x - mean)
+	}
+	sd = math.Sqrt(sq / float64(len(xs)))
+	return mean, max(sd, mean*minAnomalyDeviation)
+}
@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_AnomalyDetection(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	var got []Anomaly
+	c := New(WithClock(clk), WithAnomalyDetection(30*time.Second, 3, func(a Anomaly) {
+		got = append(got, a)
+	}))
+
+	win := auction.Outcome{Winner: &openrtb.Bid{Price: 2}, WinningDSP: "dsp", ClearingPrice: 1}
+	fast := []dispatcher.Result{{DSPName: "dsp", Latency: 20 * time.Millisecond}}
+	slow := []dispatcher.Result{{DSPName: "dsp", Latency: 200 * time.Millisecond}}
+	// second records n auctions, wins of them won, then moves to the next
+	second := func(n, wins int, results []dispatcher.Result) {
+		for i := range n {
+			outcome := auction.Outcome{}
+			if i < wins {
+				outcome = win
+			}
+			c.RecordAuction(outcome, results)
+		}
+		clk.Advance(time.Second)
+	}
+
+	// Steady traffic, win rates around a half
+	for i := range 20 {
+		second(10, 4+i%3, fast)
+	}
+	if len(got) != 0 {
+		t.Fatalf("anomalies during steady traffic: %+v", got)
+	}
+
+	second(10, 0, slow)
+	second(10, 5, fast) // ends the anomalous second
+	if len(got) != 2 {
+		t.Fatalf("anomalies = %+v, want a win rate drop and a latency spike", got)
+	}
+	drop, spike := got[0], got[1]
+	at := start.Add(20 * time.Second)
+	if drop.Kind != AnomalyWinRateDrop || drop.Value != 0 || !drop.Time.Equal(at) {
+		t.Errorf("drop = %+v, want a 0 win rate at %v", drop, at)
+	}
+	if spike.Kind != AnomalyLatencySpike || spike.Value != 200 || spike.Mean != 20 {
+		t.Errorf("spike = %+v, want 200ms against a 20ms mean", spike)
+	}
+
+	// The anomalous second is marked in the time series
+	points := c.TimeSeries(3 * time.Second)
+	if n := len(points[0].Anomalies); n != 2 || !points[0].Time.Equal(at) {
+		t.Errorf("points[0] = %+v, want both anomalies at %v", points[0], at)
+	}
+	if n := len(points[1].Anomalies); n != 0 {
+		t.Errorf("points[1].Anomalies = %d, want none", n)
+	}
+}
+
+func TestCollector_AnomalyDetection_NeedsHistory(t *testing.T) {
+	clk := clock.NewManual(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	var got []Anomaly
+	c := New(WithClock(clk), WithAnomalyDetection(time.Minute, 2, func(a Anomaly) {
+		got = append(got, a)
+	}))
+
+	win := auction.Outcome{Winner: &openrtb.Bid{Price: 2}, WinningDSP: "dsp", ClearingPrice: 1}
+	for range minAnomalySamples - 1 {
+		c.RecordAuction(win, nil)
+		clk.Advance(time.Second)
+	}
+	c.RecordAuction(auction.Outcome{}, nil)
+	clk.Advance(time.Second)
+	c.RecordAuction(win, nil)
+	if len(got) != 0 {
+		t.Errorf("anomalies with %d seconds of history: %+v", minAnomalySamples-1, got)
+	}
+}
@@ -0,0 +1,40 @@
+package stats
+
+import "fmt"
+
+// reconcile cross-checks aggregate counters against their per-DSP
+// breakdowns and returns a description of each mismatch found.
+func (s Snapshot) reconcile() []string {
+	var drift []string
+
+	var bids, wins, errs uint64
+	for name, dsp := range s.DSPStats {
+		bids += dsp.Bids
+		wins += dsp.Wins
+		errs += dsp.Errors
+
+		if dsp.Wins > dsp.Bids {
+			drift = append(drift, fmt.Sprintf("dsp %s: wins (%d) exceed bids (%d)", name, dsp.Wins, dsp.Bids))
+		}
+		if dsp.NoBids+dsp.Errors > dsp.Requests {
+			drift = append(drift, fmt.Sprintf("dsp %s: no-bids (%d) + errors (%d) exceed requests (%d)",
+				name, dsp.NoBids, dsp.Errors, dsp.Requests))
+		}
+	}
+
+	if bids != s.TotalBids {
+		drift = append(drift, fmt.Sprintf("total bids (%d) != sum of DSP bids (%d)", s.TotalBids, bids))
+	}
+	if wins != s.TotalWins {
+		drift = append(drift, fmt.Sprintf("total wins (%d) != sum of DSP wins (%d)", s.TotalWins, wins))
+	}
+	if errs != s.TotalErrors {
+		drift = append(drift, fmt.Sprintf("total errors (%d) != sum of DSP errors (%d)", s.TotalErrors, errs))
+	}
+	if s.TotalWins+s.TotalNoBids != s.TotalRequests {
+		drift = append(drift, fmt.Sprintf("wins (%d) + no-bids (%d) != requests (%d)",
+			s.TotalWins, s.TotalNoBids, s.TotalRequests))
+	}
+
+	return drift
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_ConsistencyChecks_Clean(t *testing.T) {
+	c := New(WithConsistencyChecks(true))
+
+	outcome := auction.Outcome{
+		RequestID:     "req-1",
+		Winner:        &openrtb.Bid{ID: "bid-1", Price: 2.5},
+		WinningDSP:    "dsp1",
+		ClearingPrice: 2.5,
+		AllBids: []auction.BidWithDSP{
+			{Bid: openrtb.Bid{ID: "bid-1", Price: 2.5}, DSPName: "dsp1"},
+		},
+	}
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 10 * time.Millisecond},
+		{DSPName: "dsp2", Error: testError{}},
+	}
+	c.RecordAuction(outcome, results)
+	c.RecordAuction(auction.Outcome{RequestID: "req-2"}, results[1:])
+
+	if drift := c.Snapshot().Drift; len(drift) != 0 {
+		t.Errorf("Drift = %v, want none", drift)
+	}
+}
+
+func TestCollector_ConsistencyChecks_Disabled(t *testing.T) {
+	c := New()
+
+	// Winner without a matching bid would drift, but checks are off
+	c.RecordAuction(auction.Outcome{
+		RequestID:  "req-1",
+		Winner:     &openrtb.Bid{ID: "bid-1", Price: 1},
+		WinningDSP: "dsp1",
+	}, nil)
+
+	if drift := c.Snapshot().Drift; drift != nil {
+		t.Errorf("Drift = %v, want nil when checks disabled", drift)
+	}
+}
+
+func TestSnapshot_Reconcile(t *testing.T) {
+	snap := Snapshot{
+		TotalRequests: 10,
+		TotalBids:     5,
+		TotalWins:     3,
+		TotalNoBids:   6,
+		TotalErrors:   1,
+		DSPStats: map[string]DSPStats{
+			"dsp1": {Requests: 10, Bids: 2, Wins: 3, NoBids: 2},
+		},
+	}
+
+	drift := snap.reconcile()
+
+	wantSubstrings := []string{
+		"dsp dsp1: wins (3) exceed bids (2)",
+		"total bids (5) != sum of DSP bids (2)",
+		"total errors (1) != sum of DSP errors (0)",
+		"wins (3) + no-bids (6) != requests (10)",
+	}
+	joined := strings.Join(drift, "\n")
+	for _, want := range wantSubstrings {
+		if !strings.Contains(joined, want) {
+			t.Errorf("drift missing %q; got:\n%s", want, joined)
+		}
+	}
+	if len(drift) != len(wantSubstrings) {
+		t.Errorf("len(drift) = %d, want %d", len(drift), len(wantSubstrings))
+	}
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// Top-K tables of creative categories and advertiser domains list the
+// topK most frequent values per DSP, counting up to maxTrackedKeys
+// distinct values; values first seen after that are not counted.
+const (
+	topK           = 10
+	maxTrackedKeys = 1000
+)
+
+// sizeHistogram records creative markup sizes in bytes using the same
+// log-linear layout as Histogram. Not safe for concurrent use.
+type sizeHistogram struct {
+	counts [numBuckets]uint64
+	count  uint64
+	sum    uint64
+	max    int
+}
+
+// Record adds a single size sample.
+func (h *sizeHistogram) Record(bytes int) {
+	h.counts[bucketIndex(uint64(bytes))]++
+	h.count++
+	h.sum += uint64(bytes)
+	if bytes > h.max {
+		h.max = bytes
+	}
+}
+
+// Quantile returns the approximate size at quantile q (0 < q <= 1),
+// capped at the observed maximum. Returns 0 if the histogram is empty.
+func (h *sizeHistogram) Quantile(q float64) int {
+	if h.count == 0 {
+		return 0
+	}
+	return min(int(bucketUpperBound(quantileBucket(&h.counts, h.count, q))), h.max)
+}
+
+// topCounter counts occurrences of up to maxTrackedKeys distinct values.
+// Not safe for concurrent use.
+type topCounter struct {
+	counts map[string]uint64
+}
+
+func (t *topCounter) add(key string) {
+	if t.counts == nil {
+		t.counts = make(map[string]uint64)
+	}
+	if _, ok := t.counts[key]; !ok && len(t.counts) >= maxTrackedKeys {
+		return
+	}
+	t.counts[key]++
+}
+
+// top returns the k most frequent values, most frequent first, ties in
+// name order. Returns nil if nothing was counted.
+func (t *topCounter) top(k int) []KeyCount {
+	if len(t.counts) == 0 {
+		return nil
+	}
+	out := make([]KeyCount, 0, len(t.counts))
+	for key, n := range t.counts {
+		out = append(out, KeyCount{Key: key, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out[:min(k, len(out))]
+}
+
+// creativeStatsInternal tracks creative QA for a single DSP.
+type creativeStatsInternal struct {
+	sizes        sizeHistogram
+	oversized    uint64
+	bodyTooLarge uint64
+	categories   topCounter
+	adomains     topCounter
+}
+
+// recordBid counts the bid's categories and advertiser domains.
+func (c *creativeStatsInternal) recordBid(b *openrtb.Bid) {
+	for _, cat := range b.Cat {
+		c.categories.add(cat)
+	}
+	for _, d := range b.ADomain {
+		c.adomains.add(d)
+	}
+}
+
+// record adds one creative's markup size, flagging it against limit
+// (0 disables the check).
+func (c *creativeStatsInternal) record(bytes, limit int) {
+	c.sizes.Record(bytes)
+	if limit > 0 && bytes > limit {
+		c.oversized++
+	}
+}
+
+func (c *creativeStatsInternal) snapshot() CreativeStats {
+	cs := CreativeStats{
+		Creatives:    c.sizes.count,
+		P50Bytes:     c.sizes.Quantile(0.50),
+		P95Bytes:     c.sizes.Quantile(0.95),
+		MaxBytes:     c.sizes.max,
+		Oversized:    c.oversized,
+		BodyTooLarge: c.bodyTooLarge,
+		Categories:   c.categories.top(topK),
+		ADomains:     c.adomains.top(topK),
+	}
+	if c.sizes.count > 0 {
+		cs.AvgBytes = float64(c.sizes.sum) / float64(c.sizes.count)
+	}
+	return cs
+}
+
+// CreativeStats summarizes the size of a DSP's creative markup (AdM) and
+// what its bids advertise.
+type CreativeStats struct {
+	Creatives    uint64  // bids with markup
+	AvgBytes     float64 // mean AdM size
+	P50Bytes     int
+	P95Bytes     int
+	MaxBytes     int
+	Oversized    uint64 // creatives larger than the configured AdM size limit
+	BodyTooLarge uint64 // responses rejected for exceeding the client body size cap, also counted in Errors
+
+	// Categories (bid.cat) and ADomains (bid.adomain) are the most
+	// frequent values across the DSP's bids, most frequent first.
+	Categories []KeyCount
+	ADomains   []KeyCount
+}
+
+// KeyCount is one row of a top-K table.
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
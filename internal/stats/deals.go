@@ -0,0 +1,81 @@
+package stats
+
+import "github.com/cass/rtb-simulator/internal/auction"
+
+// dealStatsInternal tracks bids on a single PMP deal, or a DSP's bids on
+// every deal.
+type dealStatsInternal struct {
+	bids    uint64
+	wins    uint64
+	revenue float64
+}
+
+func (d *dealStatsInternal) snapshot() DealStats {
+	ds := DealStats{Bids: d.bids, Wins: d.wins, Revenue: d.revenue}
+	if d.bids > 0 {
+		ds.WinRate = float64(d.wins) / float64(d.bids)
+	}
+	return ds
+}
+
+// recordDeals records the deal bids and open-market bids preempted by a
+// deal in outcome. Must be called with mu held.
+func (c *Collector) recordDeals(outcome auction.Outcome) {
+	for _, b := range outcome.AllBids {
+		if b.Bid.DealID == "" {
+			continue
+		}
+		c.deal(b.Bid.DealID).bids++
+		c.getOrCreateDSP(b.DSPName).deals.bids++
+	}
+	for _, b := range outcome.Preempted {
+		c.getOrCreateDSP(b.DSPName).preempted++
+	}
+
+	if outcome.Winner == nil || outcome.Winner.DealID == "" {
+		return
+	}
+	for _, d := range []*dealStatsInternal{
+		c.deal(outcome.Winner.DealID),
+		&c.getOrCreateDSP(outcome.WinningDSP).deals,
+	} {
+		d.wins++
+		d.revenue += outcome.ClearingPrice
+	}
+}
+
+// deal returns the stats of the deal with id, creating them if needed.
+// Must be called with mu held.
+func (c *Collector) deal(id string) *dealStatsInternal {
+	d, ok := c.deals[id]
+	if !ok {
+		if c.deals == nil {
+			c.deals = make(map[string]*dealStatsInternal)
+		}
+		d = &dealStatsInternal{}
+		c.deals[id] = d
+	}
+	return d
+}
+
+// dealsSnapshot returns per-deal stats, or nil if no deal was bid on.
+// Must be called with mu held.
+func (c *Collector) dealsSnapshot() map[string]DealStats {
+	if len(c.deals) == 0 {
+		return nil
+	}
+	deals := make(map[string]DealStats, len(c.deals))
+	for id, d := range c.deals {
+		deals[id] = d.snapshot()
+	}
+	return deals
+}
+
+// DealStats summarizes bidding on private marketplace deals. Only bids
+// that met their deal's floor and terms are counted.
+type DealStats struct {
+	Bids    uint64
+	Wins    uint64
+	WinRate float64 // Wins / Bids
+	Revenue float64 // clearing prices of deal wins
+}
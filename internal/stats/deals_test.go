@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_Deals(t *testing.T) {
+	c := New(WithConsistencyChecks(true))
+
+	// dsp1 wins deal gold over dsp2's silver deal bid; dsp3's open bid is
+	// preempted.
+	bids := []auction.BidWithDSP{
+		{DSPName: "dsp1", Bid: openrtb.Bid{Price: 5, DealID: "gold"}},
+		{DSPName: "dsp2", Bid: openrtb.Bid{Price: 3, DealID: "silver"}},
+	}
+	c.RecordAuction(auction.Outcome{
+		Winner: &bids[0].Bid, WinningDSP: "dsp1", ClearingPrice: 4, AllBids: bids,
+		Preempted: []auction.BidWithDSP{{DSPName: "dsp3", Bid: openrtb.Bid{Price: 9}}},
+	}, nil)
+
+	// An open-market win does not count toward deals.
+	open := []auction.BidWithDSP{{DSPName: "dsp2", Bid: openrtb.Bid{Price: 2}}}
+	c.RecordAuction(auction.Outcome{Winner: &open[0].Bid, WinningDSP: "dsp2", ClearingPrice: 2, AllBids: open}, nil)
+
+	snap := c.Snapshot()
+	if len(snap.Drift) > 0 {
+		t.Errorf("Drift = %v", snap.Drift)
+	}
+	if snap.TotalBids != 4 {
+		t.Errorf("TotalBids = %d, want 4", snap.TotalBids)
+	}
+	if got := snap.Deals["gold"]; got != (DealStats{Bids: 1, Wins: 1, WinRate: 1, Revenue: 4}) {
+		t.Errorf("Deals[gold] = %+v", got)
+	}
+	if got := snap.Deals["silver"]; got != (DealStats{Bids: 1}) {
+		t.Errorf("Deals[silver] = %+v", got)
+	}
+
+	dsp2 := snap.DSPStats["dsp2"]
+	if dsp2.Bids != 2 || dsp2.Deals.Bids != 1 || dsp2.Deals.Wins != 0 {
+		t.Errorf("dsp2 bids = %d, deals = %+v, want 2 bids with 1 on a deal", dsp2.Bids, dsp2.Deals)
+	}
+	if dsp3 := snap.DSPStats["dsp3"]; dsp3.Bids != 1 || dsp3.Preempted != 1 {
+		t.Errorf("dsp3 bids = %d, preempted = %d, want 1, 1", dsp3.Bids, dsp3.Preempted)
+	}
+
+	c.Reset()
+	if deals := c.Snapshot().Deals; deals != nil {
+		t.Errorf("Deals after Reset = %v, want nil", deals)
+	}
+}
@@ -0,0 +1,58 @@
+package stats
+
+import "time"
+
+// defaultRecentErrors is the number of error samples retained per DSP.
+const defaultRecentErrors = 50
+
+// ErrorSample is a single recorded DSP error.
+type ErrorSample struct {
+	Time       time.Time
+	StatusCode int // HTTP status, or 0 for transport-level errors
+	Message    string
+}
+
+// errorRing retains the most recent error samples in a fixed-size buffer.
+type errorRing struct {
+	samples []ErrorSample
+	next    int
+	full    bool
+}
+
+func (r *errorRing) add(s ErrorSample, capacity int) {
+	if capacity <= 0 {
+		return
+	}
+	if r.samples == nil {
+		r.samples = make([]ErrorSample, capacity)
+	}
+	r.samples[r.next] = s
+	r.next++
+	if r.next == len(r.samples) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// recent returns up to limit samples, newest first. A limit <= 0 returns
+// all retained samples.
+func (r *errorRing) recent(limit int) []ErrorSample {
+	n := r.next
+	if r.full {
+		n = len(r.samples)
+	}
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	out := make([]ErrorSample, n)
+	idx := r.next
+	for i := range out {
+		idx--
+		if idx < 0 {
+			idx = len(r.samples) - 1
+		}
+		out[i] = r.samples[idx]
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+)
+
+func TestErrorRing_Recent(t *testing.T) {
+	var r errorRing
+	for i := 0; i < 5; i++ {
+		r.add(ErrorSample{Message: fmt.Sprint(i)}, 3)
+	}
+
+	got := r.recent(0)
+	if len(got) != 3 {
+		t.Fatalf("len(recent) = %d, want 3", len(got))
+	}
+	for i, want := range []string{"4", "3", "2"} {
+		if got[i].Message != want {
+			t.Errorf("recent[%d] = %q, want %q", i, got[i].Message, want)
+		}
+	}
+
+	if got := r.recent(1); len(got) != 1 || got[0].Message != "4" {
+		t.Errorf("recent(1) = %v, want [4]", got)
+	}
+}
+
+func TestErrorRing_Empty(t *testing.T) {
+	var r errorRing
+	if got := r.recent(10); len(got) != 0 {
+		t.Errorf("len(recent) = %d, want 0", len(got))
+	}
+}
+
+func TestCollector_WithClock(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := New(WithClock(clock.NewManual(now)))
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: errors.New("connection refused")},
+	})
+	c.RecordCapHit("dsp1", 10, 10)
+
+	samples, _ := c.RecentErrors("dsp1", 0)
+	if len(samples) != 1 || !samples[0].Time.Equal(now) {
+		t.Errorf("error samples = %+v, want one at %v", samples, now)
+	}
+	if hits := c.Snapshot().CapHits; len(hits) != 1 || !hits[0].Time.Equal(now) {
+		t.Errorf("cap hits = %+v, want one at %v", hits, now)
+	}
+}
+
+func TestCollector_RecentErrors(t *testing.T) {
+	c := New()
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: &httpclient.StatusError{StatusCode: 503}},
+		{DSPName: "dsp2"},
+	})
+	c.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: errors.New("connection refused")},
+	})
+
+	samples, ok := c.RecentErrors("dsp1", 0)
+	if !ok {
+		t.Fatal("RecentErrors(dsp1) not found")
+	}
+	if len(samples) != 2 {
+		t.Fatalf("len(samples) = %d, want 2", len(samples))
+	}
+	if samples[0].Message != "connection refused" || samples[0].StatusCode != 0 {
+		t.Errorf("samples[0] = %+v, want transport error", samples[0])
+	}
+	if samples[1].StatusCode != 503 {
+		t.Errorf("samples[1].StatusCode = %d, want 503", samples[1].StatusCode)
+	}
+	if samples[0].Time.IsZero() {
+		t.Error("samples[0].Time is zero")
+	}
+
+	if samples, ok := c.RecentErrors("dsp2", 0); !ok || len(samples) != 0 {
+		t.Errorf("RecentErrors(dsp2) = %v, %v, want empty, true", samples, ok)
+	}
+	if _, ok := c.RecentErrors("unknown", 0); ok {
+		t.Error("RecentErrors(unknown) found, want not found")
+	}
+}
+
+func TestCollector_RecentErrorsDisabled(t *testing.T) {
+	c := New(WithRecentErrors(0))
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: errors.New("boom")},
+	})
+
+	if samples, _ := c.RecentErrors("dsp1", 0); len(samples) != 0 {
+		t.Errorf("len(samples) = %d, want 0", len(samples))
+	}
+	if got := c.Snapshot().DSPStats["dsp1"].Errors; got != 1 {
+		t.Errorf("Errors = %d, want 1", got)
+	}
+}
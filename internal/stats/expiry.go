@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+)
+
+// WithRenderDelay simulates d passing between each auction and the
+// rendering of its impression. Bids whose exp gives the impression less
+// time are counted as short, and winning ones as expired before render.
+// Zero disables the check; bids declaring exp are counted regardless.
+func WithRenderDelay(d time.Duration) Option {
+	return func(c *Collector) {
+		c.renderDelay = d
+	}
+}
+
+// expiryStatsInternal tracks bid expiry (Bid.exp) against the render
+// delay, for one DSP or all of them.
+type expiryStatsInternal struct {
+	withExp     uint64
+	short       uint64
+	expiredWins uint64
+}
+
+func (e expiryStatsInternal) snapshot() ExpiryStats {
+	return ExpiryStats{BidsWithExp: e.withExp, ShortBids: e.short, ExpiredWins: e.expiredWins}
+}
+
+// ExpiryStats summarizes how long bids stay valid (Bid.exp) against the
+// simulated render delay. A win whose bid expired before its impression
+// rendered would go unpaid, or be served after the buyer stopped
+// expecting it.
+type ExpiryStats struct {
+	BidsWithExp uint64 // bids declaring an exp
+	ShortBids   uint64 // bids whose exp ends before the impression renders
+	ExpiredWins uint64 // winning short bids: expired before render
+}
+
+// recordExpiry records the expiry of the bids in outcome. Must be called
+// with mu held.
+func (c *Collector) recordExpiry(outcome auction.Outcome) {
+	for i := range outcome.AllBids {
+		b := &outcome.AllBids[i]
+		if b.Bid.Exp <= 0 {
+			continue
+		}
+		dsp := &c.getOrCreateDSP(b.DSPName).expiry
+		dsp.withExp++
+		c.expiry.withExp++
+		if !c.expiresBeforeRender(b.Bid.Exp) {
+			continue
+		}
+		dsp.short++
+		c.expiry.short++
+		if i == outcome.WinnerIndex && outcome.Winner != nil {
+			dsp.expiredWins++
+			c.expiry.expiredWins++
+		}
+	}
+}
+
+// expiresBeforeRender reports whether a bid valid for exp seconds expires
+// before the render delay passes.
+func (c *Collector) expiresBeforeRender(exp int) bool {
+	return c.renderDelay > 0 && time.Duration(exp)*time.Second < c.renderDelay
+}
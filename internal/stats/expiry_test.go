@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_Expiry(t *testing.T) {
+	c := New(WithRenderDelay(2*time.Second), WithConsistencyChecks(true))
+
+	// dsp1 wins with a bid that expires after 1s, before the 2s render;
+	// dsp2's 5s bid and dsp3's bid without exp lose.
+	bids := []auction.BidWithDSP{
+		{DSPName: "dsp1", Bid: openrtb.Bid{Price: 5, Exp: 1}},
+		{DSPName: "dsp2", Bid: openrtb.Bid{Price: 3, Exp: 5}},
+		{DSPName: "dsp3", Bid: openrtb.Bid{Price: 2}},
+	}
+	c.RecordAuction(auction.Outcome{Winner: &bids[0].Bid, WinnerIndex: 0, WinningDSP: "dsp1", ClearingPrice: 5, AllBids: bids}, nil)
+
+	// dsp2 loses with a short bid: short, but not an expired win
+	lost := []auction.BidWithDSP{
+		{DSPName: "dsp1", Bid: openrtb.Bid{Price: 4, Exp: 30}},
+		{DSPName: "dsp2", Bid: openrtb.Bid{Price: 1, Exp: 1}},
+	}
+	c.RecordAuction(auction.Outcome{Winner: &lost[0].Bid, WinnerIndex: 0, WinningDSP: "dsp1", ClearingPrice: 4, AllBids: lost}, nil)
+
+	snap := c.Snapshot()
+	if len(snap.Drift) > 0 {
+		t.Errorf("Drift = %v", snap.Drift)
+	}
+	if want := (ExpiryStats{BidsWithExp: 4, ShortBids: 2, ExpiredWins: 1}); snap.Expiry != want {
+		t.Errorf("Expiry = %+v, want %+v", snap.Expiry, want)
+	}
+	for dsp, want := range map[string]ExpiryStats{
+		"dsp1": {BidsWithExp: 2, ShortBids: 1, ExpiredWins: 1},
+		"dsp2": {BidsWithExp: 2, ShortBids: 1},
+		"dsp3": {},
+	} {
+		if got := snap.DSPStats[dsp].Expiry; got != want {
+			t.Errorf("%s Expiry = %+v, want %+v", dsp, got, want)
+		}
+	}
+
+	c.Reset()
+	if got := c.Snapshot().Expiry; got != (ExpiryStats{}) {
+		t.Errorf("Expiry after Reset = %+v, want zero", got)
+	}
+}
+
+func TestCollector_ExpiryWithoutRenderDelay(t *testing.T) {
+	c := New()
+	bids := []auction.BidWithDSP{{DSPName: "dsp1", Bid: openrtb.Bid{Price: 5, Exp: 1}}}
+	c.RecordAuction(auction.Outcome{Winner: &bids[0].Bid, WinnerIndex: 0, WinningDSP: "dsp1", ClearingPrice: 5, AllBids: bids}, nil)
+
+	if got := c.Snapshot().Expiry; got != (ExpiryStats{BidsWithExp: 1}) {
+		t.Errorf("Expiry = %+v, want only the bid with exp counted", got)
+	}
+}
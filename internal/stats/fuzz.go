@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"maps"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/fuzz"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+)
+
+// fuzzStatsInternal counts a DSP's answers to requests malformed with one
+// class of mutation.
+type fuzzStatsInternal struct {
+	requests    uint64
+	bids        uint64
+	noBids      uint64
+	errors      uint64
+	timeouts    uint64
+	statusCodes map[int]uint64
+}
+
+// recordFuzz counts r, a call that sent a malformed request, under its
+// mutation class. Must be called with mu held.
+func (d *dspStatsInternal) recordFuzz(r dispatcher.Result) {
+	if d.fuzz == nil {
+		d.fuzz = make(map[fuzz.Class]*fuzzStatsInternal)
+	}
+	fs := d.fuzz[r.Mutation]
+	if fs == nil {
+		fs = &fuzzStatsInternal{}
+		d.fuzz[r.Mutation] = fs
+	}
+
+	fs.requests++
+	switch {
+	case r.Error != nil:
+		fs.errors++
+		if isTimeout(r.Error) {
+			fs.timeouts++
+		}
+		if code, ok := httpclient.StatusCode(r.Error); ok {
+			if fs.statusCodes == nil {
+				fs.statusCodes = make(map[int]uint64)
+			}
+			fs.statusCodes[code]++
+		}
+	case r.Response == nil || r.Response.IsNoBid():
+		fs.noBids++
+	default:
+		fs.bids++
+	}
+}
+
+// fuzzSnapshot returns the DSP's fuzzing stats keyed by mutation class,
+// or nil if it was sent no malformed requests.
+func (d *dspStatsInternal) fuzzSnapshot() map[string]FuzzStats {
+	if len(d.fuzz) == 0 {
+		return nil
+	}
+	out := make(map[string]FuzzStats, len(d.fuzz))
+	for class, fs := range d.fuzz {
+		out[string(class)] = FuzzStats{
+			Requests:    fs.requests,
+			Bids:        fs.bids,
+			NoBids:      fs.noBids,
+			Errors:      fs.errors,
+			Timeouts:    fs.timeouts,
+			StatusCodes: maps.Clone(fs.statusCodes),
+		}
+	}
+	return out
+}
+
+// FuzzStats shows how a DSP answered requests malformed with one class
+// of mutation. A robust bidder rejects them with a 4xx status or no-bids;
+// bids, 5xx statuses, and timeouts suggest it choked on or ignored the
+// malformed field.
+type FuzzStats struct {
+	Requests    uint64
+	Bids        uint64         // responses carrying bids
+	NoBids      uint64         // no-bid responses, including 204s
+	Errors      uint64         // failed calls, including the statuses below
+	Timeouts    uint64         // errors that were timeouts
+	StatusCodes map[int]uint64 // HTTP error statuses, keyed by code
+}
@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/fuzz"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_Fuzz(t *testing.T) {
+	c := New(WithConsistencyChecks(true))
+
+	bid := &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", ImpID: "1", Price: 1}}}}}
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{
+		{DSPName: "dsp", Mutation: fuzz.MissingField, Error: &httpclient.StatusError{StatusCode: 400}},
+		{DSPName: "other", Mutation: fuzz.MissingField, Response: &openrtb.BidResponse{}},
+	})
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{
+		{DSPName: "dsp", Mutation: fuzz.MissingField, Error: &httpclient.StatusError{StatusCode: 400}},
+	})
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{
+		{DSPName: "dsp", Mutation: fuzz.HugeString, Error: &httpclient.StatusError{StatusCode: 500}},
+	})
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{
+		{DSPName: "dsp", Mutation: fuzz.WrongType, Response: bid},
+	})
+	// Well-formed requests are not broken down
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{{DSPName: "dsp", Response: bid}})
+
+	snap := c.Snapshot()
+	want := map[string]FuzzStats{
+		"missing_field": {Requests: 2, Errors: 2, StatusCodes: map[int]uint64{400: 2}},
+		"huge_string":   {Requests: 1, Errors: 1, StatusCodes: map[int]uint64{500: 1}},
+		"wrong_type":    {Requests: 1, Bids: 1},
+	}
+	if got := snap.DSPStats["dsp"].Fuzz; !reflect.DeepEqual(got, want) {
+		t.Errorf("dsp Fuzz = %+v, want %+v", got, want)
+	}
+	if got := snap.DSPStats["other"].Fuzz["missing_field"]; got.Requests != 1 || got.NoBids != 1 {
+		t.Errorf("other Fuzz[missing_field] = %+v, want one no-bid", got)
+	}
+	if snap.DSPStats["dsp"].Requests != 5 {
+		t.Errorf("dsp Requests = %d, want fuzzed requests counted too", snap.DSPStats["dsp"].Requests)
+	}
+
+	c.Reset()
+	if got := c.Snapshot().DSPStats["dsp"].Fuzz; got != nil {
+		t.Errorf("Fuzz after Reset = %v, want nil", got)
+	}
+}
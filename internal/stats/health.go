@@ -0,0 +1,45 @@
+package stats
+
+// healthStatsInternal tracks health checks of a single DSP.
+type healthStatsInternal struct {
+	checks  uint64
+	failed  uint64
+	outages uint64
+	healthy bool
+}
+
+// RecordHealthCheck records a health check of a DSP and whether the DSP
+// is healthy after it.
+func (c *Collector) RecordHealthCheck(dspName string, passed, healthy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dsp := c.getOrCreateDSP(dspName)
+	if dsp.health == nil {
+		dsp.health = &healthStatsInternal{healthy: true}
+	}
+	h := dsp.health
+	h.checks++
+	if !passed {
+		h.failed++
+	}
+	if h.healthy && !healthy {
+		h.outages++
+	}
+	h.healthy = healthy
+}
+
+// HealthStats summarizes a DSP's health checks.
+type HealthStats struct {
+	Checks  uint64
+	Failed  uint64
+	Outages uint64 // times the DSP turned unhealthy
+	Healthy bool   // as of the last check
+}
+
+func (h *healthStatsInternal) snapshot() *HealthStats {
+	if h == nil {
+		return nil
+	}
+	return &HealthStats{Checks: h.checks, Failed: h.failed, Outages: h.outages, Healthy: h.healthy}
+}
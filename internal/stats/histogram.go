@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Histogram layout: values (in microseconds) below subBucketCount map 1:1 to
+// buckets; larger values use log2 magnitude plus subBucketBits of mantissa,
+// giving ~6% relative precision across the full uint64 range.
+const (
+	subBucketBits  = 4
+	subBucketCount = 1 << subBucketBits
+	numBuckets     = (64 - subBucketBits + 1) * subBucketCount
+)
+
+// Histogram is a fixed-size log-linear latency histogram.
+// It records durations without retaining individual samples, so memory use
+// is constant regardless of sample count. Not safe for concurrent use.
+type Histogram struct {
+	counts [numBuckets]uint64
+	count  uint64
+	max    time.Duration
+}
+
+// Record adds a single duration sample to the histogram.
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[bucketIndex(uint64(d/time.Microsecond))]++
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Count returns the number of recorded samples.
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Max returns the largest recorded sample.
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Quantile returns the approximate value at quantile q (0 < q <= 1).
+// The result is the upper bound of the bucket containing the q-th sample,
+// capped at the observed maximum. Returns 0 if the histogram is empty.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	v := time.Duration(bucketUpperBound(quantileBucket(&h.counts, h.count, q))) * time.Microsecond
+	if v > h.max {
+		v = h.max
+	}
+	return v
+}
+
+// Percentiles summarizes the histogram into the standard reporting quantiles.
+func (h *Histogram) Percentiles() LatencyPercentiles {
+	return LatencyPercentiles{
+		P50: h.Quantile(0.50),
+		P90: h.Quantile(0.90),
+		P95: h.Quantile(0.95),
+		P99: h.Quantile(0.99),
+		Max: h.max,
+	}
+}
+
+// Reset clears all recorded samples.
+func (h *Histogram) Reset() {
+	*h = Histogram{}
+}
+
+// quantileBucket returns the index of the bucket holding the q-th of count
+// samples. count must be positive.
+func quantileBucket(counts *[numBuckets]uint64, count uint64, q float64) int {
+	rank := uint64(math.Ceil(q * float64(count)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var cumulative uint64
+	for i, c := range counts {
+		cumulative += c
+		if cumulative >= rank {
+			return i
+		}
+	}
+	return numBuckets - 1
+}
+
+// bucketIndex maps a value to its bucket.
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return (shift+1)*subBucketCount + int(v>>uint(shift)) - subBucketCount
+}
+
+// bucketUpperBound returns the largest value that maps to bucket i.
+func bucketUpperBound(i int) uint64 {
+	if i < subBucketCount {
+		return uint64(i)
+	}
+	shift := uint(i/subBucketCount - 1)
+	mantissa := uint64(i%subBucketCount + subBucketCount)
+	return (mantissa+1)<<shift - 1
+}
+
+// LatencyPercentiles holds summary quantiles of a latency distribution.
+type LatencyPercentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram_Empty(t *testing.T) {
+	var h Histogram
+
+	if h.Count() != 0 {
+		t.Errorf("Count() = %d, want 0", h.Count())
+	}
+	if q := h.Quantile(0.99); q != 0 {
+		t.Errorf("Quantile(0.99) = %v, want 0", q)
+	}
+}
+
+func TestHistogram_Quantiles(t *testing.T) {
+	var h Histogram
+
+	// 1ms..100ms in 1ms steps
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.50, 50 * time.Millisecond},
+		{0.90, 90 * time.Millisecond},
+		{0.99, 99 * time.Millisecond},
+		{1.00, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		got := h.Quantile(tt.q)
+		// Buckets have ~6% relative precision
+		tolerance := tt.want / 15
+		if got < tt.want-tolerance || got > tt.want+tolerance {
+			t.Errorf("Quantile(%v) = %v, want ~%v", tt.q, got, tt.want)
+		}
+	}
+
+	if h.Max() != 100*time.Millisecond {
+		t.Errorf("Max() = %v, want 100ms", h.Max())
+	}
+}
+
+func TestHistogram_QuantileCappedAtMax(t *testing.T) {
+	var h Histogram
+	h.Record(1234 * time.Microsecond)
+
+	if got := h.Quantile(0.5); got != 1234*time.Microsecond {
+		t.Errorf("Quantile(0.5) = %v, want 1.234ms", got)
+	}
+}
+
+func TestHistogram_BucketBounds(t *testing.T) {
+	for _, v := range []uint64{0, 1, 15, 16, 31, 32, 1000, 123456, 1 << 40} {
+		idx := bucketIndex(v)
+		if upper := bucketUpperBound(idx); upper < v {
+			t.Errorf("bucketUpperBound(bucketIndex(%d)) = %d, want >= %d", v, upper, v)
+		}
+		if idx > 0 && bucketUpperBound(idx-1) >= v {
+			t.Errorf("value %d also fits in bucket %d", v, idx-1)
+		}
+	}
+}
+
+func TestHistogram_Reset(t *testing.T) {
+	var h Histogram
+	h.Record(time.Millisecond)
+	h.Reset()
+
+	if h.Count() != 0 || h.Max() != 0 {
+		t.Errorf("after Reset: Count() = %d, Max() = %v, want 0, 0", h.Count(), h.Max())
+	}
+}
@@ -0,0 +1,40 @@
+package stats
+
+import "github.com/cass/rtb-simulator/internal/dispatcher"
+
+// queueStatsInternal tracks calls that found all of a DSP's in-flight
+// slots taken.
+type queueStatsInternal struct {
+	queued    uint64
+	saturated uint64
+	wait      Histogram
+}
+
+// record notes a call that waited for a slot or was skipped for lack of
+// one. Calls that got a slot straight away are not recorded.
+func (q *queueStatsInternal) record(r dispatcher.Result) {
+	if r.Skipped == dispatcher.SkipSaturated {
+		q.saturated++
+	}
+	if r.QueueWait > 0 {
+		q.queued++
+		q.wait.Record(r.QueueWait)
+	}
+}
+
+func (q *queueStatsInternal) snapshot() QueueStats {
+	return QueueStats{
+		Queued:    q.queued,
+		Saturated: q.saturated,
+		Wait:      q.wait.Percentiles(),
+	}
+}
+
+// QueueStats summarizes how often a DSP's in-flight cap held calls back.
+// A rising Saturated count means the bidder cannot keep up with the
+// request rate at its current latency.
+type QueueStats struct {
+	Queued    uint64             // calls that waited for a slot, sent or not
+	Saturated uint64             // calls skipped without a slot, also counted in Skipped
+	Wait      LatencyPercentiles // time queued calls spent waiting
+}
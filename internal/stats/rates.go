@@ -0,0 +1,48 @@
+package stats
+
+// Rates are ratios of a snapshot's counters, so consumers need not derive
+// them; each is zero while its denominator is. DSP requests are the calls
+// sent to DSPs, up to one per DSP per auction.
+type Rates struct {
+	BidRate     float64 // bids per DSP request
+	WinRate     float64 // wins per auction in Snapshot, per request sent in DSPStats
+	TimeoutRate float64 // timeouts per DSP request
+
+	// ECPM is the revenue of a thousand won impressions, in the base
+	// currency. Revenue sums CPM clearing prices, so this is also the
+	// mean clearing price.
+	ECPM float64
+}
+
+// snapshotRates derives the run's rates from snap's totals and DSP stats.
+func snapshotRates(snap *Snapshot) Rates {
+	var calls, bids, timeouts uint64
+	for _, d := range snap.DSPStats {
+		calls += d.Requests
+		bids += d.Bids
+		timeouts += d.Timeouts
+	}
+	return Rates{
+		BidRate:     ratio(float64(bids), calls),
+		WinRate:     ratio(float64(snap.TotalWins), snap.TotalRequests),
+		TimeoutRate: ratio(float64(timeouts), calls),
+		ECPM:        ratio(snap.TotalRevenue, snap.TotalWins),
+	}
+}
+
+// rates derives the DSP's rates from its counters.
+func (d DSPStats) rates() Rates {
+	return Rates{
+		BidRate:     ratio(float64(d.Bids), d.Requests),
+		WinRate:     ratio(float64(d.Wins), d.Requests),
+		TimeoutRate: ratio(float64(d.Timeouts), d.Requests),
+		ECPM:        ratio(d.Spend, d.Wins),
+	}
+}
+
+func ratio(n float64, of uint64) float64 {
+	if of == 0 {
+		return 0
+	}
+	return n / float64(of)
+}
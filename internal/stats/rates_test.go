@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_Rates(t *testing.T) {
+	c := New(WithConsistencyChecks(true))
+
+	bid := func(price float64) *openrtb.BidResponse {
+		return &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", ImpID: "1", Price: price}}}}}
+	}
+	winner := &openrtb.Bid{ID: "b", Price: 3}
+	c.RecordAuction(auction.Outcome{
+		Winner: winner, WinningDSP: "dsp1", ClearingPrice: 3, WinnerIndex: 0,
+		AllBids: []auction.BidWithDSP{{Bid: *winner, DSPName: "dsp1"}},
+	}, []dispatcher.Result{
+		{DSPName: "dsp1", Response: bid(3)},
+		{DSPName: "dsp2", Error: context.DeadlineExceeded},
+	})
+	c.RecordAuction(auction.Outcome{
+		Winner: winner, WinningDSP: "dsp1", ClearingPrice: 1, WinnerIndex: 0,
+		AllBids: []auction.BidWithDSP{{Bid: *winner, DSPName: "dsp1"}},
+	}, []dispatcher.Result{
+		{DSPName: "dsp1", Response: bid(1)},
+		{DSPName: "dsp2", Response: &openrtb.BidResponse{}},
+	})
+	c.RecordAuction(auction.Outcome{WinnerIndex: -1}, []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{}},
+		{DSPName: "dsp2", Error: errors.New("connection refused")},
+	})
+	c.RecordAuction(auction.Outcome{WinnerIndex: -1}, []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{}},
+		{DSPName: "dsp2", Response: &openrtb.BidResponse{}},
+	})
+
+	snap := c.Snapshot()
+	want := Rates{BidRate: 0.25, WinRate: 0.5, TimeoutRate: 0.125, ECPM: 2}
+	if snap.Rates != want {
+		t.Errorf("Rates = %+v, want %+v", snap.Rates, want)
+	}
+	want = Rates{BidRate: 0.5, WinRate: 0.5, ECPM: 2}
+	if got := snap.DSPStats["dsp1"].Rates; got != want {
+		t.Errorf("dsp1 Rates = %+v, want %+v", got, want)
+	}
+	want = Rates{TimeoutRate: 0.25}
+	if got := snap.DSPStats["dsp2"].Rates; got != want {
+		t.Errorf("dsp2 Rates = %+v, want %+v", got, want)
+	}
+
+	if got := New().Snapshot().Rates; got != (Rates{}) {
+		t.Errorf("empty Rates = %+v, want zeros", got)
+	}
+}
@@ -0,0 +1,39 @@
+package stats
+
+import "github.com/cass/rtb-simulator/internal/dispatcher"
+
+// retryStatsInternal tracks calls retried after a transient failure.
+type retryStatsInternal struct {
+	retried   uint64
+	retries   uint64
+	recovered uint64
+}
+
+// record notes a call that was sent. Calls that were not retried are not
+// recorded.
+func (rs *retryStatsInternal) record(r dispatcher.Result) {
+	if r.Retries == 0 {
+		return
+	}
+	rs.retried++
+	rs.retries += uint64(r.Retries)
+	if r.Error == nil {
+		rs.recovered++
+	}
+}
+
+func (rs *retryStatsInternal) snapshot() RetryStats {
+	return RetryStats{
+		Retried:   rs.retried,
+		Retries:   rs.retries,
+		Recovered: rs.recovered,
+	}
+}
+
+// RetryStats summarizes retries of a DSP's calls. Recovered calls would
+// have been errors without retries; the rest still count in Errors.
+type RetryStats struct {
+	Retried   uint64 // calls retried at least once
+	Retries   uint64 // retry attempts across those calls
+	Recovered uint64 // retried calls that finally succeeded
+}
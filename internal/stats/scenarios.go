@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+)
+
+// scenarioStatsInternal tracks the auctions of requests built by one
+// scenario of a mix.
+type scenarioStatsInternal struct {
+	requests uint64
+	bids     uint64
+	wins     uint64
+	errors   uint64
+	revenue  float64
+}
+
+func (s *scenarioStatsInternal) snapshot() ScenarioStats {
+	ss := ScenarioStats{
+		Requests: s.requests,
+		Bids:     s.bids,
+		Wins:     s.wins,
+		Errors:   s.errors,
+		Revenue:  s.revenue,
+	}
+	if s.requests > 0 {
+		ss.WinRate = float64(s.wins) / float64(s.requests)
+	}
+	if s.wins > 0 {
+		ss.ECPM = s.revenue / float64(s.wins)
+	}
+	return ss
+}
+
+// recordScenario attributes an auction to the scenario that generated its
+// request, if the run mixes scenarios. Must be called with mu held.
+func (c *Collector) recordScenario(outcome auction.Outcome, results []dispatcher.Result) {
+	if outcome.Scenario == "" {
+		return
+	}
+	s, ok := c.scenarios[outcome.Scenario]
+	if !ok {
+		if c.scenarios == nil {
+			c.scenarios = make(map[string]*scenarioStatsInternal)
+		}
+		s = &scenarioStatsInternal{}
+		c.scenarios[outcome.Scenario] = s
+	}
+
+	s.requests++
+	s.bids += uint64(len(outcome.AllBids) + len(outcome.Preempted))
+	if outcome.Winner != nil {
+		s.wins++
+		s.revenue += outcome.ClearingPrice
+	}
+	for _, r := range results {
+		if r.Error != nil && r.Skipped == dispatcher.SkipNone {
+			s.errors++
+		}
+	}
+}
+
+// scenariosSnapshot returns per-scenario stats, or nil if the run does not
+// mix scenarios. Must be called with mu held.
+func (c *Collector) scenariosSnapshot() map[string]ScenarioStats {
+	if len(c.scenarios) == 0 {
+		return nil
+	}
+	scenarios := make(map[string]ScenarioStats, len(c.scenarios))
+	for name, s := range c.scenarios {
+		scenarios[name] = s.snapshot()
+	}
+	return scenarios
+}
+
+// ScenarioStats summarizes the auctions of requests built by one scenario
+// of a mix.
+type ScenarioStats struct {
+	Requests uint64
+	Bids     uint64
+	Wins     uint64
+	Errors   uint64  // failed DSP calls
+	WinRate  float64 // Wins / Requests
+	Revenue  float64
+	ECPM     float64 // Revenue / Wins
+}
@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_Scenarios(t *testing.T) {
+	c := New()
+
+	bids := []auction.BidWithDSP{{DSPName: "dsp1", Bid: openrtb.Bid{Price: 3}}}
+	c.RecordAuction(auction.Outcome{
+		Scenario: "mobile_app", Winner: &bids[0].Bid, WinningDSP: "dsp1", ClearingPrice: 2, AllBids: bids,
+	}, []dispatcher.Result{{DSPName: "dsp1"}})
+	c.RecordAuction(auction.Outcome{Scenario: "mobile_app"}, []dispatcher.Result{{DSPName: "dsp1"}})
+	c.RecordAuction(auction.Outcome{Scenario: "video"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: errors.New("timeout")},
+		{DSPName: "dsp2", Skipped: dispatcher.SkipThrottled, Error: errors.New("skipped")},
+	})
+
+	snap := c.Snapshot()
+	want := ScenarioStats{Requests: 2, Bids: 1, Wins: 1, WinRate: 0.5, Revenue: 2, ECPM: 2}
+	if got := snap.Scenarios["mobile_app"]; got != want {
+		t.Errorf("Scenarios[mobile_app] = %+v, want %+v", got, want)
+	}
+	if got := snap.Scenarios["video"]; got != (ScenarioStats{Requests: 1, Errors: 1}) {
+		t.Errorf("Scenarios[video] = %+v, want 1 request with 1 error", got)
+	}
+
+	c.Reset()
+	c.RecordAuction(auction.Outcome{}, nil)
+	if snap := c.Snapshot(); snap.Scenarios != nil {
+		t.Errorf("Scenarios without a mix = %v, want nil", snap.Scenarios)
+	}
+}
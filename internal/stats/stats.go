@@ -3,11 +3,17 @@
 package stats
 
 import (
+	"errors"
+	"log"
+	"maps"
 	"sync"
 	"time"
 
 	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
 	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/fuzz"
+	"github.com/cass/rtb-simulator/internal/httpclient"
 )
 
 // Collector aggregates auction statistics in a thread-safe manner.
@@ -22,23 +28,178 @@ type Collector struct {
 	totalRevenue  float64
 
 	dspStats map[string]*dspStatsInternal
+
+	latency         Histogram
+	auctionDuration Histogram
+	budgetBuckets   [budgetBucketCount]uint64
+
+	// Yield accumulators: first-to-second price gaps over contested
+	// auctions, and the clearing price distribution over won auctions.
+	contestedAuctions uint64
+	priceGap          float64
+	priceGapR         float64 // sum of gap/second-price ratios
+	clearingPrices    priceHistogram
+
+	// Auctions cut short by the run stopping, and the DSP calls cancelled
+	// in them.
+	partialAuctions uint64
+	cancelledCalls  uint64
+
+	capHits []CapHit
+
+	series    timeSeries       // per-second history, see TimeSeries
+	anomalies *anomalyDetector // nil unless WithAnomalyDetection
+
+	checkConsistency bool
+	recentErrors     int
+	admSizeLimit     int
+	clock            clock.Clock // timestamps error samples and cap hits
+
+	tierBounds  []time.Duration
+	tierTimeout bool // the last bound is tmax, and the final tier holds timeouts
+
+	deals     map[string]*dealStatsInternal     // keyed by deal ID, nil until a deal is bid on
+	scenarios map[string]*scenarioStatsInternal // keyed by scenario name, nil unless scenarios are mixed
+
+	traffic trafficStatsInternal // see RecordRequest
+
+	renderDelay time.Duration // see WithRenderDelay
+	expiry      expiryStatsInternal
 }
 
+// Budget histogram layout: budgetBucketCount-1 buckets of budgetBucketWidth
+// percent covering 0-100% of tmax, plus a final bucket for overruns.
+const (
+	budgetBucketWidth = 10
+	budgetBucketCount = 100/budgetBucketWidth + 1
+)
+
 // dspStatsInternal holds per-DSP statistics (internal mutable version).
 type dspStatsInternal struct {
 	requests     uint64
 	bids         uint64
 	wins         uint64
+	spend        float64
 	noBids       uint64
 	errors       uint64
+	timeouts     uint64
+	faults       uint64
+	faultKinds   map[dispatcher.FaultKind]uint64
+	throttled    uint64
+	overloaded   uint64
+	retryAfters  uint64
+	skipped      map[dispatcher.SkipReason]uint64
+	statusCodes  map[int]uint64
+	violations   map[string]uint64
+	insaneBids   uint64
+	invalidBids  uint64
 	totalLatency time.Duration
+	latency      Histogram
+	latencyTiers []uint64 // responses per latency tier, see Collector.tierBounds
+
+	winNotice     noticeStatsInternal
+	billingNotice noticeStatsInternal
+	lossNotice    noticeStatsInternal
+
+	recentErrors errorRing
+	creatives    creativeStatsInternal
+	tls          tlsStatsInternal
+	queue        queueStatsInternal
+	retries      retryStatsInternal
+	ab           *abStatsInternal // nil until an A/B split request is recorded
+	deals        dealStatsInternal
+	preempted    uint64
+	expiry       expiryStatsInternal
+	fuzz         map[fuzz.Class]*fuzzStatsInternal // nil until a malformed request is sent
+	health       *healthStatsInternal              // nil until a health check is recorded
+
+	// Win premium accumulators: margins of this DSP's winning bids over
+	// the runner-up (contested wins only) and over the floor.
+	contestedWins      uint64
+	premiumOverSecond  float64
+	premiumOverSecondR float64 // sum of margin/runner-up ratios
+	premiumOverFloor   float64
+}
+
+// noticeStatsInternal tracks delivery of a single notification type to a DSP.
+type noticeStatsInternal struct {
+	sent    uint64
+	failed  uint64
+	latency Histogram
+}
+
+func (n *noticeStatsInternal) record(latency time.Duration, err error) {
+	n.sent++
+	if err != nil {
+		n.failed++
+	}
+	n.latency.Record(latency)
+}
+
+func (n *noticeStatsInternal) snapshot() NotificationStats {
+	ns := NotificationStats{
+		Sent:      n.sent,
+		Succeeded: n.sent - n.failed,
+		Failed:    n.failed,
+		Latency:   n.latency.Percentiles(),
+	}
+	if n.sent > 0 {
+		ns.SuccessRate = float64(ns.Succeeded) / float64(n.sent)
+	}
+	return ns
+}
+
+// Option configures the collector.
+type Option func(*Collector)
+
+// WithConsistencyChecks enables reconciliation of aggregate and per-DSP
+// counters on every snapshot. Intended for debug runs; drift is reported
+// in Snapshot.Drift and logged.
+func WithConsistencyChecks(enabled bool) Option {
+	return func(c *Collector) {
+		c.checkConsistency = enabled
+	}
+}
+
+// WithRecentErrors sets how many error samples are retained per DSP for
+// RecentErrors. Zero disables sampling.
+func WithRecentErrors(n int) Option {
+	return func(c *Collector) {
+		c.recentErrors = n
+	}
+}
+
+// WithAdMSizeLimit flags creatives whose markup exceeds n bytes in
+// CreativeStats.Oversized. Zero disables the check.
+func WithAdMSizeLimit(n int) Option {
+	return func(c *Collector) {
+		c.admSizeLimit = n
+	}
+}
+
+// WithClock timestamps error samples and spend cap hits with c, and
+// buckets the time series by its seconds.
+func WithClock(c clock.Clock) Option {
+	return func(col *Collector) {
+		col.clock = c
+	}
 }
 
 // New creates a new statistics collector.
-func New() *Collector {
-	return &Collector{
-		dspStats: make(map[string]*dspStatsInternal),
+func New(opts ...Option) *Collector {
+	c := &Collector{
+		dspStats:     make(map[string]*dspStatsInternal),
+		recentErrors: defaultRecentErrors,
+		tierBounds:   defaultLatencyTiers,
+		clock:        clock.Real,
+		series:       newTimeSeries(defaultTimeSeriesWindow),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // RecordAuction records the outcome of a single auction.
@@ -47,27 +208,111 @@ func (c *Collector) RecordAuction(outcome auction.Outcome, results []dispatcher.
 	defer c.mu.Unlock()
 
 	c.totalRequests++
-	c.totalBids += uint64(len(outcome.AllBids))
+	c.totalBids += uint64(len(outcome.AllBids) + len(outcome.Preempted))
 
 	if outcome.Winner != nil {
 		c.totalWins++
 		c.totalRevenue += outcome.ClearingPrice
+		c.clearingPrices.Record(outcome.ClearingPrice)
+		if gap, ok := outcome.PriceGap(); ok {
+			c.contestedAuctions++
+			c.priceGap += gap
+			if second := outcome.Winner.Price - gap; second > 0 {
+				c.priceGapR += gap / second
+			}
+		}
 	} else {
 		c.totalNoBids++
 	}
+	if outcome.Partial {
+		c.partialAuctions++
+		c.cancelledCalls += uint64(outcome.Cancelled)
+	}
 
 	// Track per-DSP stats from results
 	for _, r := range results {
 		dsp := c.getOrCreateDSP(r.DSPName)
+		dsp.queue.record(r)
+		if r.Skipped != dispatcher.SkipNone {
+			if dsp.skipped == nil {
+				dsp.skipped = make(map[dispatcher.SkipReason]uint64)
+			}
+			dsp.skipped[r.Skipped]++
+			continue
+		}
+
 		dsp.requests++
+		dsp.retries.record(r)
 		dsp.totalLatency += r.Latency
+		dsp.latency.Record(r.Latency)
+		c.latency.Record(r.Latency)
+		if dsp.latencyTiers == nil {
+			dsp.latencyTiers = make([]uint64, len(c.tierBounds)+1)
+		}
+		dsp.latencyTiers[c.latencyTier(r)]++
+		if r.TLS {
+			dsp.tls.requests++
+			dsp.tls.latency.Record(r.Latency)
+		}
+		if arm := dsp.arm(r.Arm); arm != nil {
+			arm.record(r, outcome)
+		}
+		if r.Fault != dispatcher.FaultNone {
+			dsp.faults++
+			if dsp.faultKinds == nil {
+				dsp.faultKinds = make(map[dispatcher.FaultKind]uint64)
+			}
+			dsp.faultKinds[r.Fault]++
+		}
+		if r.RetryAfter > 0 {
+			dsp.retryAfters++
+		}
+		if r.Status != 0 {
+			if dsp.statusCodes == nil {
+				dsp.statusCodes = make(map[int]uint64)
+			}
+			dsp.statusCodes[r.Status]++
+		}
+		switch r.Class {
+		case dispatcher.ClassThrottle:
+			dsp.throttled++
+		case dispatcher.ClassOverload:
+			dsp.overloaded++
+		}
 
 		if r.Error != nil {
 			dsp.errors++
 			c.totalErrors++
+			if isTimeout(r.Error) {
+				dsp.timeouts++
+			}
+			if errors.Is(r.Error, httpclient.ErrBodyTooLarge) {
+				dsp.creatives.bodyTooLarge++
+			}
+			code, _ := httpclient.StatusCode(r.Error)
+			dsp.recentErrors.add(ErrorSample{
+				Time:       c.clock.Now(),
+				StatusCode: code,
+				Message:    r.Error.Error(),
+			}, c.recentErrors)
 		} else if r.Response != nil && r.Response.IsNoBid() {
 			dsp.noBids++
 		}
+		if r.Mutation != fuzz.ClassNone {
+			dsp.recordFuzz(r)
+		}
+
+		if r.Response != nil {
+			for _, sb := range r.Response.SeatBid {
+				for i := range sb.Bid {
+					b := &sb.Bid[i]
+					if b.AdM != "" {
+						dsp.creatives.record(len(b.AdM), c.admSizeLimit)
+					}
+					dsp.creatives.recordBid(b)
+				}
+			}
+		}
 	}
 
 	// Track bids per DSP directly without temporary map allocation
@@ -75,14 +320,120 @@ func (c *Collector) RecordAuction(outcome auction.Outcome, results []dispatcher.
 		dsp := c.getOrCreateDSP(b.DSPName)
 		dsp.bids++
 	}
+	for _, b := range outcome.Preempted {
+		dsp := c.getOrCreateDSP(b.DSPName)
+		dsp.bids++
+	}
+	c.recordDeals(outcome)
+	c.recordExpiry(outcome)
+	c.recordScenario(outcome, results)
+	if ended := c.series.record(c.clock.Now(), outcome, results); ended != 0 && c.anomalies != nil {
+		c.anomalies.check(&c.series, ended)
+	}
+	for _, r := range outcome.Rejected {
+		dsp := c.getOrCreateDSP(r.DSPName)
+		if dsp.violations == nil {
+			dsp.violations = make(map[string]uint64)
+		}
+		dsp.violations[r.Reason]++
+		if auction.IsInsane(r.Reason) {
+			dsp.insaneBids++
+		}
+		if auction.IsInvalid(r.Reason) {
+			dsp.invalidBids++
+		}
+	}
 
 	// Track wins per DSP
 	if outcome.Winner != nil && outcome.WinningDSP != "" {
 		dsp := c.getOrCreateDSP(outcome.WinningDSP)
 		dsp.wins++
+		dsp.spend += outcome.ClearingPrice
+
+		price := outcome.Winner.Price
+		dsp.premiumOverFloor += price - outcome.BidFloor
+		if second, ok := outcome.RunnerUpPrice(); ok {
+			dsp.contestedWins++
+			dsp.premiumOverSecond += price - second
+			if second > 0 {
+				dsp.premiumOverSecondR += (price - second) / second
+			}
+		}
 	}
 }
 
+// RecordCapHit records a spend cap being reached. dsp is empty for the
+// exchange-wide cap.
+func (c *Collector) RecordCapHit(dsp string, spend, limit float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capHits = append(c.capHits, CapHit{Time: c.clock.Now(), DSP: dsp, Spend: spend, Cap: limit})
+}
+
+// RecordAuctionDuration records the end-to-end duration of an auction
+// (generation through adjudication) relative to the request's tmax budget.
+// Auctions without a tmax are not recorded.
+func (c *Collector) RecordAuctionDuration(elapsed, tmax time.Duration) {
+	if tmax <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.auctionDuration.Record(elapsed)
+
+	idx := budgetBucketCount - 1
+	if elapsed <= tmax {
+		idx = int(elapsed * 100 / tmax / budgetBucketWidth)
+		if idx == budgetBucketCount-1 {
+			idx-- // exactly 100% is still within budget
+		}
+	}
+	c.budgetBuckets[idx]++
+}
+
+// RecordWinNotice records the delivery of a win notice (nurl) to a DSP.
+// A non-nil err marks the notice as failed; latency is recorded either way
+// so slow failures remain visible in the percentiles.
+func (c *Collector) RecordWinNotice(dspName string, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.getOrCreateDSP(dspName).winNotice.record(latency, err)
+}
+
+// RecordBillingNotice records the delivery of a billing notice (burl) to a DSP.
+func (c *Collector) RecordBillingNotice(dspName string, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.getOrCreateDSP(dspName).billingNotice.record(latency, err)
+}
+
+// RecordLossNotice records the delivery of a loss notice (lurl) to a DSP.
+func (c *Collector) RecordLossNotice(dspName string, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.getOrCreateDSP(dspName).lossNotice.record(latency, err)
+}
+
+// RecentErrors returns up to limit of the most recent errors recorded for
+// a DSP, newest first. A limit <= 0 returns all retained samples. The
+// boolean is false if no requests have been recorded for the DSP.
+func (c *Collector) RecentErrors(dspName string, limit int) ([]ErrorSample, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	dsp, ok := c.dspStats[dspName]
+	if !ok {
+		return nil, false
+	}
+	return dsp.recentErrors.recent(limit), true
+}
+
 // getOrCreateDSP returns the DSP stats, creating it if necessary.
 // Must be called with mu held.
 func (c *Collector) getOrCreateDSP(name string) *dspStatsInternal {
@@ -94,6 +445,19 @@ func (c *Collector) getOrCreateDSP(name string) *dspStatsInternal {
 	return dsp
 }
 
+// winPremium averages the DSP's win premium accumulators.
+func (d *dspStatsInternal) winPremium() WinPremium {
+	wp := WinPremium{ContestedWins: d.contestedWins}
+	if d.wins > 0 {
+		wp.AvgOverFloor = d.premiumOverFloor / float64(d.wins)
+	}
+	if d.contestedWins > 0 {
+		wp.AvgOverSecond = d.premiumOverSecond / float64(d.contestedWins)
+		wp.AvgOverSecondPct = d.premiumOverSecondR / float64(d.contestedWins) * 100
+	}
+	return wp
+}
+
 // Snapshot returns a point-in-time copy of all statistics.
 func (c *Collector) Snapshot() Snapshot {
 	c.mu.RLock()
@@ -109,25 +473,141 @@ func (c *Collector) Snapshot() Snapshot {
 		DSPStats:      make(map[string]DSPStats, len(c.dspStats)),
 	}
 
+	snap.Latency = c.latency.Percentiles()
+	snap.TmaxBudget = c.budgetSnapshot()
+	snap.Auction = c.auctionSnapshot()
+	snap.Deals = c.dealsSnapshot()
+	snap.Scenarios = c.scenariosSnapshot()
+	snap.Traffic = c.traffic.snapshot()
+	snap.Expiry = c.expiry.snapshot()
+	if len(c.capHits) > 0 {
+		snap.CapHits = append([]CapHit(nil), c.capHits...)
+	}
+
 	for name, internal := range c.dspStats {
 		var avgLatency time.Duration
 		if internal.requests > 0 {
 			avgLatency = internal.totalLatency / time.Duration(internal.requests)
 		}
 
+		var skipped map[string]uint64
+		if len(internal.skipped) > 0 {
+			skipped = make(map[string]uint64, len(internal.skipped))
+			for reason, n := range internal.skipped {
+				skipped[string(reason)] = n
+			}
+		}
+
+		var faultKinds map[string]uint64
+		if len(internal.faultKinds) > 0 {
+			faultKinds = make(map[string]uint64, len(internal.faultKinds))
+			for kind, n := range internal.faultKinds {
+				faultKinds[string(kind)] = n
+			}
+		}
+
+		var violations map[string]uint64
+		if len(internal.violations) > 0 {
+			violations = make(map[string]uint64, len(internal.violations))
+			for reason, n := range internal.violations {
+				violations[reason] = n
+			}
+		}
+
 		snap.DSPStats[name] = DSPStats{
-			Requests:   internal.requests,
-			Bids:       internal.bids,
-			Wins:       internal.wins,
-			NoBids:     internal.noBids,
-			Errors:     internal.errors,
-			AvgLatency: avgLatency,
+			Requests:    internal.requests,
+			Bids:        internal.bids,
+			Wins:        internal.wins,
+			Spend:       internal.spend,
+			NoBids:      internal.noBids,
+			Errors:      internal.errors,
+			Timeouts:    internal.timeouts,
+			Faults:      internal.faults,
+			FaultKinds:  faultKinds,
+			Throttled:   internal.throttled,
+			Overloaded:  internal.overloaded,
+			RetryAfter:  internal.retryAfters,
+			Skipped:     skipped,
+			StatusCodes: maps.Clone(internal.statusCodes),
+			Violations:  violations,
+			InsaneBids:  internal.insaneBids,
+			InvalidBids: internal.invalidBids,
+			AvgLatency:  avgLatency,
+			Latency:     internal.latency.Percentiles(),
+			Tiers:       c.latencyTiers(internal.latencyTiers),
+			WinNotice:   internal.winNotice.snapshot(),
+
+			BillingNotice: internal.billingNotice.snapshot(),
+			LossNotice:    internal.lossNotice.snapshot(),
+			WinPremium:    internal.winPremium(),
+			Creatives:     internal.creatives.snapshot(),
+			TLS:           internal.tls.snapshot(),
+			Queue:         internal.queue.snapshot(),
+			Retries:       internal.retries.snapshot(),
+			Deals:         internal.deals.snapshot(),
+			Preempted:     internal.preempted,
+			Expiry:        internal.expiry.snapshot(),
+			Fuzz:          internal.fuzzSnapshot(),
+			Health:        internal.health.snapshot(),
+		}
+		ds := snap.DSPStats[name]
+		ds.Rates = ds.rates()
+		if internal.ab != nil {
+			ds.AB = internal.ab.snapshot()
+		}
+		snap.DSPStats[name] = ds
+	}
+	snap.Rates = snapshotRates(&snap)
+
+	if c.checkConsistency {
+		snap.Drift = snap.reconcile()
+		for _, d := range snap.Drift {
+			log.Printf("stats consistency: %s", d)
 		}
 	}
 
 	return snap
 }
 
+// budgetSnapshot builds the tmax budget histogram.
+// Must be called with mu held.
+func (c *Collector) budgetSnapshot() BudgetStats {
+	bs := BudgetStats{
+		Auctions: c.auctionDuration.Count(),
+		Duration: c.auctionDuration.Percentiles(),
+		Overruns: c.budgetBuckets[budgetBucketCount-1],
+		Buckets:  make([]BudgetBucket, budgetBucketCount),
+	}
+	for i, count := range c.budgetBuckets {
+		bs.Buckets[i] = BudgetBucket{
+			UpToPercent: (i + 1) * budgetBucketWidth,
+			Count:       count,
+		}
+	}
+	// The final bucket is open-ended
+	bs.Buckets[budgetBucketCount-1].UpToPercent = 0
+	return bs
+}
+
+// auctionSnapshot builds the yield metrics.
+// Must be called with mu held.
+func (c *Collector) auctionSnapshot() AuctionStats {
+	as := AuctionStats{
+		ContestedAuctions: c.contestedAuctions,
+		PartialAuctions:   c.partialAuctions,
+		CancelledCalls:    c.cancelledCalls,
+		ClearingPrice:     c.clearingPrices.snapshot(),
+	}
+	if c.totalRequests > 0 {
+		as.AvgBidsPerAuction = float64(c.totalBids) / float64(c.totalRequests)
+	}
+	if c.contestedAuctions > 0 {
+		as.AvgPriceGap = c.priceGap / float64(c.contestedAuctions)
+		as.AvgPriceGapPct = c.priceGapR / float64(c.contestedAuctions) * 100
+	}
+	return as
+}
+
 // Reset clears all statistics.
 func (c *Collector) Reset() {
 	c.mu.Lock()
@@ -140,6 +620,22 @@ func (c *Collector) Reset() {
 	c.totalErrors = 0
 	c.totalRevenue = 0
 	c.dspStats = make(map[string]*dspStatsInternal)
+	c.latency.Reset()
+	c.auctionDuration.Reset()
+	c.budgetBuckets = [budgetBucketCount]uint64{}
+	c.contestedAuctions = 0
+	c.priceGap = 0
+	c.priceGapR = 0
+	c.clearingPrices = priceHistogram{}
+	c.partialAuctions = 0
+	c.cancelledCalls = 0
+	c.capHits = nil
+	clear(c.series.seconds)
+	c.series.last = 0
+	c.deals = nil
+	c.scenarios = nil
+	c.traffic = trafficStatsInternal{}
+	c.expiry = expiryStatsInternal{}
 }
 
 // Snapshot represents a point-in-time copy of statistics.
@@ -150,15 +646,114 @@ type Snapshot struct {
 	TotalNoBids   uint64
 	TotalErrors   uint64
 	TotalRevenue  float64
+	Rates         Rates              // derived from the totals and DSP stats
+	Latency       LatencyPercentiles // DSP response latency across all DSPs
+	TmaxBudget    BudgetStats
+	Auction       AuctionStats
 	DSPStats      map[string]DSPStats
+
+	// Deals holds per-deal stats keyed by deal ID; nil when no deal
+	// was bid on.
+	Deals map[string]DealStats
+
+	// Scenarios holds per-scenario stats keyed by scenario name; nil
+	// unless the run mixes scenarios.
+	Scenarios map[string]ScenarioStats
+
+	// Traffic fingerprints the generated requests.
+	Traffic TrafficStats
+
+	// Expiry compares bid expiry with the render delay; see
+	// WithRenderDelay.
+	Expiry ExpiryStats
+
+	// CapHits lists spend caps reached, in order.
+	CapHits []CapHit
+
+	// Drift lists counter inconsistencies found when consistency checks
+	// are enabled. Empty when counters reconcile.
+	Drift []string
+}
+
+// CapHit is a spend cap being reached: a DSP paused for the rest of the
+// run, or the run ended when DSP is empty.
+type CapHit struct {
+	Time  time.Time
+	DSP   string
+	Spend float64
+	Cap   float64
+}
+
+// BudgetStats describes how much of the tmax budget auctions consume.
+type BudgetStats struct {
+	Auctions uint64
+	Duration LatencyPercentiles
+	Overruns uint64
+	Buckets  []BudgetBucket
+}
+
+// BudgetBucket counts auctions that consumed up to UpToPercent of tmax.
+// An UpToPercent of 0 marks the overrun bucket (more than 100% of tmax).
+type BudgetBucket struct {
+	UpToPercent int
+	Count       uint64
 }
 
 // DSPStats holds per-DSP statistics.
 type DSPStats struct {
-	Requests   uint64
-	Bids       uint64
-	Wins       uint64
-	NoBids     uint64
-	Errors     uint64
-	AvgLatency time.Duration
+	Requests    uint64
+	Bids        uint64
+	Wins        uint64
+	Spend       float64 // clearing prices paid on wins
+	Rates       Rates   // derived from the counters above
+	NoBids      uint64
+	Errors      uint64
+	Timeouts    uint64            // errors that were timeouts, also counted in Errors
+	Faults      uint64            // injected faults, also counted in Errors when they fail the call
+	FaultKinds  map[string]uint64 // injected faults keyed by kind
+	Throttled   uint64            // throttle statuses, also counted in NoBids
+	Overloaded  uint64            // overload statuses, not counted as errors
+	RetryAfter  uint64            // 429 responses carrying Retry-After (throttle events)
+	Skipped     map[string]uint64 // requests not sent, keyed by reason
+	StatusCodes map[int]uint64    // responses keyed by HTTP status, whether or not they parsed
+	Violations  map[string]uint64 // bids rejected before the auction, keyed by reason, not counted in Bids
+	InsaneBids  uint64            // bids rejected by sanity limits, also counted in Violations
+	InvalidBids uint64            // malformed bids rejected by validation, also counted in Violations
+	AvgLatency  time.Duration
+	Latency     LatencyPercentiles
+	Tiers       []LatencyTier // response counts per latency tier, see WithLatencyTiers
+	WinNotice   NotificationStats
+
+	BillingNotice NotificationStats
+	LossNotice    NotificationStats
+	WinPremium    WinPremium
+	Creatives     CreativeStats
+	TLS           TLSStats
+	Queue         QueueStats
+	Retries       RetryStats
+	AB            *ABStats // nil for DSPs without an A/B split
+	Deals         DealStats
+	Preempted     uint64 // open-market bids that lost to a deal bid, also counted in Bids
+	Expiry        ExpiryStats
+	Fuzz          map[string]FuzzStats // answers to malformed requests, keyed by mutation class; nil unless fuzzing
+	Health        *HealthStats         // nil unless health checks are on
+}
+
+// WinPremium quantifies how much a DSP overpays when it wins. In a
+// first-price auction the margin over the runner-up is money left on the
+// table; a consistently high value suggests poor bid shading.
+type WinPremium struct {
+	AvgOverSecond    float64 // mean winning price minus runner-up bid, contested wins only
+	AvgOverSecondPct float64 // mean margin over runner-up as a percentage of the runner-up bid
+	AvgOverFloor     float64 // mean winning price minus bid floor, all wins
+	ContestedWins    uint64  // wins where at least one other eligible bid competed
+}
+
+// NotificationStats summarizes delivery of notifications to a DSP endpoint.
+type NotificationStats struct {
+	Sent        uint64
+	Succeeded   uint64
+	Failed      uint64
+	SuccessRate float64
+	Latency     LatencyPercentiles
 }
@@ -1,11 +1,17 @@
 package stats
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cass/rtb-simulator/internal/auction"
 	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/httpclient"
 	"github.com/cass/rtb-simulator/pkg/openrtb"
 )
 
@@ -47,6 +53,9 @@ func TestCollector_RecordAuction_WithWinner(t *testing.T) {
 	if snapshot.TotalRevenue != 2.5 {
 		t.Errorf("expected revenue 2.5, got %f", snapshot.TotalRevenue)
 	}
+	if spend := snapshot.DSPStats["dsp1"].Spend; spend != 2.5 {
+		t.Errorf("expected dsp1 spend 2.5, got %f", spend)
+	}
 }
 
 func TestCollector_RecordAuction_NoBid(t *testing.T) {
@@ -171,6 +180,36 @@ func TestCollector_RecordError(t *testing.T) {
 	}
 }
 
+func TestCollector_InjectedFaults(t *testing.T) {
+	c := New()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Error: dispatcher.ErrConnectionReset, Fault: dispatcher.FaultReset},
+		{DSPName: "dsp2"},
+	}
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, results)
+	c.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: dispatcher.ErrNoResponse, Fault: dispatcher.FaultDrop},
+	})
+
+	snapshot := c.Snapshot()
+	if got := snapshot.DSPStats["dsp1"].Faults; got != 2 {
+		t.Errorf("dsp1: expected 2 faults, got %d", got)
+	}
+	if got := snapshot.DSPStats["dsp1"].FaultKinds; got["reset"] != 1 || got["drop"] != 1 {
+		t.Errorf("dsp1: FaultKinds = %v, want one reset and one drop", got)
+	}
+	if got := snapshot.DSPStats["dsp1"].Errors; got != 2 {
+		t.Errorf("dsp1: expected 2 errors, got %d", got)
+	}
+	if got := snapshot.DSPStats["dsp1"].Timeouts; got != 1 {
+		t.Errorf("dsp1: expected the dropped request as 1 timeout, got %d", got)
+	}
+	if got := snapshot.DSPStats["dsp2"].Faults; got != 0 {
+		t.Errorf("dsp2: expected 0 faults, got %d", got)
+	}
+}
+
 func TestCollector_Concurrency(t *testing.T) {
 	c := New()
 
@@ -268,6 +307,615 @@ func TestCollector_AvgLatency(t *testing.T) {
 	}
 }
 
+func TestCollector_LatencyPercentiles(t *testing.T) {
+	c := New()
+
+	// dsp1: 1..100ms, dsp2: constant 200ms
+	for i := 1; i <= 100; i++ {
+		results := []dispatcher.Result{
+			{DSPName: "dsp1", Latency: time.Duration(i) * time.Millisecond},
+			{DSPName: "dsp2", Latency: 200 * time.Millisecond},
+		}
+		c.RecordAuction(auction.Outcome{RequestID: "req"}, results)
+	}
+
+	snapshot := c.Snapshot()
+
+	dsp1 := snapshot.DSPStats["dsp1"].Latency
+	if dsp1.P50 < 47*time.Millisecond || dsp1.P50 > 53*time.Millisecond {
+		t.Errorf("dsp1 P50 = %v, want ~50ms", dsp1.P50)
+	}
+	if dsp1.P99 < 95*time.Millisecond || dsp1.P99 > 100*time.Millisecond {
+		t.Errorf("dsp1 P99 = %v, want ~99ms", dsp1.P99)
+	}
+	if dsp1.Max != 100*time.Millisecond {
+		t.Errorf("dsp1 Max = %v, want 100ms", dsp1.Max)
+	}
+
+	if got := snapshot.DSPStats["dsp2"].Latency.P50; got != 200*time.Millisecond {
+		t.Errorf("dsp2 P50 = %v, want 200ms", got)
+	}
+
+	// Overall: half the samples are 200ms, so p90 lands in dsp2's range
+	if snapshot.Latency.P90 != 200*time.Millisecond {
+		t.Errorf("overall P90 = %v, want 200ms", snapshot.Latency.P90)
+	}
+	if snapshot.Latency.Max != 200*time.Millisecond {
+		t.Errorf("overall Max = %v, want 200ms", snapshot.Latency.Max)
+	}
+}
+
+func TestCollector_RecordWinNotice(t *testing.T) {
+	c := New()
+
+	c.RecordWinNotice("dsp1", 10*time.Millisecond, nil)
+	c.RecordWinNotice("dsp1", 20*time.Millisecond, nil)
+	c.RecordWinNotice("dsp1", 30*time.Millisecond, nil)
+	c.RecordWinNotice("dsp1", 500*time.Millisecond, testError{})
+
+	snapshot := c.Snapshot()
+	notice := snapshot.DSPStats["dsp1"].WinNotice
+
+	if notice.Sent != 4 {
+		t.Errorf("Sent = %d, want 4", notice.Sent)
+	}
+	if notice.Succeeded != 3 {
+		t.Errorf("Succeeded = %d, want 3", notice.Succeeded)
+	}
+	if notice.Failed != 1 {
+		t.Errorf("Failed = %d, want 1", notice.Failed)
+	}
+	if notice.SuccessRate != 0.75 {
+		t.Errorf("SuccessRate = %f, want 0.75", notice.SuccessRate)
+	}
+	if notice.Latency.Max != 500*time.Millisecond {
+		t.Errorf("Latency.Max = %v, want 500ms", notice.Latency.Max)
+	}
+	if notice.Latency.P50 < 18*time.Millisecond || notice.Latency.P50 > 22*time.Millisecond {
+		t.Errorf("Latency.P50 = %v, want ~20ms", notice.Latency.P50)
+	}
+
+	c.RecordBillingNotice("dsp1", 5*time.Millisecond, nil)
+	c.RecordLossNotice("dsp2", 5*time.Millisecond, testError{})
+
+	snapshot = c.Snapshot()
+	if got := snapshot.DSPStats["dsp1"].BillingNotice.Sent; got != 1 {
+		t.Errorf("BillingNotice.Sent = %d, want 1", got)
+	}
+	if got := snapshot.DSPStats["dsp2"].LossNotice.Failed; got != 1 {
+		t.Errorf("LossNotice.Failed = %d, want 1", got)
+	}
+
+	// Win notices alone should not count as bid requests
+	if snapshot.DSPStats["dsp1"].Requests != 0 {
+		t.Errorf("Requests = %d, want 0", snapshot.DSPStats["dsp1"].Requests)
+	}
+}
+
+func TestCollector_StatusClasses(t *testing.T) {
+	c := New()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{ID: "req-1"}, Class: dispatcher.ClassThrottle},
+		{DSPName: "dsp2", Class: dispatcher.ClassOverload},
+	}
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, results)
+
+	snapshot := c.Snapshot()
+	dsp1 := snapshot.DSPStats["dsp1"]
+	if dsp1.Throttled != 1 || dsp1.NoBids != 1 {
+		t.Errorf("dsp1: Throttled = %d, NoBids = %d, want 1, 1", dsp1.Throttled, dsp1.NoBids)
+	}
+	dsp2 := snapshot.DSPStats["dsp2"]
+	if dsp2.Overloaded != 1 || dsp2.Errors != 0 {
+		t.Errorf("dsp2: Overloaded = %d, Errors = %d, want 1, 0", dsp2.Overloaded, dsp2.Errors)
+	}
+	if snapshot.TotalErrors != 0 {
+		t.Errorf("TotalErrors = %d, want 0", snapshot.TotalErrors)
+	}
+}
+
+func TestCollector_SkippedAndRetryAfter(t *testing.T) {
+	c := New()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Skipped: dispatcher.SkipThrottled},
+		{DSPName: "dsp2", Error: testError{}, RetryAfter: time.Second, Latency: time.Millisecond},
+	}
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, results)
+
+	snapshot := c.Snapshot()
+	dsp1 := snapshot.DSPStats["dsp1"]
+	if dsp1.Requests != 0 {
+		t.Errorf("dsp1: Requests = %d, want 0 for skipped call", dsp1.Requests)
+	}
+	if dsp1.Skipped["throttled"] != 1 {
+		t.Errorf("dsp1: Skipped[throttled] = %d, want 1", dsp1.Skipped["throttled"])
+	}
+	if got := snapshot.DSPStats["dsp2"].RetryAfter; got != 1 {
+		t.Errorf("dsp2: RetryAfter = %d, want 1", got)
+	}
+}
+
+func TestCollector_Queue(t *testing.T) {
+	c := New()
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: time.Millisecond},
+		{DSPName: "dsp2", Latency: time.Millisecond, QueueWait: 4 * time.Millisecond},
+	})
+	c.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{
+		{DSPName: "dsp2", Skipped: dispatcher.SkipSaturated, QueueWait: 10 * time.Millisecond},
+	})
+
+	snapshot := c.Snapshot()
+	if q := snapshot.DSPStats["dsp1"].Queue; q.Queued != 0 || q.Saturated != 0 {
+		t.Errorf("dsp1: Queue = %+v, want empty", q)
+	}
+	dsp2 := snapshot.DSPStats["dsp2"]
+	if dsp2.Queue.Queued != 2 || dsp2.Queue.Saturated != 1 {
+		t.Errorf("dsp2: Queued = %d, Saturated = %d; want 2, 1", dsp2.Queue.Queued, dsp2.Queue.Saturated)
+	}
+	if dsp2.Queue.Wait.Max < 10*time.Millisecond {
+		t.Errorf("dsp2: Wait.Max = %v, want at least 10ms", dsp2.Queue.Wait.Max)
+	}
+	if dsp2.Requests != 1 || dsp2.Skipped["saturated"] != 1 {
+		t.Errorf("dsp2: Requests = %d, Skipped[saturated] = %d; want 1, 1", dsp2.Requests, dsp2.Skipped["saturated"])
+	}
+}
+
+func TestCollector_StatusCodes(t *testing.T) {
+	c := New()
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Status: 204, Response: &openrtb.BidResponse{ID: "req-1"}},
+		{DSPName: "dsp2", Status: 200, Response: &openrtb.BidResponse{ID: "req-1"}},
+	})
+	c.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{
+		{DSPName: "dsp1", Status: 204, Response: &openrtb.BidResponse{ID: "req-2"}},
+		{DSPName: "dsp2", Status: 503, Error: &httpclient.StatusError{StatusCode: 503}},
+	})
+	c.RecordAuction(auction.Outcome{RequestID: "req-3"}, []dispatcher.Result{
+		{DSPName: "dsp1", Error: errors.New("connection refused")},
+		{DSPName: "dsp2", Skipped: dispatcher.SkipPaused},
+	})
+
+	snapshot := c.Snapshot()
+	dsp1, dsp2 := snapshot.DSPStats["dsp1"], snapshot.DSPStats["dsp2"]
+	if !maps.Equal(dsp1.StatusCodes, map[int]uint64{204: 2}) {
+		t.Errorf("dsp1: StatusCodes = %v, want 204s only", dsp1.StatusCodes)
+	}
+	if !maps.Equal(dsp2.StatusCodes, map[int]uint64{200: 1, 503: 1}) {
+		t.Errorf("dsp2: StatusCodes = %v, want a 200 and a 503", dsp2.StatusCodes)
+	}
+	if dsp1.NoBids != 2 || dsp2.NoBids != 1 {
+		t.Errorf("NoBids = %d, %d; want 2, 1 whatever the status", dsp1.NoBids, dsp2.NoBids)
+	}
+}
+
+func TestCollector_Retries(t *testing.T) {
+	c := New()
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-1"}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: time.Millisecond},
+		{DSPName: "dsp2", Latency: time.Millisecond, Retries: 1},
+	})
+	c.RecordAuction(auction.Outcome{RequestID: "req-2"}, []dispatcher.Result{
+		{DSPName: "dsp2", Latency: time.Millisecond, Retries: 2, Error: errors.New("503")},
+	})
+
+	snapshot := c.Snapshot()
+	if r := snapshot.DSPStats["dsp1"].Retries; r != (RetryStats{}) {
+		t.Errorf("dsp1: Retries = %+v, want empty", r)
+	}
+	dsp2 := snapshot.DSPStats["dsp2"]
+	if want := (RetryStats{Retried: 2, Retries: 3, Recovered: 1}); dsp2.Retries != want {
+		t.Errorf("dsp2: Retries = %+v, want %+v", dsp2.Retries, want)
+	}
+	if dsp2.Requests != 2 || dsp2.Errors != 1 {
+		t.Errorf("dsp2: Requests = %d, Errors = %d; want 2, 1", dsp2.Requests, dsp2.Errors)
+	}
+}
+
+func TestCollector_PartialAuction(t *testing.T) {
+	c := New()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Latency: time.Millisecond},
+		{DSPName: "dsp2", Skipped: dispatcher.SkipCancelled},
+	}
+	c.RecordAuction(auction.Outcome{RequestID: "req-1", Partial: true, Completed: 1, Cancelled: 1}, results)
+	c.RecordAuction(auction.Outcome{RequestID: "req-2", Completed: 2}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: time.Millisecond},
+		{DSPName: "dsp2", Latency: time.Millisecond},
+	})
+
+	snapshot := c.Snapshot()
+	if got := snapshot.Auction.PartialAuctions; got != 1 {
+		t.Errorf("PartialAuctions = %d, want 1", got)
+	}
+	if got := snapshot.Auction.CancelledCalls; got != 1 {
+		t.Errorf("CancelledCalls = %d, want 1", got)
+	}
+	if snapshot.TotalErrors != 0 {
+		t.Errorf("TotalErrors = %d, want 0 for cancelled calls", snapshot.TotalErrors)
+	}
+	dsp2 := snapshot.DSPStats["dsp2"]
+	if dsp2.Requests != 1 || dsp2.Errors != 0 {
+		t.Errorf("dsp2: Requests = %d, Errors = %d; want 1, 0", dsp2.Requests, dsp2.Errors)
+	}
+	if dsp2.Skipped["cancelled"] != 1 {
+		t.Errorf("dsp2: Skipped[cancelled] = %d, want 1", dsp2.Skipped["cancelled"])
+	}
+
+	c.Reset()
+	if got := c.Snapshot().Auction.PartialAuctions; got != 0 {
+		t.Errorf("PartialAuctions after Reset = %d, want 0", got)
+	}
+}
+
+func TestCollector_DomainViolations(t *testing.T) {
+	c := New()
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{
+			{ID: "a", Price: 2.0}, {ID: "b", Price: 3.0},
+		}}}}},
+	}
+	outcome := auction.Outcome{
+		RequestID: "req-1",
+		Rejected: []auction.Rejection{
+			{BidWithDSP: auction.BidWithDSP{DSPName: "dsp1"}, Reason: auction.ViolationBlocked},
+			{BidWithDSP: auction.BidWithDSP{DSPName: "dsp1"}, Reason: auction.ViolationBlocked},
+		},
+		WinnerIndex: -1,
+	}
+	c.RecordAuction(outcome, results)
+
+	dsp1 := c.Snapshot().DSPStats["dsp1"]
+	if got := dsp1.Violations[auction.ViolationBlocked]; got != 2 {
+		t.Errorf("Violations[%s] = %d, want 2", auction.ViolationBlocked, got)
+	}
+	if dsp1.Bids != 0 {
+		t.Errorf("Bids = %d, want 0 for rejected bids", dsp1.Bids)
+	}
+	if dsp1.InsaneBids != 0 {
+		t.Errorf("InsaneBids = %d, want 0 for adomain violations", dsp1.InsaneBids)
+	}
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-2", WinnerIndex: -1, Rejected: []auction.Rejection{
+		{BidWithDSP: auction.BidWithDSP{DSPName: "dsp1"}, Reason: auction.ViolationInsaneCPM},
+		{BidWithDSP: auction.BidWithDSP{DSPName: "dsp1"}, Reason: auction.ViolationInsaneFloorRatio},
+	}}, nil)
+	dsp1 = c.Snapshot().DSPStats["dsp1"]
+	if dsp1.InsaneBids != 2 || dsp1.Violations[auction.ViolationInsaneCPM] != 1 {
+		t.Errorf("InsaneBids = %d, Violations = %v; want 2 insane bids by reason", dsp1.InsaneBids, dsp1.Violations)
+	}
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-3", WinnerIndex: -1, Rejected: []auction.Rejection{
+		{BidWithDSP: auction.BidWithDSP{DSPName: "dsp1"}, Reason: auction.ViolationUnknownImp},
+	}}, nil)
+	dsp1 = c.Snapshot().DSPStats["dsp1"]
+	if dsp1.InvalidBids != 1 || dsp1.InsaneBids != 2 || dsp1.Violations[auction.ViolationUnknownImp] != 1 {
+		t.Errorf("InvalidBids = %d, InsaneBids = %d, Violations = %v; want 1 invalid bid", dsp1.InvalidBids, dsp1.InsaneBids, dsp1.Violations)
+	}
+}
+
 type testError struct{}
 
 func (testError) Error() string { return "test error" }
+
+func TestCollector_RecordAuctionDuration(t *testing.T) {
+	c := New()
+	tmax := 100 * time.Millisecond
+
+	c.RecordAuctionDuration(5*time.Millisecond, tmax)   // 0-10%
+	c.RecordAuctionDuration(45*time.Millisecond, tmax)  // 40-50%
+	c.RecordAuctionDuration(100*time.Millisecond, tmax) // 90-100%
+	c.RecordAuctionDuration(150*time.Millisecond, tmax) // overrun
+	c.RecordAuctionDuration(10*time.Millisecond, 0)     // no tmax, ignored
+
+	budget := c.Snapshot().TmaxBudget
+
+	if budget.Auctions != 4 {
+		t.Errorf("Auctions = %d, want 4", budget.Auctions)
+	}
+	if budget.Overruns != 1 {
+		t.Errorf("Overruns = %d, want 1", budget.Overruns)
+	}
+	if len(budget.Buckets) != 11 {
+		t.Fatalf("len(Buckets) = %d, want 11", len(budget.Buckets))
+	}
+
+	want := map[int]uint64{0: 1, 4: 1, 9: 1, 10: 1}
+	for i, b := range budget.Buckets {
+		if b.Count != want[i] {
+			t.Errorf("Buckets[%d] (up to %d%%) = %d, want %d", i, b.UpToPercent, b.Count, want[i])
+		}
+	}
+	if budget.Buckets[9].UpToPercent != 100 {
+		t.Errorf("Buckets[9].UpToPercent = %d, want 100", budget.Buckets[9].UpToPercent)
+	}
+	if budget.Duration.Max != 150*time.Millisecond {
+		t.Errorf("Duration.Max = %v, want 150ms", budget.Duration.Max)
+	}
+
+	c.Reset()
+	if got := c.Snapshot().TmaxBudget.Auctions; got != 0 {
+		t.Errorf("Auctions after Reset = %d, want 0", got)
+	}
+}
+
+func TestCollector_WinPremium(t *testing.T) {
+	c := New()
+
+	// Contested win: 3.00 over a 2.00 runner-up and a 1.00 floor
+	c.RecordAuction(auction.Outcome{
+		RequestID:     "req-1",
+		Winner:        &openrtb.Bid{ID: "a", Price: 3.0},
+		WinningDSP:    "dsp1",
+		ClearingPrice: 3.0,
+		BidFloor:      1.0,
+		WinnerIndex:   0,
+		AllBids: []auction.BidWithDSP{
+			{Bid: openrtb.Bid{ID: "a", Price: 3.0}, DSPName: "dsp1"},
+			{Bid: openrtb.Bid{ID: "b", Price: 2.0}, DSPName: "dsp2"},
+		},
+	}, nil)
+
+	// Uncontested win: 2.00 over a 1.00 floor
+	c.RecordAuction(auction.Outcome{
+		RequestID:     "req-2",
+		Winner:        &openrtb.Bid{ID: "c", Price: 2.0},
+		WinningDSP:    "dsp1",
+		ClearingPrice: 2.0,
+		BidFloor:      1.0,
+		WinnerIndex:   0,
+		AllBids: []auction.BidWithDSP{
+			{Bid: openrtb.Bid{ID: "c", Price: 2.0}, DSPName: "dsp1"},
+		},
+	}, nil)
+
+	wp := c.Snapshot().DSPStats["dsp1"].WinPremium
+	if wp.ContestedWins != 1 {
+		t.Errorf("ContestedWins = %d, want 1", wp.ContestedWins)
+	}
+	if wp.AvgOverSecond != 1.0 {
+		t.Errorf("AvgOverSecond = %v, want 1.0", wp.AvgOverSecond)
+	}
+	if wp.AvgOverSecondPct != 50 {
+		t.Errorf("AvgOverSecondPct = %v, want 50", wp.AvgOverSecondPct)
+	}
+	if wp.AvgOverFloor != 1.5 {
+		t.Errorf("AvgOverFloor = %v, want 1.5", wp.AvgOverFloor)
+	}
+
+	if wp := c.Snapshot().DSPStats["dsp2"].WinPremium; wp != (WinPremium{}) {
+		t.Errorf("dsp2 WinPremium = %+v, want zero for a DSP without wins", wp)
+	}
+}
+
+func TestCollector_AuctionYield(t *testing.T) {
+	c := New()
+
+	// Contested: 3.00 over 2.00, one extra bid below
+	c.RecordAuction(auction.Outcome{
+		RequestID:     "req-1",
+		Winner:        &openrtb.Bid{ID: "a", Price: 3.0},
+		WinningDSP:    "dsp1",
+		ClearingPrice: 3.0,
+		WinnerIndex:   0,
+		AllBids: []auction.BidWithDSP{
+			{Bid: openrtb.Bid{ID: "a", Price: 3.0}, DSPName: "dsp1"},
+			{Bid: openrtb.Bid{ID: "b", Price: 2.0}, DSPName: "dsp2"},
+			{Bid: openrtb.Bid{ID: "c", Price: 1.0}, DSPName: "dsp3"},
+		},
+	}, nil)
+
+	// Uncontested win at 0.40
+	c.RecordAuction(auction.Outcome{
+		RequestID:     "req-2",
+		Winner:        &openrtb.Bid{ID: "d", Price: 0.4},
+		WinningDSP:    "dsp1",
+		ClearingPrice: 0.4,
+		WinnerIndex:   0,
+		AllBids: []auction.BidWithDSP{
+			{Bid: openrtb.Bid{ID: "d", Price: 0.4}, DSPName: "dsp1"},
+		},
+	}, nil)
+
+	// No bids
+	c.RecordAuction(auction.Outcome{RequestID: "req-3", WinnerIndex: -1}, nil)
+
+	as := c.Snapshot().Auction
+	if as.AvgBidsPerAuction != 4.0/3 {
+		t.Errorf("AvgBidsPerAuction = %v, want %v", as.AvgBidsPerAuction, 4.0/3)
+	}
+	if as.ContestedAuctions != 1 {
+		t.Errorf("ContestedAuctions = %d, want 1", as.ContestedAuctions)
+	}
+	if as.AvgPriceGap != 1.0 || as.AvgPriceGapPct != 50 {
+		t.Errorf("AvgPriceGap = %v (%v%%), want 1.0 (50%%)", as.AvgPriceGap, as.AvgPriceGapPct)
+	}
+
+	cp := as.ClearingPrice
+	if cp.Count != 2 || cp.Mean != 1.7 || cp.Max != 3.0 {
+		t.Errorf("ClearingPrice count/mean/max = %d/%v/%v, want 2/1.7/3", cp.Count, cp.Mean, cp.Max)
+	}
+	if cp.P50 < 0.38 || cp.P50 > 0.43 {
+		t.Errorf("ClearingPrice P50 = %v, want ~0.40", cp.P50)
+	}
+	if cp.Buckets[0].UpTo != 0.5 || cp.Buckets[0].Count != 1 {
+		t.Errorf("Buckets[0] = %+v, want {0.5 1}", cp.Buckets[0])
+	}
+	if cp.Buckets[3].UpTo != 3 || cp.Buckets[3].Count != 1 {
+		t.Errorf("Buckets[3] = %+v, want {3 1}", cp.Buckets[3])
+	}
+
+	c.Reset()
+	if as := c.Snapshot().Auction; as.ContestedAuctions != 0 || as.ClearingPrice.Count != 0 {
+		t.Errorf("Auction after Reset = %+v, want zero", as)
+	}
+}
+
+func TestCollector_CreativeStats(t *testing.T) {
+	c := New(WithAdMSizeLimit(1000))
+
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{
+			{ID: "a", Price: 1, AdM: strings.Repeat("x", 200)},
+			{ID: "b", Price: 1, AdM: strings.Repeat("x", 4000)},
+			{ID: "c", Price: 1}, // no markup
+		}}}}},
+		{DSPName: "dsp2", Error: fmt.Errorf("%w: limit is 65536 bytes", httpclient.ErrBodyTooLarge)},
+	}
+	c.RecordAuction(auction.Outcome{RequestID: "req-1", WinnerIndex: -1}, results)
+
+	snap := c.Snapshot()
+	cs := snap.DSPStats["dsp1"].Creatives
+	if cs.Creatives != 2 {
+		t.Errorf("Creatives = %d, want 2", cs.Creatives)
+	}
+	if cs.AvgBytes != 2100 || cs.MaxBytes != 4000 {
+		t.Errorf("AvgBytes/MaxBytes = %v/%d, want 2100/4000", cs.AvgBytes, cs.MaxBytes)
+	}
+	if cs.P50Bytes < 200 || cs.P50Bytes > 215 {
+		t.Errorf("P50Bytes = %d, want ~200", cs.P50Bytes)
+	}
+	if cs.Oversized != 1 {
+		t.Errorf("Oversized = %d, want 1", cs.Oversized)
+	}
+
+	dsp2 := snap.DSPStats["dsp2"]
+	if dsp2.Creatives.BodyTooLarge != 1 || dsp2.Errors != 1 {
+		t.Errorf("dsp2 BodyTooLarge/Errors = %d/%d, want 1/1", dsp2.Creatives.BodyTooLarge, dsp2.Errors)
+	}
+}
+
+func TestCollector_CreativeTopK(t *testing.T) {
+	c := New()
+	bids := []openrtb.Bid{
+		{ID: "a", Price: 1, Cat: []string{"IAB1", "IAB2"}, ADomain: []string{"brand-a.com"}},
+		{ID: "b", Price: 1, Cat: []string{"IAB2"}, ADomain: []string{"brand-b.com"}},
+		{ID: "c", Price: 1, Cat: []string{"IAB2"}, ADomain: []string{"brand-a.com"}},
+	}
+	c.RecordAuction(auction.Outcome{RequestID: "req-1", WinnerIndex: -1}, []dispatcher.Result{
+		{DSPName: "dsp1", Response: &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: bids}}}},
+	})
+
+	cs := c.Snapshot().DSPStats["dsp1"].Creatives
+	wantCats := []KeyCount{{"IAB2", 3}, {"IAB1", 1}}
+	if !reflect.DeepEqual(cs.Categories, wantCats) {
+		t.Errorf("Categories = %v, want %v", cs.Categories, wantCats)
+	}
+	wantDomains := []KeyCount{{"brand-a.com", 2}, {"brand-b.com", 1}}
+	if !reflect.DeepEqual(cs.ADomains, wantDomains) {
+		t.Errorf("ADomains = %v, want %v", cs.ADomains, wantDomains)
+	}
+}
+
+func TestTopCounter(t *testing.T) {
+	var tc topCounter
+	for i := range maxTrackedKeys + 5 {
+		tc.add(fmt.Sprint(i))
+	}
+	tc.add("0")
+
+	if len(tc.counts) != maxTrackedKeys {
+		t.Errorf("tracked %d keys, want the cap of %d", len(tc.counts), maxTrackedKeys)
+	}
+	top := tc.top(topK)
+	if len(top) != topK || top[0] != (KeyCount{"0", 2}) {
+		t.Errorf("top = %v, want %d rows led by 0 (2)", top, topK)
+	}
+	if got := (&topCounter{}).top(topK); got != nil {
+		t.Errorf("empty top = %v, want nil", got)
+	}
+}
+
+func TestCollector_TLSStats(t *testing.T) {
+	c := New()
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-1", WinnerIndex: -1}, []dispatcher.Result{
+		{DSPName: "dsp1", TLS: true, Latency: 30 * time.Millisecond},
+		{DSPName: "dsp1", Latency: 10 * time.Millisecond},
+	})
+	c.RecordTLSHandshake("dsp1", false, 20*time.Millisecond)
+	c.RecordTLSHandshake("dsp1", true, 5*time.Millisecond)
+	c.RecordTLSHandshake("dsp1", true, 5*time.Millisecond)
+
+	ts := c.Snapshot().DSPStats["dsp1"].TLS
+	if ts.Requests != 1 || ts.Latency.Max != 30*time.Millisecond {
+		t.Errorf("Requests/Latency.Max = %d/%v, want 1/30ms", ts.Requests, ts.Latency.Max)
+	}
+	if ts.Handshakes != 3 || ts.Resumed != 2 {
+		t.Errorf("Handshakes/Resumed = %d/%d, want 3/2", ts.Handshakes, ts.Resumed)
+	}
+	if ts.ResumptionRate < 0.66 || ts.ResumptionRate > 0.67 {
+		t.Errorf("ResumptionRate = %v, want 2/3", ts.ResumptionRate)
+	}
+	if ts.Handshake.Max != 20*time.Millisecond {
+		t.Errorf("Handshake.Max = %v, want 20ms", ts.Handshake.Max)
+	}
+}
+
+func TestCollector_HealthStats(t *testing.T) {
+	c := New()
+	c.RecordHealthCheck("dsp1", true, true)
+	c.RecordHealthCheck("dsp1", false, true)
+	c.RecordHealthCheck("dsp1", false, false)
+	c.RecordHealthCheck("dsp1", true, true)
+	c.RecordHealthCheck("dsp1", false, false)
+
+	snap := c.Snapshot()
+	want := HealthStats{Checks: 5, Failed: 3, Outages: 2, Healthy: false}
+	if h := snap.DSPStats["dsp1"].Health; h == nil || *h != want {
+		t.Errorf("Health = %+v, want %+v", h, want)
+	}
+
+	c.RecordAuction(auction.Outcome{RequestID: "req-1", WinnerIndex: -1}, []dispatcher.Result{{DSPName: "dsp2"}})
+	if h := c.Snapshot().DSPStats["dsp2"].Health; h != nil {
+		t.Errorf("Health = %+v for an unchecked DSP, want nil", h)
+	}
+}
+
+func TestCollector_ABStats(t *testing.T) {
+	c := New()
+	bid := func(price float64) *openrtb.BidResponse {
+		return &openrtb.BidResponse{SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{ID: "b", Price: price}}}}}
+	}
+
+	// Arm A bids once in two requests and wins; arm B bids every time but
+	// loses once and errors once
+	c.RecordAuction(auction.Outcome{RequestID: "1", Winner: &openrtb.Bid{Price: 2}, WinningDSP: "dsp1", ClearingPrice: 2},
+		[]dispatcher.Result{{DSPName: "dsp1", Arm: dispatcher.ArmA, Response: bid(2), Latency: 10 * time.Millisecond}})
+	c.RecordAuction(auction.Outcome{RequestID: "2", WinnerIndex: -1},
+		[]dispatcher.Result{{DSPName: "dsp1", Arm: dispatcher.ArmA, Response: &openrtb.BidResponse{}, Latency: 10 * time.Millisecond}})
+	c.RecordAuction(auction.Outcome{RequestID: "3", Winner: &openrtb.Bid{Price: 3}, WinningDSP: "dsp1", ClearingPrice: 3},
+		[]dispatcher.Result{{DSPName: "dsp1", Arm: dispatcher.ArmB, Response: bid(3), Latency: 30 * time.Millisecond}})
+	c.RecordAuction(auction.Outcome{RequestID: "4", Winner: &openrtb.Bid{Price: 5}, WinningDSP: "dsp2", ClearingPrice: 5},
+		[]dispatcher.Result{
+			{DSPName: "dsp1", Arm: dispatcher.ArmB, Response: bid(1), Latency: 30 * time.Millisecond},
+			{DSPName: "dsp2", Response: bid(5)},
+		})
+	c.RecordAuction(auction.Outcome{RequestID: "5", WinnerIndex: -1},
+		[]dispatcher.Result{{DSPName: "dsp1", Arm: dispatcher.ArmB, Error: errors.New("timeout"), Latency: 30 * time.Millisecond}})
+
+	snap := c.Snapshot()
+	if snap.DSPStats["dsp2"].AB != nil {
+		t.Error("dsp2 has A/B stats without a split")
+	}
+	ab := snap.DSPStats["dsp1"].AB
+	if ab == nil {
+		t.Fatal("dsp1 AB = nil")
+	}
+	if ab.A.Requests != 2 || ab.A.Bids != 1 || ab.A.Wins != 1 || ab.A.Spend != 2 {
+		t.Errorf("A = %+v, want 2 requests, 1 bid, 1 win, 2 spend", ab.A)
+	}
+	if ab.B.Requests != 3 || ab.B.Bids != 2 || ab.B.Wins != 1 || ab.B.Errors != 1 || ab.B.AvgBid != 2 {
+		t.Errorf("B = %+v, want 3 requests, 2 bids, 1 win, 1 error, avg bid 2", ab.B)
+	}
+	if ab.WinRateDelta != -0.5 || ab.P50Delta <= 0 {
+		t.Errorf("WinRateDelta = %v, P50Delta = %v, want -0.5 and positive", ab.WinRateDelta, ab.P50Delta)
+	}
+}
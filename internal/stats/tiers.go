@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/httpclient"
+)
+
+// defaultLatencyTiers are the tier bounds used unless WithLatencyTiers
+// sets others.
+var defaultLatencyTiers = []time.Duration{20 * time.Millisecond, 50 * time.Millisecond, 80 * time.Millisecond}
+
+// WithLatencyTiers sets the upper bounds of the per-DSP latency tiers.
+// With a tmax, a further tier runs from the last bound to tmax, and
+// responses slower than tmax land in the final timeout tier along with
+// calls that timed out; without one, the final tier holds everything
+// above the last bound. Bounds at or beyond tmax are ignored, and nil
+// bounds keep the default 20ms, 50ms, and 80ms.
+func WithLatencyTiers(bounds []time.Duration, tmax time.Duration) Option {
+	return func(c *Collector) {
+		if bounds == nil {
+			bounds = defaultLatencyTiers
+		}
+		c.tierBounds = latencyTierBounds(bounds, tmax)
+		c.tierTimeout = tmax > 0
+	}
+}
+
+// latencyTierBounds sorts bounds, drops those outside (0, tmax), and
+// appends tmax if set.
+func latencyTierBounds(bounds []time.Duration, tmax time.Duration) []time.Duration {
+	var out []time.Duration
+	for _, b := range bounds {
+		if b > 0 && (tmax <= 0 || b < tmax) {
+			out = append(out, b)
+		}
+	}
+	slices.Sort(out)
+	out = slices.Compact(out)
+	if tmax > 0 {
+		out = append(out, tmax)
+	}
+	return out
+}
+
+// latencyTier returns the index of the tier r falls in: the first whose
+// bound exceeds its latency, or the final tier for slower responses and
+// timeouts.
+func (c *Collector) latencyTier(r dispatcher.Result) int {
+	if isTimeout(r.Error) {
+		return len(c.tierBounds)
+	}
+	for i, bound := range c.tierBounds {
+		if r.Latency < bound || (c.tierTimeout && i == len(c.tierBounds)-1 && r.Latency == bound) {
+			return i
+		}
+	}
+	return len(c.tierBounds)
+}
+
+// isTimeout reports whether err is a DSP call running out of time.
+func isTimeout(err error) bool {
+	var te *httpclient.TimeoutError
+	return errors.As(err, &te) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// latencyTiers builds the snapshot of a DSP's tier counts.
+func (c *Collector) latencyTiers(counts []uint64) []LatencyTier {
+	var total uint64
+	for _, n := range counts {
+		total += n
+	}
+	tiers := make([]LatencyTier, len(c.tierBounds)+1)
+	for i := range tiers {
+		var lower time.Duration
+		if i > 0 {
+			lower = c.tierBounds[i-1]
+		}
+		tier := LatencyTier{}
+		switch {
+		case i == len(c.tierBounds) && c.tierTimeout:
+			tier.Label = "timeout"
+		case i == len(c.tierBounds):
+			tier.Label = fmt.Sprintf(">%v", lower)
+		case i == 0:
+			tier.Label = fmt.Sprintf("<%v", c.tierBounds[i])
+			tier.UpTo = c.tierBounds[i]
+		default:
+			tier.Label = fmt.Sprintf("%v-%v", lower, c.tierBounds[i])
+			tier.UpTo = c.tierBounds[i]
+		}
+		if i < len(counts) {
+			tier.Count = counts[i]
+		}
+		if total > 0 {
+			tier.Share = float64(tier.Count) / float64(total)
+		}
+		tiers[i] = tier
+	}
+	return tiers
+}
+
+// LatencyTier counts a DSP's responses within one latency band, giving
+// the shape of its latency without a full histogram. Tiers are ordered
+// fastest first; the last is open-ended, with a zero UpTo, and is labeled
+// "timeout" when the collector knows tmax.
+type LatencyTier struct {
+	Label string        // e.g. "<20ms", "20ms-50ms", "timeout"
+	UpTo  time.Duration // exclusive upper bound; the tmax tier includes tmax
+	Count uint64
+	Share float64 // Count over all of the DSP's tiered responses
+}
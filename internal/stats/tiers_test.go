@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+)
+
+func recordLatencies(c *Collector, dsp string, latencies ...time.Duration) {
+	results := make([]dispatcher.Result, len(latencies))
+	for i, l := range latencies {
+		results[i] = dispatcher.Result{DSPName: dsp, Latency: l}
+	}
+	c.RecordAuction(auction.Outcome{}, results)
+}
+
+func TestLatencyTiers_Default(t *testing.T) {
+	c := New(WithLatencyTiers(nil, 100*time.Millisecond))
+	recordLatencies(c, "dsp1",
+		5*time.Millisecond, 19*time.Millisecond, // <20ms
+		20*time.Millisecond,                       // 20ms-50ms
+		79*time.Millisecond,                       // 50ms-80ms
+		80*time.Millisecond, 100*time.Millisecond, // 80ms-100ms, tmax inclusive
+		101*time.Millisecond, // timeout
+	)
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 30 * time.Millisecond, Error: context.DeadlineExceeded}, // timeout
+		{DSPName: "dsp1", Skipped: dispatcher.SkipQPSLimit},                                // not tiered
+	})
+
+	dsp := c.Snapshot().DSPStats["dsp1"]
+	if dsp.Timeouts != 1 {
+		t.Errorf("Timeouts = %d, want 1 (slow responses are not errors)", dsp.Timeouts)
+	}
+	tiers := dsp.Tiers
+	want := []struct {
+		label string
+		count uint64
+	}{
+		{"<20ms", 2}, {"20ms-50ms", 1}, {"50ms-80ms", 1}, {"80ms-100ms", 2}, {"timeout", 2},
+	}
+	if len(tiers) != len(want) {
+		t.Fatalf("tiers = %+v, want %d tiers", tiers, len(want))
+	}
+	for i, w := range want {
+		if tiers[i].Label != w.label || tiers[i].Count != w.count {
+			t.Errorf("tier %d = %s: %d, want %s: %d", i, tiers[i].Label, tiers[i].Count, w.label, w.count)
+		}
+	}
+	if tiers[0].Share != 0.25 || tiers[4].UpTo != 0 || tiers[3].UpTo != 100*time.Millisecond {
+		t.Errorf("tiers = %+v", tiers)
+	}
+}
+
+func TestLatencyTiers_Custom(t *testing.T) {
+	// 150ms is past tmax and dropped; duplicates collapse
+	c := New(WithLatencyTiers([]time.Duration{150 * time.Millisecond, 40 * time.Millisecond, 10 * time.Millisecond, 40 * time.Millisecond}, 120*time.Millisecond))
+	recordLatencies(c, "dsp1", 5*time.Millisecond, 130*time.Millisecond)
+
+	var labels []string
+	for _, tier := range c.Snapshot().DSPStats["dsp1"].Tiers {
+		labels = append(labels, tier.Label)
+	}
+	want := []string{"<10ms", "10ms-40ms", "40ms-120ms", "timeout"}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("labels = %v, want %v", labels, want)
+			break
+		}
+	}
+}
+
+func TestLatencyTiers_WithoutTmax(t *testing.T) {
+	c := New()
+	recordLatencies(c, "dsp1", 500*time.Millisecond)
+	c.RecordAuction(auction.Outcome{}, []dispatcher.Result{
+		{DSPName: "dsp1", Latency: time.Millisecond, Error: errors.New("connection refused")},
+	})
+
+	tiers := c.Snapshot().DSPStats["dsp1"].Tiers
+	if len(tiers) != 4 || tiers[3].Label != ">80ms" || tiers[3].Count != 1 || tiers[0].Count != 1 {
+		t.Errorf("tiers = %+v, want 1 in <20ms and 1 in >80ms", tiers)
+	}
+}
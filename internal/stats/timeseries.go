@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"slices"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+)
+
+// defaultTimeSeriesWindow is how much per-second history is kept.
+const defaultTimeSeriesWindow = 10 * time.Minute
+
+// secondStats accumulates the auctions of one second of the collector's
+// clock.
+type secondStats struct {
+	second       int64 // Unix time; zero marks an unused slot
+	requests     uint64
+	wins         uint64
+	revenue      float64
+	responses    uint64
+	totalLatency time.Duration
+	anomalies    []Anomaly
+}
+
+// timeSeries is a ring of per-second stats covering the window. A slot
+// is reused once its second falls out of the window.
+type timeSeries struct {
+	seconds []secondStats
+	last    int64 // the second last recorded in
+}
+
+func newTimeSeries(window time.Duration) timeSeries {
+	return timeSeries{seconds: make([]secondStats, max(int(window/time.Second), 1))}
+}
+
+// record adds an auction completed at now. If that is the first auction
+// of a new second, it returns the second that ended, or 0 if none had
+// been recorded.
+func (ts *timeSeries) record(now time.Time, outcome auction.Outcome, results []dispatcher.Result) (ended int64) {
+	sec := now.Unix()
+	if sec != ts.last {
+		ended, ts.last = ts.last, sec
+	}
+	s := &ts.seconds[sec%int64(len(ts.seconds))]
+	if s.second != sec {
+		*s = secondStats{second: sec}
+	}
+
+	s.requests++
+	if outcome.Winner != nil {
+		s.wins++
+		s.revenue += outcome.ClearingPrice
+	}
+	for _, r := range results {
+		if r.Skipped == dispatcher.SkipNone {
+			s.responses++
+			s.totalLatency += r.Latency
+		}
+	}
+	return ended
+}
+
+// slot returns the stats of sec, or nil if it is not in the window or
+// had no auctions.
+func (ts *timeSeries) slot(sec int64) *secondStats {
+	s := &ts.seconds[sec%int64(len(ts.seconds))]
+	if s.second != sec {
+		return nil
+	}
+	return s
+}
+
+// points returns one point per second over the last seconds up to and
+// including now's, oldest first. Seconds without auctions are zero.
+func (ts *timeSeries) points(now time.Time, last int) []TimePoint {
+	last = min(last, len(ts.seconds))
+	end := now.Unix()
+	points := make([]TimePoint, 0, last)
+	for sec := end - int64(last) + 1; sec <= end; sec++ {
+		p := TimePoint{Time: time.Unix(sec, 0).UTC()}
+		if s := ts.seconds[sec%int64(len(ts.seconds))]; s.second == sec {
+			p.Requests = s.requests
+			p.Wins = s.wins
+			p.Revenue = s.revenue
+			if s.responses > 0 {
+				p.AvgLatency = s.totalLatency / time.Duration(s.responses)
+			}
+			p.Anomalies = slices.Clone(s.anomalies)
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// WithTimeSeriesWindow sets how much per-second history TimeSeries can
+// return. The default is ten minutes.
+func WithTimeSeriesWindow(d time.Duration) Option {
+	return func(c *Collector) {
+		if d >= time.Second {
+			c.series = newTimeSeries(d)
+		}
+	}
+}
+
+// TimeSeries returns per-second stats for the last d of the collector's
+// clock, oldest first, capped at the configured window. Every second is
+// present, so gaps in traffic show as zeros.
+func (c *Collector) TimeSeries(d time.Duration) []TimePoint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.series.points(c.clock.Now(), int(d/time.Second))
+}
+
+// TimePoint summarizes the auctions completed in one second.
+type TimePoint struct {
+	Time       time.Time // start of the second
+	Requests   uint64
+	Wins       uint64
+	Revenue    float64
+	AvgLatency time.Duration // mean DSP response latency
+	Anomalies  []Anomaly     // see WithAnomalyDetection
+}
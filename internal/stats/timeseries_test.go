@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_TimeSeries(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewManual(start)
+	c := New(WithClock(clk), WithTimeSeriesWindow(5*time.Second))
+
+	win := auction.Outcome{Winner: &openrtb.Bid{Price: 2}, WinningDSP: "dsp1", ClearingPrice: 1.5}
+	results := []dispatcher.Result{
+		{DSPName: "dsp1", Latency: 10 * time.Millisecond},
+		{DSPName: "dsp2", Latency: 30 * time.Millisecond},
+		{DSPName: "dsp3", Skipped: dispatcher.SkipCircuitOpen},
+	}
+	c.RecordAuction(win, results)
+	c.RecordAuction(auction.Outcome{}, results)
+	clk.Advance(2 * time.Second) // nothing recorded in between
+	c.RecordAuction(win, results[:1])
+
+	points := c.TimeSeries(3 * time.Second)
+	if len(points) != 3 {
+		t.Fatalf("len(TimeSeries(3s)) = %d, want 3", len(points))
+	}
+	first, gap, last := points[0], points[1], points[2]
+	if !first.Time.Equal(start) || !last.Time.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Time = %v ... %v, want %v ... +2s", first.Time, last.Time, start)
+	}
+	if first.Requests != 2 || first.Wins != 1 || first.Revenue != 1.5 || first.AvgLatency != 20*time.Millisecond {
+		t.Errorf("first second = %+v, want 2 requests, 1 win, 1.5 revenue, 20ms", first)
+	}
+	if gap.Requests != 0 || gap.AvgLatency != 0 {
+		t.Errorf("gap second = %+v, want zeros", gap)
+	}
+	if last.Requests != 1 || last.Wins != 1 || last.AvgLatency != 10*time.Millisecond {
+		t.Errorf("last second = %+v, want 1 request, 1 win, 10ms", last)
+	}
+
+	// Requests beyond the window are capped, and old seconds age out
+	if n := len(c.TimeSeries(time.Hour)); n != 5 {
+		t.Errorf("len(TimeSeries(1h)) = %d, want the 5s window", n)
+	}
+	clk.Advance(5 * time.Second)
+	c.RecordAuction(auction.Outcome{}, nil)
+	for _, p := range c.TimeSeries(time.Hour)[:4] {
+		if p.Requests != 0 {
+			t.Errorf("%v: Requests = %d after aging out, want 0", p.Time, p.Requests)
+		}
+	}
+
+	c.Reset()
+	for _, p := range c.TimeSeries(time.Hour) {
+		if p.Requests != 0 {
+			t.Errorf("%v: Requests = %d after Reset, want 0", p.Time, p.Requests)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package stats
+
+import "time"
+
+// tlsStatsInternal tracks HTTPS traffic and TLS handshakes for a single DSP.
+type tlsStatsInternal struct {
+	requests   uint64
+	latency    Histogram
+	handshakes uint64
+	resumed    uint64
+	handshake  Histogram
+}
+
+func (t *tlsStatsInternal) snapshot() TLSStats {
+	ts := TLSStats{
+		Requests:   t.requests,
+		Latency:    t.latency.Percentiles(),
+		Handshakes: t.handshakes,
+		Resumed:    t.resumed,
+		Handshake:  t.handshake.Percentiles(),
+	}
+	if t.handshakes > 0 {
+		ts.ResumptionRate = float64(t.resumed) / float64(t.handshakes)
+	}
+	return ts
+}
+
+// RecordTLSHandshake records a TLS handshake made to reach a DSP.
+func (c *Collector) RecordTLSHandshake(dspName string, resumed bool, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tls := &c.getOrCreateDSP(dspName).tls
+	tls.handshakes++
+	if resumed {
+		tls.resumed++
+	}
+	tls.handshake.Record(d)
+}
+
+// TLSStats summarizes a DSP's HTTPS traffic. Handshakes happen only when
+// a new connection is opened, so their cost is spread over the requests
+// that reuse it; comparing Latency with the DSP's overall latency shows
+// the net overhead.
+type TLSStats struct {
+	Requests       uint64             // requests sent over HTTPS, also counted in Requests
+	Latency        LatencyPercentiles // latency of HTTPS requests, handshakes included
+	Handshakes     uint64
+	Resumed        uint64  // handshakes that resumed an earlier session
+	ResumptionRate float64 // Resumed / Handshakes
+	Handshake      LatencyPercentiles
+}
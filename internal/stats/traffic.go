@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"strconv"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+// maxTrackedUsers bounds the user IDs remembered for the repeat rate.
+// Once reached, IDs not yet seen count as new users without being
+// remembered, so very long runs overstate distinct users and understate
+// repeats.
+const maxTrackedUsers = 1 << 20
+
+// trafficStatsInternal fingerprints the generated requests, so the
+// synthetic traffic can be checked against a test plan.
+type trafficStatsInternal struct {
+	requests     uint64
+	sizes        topCounter
+	countries    topCounter
+	floors       priceHistogram
+	users        map[string]struct{}
+	userRequests uint64 // requests carrying a user ID
+	repeats      uint64 // requests from a user seen earlier
+}
+
+// TrafficStats summarizes the generated requests: their first
+// impression's size and floor, their device's country, and how often
+// users recur.
+type TrafficStats struct {
+	Requests   uint64
+	Sizes      []KeyCount // "WxH", most frequent first
+	Countries  []KeyCount // most frequent first
+	Floors     PriceStats
+	Users      uint64  // distinct user IDs
+	RepeatRate float64 // share of requests with a user ID from a user seen earlier
+}
+
+// RecordRequest adds a generated request to the traffic fingerprint.
+func (c *Collector) RecordRequest(req *openrtb.BidRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &c.traffic
+	t.requests++
+	if len(req.Imp) > 0 {
+		imp := &req.Imp[0]
+		if w, h := impSize(imp); w > 0 && h > 0 {
+			t.sizes.add(strconv.Itoa(w) + "x" + strconv.Itoa(h))
+		}
+		t.floors.Record(imp.BidFloor)
+	}
+	if d := req.Device; d != nil && d.Geo != nil && d.Geo.Country != "" {
+		t.countries.add(d.Geo.Country)
+	}
+	if id := userID(req); id != "" {
+		t.userRequests++
+		if _, seen := t.users[id]; seen {
+			t.repeats++
+		} else if len(t.users) < maxTrackedUsers {
+			if t.users == nil {
+				t.users = make(map[string]struct{})
+			}
+			t.users[id] = struct{}{}
+		}
+	}
+}
+
+// impSize returns the size of an impression's banner or video.
+func impSize(imp *openrtb.Imp) (w, h int) {
+	switch {
+	case imp.Banner != nil:
+		return imp.Banner.W, imp.Banner.H
+	case imp.Video != nil:
+		return imp.Video.W, imp.Video.H
+	}
+	return 0, 0
+}
+
+// userID identifies the request's user by user ID, falling back to the
+// device's advertising ID.
+func userID(req *openrtb.BidRequest) string {
+	if req.User != nil && req.User.ID != "" {
+		return req.User.ID
+	}
+	if req.Device != nil {
+		return req.Device.IFA
+	}
+	return ""
+}
+
+func (t *trafficStatsInternal) snapshot() TrafficStats {
+	ts := TrafficStats{
+		Requests:  t.requests,
+		Sizes:     t.sizes.top(maxTrackedKeys),
+		Countries: t.countries.top(maxTrackedKeys),
+		Floors:    t.floors.snapshot(),
+		Users:     t.userRequests - t.repeats,
+	}
+	if t.userRequests > 0 {
+		ts.RepeatRate = float64(t.repeats) / float64(t.userRequests)
+	}
+	return ts
+}
@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/cass/rtb-simulator/pkg/openrtb"
+)
+
+func TestCollector_RecordRequest(t *testing.T) {
+	c := New()
+
+	request := func(size [2]int, floor float64, country, user string) *openrtb.BidRequest {
+		return &openrtb.BidRequest{
+			Imp:    []openrtb.Imp{{Banner: &openrtb.Banner{W: size[0], H: size[1]}, BidFloor: floor}},
+			Device: &openrtb.Device{Geo: &openrtb.Geo{Country: country}, IFA: "ifa-" + user},
+		}
+	}
+	c.RecordRequest(request([2]int{300, 250}, 0.4, "USA", "a"))
+	c.RecordRequest(request([2]int{300, 250}, 1.5, "USA", "b"))
+	c.RecordRequest(request([2]int{320, 50}, 2.5, "GBR", "a"))
+	c.RecordRequest(&openrtb.BidRequest{
+		Imp:  []openrtb.Imp{{Video: &openrtb.Video{W: 1920, H: 1080}, BidFloor: 12}},
+		User: &openrtb.User{ID: "ifa-b"},
+	})
+
+	ts := c.Snapshot().Traffic
+	if ts.Requests != 4 {
+		t.Errorf("Requests = %d, want 4", ts.Requests)
+	}
+	if len(ts.Sizes) != 3 || ts.Sizes[0] != (KeyCount{"300x250", 2}) || ts.Sizes[1].Key != "1920x1080" {
+		t.Errorf("Sizes = %v, want 300x250 twice, then 1920x1080 and 320x50", ts.Sizes)
+	}
+	if len(ts.Countries) != 2 || ts.Countries[0] != (KeyCount{"USA", 2}) {
+		t.Errorf("Countries = %v, want USA twice and GBR", ts.Countries)
+	}
+	if ts.Floors.Count != 4 || ts.Floors.Max != 12 || ts.Floors.Buckets[0].Count != 1 {
+		t.Errorf("Floors = %+v, want 4 floors up to 12, one under $0.50", ts.Floors)
+	}
+	// The user ID and the advertising ID identify the same users
+	if ts.Users != 2 || ts.RepeatRate != 0.5 {
+		t.Errorf("Users, RepeatRate = %d, %v; want 2, 0.5", ts.Users, ts.RepeatRate)
+	}
+
+	c.Reset()
+	if ts := c.Snapshot().Traffic; ts.Requests != 0 || ts.Users != 0 || ts.Sizes != nil {
+		t.Errorf("Traffic after Reset() = %+v, want empty", ts)
+	}
+}
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"math"
+)
+
+// priceScale converts prices to integer histogram units (hundredths of a
+// cent), so the log-linear buckets keep ~6% precision down to $0.0001.
+const priceScale = 10000
+
+// priceBucketEdges are the upper bounds of the coarse clearing price
+// buckets reported in snapshots. A final open-ended bucket follows.
+var priceBucketEdges = []float64{0.5, 1, 2, 3, 5, 10, 20}
+
+// priceHistogram records a price distribution using the same log-linear
+// layout as Histogram. Not safe for concurrent use.
+type priceHistogram struct {
+	counts [numBuckets]uint64
+	coarse []uint64
+	count  uint64
+	sum    float64
+	max    float64
+}
+
+// Record adds a single price sample.
+func (h *priceHistogram) Record(price float64) {
+	if price < 0 {
+		price = 0
+	}
+	if h.coarse == nil {
+		h.coarse = make([]uint64, len(priceBucketEdges)+1)
+	}
+
+	h.counts[bucketIndex(uint64(math.Round(price*priceScale)))]++
+	i := 0
+	for i < len(priceBucketEdges) && price > priceBucketEdges[i] {
+		i++
+	}
+	h.coarse[i]++
+	h.count++
+	h.sum += price
+	if price > h.max {
+		h.max = price
+	}
+}
+
+// Quantile returns the approximate price at quantile q (0 < q <= 1),
+// capped at the observed maximum. Returns 0 if the histogram is empty.
+func (h *priceHistogram) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	return min(float64(bucketUpperBound(quantileBucket(&h.counts, h.count, q)))/priceScale, h.max)
+}
+
+// snapshot summarizes the distribution.
+func (h *priceHistogram) snapshot() PriceStats {
+	ps := PriceStats{
+		Count:   h.count,
+		P25:     h.Quantile(0.25),
+		P50:     h.Quantile(0.50),
+		P75:     h.Quantile(0.75),
+		P90:     h.Quantile(0.90),
+		P99:     h.Quantile(0.99),
+		Max:     h.max,
+		Buckets: make([]PriceBucket, len(priceBucketEdges)+1),
+	}
+	if h.count > 0 {
+		ps.Mean = h.sum / float64(h.count)
+	}
+	for i := range ps.Buckets {
+		if i < len(priceBucketEdges) {
+			ps.Buckets[i].UpTo = priceBucketEdges[i]
+		}
+		if h.coarse != nil {
+			ps.Buckets[i].Count = h.coarse[i]
+		}
+	}
+	return ps
+}
+
+// AuctionStats holds publisher-side yield metrics across all auctions.
+type AuctionStats struct {
+	AvgBidsPerAuction float64 // eligible bids per auction (bid density)
+	ContestedAuctions uint64  // auctions with at least two eligible bids
+	AvgPriceGap       float64 // mean first minus second bid, contested auctions only
+	AvgPriceGapPct    float64 // mean gap as a percentage of the second bid
+	PartialAuctions   uint64  // auctions run while the run was stopping
+	CancelledCalls    uint64  // DSP calls cancelled in partial auctions
+	ClearingPrice     PriceStats
+}
+
+// PriceStats summarizes a price distribution in dollars.
+type PriceStats struct {
+	Count   uint64
+	Mean    float64
+	P25     float64
+	P50     float64
+	P75     float64
+	P90     float64
+	P99     float64
+	Max     float64
+	Buckets []PriceBucket
+}
+
+// PriceBucket counts prices at or below UpTo and above the previous
+// bucket's bound. An UpTo of 0 marks the final open-ended bucket.
+type PriceBucket struct {
+	UpTo  float64
+	Count uint64
+}
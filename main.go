@@ -2,30 +2,119 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+
 	"github.com/cass/rtb-simulator/internal/api"
-	"github.com/cass/rtb-simulator/internal/auction"
 	"github.com/cass/rtb-simulator/internal/config"
-	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/currency"
 	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/events"
+	"github.com/cass/rtb-simulator/internal/export"
+	"github.com/cass/rtb-simulator/internal/fuzz"
 	"github.com/cass/rtb-simulator/internal/generator"
 	"github.com/cass/rtb-simulator/internal/generator/scenarios"
-	"github.com/cass/rtb-simulator/internal/stats"
+	"github.com/cass/rtb-simulator/internal/market"
+	"github.com/cass/rtb-simulator/internal/notify"
+	"github.com/cass/rtb-simulator/internal/randutil"
+	"github.com/cass/rtb-simulator/internal/runs"
+	"github.com/cass/rtb-simulator/internal/sink"
 )
 
+// runOptions are the command-line settings that are not part of the
+// configuration file.
+type runOptions struct {
+	configPath      string // reloaded on SIGHUP; empty disables reloading
+	autoStart       bool
+	streamOut       bool
+	streamSample    float64
+	streamResponses bool
+}
+
+// preflightTimeout bounds each DSP endpoint's startup reachability check.
+const preflightTimeout = 5 * time.Second
+
+// exitRegressed is the exit status when a run regressed against the
+// configured baseline, so CI can fail the build on it.
+const exitRegressed = 3
+
+// command is a subcommand, named by the first argument.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+var commands = []command{
+	{"run", "run the simulator and serve its API", runCommand},
+	{"demo", "run against built-in mock DSPs, no configuration needed", runDemo},
+	{"validate-config", "check a configuration file and exit", validateConfigCommand},
+	{"gen", "print generated bid requests", genCommand},
+	{"report", "print a saved run report, optionally against a baseline", reportCommand},
+}
+
 func main() {
-	configPath := flag.String("config", "config.yaml", "path to configuration file")
-	autoStart := flag.Bool("auto-start", false, "automatically start simulation on startup")
-	flag.Parse()
+	if len(os.Args) < 2 || (strings.HasPrefix(os.Args[1], "-") && !isHelp(os.Args[1])) {
+		// Flags alone run the simulator, as before there were subcommands
+		runCommand(os.Args[1:])
+		return
+	}
+
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name == name {
+			c.run(os.Args[2:])
+			return
+		}
+	}
+	if isHelp(name) {
+		usage(os.Stdout)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun %s <command> -h for a command's flags.\n", os.Args[0])
+}
+
+// runCommand loads the configuration file and runs the simulator.
+func runCommand(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "path to configuration file")
+	autoStart := fs.Bool("auto-start", false, "automatically start simulation on startup")
+	streamOut := fs.Bool("stream", false, "write one NDJSON line per auction to stdout")
+	streamSample := fs.Float64("stream-sample", 1.0, "fraction of auctions written by -stream (0-1]")
+	streamResponses := fs.Bool("stream-responses", false, "include full DSP responses in -stream records so they can be replayed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s run [flags]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
 
 	// Load configuration
 	cfg, err := config.Load(*configPath)
@@ -34,10 +123,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	run(cfg, runOptions{
+		configPath:      *configPath,
+		autoStart:       *autoStart,
+		streamOut:       *streamOut,
+		streamSample:    *streamSample,
+		streamResponses: *streamResponses,
+	})
+}
+
+// run wires up the simulator for cfg and serves the API until interrupted
+// or a bounded run completes.
+func run(cfg *config.Config, opts runOptions) {
+	// Keep recent log output for run artifact bundles
+	logBuf := runs.NewLogBuffer(0)
+	log.SetOutput(io.MultiWriter(os.Stderr, logBuf))
+
 	log.Printf("RTB Simulator starting...")
 	log.Printf("  Server port: %d", cfg.Server.Port)
-	log.Printf("  Requests/sec: %d", cfg.Simulation.RequestsPerSecond)
-	log.Printf("  Scenario: %s", cfg.Simulation.Scenario)
+	log.Printf("  Requests/sec: %d (concurrency %d, batch size %d)",
+		cfg.Simulation.RequestsPerSecond, cfg.Simulation.Concurrency, cfg.Simulation.BatchSize)
+	if mix := cfg.Simulation.Scenarios; len(mix) > 0 {
+		parts := make([]string, len(mix))
+		for i, sw := range mix {
+			parts[i] = fmt.Sprintf("%s (weight %g)", sw.Name, sw.Weight)
+		}
+		log.Printf("  Scenario mix: %s", strings.Join(parts, ", "))
+	} else {
+		log.Printf("  Scenario: %s", cfg.Simulation.Scenario)
+	}
 	log.Printf("  Auction type: %s", cfg.Auction.Type)
 	log.Printf("  Timeout: %dms", cfg.Auction.TimeoutMS)
 	log.Printf("  DSPs configured: %d (%d enabled)", len(cfg.DSPs), len(cfg.EnabledDSPs()))
@@ -49,30 +163,315 @@ func main() {
 		}
 		log.Printf("    - %s: %s [%s]", dsp.Name, dsp.Endpoint, status)
 	}
+	if cfg.Market.Enabled() {
+		log.Printf("  Market seats: %d, bidding in-process", len(cfg.Market.Seats))
+		for _, seat := range cfg.Market.Seats {
+			budget := "unlimited budget"
+			if seat.Budget > 0 {
+				budget = fmt.Sprintf("budget $%.2f (%s pacing)", seat.Budget, seat.Pacing)
+			}
+			if fc := seat.FrequencyCap; fc.Enabled() {
+				budget += fmt.Sprintf(", %d wins per user", fc.Impressions)
+				if fc.Window > 0 {
+					budget += " per " + fc.Window.String()
+				}
+			}
+			log.Printf("    - %s: %s, %s", seat.Name, seat.Strategy, budget)
+		}
+	}
 
 	// Initialize components
-	scenario := createScenario(cfg.Simulation.Scenario)
-	gen := generator.New(scenario,
-		generator.WithTimeout(cfg.Auction.TimeoutMS),
-	)
+	if cfg.Simulation.Seed != 0 {
+		randutil.Seed(cfg.Simulation.Seed)
+		log.Printf("  Random seed: %d", cfg.Simulation.Seed)
+	}
+
+	teardown := newShutdownSequence(cfg.Shutdown)
+	var rates *currency.Table
+	var err error
+	if cc := cfg.Currency; cc.Enabled() {
+		rates, err = currency.NewTable(cc.Base, cc.Rates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in currency: %v\n", err)
+			os.Exit(1)
+		}
+		if cc.URL != "" {
+			refresher, err := currency.NewRefresher(rates, cc.URL, cc.Refresh)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading currency rates: %v\n", err)
+				os.Exit(1)
+			}
+			teardown.add(stageCloseClients, "currency refresher", func(context.Context) { refresher.Close() })
+		}
+		log.Printf("  Currency: bids converted to %s (%d rates)", cc.Base, len(rates.Rates())-1)
+	}
+
+	// The user pool, frequency caps, and seat spend of every simulation
+	// share one store, saved on shutdown when it has a snapshot file
+	store, err := openState(cfg.State, simulationClock(cfg.Simulation))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading state: %v\n", err)
+		os.Exit(1)
+	}
+	teardown.add(stageCloseClients, "state store", func(context.Context) {
+		if err := store.Close(); err != nil {
+			log.Printf("Error saving state: %v", err)
+		}
+	})
+	if cfg.State.Path != "" {
+		log.Printf("  State: %s (%d entries)", cfg.State.Path, store.Len())
+	}
+
+	// Exporters and alerting consume engine, dispatcher, and stats events
+	// from the bus, off the auction hot path
+	bus := events.New()
+	teardown.add(stageFlushExporters, "event bus", func(context.Context) {
+		// Deliver the last events before the exporters close
+		bus.Close()
+		for name, n := range bus.Dropped() {
+			log.Printf("Event subscriber %s missed %d events", name, n)
+		}
+	})
+
+	sim, err := buildSimulation(cfg, "", bus, rates, store, log.Printf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in %v\n", err)
+		os.Exit(1)
+	}
+	gen, disp, collector, simClock := sim.gen, sim.disp, sim.collector, sim.clock
+	teardown.add(stageCloseClients, "dispatcher", func(context.Context) { disp.Close() })
+	var engineOpts []engine.Option
+
+	if cfg.Notifications.Enabled {
+		notifier := notify.New(collector,
+			notify.WithCurrency(cfg.Currency.BaseCurrency()),
+			notify.WithTimeout(time.Duration(cfg.Notifications.TimeoutMS)*time.Millisecond),
+			notify.WithWorkers(cfg.Notifications.Workers),
+			notify.WithQueueSize(cfg.Notifications.QueueSize),
+		)
+		teardown.add(stageFlushNotifications, "notifier", func(context.Context) { notifier.Close() })
+		engineOpts = append(engineOpts, engine.WithNotifier(notifier))
+		log.Printf("  Notifications: enabled (%d workers)", cfg.Notifications.Workers)
+	}
+
+	if opts.streamOut {
+		if opts.streamSample <= 0 || opts.streamSample > 1 {
+			fmt.Fprintf(os.Stderr, "Error: -stream-sample must be in (0, 1]\n")
+			os.Exit(1)
+		}
+		streamOpts := []export.StreamOption{export.WithSampleRate(opts.streamSample), export.WithLossless(), export.WithStreamClock(simClock)}
+		if opts.streamResponses {
+			streamOpts = append(streamOpts, export.WithResponses())
+		}
+		stream := export.NewStream(os.Stdout, streamOpts...)
+		teardown.add(stageFlushExporters, "auction stream", func(context.Context) { stream.Close() })
+		// File exporters keep every sampled auction, slowing the run if
+		// they must
+		engine.SubscribeObserver(bus, "stream", stream, events.Blocking())
+		log.Printf("  Streaming auctions to stdout (sample rate %.2f)", opts.streamSample)
+	}
 
-	disp := dispatcher.New(cfg.EnabledDSPs(),
-		dispatcher.WithTimeout(time.Duration(cfg.Auction.TimeoutMS)*time.Millisecond),
-	)
-	defer disp.Close()
+	if bl := cfg.BidLog; bl.Enabled() {
+		var f *os.File
+		w := io.Writer(os.Stdout)
+		if bl.Path != "-" {
+			f, err = os.Create(bl.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error in bid_log: %v\n", err)
+				os.Exit(1)
+			}
+			w = f
+		}
+		bidLog := export.NewBidLog(w, export.WithBidLogSampleRate(bl.SampleRate), export.WithBidLogLossless(), export.WithBidLogClock(simClock))
+		teardown.add(stageFlushExporters, "bid log", func(context.Context) {
+			bidLog.Close()
+			if f != nil {
+				f.Close()
+			}
+		})
+		engine.SubscribeObserver(bus, "bid-log", bidLog, events.Blocking())
+		log.Printf("  Bid log: %s (sample rate %.2f)", bl.Path, bl.SampleRate)
+	}
 
-	auc := auction.NewFirstPrice()
-	collector := stats.New()
+	if rs := cfg.ResultSink; rs.Enabled() {
+		w, err := openResultSink(rs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in result_sink: %v\n", err)
+			os.Exit(1)
+		}
+		resultSink := sink.New(w,
+			sink.WithBatchSize(rs.BatchSize),
+			sink.WithFlushInterval(rs.FlushInterval),
+			sink.WithQueueSize(rs.QueueSize),
+			sink.WithClock(simClock),
+		)
+		teardown.add(stageFlushExporters, "result sink", func(context.Context) {
+			if err := resultSink.Close(); err != nil {
+				log.Printf("Result sink close error: %v", err)
+			}
+			if n := resultSink.Dropped() + resultSink.Failed(); n > 0 {
+				log.Printf("Result sink lost %d records (%d dropped, %d failed writes)", n, resultSink.Dropped(), resultSink.Failed())
+			}
+		})
+		engine.SubscribeObserver(bus, "result-sink", resultSink)
+		log.Printf("  Result sink: %s table %s", rs.Type, rs.Table)
+	}
 
-	eng := engine.New(gen, disp, auc, collector,
-		engine.WithRPS(cfg.Simulation.RequestsPerSecond),
-	)
+	if rc := cfg.Rollups; rc.Enabled() {
+		f, err := os.Create(rc.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in rollups: %v\n", err)
+			os.Exit(1)
+		}
+		rollup := export.NewRollup(f, export.WithRollupClock(simClock), export.WithRollupInterval(rc.Interval))
+		teardown.add(stageFlushExporters, "rollups", func(context.Context) {
+			if err := rollup.Close(); err != nil {
+				log.Printf("Rollup write error: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				log.Printf("Rollup close error: %v", err)
+			}
+		})
+		// Each auction lands in the minute it completed, and none is
+		// dropped from the counts
+		engine.SubscribeObserver(bus, "rollups", rollup, events.Blocking())
+		log.Printf("  Rollups: %s every %v", rc.Path, rc.Interval)
+	}
+
+	if kc := cfg.Kafka; kc.Enabled() {
+		conn, err := kafkaConn(kc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in kafka: %v\n", err)
+			os.Exit(1)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		kafka, err := sink.OpenKafka(ctx, conn,
+			sink.KafkaTopics{Requests: kc.Topics.Requests, Responses: kc.Topics.Responses, Outcomes: kc.Topics.Outcomes},
+			sink.Format(kc.Format),
+			sink.WithBatchSize(kc.BatchSize),
+			sink.WithFlushInterval(kc.FlushInterval),
+			sink.WithQueueSize(kc.QueueSize),
+			sink.WithClock(simClock),
+		)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in kafka: %v\n", err)
+			os.Exit(1)
+		}
+		teardown.add(stageFlushExporters, "kafka", func(context.Context) {
+			if err := kafka.Close(); err != nil {
+				log.Printf("Kafka close error: %v", err)
+			}
+			if kafka.Dropped()+kafka.Failed() > 0 {
+				log.Printf("Kafka export lost %d auctions and %d messages", kafka.Dropped(), kafka.Failed())
+			}
+		})
+		engine.SubscribeObserver(bus, "kafka", kafka)
+		log.Printf("  Kafka export: %s to %v", kc.Format, kc.Brokers)
+	}
+
+	feed := market.New()
+	engine.SubscribeObserver(bus, "market-feed", feed)
+
+	sampler := export.NewSampler(export.WithSamplerClock(simClock))
+	engine.SubscribeObserver(bus, "auction-sampler", sampler)
+	engineOpts = append(engineOpts, engine.WithRunListener(sampler))
+
+	runOpts := []runs.Option{
+		runs.WithLogBuffer(logBuf),
+		runs.WithReportDir(cfg.Report.Dir),
+		runs.WithClock(simClock),
+	}
+	if cfg.Report.Dir != "" {
+		log.Printf("  Run reports: %s", cfg.Report.Dir)
+	}
+	if b := cfg.Report.Baseline; b.Enabled() {
+		runOpts = append(runOpts, runs.WithBaseline(b.Path(), b.Update, runs.Thresholds{
+			WinRateDrop:       b.MaxWinRateDrop,
+			ECPMDrop:          b.MaxECPMDrop,
+			LatencyIncrease:   b.MaxLatencyIncrease,
+			ErrorRateIncrease: b.MaxErrorRateIncrease,
+		}))
+		log.Printf("  Baseline: %s", b.Path())
+	}
+	registry := runs.NewRegistry(collector, cfg, runOpts...)
+	engineOpts = append(engineOpts, engine.WithRunListener(registry))
+	engine.SubscribeObserver(bus, "run-outliers", registry)
+
+	eng := sim.newEngine(engineOpts...)
+	teardown.add(stageStopGeneration, "simulation", func(context.Context) {
+		if eng.IsRunning() {
+			log.Printf("Stopping simulation...")
+		}
+		eng.StopGenerating()
+	})
+	teardown.add(stageDrainAuctions, "simulation", func(ctx context.Context) { _ = eng.Wait(ctx) })
+
+	var sims *engine.Manager
+	if len(cfg.Simulations) > 0 {
+		log.Printf("  Named simulations: %d", len(cfg.Simulations))
+		if sims, err = newSimulations(cfg, rates, store); err != nil {
+			fmt.Fprintf(os.Stderr, "Error in %v\n", err)
+			os.Exit(1)
+		}
+		teardown.add(stageStopGeneration, "named simulations", func(context.Context) { sims.StopGenerating() })
+		teardown.add(stageDrainAuctions, "named simulations", func(ctx context.Context) { _ = sims.Wait(ctx) })
+		teardown.add(stageCloseClients, "named simulations", func(context.Context) { sims.Close() })
+	}
 
 	// Create API server
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
-	srv := api.New(eng, collector, cfg,
+	apiOpts := []api.Option{
 		api.WithAddr(addr),
-	)
+		api.WithMarketFeed(feed),
+		api.WithAuctionSampler(sampler),
+		api.WithRuns(registry),
+		api.WithDSPManager(disp),
+		api.WithReachability(disp),
+		api.WithHealth(disp),
+	}
+	if cfg.Simulation.RequestBuffer > 0 {
+		apiOpts = append(apiOpts, api.WithRequestBuffer(eng))
+	}
+	if sims != nil {
+		apiOpts = append(apiOpts, api.WithSimulations(sims))
+	}
+	if adminAddr := cfg.Server.AdminAddr(); adminAddr != "" {
+		apiOpts = append(apiOpts, api.WithAdminAddr(adminAddr))
+	}
+	if a := cfg.Server.Auth; a.Enabled() {
+		apiOpts = append(apiOpts, api.WithAuth(a.APIKey, a.Username, a.Password))
+		log.Printf("  Control API authentication: enabled")
+	}
+	if cfg.Debug.Endpoints {
+		apiOpts = append(apiOpts, api.WithDebug())
+		log.Printf("  Debug endpoints: /debug/pprof/, /debug/runtime")
+	}
+	var reload *reloader
+	if opts.configPath != "" {
+		reload = &reloader{path: opts.configPath, eng: eng, gen: gen, disp: disp, cfg: cfg}
+		apiOpts = append(apiOpts, api.WithConfigReloader(reload))
+	}
+	srv := api.New(eng, collector, cfg, apiOpts...)
+	teardown.add(stageStopGeneration, "API server", func(ctx context.Context) {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Server shutdown error: %v", err)
+		}
+	})
+	if reload != nil {
+		reload.onReload = []func(*config.Config){registry.SetConfig, srv.SetConfig}
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if _, err := reload.Reload(); err != nil {
+					log.Printf("Config reload failed: %v", err)
+				}
+			}
+		}()
+		log.Printf("  Config reload: SIGHUP or POST /config/reload")
+	}
 
 	// Handle graceful shutdown
 	shutdown := make(chan os.Signal, 1)
@@ -81,13 +480,16 @@ func main() {
 	// Start API server
 	go func() {
 		log.Printf("API server listening on %s", addr)
+		if adminAddr := cfg.Server.AdminAddr(); adminAddr != "" {
+			log.Printf("Admin API listening on %s", adminAddr)
+		}
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Printf("Server error: %v", err)
 		}
 	}()
 
 	// Auto-start simulation if requested
-	if *autoStart {
+	if opts.autoStart {
 		log.Printf("Auto-starting simulation...")
 		if err := eng.Start(); err != nil {
 			log.Printf("Failed to start simulation: %v", err)
@@ -98,23 +500,17 @@ func main() {
 		log.Printf("Simulation ready. POST /start to begin.")
 	}
 
-	// Wait for shutdown signal
-	sig := <-shutdown
-	log.Printf("Received signal %v, shutting down...", sig)
-
-	// Stop simulation if running
-	if eng.IsRunning() {
-		log.Printf("Stopping simulation...")
-		eng.Stop()
+	// Wait for shutdown signal or a bounded run to finish
+	select {
+	case sig := <-shutdown:
+		log.Printf("Received signal %v, shutting down...", sig)
+	case <-eng.Completed():
+		log.Printf("Simulation reached its run limit, shutting down...")
 	}
 
-	// Shutdown API server
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
-	}
+	// Stop generating, let the auctions underway finish, and deliver their
+	// notifications and exports before closing clients
+	teardown.run()
 
 	// Print final stats
 	snap := collector.Snapshot()
@@ -125,17 +521,181 @@ func main() {
 	log.Printf("  Total no-bids: %d", snap.TotalNoBids)
 	log.Printf("  Total errors: %d", snap.TotalErrors)
 	log.Printf("  Total revenue: $%.4f", snap.TotalRevenue)
+	log.Printf("  Latency p50/p95/p99: %v / %v / %v", snap.Latency.P50, snap.Latency.P95, snap.Latency.P99)
+	if sim.seats != nil {
+		for _, seat := range sim.seats.Seats() {
+			log.Printf("  Seat %s spent: $%.4f", seat.Name, seat.Spent)
+		}
+	}
+
+	if path := cfg.Report.Summary; path != "" {
+		if err := registry.WriteSummary(path); err != nil {
+			log.Printf("Summary write error: %v", err)
+		} else {
+			log.Printf("Summary written to %s", path)
+		}
+	}
 
+	if registry.Regressed() {
+		log.Printf("Shutdown complete: runs regressed against the baseline")
+		os.Exit(exitRegressed)
+	}
 	log.Printf("Shutdown complete")
 }
 
-// createScenario returns the appropriate scenario based on name.
-func createScenario(name string) generator.Scenario {
+// latencyTiers converts tier bounds in milliseconds, keeping nil for the
+// collector's defaults.
+func latencyTiers(ms []int) []time.Duration {
+	if len(ms) == 0 {
+		return nil
+	}
+	bounds := make([]time.Duration, len(ms))
+	for i, v := range ms {
+		bounds[i] = time.Duration(v) * time.Millisecond
+	}
+	return bounds
+}
+
+// newFuzzer returns the fuzzer for the configured rate and classes.
+func newFuzzer(fc config.FuzzConfig) *fuzz.Fuzzer {
+	classes := make([]fuzz.Class, len(fc.Classes))
+	for i, c := range fc.Classes {
+		classes[i] = fuzz.Class(c)
+	}
+	return fuzz.New(fc.Rate, classes...)
+}
+
+// openResultSink connects the configured result sink's writer.
+func openResultSink(rs config.ResultSinkConfig) (sink.Writer, error) {
+	if rs.Type == config.SinkPostgres {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return sink.OpenPostgres(ctx, rs.URL, rs.Table)
+	}
+	return sink.NewClickHouse(rs.URL, rs.Table)
+}
+
+// kafkaConn returns the connection settings of the configured Kafka
+// cluster, reading its CA file if set.
+func kafkaConn(kc config.KafkaConfig) (sink.KafkaConn, error) {
+	conn := sink.KafkaConn{Brokers: kc.Brokers}
+	if t := kc.TLS; t.Enabled {
+		conn.TLS = &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+		if t.CAFile != "" {
+			pem, err := os.ReadFile(t.CAFile)
+			if err != nil {
+				return conn, fmt.Errorf("tls.ca_file: %w", err)
+			}
+			conn.TLS.RootCAs = x509.NewCertPool()
+			if !conn.TLS.RootCAs.AppendCertsFromPEM(pem) {
+				return conn, fmt.Errorf("tls.ca_file: no certificates in %s", t.CAFile)
+			}
+		}
+	}
+	switch s := kc.SASL; s.Mechanism {
+	case config.SASLPlain:
+		conn.SASL = plain.Auth{User: s.Username, Pass: s.Password}.AsMechanism()
+	case config.SASLScramSHA256:
+		conn.SASL = scram.Auth{User: s.Username, Pass: s.Password}.AsSha256Mechanism()
+	case config.SASLScramSHA512:
+		conn.SASL = scram.Auth{User: s.Username, Pass: s.Password}.AsSha512Mechanism()
+	}
+	return conn, nil
+}
+
+// createScenario returns the configured scenario, or a mix of them, with
+// deal floors in floorCur. Only loading a replay file, or settings
+// config.Validate rejects, can fail.
+func createScenario(sim config.SimulationConfig, floorCur string) (generator.Scenario, error) {
+	if len(sim.Scenarios) == 0 {
+		return namedScenario(sim, sim.Scenario, floorCur)
+	}
+	mixed := make([]generator.Scenario, len(sim.Scenarios))
+	weights := make([]float64, len(sim.Scenarios))
+	for i, sw := range sim.Scenarios {
+		scenarioSim := sim
+		if sw.NonSecureShare > 0 {
+			scenarioSim.NonSecureShare = sw.NonSecureShare
+		}
+		if sw.Floors.Enabled() {
+			scenarioSim.Floors = sw.Floors
+		}
+		s, err := namedScenario(scenarioSim, sw.Name, floorCur)
+		if err != nil {
+			return nil, err
+		}
+		mixed[i], weights[i] = s, sw.Weight
+	}
+	return generator.NewMix(mixed, weights)
+}
+
+// namedScenario returns the scenario with the given name, which
+// config.Validate checks against scenarios.Names.
+func namedScenario(sim config.SimulationConfig, name, floorCur string) (generator.Scenario, error) {
+	var opts []scenarios.Option
+	if len(sim.Locales) > 0 {
+		opts = append(opts, scenarios.WithLocaleWeights(sim.Locales))
+	}
+	if len(sim.DeviceMix) > 0 {
+		opts = append(opts, scenarios.WithDeviceMix(sim.DeviceMix))
+	}
+	if sip := sim.SharedIPs; sip.Enabled() {
+		opts = append(opts, scenarios.WithSharedIPs(sip.PoolSize, sip.Concentration, sip.Share))
+	}
+	if cs := sim.Consent; cs.Enabled() {
+		opts = append(opts, scenarios.WithConsent(cs.GDPRShare, cs.USPrivacyShare))
+	}
+	if sc := sim.SupplyChain; sc.Enabled() {
+		opts = append(opts, scenarios.WithSupplyChain(sc.Hops, sc.SellerIDs))
+	}
+	if len(sim.Currencies) > 0 {
+		opts = append(opts, scenarios.WithCurrencies(sim.Currencies))
+	}
+	if d := sim.Deals; d.Enabled() {
+		opts = append(opts, scenarios.WithDeals(d.Share, d.PrivateAuction, d.PMPDeals()), scenarios.WithDealCurrency(floorCur))
+	}
+	if cx := sim.Contextual; cx.Enabled() {
+		opts = append(opts, scenarios.WithContextual(cx.Share, cx.Topics(), cx.UserKeywords))
+	}
+	if sim.NonSecureShare > 0 {
+		opts = append(opts, scenarios.WithNonSecureShare(sim.NonSecureShare))
+	}
+	if f := sim.Floors; f.Enabled() {
+		if err := scenarios.ValidateFloors(f.Default, f.BySize); err != nil {
+			return nil, fmt.Errorf("floors: %w", err)
+		}
+		opts = append(opts, scenarios.WithFloors(f.Default, f.BySize))
+	}
+
 	switch name {
 	case "mobile_app":
-		return scenarios.NewMobileApp()
+		return scenarios.NewMobileApp(opts...), nil
+	case "video":
+		return scenarios.NewVideo(opts...), nil
+	case "replay":
+		var replayOpts []scenarios.ReplayOption
+		if sim.Replay.RewriteIDs {
+			replayOpts = append(replayOpts, scenarios.WithRewriteIDs())
+		}
+		replay, err := scenarios.NewReplay(sim.Replay.File, replayOpts...)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("  Replaying %d recorded requests from %s", replay.Len(), sim.Replay.File)
+		return replay, nil
 	default:
-		log.Printf("Unknown scenario %q, defaulting to mobile_app", name)
-		return scenarios.NewMobileApp()
+		return nil, scenarios.ValidateName(name)
+	}
+}
+
+// spendCaps returns the per-run spend caps of DSPs that set one, keyed by
+// DSP name.
+func spendCaps(dsps []config.DSPConfig) map[string]float64 {
+	caps := make(map[string]float64)
+	for _, dsp := range dsps {
+		if dsp.SpendCap > 0 {
+			caps[dsp.Name] = dsp.SpendCap
+		}
 	}
+	return caps
 }
@@ -8,6 +8,8 @@ type BidRequest struct {
 	Site   *Site    `json:"site,omitempty"`
 	Device *Device  `json:"device,omitempty"`
 	User   *User    `json:"user,omitempty"`
+	Regs   *Regs    `json:"regs,omitempty"`
+	Source *Source  `json:"source,omitempty"`
 	At     int      `json:"at"`
 	Tmax   int      `json:"tmax"`
 	Cur    []string `json:"cur,omitempty"`
@@ -19,9 +21,38 @@ type Imp struct {
 	ID       string  `json:"id"`
 	Banner   *Banner `json:"banner,omitempty"`
 	Video    *Video  `json:"video,omitempty"`
+	Native   *Native `json:"native,omitempty"`
+	Instl    int     `json:"instl,omitempty"` // 1 = interstitial or full screen
 	BidFloor float64 `json:"bidfloor"`
 	Secure   int     `json:"secure,omitempty"`
 	Tagid    string  `json:"tagid,omitempty"`
+	PMP      *PMP    `json:"pmp,omitempty"`
+	Exp      int     `json:"exp,omitempty"` // seconds that may pass between the auction and the impression
+}
+
+// PMP is a private marketplace: the direct deals that apply to an
+// impression.
+type PMP struct {
+	// PrivateAuction is 1 when only bids on the listed deals are
+	// accepted, 0 when the open market may also bid.
+	PrivateAuction int    `json:"private_auction,omitempty"`
+	Deals          []Deal `json:"deals,omitempty"`
+}
+
+// Deal is a direct deal between a buyer and the seller.
+type Deal struct {
+	ID          string   `json:"id"`
+	BidFloor    float64  `json:"bidfloor,omitempty"`
+	BidFloorCur string   `json:"bidfloorcur,omitempty"`
+	WSeat       []string `json:"wseat,omitempty"` // buyer seats allowed to bid; empty allows any
+}
+
+// Floor returns the deal's floor, or open if the deal sets none.
+func (d Deal) Floor(open float64) float64 {
+	if d.BidFloor > 0 {
+		return d.BidFloor
+	}
+	return open
 }
 
 // Banner represents a banner impression.
@@ -37,13 +68,25 @@ type Banner struct {
 	Pos   int   `json:"pos,omitempty"`
 }
 
-// Video represents a video impression (placeholder for future use).
+// Video represents a video impression.
 type Video struct {
 	Mimes       []string `json:"mimes,omitempty"`
 	Minduration int      `json:"minduration,omitempty"`
 	Maxduration int      `json:"maxduration,omitempty"`
+	Protocols   []int    `json:"protocols,omitempty"`
 	W           int      `json:"w,omitempty"`
 	H           int      `json:"h,omitempty"`
+	StartDelay  int      `json:"startdelay"` // 0 = pre-roll, so always sent
+	Placement   int      `json:"placement,omitempty"`
+	Linearity   int      `json:"linearity,omitempty"`
+	Skip        int      `json:"skip,omitempty"`
+}
+
+// Native represents a native ad impression. Request is the Native Ad
+// Specification request payload, encoded as a JSON string.
+type Native struct {
+	Request string `json:"request"`
+	Ver     string `json:"ver,omitempty"`
 }
 
 // App represents an application object.
@@ -56,50 +99,132 @@ type App struct {
 	Cat      []string `json:"cat,omitempty"`
 	Ver      string   `json:"ver,omitempty"`
 	Paid     int      `json:"paid,omitempty"`
+	Content  *Content `json:"content,omitempty"`
 }
 
 // Site represents a website object (placeholder for future use).
 type Site struct {
-	ID     string   `json:"id,omitempty"`
-	Name   string   `json:"name,omitempty"`
-	Domain string   `json:"domain,omitempty"`
-	Page   string   `json:"page,omitempty"`
-	Cat    []string `json:"cat,omitempty"`
+	ID      string   `json:"id,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Domain  string   `json:"domain,omitempty"`
+	Page    string   `json:"page,omitempty"`
+	Cat     []string `json:"cat,omitempty"`
+	Content *Content `json:"content,omitempty"`
+}
+
+// Content describes the content an impression appears in, for
+// contextual targeting.
+type Content struct {
+	Title         string   `json:"title,omitempty"`
+	Genre         string   `json:"genre,omitempty"`
+	Keywords      string   `json:"keywords,omitempty"` // comma-separated
+	Cat           []string `json:"cat,omitempty"`
+	Language      string   `json:"language,omitempty"`
+	LiveStream    int      `json:"livestream,omitempty"`    // 1 = live
+	ContentRating string   `json:"contentrating,omitempty"` // e.g. TV-PG, PG-13
 }
 
 // Device represents device information.
 type Device struct {
-	UA           string `json:"ua,omitempty"`
-	IP           string `json:"ip,omitempty"`
-	Geo          *Geo   `json:"geo,omitempty"`
-	Make         string `json:"make,omitempty"`
-	Model        string `json:"model,omitempty"`
-	OS           string `json:"os,omitempty"`
-	OSV          string `json:"osv,omitempty"`
-	DeviceType   int    `json:"devicetype,omitempty"`
-	Carrier      string `json:"carrier,omitempty"`
-	Language     string `json:"language,omitempty"`
-	IFA          string `json:"ifa,omitempty"`
-	ConnectionType int  `json:"connectiontype,omitempty"`
+	UA             string `json:"ua,omitempty"`
+	IP             string `json:"ip,omitempty"`
+	Geo            *Geo   `json:"geo,omitempty"`
+	Make           string `json:"make,omitempty"`
+	Model          string `json:"model,omitempty"`
+	OS             string `json:"os,omitempty"`
+	OSV            string `json:"osv,omitempty"`
+	DeviceType     int    `json:"devicetype,omitempty"`
+	Carrier        string `json:"carrier,omitempty"`
+	Language       string `json:"language,omitempty"`
+	LangB          string `json:"langb,omitempty"` // BCP-47 language tag (OpenRTB 2.6)
+	IFA            string `json:"ifa,omitempty"`
+	ConnectionType int    `json:"connectiontype,omitempty"`
 }
 
 // Geo represents geographic location.
 type Geo struct {
-	Lat     float64 `json:"lat,omitempty"`
-	Lon     float64 `json:"lon,omitempty"`
-	Country string  `json:"country,omitempty"`
-	Region  string  `json:"region,omitempty"`
-	City    string  `json:"city,omitempty"`
-	ZIP     string  `json:"zip,omitempty"`
-	Type    int     `json:"type,omitempty"`
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Region    string  `json:"region,omitempty"`
+	City      string  `json:"city,omitempty"`
+	ZIP       string  `json:"zip,omitempty"`
+	Type      int     `json:"type,omitempty"`
+	UTCOffset int     `json:"utcoffset,omitempty"` // minutes from UTC
 }
 
 // User represents user information.
 type User struct {
-	ID       string `json:"id,omitempty"`
-	BuyerUID string `json:"buyeruid,omitempty"`
-	Gender   string `json:"gender,omitempty"`
-	Yob      int    `json:"yob,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	BuyerUID string   `json:"buyeruid,omitempty"`
+	Gender   string   `json:"gender,omitempty"`
+	Yob      int      `json:"yob,omitempty"`
+	Keywords string   `json:"keywords,omitempty"` // comma-separated interests
+	Data     []Data   `json:"data,omitempty"`
+	Ext      *UserExt `json:"ext,omitempty"`
+}
+
+// Data is additional data about the user from a data provider.
+type Data struct {
+	ID      string    `json:"id,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Segment []Segment `json:"segment,omitempty"`
+}
+
+// Segment is a single piece of user data, such as an audience segment.
+type Segment struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// UserExt carries user extensions (OpenRTB 2.5 community conventions).
+type UserExt struct {
+	Consent string `json:"consent,omitempty"` // IAB TCF v2 consent string
+}
+
+// Regs represents regulatory signals that apply to the request.
+type Regs struct {
+	COPPA int      `json:"coppa,omitempty"`
+	Ext   *RegsExt `json:"ext,omitempty"`
+}
+
+// RegsExt carries regulation extensions (OpenRTB 2.5 community conventions).
+type RegsExt struct {
+	GDPR      int    `json:"gdpr"`                 // 1 if GDPR applies; 0 is meaningful, so always sent
+	USPrivacy string `json:"us_privacy,omitempty"` // IAB CCPA US Privacy string, e.g. "1YNN"
+}
+
+// Source describes the nature and behavior of the entity that is the
+// source of the bid request upstream from the exchange.
+type Source struct {
+	FD     int        `json:"fd,omitempty"`
+	TID    string     `json:"tid,omitempty"`
+	PChain string     `json:"pchain,omitempty"`
+	Ext    *SourceExt `json:"ext,omitempty"`
+}
+
+// SourceExt carries source extensions (OpenRTB 2.5 community conventions).
+type SourceExt struct {
+	SChain *SupplyChain `json:"schain,omitempty"`
+}
+
+// SupplyChain is the IAB SupplyChain object: every entity that took part
+// in selling the impression, starting with the inventory owner.
+type SupplyChain struct {
+	Complete int               `json:"complete"` // 1 if the chain reaches back to the inventory owner; 0 is meaningful, so always sent
+	Nodes    []SupplyChainNode `json:"nodes"`
+	Ver      string            `json:"ver"`
+}
+
+// SupplyChainNode identifies one seller in a SupplyChain.
+type SupplyChainNode struct {
+	ASI    string `json:"asi"`           // canonical domain of the SSP or exchange
+	SID    string `json:"sid"`           // seller ID in that system's sellers.json
+	RID    string `json:"rid,omitempty"` // request ID issued by this node
+	Name   string `json:"name,omitempty"`
+	Domain string `json:"domain,omitempty"`
+	HP     int    `json:"hp"` // 1 if this node handles payment
 }
 
 // Auction types
@@ -110,12 +235,44 @@ const (
 
 // Device types
 const (
-	DeviceTypeMobile  = 1
-	DeviceTypePC      = 2
-	DeviceTypeTV      = 3
-	DeviceTypePhone   = 4
-	DeviceTypeTablet  = 5
-	DeviceTypeWatch   = 6
+	DeviceTypeMobile = 1
+	DeviceTypePC     = 2
+	DeviceTypeTV     = 3
+	DeviceTypePhone  = 4
+	DeviceTypeTablet = 5
+	DeviceTypeWatch  = 6
+)
+
+// Video protocols (subset of OpenRTB 2.5 section 5.8)
+const (
+	ProtocolVAST2        = 2
+	ProtocolVAST3        = 3
+	ProtocolVAST2Wrapper = 5
+	ProtocolVAST3Wrapper = 6
+	ProtocolVAST4        = 7
+	ProtocolVAST4Wrapper = 8
+)
+
+// Video placement types
+const (
+	PlacementInStream     = 1
+	PlacementInBanner     = 2
+	PlacementInArticle    = 3
+	PlacementInFeed       = 4
+	PlacementInterstitial = 5
+)
+
+// Video start delay values for mid- and post-roll (positive values are
+// the mid-roll offset in seconds; 0 is pre-roll)
+const (
+	StartDelayGenericMidRoll  = -1
+	StartDelayGenericPostRoll = -2
+)
+
+// Video linearity
+const (
+	LinearityLinear    = 1
+	LinearityNonLinear = 2
 )
 
 // Connection types
@@ -24,6 +24,8 @@ type Bid struct {
 	Price   float64  `json:"price"`
 	AdID    string   `json:"adid,omitempty"`
 	NURL    string   `json:"nurl,omitempty"`
+	BURL    string   `json:"burl,omitempty"`
+	LURL    string   `json:"lurl,omitempty"`
 	AdM     string   `json:"adm,omitempty"`
 	ADomain []string `json:"adomain,omitempty"`
 	CID     string   `json:"cid,omitempty"`
@@ -31,8 +33,19 @@ type Bid struct {
 	Cat     []string `json:"cat,omitempty"`
 	W       int      `json:"w,omitempty"`
 	H       int      `json:"h,omitempty"`
+	MType   int      `json:"mtype,omitempty"` // markup type (OpenRTB 2.6)
+	DealID  string   `json:"dealid,omitempty"`
+	Exp     int      `json:"exp,omitempty"` // seconds the bidder will wait for the impression
 }
 
+// Bid markup types
+const (
+	MTypeBanner = 1
+	MTypeVideo  = 2
+	MTypeAudio  = 3
+	MTypeNative = 4
+)
+
 // NoBidReason codes
 const (
 	NBRUnknown           = 0
@@ -46,6 +59,28 @@ const (
 	NBRUnmatchedUser     = 8
 )
 
+// Loss reason codes for ${AUCTION_LOSS} (subset of OpenRTB 2.5 section 5.25)
+const (
+	LossBidWon           = 0
+	LossInternalError    = 1
+	LossBelowFloor       = 100
+	LossLostToHigherBid  = 102
+	LossLostToPMPDeal    = 103
+	LossSeatBlocked      = 104
+	LossCreativeFiltered = 200
+)
+
+// Substitution macros for notification URLs and markup (OpenRTB 2.5 section 4.4)
+const (
+	MacroAuctionID       = "${AUCTION_ID}"
+	MacroAuctionBidID    = "${AUCTION_BID_ID}"
+	MacroAuctionImpID    = "${AUCTION_IMP_ID}"
+	MacroAuctionSeatID   = "${AUCTION_SEAT_ID}"
+	MacroAuctionPrice    = "${AUCTION_PRICE}"
+	MacroAuctionCurrency = "${AUCTION_CURRENCY}"
+	MacroAuctionLoss     = "${AUCTION_LOSS}"
+)
+
 // IsNoBid returns true if the response contains no bids.
 func (r *BidResponse) IsNoBid() bool {
 	if len(r.SeatBid) == 0 {
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/generator"
+)
+
+// reloader re-reads the configuration file, on SIGHUP or POST
+// /config/reload, and applies the settings that can change while a
+// simulation runs: the request rate, the DSP list, and the auction. Each
+// is applied only when the file changed it since the last load, so a
+// reload does not undo changes made through the API to settings the file
+// leaves alone. Other settings take effect on restart.
+type reloader struct {
+	path     string
+	eng      *engine.Engine
+	gen      *generator.Generator
+	disp     *dispatcher.Dispatcher
+	onReload []func(*config.Config)
+
+	mu  sync.Mutex
+	cfg *config.Config // the last configuration loaded
+}
+
+// Reload loads and applies the configuration file, returning the new
+// configuration. Nothing is applied if the file is invalid.
+func (r *reloader) Reload() (*config.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load(r.path)
+	if err != nil {
+		return nil, err
+	}
+	prev := r.cfg
+
+	dspsChanged := !reflect.DeepEqual(prev.DSPs, cfg.DSPs)
+	auctionChanged := dspsChanged || auctionSettingsChanged(prev.Auction, cfg.Auction)
+	var auc auction.Auction
+	if auctionChanged {
+		// Build the auction first so a bad one leaves the DSPs alone
+		if auc, err = auction.FromConfig(cfg); err != nil {
+			return nil, fmt.Errorf("auction: %w", err)
+		}
+	}
+
+	var applied []string
+	if dspsChanged {
+		if err := r.disp.SyncDSPs(cfg.DSPs); err != nil {
+			return nil, fmt.Errorf("dsps: %w", err)
+		}
+		applied = append(applied, fmt.Sprintf("%d DSPs (%d enabled)", len(cfg.DSPs), len(cfg.EnabledDSPs())))
+	}
+	if auctionChanged {
+		r.eng.SetAuction(auc)
+		r.gen.SetAuctionType(auction.RequestType(cfg.Auction.Type))
+		applied = append(applied, "auction "+cfg.Auction.Type)
+	}
+	if rps := cfg.Simulation.RequestsPerSecond; rps != prev.Simulation.RequestsPerSecond {
+		if err := r.eng.SetRPS(rps); err != nil {
+			return nil, fmt.Errorf("simulation.requests_per_second: %w", err)
+		}
+		applied = append(applied, fmt.Sprintf("%d RPS", rps))
+	}
+
+	if len(applied) == 0 {
+		log.Printf("Config reloaded from %s: nothing to apply", r.path)
+	} else {
+		log.Printf("Config reloaded from %s: applied %s", r.path, strings.Join(applied, ", "))
+	}
+	if restartRequired(prev, cfg) {
+		log.Printf("Config reload: other changed settings take effect after a restart")
+	}
+
+	r.cfg = cfg
+	for _, f := range r.onReload {
+		f(cfg)
+	}
+	return cfg, nil
+}
+
+// auctionSettingsChanged reports whether the settings newAuction builds
+// the auction from differ.
+func auctionSettingsChanged(a, b config.AuctionConfig) bool {
+	return a.Type != b.Type || !equalPtr(a.Increment, b.Increment) || !equalPtr(a.BidReduction, b.BidReduction) ||
+		a.SoftFloor != b.SoftFloor || a.MaxCPM != b.MaxCPM || a.MaxFloorRatio != b.MaxFloorRatio
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	return a == b || (a != nil && b != nil && *a == *b)
+}
+
+// restartRequired reports whether next changes any setting Reload does
+// not apply.
+func restartRequired(prev, next *config.Config) bool {
+	if !maps.Equal(spendCaps(prev.DSPs), spendCaps(next.DSPs)) {
+		return true
+	}
+	rest := *next
+	rest.Sources = prev.Sources
+	rest.Simulation.RequestsPerSecond = prev.Simulation.RequestsPerSecond
+	rest.DSPs = prev.DSPs
+	rest.Auction.Type = prev.Auction.Type
+	rest.Auction.Increment = prev.Auction.Increment
+	rest.Auction.BidReduction = prev.Auction.BidReduction
+	rest.Auction.SoftFloor = prev.Auction.SoftFloor
+	rest.Auction.MaxCPM = prev.Auction.MaxCPM
+	rest.Auction.MaxFloorRatio = prev.Auction.MaxFloorRatio
+	return !reflect.DeepEqual(&rest, prev)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cass/rtb-simulator/internal/runs"
+)
+
+// reportCommand prints a run report or session summary saved as JSON and,
+// given a baseline report, exits with exitRegressed if it regressed.
+func reportCommand(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	baselinePath := fs.String("baseline", "", "baseline report to compare against")
+	winRateDrop := fs.Float64("max-win-rate-drop", 0.1, "largest relative win rate drop from the baseline")
+	ecpmDrop := fs.Float64("max-ecpm-drop", 0.1, "largest relative eCPM drop from the baseline")
+	latencyIncrease := fs.Float64("max-latency-increase", 0.2, "largest relative p95 latency increase from the baseline")
+	errorRateIncrease := fs.Float64("max-error-rate-increase", 0.01, "largest absolute error rate increase from the baseline")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s report [flags] <report.json>\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Prints a run report or session summary. With -baseline, exits with status 3 on regressions.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	rep, err := runs.ReadReport(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading report: %v\n", err)
+		os.Exit(1)
+	}
+	if *baselinePath != "" {
+		baseline, err := runs.ReadReport(*baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading baseline: %v\n", err)
+			os.Exit(1)
+		}
+		// Only the comparison asked for here counts, not one recorded
+		// when the run ended
+		rep.Regressions = runs.Compare(baseline, rep, runs.Thresholds{
+			WinRateDrop:       *winRateDrop,
+			ECPMDrop:          *ecpmDrop,
+			LatencyIncrease:   *latencyIncrease,
+			ErrorRateIncrease: *errorRateIncrease,
+		})
+	}
+
+	runs.FormatReport(os.Stdout, rep)
+	if *baselinePath != "" && len(rep.Regressions) > 0 {
+		os.Exit(exitRegressed)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/config"
+)
+
+// Shutdown stages, in the order they run. Each consumes what the stages
+// before it produce, so tail data reaches the exporters before they close.
+const (
+	stageStopGeneration     = iota // stop the generators and the API
+	stageDrainAuctions             // let the auctions underway finish
+	stageFlushNotifications        // deliver queued win, billing, and loss notices
+	stageFlushExporters            // deliver the last events, then flush sinks
+	stageCloseClients              // close DSP and other clients
+	numShutdownStages
+)
+
+// shutdownSequence runs the steps registered for each stage in order,
+// bounding each stage by its timeout.
+type shutdownSequence struct {
+	stages [numShutdownStages]shutdownStage
+}
+
+type shutdownStage struct {
+	name    string
+	timeout time.Duration
+	steps   []shutdownStep
+}
+
+type shutdownStep struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// newShutdownSequence returns a sequence with the stage timeouts in cfg.
+// Stopping generation is immediate, so it shares the clients' timeout.
+func newShutdownSequence(cfg config.ShutdownConfig) *shutdownSequence {
+	return &shutdownSequence{stages: [numShutdownStages]shutdownStage{
+		stageStopGeneration:     {name: "stop generation", timeout: cfg.Clients},
+		stageDrainAuctions:      {name: "drain auctions", timeout: cfg.Drain},
+		stageFlushNotifications: {name: "flush notifications", timeout: cfg.Notifications},
+		stageFlushExporters:     {name: "flush exporters", timeout: cfg.Exporters},
+		stageCloseClients:       {name: "close clients", timeout: cfg.Clients},
+	}}
+}
+
+// add registers run as a step of stage. Steps run in the order added;
+// run should return once ctx ends, but a step that does not is abandoned
+// when its stage times out. A stage whose ctx ended counts as timed out
+// even if its steps returned.
+func (s *shutdownSequence) add(stage int, name string, run func(ctx context.Context)) {
+	s.stages[stage].steps = append(s.stages[stage].steps, shutdownStep{name: name, run: run})
+}
+
+// run runs every stage in order, logging how long each took and which
+// step a stage that timed out was left at.
+func (s *shutdownSequence) run() {
+	for _, st := range s.stages {
+		if len(st.steps) == 0 {
+			continue
+		}
+		start := time.Now()
+		if step, ok := st.run(); !ok {
+			log.Printf("Warning: shutdown stage %q timed out after %v in %s; continuing", st.name, st.timeout, step)
+			continue
+		}
+		log.Printf("Shutdown: %s done in %v", st.name, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// run runs the stage's steps, reporting false and the step underway if
+// the stage's timeout passes first.
+func (st shutdownStage) run() (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), st.timeout)
+	defer cancel()
+
+	current := make(chan string, len(st.steps))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, step := range st.steps {
+			current <- step.name
+			step.run(ctx)
+		}
+	}()
+
+	select {
+	case <-done:
+		if ctx.Err() == nil {
+			return "", true
+		}
+	case <-ctx.Done():
+	}
+	// The last step started is the last one queued
+	var step string
+	for len(current) > 0 {
+		step = <-current
+	}
+	return step, false
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/clock"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/currency"
+	"github.com/cass/rtb-simulator/internal/dispatcher"
+	"github.com/cass/rtb-simulator/internal/engine"
+	"github.com/cass/rtb-simulator/internal/events"
+	"github.com/cass/rtb-simulator/internal/generator"
+	"github.com/cass/rtb-simulator/internal/schema"
+	"github.com/cass/rtb-simulator/internal/seats"
+	"github.com/cass/rtb-simulator/internal/state"
+	"github.com/cass/rtb-simulator/internal/stats"
+)
+
+// simulation is one simulation's components, wired from its
+// configuration by buildSimulation.
+type simulation struct {
+	gen       *generator.Generator
+	auc       auction.Auction
+	collector *stats.Collector
+	disp      *dispatcher.Dispatcher
+	clock     clock.Clock   // simulated time, scaled by simulation.time_scale
+	seats     *seats.Market // nil unless market seats bid in place of the DSPs
+	opts      []engine.Option
+}
+
+// buildSimulation wires the generator, auction, stats, dispatcher, and
+// engine options for cfg, the same way for the main simulation and each
+// named one. Dispatcher, engine, and stats events are published to bus,
+// where the breaker, health, and anomaly alerts subscribe, naming the
+// simulation unless name is empty. The user pool and seats keep their
+// state in a namespace of store of the simulation's own. logf reports
+// what is enabled.
+func buildSimulation(cfg *config.Config, name string, bus *events.Bus, rates *currency.Table, store *state.Store, logf func(string, ...any)) (*simulation, error) {
+	auc, err := auction.FromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("auction.type: %w", err)
+	}
+	if policies := auction.DomainPolicies(cfg.DSPs); len(policies) > 0 {
+		logf("  Advertiser domain policies: %d DSPs", len(policies))
+	}
+	if limits := auction.ConfiguredLimits(cfg.Auction); limits.Enabled() {
+		logf("  Bid sanity limits: max CPM $%.2f, max %.0fx floor", limits.MaxCPM, limits.MaxFloorRatio)
+	}
+
+	scenario, err := createScenario(cfg.Simulation, cfg.Currency.BaseCurrency())
+	if err != nil {
+		return nil, fmt.Errorf("simulation: %w", err)
+	}
+	genOpts := []generator.Option{
+		generator.WithTimeout(cfg.Auction.TimeoutMS),
+		generator.WithAuctionType(auction.RequestType(cfg.Auction.Type)),
+	}
+	if d := cfg.Simulation.RenderDelay; d > 0 {
+		genOpts = append(genOpts, generator.WithImpExpiry(d))
+		logf("  Render delay: %v (imp.exp)", d)
+	}
+	if cfg.Debug.ValidateRequests {
+		genOpts = append(genOpts, generator.WithValidation(schema.BidRequest().Validate))
+		logf("  Validating generated requests against the OpenRTB schema")
+	}
+	ns := store.Namespace("simulation")
+	if name != "" {
+		ns = store.Namespace("simulations").Namespace(name)
+	}
+	if u := cfg.Simulation.Users; u.Enabled() {
+		genOpts = append(genOpts, generator.WithContext(generator.UserPool(ns.Namespace("users"), u.Size, u.TTL)))
+		if u.TTL > 0 {
+			logf("  User pool: %d users, each replaced after %v", u.Size, u.TTL)
+		} else {
+			logf("  User pool: %d users", u.Size)
+		}
+	}
+	gen := generator.New(scenario, genOpts...)
+
+	subscribeAlerts(bus, name)
+	statsOpts := []stats.Option{
+		stats.WithConsistencyChecks(cfg.Debug.ConsistencyChecks),
+		stats.WithAdMSizeLimit(cfg.CreativeQA.MaxAdMBytes),
+		stats.WithLatencyTiers(latencyTiers(cfg.Auction.LatencyTiersMS), time.Duration(cfg.Auction.TimeoutMS)*time.Millisecond),
+		stats.WithRenderDelay(cfg.Simulation.RenderDelay),
+	}
+	if a := cfg.Anomalies; a.Enabled() {
+		statsOpts = append(statsOpts, stats.WithAnomalyDetection(a.Window, a.Sigma, func(an stats.Anomaly) {
+			events.Publish(bus, an)
+		}))
+		events.Subscribe(bus, "anomaly-alerts", alertAnomalies(a.AlertURL, name))
+		logf("  Anomaly detection: %g sigma against the trailing %v", a.Sigma, a.Window)
+	}
+	collector := stats.New(statsOpts...)
+
+	dispOpts := []dispatcher.Option{
+		dispatcher.WithTimeout(time.Duration(cfg.Auction.TimeoutMS) * time.Millisecond),
+		dispatcher.WithCircuitBreaker(cfg.CircuitBreaker.ErrorThreshold, cfg.CircuitBreaker.Cooldown),
+		dispatcher.WithTLSRecorder(collector),
+		dispatcher.WithPreflight(preflightTimeout),
+		dispatcher.WithEvents(bus),
+	}
+	if a := cfg.Auction; a.TmaxDeadline {
+		dispOpts = append(dispOpts, dispatcher.WithTmaxDeadline(time.Duration(a.TmaxOverheadMS)*time.Millisecond))
+		logf("  DSP deadline: request tmax less %dms overhead", a.TmaxOverheadMS)
+	}
+	if f := cfg.Simulation.Fuzz; f.Enabled() {
+		dispOpts = append(dispOpts, dispatcher.WithFuzzer(newFuzzer(f)))
+		logf("  Fuzzing: %.1f%% of requests malformed (%s)", f.Rate*100, cmp.Or(strings.Join(f.Classes, ", "), "all classes"))
+	}
+	if rc := cfg.Retries; rc.Enabled() {
+		dispOpts = append(dispOpts, dispatcher.WithRetries(rc))
+		logf("  Retries: up to %d on %s, backing off from %v", rc.Max, strings.Join(rc.On, ", "), rc.Backoff)
+	}
+	if hc := cfg.HealthChecks; hc.Enabled() {
+		dispOpts = append(dispOpts, dispatcher.WithHealthChecks(hc), dispatcher.WithHealthRecorder(collector))
+		action := "reported"
+		if hc.AutoDisable {
+			action = "skipped"
+		}
+		logf("  Health checks: %s every %v, unhealthy DSPs %s after %d failures", hc.Path, hc.Interval, action, hc.UnhealthyAfter)
+	}
+	disp := dispatcher.New(cfg.DSPs, dispOpts...)
+	if cb := cfg.CircuitBreaker; cb.ErrorThreshold > 0 {
+		logf("  Circuit breaker: %d consecutive failures, %v cooldown", cb.ErrorThreshold, cb.Cooldown)
+	}
+
+	opts := []engine.Option{
+		engine.WithRPS(cfg.Simulation.RequestsPerSecond),
+		engine.WithConcurrency(cfg.Simulation.Concurrency),
+		engine.WithBatchSize(cfg.Simulation.BatchSize),
+		engine.WithDuration(cfg.Simulation.Duration),
+		engine.WithMaxRequests(cfg.Simulation.MaxRequests),
+		engine.WithEvents(bus),
+		engine.WithResponseValidation(),
+		engine.WithRequestBuffer(cfg.Simulation.RequestBuffer),
+	}
+	simClock := simulationClock(cfg.Simulation)
+	if simClock != clock.Real {
+		ts := cfg.Simulation.TimeScale
+		opts = append(opts, engine.WithClock(simClock))
+		logf("  Time scale: %gx (a simulated day takes %v)", ts, time.Duration(float64(24*time.Hour)/ts).Round(time.Second))
+	}
+	if r := cfg.Simulation.Ramp; r.Enabled() {
+		opts = append(opts, engine.WithRamp(r.StartRPS, r.EndRPS, r.Duration))
+		logf("  Ramp: %d -> %d RPS over %v", r.StartRPS, r.EndRPS, r.Duration)
+	}
+	if cfg.Simulation.Duration > 0 {
+		logf("  Run duration limit: %v", cfg.Simulation.Duration)
+	}
+	if cfg.Simulation.MaxRequests > 0 {
+		logf("  Run request limit: %d", cfg.Simulation.MaxRequests)
+	}
+	if n := cfg.Simulation.RequestBuffer; n > 0 {
+		logf("  Request buffer: %d requests generated ahead", n)
+	}
+	if dspCaps := spendCaps(cfg.DSPs); cfg.Simulation.SpendCap > 0 || len(dspCaps) > 0 {
+		opts = append(opts, engine.WithSpendCaps(cfg.Simulation.SpendCap, dspCaps))
+		logf("  Spend caps: $%.2f total, %d DSPs capped", cfg.Simulation.SpendCap, len(dspCaps))
+	}
+	if rates != nil {
+		opts = append(opts, engine.WithCurrency(rates))
+	}
+
+	s := &simulation{gen: gen, auc: auc, collector: collector, disp: disp, clock: simClock, opts: opts}
+	// Each simulation's seats spend their own budgets
+	if cfg.Market.Enabled() {
+		s.seats = seats.New(cfg.Market.Seats, seats.WithClock(simClock), seats.WithState(ns.Namespace("seats")))
+	}
+	return s, nil
+}
+
+// simulationClock returns the clock simulated time is kept on: the wall
+// clock, or one scaled by simulation.time_scale.
+func simulationClock(sc config.SimulationConfig) clock.Clock {
+	if ts := sc.TimeScale; ts > 0 && ts != 1 {
+		return clock.NewScaled(ts)
+	}
+	return clock.Real
+}
+
+// openState opens the state store sc configures, measuring TTLs on
+// simClock: loaded from and saved to sc.Path when set, in memory
+// otherwise.
+func openState(sc config.StateConfig, simClock clock.Clock) (*state.Store, error) {
+	opts := []state.Option{state.WithClock(simClock)}
+	if sc.SweepInterval > 0 {
+		opts = append(opts, state.WithSweepInterval(sc.SweepInterval))
+	}
+	if sc.Path == "" {
+		return state.New(opts...), nil
+	}
+	return state.Open(sc.Path, opts...)
+}
+
+// newEngine builds the simulation's engine with opts after its own.
+// Market seats, when configured, stand in for the DSPs, which are then
+// left uncalled.
+func (s *simulation) newEngine(opts ...engine.Option) *engine.Engine {
+	opts = slices.Concat(s.opts, opts)
+	var bidders engine.Dispatcher = s.disp
+	if s.seats != nil {
+		bidders = s.seats
+		opts = append(opts, engine.WithObserver(s.seats), engine.WithRunListener(s.seats))
+	}
+	return engine.New(s.gen, bidders, s.auc, s.collector, opts...)
+}
+
+// subscribeAlerts logs circuit breaker and health check changes from
+// bus, naming the simulation unless name is empty.
+func subscribeAlerts(bus *events.Bus, name string) {
+	prefix := ""
+	if name != "" {
+		prefix = "simulation " + name + ": "
+	}
+	events.Subscribe(bus, "breaker-alerts", func(ev dispatcher.BreakerOpened) {
+		log.Printf("Warning: %sDSP %s circuit breaker opened until %s", prefix, ev.DSP, ev.Until.Format(time.TimeOnly))
+	})
+	events.Subscribe(bus, "health-alerts", func(ev dispatcher.HealthChanged) {
+		if ev.Healthy {
+			log.Printf("%sDSP %s is healthy again", prefix, ev.DSP)
+			return
+		}
+		log.Printf("Warning: %sDSP %s is unhealthy after %d failed health checks: %v", prefix, ev.DSP, ev.Failures, ev.Err)
+	})
+}
+
+// newSimulations builds the named simulations configured alongside the
+// main one, converting bids with rates when set. Each gets its own
+// generator, dispatcher, auction, stats, event bus, and namespace of
+// store, so none affects another.
+func newSimulations(cfg *config.Config, rates *currency.Table, store *state.Store) (*engine.Manager, error) {
+	m := engine.NewManager()
+	for _, ns := range cfg.Simulations {
+		sim, err := newSimulation(cfg.ForSimulation(ns), ns.Name, rates, store)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("simulation %s: %w", ns.Name, err)
+		}
+		if err := m.Add(sim); err != nil {
+			sim.Close()
+			m.Close()
+			return nil, err
+		}
+		log.Printf("    - %s: %d RPS, scenario %s, DSPs %v", sim.Name, sim.Engine.RPS(), sim.Scenario, sim.DSPs)
+	}
+	return m, nil
+}
+
+// newSimulation wires up an engine for cfg, a configuration returned by
+// ForSimulation. Its events stay on its own bus, which carries only its
+// alerts: notifications, exporters, and run reports stay with the main
+// simulation.
+func newSimulation(cfg config.Config, name string, rates *currency.Table, store *state.Store) (*engine.Simulation, error) {
+	bus := events.New()
+	s, err := buildSimulation(&cfg, name, bus, rates, store, func(string, ...any) {})
+	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+
+	var dsps []string
+	for _, dsp := range cfg.EnabledDSPs() {
+		dsps = append(dsps, dsp.Name)
+	}
+	if cfg.Market.Enabled() {
+		for _, seat := range cfg.Market.Seats {
+			dsps = append(dsps, seat.Name)
+		}
+	}
+	return &engine.Simulation{
+		Name:     name,
+		Engine:   s.newEngine(),
+		Stats:    s.collector,
+		Scenario: s.gen.ScenarioName(),
+		DSPs:     dsps,
+		Close: func() {
+			s.disp.Close()
+			bus.Close()
+		},
+	}, nil
+}
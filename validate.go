@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cass/rtb-simulator/internal/auction"
+	"github.com/cass/rtb-simulator/internal/config"
+	"github.com/cass/rtb-simulator/internal/currency"
+)
+
+// validateConfigCommand checks a configuration file as run would before
+// starting, without contacting DSPs or sinks, and exits non-zero if it is
+// invalid.
+func validateConfigCommand(args []string) {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "path to configuration file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s validate-config [flags]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Checks a configuration file and exits with status 1 if it is invalid.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	cfg, err := checkConfigFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: OK (%d DSPs, %d enabled; %s auction)\n",
+		*configPath, len(cfg.DSPs), len(cfg.EnabledDSPs()), cfg.Auction.Type)
+}
+
+// checkConfigFile loads the configuration at path and builds what run
+// builds from it that can fail on its settings alone.
+func checkConfigFile(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := auction.FromConfig(cfg); err != nil {
+		return nil, fmt.Errorf("auction.type: %w", err)
+	}
+	if _, err := createScenario(cfg.Simulation, cfg.Currency.BaseCurrency()); err != nil {
+		return nil, fmt.Errorf("simulation: %w", err)
+	}
+	if cc := cfg.Currency; cc.Enabled() {
+		if _, err := currency.NewTable(cc.Base, cc.Rates); err != nil {
+			return nil, fmt.Errorf("currency: %w", err)
+		}
+	}
+	return cfg, nil
+}